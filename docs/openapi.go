@@ -0,0 +1,241 @@
+// Package docs assembles the OpenAPI 3 description of the HTTP API. Schemas are derived from the
+// request and response structs by reflection, so the documented shapes follow the code.
+package docs
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// OpenAPIVersion is the version of the OpenAPI specification the documents follow
+const OpenAPIVersion = "3.0.3"
+
+// Spec is an OpenAPI document
+type Spec struct {
+	OpenAPI    string                          `json:"openapi"`
+	Info       Info                            `json:"info"`
+	Servers    []Server                        `json:"servers,omitempty"`
+	Paths      map[string]map[string]Operation `json:"paths"`
+	Components Components                      `json:"components"`
+}
+
+// Info describes the API
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Server is a base URL the API is served from
+type Server struct {
+	URL string `json:"url"`
+}
+
+// Components holds the named schemas and security schemes operations refer to
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme describes how requests authenticate
+type SecurityScheme struct {
+	Type        string `json:"type"`
+	In          string `json:"in,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// Operation is one method of a path
+type Operation struct {
+	Summary     string                `json:"summary"`
+	Description string                `json:"description,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]Response   `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+// Parameter is a path or query parameter of an operation
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody is the JSON body of an operation
+type RequestBody struct {
+	Required bool                 `json:"required,omitempty"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response is a response of an operation, without content for empty bodies
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType holds the schema of a body
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Schema is a JSON schema, either inline or a reference to a component schema
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+}
+
+// ErrorSchemaName is the component schema of error responses
+const ErrorSchemaName = "Error"
+
+// New returns a document without paths. Every document describes the error response shape as the
+// ErrorSchemaName schema.
+func New(info Info, servers ...Server) *Spec {
+	return &Spec{
+		OpenAPI: OpenAPIVersion,
+		Info:    info,
+		Servers: servers,
+		Paths:   make(map[string]map[string]Operation),
+		Components: Components{
+			Schemas: map[string]*Schema{
+				ErrorSchemaName: {
+					Type: "object",
+					Properties: map[string]*Schema{
+						"error": {Type: "string", Description: "Human-readable message"},
+						"code":  {Type: "string", Description: "Stable identifier to match on, absent for some validation errors"},
+					},
+					Required: []string{"error"},
+				},
+			},
+			SecuritySchemes: make(map[string]SecurityScheme),
+		},
+	}
+}
+
+// echoParam matches the :name parameters of echo routes
+var echoParam = regexp.MustCompile(`:([A-Za-z0-9_]+)`)
+
+// Add documents an operation of an echo route, e.g. GET /api/urls/:code. The route's parameters are
+// added as required path parameters.
+func (s *Spec) Add(method, route string, op Operation) {
+	var params []Parameter
+	for _, match := range echoParam.FindAllStringSubmatch(route, -1) {
+		params = append(params, Parameter{Name: match[1], In: "path", Required: true, Schema: &Schema{Type: "string"}})
+	}
+	op.Parameters = append(params, op.Parameters...)
+
+	path := echoParam.ReplaceAllString(route, "{$1}")
+	if s.Paths[path] == nil {
+		s.Paths[path] = make(map[string]Operation)
+	}
+	s.Paths[path][strings.ToLower(method)] = op
+}
+
+// Ref registers the schema of v's type as a component named after the type, and returns a reference
+// to it. Structs it refers to are registered as well.
+func (s *Spec) Ref(v any) *Schema {
+	return s.schemaOf(reflect.TypeOf(v))
+}
+
+// Error returns a reference to the error response schema
+func (s *Spec) Error() *Schema {
+	return &Schema{Ref: "#/components/schemas/" + ErrorSchemaName}
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// schemaOf returns the schema of t, registering named structs as components
+func (s *Spec) schemaOf(t reflect.Type) *Schema {
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case durationType:
+		return &Schema{Type: "integer", Format: "int64"}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		schema := s.schemaOf(t.Elem())
+		if schema.Ref != "" {
+			return schema
+		}
+		schema.Nullable = true
+		return schema
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: s.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: s.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.structSchema(t)
+		}
+		if _, ok := s.Components.Schemas[t.Name()]; !ok {
+			// Registered before its fields, so self-referencing structs terminate
+			s.Components.Schemas[t.Name()] = &Schema{}
+			*s.Components.Schemas[t.Name()] = *s.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + t.Name()}
+	default:
+		// Interfaces can hold any value
+		return &Schema{}
+	}
+}
+
+// structSchema returns the object schema of a struct from its JSON field names. Fields without
+// omitempty are required, and embedded structs contribute their fields.
+func (s *Spec) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		// Like encoding/json, the fields of embedded structs are promoted even if the struct isn't exported
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			embedded := s.structSchema(field.Type)
+			for prop, propSchema := range embedded.Properties {
+				schema.Properties[prop] = propSchema
+			}
+			schema.Required = append(schema.Required, embedded.Required...)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		schema.Properties[name] = s.schemaOf(field.Type)
+		if !strings.Contains(opts, "omitempty") {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	return schema
+}
@@ -0,0 +1,74 @@
+package docs
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testBase struct {
+	ID int64 `json:"id"`
+}
+
+type testItem struct {
+	testBase
+	Name     string            `json:"name"`
+	Note     string            `json:"note,omitempty"`
+	Limit    *int              `json:"limit,omitempty"`
+	Tags     []string          `json:"tags"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Created  time.Time         `json:"created_at"`
+	Parent   *testItem         `json:"parent,omitempty"`
+	Internal bool              `json:"-"`
+	hidden   string
+}
+
+func TestRef(t *testing.T) {
+	spec := New(Info{Title: "Test", Version: "1"})
+
+	ref := spec.Ref(testItem{})
+	assert.Equal(t, "#/components/schemas/testItem", ref.Ref)
+
+	schema := spec.Components.Schemas["testItem"]
+	require.NotNil(t, schema)
+	assert.Equal(t, "object", schema.Type)
+	assert.ElementsMatch(t, []string{"id", "name", "tags", "labels", "note", "limit", "created_at", "parent"}, keys(schema.Properties))
+	assert.ElementsMatch(t, []string{"id", "name", "tags", "created_at"}, schema.Required)
+
+	assert.Equal(t, &Schema{Type: "integer", Format: "int64"}, schema.Properties["id"])
+	assert.Equal(t, &Schema{Type: "integer", Format: "int32", Nullable: true}, schema.Properties["limit"])
+	assert.Equal(t, &Schema{Type: "array", Items: &Schema{Type: "string"}}, schema.Properties["tags"])
+	assert.Equal(t, &Schema{Type: "object", AdditionalProperties: &Schema{Type: "string"}}, schema.Properties["labels"])
+	assert.Equal(t, &Schema{Type: "string", Format: "date-time"}, schema.Properties["created_at"])
+	// Self references point back to the component
+	assert.Equal(t, ref, schema.Properties["parent"])
+}
+
+func TestAdd(t *testing.T) {
+	spec := New(Info{Title: "Test", Version: "1"})
+
+	spec.Add(http.MethodGet, "/api/items/:owner/:id", Operation{
+		Summary:    "Get an item",
+		Parameters: []Parameter{{Name: "verbose", In: "query", Schema: &Schema{Type: "boolean"}}},
+		Responses:  map[string]Response{"404": {Description: "Not found", Content: map[string]MediaType{"application/json": {Schema: spec.Error()}}}},
+	})
+
+	op, ok := spec.Paths["/api/items/{owner}/{id}"]["get"]
+	require.True(t, ok)
+	require.Len(t, op.Parameters, 3)
+	assert.Equal(t, Parameter{Name: "owner", In: "path", Required: true, Schema: &Schema{Type: "string"}}, op.Parameters[0])
+	assert.Equal(t, "id", op.Parameters[1].Name)
+	assert.Equal(t, "verbose", op.Parameters[2].Name)
+	assert.Contains(t, spec.Components.Schemas, ErrorSchemaName)
+}
+
+func keys(m map[string]*Schema) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	return names
+}
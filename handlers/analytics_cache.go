@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// ResponseCache stores rendered responses until they expire
+type ResponseCache interface {
+	// Get retrieves the response cached under key, reporting whether there was one
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set caches a response under key for ttl
+	Set(ctx context.Context, key string, data []byte, ttl time.Duration) error
+}
+
+// cachedResponse is a successful response as kept in a ResponseCache
+type cachedResponse struct {
+	ContentType string `json:"content_type"`
+	Body        []byte `json:"body"`
+}
+
+// responseRecorder copies everything written to the response into a buffer
+type responseRecorder struct {
+	http.ResponseWriter
+	body io.Writer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(b)
+	w.body.Write(b[:n])
+	return n, err
+}
+
+// AnalyticsCacheMiddleware creates a middleware that lets clients cache successful responses for ttl
+// with Cache-Control: max-age. With a cache, successful responses are also cached server-side for ttl
+// and served from there, marked X-Cache: HIT, until they expire. Responses are cached per path and
+// query; they are not invalidated by new clicks, so analytics may lag by up to ttl. Requests are served
+// normally if the cache fails.
+func AnalyticsCacheMiddleware(ttl time.Duration, cache ResponseCache) echo.MiddlewareFunc {
+	cacheControl := "private, max-age=" + strconv.Itoa(int(ttl.Seconds()))
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			res := c.Response()
+			res.Before(func() {
+				if res.Status == http.StatusOK {
+					res.Header().Set("Cache-Control", cacheControl)
+				}
+			})
+			if cache == nil {
+				return next(c)
+			}
+
+			ctx := c.Request().Context()
+			key := c.Request().URL.Path + "?" + c.Request().URL.Query().Encode()
+			if data, ok, err := cache.Get(ctx, key); err != nil {
+				log.Warn().Err(err).Str("key", key).Msg("Failed to read cached analytics response")
+			} else if ok {
+				var cached cachedResponse
+				if err := json.Unmarshal(data, &cached); err == nil {
+					log.Debug().Str("key", key).Msg("Serving cached analytics response")
+					res.Header().Set("X-Cache", "HIT")
+					return c.Blob(http.StatusOK, cached.ContentType, cached.Body)
+				}
+				log.Warn().Str("key", key).Msg("Ignoring malformed cached analytics response")
+			}
+
+			res.Header().Set("X-Cache", "MISS")
+			var body bytes.Buffer
+			res.Writer = &responseRecorder{ResponseWriter: res.Writer, body: &body}
+			if err := next(c); err != nil {
+				return err
+			}
+			if res.Status != http.StatusOK {
+				return nil
+			}
+
+			data, err := json.Marshal(cachedResponse{ContentType: res.Header().Get(echo.HeaderContentType), Body: body.Bytes()})
+			if err != nil {
+				log.Warn().Err(err).Str("key", key).Msg("Failed to encode analytics response for caching")
+				return nil
+			}
+			if err := cache.Set(ctx, key, data, ttl); err != nil {
+				log.Warn().Err(err).Str("key", key).Msg("Failed to cache analytics response")
+			}
+			return nil
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/fransfilastap/urlshortener/store"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeResponseCache is an in-memory ResponseCache that ignores TTLs, counting cache hits
+type fakeResponseCache struct {
+	mu   sync.Mutex
+	data map[string][]byte
+	ttl  time.Duration
+	hits int
+}
+
+func (f *fakeResponseCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	data, ok := f.data[key]
+	if ok {
+		f.hits++
+	}
+	return data, ok, nil
+}
+
+func (f *fakeResponseCache) Set(ctx context.Context, key string, data []byte, ttl time.Duration) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.data == nil {
+		f.data = make(map[string][]byte)
+	}
+	f.data[key] = data
+	f.ttl = ttl
+	return nil
+}
+
+// TestAnalyticsResponseCache tests Cache-Control on analytics responses and serving them from the server-side cache
+func TestAnalyticsResponseCache(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeRepository()
+	created, err := repo.Create(ctx, models.NewURL("https://example.com", "abc123", "", time.Time{}, "test-user"))
+	assert.NoError(t, err)
+	addClick := func() {
+		assert.NoError(t, repo.StoreClick(ctx, models.NewClick(created.ID, "abc123", "127.0.0.1", "Unknown", "Chrome", "Desktop")))
+	}
+	addClick()
+
+	cfg := newTestConfig()
+	cfg.AnalyticsCacheTTL = time.Minute
+	get := func(e *echo.Echo, path string) (*httptest.ResponseRecorder, float64) {
+		rec := serveAPI(e, http.MethodGet, path, "")
+		var response struct {
+			Analytics map[string]interface{} `json:"analytics"`
+		}
+		_ = json.Unmarshal(rec.Body.Bytes(), &response)
+		total, _ := response.Analytics["total_clicks"].(float64)
+		return rec, total
+	}
+
+	t.Run("CacheControl", func(t *testing.T) {
+		e := newRealTestServer(repo, cfg)
+		rec, _ := get(e, "/api/urls/abc123/analytics")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "private, max-age=60", rec.Header().Get("Cache-Control"))
+		assert.Empty(t, rec.Header().Get("X-Cache"))
+
+		rec, _ = get(e, "/api/urls/missing/analytics")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Empty(t, rec.Header().Get("Cache-Control"))
+	})
+
+	t.Run("ServedFromCacheWithinTTL", func(t *testing.T) {
+		cache := &fakeResponseCache{}
+		e := echo.New()
+		h := NewURLHandler(store.NewURLService(repo, nil), cfg)
+		h.SetAnalyticsResponseCache(cache)
+		h.Register(e)
+
+		rec, total := get(e, "/api/urls/abc123/analytics?from=2000-01-01")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "MISS", rec.Header().Get("X-Cache"))
+		assert.Equal(t, time.Minute, cache.ttl)
+
+		// A new click isn't reflected until the cached response expires
+		addClick()
+		cached, cachedTotal := get(e, "/api/urls/abc123/analytics?from=2000-01-01")
+		assert.Equal(t, http.StatusOK, cached.Code)
+		assert.Equal(t, "HIT", cached.Header().Get("X-Cache"))
+		assert.Equal(t, "private, max-age=60", cached.Header().Get("Cache-Control"))
+		assert.Equal(t, rec.Header().Get(echo.HeaderContentType), cached.Header().Get(echo.HeaderContentType))
+		assert.Equal(t, total, cachedTotal)
+		assert.Equal(t, 1, cache.hits)
+
+		// Other queries are cached separately
+		rec, fresh := get(e, "/api/urls/abc123/analytics?from=2000-01-02")
+		assert.Equal(t, "MISS", rec.Header().Get("X-Cache"))
+		assert.Equal(t, total+1, fresh)
+	})
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/fransfilastap/urlshortener/store"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// Errors reported for batch items that fail the checks POST /api/shorten makes before creating a link
+var (
+	errBatchInvalidRateLimit = store.NewAPIError(http.StatusBadRequest, "invalid_rate_limit", "Invalid rate limit")
+	errBatchInvalidMaxClicks = store.NewAPIError(http.StatusBadRequest, "invalid_max_clicks", "Invalid max clicks")
+	errBatchInvalidCacheTTL  = store.NewAPIError(http.StatusBadRequest, "invalid_cache_ttl", "Invalid cache TTL")
+	errBatchClicks           = store.NewAPIError(http.StatusForbidden, "clicks_require_admin", "Setting clicks requires admin access")
+	errBatchReuse            = store.NewAPIError(http.StatusBadRequest, "reuse_not_supported", "reuse_existing is not supported in batches")
+)
+
+// BatchShortenResult is the outcome of one item of a batch shorten request: the created URL, or the
+// error that prevented it
+type BatchShortenResult struct {
+	Index int          `json:"index"`
+	URL   *URLResponse `json:"url,omitempty"`
+	Error string       `json:"error,omitempty"`
+	Code  string       `json:"code,omitempty"`
+}
+
+// BatchShortenURL handles requests to shorten up to store.MaxBatchSize URLs at once. Every item is
+// validated, counted against the shorten rate limit and has its destination verified on its own, so
+// invalid, limited or unreachable items are reported in their result without failing the others.
+func (h *URLHandler) BatchShortenURL(c echo.Context) error {
+	var reqs []ShortenRequest
+	if err := c.Bind(&reqs); err != nil {
+		log.Error().Err(err).Msg("Invalid request format for batch URL shortening")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request"})
+	}
+
+	log.Debug().Int("items", len(reqs)).Msg("Shortening URL batch")
+
+	if len(reqs) > store.MaxBatchSize {
+		log.Error().Int("items", len(reqs)).Int("max", store.MaxBatchSize).Msg("Batch too large for URL shortening")
+		return store.ErrBatchTooLarge
+	}
+
+	results := make([]BatchShortenResult, len(reqs))
+	items := make([]store.BatchItem, 0, len(reqs))
+	var indexes []int
+	for i, req := range reqs {
+		results[i].Index = i
+		var err error
+		switch {
+		case req.RateLimit < 0:
+			err = errBatchInvalidRateLimit
+		case req.MaxClicks < 0:
+			err = errBatchInvalidMaxClicks
+		case req.CacheTTL < 0:
+			err = errBatchInvalidCacheTTL
+		case req.Clicks != 0:
+			err = errBatchClicks
+		case req.ReuseExisting:
+			err = errBatchReuse
+		default:
+			if err = h.limitShorten(c); err == nil {
+				err = h.verifyDestination(c, req.URL)
+			}
+		}
+		if err != nil {
+			results[i].setError(err)
+			continue
+		}
+
+		items = append(items, store.BatchItem{
+			OriginalURL:      req.URL,
+			CustomShort:      req.CustomCode,
+			Title:            req.Title,
+			ExpireAfter:      req.Expiry * time.Second,
+			CreatorReference: req.CreatorReference,
+			Options:          shortenOptions(req),
+		})
+		indexes = append(indexes, i)
+	}
+
+	created, err := h.service.CreateShortURLBatch(c.Request().Context(), items)
+	if err != nil {
+		log.Error().Err(err).Int("items", len(reqs)).Msg("Failed to shorten URL batch")
+		return err
+	}
+	var succeeded int
+	for j, i := range indexes {
+		if created[j].Err != nil {
+			results[i].setError(created[j].Err)
+			continue
+		}
+		response := h.toURLResponse(created[j].URL)
+		results[i].URL = &response
+		succeeded++
+	}
+
+	log.Info().
+		Int("items", len(reqs)).
+		Int("created", succeeded).
+		Int("failed", len(reqs)-succeeded).
+		Msg("URL batch shortened")
+
+	return c.JSON(http.StatusOK, results)
+}
+
+// setError records why a batch item failed, hiding the details of unexpected errors
+func (r *BatchShortenResult) setError(err error) {
+	apiErr := batchItemError(err)
+	r.Error = apiErr.Message
+	r.Code = apiErr.Code
+}
+
+// batchItemError describes why an item of a batch failed, hiding the details of unexpected errors
+func batchItemError(err error) *store.APIError {
+	var apiErr *store.APIError
+	if !errors.As(err, &apiErr) {
+		apiErr = errInternal
+	}
+	return apiErr
+}
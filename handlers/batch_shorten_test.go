@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fransfilastap/urlshortener/store"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestBatchShortenURL tests shortening several URLs in one request
+func TestBatchShortenURL(t *testing.T) {
+	repo := newFakeRepository()
+	e := newRealTestServer(repo, newTestConfig())
+
+	post := func(body string) *httptest.ResponseRecorder {
+		return serveAPI(e, http.MethodPost, "/api/shorten/batch", body)
+	}
+
+	t.Run("ItemsSucceedOrFailIndependently", func(t *testing.T) {
+		rec := post(`[
+			{"url": "https://example.com/1", "custom_code": "first"},
+			{"url": "not a url"},
+			{"url": "https://example.com/2", "custom_code": "first"},
+			{"url": "https://example.com/3", "rate_limit": -1},
+			{"url": "https://example.com/4", "creator_reference": "test-user"}
+		]`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var results []BatchShortenResult
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &results))
+		if assert.Len(t, results, 5) {
+			for i, result := range results {
+				assert.Equal(t, i, result.Index)
+			}
+			assert.Equal(t, "first", results[0].URL.ShortCode)
+			assert.Equal(t, "invalid_url", results[1].Code)
+			assert.Equal(t, "url_exists", results[2].Code)
+			assert.Equal(t, "invalid_rate_limit", results[3].Code)
+			assert.Equal(t, "test-user", results[4].URL.CreatorReference)
+			assert.Empty(t, results[4].Error)
+		}
+
+		url, err := repo.GetByShort(context.Background(), "first")
+		assert.NoError(t, err)
+		assert.Equal(t, "https://example.com/1", url.Original)
+	})
+
+	t.Run("TooLarge", func(t *testing.T) {
+		items := make([]ShortenRequest, store.MaxBatchSize+1)
+		for i := range items {
+			items[i].URL = "https://example.com"
+		}
+		body, err := json.Marshal(items)
+		assert.NoError(t, err)
+
+		rec := post(string(body))
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), "batch_too_large")
+	})
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/fransfilastap/urlshortener/store"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// BulkTagRequest represents a request to add and remove tags on several URLs of a creator
+type BulkTagRequest struct {
+	CreatorReference string   `json:"creator_reference"`
+	Codes            []string `json:"codes"`
+	Add              []string `json:"add,omitempty"`
+	Remove           []string `json:"remove,omitempty"`
+}
+
+// BulkTagResult is the outcome for one code of a bulk tag request: the updated URL, or the error that
+// prevented the update
+type BulkTagResult struct {
+	ShortCode string       `json:"short_code"`
+	URL       *URLResponse `json:"url,omitempty"`
+	Error     string       `json:"error,omitempty"`
+	Code      string       `json:"code,omitempty"`
+}
+
+// BulkTagURLs handles requests to add and remove tags on up to store.MaxBatchSize URLs at once. The
+// changes are applied in a single transaction; codes the creator doesn't own are reported in their
+// result without failing the others.
+func (h *URLHandler) BulkTagURLs(c echo.Context) error {
+	var req BulkTagRequest
+	if err := c.Bind(&req); err != nil {
+		log.Error().Err(err).Msg("Invalid request format for bulk tagging")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request"})
+	}
+
+	log.Debug().
+		Str("creator_reference", req.CreatorReference).
+		Int("codes", len(req.Codes)).
+		Msg("Updating tags of URLs")
+
+	if req.CreatorReference == "" {
+		log.Warn().Msg("No creator reference provided for bulk tagging")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Missing creator reference"})
+	}
+	if len(req.Codes) == 0 {
+		log.Error().Str("creator_reference", req.CreatorReference).Msg("No codes provided for bulk tagging")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Missing codes"})
+	}
+
+	updated, err := h.service.UpdateURLTags(c.Request().Context(), req.CreatorReference, req.Codes,
+		store.TagChange{Add: req.Add, Remove: req.Remove})
+	if err != nil {
+		log.Error().Err(err).Str("creator_reference", req.CreatorReference).Msg("Failed to update tags of URLs")
+		return err
+	}
+
+	results := make([]BulkTagResult, len(req.Codes))
+	var succeeded int
+	for i, code := range req.Codes {
+		results[i].ShortCode = code
+		if updated[i].Err != nil {
+			apiErr := batchItemError(updated[i].Err)
+			results[i].Error, results[i].Code = apiErr.Message, apiErr.Code
+			continue
+		}
+		response := h.toURLResponse(updated[i].URL)
+		results[i].URL = &response
+		succeeded++
+	}
+
+	log.Info().
+		Str("creator_reference", req.CreatorReference).
+		Int("codes", len(req.Codes)).
+		Int("updated", succeeded).
+		Int("failed", len(req.Codes)-succeeded).
+		Msg("Tags of URLs updated")
+
+	return c.JSON(http.StatusOK, results)
+}
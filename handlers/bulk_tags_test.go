@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestBulkTagURLs tests adding and removing tags on several URLs at once
+func TestBulkTagURLs(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeRepository()
+	e := newRealTestServer(repo, newTestConfig())
+
+	for _, code := range []string{"tag-a", "tag-b", "tag-c"} {
+		url := models.NewURL("https://example.com/"+code, code, "", time.Time{}, "tagger")
+		url.Tags = []string{"old"}
+		_, err := repo.Create(ctx, url)
+		assert.NoError(t, err)
+	}
+	_, err := repo.Create(ctx, models.NewURL("https://example.com/other", "tag-other", "", time.Time{}, "someone-else"))
+	assert.NoError(t, err)
+
+	post := func(body string) *httptest.ResponseRecorder {
+		return serveAPI(e, http.MethodPost, "/api/urls/tags", body)
+	}
+	tagsOf := func(code string) []string {
+		url, err := repo.GetByShort(ctx, code)
+		assert.NoError(t, err)
+		return url.Tags
+	}
+
+	t.Run("AddToSeveral", func(t *testing.T) {
+		rec := post(`{"creator_reference": "tagger", "codes": ["tag-a", "tag-b", "tag-c", "tag-other", "missing"], "add": ["campaign", " campaign "]}`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var results []BulkTagResult
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &results))
+		if assert.Len(t, results, 5) {
+			assert.Equal(t, "tag-a", results[0].ShortCode)
+			assert.Equal(t, []string{"old", "campaign"}, results[0].URL.Tags)
+			assert.Equal(t, "creator_mismatch", results[3].Code)
+			assert.Nil(t, results[3].URL)
+			assert.Equal(t, "url_not_found", results[4].Code)
+		}
+
+		for _, code := range []string{"tag-a", "tag-b", "tag-c"} {
+			assert.Equal(t, []string{"old", "campaign"}, tagsOf(code))
+		}
+		assert.Empty(t, tagsOf("tag-other"))
+	})
+
+	t.Run("RemoveFromOne", func(t *testing.T) {
+		rec := post(`{"creator_reference": "tagger", "codes": ["tag-b"], "remove": ["campaign", "old"]}`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		assert.Equal(t, []string{"old", "campaign"}, tagsOf("tag-a"))
+		assert.Empty(t, tagsOf("tag-b"))
+		assert.Equal(t, []string{"old", "campaign"}, tagsOf("tag-c"))
+	})
+
+	t.Run("InvalidRequests", func(t *testing.T) {
+		rec := post(`{"codes": ["tag-a"], "add": ["x"]}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+		rec = post(`{"creator_reference": "tagger", "add": ["x"]}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+		rec = post(`{"creator_reference": "tagger", "codes": ["tag-a"], "add": [" "]}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), "no_tag_change")
+	})
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"bytes"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/wcharczuk/go-chart/v2"
+)
+
+// Chart dimensions in pixels
+const (
+	chartWidth  = 800
+	chartHeight = 400
+)
+
+// renderClicksChart renders clicks over time as a PNG bar chart
+func renderClicksChart(title string, points []*models.TimeSeriesPoint) ([]byte, error) {
+	bars := make([]chart.Value, 0, len(points))
+	maxClicks := float64(1)
+	for _, point := range points {
+		clicks := float64(point.Clicks)
+		if clicks > maxClicks {
+			maxClicks = clicks
+		}
+		bars = append(bars, chart.Value{
+			Label: point.Time.Format("01-02"),
+			Value: clicks,
+		})
+	}
+
+	// Scale bar width so long ranges still fit the canvas
+	barWidth := chartWidth / (len(bars) + 1) / 2
+	if barWidth < 2 {
+		barWidth = 2
+	}
+
+	graph := chart.BarChart{
+		Title:  title,
+		Width:  chartWidth,
+		Height: chartHeight,
+		Background: chart.Style{
+			Padding: chart.Box{Top: 40},
+		},
+		BarWidth: barWidth,
+		// Fix the range so a series without clicks still renders
+		YAxis: chart.YAxis{
+			Range: &chart.ContinuousRange{Min: 0, Max: maxClicks},
+		},
+		Bars: bars,
+	}
+
+	var buf bytes.Buffer
+	if err := graph.Render(chart.PNG, &buf); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
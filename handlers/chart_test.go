@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"image/png"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestGetURLAnalyticsChart tests rendering clicks over time as a PNG
+func TestGetURLAnalyticsChart(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeRepository()
+	_, err := repo.Create(ctx, models.NewURL("https://example.com", "abc123", "", time.Time{}, "test-user"))
+	assert.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		click := models.NewClick(1, "abc123", "127.0.0.1", "Unknown", "Chrome", "Desktop")
+		click.Timestamp = time.Now().AddDate(0, 0, -i)
+		assert.NoError(t, repo.StoreClick(ctx, click))
+	}
+	e := newRealTestServer(repo, newTestConfig())
+
+	t.Run("ValidPNG", func(t *testing.T) {
+		rec := serveAPI(e, http.MethodGet, "/api/urls/abc123/analytics/chart.png?days=7", "")
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "image/png", rec.Header().Get(echo.HeaderContentType))
+		img, err := png.Decode(bytes.NewReader(rec.Body.Bytes()))
+		assert.NoError(t, err)
+		assert.Greater(t, img.Bounds().Dx(), 0)
+		assert.Greater(t, img.Bounds().Dy(), 0)
+	})
+
+	t.Run("NoClicks", func(t *testing.T) {
+		_, err := repo.Create(ctx, models.NewURL("https://example.com/quiet", "quiet", "", time.Time{}, "test-user"))
+		assert.NoError(t, err)
+
+		rec := serveAPI(e, http.MethodGet, "/api/urls/quiet/analytics/chart.png", "")
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		_, err = png.Decode(bytes.NewReader(rec.Body.Bytes()))
+		assert.NoError(t, err)
+	})
+
+	t.Run("InvalidDays", func(t *testing.T) {
+		rec := serveAPI(e, http.MethodGet, "/api/urls/abc123/analytics/chart.png?days=0", "")
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("URLNotFound", func(t *testing.T) {
+		rec := serveAPI(e, http.MethodGet, "/api/urls/missing/analytics/chart.png", "")
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
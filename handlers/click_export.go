@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/fransfilastap/urlshortener/models"
+)
+
+// MIMEApplicationNDJSON is the content type for newline-delimited JSON
+const MIMEApplicationNDJSON = "application/x-ndjson"
+
+// clickExportFlushEvery is the number of rows written between flushes of a streamed export
+const clickExportFlushEvery = 100
+
+// clickWriter writes clicks to an export stream in a single format
+type clickWriter interface {
+	Write(click *models.Click) error
+	Flush() error
+}
+
+// ndjsonClickWriter writes one JSON-encoded click per line
+type ndjsonClickWriter struct {
+	enc *json.Encoder
+}
+
+func newNDJSONClickWriter(w io.Writer) *ndjsonClickWriter {
+	return &ndjsonClickWriter{enc: json.NewEncoder(w)}
+}
+
+func (w *ndjsonClickWriter) Write(click *models.Click) error {
+	return w.enc.Encode(click)
+}
+
+func (w *ndjsonClickWriter) Flush() error {
+	return nil
+}
+
+// csvClickWriter writes clicks as CSV rows preceded by a header row
+type csvClickWriter struct {
+	csv         *csv.Writer
+	wroteHeader bool
+}
+
+// csvClickHeader lists the CSV export columns
+var csvClickHeader = []string{"id", "url_id", "url_short", "ip", "location", "browser", "device", "timestamp", "browser_version", "os", "referrer", "extra"}
+
+func newCSVClickWriter(w io.Writer) *csvClickWriter {
+	return &csvClickWriter{csv: csv.NewWriter(w)}
+}
+
+func (w *csvClickWriter) Write(click *models.Click) error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	// Extra fields vary by deployment, so they share one column as a JSON object
+	var extra string
+	if len(click.Extra) > 0 {
+		encoded, err := json.Marshal(click.Extra)
+		if err != nil {
+			return err
+		}
+		extra = string(encoded)
+	}
+	return w.csv.Write([]string{
+		strconv.FormatInt(click.ID, 10),
+		strconv.FormatInt(click.URLID, 10),
+		click.URLShort,
+		click.IP,
+		click.Location,
+		click.Browser,
+		click.Device,
+		click.Timestamp.UTC().Format(time.RFC3339),
+		click.BrowserVersion,
+		click.OS,
+		click.Referrer,
+		extra,
+	})
+}
+
+func (w *csvClickWriter) Flush() error {
+	// An export without clicks still carries the header
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	w.csv.Flush()
+	return w.csv.Error()
+}
+
+func (w *csvClickWriter) writeHeader() error {
+	if w.wroteHeader {
+		return nil
+	}
+	w.wroteHeader = true
+	return w.csv.Write(csvClickHeader)
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestExportClicks tests streaming click exports in both formats
+func TestExportClicks(t *testing.T) {
+	ctx := context.Background()
+	newRepo := func(t *testing.T) *fakeRepository {
+		repo := newFakeRepository()
+		created, err := repo.Create(ctx, models.NewURL("https://example.com", "abc123", "", time.Time{}, "test-user"))
+		assert.NoError(t, err)
+		for i, browser := range []string{"Chrome", "Firefox", "Safari"} {
+			click := models.NewClick(created.ID, "abc123", "10.0.0."+strconv.Itoa(i), "Unknown", browser, "Desktop")
+			click.ID = int64(i + 1)
+			assert.NoError(t, repo.StoreClick(ctx, click))
+		}
+		return repo
+	}
+
+	t.Run("NDJSON", func(t *testing.T) {
+		e := newRealTestServer(newRepo(t), newTestConfig())
+
+		req := newAPIRequest(http.MethodGet, "/api/urls/abc123/clicks/export", "")
+		req.Header.Set("Accept", MIMEApplicationNDJSON)
+		rec := serveRequest(e, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, MIMEApplicationNDJSON, rec.Header().Get(echo.HeaderContentType))
+
+		var clicks []models.Click
+		scanner := bufio.NewScanner(rec.Body)
+		for scanner.Scan() {
+			var click models.Click
+			assert.NoError(t, json.Unmarshal(scanner.Bytes(), &click))
+			clicks = append(clicks, click)
+		}
+		assert.NoError(t, scanner.Err())
+		if assert.Len(t, clicks, 3) {
+			assert.Equal(t, int64(1), clicks[0].ID)
+			assert.Equal(t, "abc123", clicks[0].URLShort)
+			assert.Equal(t, "Chrome", clicks[0].Browser)
+			assert.Equal(t, "10.0.0.2", clicks[2].IP)
+			assert.False(t, clicks[2].Timestamp.IsZero())
+		}
+	})
+
+	t.Run("CSVByDefault", func(t *testing.T) {
+		e := newRealTestServer(newRepo(t), newTestConfig())
+
+		rec := serveAPI(e, http.MethodGet, "/api/urls/abc123/clicks/export", "")
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Header().Get(echo.HeaderContentType), "text/csv")
+		records, err := csv.NewReader(rec.Body).ReadAll()
+		assert.NoError(t, err)
+		if assert.Len(t, records, 4) {
+			assert.Equal(t, "url_short", records[0][2])
+			assert.Equal(t, "Firefox", records[2][5])
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		e := newRealTestServer(newFakeRepository(), newTestConfig())
+
+		req := newAPIRequest(http.MethodGet, "/api/urls/missing/clicks/export", "")
+		req.Header.Set("Accept", MIMEApplicationNDJSON)
+		rec := serveRequest(e, req)
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
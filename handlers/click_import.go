@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/fransfilastap/urlshortener/store"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// ClickImportRecord is one historical click in a click import request. When browser is empty, the
+// browser, OS and device are parsed from user_agent.
+type ClickImportRecord struct {
+	Timestamp      time.Time `json:"timestamp"`
+	IP             string    `json:"ip,omitempty"`
+	Location       string    `json:"location,omitempty"`
+	UserAgent      string    `json:"user_agent,omitempty"`
+	Browser        string    `json:"browser,omitempty"`
+	BrowserVersion string    `json:"browser_version,omitempty"`
+	OS             string    `json:"os,omitempty"`
+	Device         string    `json:"device,omitempty"`
+	Target         string    `json:"target,omitempty"`
+	Referrer       string    `json:"referrer,omitempty"`
+}
+
+// ClickImportRequest represents a request to import historical clicks of a URL
+type ClickImportRequest struct {
+	Clicks []ClickImportRecord `json:"clicks"`
+}
+
+// ImportClicks handles admin requests to import up to store.MaxClickImportSize historical clicks of a
+// URL, for example when migrating from another shortener. The clicks are added to the URL's click count
+// and show up in its analytics.
+func (h *URLHandler) ImportClicks(c echo.Context) error {
+	code := c.Param("code")
+
+	var req ClickImportRequest
+	if err := c.Bind(&req); err != nil {
+		log.Error().Err(err).Str("code", code).Msg("Invalid request format for click import")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request"})
+	}
+
+	log.Debug().Str("code", code).Int("count", len(req.Clicks)).Msg("Importing clicks")
+
+	if len(req.Clicks) == 0 {
+		log.Error().Str("code", code).Msg("No clicks provided for import")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Missing clicks"})
+	}
+
+	imported := make([]store.ImportedClick, len(req.Clicks))
+	for i, click := range req.Clicks {
+		imported[i] = store.ImportedClick{
+			Timestamp:      click.Timestamp,
+			IP:             click.IP,
+			Location:       click.Location,
+			UserAgent:      click.UserAgent,
+			Browser:        click.Browser,
+			BrowserVersion: click.BrowserVersion,
+			OS:             click.OS,
+			Device:         click.Device,
+			Target:         click.Target,
+			Referrer:       click.Referrer,
+		}
+	}
+
+	clicks, err := h.service.ImportClicks(c.Request().Context(), code, imported)
+	if err != nil {
+		log.Error().Err(err).Str("code", code).Msg("Failed to import clicks")
+		return err
+	}
+
+	log.Info().Str("code", code).Int("imported", len(imported)).Int64("clicks", clicks).Msg("Clicks imported")
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"imported": len(imported), "clicks": clicks})
+}
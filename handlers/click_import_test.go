@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestImportClicks tests that admins can import historical clicks and that they show up in analytics
+func TestImportClicks(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeRepository()
+	e := newRealTestServer(repo, newTestConfig())
+	_, err := repo.Create(ctx, models.NewURL("https://example.com", "migrated", "", time.Time{}, "test-user"))
+	assert.NoError(t, err)
+
+	post := func(code, adminKey, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/admin/urls/"+code+"/clicks/import", bytes.NewBufferString(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set("X-Admin-Key", adminKey)
+		return serveRequest(e, req)
+	}
+	ago := func(days int) string {
+		return time.Now().AddDate(0, 0, -days).UTC().Format(time.RFC3339)
+	}
+
+	t.Run("RequiresAdminKey", func(t *testing.T) {
+		rec := post("migrated", "wrong", `{"clicks": [{"timestamp": "`+ago(1)+`"}]}`)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Equal(t, 0, len(repo.clicks))
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		for _, body := range []string{
+			`{"clicks": []}`,
+			`{"clicks": [{"ip": "203.0.113.1"}]}`,
+			`{"clicks": [{"timestamp": "` + time.Now().Add(time.Hour).UTC().Format(time.RFC3339) + `"}]}`,
+		} {
+			rec := post("migrated", "test-admin-key", body)
+			assert.Equal(t, http.StatusBadRequest, rec.Code, body)
+		}
+		assert.Equal(t, 0, len(repo.clicks))
+
+		rec := post("missing", "test-admin-key", `{"clicks": [{"timestamp": "`+ago(1)+`"}]}`)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("PastClicksInAnalytics", func(t *testing.T) {
+		rec := post("migrated", "test-admin-key", `{"clicks": [
+			{"timestamp": "`+ago(2)+`", "ip": "203.0.113.1", "location": "Germany", "user_agent": "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"},
+			{"timestamp": "`+ago(5)+`", "ip": "203.0.113.2", "browser": "Firefox", "device": "Mobile"},
+			{"timestamp": "`+ago(365)+`", "ip": "203.0.113.3"}
+		]}`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var response map[string]interface{}
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		assert.Equal(t, float64(3), response["imported"])
+		assert.Equal(t, float64(3), response["clicks"])
+
+		url, err := repo.GetByShort(ctx, "migrated")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), url.Clicks)
+
+		get := func(query string) map[string]interface{} {
+			rec := serveAPI(e, http.MethodGet, "/api/urls/migrated/analytics"+query, "")
+			assert.Equal(t, http.StatusOK, rec.Code)
+			var response struct {
+				Analytics map[string]interface{} `json:"analytics"`
+			}
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+			return response.Analytics
+		}
+		assert.Equal(t, float64(2), get("?from=" + ago(30))["total_clicks"])
+		assert.Equal(t, float64(3), get("?from=" + ago(400))["total_clicks"])
+
+		clicks, err := repo.GetClicksByShort(ctx, "migrated")
+		assert.NoError(t, err)
+		browsers := map[string]bool{}
+		for _, click := range clicks {
+			assert.Equal(t, url.ID, click.URLID)
+			browsers[click.Browser] = true
+		}
+		assert.True(t, browsers["Chrome"])
+		assert.True(t, browsers["Firefox"])
+	})
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// CreatorDefaultsRequest represents a request to set the defaults for a creator's new links
+type CreatorDefaultsRequest struct {
+	Expiry          int64             `json:"expiry,omitempty"`     // in seconds
+	RateLimit       int               `json:"rate_limit,omitempty"` // redirects per minute
+	RedirectHeaders map[string]string `json:"redirect_headers,omitempty"`
+}
+
+// GetCreatorDefaults returns the defaults applied to a creator's new links
+func (h *URLHandler) GetCreatorDefaults(c echo.Context) error {
+	creatorReference := c.Param("creator_reference")
+
+	log.Debug().Str("creator_reference", creatorReference).Msg("Getting creator defaults")
+
+	defaults, err := h.service.GetCreatorDefaults(c.Request().Context(), creatorReference)
+	if err != nil {
+		log.Error().Err(err).Str("creator_reference", creatorReference).Msg("Failed to get creator defaults")
+		return err
+	}
+
+	log.Info().Str("creator_reference", creatorReference).Msg("Creator defaults retrieved successfully")
+	return c.JSON(http.StatusOK, defaults)
+}
+
+// SetCreatorDefaults replaces the defaults applied to a creator's new links when a request omits them
+func (h *URLHandler) SetCreatorDefaults(c echo.Context) error {
+	creatorReference := c.Param("creator_reference")
+
+	var req CreatorDefaultsRequest
+	if err := c.Bind(&req); err != nil {
+		log.Error().Err(err).Msg("Invalid request format for creator defaults")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request"})
+	}
+
+	log.Debug().
+		Str("creator_reference", creatorReference).
+		Int64("expiry", req.Expiry).
+		Int("rate_limit", req.RateLimit).
+		Msg("Setting creator defaults")
+
+	defaults, err := h.service.SetCreatorDefaults(c.Request().Context(), &models.CreatorDefaults{
+		CreatorReference: creatorReference,
+		Expiry:           req.Expiry,
+		RateLimit:        req.RateLimit,
+		RedirectHeaders:  req.RedirectHeaders,
+	})
+	if err != nil {
+		log.Error().Err(err).Str("creator_reference", creatorReference).Msg("Failed to set creator defaults")
+		return err
+	}
+
+	log.Info().Str("creator_reference", creatorReference).Msg("Creator defaults set successfully")
+	return c.JSON(http.StatusOK, defaults)
+}
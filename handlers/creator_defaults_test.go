@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCreatorDefaults tests that a creator's stored defaults apply to new links unless the request overrides them
+func TestCreatorDefaults(t *testing.T) {
+	repo := newFakeRepository()
+	e := newRealTestServer(repo, newTestConfig())
+
+	serve := func(method, path string, body interface{}) *httptest.ResponseRecorder {
+		var payload []byte
+		if body != nil {
+			payload, _ = json.Marshal(body)
+		}
+		return serveAPI(e, method, path, string(payload))
+	}
+
+	assert.Equal(t, http.StatusNotFound, serve(http.MethodGet, "/api/creators/power-user/defaults", nil).Code)
+
+	rec := serve(http.MethodPut, "/api/creators/power-user/defaults", CreatorDefaultsRequest{
+		Expiry:          3600,
+		RedirectHeaders: map[string]string{"Cache-Control": "no-store"},
+	})
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = serve(http.MethodGet, "/api/creators/power-user/defaults", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var defaults models.CreatorDefaults
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &defaults))
+	assert.Equal(t, int64(3600), defaults.Expiry)
+
+	t.Run("AppliedWhenOmitted", func(t *testing.T) {
+		rec := serve(http.MethodPost, "/api/shorten", ShortenRequest{URL: "https://example.com", CustomCode: "defaulted", CreatorReference: "power-user"})
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		stored, err := repo.GetByShort(context.Background(), "defaulted")
+		assert.NoError(t, err)
+		if assert.NotNil(t, stored.ExpiresAt) {
+			assert.WithinDuration(t, time.Now().Add(time.Hour), *stored.ExpiresAt, time.Minute)
+		}
+		assert.Equal(t, map[string]string{"Cache-Control": "no-store"}, stored.RedirectHeaders)
+	})
+
+	t.Run("OverriddenByRequest", func(t *testing.T) {
+		// An explicit empty redirect_headers object clears the default headers
+		rec := serve(http.MethodPost, "/api/shorten", map[string]interface{}{
+			"url":               "https://example.com",
+			"custom_code":       "overridden",
+			"creator_reference": "power-user",
+			"expiry":            60,
+			"redirect_headers":  map[string]string{},
+		})
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		stored, err := repo.GetByShort(context.Background(), "overridden")
+		assert.NoError(t, err)
+		if assert.NotNil(t, stored.ExpiresAt) {
+			assert.WithinDuration(t, time.Now().Add(time.Minute), *stored.ExpiresAt, 10*time.Second)
+		}
+		assert.Empty(t, stored.RedirectHeaders)
+	})
+
+	t.Run("RejectsNegativeValues", func(t *testing.T) {
+		rec := serve(http.MethodPut, "/api/creators/power-user/defaults", CreatorDefaultsRequest{Expiry: -1})
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
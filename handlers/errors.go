@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/fransfilastap/urlshortener/metrics"
+	"github.com/fransfilastap/urlshortener/store"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// errInternal is reported for errors that carry no status of their own
+var errInternal = store.NewAPIError(http.StatusInternalServerError, "internal_error", "Internal server error")
+
+// codeGenerationRetryAfter is the Retry-After, in seconds, sent when no short code could be generated
+const codeGenerationRetryAfter = "1"
+
+// ErrorHandler renders errors returned by handlers as JSON. A store.APIError is rendered with its
+// own status and code, an echo.HTTPError is left to echo, and anything else becomes a 500.
+func ErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		c.Echo().DefaultHTTPErrorHandler(err, c)
+		return
+	}
+
+	var apiErr *store.APIError
+	if !errors.As(err, &apiErr) {
+		apiErr = errInternal
+	}
+
+	// Every failed request passes through here, so this is where errors are counted by code
+	metrics.Errors.WithLabelValues(apiErr.Code, strconv.Itoa(apiErr.Status)).Inc()
+	event := log.Warn()
+	if apiErr.Status >= http.StatusInternalServerError {
+		event = log.Error()
+	}
+	event.Err(err).
+		Int("status", apiErr.Status).
+		Str("code", apiErr.Code).
+		Str("method", c.Request().Method).
+		Str("path", c.Path()).
+		Msg("Request failed")
+
+	if errors.Is(err, store.ErrCodeGenerationFailed) {
+		c.Response().Header().Set("Retry-After", codeGenerationRetryAfter)
+	}
+	if c.Request().Method == http.MethodHead {
+		err = c.NoContent(apiErr.Status)
+	} else {
+		err = c.JSON(apiErr.Status, map[string]string{"error": apiErr.Message, "code": apiErr.Code})
+	}
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to write error response")
+	}
+}
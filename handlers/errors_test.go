@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fransfilastap/urlshortener/metrics"
+	"github.com/fransfilastap/urlshortener/store"
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorHandler(t *testing.T) {
+	// Setup
+	e := echo.New()
+	e.HTTPErrorHandler = ErrorHandler
+
+	serve := func(err error) *httptest.ResponseRecorder {
+		e.GET("/fail", func(c echo.Context) error { return err })
+		req := httptest.NewRequest(http.MethodGet, "/fail", nil)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	decode := func(t *testing.T, rec *httptest.ResponseRecorder) map[string]string {
+		var body map[string]string
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		return body
+	}
+
+	// Test case 1: An APIError renders its own status, code and message
+	t.Run("APIError", func(t *testing.T) {
+		rec := serve(store.NewAPIError(http.StatusTeapot, "teapot", "I'm a teapot"))
+
+		assert.Equal(t, http.StatusTeapot, rec.Code)
+		assert.Equal(t, map[string]string{"error": "I'm a teapot", "code": "teapot"}, decode(t, rec))
+	})
+
+	// Test case 2: A wrapped store sentinel is still rendered as an APIError
+	t.Run("WrappedSentinel", func(t *testing.T) {
+		rec := serve(fmt.Errorf("lookup: %w", store.ErrURLNotFound))
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Equal(t, map[string]string{"error": "URL not found", "code": "url_not_found"}, decode(t, rec))
+	})
+
+	// Test case 3: Errors without a status become a generic 500
+	t.Run("PlainError", func(t *testing.T) {
+		rec := serve(errors.New("connection refused"))
+
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		assert.Equal(t, map[string]string{"error": "Internal server error", "code": "internal_error"}, decode(t, rec))
+	})
+
+	// Test case 4: Errors are counted by code and status
+	t.Run("Counted", func(t *testing.T) {
+		counter := metrics.Errors.WithLabelValues("gone_fishing", "503")
+		before := testutil.ToFloat64(counter)
+
+		serve(store.NewAPIError(http.StatusServiceUnavailable, "gone_fishing", "Gone fishing"))
+
+		assert.Equal(t, before+1, testutil.ToFloat64(counter))
+	})
+
+	// Test case 5: Echo's own errors keep echo's rendering
+	t.Run("HTTPError", func(t *testing.T) {
+		rec := serve(echo.NewHTTPError(http.StatusMethodNotAllowed, "nope"))
+
+		assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+		assert.Contains(t, rec.Body.String(), "nope")
+	})
+}
+
+// TestHandlersReturnAPIErrors tests that handler errors are rendered through ErrorHandler
+func TestHandlersReturnAPIErrors(t *testing.T) {
+	e := newRealTestServer(newFakeRepository(), newTestConfig())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/urls/missing/history?action=bogus", nil)
+	req.Header.Set("X-API-Key", "test-api-key")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.JSONEq(t, `{"error":"Invalid action","code":"invalid_history_action"}`, rec.Body.String())
+
+	req = httptest.NewRequest(http.MethodGet, "/api/urls/missing", nil)
+	req.Header.Set("X-API-Key", "test-api-key")
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.JSONEq(t, `{"error":"URL not found","code":"url_not_found"}`, rec.Body.String())
+}
@@ -0,0 +1,997 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/fransfilastap/urlshortener/store"
+)
+
+// fakeRepository is an in-memory implementation of store.URLRepository used to
+// exercise the real URLHandler and URLService together in tests
+type fakeRepository struct {
+	mu      sync.Mutex
+	nextID  int64
+	urls    map[string]*models.URL
+	clicks  []*models.Click
+	history []*models.URLHistory
+
+	creatorDefaults map[string]*models.CreatorDefaults
+
+	// pingErr is returned by Ping, to simulate an unreachable database
+	pingErr error
+}
+
+// Ensure fakeRepository implements store.URLRepository and store.CreatorDefaultsRepository
+var (
+	_ store.URLRepository             = (*fakeRepository)(nil)
+	_ store.CreatorDefaultsRepository = (*fakeRepository)(nil)
+)
+
+func newFakeRepository() *fakeRepository {
+	return &fakeRepository{urls: make(map[string]*models.URL), creatorDefaults: make(map[string]*models.CreatorDefaults)}
+}
+
+// live returns the URL for short if it is neither deleted nor expired
+func (r *fakeRepository) live(short string) (*models.URL, bool) {
+	url, ok := r.urls[short]
+	if !ok || url.DeletedAt != nil {
+		return nil, false
+	}
+	if url.IsExpired() {
+		return nil, false
+	}
+	return url, true
+}
+
+func (r *fakeRepository) Create(ctx context.Context, url *models.URL) (*models.URL, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.create(url)
+}
+
+// create stores url; r.mu must be held
+func (r *fakeRepository) create(url *models.URL) (*models.URL, error) {
+	if _, ok := r.urls[url.Short]; ok {
+		return nil, store.ErrURLExists
+	}
+	created := *url
+	if created.ID == 0 {
+		r.nextID++
+		created.ID = r.nextID
+	}
+	r.urls[url.Short] = &created
+	copied := created
+	return &copied, nil
+}
+
+func (r *fakeRepository) CreateBatch(ctx context.Context, urls []*models.URL) ([]*models.URL, []error, error) {
+	created := make([]*models.URL, len(urls))
+	errs := make([]error, len(urls))
+	for i, url := range urls {
+		created[i], errs[i] = r.Create(ctx, url)
+	}
+	return created, errs, nil
+}
+
+func (r *fakeRepository) BulkCreate(ctx context.Context, urls []*models.URL) ([]error, error) {
+	errs := make([]error, len(urls))
+	for i, url := range urls {
+		_, errs[i] = r.Create(ctx, url)
+	}
+	return errs, nil
+}
+
+// Stats reports a fixed pool so handlers can be tested with a pooled repository
+func (r *fakeRepository) Ping(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.pingErr
+}
+
+func (r *fakeRepository) Stats() store.PoolStats {
+	return store.PoolStats{TotalConns: 4, AcquiredConns: 1, IdleConns: 3, MaxConns: 10}
+}
+
+func (r *fakeRepository) CountActiveURLs(ctx context.Context) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var count int64
+	for short := range r.urls {
+		if _, ok := r.live(short); ok {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *fakeRepository) NextURLID(ctx context.Context) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextID++
+	return r.nextID, nil
+}
+
+func (r *fakeRepository) GetByShort(ctx context.Context, short string) (*models.URL, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	url, ok := r.urls[short]
+	if !ok || url.DeletedAt != nil {
+		return nil, store.ErrURLNotFound
+	}
+	if url.IsExpired() {
+		return nil, store.ErrURLExpired
+	}
+	copied := *url
+	return &copied, nil
+}
+
+func (r *fakeRepository) GetByShorts(ctx context.Context, shorts []string) ([]*models.URL, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var urls []*models.URL
+	for _, short := range shorts {
+		if url, ok := r.urls[short]; ok && url.DeletedAt == nil {
+			copied := *url
+			urls = append(urls, &copied)
+		}
+	}
+	return urls, nil
+}
+
+func (r *fakeRepository) GetByShortIncludingDeleted(ctx context.Context, short string) (*models.URL, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	url, ok := r.urls[short]
+	if !ok {
+		return nil, store.ErrURLNotFound
+	}
+	copied := *url
+	return &copied, nil
+}
+
+func (r *fakeRepository) GetByOriginal(ctx context.Context, original string) (*models.URL, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for short, url := range r.urls {
+		if url.Original != original {
+			continue
+		}
+		if live, ok := r.live(short); ok {
+			copied := *live
+			return &copied, nil
+		}
+	}
+	return nil, store.ErrURLNotFound
+}
+
+func (r *fakeRepository) GetByOriginalForCreator(ctx context.Context, original string, creatorReference string) (*models.URL, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	newest := r.newestForCreator(original, creatorReference)
+	if newest == nil {
+		return nil, store.ErrURLNotFound
+	}
+	copied := *newest
+	return &copied, nil
+}
+
+// newestForCreator returns the creator's most recently created live URL for original, or nil; r.mu must be held
+func (r *fakeRepository) newestForCreator(original string, creatorReference string) *models.URL {
+	var newest *models.URL
+	for short, url := range r.urls {
+		if url.Original != original || url.CreatorReference != creatorReference {
+			continue
+		}
+		if live, ok := r.live(short); ok && (newest == nil || live.CreatedAt.After(newest.CreatedAt)) {
+			newest = live
+		}
+	}
+	return newest
+}
+
+func (r *fakeRepository) CreateOrGet(ctx context.Context, url *models.URL) (*models.URL, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if existing := r.newestForCreator(url.Original, url.CreatorReference); existing != nil {
+		copied := *existing
+		return &copied, false, nil
+	}
+	created, err := r.create(url)
+	if err != nil {
+		return nil, false, err
+	}
+	return created, true, nil
+}
+
+func (r *fakeRepository) GetRandomURLByCreator(ctx context.Context, creatorReference string) (*models.URL, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var candidates []*models.URL
+	for short, url := range r.urls {
+		if url.CreatorReference != creatorReference || !url.Enabled || url.RandomTarget {
+			continue
+		}
+		if live, ok := r.live(short); ok {
+			candidates = append(candidates, live)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, store.ErrURLNotFound
+	}
+	copied := *candidates[rand.Intn(len(candidates))]
+	return &copied, nil
+}
+
+func (r *fakeRepository) CountByCreator(ctx context.Context, creatorReference string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var count int64
+	for short, url := range r.urls {
+		if _, ok := r.live(short); ok && url.CreatorReference == creatorReference {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *fakeRepository) GetByCreator(ctx context.Context, creatorReference string, filter store.CreatorURLFilter) ([]*models.URL, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var urls []*models.URL
+	for short, url := range r.urls {
+		if url.CreatorReference != creatorReference {
+			continue
+		}
+		if live, ok := r.live(short); ok {
+			copied := *live
+			urls = append(urls, &copied)
+		}
+	}
+	sort.Slice(urls, func(i, j int) bool {
+		a, b := urls[i], urls[j]
+		if filter.Descending {
+			a, b = b, a
+		}
+		if filter.Sort == store.SortByClicks && a.Clicks != b.Clicks {
+			return a.Clicks < b.Clicks
+		}
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return a.ID < b.ID
+	})
+	total := int64(len(urls))
+	if filter.Offset >= len(urls) {
+		return nil, total, nil
+	}
+	urls = urls[filter.Offset:]
+	if len(urls) > filter.Limit {
+		urls = urls[:filter.Limit]
+	}
+	return urls, total, nil
+}
+
+func (r *fakeRepository) ListURLs(ctx context.Context, filter store.URLListFilter, page store.Page) ([]*models.URL, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	search := strings.ToLower(filter.Search)
+	var urls []*models.URL
+	for _, url := range r.urls {
+		switch {
+		case url.DeletedAt != nil && !filter.IncludeDeleted,
+			filter.CreatorReference != "" && url.CreatorReference != filter.CreatorReference,
+			!filter.CreatedAfter.IsZero() && url.CreatedAt.Before(filter.CreatedAfter),
+			!filter.CreatedBefore.IsZero() && !url.CreatedAt.Before(filter.CreatedBefore),
+			url.Clicks < filter.MinClicks,
+			!strings.Contains(strings.ToLower(url.Original), search) && !strings.Contains(strings.ToLower(url.Title), search):
+			continue
+		}
+		copied := *url
+		urls = append(urls, &copied)
+	}
+	sort.Slice(urls, func(i, j int) bool {
+		if !urls[i].CreatedAt.Equal(urls[j].CreatedAt) {
+			return urls[i].CreatedAt.After(urls[j].CreatedAt)
+		}
+		return urls[i].ID > urls[j].ID
+	})
+	total := int64(len(urls))
+	if page.Offset >= len(urls) {
+		return nil, total, nil
+	}
+	urls = urls[page.Offset:]
+	if len(urls) > page.Limit {
+		urls = urls[:page.Limit]
+	}
+	return urls, total, nil
+}
+
+// SearchURLs matches every query word as a prefix of a word of the original URL or title, newest first
+func (r *fakeRepository) SearchURLs(ctx context.Context, query string, creatorReference string, page store.Page) ([]*models.URL, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	words := func(text string) []string {
+		return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+	}
+	var urls []*models.URL
+	for short, url := range r.urls {
+		live, ok := r.live(short)
+		if !ok || (creatorReference != "" && url.CreatorReference != creatorReference) {
+			continue
+		}
+		document := words(url.Original + " " + url.Title)
+		matches := true
+		for _, term := range words(query) {
+			if !slices.ContainsFunc(document, func(word string) bool { return strings.HasPrefix(word, term) }) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			copied := *live
+			urls = append(urls, &copied)
+		}
+	}
+	sort.Slice(urls, func(i, j int) bool {
+		if !urls[i].CreatedAt.Equal(urls[j].CreatedAt) {
+			return urls[i].CreatedAt.After(urls[j].CreatedAt)
+		}
+		return urls[i].ID > urls[j].ID
+	})
+	total := int64(len(urls))
+	if page.Offset >= len(urls) {
+		return nil, total, nil
+	}
+	urls = urls[page.Offset:]
+	if len(urls) > page.Limit {
+		urls = urls[:page.Limit]
+	}
+	return urls, total, nil
+}
+
+func (r *fakeRepository) GetTopURLs(ctx context.Context, creatorReference string, limit int) ([]*models.URL, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var urls []*models.URL
+	for short, url := range r.urls {
+		if creatorReference != "" && url.CreatorReference != creatorReference {
+			continue
+		}
+		if live, ok := r.live(short); ok {
+			copied := *live
+			urls = append(urls, &copied)
+		}
+	}
+	sort.Slice(urls, func(i, j int) bool {
+		if urls[i].Clicks != urls[j].Clicks {
+			return urls[i].Clicks > urls[j].Clicks
+		}
+		return urls[i].ID < urls[j].ID
+	})
+	if len(urls) > limit {
+		urls = urls[:limit]
+	}
+	return urls, nil
+}
+
+func (r *fakeRepository) IncrementClicks(ctx context.Context, short string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	url, ok := r.live(short)
+	if !ok {
+		return 0, store.ErrURLNotFound
+	}
+	if url.MaxClicks > 0 && url.Clicks >= url.MaxClicks {
+		return 0, store.ErrClickLimitReached
+	}
+	now := time.Now()
+	url.Clicks++
+	url.LastAccessedAt = &now
+	return url.Clicks, nil
+}
+
+func (r *fakeRepository) RebuildClickCount(ctx context.Context, short string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	url, ok := r.urls[short]
+	if !ok {
+		return 0, store.ErrURLNotFound
+	}
+	url.Clicks = url.SeededClicks + r.countClicks(url.ID)
+	return url.Clicks, nil
+}
+
+func (r *fakeRepository) RebuildClickCounts(ctx context.Context, afterID int64, limit int) ([]string, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var batch []*models.URL
+	for _, url := range r.urls {
+		if url.ID > afterID {
+			batch = append(batch, url)
+		}
+	}
+	sort.Slice(batch, func(i, j int) bool { return batch[i].ID < batch[j].ID })
+	if len(batch) > limit {
+		batch = batch[:limit]
+	}
+	shorts := []string{}
+	lastID := afterID
+	for _, url := range batch {
+		url.Clicks = url.SeededClicks + r.countClicks(url.ID)
+		shorts = append(shorts, url.Short)
+		lastID = url.ID
+	}
+	return shorts, lastID, nil
+}
+
+// countClicks returns the number of recorded clicks for a URL ID
+func (r *fakeRepository) countClicks(urlID int64) int64 {
+	var count int64
+	for _, click := range r.clicks {
+		if click.URLID == urlID {
+			count++
+		}
+	}
+	return count
+}
+
+func (r *fakeRepository) Delete(ctx context.Context, short string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if url, ok := r.live(short); ok {
+		now := time.Now()
+		url.DeletedAt = &now
+	}
+	return nil
+}
+
+func (r *fakeRepository) Restore(ctx context.Context, short string) (*models.URL, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	url, ok := r.urls[short]
+	if !ok {
+		return nil, store.ErrURLNotFound
+	}
+	if url.DeletedAt == nil {
+		return nil, store.ErrRestoreConflict
+	}
+	url.DeletedAt = nil
+	copied := *url
+	return &copied, nil
+}
+
+func (r *fakeRepository) HardDelete(ctx context.Context, short, creatorReference string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if url, ok := r.urls[short]; !ok || url.CreatorReference != creatorReference {
+		return nil
+	}
+	delete(r.urls, short)
+	var clicks []*models.Click
+	for _, click := range r.clicks {
+		if click.URLShort != short {
+			clicks = append(clicks, click)
+		}
+	}
+	r.clicks = clicks
+	return nil
+}
+
+func (r *fakeRepository) PurgeExpired(ctx context.Context, grace time.Duration, limit int) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cutoff := time.Now().Add(-grace)
+	var shorts []string
+	for short, url := range r.urls {
+		if len(shorts) == limit {
+			break
+		}
+		if url.ExpiresAt != nil && url.ExpiresAt.Before(cutoff) {
+			shorts = append(shorts, short)
+		}
+	}
+	for _, short := range shorts {
+		delete(r.urls, short)
+	}
+	var clicks []*models.Click
+	for _, click := range r.clicks {
+		if _, ok := r.urls[click.URLShort]; ok {
+			clicks = append(clicks, click)
+		}
+	}
+	r.clicks = clicks
+	return shorts, nil
+}
+
+func (r *fakeRepository) DeleteWithCreator(ctx context.Context, short string, creatorReference string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	url, ok := r.live(short)
+	if !ok {
+		return store.ErrURLNotFound
+	}
+	if url.CreatorReference != creatorReference {
+		return store.ErrCreatorMismatch
+	}
+	now := time.Now()
+	url.DeletedAt = &now
+	return nil
+}
+
+func (r *fakeRepository) StoreClick(ctx context.Context, click *models.Click) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.clicks = append(r.clicks, click)
+	return nil
+}
+
+func (r *fakeRepository) StoreCountedClick(ctx context.Context, click *models.Click) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var clicks int64
+	if !click.Counted {
+		url, ok := r.live(click.URLShort)
+		if !ok {
+			return 0, store.ErrURLNotFound
+		}
+		if url.MaxClicks > 0 && url.Clicks >= url.MaxClicks {
+			return 0, store.ErrClickLimitReached
+		}
+		now := time.Now()
+		url.Clicks++
+		url.LastAccessedAt = &now
+		clicks = url.Clicks
+	}
+	r.clicks = append(r.clicks, click)
+	return clicks, nil
+}
+
+func (r *fakeRepository) StoreClickBatch(ctx context.Context, clicks []*models.Click) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	for _, click := range clicks {
+		r.clicks = append(r.clicks, click)
+		if url, ok := r.live(click.URLShort); ok && !click.Counted {
+			url.Clicks++
+			url.LastAccessedAt = &now
+		}
+	}
+	return nil
+}
+
+func (r *fakeRepository) ImportClicks(ctx context.Context, short string, clicks []*models.Click) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	url, ok := r.live(short)
+	if !ok {
+		return 0, store.ErrURLNotFound
+	}
+	for _, click := range clicks {
+		click.URLID = url.ID
+		click.URLShort = short
+		r.clicks = append(r.clicks, click)
+		if url.LastAccessedAt == nil || click.Timestamp.After(*url.LastAccessedAt) {
+			ts := click.Timestamp
+			url.LastAccessedAt = &ts
+		}
+	}
+	url.Clicks += int64(len(clicks))
+	return url.Clicks, nil
+}
+
+func (r *fakeRepository) GetClicksByShort(ctx context.Context, short string) ([]*models.Click, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var clicks []*models.Click
+	for i := len(r.clicks) - 1; i >= 0; i-- {
+		if r.clicks[i].URLShort == short {
+			clicks = append(clicks, r.clicks[i])
+		}
+	}
+	return clicks, nil
+}
+
+func (r *fakeRepository) StreamByCreator(ctx context.Context, creatorReference string, fn func(*models.URL) error) error {
+	r.mu.Lock()
+	limit := len(r.urls)
+	r.mu.Unlock()
+	urls, _, err := r.GetByCreator(ctx, creatorReference, store.CreatorURLFilter{Limit: limit})
+	if err != nil {
+		return err
+	}
+	for _, url := range urls {
+		if err := fn(url); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *fakeRepository) StreamClicksByShort(ctx context.Context, short string, fn func(*models.Click) error) error {
+	r.mu.Lock()
+	var clicks []*models.Click
+	for _, click := range r.clicks {
+		if click.URLShort == short {
+			clicks = append(clicks, click)
+		}
+	}
+	r.mu.Unlock()
+	for _, click := range clicks {
+		if err := fn(click); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *fakeRepository) CountClicks(ctx context.Context, short string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var count int64
+	for _, click := range r.clicks {
+		if click.URLShort == short {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *fakeRepository) GetResolveTiming(ctx context.Context, short string) (*models.ResolveTiming, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var samples []float64
+	for _, click := range r.clicks {
+		if click.URLShort == short && click.ResolveMs != nil {
+			samples = append(samples, *click.ResolveMs)
+		}
+	}
+	sort.Float64s(samples)
+	// Linear interpolation between samples, like PostgreSQL's percentile_cont
+	percentile := func(p float64) float64 {
+		if len(samples) == 0 {
+			return 0
+		}
+		pos := p * float64(len(samples)-1)
+		lower := int(pos)
+		if lower+1 >= len(samples) {
+			return samples[lower]
+		}
+		return samples[lower] + (pos-float64(lower))*(samples[lower+1]-samples[lower])
+	}
+	return &models.ResolveTiming{Samples: int64(len(samples)), P50: percentile(0.5), P95: percentile(0.95), P99: percentile(0.99)}, nil
+}
+
+func (r *fakeRepository) GetClickAnalytics(ctx context.Context, short string, rng store.ClickRange) (map[string]interface{}, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var total int64
+	browsers := make(map[string]int64)
+	devices := make(map[string]int64)
+	operatingSystems := make(map[string]int64)
+	locations := make(map[string]int64)
+	targets := make(map[string]int64)
+	referrers := make(map[string]int64)
+	for _, click := range r.clicks {
+		if click.URLShort != short {
+			continue
+		}
+		if !inClickRange(click.Timestamp, rng) {
+			continue
+		}
+		total++
+		browsers[click.Browser]++
+		devices[click.Device]++
+		if click.OS != "" {
+			operatingSystems[click.OS]++
+		}
+		locations[click.Location]++
+		if click.Target != "" {
+			targets[click.Target]++
+		}
+		if click.Referrer != "" {
+			referrers[click.Referrer]++
+		}
+	}
+	analytics := map[string]interface{}{
+		"total_clicks": total,
+		"browsers":     browsers,
+		"devices":      devices,
+		"os":           operatingSystems,
+		"locations":    locations,
+		"referrers":    referrers,
+	}
+	if len(targets) > 0 {
+		analytics["targets"] = targets
+	}
+	return analytics, nil
+}
+
+func (r *fakeRepository) SetEnabled(ctx context.Context, short string, enabled bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	url, ok := r.live(short)
+	if !ok {
+		return store.ErrURLNotFound
+	}
+	url.Enabled = enabled
+	return nil
+}
+
+func (r *fakeRepository) SetEnabledByCreator(ctx context.Context, creatorReference string, tag string, enabled bool) ([]*models.URL, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var urls []*models.URL
+	for _, url := range r.urls {
+		if url.CreatorReference != creatorReference || url.DeletedAt != nil || url.Enabled == enabled {
+			continue
+		}
+		if tag != "" && !slices.Contains(url.Tags, tag) {
+			continue
+		}
+		url.Enabled = enabled
+		copied := *url
+		urls = append(urls, &copied)
+	}
+	return urls, nil
+}
+
+func (r *fakeRepository) UpdateTags(ctx context.Context, creatorReference string, codes []string, change store.TagChange) ([]*models.URL, []error, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	previous := make([]*models.URL, len(codes))
+	errs := make([]error, len(codes))
+	for i, short := range codes {
+		url, ok := r.live(short)
+		switch {
+		case !ok:
+			errs[i] = store.ErrURLNotFound
+		case url.CreatorReference != creatorReference:
+			errs[i] = store.ErrCreatorMismatch
+		default:
+			copied := *url
+			previous[i] = &copied
+			url.Tags = change.Apply(url.Tags)
+		}
+	}
+	return previous, errs, nil
+}
+
+func (r *fakeRepository) ClickCountsByMetadata(ctx context.Context, creatorReference string, key string) ([]*models.MetadataClickCount, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	byValue := make(map[string]*models.MetadataClickCount)
+	for _, url := range r.urls {
+		value, ok := url.Metadata[key]
+		if !ok || url.DeletedAt != nil || url.CreatorReference != creatorReference {
+			continue
+		}
+		count, ok := byValue[value]
+		if !ok {
+			count = &models.MetadataClickCount{Value: value}
+			byValue[value] = count
+		}
+		count.URLs++
+		for _, click := range r.clicks {
+			if click.URLID == url.ID {
+				count.Clicks++
+			}
+		}
+	}
+	counts := make([]*models.MetadataClickCount, 0, len(byValue))
+	for _, count := range byValue {
+		counts = append(counts, count)
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Clicks != counts[j].Clicks {
+			return counts[i].Clicks > counts[j].Clicks
+		}
+		return counts[i].Value < counts[j].Value
+	})
+	return counts, nil
+}
+
+// inClickRange reports whether a click at t falls within rng
+func inClickRange(t time.Time, rng store.ClickRange) bool {
+	return (rng.From.IsZero() || !t.Before(rng.From)) && (rng.To.IsZero() || t.Before(rng.To))
+}
+
+func (r *fakeRepository) GetClickTimeSeries(ctx context.Context, short string, rng store.ClickRange, interval string, timezone string) ([]*models.TimeSeriesPoint, error) {
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	clicksByDay := make(map[time.Time]int64)
+	var days []time.Time
+	for _, click := range r.clicks {
+		if click.URLShort != short || !inClickRange(click.Timestamp, rng) {
+			continue
+		}
+		day := store.TimeSeriesInterval(interval).BucketStart(click.Timestamp, loc).UTC()
+		if _, ok := clicksByDay[day]; !ok {
+			days = append(days, day)
+		}
+		clicksByDay[day]++
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+	var points []*models.TimeSeriesPoint
+	for _, day := range days {
+		points = append(points, &models.TimeSeriesPoint{Time: day, Clicks: clicksByDay[day]})
+	}
+	return points, nil
+}
+
+func (r *fakeRepository) GetClickDistribution(ctx context.Context, short string, rng store.ClickRange, timezone string) (*models.ClickDistribution, error) {
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	distribution := &models.ClickDistribution{Timezone: timezone}
+	for _, click := range r.clicks {
+		if click.URLShort != short || !inClickRange(click.Timestamp, rng) {
+			continue
+		}
+		local := click.Timestamp.In(loc)
+		distribution.HourOfDay[local.Hour()]++
+		distribution.DayOfWeek[local.Weekday()]++
+	}
+	return distribution, nil
+}
+
+func (r *fakeRepository) HasRecentClick(ctx context.Context, short string, ip string, browser string, device string, window time.Duration) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, click := range r.clicks {
+		if click.URLShort == short && click.IP == ip && click.Browser == browser && click.Device == device &&
+			click.Timestamp.After(time.Now().Add(-window)) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeRepository) UpdateURL(ctx context.Context, short string, url *models.URL) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	existing, ok := r.live(short)
+	if !ok {
+		return store.ErrURLNotFound
+	}
+	existing.Original = url.Original
+	existing.Title = url.Title
+	existing.ExpiresAt = url.ExpiresAt
+	existing.RateLimit = url.RateLimit
+	existing.RedirectHeaders = url.RedirectHeaders
+	existing.Metadata = url.Metadata
+	existing.Tags = url.Tags
+	existing.DisabledRedirectURL = url.DisabledRedirectURL
+	existing.Notes = url.Notes
+	return nil
+}
+
+func (r *fakeRepository) UpdateURLWithCreator(ctx context.Context, short string, url *models.URL, creatorReference string) error {
+	r.mu.Lock()
+	existing, ok := r.live(short)
+	matches := ok && existing.CreatorReference == creatorReference
+	r.mu.Unlock()
+	if !ok {
+		return store.ErrURLNotFound
+	}
+	if !matches {
+		return store.ErrCreatorMismatch
+	}
+	return r.UpdateURL(ctx, short, url)
+}
+
+func (r *fakeRepository) SetCreator(ctx context.Context, short string, creatorReference string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	url, ok := r.live(short)
+	if !ok {
+		return store.ErrURLNotFound
+	}
+	url.CreatorReference = creatorReference
+	return nil
+}
+
+func (r *fakeRepository) TransferCreator(ctx context.Context, from string, to string) ([]*models.URL, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var urls []*models.URL
+	for short, url := range r.urls {
+		if url.CreatorReference != from || url.DeletedAt != nil {
+			continue
+		}
+		url.CreatorReference = to
+		copied := *r.urls[short]
+		urls = append(urls, &copied)
+	}
+	return urls, nil
+}
+
+func (r *fakeRepository) LogURLHistory(ctx context.Context, urlID int64, short string, action string, oldValue, newValue interface{}, modifiedBy string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	oldValueJSON, err := json.Marshal(oldValue)
+	if err != nil {
+		return err
+	}
+	newValueJSON, err := json.Marshal(newValue)
+	if err != nil {
+		return err
+	}
+	r.history = append(r.history, &models.URLHistory{
+		ID:         int64(len(r.history) + 1),
+		URLID:      urlID,
+		URLShort:   short,
+		Action:     action,
+		OldValue:   oldValueJSON,
+		NewValue:   newValueJSON,
+		ModifiedAt: time.Now(),
+		ModifiedBy: modifiedBy,
+	})
+	return nil
+}
+
+func (r *fakeRepository) GetURLHistory(ctx context.Context, short string, filter store.HistoryFilter) ([]*models.URLHistory, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var matching []*models.URLHistory
+	for i := len(r.history) - 1; i >= 0; i-- {
+		entry := r.history[i]
+		if entry.URLShort == short && (filter.Action == "" || entry.Action == filter.Action) {
+			matching = append(matching, entry)
+		}
+	}
+	total := int64(len(matching))
+	if filter.Offset >= len(matching) {
+		return nil, total, nil
+	}
+	matching = matching[filter.Offset:]
+	if len(matching) > filter.Limit {
+		matching = matching[:filter.Limit]
+	}
+	return matching, total, nil
+}
+
+// count returns the number of stored URLs, including soft-deleted ones
+func (r *fakeRepository) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.urls)
+}
+
+func (r *fakeRepository) GetCreatorDefaults(ctx context.Context, creatorReference string) (*models.CreatorDefaults, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	defaults, ok := r.creatorDefaults[creatorReference]
+	if !ok {
+		return nil, store.ErrCreatorDefaultsNotFound
+	}
+	copied := *defaults
+	return &copied, nil
+}
+
+func (r *fakeRepository) SetCreatorDefaults(ctx context.Context, defaults *models.CreatorDefaults) (*models.CreatorDefaults, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := *defaults
+	r.creatorDefaults[defaults.CreatorReference] = &stored
+	copied := stored
+	return &copied, nil
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/fransfilastap/urlshortener/metrics"
+	"github.com/fransfilastap/urlshortener/store"
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/rs/zerolog/log"
+)
+
+// HealthResponse reports whether the server can serve requests, with the state of each dependency
+type HealthResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+// ReadyResponse reports the health of the server along with the state of its connection pools
+type ReadyResponse struct {
+	HealthResponse
+	store.ServiceStats
+}
+
+// Live handles liveness probes, which only confirm that the process is up
+func (h *URLHandler) Live(c echo.Context) error {
+	return c.JSON(http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// Health handles readiness probes, pinging the database and cache. It responds 503 if either can't
+// be reached.
+func (h *URLHandler) Health(c echo.Context) error {
+	response, status := h.health(c)
+	return c.JSON(status, response)
+}
+
+// Ready is Health with the database and cache connection pool stats added
+func (h *URLHandler) Ready(c echo.Context) error {
+	response, status := h.health(c)
+	stats := h.service.Stats()
+	log.Debug().Interface("stats", stats).Msg("Readiness check")
+	return c.JSON(status, ReadyResponse{HealthResponse: response, ServiceStats: stats})
+}
+
+// health checks the dependencies and returns the response body and status code
+func (h *URLHandler) health(c echo.Context) (HealthResponse, int) {
+	report := h.service.CheckHealth(c.Request().Context())
+	if !report.Healthy {
+		return HealthResponse{Status: store.HealthUnavailable, Checks: report.Dependencies}, http.StatusServiceUnavailable
+	}
+	return HealthResponse{Status: store.HealthOK, Checks: report.Dependencies}, http.StatusOK
+}
+
+// Metrics exposes the request, redirect and lookup metrics of the metrics package along with the
+// connection pool stats, dropped clicks and the scaled code length in the Prometheus text format
+func (h *URLHandler) Metrics(c echo.Context) error {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(&handlerCollector{h: h})
+	promhttp.HandlerFor(prometheus.Gatherers{metrics.Registry, registry}, promhttp.HandlerOpts{}).
+		ServeHTTP(c.Response(), c.Request())
+	return nil
+}
+
+// poolMetrics lists the exported pool metrics with their type
+var poolMetrics = []struct {
+	desc  *prometheus.Desc
+	kind  prometheus.ValueType
+	value func(*store.PoolStats) int64
+}{
+	{poolDesc("urlshortener_pool_total_conns", "Open connections in the pool."), prometheus.GaugeValue, func(s *store.PoolStats) int64 { return s.TotalConns }},
+	{poolDesc("urlshortener_pool_acquired_conns", "Connections currently in use."), prometheus.GaugeValue, func(s *store.PoolStats) int64 { return s.AcquiredConns }},
+	{poolDesc("urlshortener_pool_idle_conns", "Idle connections in the pool."), prometheus.GaugeValue, func(s *store.PoolStats) int64 { return s.IdleConns }},
+	{poolDesc("urlshortener_pool_max_conns", "Maximum connections in the pool."), prometheus.GaugeValue, func(s *store.PoolStats) int64 { return s.MaxConns }},
+	{poolDesc("urlshortener_pool_empty_acquires_total", "Acquisitions that found no idle connection."), prometheus.CounterValue, func(s *store.PoolStats) int64 { return s.EmptyAcquires }},
+	{poolDesc("urlshortener_pool_timeouts_total", "Acquisitions that gave up before getting a connection."), prometheus.CounterValue, func(s *store.PoolStats) int64 { return s.Timeouts }},
+}
+
+func poolDesc(name, help string) *prometheus.Desc {
+	return prometheus.NewDesc(name, help, []string{"pool"}, nil)
+}
+
+var (
+	droppedClicksDesc = prometheus.NewDesc("urlshortener_dropped_clicks_total", "Clicks dropped because the click queue was full.", nil, nil)
+	codeLengthDesc    = prometheus.NewDesc("urlshortener_code_length", "Length of generated random codes.", nil, nil)
+)
+
+// handlerCollector collects the pool stats, dropped clicks and code length of a URLHandler when scraped
+type handlerCollector struct {
+	h *URLHandler
+}
+
+func (c *handlerCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, metric := range poolMetrics {
+		ch <- metric.desc
+	}
+	ch <- droppedClicksDesc
+	ch <- codeLengthDesc
+}
+
+// Collect reports every pool metric labeled by pool, skipping pools without stats, and the code length
+// only once it has been scaled
+func (c *handlerCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.h.service.Stats()
+	pools := []struct {
+		name  string
+		stats *store.PoolStats
+	}{{"database", stats.Database}, {"cache", stats.Cache}}
+	for _, metric := range poolMetrics {
+		for _, pool := range pools {
+			if pool.stats != nil {
+				ch <- prometheus.MustNewConstMetric(metric.desc, metric.kind, float64(metric.value(pool.stats)), pool.name)
+			}
+		}
+	}
+	ch <- prometheus.MustNewConstMetric(droppedClicksDesc, prometheus.CounterValue, float64(c.h.droppedClicks.Load()))
+	if length := c.h.service.CodeLength(); length > 0 {
+		ch <- prometheus.MustNewConstMetric(codeLengthDesc, prometheus.GaugeValue, float64(length))
+	}
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fransfilastap/urlshortener/store"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestHealthCheck tests the health check endpoint
+func TestHealthCheck(t *testing.T) {
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/health", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	// Create a simple handler for the health check
+	handler := func(c echo.Context) error {
+		return c.JSON(http.StatusOK, map[string]string{"status": "ok"})
+	}
+
+	// Call the handler
+	if assert.NoError(t, handler(c)) {
+		// Assertions
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var response map[string]string
+		err := json.Unmarshal(rec.Body.Bytes(), &response)
+		assert.NoError(t, err)
+		assert.Equal(t, "ok", response["status"])
+	}
+}
+
+// TestReadyAndMetrics tests the readiness and metrics endpoints
+func TestReadyAndMetrics(t *testing.T) {
+	e := newRealTestServer(newFakeRepository(), newTestConfig())
+
+	t.Run("Ready", func(t *testing.T) {
+		rec := serveRequest(e, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var response ReadyResponse
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		assert.Equal(t, "ok", response.Status)
+		if assert.NotNil(t, response.Database) {
+			assert.Equal(t, int64(1), response.Database.AcquiredConns)
+			assert.Equal(t, int64(10), response.Database.MaxConns)
+		}
+		assert.Nil(t, response.Cache)
+	})
+
+	t.Run("Health", func(t *testing.T) {
+		rec := serveRequest(e, httptest.NewRequest(http.MethodGet, "/health", nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var response HealthResponse
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		assert.Equal(t, "ok", response.Status)
+		assert.Equal(t, map[string]string{"database": "ok"}, response.Checks)
+	})
+
+	t.Run("DatabaseDown", func(t *testing.T) {
+		repo := newFakeRepository()
+		repo.pingErr = assert.AnError
+		e := newRealTestServer(repo, newTestConfig())
+
+		for _, path := range []string{"/health", "/health/ready"} {
+			rec := serveRequest(e, httptest.NewRequest(http.MethodGet, path, nil))
+			assert.Equal(t, http.StatusServiceUnavailable, rec.Code, path)
+
+			var response HealthResponse
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response), path)
+			assert.Equal(t, "unavailable", response.Status, path)
+			assert.Equal(t, "unavailable", response.Checks["database"], path)
+		}
+
+		// Liveness doesn't depend on the database
+		rec := serveRequest(e, httptest.NewRequest(http.MethodGet, "/livez", nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("Metrics", func(t *testing.T) {
+		rec := serveRequest(e, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Header().Get(echo.HeaderContentType), "text/plain")
+		assert.Contains(t, rec.Body.String(), `urlshortener_pool_acquired_conns{pool="database"} 1`)
+		assert.Contains(t, rec.Body.String(), `urlshortener_pool_idle_conns{pool="database"} 3`)
+		assert.NotContains(t, rec.Body.String(), `pool="cache"`)
+		assert.Contains(t, rec.Body.String(), "urlshortener_dropped_clicks_total 0")
+		assert.NotContains(t, rec.Body.String(), "urlshortener_code_length")
+	})
+
+	t.Run("MetricsCodeLength", func(t *testing.T) {
+		scaler, err := store.NewCodeLengthScaler(store.Base62Alphabet, 6, 0.01)
+		assert.NoError(t, err)
+		e := echo.New()
+		NewURLHandler(store.NewURLService(newFakeRepository(), nil, store.WithCodeLengthScaling(scaler)), newTestConfig()).Register(e)
+
+		rec := serveRequest(e, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		assert.Contains(t, rec.Body.String(), "urlshortener_code_length 6")
+	})
+}
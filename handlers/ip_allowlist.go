@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// ParseCIDRs parses a list of CIDRs. Bare IP addresses are accepted as single-address ranges.
+func ParseCIDRs(values []string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(values))
+	for _, value := range values {
+		if !strings.Contains(value, "/") {
+			ip := net.ParseIP(value)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid IP address: %q", value)
+			}
+			bits := 8 * net.IPv4len
+			if ip.To4() == nil {
+				bits = 8 * net.IPv6len
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR: %w", err)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+// newIPExtractor returns an IP extractor that honors X-Forwarded-For only when the request arrives from
+// one of the trusted proxies, and otherwise uses the address of the direct peer
+func newIPExtractor(trustedProxies []*net.IPNet) echo.IPExtractor {
+	options := []echo.TrustOption{
+		echo.TrustLoopback(false),
+		echo.TrustLinkLocal(false),
+		echo.TrustPrivateNet(false),
+	}
+	for _, proxy := range trustedProxies {
+		options = append(options, echo.TrustIPRange(proxy))
+	}
+	return echo.ExtractIPFromXFFHeader(options...)
+}
+
+// ipAllowlist checks request source IPs against allowed CIDRs
+type ipAllowlist struct {
+	allowed   []*net.IPNet
+	extractIP echo.IPExtractor
+	err       error
+}
+
+// newIPAllowlist parses an allowlist. X-Forwarded-For is only honored when the request arrives from
+// one of the trusted proxies.
+func newIPAllowlist(allowedCIDRs, trustedProxies []string) *ipAllowlist {
+	allowed, err := ParseCIDRs(allowedCIDRs)
+	var proxies []*net.IPNet
+	if err == nil {
+		proxies, err = ParseCIDRs(trustedProxies)
+	}
+	return &ipAllowlist{allowed: allowed, extractIP: newIPExtractor(proxies), err: err}
+}
+
+// Allows reports whether the request's source IP is allowed and returns the IP. An empty allowlist
+// allows every source; an invalid one allows none.
+func (l *ipAllowlist) Allows(r *http.Request) (net.IP, bool) {
+	ip := net.ParseIP(l.extractIP(r))
+	if l.err != nil {
+		return ip, false
+	}
+	if len(l.allowed) == 0 {
+		return ip, true
+	}
+	for _, network := range l.allowed {
+		if ip != nil && network.Contains(ip) {
+			return ip, true
+		}
+	}
+	return ip, false
+}
+
+// IPAllowlistMiddleware creates a middleware that rejects requests from source IPs outside the
+// allowed CIDRs with 403. X-Forwarded-For is only honored when the request arrives from one of the
+// trusted proxies. An empty allowlist allows every source; an invalid one rejects every request.
+func IPAllowlistMiddleware(allowedCIDRs, trustedProxies []string) echo.MiddlewareFunc {
+	allowlist := newIPAllowlist(allowedCIDRs, trustedProxies)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if allowlist.err != nil {
+				log.Error().Err(allowlist.err).Msg("Invalid IP allowlist configuration, rejecting request")
+				return c.JSON(http.StatusForbidden, map[string]string{
+					"error": "Forbidden",
+				})
+			}
+
+			// Check if the source IP is in an allowed network
+			ip, ok := allowlist.Allows(c.Request())
+			if ok {
+				return next(c)
+			}
+
+			log.Warn().Str("ip", ip.String()).Str("path", c.Path()).Msg("Request from non-allowlisted IP rejected")
+			return c.JSON(http.StatusForbidden, map[string]string{
+				"error": "Forbidden",
+			})
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestAdminIPAllowlist tests that admin routes check the source IP before the admin key
+func TestAdminIPAllowlist(t *testing.T) {
+	repo := newFakeRepository()
+	_, err := repo.Create(context.Background(), models.NewURL("https://example.com", "abc123", "", time.Time{}, "test-user"))
+	assert.NoError(t, err)
+
+	cfg := newTestConfig()
+	cfg.AdminAllowedCIDRs = []string{"203.0.113.0/24"}
+	e := newRealTestServer(repo, cfg)
+
+	serve := func(remoteAddr, adminKey string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/urls/abc123/raw", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("X-Admin-Key", adminKey)
+		rec := serveRequest(e, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusOK, serve("203.0.113.5:1234", "test-admin-key"))
+	assert.Equal(t, http.StatusForbidden, serve("192.0.2.1:1234", "test-admin-key"))
+	// The allowlist is checked before authentication
+	assert.Equal(t, http.StatusForbidden, serve("192.0.2.1:1234", "wrong-key"))
+	assert.Equal(t, http.StatusUnauthorized, serve("203.0.113.5:1234", "wrong-key"))
+}
+
+func TestIPAllowlistMiddleware(t *testing.T) {
+	// Setup
+	e := echo.New()
+	handler := func(c echo.Context) error {
+		return c.String(http.StatusOK, "success")
+	}
+	middlewareHandler := IPAllowlistMiddleware([]string{"203.0.113.0/24", "198.51.100.7"}, []string{"10.0.0.1"})(handler)
+
+	serve := func(remoteAddr, forwardedFor string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remoteAddr
+		if forwardedFor != "" {
+			req.Header.Set(echo.HeaderXForwardedFor, forwardedFor)
+		}
+		rec := httptest.NewRecorder()
+		assert.NoError(t, middlewareHandler(e.NewContext(req, rec)))
+		return rec
+	}
+
+	// Test case 1: Allowed source IPs pass
+	t.Run("AllowedIP", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve("203.0.113.42:1234", "").Code)
+		assert.Equal(t, http.StatusOK, serve("198.51.100.7:1234", "").Code)
+	})
+
+	// Test case 2: Other source IPs are rejected
+	t.Run("DisallowedIP", func(t *testing.T) {
+		rec := serve("192.0.2.1:1234", "")
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		assert.Contains(t, rec.Body.String(), "Forbidden")
+	})
+
+	// Test case 3: X-Forwarded-For is honored only from trusted proxies
+	t.Run("ForwardedFor", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve("10.0.0.1:1234", "203.0.113.42").Code)
+		assert.Equal(t, http.StatusForbidden, serve("10.0.0.1:1234", "192.0.2.1").Code)
+		assert.Equal(t, http.StatusForbidden, serve("192.0.2.1:1234", "203.0.113.42").Code)
+	})
+
+	// Test case 4: An empty allowlist allows every source, an invalid one allows none
+	t.Run("Configuration", func(t *testing.T) {
+		open := IPAllowlistMiddleware(nil, nil)(handler)
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		rec := httptest.NewRecorder()
+		assert.NoError(t, open(e.NewContext(req, rec)))
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		invalid := IPAllowlistMiddleware([]string{"not-a-cidr"}, nil)(handler)
+		req = httptest.NewRequest(http.MethodGet, "/", nil)
+		rec = httptest.NewRecorder()
+		assert.NoError(t, invalid(e.NewContext(req, rec)))
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+
+		_, err := ParseCIDRs([]string{"10.0.0.0/8", "::1", "bogus/33"})
+		assert.Error(t, err)
+	})
+}
@@ -24,4 +24,25 @@ func APIKeyMiddleware(apiKey string) echo.MiddlewareFunc {
 			return next(c)
 		}
 	}
-}
\ No newline at end of file
+}
+
+// AdminKeyMiddleware creates a middleware that checks for a valid admin API key.
+// Admin endpoints are always rejected when no admin key is configured.
+func AdminKeyMiddleware(adminKey string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			// Get admin key from header
+			key := c.Request().Header.Get("X-Admin-Key")
+
+			// Check if admin key is configured and valid
+			if adminKey == "" || key != adminKey {
+				return c.JSON(http.StatusUnauthorized, map[string]string{
+					"error": "Invalid or missing admin key",
+				})
+			}
+
+			// Admin key is valid, continue
+			return next(c)
+		}
+	}
+}
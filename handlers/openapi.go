@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/fransfilastap/urlshortener/docs"
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/fransfilastap/urlshortener/store"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// apiKeyScheme is the name of the X-API-Key security scheme in the OpenAPI document
+const apiKeyScheme = "apiKey"
+
+// openAPISpec describes the API routes served from baseURL
+func openAPISpec(baseURL string) *docs.Spec {
+	spec := docs.New(docs.Info{
+		Title:       "URL Shortener API",
+		Version:     "1.0.0",
+		Description: "Create and manage short links and read their click analytics.",
+	}, docs.Server{URL: baseURL})
+	spec.Components.SecuritySchemes[apiKeyScheme] = docs.SecurityScheme{
+		Type: "apiKey",
+		In:   "header",
+		Name: "X-API-Key",
+	}
+	apiKey := []map[string][]string{{apiKeyScheme: {}}}
+
+	jsonContent := func(schema *docs.Schema) map[string]docs.MediaType {
+		return map[string]docs.MediaType{echo.MIMEApplicationJSON: {Schema: schema}}
+	}
+	body := func(v any) *docs.RequestBody {
+		return &docs.RequestBody{Required: true, Content: jsonContent(spec.Ref(v))}
+	}
+	ok := func(description string, v any) docs.Response {
+		return docs.Response{Description: description, Content: jsonContent(spec.Ref(v))}
+	}
+	failure := func(description string) docs.Response {
+		return docs.Response{Description: description, Content: jsonContent(spec.Error())}
+	}
+	query := func(name, description string, schema *docs.Schema) docs.Parameter {
+		return docs.Parameter{Name: name, In: "query", Description: description, Schema: schema}
+	}
+	str := &docs.Schema{Type: "string"}
+	integer := &docs.Schema{Type: "integer"}
+
+	spec.Add(http.MethodPost, "/api/shorten", docs.Operation{
+		Summary:     "Create a short URL",
+		Description: "With reuse_existing, the creator's existing live link for the URL is returned with 200 instead.",
+		Tags:        []string{"urls"},
+		Parameters: []docs.Parameter{
+			query("verify", "Check that the destination responds first, VERIFY_DESTINATIONS by default", &docs.Schema{Type: "boolean"}),
+		},
+		RequestBody: body(ShortenRequest{}),
+		Responses: map[string]docs.Response{
+			"201": ok("Short URL created", ShortenResponse{}),
+			"200": ok("Existing short URL reused", ShortenResponse{}),
+			"400": failure("Invalid request"),
+			"401": failure("Missing or invalid API key"),
+			"409": failure("Custom code already in use"),
+			"422": failure("The destination is not reachable"),
+			"503": failure("No short code could be generated, retry after the Retry-After header"),
+		},
+		Security: apiKey,
+	})
+	spec.Add(http.MethodGet, "/api/urls/:code", docs.Operation{
+		Summary: "Get a short URL",
+		Tags:    []string{"urls"},
+		Responses: map[string]docs.Response{
+			"200": ok("Short URL", URLResponse{}),
+			"401": failure("Missing or invalid API key"),
+			"404": failure("No URL has the code"),
+			"410": failure("The URL expired"),
+		},
+		Security: apiKey,
+	})
+	spec.Add(http.MethodPut, "/api/urls/:code", docs.Operation{
+		Summary:     "Update a short URL",
+		Description: "Only the given fields change. creator_reference must match the URL's creator.",
+		Tags:        []string{"urls"},
+		RequestBody: body(UpdateURLRequest{}),
+		Responses: map[string]docs.Response{
+			"200": ok("Updated short URL", URLResponse{}),
+			"400": failure("Invalid request"),
+			"401": failure("Missing or invalid API key, or creator reference does not match"),
+			"404": failure("No URL has the code"),
+		},
+		Security: apiKey,
+	})
+	spec.Add(http.MethodDelete, "/api/urls/:code", docs.Operation{
+		Summary:     "Delete a short URL",
+		Description: "Requests with the X-Admin-Key header may delete any URL without creator_reference.",
+		Tags:        []string{"urls"},
+		Parameters:  []docs.Parameter{query("creator_reference", "Creator of the URL", str)},
+		Responses: map[string]docs.Response{
+			"200": ok("Short URL deleted", MessageResponse{}),
+			"400": failure("Missing creator reference"),
+			"401": failure("Missing or invalid API key, or creator reference does not match"),
+			"404": failure("No URL has the code"),
+		},
+		Security: apiKey,
+	})
+	spec.Add(http.MethodGet, "/api/urls/:code/analytics", docs.Operation{
+		Summary:     "Get click analytics of a short URL",
+		Description: "Without from and to, analytics cover the configured analytics window.",
+		Tags:        []string{"analytics"},
+		Parameters: []docs.Parameter{
+			query("from", "Start of the range (inclusive), RFC 3339 timestamp or date", &docs.Schema{Type: "string", Format: "date-time"}),
+			query("to", "End of the range (exclusive), RFC 3339 timestamp or date", &docs.Schema{Type: "string", Format: "date-time"}),
+			query("granularity", "Adds a time series in buckets of this size", &docs.Schema{
+				Type: "string",
+				Enum: []string{string(store.IntervalMinute), string(store.IntervalHour), string(store.IntervalDay), string(store.IntervalWeek)},
+			}),
+			query("tz", "IANA time zone of the time series buckets, UTC by default", str),
+		},
+		Responses: map[string]docs.Response{
+			"200": ok("Click analytics", URLAnalyticsResponse{}),
+			"400": failure("Invalid range, granularity or time zone"),
+			"401": failure("Missing or invalid API key"),
+			"404": failure("No URL has the code"),
+		},
+		Security: apiKey,
+	})
+	spec.Add(http.MethodGet, "/api/urls/:code/analytics/distribution", docs.Operation{
+		Summary:     "Get clicks of a short URL by hour of day and day of week",
+		Description: "hour_of_day counts from midnight (0) to 23:00, day_of_week from Sunday (0) to Saturday, on the wall clock of tz.",
+		Tags:        []string{"analytics"},
+		Parameters: []docs.Parameter{
+			query("from", "Start of the range (inclusive), RFC 3339 timestamp or date", &docs.Schema{Type: "string", Format: "date-time"}),
+			query("to", "End of the range (exclusive), RFC 3339 timestamp or date", &docs.Schema{Type: "string", Format: "date-time"}),
+			query("tz", "IANA time zone of the hours and days, UTC by default", str),
+		},
+		Responses: map[string]docs.Response{
+			"200": ok("Clicks by hour of day and day of week", models.ClickDistribution{}),
+			"400": failure("Invalid range or time zone"),
+			"401": failure("Missing or invalid API key"),
+			"404": failure("No URL has the code"),
+		},
+		Security: apiKey,
+	})
+	spec.Add(http.MethodGet, "/api/urls/search", docs.Operation{
+		Summary:     "Search short URLs",
+		Description: "Matches live URLs whose original URL or title has words starting with every word of q, best matches first. Without creator_reference, every creator's URLs are searched, which requires the X-Admin-Key header.",
+		Tags:        []string{"urls"},
+		Parameters: []docs.Parameter{
+			query("q", "Words to search for; a query without words matches nothing", str),
+			query("creator_reference", "Only search this creator's URLs", str),
+			query("limit", "Page size, 20 by default", integer),
+			query("offset", "URLs to skip", integer),
+		},
+		Responses: map[string]docs.Response{
+			"200": ok("Page of matching short URLs", URLSearchResponse{}),
+			"400": failure("Invalid paging"),
+			"401": failure("Missing or invalid API key"),
+			"403": failure("Searching all creators without admin access"),
+		},
+		Security: apiKey,
+	})
+	spec.Add(http.MethodGet, "/api/urls/creator/:creator_reference", docs.Operation{
+		Summary: "List a creator's short URLs",
+		Tags:    []string{"creators"},
+		Parameters: []docs.Parameter{
+			query("sort", "Column to order by", &docs.Schema{Type: "string", Enum: []string{store.SortByCreatedAt, store.SortByClicks}}),
+			query("order", "Sort order, desc by default", &docs.Schema{Type: "string", Enum: []string{"asc", "desc"}}),
+			query("limit", "Page size, 20 by default", integer),
+			query("offset", "URLs to skip", integer),
+		},
+		Responses: map[string]docs.Response{
+			"200": ok("Page of short URLs", CreatorURLsResponse{}),
+			"400": failure("Invalid paging or sort"),
+			"401": failure("Missing or invalid API key"),
+		},
+		Security: apiKey,
+	})
+	spec.Add(http.MethodGet, "/api/urls/creator/:creator_reference/count", docs.Operation{
+		Summary: "Count a creator's short URLs",
+		Tags:    []string{"creators"},
+		Responses: map[string]docs.Response{
+			"200": ok("Number of live short URLs", CreatorURLCountResponse{}),
+			"401": failure("Missing or invalid API key"),
+		},
+		Security: apiKey,
+	})
+
+	return spec
+}
+
+// OpenAPI returns the OpenAPI 3 document of the API
+func (h *URLHandler) OpenAPI(c echo.Context) error {
+	log.Debug().Msg("Serving OpenAPI document")
+
+	spec := openAPISpec(h.cfg.BaseURL)
+
+	log.Info().Int("paths", len(spec.Paths)).Msg("OpenAPI document served")
+
+	return c.JSON(http.StatusOK, spec)
+}
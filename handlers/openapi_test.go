@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fransfilastap/urlshortener/docs"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestOpenAPI tests that the OpenAPI document describes the routes from the handler structs
+func TestOpenAPI(t *testing.T) {
+	e := newRealTestServer(newFakeRepository(), newTestConfig())
+
+	// The document is public, like the probes
+	req := httptest.NewRequest(http.MethodGet, "/openapi.json", nil)
+	rec := serveRequest(e, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var spec docs.Spec
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &spec))
+	assert.Equal(t, docs.OpenAPIVersion, spec.OpenAPI)
+	for _, path := range []string{"/api/shorten", "/api/urls/{code}", "/api/urls/{code}/analytics", "/api/urls/creator/{creator_reference}"} {
+		assert.Contains(t, spec.Paths, path)
+	}
+	assert.Len(t, spec.Paths["/api/urls/{code}"], 3)
+	assert.Equal(t, docs.SecurityScheme{Type: "apiKey", In: "header", Name: "X-API-Key"}, spec.Components.SecuritySchemes["apiKey"])
+
+	shorten := spec.Paths["/api/shorten"]["post"]
+	assert.Equal(t, "#/components/schemas/ShortenRequest", shorten.RequestBody.Content[echo.MIMEApplicationJSON].Schema.Ref)
+	assert.Equal(t, "#/components/schemas/Error", shorten.Responses["409"].Content[echo.MIMEApplicationJSON].Schema.Ref)
+	assert.Equal(t, []string{"url"}, spec.Components.Schemas["ShortenRequest"].Required)
+	// Embedded fields are promoted into the schema
+	assert.Contains(t, spec.Components.Schemas["ShortenResponse"].Properties, "short_code")
+	assert.Contains(t, spec.Components.Schemas["ShortenResponse"].Properties, "created")
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// setPaginationLinks sets an RFC 5988 Link header with first, prev, next and last pages of a
+// limit/offset paginated listing. The links keep the request's other query parameters.
+func setPaginationLinks(c echo.Context, limit, offset int, total int64) {
+	if limit <= 0 {
+		return
+	}
+
+	lastOffset := 0
+	if total > 0 {
+		lastOffset = int((total - 1) / int64(limit) * int64(limit))
+	}
+
+	links := []string{paginationLink(c, limit, 0, "first")}
+	if offset > 0 {
+		links = append(links, paginationLink(c, limit, max(offset-limit, 0), "prev"))
+	}
+	if int64(offset+limit) < total {
+		links = append(links, paginationLink(c, limit, offset+limit, "next"))
+	}
+	links = append(links, paginationLink(c, limit, lastOffset, "last"))
+
+	c.Response().Header().Set("Link", strings.Join(links, ", "))
+}
+
+// paginationLink formats a single Link header entry for the page at offset
+func paginationLink(c echo.Context, limit, offset int, rel string) string {
+	query := c.Request().URL.Query()
+	query.Set("limit", strconv.Itoa(limit))
+	query.Set("offset", strconv.Itoa(offset))
+	return fmt.Sprintf(`<%s?%s>; rel="%s"`, c.Request().URL.Path, query.Encode(), rel)
+}
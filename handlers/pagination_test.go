@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestHistoryPaginationLinks tests the Link header on the first, middle and last history pages
+func TestHistoryPaginationLinks(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeRepository()
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, repo.LogURLHistory(ctx, 1, "abc123", "update", nil, nil, "test-user"))
+	}
+	e := newRealTestServer(repo, newTestConfig())
+
+	link := func(query string) string {
+		rec := serveAPI(e, http.MethodGet, "/api/urls/abc123/history"+query, "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		return rec.Header().Get("Link")
+	}
+	page := func(offset int, rel string) string {
+		return `</api/urls/abc123/history?action=update&limit=2&offset=` + strconv.Itoa(offset) + `>; rel="` + rel + `"`
+	}
+
+	t.Run("FirstPage", func(t *testing.T) {
+		assert.Equal(t, page(0, "first")+", "+page(2, "next")+", "+page(4, "last"), link("?action=update&limit=2"))
+	})
+
+	t.Run("MiddlePage", func(t *testing.T) {
+		assert.Equal(t, page(0, "first")+", "+page(0, "prev")+", "+page(4, "next")+", "+page(4, "last"), link("?action=update&limit=2&offset=2"))
+	})
+
+	t.Run("LastPage", func(t *testing.T) {
+		assert.Equal(t, page(0, "first")+", "+page(2, "prev")+", "+page(4, "last"), link("?action=update&limit=2&offset=4"))
+	})
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+	"github.com/skip2/go-qrcode"
+)
+
+// QR code dimensions in pixels
+const (
+	defaultQRSize = 256
+	minQRSize     = 64
+	maxQRSize     = 2048
+)
+
+// qrCacheControl lets clients cache QR codes for a day, as the encoded short URL never changes
+const qrCacheControl = "public, max-age=86400"
+
+// GetURLQRCode returns a QR code encoding the full short URL as a PNG, or as an SVG with format=svg
+func (h *URLHandler) GetURLQRCode(c echo.Context) error {
+	code := c.Param("code")
+	if code == "" {
+		log.Error().Msg("Missing URL code in QR code request")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Missing URL code"})
+	}
+
+	format := c.QueryParam("format")
+	switch format {
+	case "":
+		format = "png"
+	case "png", "svg":
+	default:
+		log.Error().Str("format", format).Msg("Invalid format in QR code request")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid format"})
+	}
+
+	size, err := queryInt(c, "size", defaultQRSize)
+	if err != nil || size < minQRSize || size > maxQRSize {
+		log.Error().Str("size", c.QueryParam("size")).Msg("Invalid size in QR code request")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("Invalid size, must be between %d and %d", minQRSize, maxQRSize)})
+	}
+
+	log.Debug().Str("code", code).Str("format", format).Int("size", size).Msg("Rendering QR code")
+
+	// Get URL to verify it exists and hasn't expired
+	url, err := h.service.GetByShort(c.Request().Context(), code)
+	if err != nil {
+		log.Error().Err(err).Str("code", code).Msg("Failed to retrieve URL for QR code request")
+		return err
+	}
+
+	shortURL := h.shortURL(url.Short)
+	qr, err := qrcode.New(shortURL, qrcode.Medium)
+	if err != nil {
+		log.Error().Err(err).Str("code", code).Msg("Failed to encode QR code")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to render QR code"})
+	}
+
+	var body []byte
+	contentType := "image/png"
+	if format == "svg" {
+		body = []byte(renderQRSVG(qr.Bitmap(), size))
+		contentType = "image/svg+xml"
+	} else {
+		body, err = qr.PNG(size)
+		if err != nil {
+			log.Error().Err(err).Str("code", code).Msg("Failed to render QR code")
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to render QR code"})
+		}
+	}
+
+	log.Info().
+		Str("code", code).
+		Str("short_url", shortURL).
+		Str("format", format).
+		Int("bytes", len(body)).
+		Msg("QR code rendered")
+
+	c.Response().Header().Set(echo.HeaderCacheControl, qrCacheControl)
+	return c.Blob(http.StatusOK, contentType, body)
+}
+
+// renderQRSVG draws a QR code bitmap as a size×size SVG, merging each row's dark modules into runs
+func renderQRSVG(bitmap [][]bool, size int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		size, size, len(bitmap), len(bitmap))
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="#fff"/><path fill="#000" d="`, len(bitmap), len(bitmap))
+	for y, row := range bitmap {
+		for x := 0; x < len(row); x++ {
+			if !row[x] {
+				continue
+			}
+			start := x
+			for x < len(row) && row[x] {
+				x++
+			}
+			fmt.Fprintf(&b, "M%d %dh%dv1h-%dz", start, y, x-start, x-start)
+		}
+	}
+	b.WriteString(`"/></svg>`)
+	return b.String()
+}
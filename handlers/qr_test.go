@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestGetURLQRCode tests rendering a short URL as a PNG or SVG QR code
+func TestGetURLQRCode(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeRepository()
+	_, err := repo.Create(ctx, models.NewURL("https://example.com", "abc123", "", time.Time{}, "test-user"))
+	assert.NoError(t, err)
+	_, err = repo.Create(ctx, models.NewURL("https://example.com/old", "expired", "", time.Now().Add(-time.Hour), "test-user"))
+	assert.NoError(t, err)
+	e := newRealTestServer(repo, newTestConfig())
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		return serveAPI(e, http.MethodGet, path, "")
+	}
+
+	t.Run("PNG", func(t *testing.T) {
+		rec := serve("/api/urls/abc123/qr?size=128")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "image/png", rec.Header().Get(echo.HeaderContentType))
+		assert.NotEmpty(t, rec.Header().Get(echo.HeaderCacheControl))
+		img, err := png.Decode(bytes.NewReader(rec.Body.Bytes()))
+		assert.NoError(t, err)
+		assert.Equal(t, 128, img.Bounds().Dx())
+		assert.Equal(t, 128, img.Bounds().Dy())
+	})
+
+	t.Run("SVG", func(t *testing.T) {
+		rec := serve("/api/urls/abc123/qr?format=svg")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "image/svg+xml", rec.Header().Get(echo.HeaderContentType))
+		assert.Regexp(t, "^<svg ", rec.Body.String())
+		assert.Contains(t, rec.Body.String(), `width="256"`)
+	})
+
+	t.Run("InvalidParameters", func(t *testing.T) {
+		for _, query := range []string{"?size=10", "?size=100000", "?size=abc", "?format=gif"} {
+			assert.Equal(t, http.StatusBadRequest, serve("/api/urls/abc123/qr"+query).Code, query)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, serve("/api/urls/missing/qr").Code)
+		assert.Equal(t, http.StatusGone, serve("/api/urls/expired/qr").Code)
+	})
+}
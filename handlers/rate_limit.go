@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/fransfilastap/urlshortener/store"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// errTooManyRequests is returned when a client exceeds its request rate limit
+var errTooManyRequests = store.NewAPIError(http.StatusTooManyRequests, "too_many_requests", "Too many requests, slow down")
+
+// RateLimiter limits how often a client may make requests
+type RateLimiter interface {
+	// Allow counts a request for key and reports whether it is within the limit, and if not, how
+	// long until the next request would be
+	Allow(ctx context.Context, key string) (bool, time.Duration, error)
+}
+
+// RateLimitMiddleware creates a middleware that limits requests per client IP with limiter, rejecting
+// requests over the limit with 429 and a Retry-After header. Requests are allowed if the limiter fails.
+func RateLimitMiddleware(limiter RateLimiter) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if err := checkRateLimit(c, limiter); err != nil {
+				return err
+			}
+			return next(c)
+		}
+	}
+}
+
+// limitShorten counts one link created by the client against the shorten rate limit, if any. Every
+// link counts, whether it is created by POST /api/shorten, a batch, a CSV import or an RPC call.
+func (h *URLHandler) limitShorten(c echo.Context) error {
+	if h.shortenLimiter == nil {
+		return nil
+	}
+	return checkRateLimit(c, h.shortenLimiter)
+}
+
+// checkRateLimit counts a request of the client IP with limiter and returns errTooManyRequests, setting
+// Retry-After, if it is over the limit. The request is allowed if the limiter fails.
+func checkRateLimit(c echo.Context, limiter RateLimiter) error {
+	ip := c.RealIP()
+	allowed, retryAfter, err := limiter.Allow(c.Request().Context(), ip)
+	if err != nil {
+		log.Warn().Err(err).Str("ip", ip).Msg("Failed to check rate limit, allowing request")
+		return nil
+	}
+	if !allowed {
+		log.Warn().Str("ip", ip).Str("path", c.Path()).Dur("retry_after", retryAfter).Msg("Request rate limit exceeded")
+		seconds := int(math.Max(1, math.Ceil(retryAfter.Seconds())))
+		c.Response().Header().Set("Retry-After", strconv.Itoa(seconds))
+		return errTooManyRequests
+	}
+	return nil
+}
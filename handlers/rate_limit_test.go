@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fransfilastap/urlshortener/store"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeRateLimiter allows limit requests per key, or fails every check when err is set
+type fakeRateLimiter struct {
+	mu     sync.Mutex
+	limit  int
+	counts map[string]int
+	err    error
+}
+
+func (l *fakeRateLimiter) Allow(ctx context.Context, key string) (bool, time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.err != nil {
+		return false, 0, l.err
+	}
+	if l.counts == nil {
+		l.counts = make(map[string]int)
+	}
+	l.counts[key]++
+	return l.counts[key] <= l.limit, 1500 * time.Millisecond, nil
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	e := echo.New()
+	handler := func(c echo.Context) error {
+		return c.String(http.StatusOK, "success")
+	}
+
+	serve := func(middlewareHandler echo.HandlerFunc, remoteAddr string) (*httptest.ResponseRecorder, error) {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		err := middlewareHandler(e.NewContext(req, rec))
+		return rec, err
+	}
+
+	// Test case 1: Requests over the limit are rejected per IP with Retry-After
+	t.Run("LimitsPerIP", func(t *testing.T) {
+		limited := RateLimitMiddleware(&fakeRateLimiter{limit: 2})(handler)
+		for i := 0; i < 2; i++ {
+			rec, err := serve(limited, "203.0.113.1:1234")
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusOK, rec.Code)
+		}
+
+		rec, err := serve(limited, "203.0.113.1:1234")
+		assert.ErrorIs(t, err, errTooManyRequests)
+		assert.Equal(t, "2", rec.Header().Get("Retry-After"))
+
+		rec, err = serve(limited, "203.0.113.2:1234")
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	// Test case 2: Requests are allowed when the limiter fails
+	t.Run("LimiterFailure", func(t *testing.T) {
+		failing := RateLimitMiddleware(&fakeRateLimiter{err: errors.New("connection refused")})(handler)
+		rec, err := serve(failing, "203.0.113.1:1234")
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	// Test case 3: POST /api/shorten is limited, other routes aren't
+	t.Run("ShortenRoute", func(t *testing.T) {
+		e := echo.New()
+		h := NewURLHandler(store.NewURLService(newFakeRepository(), nil), newTestConfig())
+		h.SetShortenRateLimiter(&fakeRateLimiter{limit: 1})
+		h.Register(e)
+
+		shorten := func() *httptest.ResponseRecorder {
+			return serveAPI(e, http.MethodPost, "/api/shorten", `{"url": "https://example.com"}`)
+		}
+		assert.Equal(t, http.StatusCreated, shorten().Code)
+		rec := shorten()
+		assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+		assert.Equal(t, "2", rec.Header().Get("Retry-After"))
+		assert.Contains(t, rec.Body.String(), "too_many_requests")
+
+		req := httptest.NewRequest(http.MethodGet, "/health/ready", nil)
+		rec = httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		assert.NotEqual(t, http.StatusTooManyRequests, rec.Code)
+	})
+
+	// Test case 4: Every item of a batch or CSV import counts as a link
+	t.Run("BatchAndImportItems", func(t *testing.T) {
+		e := echo.New()
+		h := NewURLHandler(store.NewURLService(newFakeRepository(), nil), newTestConfig())
+		h.SetShortenRateLimiter(&fakeRateLimiter{limit: 3})
+		h.Register(e)
+
+		rec := serveAPI(e, http.MethodPost, "/api/shorten/batch", `[{"url": "https://example.com/1"}, {"url": "https://example.com/2"}]`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var results []BatchShortenResult
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &results))
+		if assert.Len(t, results, 2) {
+			assert.Empty(t, results[0].Code)
+			assert.Empty(t, results[1].Code)
+		}
+
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		part, err := form.CreateFormFile("file", "urls.csv")
+		assert.NoError(t, err)
+		_, err = part.Write([]byte("https://example.com/3\nhttps://example.com/4\n"))
+		assert.NoError(t, err)
+		assert.NoError(t, form.Close())
+		req := httptest.NewRequest(http.MethodPost, "/api/import", &body)
+		req.Header.Set(echo.HeaderContentType, form.FormDataContentType())
+		req.Header.Set("X-API-Key", "test-api-key")
+		rec = serveRequest(e, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var response ImportResponse
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		assert.Equal(t, 1, response.Imported)
+		if assert.Len(t, response.Results, 2) {
+			assert.Equal(t, "too_many_requests", response.Results[1].Code)
+		}
+
+		rec = serveAPI(e, http.MethodPost, "/api/shorten/batch", `[{"url": "https://example.com/5"}]`)
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &results))
+		if assert.Len(t, results, 1) {
+			assert.Equal(t, "too_many_requests", results[0].Code)
+		}
+	})
+
+	// Test case 5: Forwarded client IPs are only honored from trusted proxies
+	t.Run("ForwardedFor", func(t *testing.T) {
+		e := echo.New()
+		cfg := newTestConfig()
+		cfg.TrustedProxies = []string{"10.0.0.1"}
+		h := NewURLHandler(store.NewURLService(newFakeRepository(), nil), cfg)
+		h.SetShortenRateLimiter(&fakeRateLimiter{limit: 1})
+		h.Register(e)
+
+		shorten := func(remoteAddr, forwardedFor string) *httptest.ResponseRecorder {
+			req := newAPIRequest(http.MethodPost, "/api/shorten", `{"url": "https://example.com"}`)
+			req.RemoteAddr = remoteAddr
+			req.Header.Set(echo.HeaderXForwardedFor, forwardedFor)
+			req.Header.Set(echo.HeaderXRealIP, forwardedFor)
+			return serveRequest(e, req)
+		}
+
+		// A client can't dodge the limit by making up forwarded addresses
+		assert.Equal(t, http.StatusCreated, shorten("203.0.113.1:1234", "198.51.100.1").Code)
+		assert.Equal(t, http.StatusTooManyRequests, shorten("203.0.113.1:1234", "198.51.100.2").Code)
+
+		// Clients behind a trusted proxy are limited separately
+		assert.Equal(t, http.StatusCreated, shorten("10.0.0.1:1234", "198.51.100.3").Code)
+		assert.Equal(t, http.StatusCreated, shorten("10.0.0.1:1234", "198.51.100.4").Code)
+		assert.Equal(t, http.StatusTooManyRequests, shorten("10.0.0.1:1234", "198.51.100.4").Code)
+	})
+}
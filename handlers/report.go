@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/fransfilastap/urlshortener/store"
+	"github.com/go-pdf/fpdf"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// reportTopEntries is the number of browsers, devices and locations listed in a report
+const reportTopEntries = 5
+
+// reportBreakdowns are the analytics breakdowns listed in a report, in order
+var reportBreakdowns = []struct {
+	key   string
+	title string
+}{
+	{"browsers", "Top browsers"},
+	{"devices", "Top devices"},
+	{"locations", "Top locations"},
+}
+
+// reportEntry is one row of a report breakdown
+type reportEntry struct {
+	name   string
+	clicks int64
+}
+
+// topEntries returns the n entries of an analytics breakdown with the most clicks, ties sorted by name
+func topEntries(counts map[string]int64, n int) []reportEntry {
+	entries := make([]reportEntry, 0, len(counts))
+	for name, clicks := range counts {
+		entries = append(entries, reportEntry{name: name, clicks: clicks})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].clicks != entries[j].clicks {
+			return entries[i].clicks > entries[j].clicks
+		}
+		return entries[i].name < entries[j].name
+	})
+	if len(entries) > n {
+		entries = entries[:n]
+	}
+	return entries
+}
+
+// renderReportPDF renders a one-page analytics report of a URL: its totals, top browsers, devices and
+// locations, and the clicks over time chart
+func renderReportPDF(url *models.URL, shortURL string, analytics map[string]interface{}, chartPNG []byte) ([]byte, error) {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	// The core fonts only cover Latin-1, so text is translated from UTF-8
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.SetTitle("Link report for /"+url.Short, true)
+	pdf.AddPage()
+
+	pageWidth, _ := pdf.GetPageSize()
+	left, _, right, _ := pdf.GetMargins()
+	width := pageWidth - left - right
+
+	pdf.SetFont("Helvetica", "B", 18)
+	pdf.CellFormat(width, 10, tr("Link report for /"+url.Short), "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.CellFormat(width, 6, tr("Generated "+time.Now().UTC().Format(time.RFC1123)), "", 1, "L", false, 0, "")
+	pdf.Ln(4)
+
+	// Totals
+	totalClicks, _ := analytics["total_clicks"].(int64)
+	rows := [][2]string{
+		{"Short URL", shortURL},
+		{"Destination", url.Original},
+		{"Title", url.Title},
+		{"Created", url.CreatedAt.UTC().Format(time.RFC3339)},
+		{"Total clicks", strconv.FormatInt(url.Clicks, 10)},
+		{"Clicks in range", strconv.FormatInt(totalClicks, 10)},
+	}
+	if from, ok := analytics["from"].(time.Time); ok {
+		rows = append(rows, [2]string{"Since", from.Format(time.RFC3339)})
+	}
+	for _, row := range rows {
+		pdf.SetFont("Helvetica", "B", 10)
+		pdf.CellFormat(40, 6, tr(row[0]), "", 0, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "", 10)
+		pdf.CellFormat(width-40, 6, tr(row[1]), "", 1, "L", false, 0, "")
+	}
+
+	// Breakdowns, side by side
+	pdf.Ln(4)
+	columnWidth := width / float64(len(reportBreakdowns))
+	pdf.SetFont("Helvetica", "B", 12)
+	for _, breakdown := range reportBreakdowns {
+		pdf.CellFormat(columnWidth, 8, breakdown.title, "B", 0, "L", false, 0, "")
+	}
+	pdf.Ln(-1)
+	pdf.SetFont("Helvetica", "", 10)
+	columns := make([][]reportEntry, len(reportBreakdowns))
+	for i, breakdown := range reportBreakdowns {
+		counts, _ := analytics[breakdown.key].(map[string]int64)
+		columns[i] = topEntries(counts, reportTopEntries)
+	}
+	for row := 0; row < reportTopEntries; row++ {
+		for _, entries := range columns {
+			if row >= len(entries) {
+				pdf.CellFormat(columnWidth, 6, "", "", 0, "L", false, 0, "")
+				continue
+			}
+			entry := entries[row]
+			pdf.CellFormat(columnWidth-15, 6, tr(entry.name), "", 0, "L", false, 0, "")
+			pdf.CellFormat(15, 6, strconv.FormatInt(entry.clicks, 10), "", 0, "R", false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+
+	// Clicks over time
+	pdf.Ln(6)
+	pdf.RegisterImageOptionsReader("clicks", fpdf.ImageOptions{ImageType: "PNG"}, bytes.NewReader(chartPNG))
+	pdf.ImageOptions("clicks", left, pdf.GetY(), width, width*chartHeight/chartWidth, true, fpdf.ImageOptions{ImageType: "PNG"}, 0, "")
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GetURLReport returns a PDF report of a URL's analytics: its totals, top browsers, devices and
+// locations, and its clicks over the last days (30 by default)
+func (h *URLHandler) GetURLReport(c echo.Context) error {
+	code := c.Param("code")
+	if code == "" {
+		log.Error().Msg("Missing URL code in report request")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Missing URL code"})
+	}
+
+	days, err := queryInt(c, "days", defaultSeriesDays)
+	if err != nil || days == 0 || days > maxSeriesDays {
+		log.Error().Str("days", c.QueryParam("days")).Msg("Invalid days in report request")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid days"})
+	}
+
+	log.Debug().Str("code", code).Int("days", days).Msg("Rendering URL report")
+
+	ctx := c.Request().Context()
+	url, err := h.service.GetByShort(ctx, code)
+	if err != nil {
+		log.Error().Err(err).Str("code", code).Msg("Failed to retrieve URL for report request")
+		return err
+	}
+
+	analytics, err := h.service.GetClickAnalytics(ctx, code, store.ClickRange{})
+	if err != nil {
+		log.Error().Err(err).Str("code", code).Msg("Failed to retrieve analytics data")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve analytics data"})
+	}
+	series, err := h.service.GetClickTimeSeries(ctx, code, days)
+	if err != nil {
+		log.Error().Err(err).Str("code", code).Msg("Failed to retrieve click time series")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve analytics data"})
+	}
+
+	chartPNG, err := renderClicksChart(fmt.Sprintf("Clicks over the last %d days", days), series)
+	if err != nil {
+		log.Error().Err(err).Str("code", code).Msg("Failed to render analytics chart")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to render report"})
+	}
+	pdf, err := renderReportPDF(url, h.shortURL(url.Short), analytics, chartPNG)
+	if err != nil {
+		log.Error().Err(err).Str("code", code).Msg("Failed to render report")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to render report"})
+	}
+
+	log.Info().
+		Str("code", code).
+		Int("days", days).
+		Int("bytes", len(pdf)).
+		Msg("URL report rendered")
+
+	c.Response().Header().Set(echo.HeaderContentDisposition,
+		mime.FormatMediaType("inline", map[string]string{"filename": code + "-report.pdf"}))
+	return c.Blob(http.StatusOK, "application/pdf", pdf)
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestGetURLReport tests rendering a URL's analytics as a PDF report
+func TestGetURLReport(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeRepository()
+	_, err := repo.Create(ctx, models.NewURL("https://example.com", "abc123", "Quarterly Launch", time.Time{}, "test-user"))
+	assert.NoError(t, err)
+	for i, browser := range []string{"Chrome", "Chrome", "Firefox"} {
+		click := models.NewClick(1, "abc123", "127.0.0.1", "Zürich", browser, "Desktop")
+		click.Timestamp = time.Now().AddDate(0, 0, -i)
+		assert.NoError(t, repo.StoreClick(ctx, click))
+	}
+	e := newRealTestServer(repo, newTestConfig())
+
+	get := func(path string) *httptest.ResponseRecorder {
+		return serveAPI(e, http.MethodGet, path, "")
+	}
+
+	t.Run("ValidPDF", func(t *testing.T) {
+		rec := get("/api/urls/abc123/report.pdf")
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "application/pdf", rec.Header().Get(echo.HeaderContentType))
+		assert.Contains(t, rec.Header().Get(echo.HeaderContentDisposition), `filename=abc123-report.pdf`)
+		body := rec.Body.Bytes()
+		assert.Greater(t, len(body), 1000)
+		assert.True(t, bytes.HasPrefix(body, []byte("%PDF-")))
+		assert.True(t, bytes.HasSuffix(bytes.TrimSpace(body), []byte("%%EOF")))
+	})
+
+	t.Run("InvalidDays", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, get("/api/urls/abc123/report.pdf?days=0").Code)
+	})
+
+	t.Run("URLNotFound", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, get("/api/urls/missing/report.pdf").Code)
+	})
+}
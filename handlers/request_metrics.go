@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/fransfilastap/urlshortener/store"
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// RequestMetricsMiddleware creates a middleware that counts requests in requests by response status
+// code and, when duration is not nil, observes how long they took in seconds
+func RequestMetricsMiddleware(requests *prometheus.CounterVec, duration prometheus.Observer) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+			err := next(c)
+			if duration != nil {
+				duration.Observe(time.Since(start).Seconds())
+			}
+			requests.WithLabelValues(strconv.Itoa(responseStatus(c, err))).Inc()
+			return err
+		}
+	}
+}
+
+// responseStatus returns the status code ErrorHandler will answer with for err, or the status already
+// written when the handler succeeded
+func responseStatus(c echo.Context, err error) int {
+	if err == nil {
+		return c.Response().Status
+	}
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.Code
+	}
+	var apiErr *store.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Status
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRequestMetrics tests that shorten requests and redirects are counted by status and that
+// redirects are timed
+func TestRequestMetrics(t *testing.T) {
+	e := newRealTestServer(newFakeRepository(), newTestConfig())
+
+	scrape := func(series string) float64 {
+		rec := serveRequest(e, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		scanner := bufio.NewScanner(rec.Body)
+		for scanner.Scan() {
+			if value, ok := strings.CutPrefix(scanner.Text(), series+" "); ok {
+				f, err := strconv.ParseFloat(value, 64)
+				assert.NoError(t, err)
+				return f
+			}
+		}
+		return 0
+	}
+
+	body, _ := json.Marshal(ShortenRequest{URL: "https://example.com/metrics", CustomCode: "measured"})
+	req := newAPIRequest(http.MethodPost, "/api/shorten", string(body))
+	shortenSeries := `urlshortener_shorten_requests_total{status="201"}`
+	shortens := scrape(shortenSeries)
+	rec := serveRequest(e, req)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, shortens+1, scrape(shortenSeries))
+
+	found := scrape(`urlshortener_redirects_total{status="302"}`)
+	missing := scrape(`urlshortener_redirects_total{status="404"}`)
+	timed := scrape("urlshortener_redirect_duration_seconds_count")
+	rec = serveRequest(e, httptest.NewRequest(http.MethodGet, "/measured", nil))
+	assert.Equal(t, http.StatusFound, rec.Code)
+	rec = serveRequest(e, httptest.NewRequest(http.MethodGet, "/unmeasured", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, found+1, scrape(`urlshortener_redirects_total{status="302"}`))
+	assert.Equal(t, missing+1, scrape(`urlshortener_redirects_total{status="404"}`))
+	assert.Equal(t, timed+2, scrape("urlshortener_redirect_duration_seconds_count"))
+}
@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/fransfilastap/urlshortener/metrics"
+	"github.com/fransfilastap/urlshortener/store"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// Errors reported for RPC operations that can't be dispatched
+var (
+	errRPCMethodNotFound = store.NewAPIError(http.StatusNotFound, "method_not_found", "Unknown method")
+	errRPCInvalidParams  = store.NewAPIError(http.StatusBadRequest, "invalid_params", "Invalid params")
+)
+
+// RPCRequest is one operation of a POST /api/rpc call
+type RPCRequest struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// RPCError describes why an RPC operation failed
+type RPCError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// RPCResponse is the outcome of one RPC operation, carrying the id of its request
+type RPCResponse struct {
+	ID     json.RawMessage `json:"id"`
+	Result interface{}     `json:"result,omitempty"`
+	Error  *RPCError       `json:"error,omitempty"`
+}
+
+// RPCCodeParams are the params of the resolve and delete methods
+type RPCCodeParams struct {
+	Code             string `json:"code"`
+	CreatorReference string `json:"creator_reference,omitempty"`
+}
+
+// rpcMethod runs an RPC operation with its raw params
+type rpcMethod func(h *URLHandler, c echo.Context, params json.RawMessage) (interface{}, error)
+
+// rpcMethods are the operations POST /api/rpc dispatches to the service
+var rpcMethods = map[string]rpcMethod{
+	"shorten": (*URLHandler).rpcShorten,
+	"resolve": (*URLHandler).rpcResolve,
+	"delete":  (*URLHandler).rpcDelete,
+}
+
+// RPC handles a JSON-RPC-style batch of up to store.MaxBatchSize operations over a single endpoint.
+// Operations run in order and fail on their own; each response carries the id of its request.
+func (h *URLHandler) RPC(c echo.Context) error {
+	var reqs []RPCRequest
+	if err := c.Bind(&reqs); err != nil {
+		log.Error().Err(err).Msg("Invalid request format for RPC")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request"})
+	}
+
+	log.Debug().Int("operations", len(reqs)).Msg("Running RPC operations")
+
+	if len(reqs) > store.MaxBatchSize {
+		log.Error().Int("operations", len(reqs)).Int("max", store.MaxBatchSize).Msg("Too many RPC operations")
+		return store.ErrBatchTooLarge
+	}
+
+	responses := make([]RPCResponse, len(reqs))
+	var failed int
+	for i, req := range reqs {
+		responses[i].ID = req.ID
+		if len(req.ID) == 0 {
+			responses[i].ID = json.RawMessage("null")
+		}
+
+		var result interface{}
+		var err error = errRPCMethodNotFound
+		if method, ok := rpcMethods[req.Method]; ok {
+			result, err = method(h, c, req.Params)
+		}
+		if err != nil {
+			log.Debug().Err(err).Str("method", req.Method).Int("index", i).Msg("RPC operation failed")
+			apiErr := batchItemError(err)
+			responses[i].Error = &RPCError{Code: apiErr.Code, Message: apiErr.Message}
+			failed++
+			continue
+		}
+		responses[i].Result = result
+	}
+
+	log.Info().
+		Int("operations", len(reqs)).
+		Int("failed", failed).
+		Msg("RPC operations completed")
+
+	return c.JSON(http.StatusOK, responses)
+}
+
+// rpcShorten creates a short URL from ShortenRequest params, like POST /api/shorten. Operations are
+// rate limited, have their destination verified and are counted in the shorten metrics the same way as
+// POST /api/shorten requests.
+func (h *URLHandler) rpcShorten(c echo.Context, params json.RawMessage) (result interface{}, err error) {
+	status := http.StatusCreated
+	defer func() {
+		if err != nil {
+			status = batchItemError(err).Status
+		}
+		metrics.ShortenRequests.WithLabelValues(strconv.Itoa(status)).Inc()
+	}()
+	if err := h.limitShorten(c); err != nil {
+		return nil, err
+	}
+
+	ctx := c.Request().Context()
+	var req ShortenRequest
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, errRPCInvalidParams
+	}
+	switch {
+	case req.RateLimit < 0:
+		return nil, errBatchInvalidRateLimit
+	case req.Clicks != 0:
+		return nil, errBatchClicks
+	}
+	if err := h.verifyDestination(c, req.URL); err != nil {
+		return nil, err
+	}
+
+	expiry := req.Expiry * time.Second
+	opts := shortenOptions(req)
+	if req.ReuseExisting {
+		url, reused, err := h.service.CreateOrReuseShortURL(ctx, req.URL, req.CustomCode, req.Title, expiry, req.CreatorReference, opts...)
+		if err != nil {
+			return nil, err
+		}
+		if reused {
+			status = http.StatusOK
+		}
+		return ShortenResponse{URLResponse: h.toURLResponse(url), Created: !reused}, nil
+	}
+	url, err := h.service.CreateShortURL(ctx, req.URL, req.CustomCode, req.Title, expiry, req.CreatorReference, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return ShortenResponse{URLResponse: h.toURLResponse(url), Created: true}, nil
+}
+
+// rpcResolve looks up a live short URL by code without counting a click
+func (h *URLHandler) rpcResolve(c echo.Context, params json.RawMessage) (interface{}, error) {
+	var req RPCCodeParams
+	if err := json.Unmarshal(params, &req); err != nil || req.Code == "" {
+		return nil, errRPCInvalidParams
+	}
+	url, err := h.service.GetByShort(c.Request().Context(), req.Code)
+	if err != nil {
+		return nil, err
+	}
+	return h.toURLResponse(url), nil
+}
+
+// rpcDelete deletes a short URL owned by the creator_reference param
+func (h *URLHandler) rpcDelete(c echo.Context, params json.RawMessage) (interface{}, error) {
+	var req RPCCodeParams
+	if err := json.Unmarshal(params, &req); err != nil || req.Code == "" || req.CreatorReference == "" {
+		return nil, errRPCInvalidParams
+	}
+	if err := h.service.DeleteWithCreator(c.Request().Context(), req.Code, req.CreatorReference); err != nil {
+		if errors.Is(err, store.ErrCreatorMismatch) {
+			log.Warn().Str("code", req.Code).Str("creator_reference", req.CreatorReference).Msg("Unauthorized RPC delete attempt")
+		}
+		return nil, err
+	}
+	return map[string]string{"message": "URL deleted successfully"}, nil
+}
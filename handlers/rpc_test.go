@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fransfilastap/urlshortener/metrics"
+	"github.com/fransfilastap/urlshortener/store"
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRPC tests running several operations in one call to the RPC endpoint
+func TestRPC(t *testing.T) {
+	repo := newFakeRepository()
+	e := newRealTestServer(repo, newTestConfig())
+
+	post := func(body string) (*httptest.ResponseRecorder, []map[string]json.RawMessage) {
+		rec := serveAPI(e, http.MethodPost, "/api/rpc", body)
+		var responses []map[string]json.RawMessage
+		if rec.Code == http.StatusOK {
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &responses))
+		}
+		return rec, responses
+	}
+
+	t.Run("ShortenAndResolve", func(t *testing.T) {
+		rec, responses := post(`[
+			{"id": 1, "method": "shorten", "params": {"url": "https://example.com/rpc", "custom_code": "rpc-link", "creator_reference": "rpc-user"}},
+			{"id": "two", "method": "resolve", "params": {"code": "rpc-link"}}
+		]`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		if assert.Len(t, responses, 2) {
+			assert.JSONEq(t, `1`, string(responses[0]["id"]))
+			var shortened ShortenResponse
+			assert.NoError(t, json.Unmarshal(responses[0]["result"], &shortened))
+			assert.True(t, shortened.Created)
+			assert.Equal(t, "rpc-link", shortened.ShortCode)
+
+			assert.JSONEq(t, `"two"`, string(responses[1]["id"]))
+			var resolved URLResponse
+			assert.NoError(t, json.Unmarshal(responses[1]["result"], &resolved))
+			assert.Equal(t, "https://example.com/rpc", resolved.OriginalURL)
+			assert.Equal(t, int64(0), resolved.Clicks)
+		}
+	})
+
+	t.Run("OperationsFailOnTheirOwn", func(t *testing.T) {
+		rec, responses := post(`[
+			{"id": 1, "method": "explode"},
+			{"id": 2, "method": "resolve", "params": {"code": "missing"}},
+			{"id": 3, "method": "delete", "params": {"code": "rpc-link", "creator_reference": "someone-else"}},
+			{"id": 4, "method": "delete", "params": {"code": "rpc-link", "creator_reference": "rpc-user"}},
+			{"method": "resolve", "params": {}}
+		]`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		if assert.Len(t, responses, 5) {
+			errorCode := func(i int) string {
+				var rpcErr RPCError
+				assert.NoError(t, json.Unmarshal(responses[i]["error"], &rpcErr))
+				return rpcErr.Code
+			}
+			assert.Equal(t, "method_not_found", errorCode(0))
+			assert.Equal(t, "url_not_found", errorCode(1))
+			assert.Equal(t, "creator_mismatch", errorCode(2))
+			assert.NotContains(t, responses[3], "error")
+			assert.JSONEq(t, `null`, string(responses[4]["id"]))
+			assert.Equal(t, "invalid_params", errorCode(4))
+		}
+
+		_, err := repo.GetByShort(context.Background(), "rpc-link")
+		assert.ErrorIs(t, err, store.ErrURLNotFound)
+	})
+
+	t.Run("ShortenIsLimitedAndCounted", func(t *testing.T) {
+		e := echo.New()
+		h := NewURLHandler(store.NewURLService(newFakeRepository(), nil), newTestConfig())
+		h.SetShortenRateLimiter(&fakeRateLimiter{limit: 1})
+		h.Register(e)
+
+		created := testutil.ToFloat64(metrics.ShortenRequests.WithLabelValues("201"))
+		limited := testutil.ToFloat64(metrics.ShortenRequests.WithLabelValues("429"))
+
+		rec := serveAPI(e, http.MethodPost, "/api/rpc", `[
+			{"id": 1, "method": "shorten", "params": {"url": "https://example.com/one"}},
+			{"id": 2, "method": "shorten", "params": {"url": "https://example.com/two"}}
+		]`)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var responses []RPCResponse
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &responses))
+		if assert.Len(t, responses, 2) {
+			assert.Nil(t, responses[0].Error)
+			if assert.NotNil(t, responses[1].Error) {
+				assert.Equal(t, "too_many_requests", responses[1].Error.Code)
+			}
+		}
+		assert.Equal(t, created+1, testutil.ToFloat64(metrics.ShortenRequests.WithLabelValues("201")))
+		assert.Equal(t, limited+1, testutil.ToFloat64(metrics.ShortenRequests.WithLabelValues("429")))
+	})
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSignedCodes tests that only signed short URLs resolve when signed codes are enabled
+func TestSignedCodes(t *testing.T) {
+	repo := newFakeRepository()
+	cfg := newTestConfig()
+	cfg.SignedCodesEnabled = true
+	cfg.SignedCodesSecret = "test-secret"
+	e := newRealTestServer(repo, cfg)
+
+	body, _ := json.Marshal(ShortenRequest{URL: "https://example.com", CustomCode: "abc123", CreatorReference: "test-user"})
+	rec := serveAPI(e, http.MethodPost, "/api/shorten", string(body))
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	var response URLResponse
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	signedPath := response.ShortURL[len("http://localhost:8080"):]
+	assert.Len(t, signedPath, len("/abc123")+codeSignatureLength)
+
+	redirect := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		return serveRequest(e, req)
+	}
+
+	t.Run("SignedLinkResolves", func(t *testing.T) {
+		rec := redirect(signedPath)
+		assert.Equal(t, http.StatusFound, rec.Code)
+		assert.Equal(t, "https://example.com", rec.Header().Get("Location"))
+	})
+
+	t.Run("UnsignedCodeIsNotFound", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, redirect("/abc123").Code)
+	})
+
+	t.Run("GuessedSignatureIsNotFound", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, redirect("/abc123AAAAAA").Code)
+	})
+
+	t.Run("OtherSecretDoesNotVerify", func(t *testing.T) {
+		forged := newCodeSigner("other-secret").Sign("abc123")
+		assert.Equal(t, http.StatusNotFound, redirect("/"+forged).Code)
+	})
+}
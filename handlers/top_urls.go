@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// defaultTopLimit is how many links GET /api/urls/top returns without a limit
+const defaultTopLimit = 10
+
+// GetTopURLs handles leaderboard requests for the most clicked live links of a creator, or of every
+// creator for admins
+func (h *URLHandler) GetTopURLs(c echo.Context) error {
+	creatorReference := c.QueryParam("creator")
+
+	limit, err := queryInt(c, "limit", defaultTopLimit)
+	if err != nil || limit == 0 || limit > maxPageLimit {
+		log.Error().Str("limit", c.QueryParam("limit")).Msg("Invalid limit in top URLs request")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid limit"})
+	}
+
+	log.Debug().Str("creator_reference", creatorReference).Int("limit", limit).Msg("Getting top URLs")
+
+	// Every creator's links are only visible to admins
+	if creatorReference == "" && !h.isAdmin(c) {
+		log.Warn().Msg("Global top URLs requested without admin access")
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "Top URLs across all creators require admin access"})
+	}
+
+	urls, err := h.service.GetTopURLs(c.Request().Context(), creatorReference, limit)
+	if err != nil {
+		log.Error().Err(err).Str("creator_reference", creatorReference).Msg("Failed to retrieve top URLs")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve top URLs"})
+	}
+
+	response := make([]URLResponse, 0, len(urls))
+	for _, url := range urls {
+		response = append(response, h.toURLResponse(url))
+	}
+
+	log.Info().Str("creator_reference", creatorReference).Int("count", len(response)).Msg("Top URLs retrieved")
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"urls":    response,
+		"creator": creatorReference,
+		"limit":   limit,
+	})
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestGetTopURLs tests the leaderboard of most clicked links
+func TestGetTopURLs(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeRepository()
+	e := newRealTestServer(repo, newTestConfig())
+
+	for i, clicks := range []int64{5, 50, 0, 20} {
+		url := models.NewURL("https://example.com", "top"+strconv.Itoa(i), "", time.Time{}, "leader")
+		url.Clicks = clicks
+		_, err := repo.Create(ctx, url)
+		assert.NoError(t, err)
+	}
+	other := models.NewURL("https://example.com", "top-other", "", time.Time{}, "someone-else")
+	other.Clicks = 100
+	_, err := repo.Create(ctx, other)
+	assert.NoError(t, err)
+
+	type topResponse struct {
+		URLs  []URLResponse `json:"urls"`
+		Limit int           `json:"limit"`
+	}
+	get := func(query, adminKey string) (*httptest.ResponseRecorder, topResponse) {
+		req := newAPIRequest(http.MethodGet, "/api/urls/top"+query, "")
+		if adminKey != "" {
+			req.Header.Set("X-Admin-Key", adminKey)
+		}
+		rec := serveRequest(e, req)
+		var response topResponse
+		if rec.Code == http.StatusOK {
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		}
+		return rec, response
+	}
+	codes := func(urls []URLResponse) []string {
+		var codes []string
+		for _, url := range urls {
+			codes = append(codes, url.ShortCode)
+		}
+		return codes
+	}
+
+	t.Run("PerCreator", func(t *testing.T) {
+		rec, response := get("?creator=leader&limit=3", "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, 3, response.Limit)
+		assert.Equal(t, []string{"top1", "top3", "top0"}, codes(response.URLs))
+		for i := 1; i < len(response.URLs); i++ {
+			assert.GreaterOrEqual(t, response.URLs[i-1].Clicks, response.URLs[i].Clicks)
+		}
+	})
+
+	t.Run("GlobalRequiresAdmin", func(t *testing.T) {
+		rec, _ := get("", "")
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+
+		rec, response := get("?limit=2", "test-admin-key")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, []string{"top-other", "top1"}, codes(response.URLs))
+	})
+
+	t.Run("InvalidLimit", func(t *testing.T) {
+		rec, _ := get("?creator=leader&limit=0", "")
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		rec, _ = get("?creator=leader&limit=101", "")
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
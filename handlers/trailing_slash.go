@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/fransfilastap/urlshortener/config"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// trailingSlashMiddleware serves paths with a trailing slash like the bare path, so /abc/ resolves
+// code abc. In redirect mode, GET and HEAD requests are redirected to the bare path instead, keeping
+// a single canonical URL; other methods are still served in place so request bodies aren't lost.
+func trailingSlashMiddleware(mode string) echo.MiddlewareFunc {
+	strip := middleware.RemoveTrailingSlash()
+	if mode != config.TrailingSlashRedirect {
+		return strip
+	}
+
+	redirect := middleware.RemoveTrailingSlashWithConfig(middleware.TrailingSlashConfig{
+		RedirectCode: http.StatusMovedPermanently,
+		Skipper: func(c echo.Context) bool {
+			method := c.Request().Method
+			return method != http.MethodGet && method != http.MethodHead
+		},
+	})
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return redirect(strip(next))
+	}
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fransfilastap/urlshortener/config"
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestTrailingSlash tests that paths with a trailing slash resolve like the bare path
+func TestTrailingSlash(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeRepository()
+	_, err := repo.Create(ctx, models.NewURL("https://example.com/slash", "abc", "", time.Time{}, "slasher"))
+	assert.NoError(t, err)
+
+	get := func(e *echo.Echo, path string) *httptest.ResponseRecorder {
+		return serveAPI(e, http.MethodGet, path, "")
+	}
+
+	t.Run("Strip", func(t *testing.T) {
+		e := newRealTestServer(repo, newTestConfig())
+
+		bare := get(e, "/abc")
+		slashed := get(e, "/abc/")
+		assert.Equal(t, http.StatusFound, bare.Code)
+		assert.Equal(t, bare.Code, slashed.Code)
+		assert.Equal(t, "https://example.com/slash", slashed.Header().Get(echo.HeaderLocation))
+
+		rec := get(e, "/api/urls/abc/")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "https://example.com/slash")
+	})
+
+	t.Run("Redirect", func(t *testing.T) {
+		cfg := newTestConfig()
+		cfg.TrailingSlash = config.TrailingSlashRedirect
+		e := newRealTestServer(repo, cfg)
+
+		rec := get(e, "/abc/")
+		assert.Equal(t, http.StatusMovedPermanently, rec.Code)
+		assert.Equal(t, "/abc", rec.Header().Get(echo.HeaderLocation))
+		assert.Equal(t, http.StatusFound, get(e, "/abc").Code)
+
+		// Other methods are served in place
+		rec = serveAPI(e, http.MethodPost, "/api/shorten/", `{"url": "https://example.com"}`)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+	})
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// Formats of a creator's URL export
+const (
+	urlExportCSV    = "csv"
+	urlExportNDJSON = "ndjson"
+)
+
+// urlWriter writes URLs to an export stream in a single format
+type urlWriter interface {
+	Write(url *models.URL) error
+	Flush() error
+}
+
+// ndjsonURLWriter writes one JSON-encoded URL per line, in the shape of the other URL responses
+type ndjsonURLWriter struct {
+	enc      *json.Encoder
+	response func(url *models.URL) URLResponse
+}
+
+func newNDJSONURLWriter(w io.Writer, response func(url *models.URL) URLResponse) *ndjsonURLWriter {
+	return &ndjsonURLWriter{enc: json.NewEncoder(w), response: response}
+}
+
+func (w *ndjsonURLWriter) Write(url *models.URL) error {
+	return w.enc.Encode(w.response(url))
+}
+
+func (w *ndjsonURLWriter) Flush() error {
+	return nil
+}
+
+// csvURLWriter writes URLs as CSV rows preceded by a header row. The first three columns match the
+// CSV import format.
+type csvURLWriter struct {
+	csv         *csv.Writer
+	shortURL    func(code string) string
+	wroteHeader bool
+}
+
+// csvURLHeader lists the CSV export columns
+var csvURLHeader = []string{"original", "short", "title", "short_url", "clicks", "created_at", "expires_at", "last_accessed_at", "enabled", "tags"}
+
+func newCSVURLWriter(w io.Writer, shortURL func(code string) string) *csvURLWriter {
+	return &csvURLWriter{csv: csv.NewWriter(w), shortURL: shortURL}
+}
+
+func (w *csvURLWriter) Write(url *models.URL) error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	return w.csv.Write([]string{
+		url.Original,
+		url.Short,
+		url.Title,
+		w.shortURL(url.Short),
+		strconv.FormatInt(url.Clicks, 10),
+		url.CreatedAt.UTC().Format(time.RFC3339),
+		formatOptionalTime(url.ExpiresAt),
+		formatOptionalTime(url.LastAccessedAt),
+		strconv.FormatBool(url.Enabled),
+		strings.Join(url.Tags, " "),
+	})
+}
+
+func (w *csvURLWriter) Flush() error {
+	// An export without URLs still carries the header
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	w.csv.Flush()
+	return w.csv.Error()
+}
+
+func (w *csvURLWriter) writeHeader() error {
+	if w.wroteHeader {
+		return nil
+	}
+	w.wroteHeader = true
+	return w.csv.Write(csvURLHeader)
+}
+
+// formatOptionalTime formats t as RFC 3339 in UTC, or as an empty string when it is nil
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// ExportCreatorURLs streams all live URLs of a creator, oldest first, as CSV (the default) or NDJSON
+// with ?format=ndjson. Rows are written as they are read from the database, so large exports aren't
+// held in memory.
+func (h *URLHandler) ExportCreatorURLs(c echo.Context) error {
+	creatorReference := c.Param("creator_reference")
+	format := c.QueryParam("format")
+	if format == "" {
+		format = urlExportCSV
+	}
+
+	log.Debug().Str("creator_reference", creatorReference).Str("format", format).Msg("Exporting creator URLs")
+
+	res := c.Response()
+	var writer urlWriter
+	switch format {
+	case urlExportCSV:
+		res.Header().Set(echo.HeaderContentType, "text/csv; charset=UTF-8")
+		writer = newCSVURLWriter(res, h.shortURL)
+	case urlExportNDJSON:
+		res.Header().Set(echo.HeaderContentType, MIMEApplicationNDJSON)
+		writer = newNDJSONURLWriter(res, h.toURLResponse)
+	default:
+		log.Error().Str("format", format).Msg("Invalid format in creator URL export request")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid format"})
+	}
+	res.Header().Set(echo.HeaderContentDisposition,
+		mime.FormatMediaType("attachment", map[string]string{"filename": creatorReference + "-urls." + format}))
+	res.WriteHeader(http.StatusOK)
+
+	// The status is already sent, so failures past this point can only be logged
+	rows := 0
+	err := h.service.StreamByCreator(c.Request().Context(), creatorReference, func(url *models.URL) error {
+		if err := writer.Write(url); err != nil {
+			return err
+		}
+		rows++
+		if rows%clickExportFlushEvery == 0 {
+			if err := writer.Flush(); err != nil {
+				return err
+			}
+			res.Flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = writer.Flush()
+	}
+	res.Flush()
+	if err != nil {
+		log.Error().Err(err).Str("creator_reference", creatorReference).Int("rows", rows).Msg("Creator URL export interrupted")
+		return nil
+	}
+
+	log.Info().Str("creator_reference", creatorReference).Str("format", format).Int("rows", rows).Msg("Creator URLs exported")
+
+	return nil
+}
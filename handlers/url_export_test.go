@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestExportCreatorURLs tests streaming a creator's URLs as CSV and NDJSON
+func TestExportCreatorURLs(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeRepository()
+	e := newRealTestServer(repo, newTestConfig())
+	for i, code := range []string{"first", "second", "third"} {
+		url := models.NewURL("https://example.com/"+code, code, "Title "+code, time.Time{}, "exporter")
+		url.CreatedAt = time.Now().Add(time.Duration(i-3) * time.Hour)
+		url.Clicks = int64(i)
+		_, err := repo.Create(ctx, url)
+		assert.NoError(t, err)
+	}
+	_, err := repo.Create(ctx, models.NewURL("https://example.com/other", "other", "", time.Time{}, "someone-else"))
+	assert.NoError(t, err)
+
+	get := func(query string) *httptest.ResponseRecorder {
+		return serveAPI(e, http.MethodGet, "/api/urls/creator/exporter/export"+query, "")
+	}
+
+	t.Run("CSVByDefault", func(t *testing.T) {
+		rec := get("")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Header().Get(echo.HeaderContentType), "text/csv")
+		assert.Equal(t, `attachment; filename=exporter-urls.csv`, rec.Header().Get(echo.HeaderContentDisposition))
+
+		records, err := csv.NewReader(rec.Body).ReadAll()
+		assert.NoError(t, err)
+		if assert.Len(t, records, 4) {
+			assert.Equal(t, []string{"original", "short", "title", "short_url", "clicks", "created_at", "expires_at", "last_accessed_at", "enabled", "tags"}, records[0])
+			assert.Equal(t, "https://example.com/first", records[1][0])
+			assert.Equal(t, "http://localhost:8080/first", records[1][3])
+			assert.Equal(t, "2", records[3][4])
+			_, err := time.Parse(time.RFC3339, records[3][5])
+			assert.NoError(t, err)
+		}
+	})
+
+	t.Run("NDJSON", func(t *testing.T) {
+		rec := get("?format=ndjson")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, MIMEApplicationNDJSON, rec.Header().Get(echo.HeaderContentType))
+		assert.Equal(t, `attachment; filename=exporter-urls.ndjson`, rec.Header().Get(echo.HeaderContentDisposition))
+
+		var urls []URLResponse
+		scanner := bufio.NewScanner(rec.Body)
+		for scanner.Scan() {
+			var url URLResponse
+			assert.NoError(t, json.Unmarshal(scanner.Bytes(), &url))
+			urls = append(urls, url)
+		}
+		assert.NoError(t, scanner.Err())
+		if assert.Len(t, urls, 3) {
+			assert.Equal(t, "first", urls[0].ShortCode)
+			assert.Equal(t, "third", urls[2].ShortCode)
+			assert.Equal(t, int64(2), urls[2].Clicks)
+			assert.False(t, urls[2].CreatedAt.IsZero())
+		}
+	})
+
+	t.Run("EmptyCSVHasHeader", func(t *testing.T) {
+		rec := serveAPI(e, http.MethodGet, "/api/urls/creator/nobody/export", "")
+
+		records, err := csv.NewReader(rec.Body).ReadAll()
+		assert.NoError(t, err)
+		assert.Len(t, records, 1)
+	})
+
+	t.Run("InvalidFormat", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, get("?format=xml").Code)
+	})
+}
@@ -2,11 +2,19 @@ package handlers
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"github.com/fransfilastap/urlshortener/config"
+	"github.com/fransfilastap/urlshortener/metrics"
 	"github.com/fransfilastap/urlshortener/models"
 	"html/template"
+	"io"
+	"math"
 	"net/http"
+	neturl "net/url"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/fransfilastap/urlshortener/store"
@@ -16,59 +24,290 @@ import (
 
 // ShortenRequest represents a request to shorten a URL
 type ShortenRequest struct {
-	URL              string        `json:"url" validate:"required,url"`
-	CustomCode       string        `json:"custom_code,omitempty"`
-	Title            string        `json:"title,omitempty"`
-	Expiry           time.Duration `json:"expiry,omitempty"` // in seconds
-	CreatorReference string        `json:"creator_reference,omitempty"`
+	URL                 string            `json:"url" validate:"required,url"`
+	CustomCode          string            `json:"custom_code,omitempty"`
+	Title               string            `json:"title,omitempty"`
+	Expiry              time.Duration     `json:"expiry,omitempty"` // in seconds
+	CreatorReference    string            `json:"creator_reference,omitempty"`
+	ReuseExisting       bool              `json:"reuse_existing,omitempty"`
+	RateLimit           int               `json:"rate_limit,omitempty"` // redirects per minute
+	RedirectHeaders     map[string]string `json:"redirect_headers,omitempty"`
+	Metadata            map[string]string `json:"metadata,omitempty"`
+	Clicks              int64             `json:"clicks,omitempty"` // initial click count, admin only
+	Tags                []string          `json:"tags,omitempty"`
+	DisabledRedirectURL string            `json:"disabled_redirect_url,omitempty"`
+	RandomTarget        bool              `json:"random_target,omitempty"` // redirect to a random link of the creator, falling back to url
+	Notes               string            `json:"notes,omitempty"`         // internal note, never shown to visitors
+	MaxClicks           int64             `json:"max_clicks,omitempty"`    // redirects before the link stops working
+	CacheTTL            int               `json:"cache_ttl,omitempty"`     // seconds the link stays cached
 }
 
 // URLResponse represents a response with URL information
 type URLResponse struct {
-	OriginalURL      string    `json:"original_url"`
-	ShortURL         string    `json:"short_url"`
-	ShortCode        string    `json:"short_code"`
-	Title            string    `json:"title,omitempty"`
-	ExpiresAt        time.Time `json:"expires_at,omitempty"`
-	CreatedAt        time.Time `json:"created_at"`
-	Clicks           int64     `json:"clicks"`
-	CreatorReference string    `json:"creator_reference,omitempty"`
+	OriginalURL         string            `json:"original_url"`
+	ShortURL            string            `json:"short_url"`
+	ShortCode           string            `json:"short_code"`
+	Title               string            `json:"title,omitempty"`
+	ExpiresAt           *time.Time        `json:"expires_at,omitempty"`
+	CreatedAt           time.Time         `json:"created_at"`
+	Clicks              int64             `json:"clicks"`
+	LastAccessedAt      *time.Time        `json:"last_accessed_at,omitempty"`
+	CreatorReference    string            `json:"creator_reference,omitempty"`
+	RateLimit           int               `json:"rate_limit,omitempty"`
+	RedirectHeaders     map[string]string `json:"redirect_headers,omitempty"`
+	Metadata            map[string]string `json:"metadata,omitempty"`
+	Enabled             bool              `json:"enabled"`
+	Tags                []string          `json:"tags,omitempty"`
+	DisabledRedirectURL string            `json:"disabled_redirect_url,omitempty"`
+	RandomTarget        bool              `json:"random_target,omitempty"` // redirects to a random link of the creator
+	Notes               string            `json:"notes,omitempty"`
+	MaxClicks           int64             `json:"max_clicks,omitempty"`
+	CacheTTL            int               `json:"cache_ttl,omitempty"`
+}
+
+// toURLResponse describes a URL in full for API responses
+func (h *URLHandler) toURLResponse(url *models.URL) URLResponse {
+	return URLResponse{
+		OriginalURL:         url.Original,
+		ShortURL:            h.shortURL(url.Short),
+		ShortCode:           url.Short,
+		Title:               url.Title,
+		ExpiresAt:           url.ExpiresAt,
+		CreatedAt:           url.CreatedAt,
+		Clicks:              url.Clicks,
+		LastAccessedAt:      url.LastAccessedAt,
+		CreatorReference:    url.CreatorReference,
+		RateLimit:           url.RateLimit,
+		RedirectHeaders:     url.RedirectHeaders,
+		Metadata:            url.Metadata,
+		Enabled:             url.Enabled,
+		Tags:                url.Tags,
+		DisabledRedirectURL: url.DisabledRedirectURL,
+		Notes:               url.Notes,
+		MaxClicks:           url.MaxClicks,
+		CacheTTL:            url.CacheTTL,
+		RandomTarget:        url.RandomTarget,
+	}
+}
+
+// ShortenResponse represents the result of shortening a URL
+type ShortenResponse struct {
+	URLResponse
+	// Created is false when reuse_existing returned an existing link
+	Created bool `json:"created"`
+}
+
+// MessageResponse confirms an action
+type MessageResponse struct {
+	Message string `json:"message"`
 }
 
 // URLHandler handles URL shortening requests
 type URLHandler struct {
-	service *store.URLService
-	baseURL string
-	apiKey  string
+	service         *store.URLService
+	baseURL         string
+	apiKey          string
+	adminKey        string
+	adminAllowlist  []string
+	trustedProxies  []string
+	selfTestEnabled bool
+	selfTestCreator string
+	clickCountMode  string
+	signer          *codeSigner
+	notFoundURL     string
+	disabledMode    string
+	disabledURL     string
+	resolveTiming   bool
+	redirectHeaders map[string]bool
+	adminIPs        *ipAllowlist
+	droppedClicks   atomic.Int64
+	shortenLimiter  RateLimiter
+	analyticsCache  ResponseCache
+	cfg             *config.Config
 }
 
 // NewURLHandler creates a new URL handler
-func NewURLHandler(service *store.URLService, baseURL string, apiKey string) *URLHandler {
-	return &URLHandler{
-		service: service,
-		baseURL: baseURL,
-		apiKey:  apiKey,
+func NewURLHandler(service *store.URLService, cfg *config.Config) *URLHandler {
+	h := &URLHandler{
+		service:         service,
+		baseURL:         cfg.BaseURL,
+		apiKey:          cfg.APIKey,
+		adminKey:        cfg.AdminAPIKey,
+		adminAllowlist:  cfg.AdminAllowedCIDRs,
+		trustedProxies:  cfg.TrustedProxies,
+		adminIPs:        newIPAllowlist(cfg.AdminAllowedCIDRs, cfg.TrustedProxies),
+		selfTestEnabled: cfg.SelfTestEnabled,
+		selfTestCreator: cfg.SelfTestCreator,
+		clickCountMode:  cfg.ClickCountMode,
+		notFoundURL:     cfg.NotFoundRedirectURL,
+		disabledMode:    cfg.DisabledResponse,
+		disabledURL:     cfg.DisabledRedirectURL,
+		resolveTiming:   cfg.ClickResolveTiming,
+		cfg:             cfg,
+		redirectHeaders: make(map[string]bool),
+	}
+	for _, name := range cfg.RedirectHeaderNames {
+		h.redirectHeaders[http.CanonicalHeaderKey(name)] = true
+	}
+	if cfg.SignedCodesEnabled {
+		h.signer = newCodeSigner(cfg.SignedCodesSecret)
+	}
+	return h
+}
+
+// shortURL returns the public short URL for a code, signed when signed codes are enabled
+func (h *URLHandler) shortURL(code string) string {
+	if h.signer != nil {
+		code = h.signer.Sign(code)
+	}
+	return h.baseURL + "/" + code
+}
+
+// publicCode resolves the code of a public short URL, verifying its signature when signed codes are
+// enabled. It reports false for unsigned or forged codes, which must be treated as unknown.
+func (h *URLHandler) publicCode(code string) (string, bool) {
+	if h.signer == nil {
+		return code, true
+	}
+	return h.signer.Verify(code)
+}
+
+// adminIdentity is recorded as the modifier in the history of URLs changed by an admin
+const adminIdentity = "admin"
+
+// isAdmin reports whether the request presents the admin API key from an allowlisted source IP,
+// granting the same access as the admin endpoints
+func (h *URLHandler) isAdmin(c echo.Context) bool {
+	key := c.Request().Header.Get("X-Admin-Key")
+	if key == "" || h.adminKey == "" || key != h.adminKey {
+		return false
+	}
+	if _, ok := h.adminIPs.Allows(c.Request()); !ok {
+		log.Warn().Str("path", c.Path()).Msg("Admin key presented from non-allowlisted IP")
+		return false
 	}
+	return true
+}
+
+// SetShortenRateLimiter limits how many links each client IP may create, through POST /api/shorten,
+// batches, CSV imports or RPC calls. It must be called before Register.
+func (h *URLHandler) SetShortenRateLimiter(limiter RateLimiter) {
+	h.shortenLimiter = limiter
+}
+
+// SetAnalyticsResponseCache caches analytics responses server-side for the configured analytics cache
+// TTL. It must be called before Register.
+func (h *URLHandler) SetAnalyticsResponseCache(cache ResponseCache) {
+	h.analyticsCache = cache
 }
 
-// Register registers the URL handler routes with Echo
+// DroppedClicks returns the number of clicks not recorded because the click queue was full
+func (h *URLHandler) DroppedClicks() int64 {
+	return h.droppedClicks.Load()
+}
+
+// Register registers the URL handler routes with Echo and installs ErrorHandler to render the errors they
+// return. Client IPs, as used for rate limits, clicks and request logs, honor X-Forwarded-For only from
+// the trusted proxies.
 func (h *URLHandler) Register(e *echo.Echo) {
+	e.HTTPErrorHandler = ErrorHandler
+	proxies, _ := ParseCIDRs(h.trustedProxies)
+	e.IPExtractor = newIPExtractor(proxies)
+	e.Pre(trailingSlashMiddleware(h.cfg.TrailingSlash))
+
 	// Public endpoint for redirecting
-	e.GET("/:code", h.RedirectURL)
+	e.GET("/:code", h.RedirectURL, RequestMetricsMiddleware(metrics.Redirects, metrics.RedirectDuration))
+	e.POST("/:code/beacon", h.Beacon)
+
+	// Public endpoints for probes and monitoring
+	e.GET("/livez", h.Live)
+	e.GET("/health", h.Health)
+	e.GET("/health/ready", h.Ready)
+	e.GET("/metrics", h.Metrics)
+	e.GET("/openapi.json", h.OpenAPI)
 
 	// Protected endpoints that require API key
 	apiGroup := e.Group("")
 	apiGroup.Use(APIKeyMiddleware(h.apiKey))
-	apiGroup.POST("/api/shorten", h.ShortenURL)
+	shortenMiddleware := []echo.MiddlewareFunc{RequestMetricsMiddleware(metrics.ShortenRequests, nil)}
+	if h.shortenLimiter != nil {
+		shortenMiddleware = append(shortenMiddleware, RateLimitMiddleware(h.shortenLimiter))
+	}
+	var analyticsMiddleware []echo.MiddlewareFunc
+	if h.cfg.AnalyticsCacheTTL > 0 {
+		analyticsMiddleware = append(analyticsMiddleware, AnalyticsCacheMiddleware(h.cfg.AnalyticsCacheTTL, h.analyticsCache))
+	}
+	apiGroup.POST("/api/shorten", h.ShortenURL, shortenMiddleware...)
+	apiGroup.POST("/api/shorten/batch", h.BatchShortenURL)
+	apiGroup.POST("/api/resolve/batch", h.ResolveBatch)
+	apiGroup.POST("/api/import", h.ImportCSV)
+	apiGroup.POST("/api/urls/suggest", h.SuggestCodes)
+	apiGroup.POST("/api/urls/tags", h.BulkTagURLs)
+	apiGroup.POST("/api/rpc", h.RPC)
+	apiGroup.GET("/api/urls/top", h.GetTopURLs)
+	apiGroup.GET("/api/urls/search", h.SearchURLs)
 	apiGroup.GET("/api/urls/:code", h.GetURLInfo)
 	apiGroup.PUT("/api/urls/:code", h.UpdateURL)
 	apiGroup.DELETE("/api/urls/:code", h.DeleteURL)
-	apiGroup.GET("/api/urls/:code/analytics", h.GetURLAnalytics)
+	apiGroup.POST("/api/urls/:code/transfer", h.TransferURL)
+	apiGroup.POST("/api/urls/:code/disable", h.DisableURL)
+	apiGroup.POST("/api/urls/:code/enable", h.EnableURL)
+	apiGroup.POST("/api/urls/:code/restore", h.RestoreURL)
+	apiGroup.GET("/api/urls/:code/analytics", h.GetURLAnalytics, analyticsMiddleware...)
+	apiGroup.GET("/api/urls/:code/analytics/chart.png", h.GetURLAnalyticsChart, analyticsMiddleware...)
+	apiGroup.GET("/api/urls/:code/report.pdf", h.GetURLReport, analyticsMiddleware...)
+	apiGroup.GET("/api/urls/:code/analytics/timeseries", h.GetURLTimeSeries, analyticsMiddleware...)
+	apiGroup.GET("/api/urls/:code/analytics/timing", h.GetURLResolveTiming, analyticsMiddleware...)
+	apiGroup.GET("/api/urls/:code/analytics/distribution", h.GetURLClickDistribution, analyticsMiddleware...)
+	apiGroup.GET("/api/urls/:code/qr", h.GetURLQRCode)
+	apiGroup.GET("/api/urls/:code/preview", h.PreviewInterstitial)
+	apiGroup.GET("/api/urls/:code/history", h.GetURLHistory)
+	apiGroup.GET("/api/urls/:code/summary", h.GetURLSummary)
+	apiGroup.GET("/api/urls/:code/clicks/export", h.ExportClicks)
 	apiGroup.GET("/api/urls/creator/:creator_reference", h.GetURLsByCreator)
+	apiGroup.GET("/api/urls/creator/:creator_reference/count", h.CountURLsByCreator)
+	apiGroup.GET("/api/urls/creator/:creator_reference/export", h.ExportCreatorURLs)
+	apiGroup.GET("/api/creators/:creator_reference/defaults", h.GetCreatorDefaults)
+	apiGroup.PUT("/api/creators/:creator_reference/defaults", h.SetCreatorDefaults)
+	apiGroup.GET("/api/creators/:creator_reference/analytics", h.GetCreatorAnalytics, analyticsMiddleware...)
+	apiGroup.POST("/api/creators/:creator_reference/disable", h.DisableCreatorURLs)
+	apiGroup.POST("/api/creators/:creator_reference/enable", h.EnableCreatorURLs)
+
+	// Admin endpoints that require an allowlisted source IP and the admin API key
+	adminGroup := e.Group("/api/admin")
+	adminGroup.Use(IPAllowlistMiddleware(h.adminAllowlist, h.trustedProxies))
+	adminGroup.Use(AdminKeyMiddleware(h.adminKey))
+	adminGroup.GET("/urls", h.ListURLs)
+	adminGroup.GET("/urls/:code/raw", h.GetRawURL)
+	adminGroup.POST("/analytics/rebuild", h.RebuildAnalytics)
+	adminGroup.POST("/urls/:code/transfer", h.AdminTransferURL)
+	adminGroup.POST("/creators/:creator_reference/transfer", h.TransferCreatorURLs)
+	adminGroup.GET("/config", h.GetConfig)
+	adminGroup.POST("/shorten", h.ImportURL)
+	adminGroup.POST("/urls/:code/clicks/import", h.ImportClicks)
+	if h.selfTestEnabled {
+		adminGroup.GET("/selftest", h.SelfTest)
+	}
 }
 
-// ShortenURL handles requests to create short URLs
+// ShortenURL handles requests to create short URLs.
+//
+// With reuse_existing, the creator's existing live link for the URL is returned instead of a new one.
+// A custom_code matching that link is accepted as-is. A different custom_code is resolved by the
+// configured reuse conflict policy: by default the request is rejected with 409, otherwise either the
+// custom code is created or the existing link is returned. Without an existing link, custom_code is
+// handled as usual.
 func (h *URLHandler) ShortenURL(c echo.Context) error {
+	return h.shortenURL(c, false)
+}
+
+// ImportURL handles admin requests to create short URLs, which may seed the initial click count
+// when migrating links from another shortener
+func (h *URLHandler) ImportURL(c echo.Context) error {
+	return h.shortenURL(c, true)
+}
+
+func (h *URLHandler) shortenURL(c echo.Context, asAdmin bool) error {
 	var req ShortenRequest
 	if err := c.Bind(&req); err != nil {
 		log.Error().Err(err).Msg("Invalid request format for URL shortening")
@@ -81,44 +320,180 @@ func (h *URLHandler) ShortenURL(c echo.Context) error {
 		Str("title", req.Title).
 		Dur("expiry", req.Expiry).
 		Str("creator_reference", req.CreatorReference).
+		Bool("reuse_existing", req.ReuseExisting).
+		Int("rate_limit", req.RateLimit).
+		Int64("clicks", req.Clicks).
+		Int64("max_clicks", req.MaxClicks).
+		Int("cache_ttl", req.CacheTTL).
+		Bool("admin", asAdmin).
 		Msg("Shortening URL")
 
+	if req.RateLimit < 0 {
+		log.Error().Int("rate_limit", req.RateLimit).Msg("Invalid rate limit for URL shortening")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid rate limit"})
+	}
+	if req.MaxClicks < 0 {
+		log.Error().Int64("max_clicks", req.MaxClicks).Msg("Invalid max clicks for URL shortening")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid max clicks"})
+	}
+	if req.CacheTTL < 0 {
+		log.Error().Int("cache_ttl", req.CacheTTL).Msg("Invalid cache TTL for URL shortening")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid cache TTL"})
+	}
+	if req.Clicks != 0 && !asAdmin {
+		log.Error().Int64("clicks", req.Clicks).Msg("Initial click count requires admin access")
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "Setting clicks requires admin access"})
+	}
+	if req.Clicks < 0 {
+		log.Error().Int64("clicks", req.Clicks).Msg("Invalid initial click count for URL shortening")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid clicks"})
+	}
+
+	if err := h.verifyDestination(c, req.URL); err != nil {
+		return err
+	}
+
 	// Create short URL
 	// Convert expiry from seconds to time.Duration
 	expiry := req.Expiry * time.Second
-	url, err := h.service.CreateShortURL(c.Request().Context(), req.URL, req.CustomCode, req.Title, expiry, req.CreatorReference)
-	if err != nil {
-		switch {
-		case errors.Is(err, store.ErrInvalidURL):
-			log.Error().Err(err).Str("url", req.URL).Msg("Invalid URL provided")
-			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid URL"})
-		case errors.Is(err, store.ErrURLExists):
-			log.Error().Err(err).Str("custom_code", req.CustomCode).Msg("Custom code already in use")
-			return c.JSON(http.StatusConflict, map[string]string{"error": "Custom code already in use"})
-		default:
-			log.Error().Err(err).Str("url", req.URL).Msg("Failed to create short URL")
-			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create short URL"})
+	opts := shortenOptions(req)
+	var url *models.URL
+	var reused bool
+	var err error
+	if req.ReuseExisting {
+		url, reused, err = h.service.CreateOrReuseShortURL(c.Request().Context(), req.URL, req.CustomCode, req.Title, expiry, req.CreatorReference, opts...)
+	} else {
+		url, err = h.service.CreateShortURL(c.Request().Context(), req.URL, req.CustomCode, req.Title, expiry, req.CreatorReference, opts...)
+	}
+	if err != nil {
+		if errors.Is(err, store.ErrReuseConflict) {
+			log.Error().Err(err).Str("url", req.URL).Str("custom_code", req.CustomCode).Msg("Custom code conflicts with existing short URL")
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error":              store.ErrReuseConflict.Message,
+				"code":               store.ErrReuseConflict.Code,
+				"existing_short_url": h.shortURL(url.Short),
+			})
 		}
+		log.Error().Err(err).Str("url", req.URL).Str("custom_code", req.CustomCode).Msg("Failed to create short URL")
+		return err
 	}
 
 	// Construct full short URL
-	shortURL := h.baseURL + "/" + url.Short
+	shortURL := h.shortURL(url.Short)
 
 	log.Info().
 		Str("original_url", url.Original).
 		Str("short_url", shortURL).
-		Time("expires_at", url.ExpiresAt).
+		Interface("expires_at", url.ExpiresAt).
+		Bool("created", !reused).
 		Msg("URL shortened successfully")
 
+	// Reused links already existed, so only brand-new links get 201 Created
+	status := http.StatusCreated
+	if reused {
+		status = http.StatusOK
+	}
+
 	// Return response
-	return c.JSON(http.StatusCreated, URLResponse{
-		OriginalURL:      url.Original,
-		ShortURL:         shortURL,
-		Title:            url.Title,
-		ExpiresAt:        url.ExpiresAt,
-		Clicks:           url.Clicks,
-		CreatorReference: url.CreatorReference,
-	})
+	return c.JSON(status, ShortenResponse{URLResponse: h.toURLResponse(url), Created: !reused})
+}
+
+// errInvalidVerify is returned when the verify query parameter isn't a boolean
+var errInvalidVerify = store.NewAPIError(http.StatusBadRequest, "invalid_verify", "Invalid verify")
+
+// verifyDestination checks that a destination responds before a link to it is created, if
+// VERIFY_DESTINATIONS is set or the request's verify query parameter asks for it. Every way of creating
+// links on behalf of a client goes through it.
+func (h *URLHandler) verifyDestination(c echo.Context, originalURL string) error {
+	verify := h.cfg.VerifyDestinations
+	if param := c.QueryParam("verify"); param != "" {
+		var err error
+		if verify, err = strconv.ParseBool(param); err != nil {
+			log.Error().Str("verify", param).Msg("Invalid verify for URL shortening")
+			return errInvalidVerify
+		}
+	}
+	if !verify {
+		return nil
+	}
+	if err := h.service.VerifyReachable(c.Request().Context(), originalURL); err != nil {
+		log.Error().Err(err).Str("url", originalURL).Msg("Destination check failed for URL shortening")
+		return err
+	}
+	return nil
+}
+
+// shortenOptions converts the optional settings of a shorten request into URL options. Settings
+// omitted from the request fall back to the creator's defaults.
+func shortenOptions(req ShortenRequest) []store.URLOption {
+	var opts []store.URLOption
+	if req.RateLimit > 0 {
+		opts = append(opts, store.WithRateLimit(req.RateLimit))
+	}
+	if req.RedirectHeaders != nil {
+		opts = append(opts, store.WithRedirectHeaders(req.RedirectHeaders))
+	}
+	if req.Metadata != nil {
+		opts = append(opts, store.WithMetadata(req.Metadata))
+	}
+	if req.Tags != nil {
+		opts = append(opts, store.WithTags(req.Tags))
+	}
+	if req.DisabledRedirectURL != "" {
+		opts = append(opts, store.WithDisabledRedirectURL(req.DisabledRedirectURL))
+	}
+	if req.Notes != "" {
+		opts = append(opts, store.WithNotes(req.Notes))
+	}
+	if req.RandomTarget {
+		opts = append(opts, store.WithRandomTarget())
+	}
+	if req.Clicks > 0 {
+		opts = append(opts, store.WithInitialClicks(req.Clicks))
+	}
+	if req.MaxClicks > 0 {
+		opts = append(opts, store.WithMaxClicks(req.MaxClicks))
+	}
+	if req.CacheTTL > 0 {
+		opts = append(opts, store.WithCacheTTL(req.CacheTTL))
+	}
+	return opts
+}
+
+// SuggestCodeRequest represents a request to check a desired custom code
+type SuggestCodeRequest struct {
+	Code  string `json:"code"`
+	Count int    `json:"count,omitempty"` // number of suggestions, default 5, at most 20
+}
+
+// SuggestCodes reports whether a desired custom code is available and suggests available alternatives
+func (h *URLHandler) SuggestCodes(c echo.Context) error {
+	var req SuggestCodeRequest
+	if err := c.Bind(&req); err != nil {
+		log.Error().Err(err).Msg("Invalid request format for code suggestions")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request"})
+	}
+	if req.Code == "" {
+		log.Error().Msg("Missing code in code suggestion request")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Missing code"})
+	}
+	if req.Count <= 0 {
+		req.Count = 5
+	}
+	if req.Count > 20 {
+		req.Count = 20
+	}
+
+	log.Debug().Str("code", req.Code).Int("count", req.Count).Msg("Suggesting custom codes")
+
+	suggestion, err := h.service.SuggestCodes(c.Request().Context(), req.Code, req.Count)
+	if err != nil {
+		log.Error().Err(err).Str("code", req.Code).Msg("Failed to suggest custom codes")
+		return err
+	}
+
+	log.Info().Str("code", req.Code).Bool("available", suggestion.Available).Msg("Custom code suggestions returned")
+	return c.JSON(http.StatusOK, suggestion)
 }
 
 // RedirectURL handles requests to redirect short URLs
@@ -131,68 +506,103 @@ func (h *URLHandler) RedirectURL(c echo.Context) error {
 
 	log.Debug().Str("code", code).Msg("Redirecting short URL")
 
-	// Get URL by short code
+	// Reject unsigned or forged codes before touching storage
+	signedCode := code
+	code, ok := h.publicCode(signedCode)
+	if !ok {
+		log.Error().Str("code", signedCode).Msg("Invalid short code signature for redirect")
+		return h.notFound(c, signedCode)
+	}
+
+	// Get URL by short code, timing the lookup so slow links (e.g. cache misses) show up in analytics
+	resolveStart := time.Now()
 	url, err := h.service.GetByShort(c.Request().Context(), code)
+	resolveTime := time.Since(resolveStart)
 	if err != nil {
+		if errors.Is(err, store.ErrURLExpired) {
+			log.Warn().Str("code", code).Msg("Redirect requested for expired URL")
+			return h.unavailable(c, code, store.ErrURLExpired)
+		}
 		if errors.Is(err, store.ErrURLNotFound) {
 			log.Error().Err(err).Str("code", code).Msg("URL not found for redirect")
-			return c.JSON(http.StatusNotFound, map[string]string{"error": "URL not found"})
+			return h.notFound(c, code)
 		}
 		log.Error().Err(err).Str("code", code).Msg("Failed to retrieve URL for redirect")
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve URL"})
 	}
 
-	// Increment click count and record analytics asynchronously
-	go func() {
-		ctx := context.Background()
-
-		// Extract client information
-		req := c.Request()
-		ip := c.RealIP()
-		userAgent := req.UserAgent()
-
-		// Simple parsing of user agent - in a real app, you'd use a proper user agent parser library
-		var browser, device string
-		if strings.Contains(userAgent, "Mozilla") {
-			browser = "Mozilla"
-		} else if strings.Contains(userAgent, "Chrome") {
-			browser = "Chrome"
-		} else if strings.Contains(userAgent, "Safari") {
-			browser = "Safari"
-		} else if strings.Contains(userAgent, "Edge") {
-			browser = "Edge"
-		} else if strings.Contains(userAgent, "Firefox") {
-			browser = "Firefox"
-		} else {
-			browser = "Other"
-		}
+	// The link's headers apply to every response for it, not only the direct redirect
+	h.setRedirectHeaders(c, url)
 
-		if strings.Contains(userAgent, "Mobile") {
-			device = "Mobile"
-		} else if strings.Contains(userAgent, "Tablet") {
-			device = "Tablet"
-		} else {
-			device = "Desktop"
-		}
+	// Disabled links stay in place with their analytics but no longer redirect
+	if !url.Enabled {
+		log.Warn().Str("code", code).Msg("Redirect requested for disabled URL")
+		return h.disabled(c, url)
+	}
 
-		// Simple location determination based on IP - in a real app, you'd use a geolocation service
-		location := "Unknown"
+	// Throttle links redirected more often than their rate limit allows
+	if err := h.service.AllowRedirect(c.Request().Context(), url); err != nil {
+		log.Warn().Err(err).Str("code", code).Int("rate_limit", url.RateLimit).Msg("Redirect rate limited")
+		windowEnd := time.Now().Truncate(store.RedirectRateWindow).Add(store.RedirectRateWindow)
+		retryAfter := int(math.Ceil(time.Until(windowEnd).Seconds()))
+		c.Response().Header().Set("Retry-After", strconv.Itoa(retryAfter))
+		return err
+	}
 
-		// Record click analytics
-		err := h.service.RecordClick(ctx, code, ip, location, browser, device)
+	// Random links redirect to one of their creator's links, chosen per hit
+	var target string
+	if url.RandomTarget {
+		chosen, err := h.service.ResolveRandomTarget(c.Request().Context(), url)
 		if err != nil {
-			if errors.Is(err, store.ErrRecentClick) {
-				log.Debug().Str("code", code).Msg("Recent click from the same visitor, not incrementing click count")
-			} else {
-				log.Error().Err(err).Str("code", code).Msg("Failed to record click analytics")
-			}
-		} else {
-			// Only increment click count if it's a unique click or if the last click from the same visitor was more than 1 hour ago
-			if err := h.service.IncrementClicks(ctx, code); err != nil {
-				log.Error().Err(err).Str("code", code).Msg("Failed to increment click count")
-			}
+			log.Error().Err(err).Str("code", code).Msg("Failed to resolve random link target")
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve URL"})
+		}
+		if chosen != url {
+			target = chosen.Short
+			resolved := *url
+			resolved.Original = chosen.Original
+			url = &resolved
+		}
+	}
+
+	// Link preview crawlers are described the link instead of being redirected
+	servesPreview := h.cfg.LinkPreviewPages && store.IsLinkPreviewBot(c.Request().UserAgent())
+
+	// Check if the request accepts HTML
+	servesInterstitial := !servesPreview && strings.Contains(c.Request().Header.Get("Accept"), "text/html")
+
+	// Usage-limited links count the click before revealing the destination, so concurrent redirects
+	// can't use the link more often than it allows
+	counted := false
+	if url.MaxClicks > 0 && !servesPreview {
+		if url.Clicks >= url.MaxClicks {
+			log.Warn().Str("code", code).Int64("max_clicks", url.MaxClicks).Msg("Redirect requested for URL past its click limit")
+			return store.ErrClickLimitReached
 		}
-	}()
+		if _, err := h.service.IncrementClicks(c.Request().Context(), code); err != nil {
+			log.Warn().Err(err).Str("code", code).Int64("max_clicks", url.MaxClicks).Msg("Failed to count click of usage-limited URL")
+			return err
+		}
+		counted = true
+	}
+
+	// Increment click count and record analytics asynchronously, unless the request is a link preview
+	// crawler or the click is only counted once the visitor proceeds past the interstitial
+	if !servesPreview && (!servesInterstitial || h.clickCountMode != config.ClickCountModeProceed) {
+		click := &store.ClickContext{
+			Short:     code,
+			IP:        c.RealIP(),
+			UserAgent: c.Request().UserAgent(),
+			Referrer:  c.Request().Referer(),
+			Target:    target,
+			Extra:     h.clickExtra(c),
+			Counted:   counted,
+		}
+		if h.resolveTiming {
+			click.ResolveTime = resolveTime
+		}
+		h.trackClickAsync(click)
+	}
 
 	log.Info().
 		Str("code", code).
@@ -200,33 +610,22 @@ func (h *URLHandler) RedirectURL(c echo.Context) error {
 		Int64("clicks", url.Clicks+1).
 		Msg("Serving redirect page for URL")
 
-	// Check if the request accepts HTML
-	if strings.Contains(c.Request().Header.Get("Accept"), "text/html") {
-		// Define template data
-		type TemplateData struct {
-			OriginalURL string
-			ShortURL    string
-			Clicks      int64
+	if servesPreview {
+		if err := h.renderLinkPreview(c, url, signedCode); err != nil {
+			log.Error().Err(err).Msg("Failed to render link preview template")
+			return c.Redirect(http.StatusFound, url.Original)
 		}
 
-		data := TemplateData{
-			OriginalURL: url.Original,
-			ShortURL:    url.Short,
-			Clicks:      url.Clicks,
-		}
+		return nil
+	}
 
-		// Parse the template
-		tmpl, err := template.ParseFiles("static/redirect.html")
-		if err != nil {
-			log.Error().Err(err).Msg("Failed to parse template")
-			return c.Redirect(http.StatusFound, url.Original)
+	if servesInterstitial {
+		beaconURL := ""
+		if h.clickCountMode == config.ClickCountModeProceed {
+			beaconURL = "/" + signedCode + "/beacon"
 		}
-
-		// Render the template
-		c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextHTML)
-		c.Response().WriteHeader(http.StatusOK)
-		if err := tmpl.Execute(c.Response().Writer, data); err != nil {
-			log.Error().Err(err).Msg("Failed to render template")
+		if err := h.renderInterstitial(c, url, beaconURL); err != nil {
+			log.Error().Err(err).Msg("Failed to render interstitial template")
 			return c.Redirect(http.StatusFound, url.Original)
 		}
 
@@ -237,6 +636,285 @@ func (h *URLHandler) RedirectURL(c echo.Context) error {
 	return c.Redirect(http.StatusFound, url.Original)
 }
 
+// interstitialData is the data rendered into the interstitial template
+type interstitialData struct {
+	OriginalURL string
+	ShortURL    string
+	Clicks      int64
+	BeaconURL   string
+}
+
+// renderInterstitial renders the interstitial page for a URL. The page reports proceeding visitors
+// to beaconURL when it is set.
+func (h *URLHandler) renderInterstitial(c echo.Context, url *models.URL, beaconURL string) error {
+	// Parse the template
+	tmpl, err := template.ParseFiles("static/redirect.html")
+	if err != nil {
+		return err
+	}
+
+	// Render the template
+	c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextHTML)
+	c.Response().WriteHeader(http.StatusOK)
+	return tmpl.Execute(c.Response().Writer, interstitialData{
+		OriginalURL: url.Original,
+		ShortURL:    url.Short,
+		Clicks:      url.Clicks,
+		BeaconURL:   beaconURL,
+	})
+}
+
+// linkPreviewData is the data rendered into the link preview template
+type linkPreviewData struct {
+	Title       string
+	Description string
+	Host        string
+	ShortURL    string
+	OriginalURL string
+}
+
+// renderLinkPreview renders a page with OpenGraph tags describing a URL for link preview crawlers.
+// The title falls back to the destination host, and the description to the link's "description"
+// metadata or else the destination host.
+func (h *URLHandler) renderLinkPreview(c echo.Context, url *models.URL, signedCode string) error {
+	tmpl, err := template.ParseFiles("static/preview.html")
+	if err != nil {
+		return err
+	}
+
+	host := url.Original
+	if parsed, err := neturl.Parse(url.Original); err == nil && parsed.Hostname() != "" {
+		host = parsed.Hostname()
+	}
+	data := linkPreviewData{
+		Title:       url.Title,
+		Description: url.Metadata["description"],
+		Host:        host,
+		ShortURL:    h.baseURL + "/" + signedCode,
+		OriginalURL: url.Original,
+	}
+	if data.Title == "" {
+		data.Title = host
+	}
+	if data.Description == "" {
+		data.Description = "Link to " + host
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextHTMLCharsetUTF8)
+	c.Response().WriteHeader(http.StatusOK)
+	return tmpl.Execute(c.Response().Writer, data)
+}
+
+// PreviewInterstitial renders the interstitial page for a URL without counting a click, regardless
+// of the Accept header, so the template can be checked
+func (h *URLHandler) PreviewInterstitial(c echo.Context) error {
+	code := c.Param("code")
+	if code == "" {
+		log.Error().Msg("Missing URL code in preview request")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Missing URL code"})
+	}
+
+	log.Debug().Str("code", code).Msg("Previewing interstitial")
+
+	url, err := h.service.GetByShort(c.Request().Context(), code)
+	if err != nil {
+		log.Error().Err(err).Str("code", code).Msg("Failed to retrieve URL for preview request")
+		return err
+	}
+
+	// Without a beacon URL the preview never reports a proceeding visitor
+	if err := h.renderInterstitial(c, url, ""); err != nil {
+		log.Error().Err(err).Str("code", code).Msg("Failed to render interstitial preview")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to render preview"})
+	}
+
+	log.Info().Str("code", code).Msg("Interstitial preview rendered")
+
+	return nil
+}
+
+// setRedirectHeaders applies the URL's custom redirect headers whose names are allowlisted
+func (h *URLHandler) setRedirectHeaders(c echo.Context, url *models.URL) {
+	for name, value := range url.RedirectHeaders {
+		if !h.redirectHeaders[http.CanonicalHeaderKey(name)] {
+			log.Debug().Str("code", url.Short).Str("header", name).Msg("Ignoring redirect header that is not allowlisted")
+			continue
+		}
+		c.Response().Header().Set(name, value)
+	}
+}
+
+// notFound answers a request for an unknown code, see unavailable
+func (h *URLHandler) notFound(c echo.Context, code string) error {
+	return h.unavailable(c, code, store.ErrURLNotFound)
+}
+
+// unavailable answers a redirect to a code that is unknown or no longer works: with the configured
+// fallback redirect if there is one, and otherwise with a branded page for browsers or a JSON error
+func (h *URLHandler) unavailable(c echo.Context, code string, apiErr *store.APIError) error {
+	if h.notFoundURL != "" {
+		log.Debug().Str("code", code).Str("fallback_url", h.notFoundURL).Msg("Redirecting unknown code to fallback URL")
+		return c.Redirect(http.StatusFound, h.notFoundURL)
+	}
+
+	if !strings.Contains(c.Request().Header.Get("Accept"), "text/html") {
+		if apiErr == store.ErrURLNotFound {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "URL not found"})
+		}
+		return apiErr
+	}
+
+	tmpl, err := template.ParseFiles("static/not_found.html")
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to parse not found template")
+		return c.JSON(apiErr.Status, map[string]string{"error": apiErr.Message})
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextHTMLCharsetUTF8)
+	c.Response().WriteHeader(apiErr.Status)
+	data := map[string]any{"Code": code, "Status": apiErr.Status, "Expired": apiErr == store.ErrURLExpired}
+	if err := tmpl.Execute(c.Response().Writer, data); err != nil {
+		log.Error().Err(err).Msg("Failed to render not found template")
+	}
+
+	return nil
+}
+
+// disabled answers a request for a disabled URL with the URL's own redirect target if it has one, and
+// otherwise with the configured response: a JSON error, a branded page for browsers, or a redirect
+func (h *URLHandler) disabled(c echo.Context, url *models.URL) error {
+	target := url.DisabledRedirectURL
+	if target == "" && h.disabledMode == config.DisabledResponseRedirect {
+		target = h.disabledURL
+	}
+	if target != "" {
+		log.Debug().Str("code", url.Short).Str("disabled_redirect_url", target).Msg("Redirecting disabled URL")
+		return c.Redirect(http.StatusFound, target)
+	}
+
+	if h.disabledMode != config.DisabledResponseHTML || !strings.Contains(c.Request().Header.Get("Accept"), "text/html") {
+		return store.ErrURLDisabled
+	}
+
+	tmpl, err := template.ParseFiles("static/disabled.html")
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to parse disabled template")
+		return store.ErrURLDisabled
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextHTMLCharsetUTF8)
+	c.Response().WriteHeader(http.StatusForbidden)
+	if err := tmpl.Execute(c.Response().Writer, map[string]string{"Code": url.Short}); err != nil {
+		log.Error().Err(err).Msg("Failed to render disabled template")
+	}
+
+	return nil
+}
+
+// maxBeaconBodySize is the largest beacon body that is read
+const maxBeaconBodySize = 64 << 10
+
+// BeaconRequest is the optional body of a click beacon
+type BeaconRequest struct {
+	// Extra holds extra click fields, which must be allowlisted by CLICK_EXTRA_FIELDS
+	Extra map[string]string `json:"extra,omitempty"`
+}
+
+// Beacon counts a click once the visitor proceeds past the interstitial page, with the extra click
+// fields of its optional JSON body
+func (h *URLHandler) Beacon(c echo.Context) error {
+	code := c.Param("code")
+	if code == "" {
+		log.Error().Msg("Missing URL code in beacon request")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Missing URL code"})
+	}
+
+	log.Debug().Str("code", code).Msg("Received click beacon")
+
+	signedCode := code
+	code, ok := h.publicCode(signedCode)
+	if !ok {
+		log.Error().Str("code", signedCode).Msg("Invalid short code signature for beacon")
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "URL not found"})
+	}
+
+	// The beacon may report extra click fields, e.g. the screen size, as JSON
+	var req BeaconRequest
+	if err := json.NewDecoder(io.LimitReader(c.Request().Body, maxBeaconBodySize)).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		log.Error().Err(err).Str("code", code).Msg("Invalid beacon body")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request"})
+	}
+	if err := h.service.ValidateClickExtra(req.Extra); err != nil {
+		log.Error().Err(err).Str("code", code).Msg("Invalid extra click fields in beacon")
+		return err
+	}
+
+	// Clicks were already counted when the interstitial was served
+	if h.clickCountMode != config.ClickCountModeProceed {
+		return c.NoContent(http.StatusNoContent)
+	}
+
+	// Make sure the URL exists and is enabled before counting the click
+	url, err := h.service.GetByShort(c.Request().Context(), code)
+	if err != nil {
+		log.Error().Err(err).Str("code", code).Msg("Failed to retrieve URL for beacon")
+		return err
+	}
+	if !url.Enabled {
+		log.Warn().Str("code", code).Msg("Click beacon for disabled URL")
+		return store.ErrURLDisabled
+	}
+
+	// Usage-limited links counted the click when the interstitial was served
+	h.trackClick(c.Request().Context(), &store.ClickContext{
+		Short:     code,
+		IP:        c.RealIP(),
+		UserAgent: c.Request().UserAgent(),
+		Referrer:  c.Request().Referer(),
+		Extra:     req.Extra,
+		Counted:   url.MaxClicks > 0,
+	})
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// clickExtra collects the allowlisted extra click fields passed as query parameters of a redirect.
+// Other query parameters are ignored.
+func (h *URLHandler) clickExtra(c echo.Context) map[string]string {
+	var extra map[string]string
+	for _, field := range h.service.ClickExtraFields() {
+		value := c.QueryParam(field)
+		if value == "" {
+			continue
+		}
+		if extra == nil {
+			extra = make(map[string]string)
+		}
+		extra[field] = value
+	}
+	return extra
+}
+
+// trackClickAsync hands a click to the service's click workers, counting it as dropped when their queue is full
+func (h *URLHandler) trackClickAsync(click *store.ClickContext) {
+	if !h.service.EnqueueClick(click) {
+		dropped := h.droppedClicks.Add(1)
+		log.Warn().Str("code", click.Short).Int64("dropped_clicks", dropped).Msg("Click queue full, dropping click")
+	}
+}
+
+// trackClick records click analytics and increments the click count for a unique visitor.
+// A zero click.ResolveTime means the redirect latency wasn't captured.
+func (h *URLHandler) trackClick(ctx context.Context, click *store.ClickContext) {
+	if err := h.service.TrackClick(ctx, click); err != nil {
+		if errors.Is(err, store.ErrRecentClick) {
+			log.Debug().Str("code", click.Short).Msg("Recent click from the same visitor, not incrementing click count")
+		} else {
+			log.Error().Err(err).Str("code", click.Short).Msg("Failed to track click")
+		}
+	}
+}
+
 // GetURLInfo returns information about a short URL
 func (h *URLHandler) GetURLInfo(c echo.Context) error {
 	code := c.Param("code")
@@ -250,42 +928,37 @@ func (h *URLHandler) GetURLInfo(c echo.Context) error {
 	// Get URL by short code
 	url, err := h.service.GetByShort(c.Request().Context(), code)
 	if err != nil {
-		if errors.Is(err, store.ErrURLNotFound) {
-			log.Error().Err(err).Str("code", code).Msg("URL not found for info request")
-			return c.JSON(http.StatusNotFound, map[string]string{"error": "URL not found"})
-		}
 		log.Error().Err(err).Str("code", code).Msg("Failed to retrieve URL for info request")
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve URL"})
+		return err
 	}
 
 	// Construct full short URL
-	shortURL := h.baseURL + "/" + url.Short
+	shortURL := h.shortURL(url.Short)
 
 	log.Info().
 		Str("code", code).
 		Str("original_url", url.Original).
 		Str("short_url", shortURL).
-		Time("expires_at", url.ExpiresAt).
+		Interface("expires_at", url.ExpiresAt).
 		Int64("clicks", url.Clicks).
 		Msg("URL info retrieved")
 
 	// Return response
-	return c.JSON(http.StatusOK, URLResponse{
-		OriginalURL:      url.Original,
-		ShortURL:         shortURL,
-		Title:            url.Title,
-		ExpiresAt:        url.ExpiresAt,
-		Clicks:           url.Clicks,
-		CreatorReference: url.CreatorReference,
-	})
+	return c.JSON(http.StatusOK, h.toURLResponse(url))
 }
 
 // UpdateURLRequest represents a request to update a URL
 type UpdateURLRequest struct {
-	URL              string        `json:"url,omitempty"`
-	Title            string        `json:"title,omitempty"`
-	Expiry           time.Duration `json:"expiry,omitempty"` // in seconds
-	CreatorReference string        `json:"creator_reference,omitempty"`
+	URL                 string            `json:"url,omitempty"`
+	Title               string            `json:"title,omitempty"`
+	Expiry              time.Duration     `json:"expiry,omitempty"` // in seconds
+	CreatorReference    string            `json:"creator_reference,omitempty"`
+	RateLimit           *int              `json:"rate_limit,omitempty"`            // redirects per minute, 0 removes the limit
+	RedirectHeaders     map[string]string `json:"redirect_headers,omitempty"`      // an empty object removes all headers
+	Metadata            map[string]string `json:"metadata,omitempty"`              // an empty object removes all metadata
+	Tags                []string          `json:"tags,omitempty"`                  // an empty list removes all tags
+	DisabledRedirectURL *string           `json:"disabled_redirect_url,omitempty"` // an empty string restores the configured disabled response
+	Notes               *string           `json:"notes,omitempty"`                 // an empty string removes the note
 }
 
 // UpdateURL handles requests to update a URL
@@ -308,17 +981,38 @@ func (h *URLHandler) UpdateURL(c echo.Context) error {
 		Str("title", req.Title).
 		Dur("expiry", req.Expiry).
 		Str("creator_reference", req.CreatorReference).
+		Interface("rate_limit", req.RateLimit).
 		Msg("Updating URL")
 
+	var opts []store.URLOption
+	if req.RateLimit != nil {
+		if *req.RateLimit < 0 {
+			log.Error().Int("rate_limit", *req.RateLimit).Msg("Invalid rate limit for URL update")
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid rate limit"})
+		}
+		opts = append(opts, store.WithRateLimit(*req.RateLimit))
+	}
+	if req.RedirectHeaders != nil {
+		opts = append(opts, store.WithRedirectHeaders(req.RedirectHeaders))
+	}
+	if req.Metadata != nil {
+		opts = append(opts, store.WithMetadata(req.Metadata))
+	}
+	if req.Tags != nil {
+		opts = append(opts, store.WithTags(req.Tags))
+	}
+	if req.DisabledRedirectURL != nil {
+		opts = append(opts, store.WithDisabledRedirectURL(*req.DisabledRedirectURL))
+	}
+	if req.Notes != nil {
+		opts = append(opts, store.WithNotes(*req.Notes))
+	}
+
 	// Get existing URL to verify it exists
 	existingURL, err := h.service.GetByShort(c.Request().Context(), code)
 	if err != nil {
-		if errors.Is(err, store.ErrURLNotFound) {
-			log.Error().Err(err).Str("code", code).Msg("URL not found for update")
-			return c.JSON(http.StatusNotFound, map[string]string{"error": "URL not found"})
-		}
 		log.Error().Err(err).Str("code", code).Msg("Failed to retrieve URL for update")
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve URL"})
+		return err
 	}
 
 	// Use existing values if not provided in request
@@ -335,54 +1029,42 @@ func (h *URLHandler) UpdateURL(c echo.Context) error {
 	var updatedURL *models.URL
 	var updateErr error
 
-	// If creator reference is provided, use it for authorization
-	if req.CreatorReference != "" {
-		// Convert expiry from seconds to time.Duration
-		expiry := req.Expiry * time.Second
+	// Convert expiry from seconds to time.Duration
+	expiry := req.Expiry * time.Second
+	if h.isAdmin(c) {
+		// Admins may update any URL, e.g. to neutralize abusive links
+		log.Warn().Str("code", code).Str("owner", existingURL.CreatorReference).Msg("Admin updating URL without creator check")
+		updatedURL, updateErr = h.service.UpdateURL(c.Request().Context(), code, title, originalURL, expiry, adminIdentity, opts...)
+	} else if req.CreatorReference != "" {
 		// Update URL with creator reference check
-		updatedURL, updateErr = h.service.UpdateURLWithCreator(c.Request().Context(), code, title, originalURL, expiry, req.CreatorReference)
+		updatedURL, updateErr = h.service.UpdateURLWithCreator(c.Request().Context(), code, title, originalURL, expiry, req.CreatorReference, opts...)
 	} else {
 		log.Warn().Str("code", code).Msg("No creator reference provided for URL update")
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Missing creator reference"})
 	}
 
 	if updateErr != nil {
-		switch {
-		case errors.Is(updateErr, store.ErrInvalidURL):
-			log.Error().Err(updateErr).Str("url", req.URL).Msg("Invalid URL provided")
-			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid URL"})
-		case errors.Is(updateErr, store.ErrURLNotFound):
-			log.Error().Err(updateErr).Str("code", code).Msg("URL not found for update")
-			return c.JSON(http.StatusNotFound, map[string]string{"error": "URL not found"})
-		case strings.Contains(updateErr.Error(), "unauthorized"):
+		if errors.Is(updateErr, store.ErrCreatorMismatch) {
 			log.Error().Err(updateErr).Str("code", code).Str("creator_reference", req.CreatorReference).Msg("Unauthorized update attempt")
-			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized: creator reference does not match"})
-		default:
-			log.Error().Err(updateErr).Str("code", code).Msg("Failed to update URL")
-			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update URL"})
+			return updateErr
 		}
+		log.Error().Err(updateErr).Str("code", code).Msg("Failed to update URL")
+		return updateErr
 	}
 
 	// Construct full short URL
-	shortURL := h.baseURL + "/" + updatedURL.Short
+	shortURL := h.shortURL(updatedURL.Short)
 
 	log.Info().
 		Str("code", code).
 		Str("original_url", updatedURL.Original).
 		Str("short_url", shortURL).
 		Str("title", updatedURL.Title).
-		Time("expires_at", updatedURL.ExpiresAt).
+		Interface("expires_at", updatedURL.ExpiresAt).
 		Msg("URL updated successfully")
 
 	// Return response
-	return c.JSON(http.StatusOK, URLResponse{
-		OriginalURL:      updatedURL.Original,
-		ShortURL:         shortURL,
-		Title:            updatedURL.Title,
-		ExpiresAt:        updatedURL.ExpiresAt,
-		Clicks:           updatedURL.Clicks,
-		CreatorReference: updatedURL.CreatorReference,
-	})
+	return c.JSON(http.StatusOK, h.toURLResponse(updatedURL))
 }
 
 // DeleteURL handles requests to delete a URL
@@ -403,8 +1085,11 @@ func (h *URLHandler) DeleteURL(c echo.Context) error {
 
 	var err error
 
-	// If creator reference is provided, use it for authorization
-	if creatorReference != "" {
+	if h.isAdmin(c) {
+		// Admins may delete any URL, e.g. to remove abusive links
+		log.Warn().Str("code", code).Msg("Admin deleting URL without creator check")
+		err = h.service.Delete(c.Request().Context(), code, adminIdentity)
+	} else if creatorReference != "" {
 		// Delete URL with creator reference check
 		err = h.service.DeleteWithCreator(c.Request().Context(), code, creatorReference)
 	} else {
@@ -414,15 +1099,12 @@ func (h *URLHandler) DeleteURL(c echo.Context) error {
 	}
 
 	if err != nil {
-		if errors.Is(err, store.ErrURLNotFound) {
-			log.Error().Err(err).Str("code", code).Msg("URL not found for deletion")
-			return c.JSON(http.StatusNotFound, map[string]string{"error": "URL not found"})
-		} else if strings.Contains(err.Error(), "unauthorized") {
+		if errors.Is(err, store.ErrCreatorMismatch) {
 			log.Error().Err(err).Str("code", code).Str("creator_reference", creatorReference).Msg("Unauthorized delete attempt")
-			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized: creator reference does not match"})
+			return err
 		}
 		log.Error().Err(err).Str("code", code).Msg("Failed to delete URL")
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to delete URL"})
+		return err
 	}
 
 	log.Info().
@@ -431,32 +1113,249 @@ func (h *URLHandler) DeleteURL(c echo.Context) error {
 		Msg("URL deleted successfully")
 
 	// Return success response
-	return c.JSON(http.StatusOK, map[string]string{"message": "URL deleted successfully"})
+	return c.JSON(http.StatusOK, MessageResponse{Message: "URL deleted successfully"})
 }
 
-// GetURLAnalytics returns analytics data for a URL
-func (h *URLHandler) GetURLAnalytics(c echo.Context) error {
-	code := c.Param("code")
-	if code == "" {
-		log.Error().Msg("Missing URL code in analytics request")
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Missing URL code"})
-	}
+// TransferURLRequest represents a request to transfer URL ownership
+type TransferURLRequest struct {
+	CreatorReference        string `json:"creator_reference"`                   // the new owner
+	CurrentCreatorReference string `json:"current_creator_reference,omitempty"` // the current owner, not needed by admins
+}
 
-	log.Debug().Str("code", code).Msg("Getting URL analytics")
+// TransferURL handles requests from a URL's current owner to transfer it to another creator
+func (h *URLHandler) TransferURL(c echo.Context) error {
+	return h.transferURL(c, false)
+}
 
-	// Get URL to verify it exists
-	url, err := h.service.GetByShort(c.Request().Context(), code)
+// AdminTransferURL handles admin requests to transfer a URL regardless of its current owner
+func (h *URLHandler) AdminTransferURL(c echo.Context) error {
+	return h.transferURL(c, true)
+}
+
+func (h *URLHandler) transferURL(c echo.Context, asAdmin bool) error {
+	code := c.Param("code")
+
+	var req TransferURLRequest
+	if err := c.Bind(&req); err != nil {
+		log.Error().Err(err).Msg("Invalid request format for URL transfer")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request"})
+	}
+
+	log.Debug().
+		Str("code", code).
+		Str("creator_reference", req.CreatorReference).
+		Str("current_creator_reference", req.CurrentCreatorReference).
+		Bool("admin", asAdmin).
+		Msg("Transferring URL")
+
+	if req.CreatorReference == "" {
+		log.Error().Str("code", code).Msg("Missing new creator reference in transfer request")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Missing creator reference"})
+	}
+	if !asAdmin && req.CurrentCreatorReference == "" {
+		log.Warn().Str("code", code).Msg("No current creator reference provided for URL transfer")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Missing current creator reference"})
+	}
+
+	url, err := h.service.TransferURL(c.Request().Context(), code, req.CurrentCreatorReference, req.CreatorReference, asAdmin)
 	if err != nil {
-		if errors.Is(err, store.ErrURLNotFound) {
-			log.Error().Err(err).Str("code", code).Msg("URL not found for analytics request")
-			return c.JSON(http.StatusNotFound, map[string]string{"error": "URL not found"})
+		log.Error().Err(err).Str("code", code).Msg("Failed to transfer URL")
+		return err
+	}
+
+	log.Info().Str("code", code).Str("creator_reference", url.CreatorReference).Msg("URL transferred successfully")
+
+	return c.JSON(http.StatusOK, h.toURLResponse(url))
+}
+
+// SetEnabledRequest represents a request to enable or disable a URL
+type SetEnabledRequest struct {
+	CreatorReference string `json:"creator_reference"`
+}
+
+// DisableURL handles requests to turn a URL off without deleting it or its analytics
+func (h *URLHandler) DisableURL(c echo.Context) error {
+	return h.setURLEnabled(c, false)
+}
+
+// EnableURL handles requests to turn a disabled URL back on
+func (h *URLHandler) EnableURL(c echo.Context) error {
+	return h.setURLEnabled(c, true)
+}
+
+func (h *URLHandler) setURLEnabled(c echo.Context, enabled bool) error {
+	code := c.Param("code")
+
+	var req SetEnabledRequest
+	if err := c.Bind(&req); err != nil {
+		log.Error().Err(err).Msg("Invalid request format for enabling or disabling URL")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request"})
+	}
+
+	log.Debug().
+		Str("code", code).
+		Str("creator_reference", req.CreatorReference).
+		Bool("enabled", enabled).
+		Msg("Setting URL enabled state")
+
+	if req.CreatorReference == "" {
+		log.Warn().Str("code", code).Msg("No creator reference provided for enabling or disabling URL")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Missing creator reference"})
+	}
+
+	url, err := h.service.SetURLEnabled(c.Request().Context(), code, req.CreatorReference, enabled)
+	if err != nil {
+		log.Error().Err(err).Str("code", code).Msg("Failed to set URL enabled state")
+		return err
+	}
+
+	log.Info().Str("code", code).Bool("enabled", url.Enabled).Msg("URL enabled state set successfully")
+
+	return c.JSON(http.StatusOK, h.toURLResponse(url))
+}
+
+// SetCreatorURLsEnabledRequest optionally limits a bulk enable or disable to the creator's URLs carrying a tag
+type SetCreatorURLsEnabledRequest struct {
+	Tag string `json:"tag,omitempty"`
+}
+
+// DisableCreatorURLs handles requests to disable all of a creator's URLs, or only those with a tag,
+// e.g. to pull a whole campaign offline
+func (h *URLHandler) DisableCreatorURLs(c echo.Context) error {
+	return h.setCreatorURLsEnabled(c, false)
+}
+
+// EnableCreatorURLs handles requests to enable all of a creator's URLs, or only those with a tag
+func (h *URLHandler) EnableCreatorURLs(c echo.Context) error {
+	return h.setCreatorURLsEnabled(c, true)
+}
+
+func (h *URLHandler) setCreatorURLsEnabled(c echo.Context, enabled bool) error {
+	creatorReference := c.Param("creator_reference")
+
+	var req SetCreatorURLsEnabledRequest
+	if err := c.Bind(&req); err != nil {
+		log.Error().Err(err).Msg("Invalid request format for enabling or disabling creator URLs")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request"})
+	}
+
+	log.Debug().
+		Str("creator_reference", creatorReference).
+		Str("tag", req.Tag).
+		Bool("enabled", enabled).
+		Msg("Setting enabled state of creator URLs")
+
+	affected, err := h.service.SetCreatorURLsEnabled(c.Request().Context(), creatorReference, strings.TrimSpace(req.Tag), enabled)
+	if err != nil {
+		log.Error().Err(err).Str("creator_reference", creatorReference).Msg("Failed to set enabled state of creator URLs")
+		return err
+	}
+
+	log.Info().Str("creator_reference", creatorReference).Bool("enabled", enabled).Int("affected", affected).Msg("Enabled state of creator URLs set")
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"affected": affected})
+}
+
+// TransferCreatorURLs handles admin requests to transfer every URL of a creator to another creator
+func (h *URLHandler) TransferCreatorURLs(c echo.Context) error {
+	from := c.Param("creator_reference")
+
+	var req TransferURLRequest
+	if err := c.Bind(&req); err != nil {
+		log.Error().Err(err).Msg("Invalid request format for creator transfer")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request"})
+	}
+
+	log.Debug().Str("from", from).Str("to", req.CreatorReference).Msg("Transferring all URLs of creator")
+
+	if req.CreatorReference == "" {
+		log.Error().Str("from", from).Msg("Missing new creator reference in creator transfer request")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Missing creator reference"})
+	}
+
+	transferred, err := h.service.TransferCreatorURLs(c.Request().Context(), from, req.CreatorReference)
+	if err != nil {
+		log.Error().Err(err).Str("from", from).Msg("Failed to transfer creator URLs")
+		return err
+	}
+
+	log.Info().Str("from", from).Str("to", req.CreatorReference).Int("transferred", transferred).Msg("Creator URLs transferred")
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"transferred": transferred})
+}
+
+// URLAnalyticsResponse is the response of GetURLAnalytics
+type URLAnalyticsResponse struct {
+	URL URLResponse `json:"url"`
+	// Analytics holds total_clicks, the clicks per browsers, os, devices, locations and referrers, and
+	// the from and to of the range they cover
+	Analytics    map[string]interface{} `json:"analytics"`
+	RecentClicks []*models.Click        `json:"recent_clicks"`
+	TimeSeries   *TimeSeriesResponse    `json:"time_series,omitempty"`
+}
+
+// TimeSeriesResponse is the click time series of a URL analytics response
+type TimeSeriesResponse struct {
+	Granularity          store.TimeSeriesInterval  `json:"granularity"`
+	RequestedGranularity store.TimeSeriesInterval  `json:"requested_granularity"`
+	TZ                   string                    `json:"tz"`
+	Points               []*models.TimeSeriesPoint `json:"points"`
+}
+
+// GetURLAnalytics returns analytics data for a URL. The optional from and to query parameters
+// bound the aggregated clicks; without them the service's default analytics window applies.
+func (h *URLHandler) GetURLAnalytics(c echo.Context) error {
+	code := c.Param("code")
+	if code == "" {
+		log.Error().Msg("Missing URL code in analytics request")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Missing URL code"})
+	}
+
+	var rng store.ClickRange
+	var err error
+	if rng.From, err = queryTime(c, "from"); err != nil {
+		log.Error().Str("from", c.QueryParam("from")).Msg("Invalid from in analytics request")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid from"})
+	}
+	if rng.To, err = queryTime(c, "to"); err != nil {
+		log.Error().Str("to", c.QueryParam("to")).Msg("Invalid to in analytics request")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid to"})
+	}
+	if !rng.From.IsZero() && !rng.To.IsZero() && !rng.From.Before(rng.To) {
+		log.Error().Time("from", rng.From).Time("to", rng.To).Msg("Empty range in analytics request")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "from must be before to"})
+	}
+
+	// A granularity adds a click time series over the same range
+	var granularity store.TimeSeriesInterval
+	if param := c.QueryParam("granularity"); param != "" {
+		if granularity, err = store.ParseTimeSeriesInterval(param); err != nil {
+			log.Error().Err(err).Str("granularity", param).Msg("Invalid granularity in analytics request")
+			return err
 		}
+	}
+	loc, err := store.ParseTimezone(c.QueryParam("tz"))
+	if err != nil {
+		log.Error().Err(err).Str("tz", c.QueryParam("tz")).Msg("Invalid time zone in analytics request")
+		return err
+	}
+
+	log.Debug().
+		Str("code", code).
+		Time("from", rng.From).
+		Time("to", rng.To).
+		Str("granularity", string(granularity)).
+		Msg("Getting URL analytics")
+
+	// Get URL to verify it exists
+	url, err := h.service.GetByShort(c.Request().Context(), code)
+	if err != nil {
 		log.Error().Err(err).Str("code", code).Msg("Failed to retrieve URL for analytics request")
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve URL"})
+		return err
 	}
 
 	// Get analytics data
-	analytics, err := h.service.GetClickAnalytics(c.Request().Context(), code)
+	analytics, err := h.service.GetClickAnalytics(c.Request().Context(), code, rng)
 	if err != nil {
 		log.Error().Err(err).Str("code", code).Msg("Failed to retrieve analytics data")
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve analytics data"})
@@ -476,17 +1375,24 @@ func (h *URLHandler) GetURLAnalytics(c echo.Context) error {
 	}
 
 	// Combine data
-	result := map[string]interface{}{
-		"url": URLResponse{
-			OriginalURL:      url.Original,
-			ShortURL:         h.baseURL + "/" + url.Short,
-			Title:            url.Title,
-			ExpiresAt:        url.ExpiresAt,
-			Clicks:           url.Clicks,
-			CreatorReference: url.CreatorReference,
-		},
-		"analytics":     analytics,
-		"recent_clicks": clicks,
+	result := URLAnalyticsResponse{
+		URL:          h.toURLResponse(url),
+		Analytics:    analytics,
+		RecentClicks: clicks,
+	}
+
+	if granularity != "" {
+		series, effective, err := h.service.GetClickTimeSeriesInRange(c.Request().Context(), code, rng, granularity, loc)
+		if err != nil {
+			log.Error().Err(err).Str("code", code).Msg("Failed to retrieve click time series")
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve analytics data"})
+		}
+		result.TimeSeries = &TimeSeriesResponse{
+			Granularity:          effective,
+			RequestedGranularity: granularity,
+			TZ:                   loc.String(),
+			Points:               series,
+		}
 	}
 
 	log.Info().
@@ -497,7 +1403,392 @@ func (h *URLHandler) GetURLAnalytics(c echo.Context) error {
 	return c.JSON(http.StatusOK, result)
 }
 
-// GetURLsByCreator returns all URLs created by a specific creator
+// Time-series defaults for analytics endpoints
+const (
+	defaultSeriesDays = 30
+	maxSeriesDays     = 365
+)
+
+// GetURLTimeSeries returns click counts for a URL over the last days in buckets of the requested
+// interval. Intervals producing too many buckets are coarsened; the response reports the one used.
+func (h *URLHandler) GetURLTimeSeries(c echo.Context) error {
+	code := c.Param("code")
+	if code == "" {
+		log.Error().Msg("Missing URL code in time series request")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Missing URL code"})
+	}
+
+	days, err := queryInt(c, "days", defaultSeriesDays)
+	if err != nil || days == 0 || days > maxSeriesDays {
+		log.Error().Str("days", c.QueryParam("days")).Msg("Invalid days in time series request")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid days"})
+	}
+
+	requested := store.IntervalDay
+	if param := c.QueryParam("interval"); param != "" {
+		if requested, err = store.ParseTimeSeriesInterval(param); err != nil {
+			log.Error().Err(err).Str("interval", param).Msg("Invalid interval in time series request")
+			return err
+		}
+	}
+
+	loc, err := store.ParseTimezone(c.QueryParam("tz"))
+	if err != nil {
+		log.Error().Err(err).Str("tz", c.QueryParam("tz")).Msg("Invalid time zone in time series request")
+		return err
+	}
+
+	log.Debug().Str("code", code).Int("days", days).Str("interval", string(requested)).Str("tz", loc.String()).Msg("Getting URL time series")
+
+	// Get URL to verify it exists
+	if _, err := h.service.GetByShort(c.Request().Context(), code); err != nil {
+		log.Error().Err(err).Str("code", code).Msg("Failed to retrieve URL for time series request")
+		return err
+	}
+
+	series, interval, err := h.service.GetClickTimeSeriesByInterval(c.Request().Context(), code, days, requested, loc)
+	if err != nil {
+		log.Error().Err(err).Str("code", code).Msg("Failed to retrieve click time series")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve analytics data"})
+	}
+
+	log.Info().
+		Str("code", code).
+		Int("days", days).
+		Str("interval", string(interval)).
+		Int("buckets", len(series)).
+		Msg("URL time series retrieved")
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"interval":           interval,
+		"requested_interval": requested,
+		"days":               days,
+		"tz":                 loc.String(),
+		"points":             series,
+	})
+}
+
+// GetURLResolveTiming returns percentiles of how long a URL's redirects took to resolve its code
+func (h *URLHandler) GetURLResolveTiming(c echo.Context) error {
+	code := c.Param("code")
+	if code == "" {
+		log.Error().Msg("Missing URL code in resolve timing request")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Missing URL code"})
+	}
+
+	log.Debug().Str("code", code).Msg("Getting URL resolve timing")
+
+	// Get URL to verify it exists
+	if _, err := h.service.GetByShort(c.Request().Context(), code); err != nil {
+		log.Error().Err(err).Str("code", code).Msg("Failed to retrieve URL for resolve timing request")
+		return err
+	}
+
+	timing, err := h.service.GetResolveTiming(c.Request().Context(), code)
+	if err != nil {
+		log.Error().Err(err).Str("code", code).Msg("Failed to retrieve resolve timing")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve analytics data"})
+	}
+
+	log.Info().Str("code", code).Int64("samples", timing.Samples).Msg("URL resolve timing retrieved")
+
+	return c.JSON(http.StatusOK, timing)
+}
+
+// GetURLClickDistribution returns a URL's clicks by hour of the day and day of the week, in the time
+// zone of the optional tz query parameter (UTC by default), to find when its audience is active. The
+// optional from and to query parameters bound the clicks like for GetURLAnalytics.
+func (h *URLHandler) GetURLClickDistribution(c echo.Context) error {
+	code := c.Param("code")
+	if code == "" {
+		log.Error().Msg("Missing URL code in click distribution request")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Missing URL code"})
+	}
+
+	var rng store.ClickRange
+	var err error
+	if rng.From, err = queryTime(c, "from"); err != nil {
+		log.Error().Str("from", c.QueryParam("from")).Msg("Invalid from in click distribution request")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid from"})
+	}
+	if rng.To, err = queryTime(c, "to"); err != nil {
+		log.Error().Str("to", c.QueryParam("to")).Msg("Invalid to in click distribution request")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid to"})
+	}
+	if !rng.From.IsZero() && !rng.To.IsZero() && !rng.From.Before(rng.To) {
+		log.Error().Time("from", rng.From).Time("to", rng.To).Msg("Empty range in click distribution request")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "from must be before to"})
+	}
+	loc, err := store.ParseTimezone(c.QueryParam("tz"))
+	if err != nil {
+		log.Error().Err(err).Str("tz", c.QueryParam("tz")).Msg("Invalid time zone in click distribution request")
+		return err
+	}
+
+	log.Debug().
+		Str("code", code).
+		Time("from", rng.From).
+		Time("to", rng.To).
+		Str("tz", loc.String()).
+		Msg("Getting URL click distribution")
+
+	// Get URL to verify it exists
+	if _, err := h.service.GetByShort(c.Request().Context(), code); err != nil {
+		log.Error().Err(err).Str("code", code).Msg("Failed to retrieve URL for click distribution request")
+		return err
+	}
+
+	distribution, err := h.service.GetClickDistribution(c.Request().Context(), code, rng, loc)
+	if err != nil {
+		log.Error().Err(err).Str("code", code).Msg("Failed to retrieve click distribution")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve analytics data"})
+	}
+
+	log.Info().Str("code", code).Str("tz", loc.String()).Msg("URL click distribution retrieved")
+
+	return c.JSON(http.StatusOK, distribution)
+}
+
+// GetURLAnalyticsChart returns clicks over time for a URL as a PNG chart
+func (h *URLHandler) GetURLAnalyticsChart(c echo.Context) error {
+	code := c.Param("code")
+	if code == "" {
+		log.Error().Msg("Missing URL code in analytics chart request")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Missing URL code"})
+	}
+
+	days, err := queryInt(c, "days", defaultSeriesDays)
+	if err != nil || days == 0 || days > maxSeriesDays {
+		log.Error().Str("days", c.QueryParam("days")).Msg("Invalid days in analytics chart request")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid days"})
+	}
+
+	log.Debug().Str("code", code).Int("days", days).Msg("Rendering URL analytics chart")
+
+	// Get URL to verify it exists
+	if _, err := h.service.GetByShort(c.Request().Context(), code); err != nil {
+		log.Error().Err(err).Str("code", code).Msg("Failed to retrieve URL for analytics chart request")
+		return err
+	}
+
+	series, err := h.service.GetClickTimeSeries(c.Request().Context(), code, days)
+	if err != nil {
+		log.Error().Err(err).Str("code", code).Msg("Failed to retrieve click time series")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve analytics data"})
+	}
+
+	png, err := renderClicksChart("Clicks for /"+code, series)
+	if err != nil {
+		log.Error().Err(err).Str("code", code).Msg("Failed to render analytics chart")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to render chart"})
+	}
+
+	log.Info().
+		Str("code", code).
+		Int("days", days).
+		Int("bytes", len(png)).
+		Msg("URL analytics chart rendered")
+
+	return c.Blob(http.StatusOK, "image/png", png)
+}
+
+// ExportClicks streams every click of a URL, oldest first, as CSV or, for clients
+// accepting application/x-ndjson, as one JSON object per line
+func (h *URLHandler) ExportClicks(c echo.Context) error {
+	code := c.Param("code")
+	if code == "" {
+		log.Error().Msg("Missing URL code in click export request")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Missing URL code"})
+	}
+
+	log.Debug().Str("code", code).Str("accept", c.Request().Header.Get("Accept")).Msg("Exporting clicks")
+
+	// Get URL to verify it exists
+	if _, err := h.service.GetByShort(c.Request().Context(), code); err != nil {
+		log.Error().Err(err).Str("code", code).Msg("Failed to retrieve URL for click export request")
+		return err
+	}
+
+	res := c.Response()
+	var writer clickWriter
+	if strings.Contains(c.Request().Header.Get("Accept"), MIMEApplicationNDJSON) {
+		res.Header().Set(echo.HeaderContentType, MIMEApplicationNDJSON)
+		writer = newNDJSONClickWriter(res)
+	} else {
+		res.Header().Set(echo.HeaderContentType, "text/csv; charset=UTF-8")
+		res.Header().Set(echo.HeaderContentDisposition, `attachment; filename="`+code+`-clicks.csv"`)
+		writer = newCSVClickWriter(res)
+	}
+	res.WriteHeader(http.StatusOK)
+
+	// The status is already sent, so failures past this point can only be logged
+	rows := 0
+	err := h.service.StreamClicks(c.Request().Context(), code, func(click *models.Click) error {
+		if err := writer.Write(click); err != nil {
+			return err
+		}
+		rows++
+		if rows%clickExportFlushEvery == 0 {
+			if err := writer.Flush(); err != nil {
+				return err
+			}
+			res.Flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = writer.Flush()
+	}
+	res.Flush()
+	if err != nil {
+		log.Error().Err(err).Str("code", code).Int("rows", rows).Msg("Click export interrupted")
+		return nil
+	}
+
+	log.Info().Str("code", code).Int("rows", rows).Msg("Clicks exported")
+
+	return nil
+}
+
+// Pagination defaults for list endpoints
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// queryInt parses an optional non-negative integer query parameter
+func queryInt(c echo.Context, name string, defaultValue int) (int, error) {
+	raw := c.QueryParam(name)
+	if raw == "" {
+		return defaultValue, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < 0 {
+		return 0, errors.New("invalid " + name)
+	}
+	return value, nil
+}
+
+// queryTime parses an optional RFC 3339 timestamp or YYYY-MM-DD date (midnight UTC) query
+// parameter, returning the zero time when it is absent
+func queryTime(c echo.Context, name string) (time.Time, error) {
+	raw := c.QueryParam(name)
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.DateOnly, raw)
+	if err != nil {
+		return time.Time{}, errors.New("invalid " + name)
+	}
+	return t, nil
+}
+
+// GetURLHistory returns a filtered page of modification history for a URL
+func (h *URLHandler) GetURLHistory(c echo.Context) error {
+	code := c.Param("code")
+	if code == "" {
+		log.Error().Msg("Missing URL code in history request")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Missing URL code"})
+	}
+
+	limit, err := queryInt(c, "limit", defaultPageLimit)
+	if err != nil || limit == 0 || limit > maxPageLimit {
+		log.Error().Str("limit", c.QueryParam("limit")).Msg("Invalid limit in history request")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid limit"})
+	}
+
+	offset, err := queryInt(c, "offset", 0)
+	if err != nil {
+		log.Error().Str("offset", c.QueryParam("offset")).Msg("Invalid offset in history request")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid offset"})
+	}
+
+	filter := store.HistoryFilter{
+		Action: c.QueryParam("action"),
+		Limit:  limit,
+		Offset: offset,
+	}
+
+	log.Debug().
+		Str("code", code).
+		Str("action", filter.Action).
+		Int("limit", filter.Limit).
+		Int("offset", filter.Offset).
+		Msg("Getting URL history")
+
+	history, total, err := h.service.GetURLHistory(c.Request().Context(), code, filter)
+	if err != nil {
+		log.Error().Err(err).Str("code", code).Str("action", filter.Action).Msg("Failed to retrieve URL history")
+		return err
+	}
+
+	if history == nil {
+		history = []*models.URLHistory{}
+	}
+
+	log.Info().
+		Str("code", code).
+		Int("count", len(history)).
+		Int64("total", total).
+		Msg("URL history retrieved")
+
+	setPaginationLinks(c, filter.Limit, filter.Offset, total)
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"history": history,
+		"total":   total,
+		"limit":   filter.Limit,
+		"offset":  filter.Offset,
+	})
+}
+
+// GetCreatorAnalytics returns click totals across a creator's URLs grouped by the metadata key in group_by
+func (h *URLHandler) GetCreatorAnalytics(c echo.Context) error {
+	creatorReference := c.Param("creator_reference")
+	key := c.QueryParam("group_by")
+	if key == "" {
+		log.Error().Str("creator_reference", creatorReference).Msg("Missing group_by in creator analytics request")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Missing group_by"})
+	}
+
+	log.Debug().Str("creator_reference", creatorReference).Str("group_by", key).Msg("Getting creator analytics")
+
+	groups, err := h.service.GetCreatorAnalyticsByMetadata(c.Request().Context(), creatorReference, key)
+	if err != nil {
+		log.Error().Err(err).Str("creator_reference", creatorReference).Str("group_by", key).Msg("Failed to retrieve creator analytics")
+		return err
+	}
+	if groups == nil {
+		groups = []*models.MetadataClickCount{}
+	}
+
+	log.Info().
+		Str("creator_reference", creatorReference).
+		Str("group_by", key).
+		Int("groups", len(groups)).
+		Msg("Creator analytics retrieved successfully")
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"creator_reference": creatorReference,
+		"group_by":          key,
+		"groups":            groups,
+	})
+}
+
+// CreatorURLsResponse is a page of a creator's URLs
+type CreatorURLsResponse struct {
+	URLs   []URLResponse `json:"urls"`
+	Total  int64         `json:"total"`
+	Limit  int           `json:"limit"`
+	Offset int           `json:"offset"`
+	Sort   string        `json:"sort"`
+	Order  string        `json:"order"`
+}
+
+// GetURLsByCreator returns a sorted page of the URLs created by a specific creator.
+// Without query parameters the newest 20 URLs are returned.
 func (h *URLHandler) GetURLsByCreator(c echo.Context) error {
 	creatorReference := c.Param("creator_reference")
 	if creatorReference == "" {
@@ -505,41 +1796,165 @@ func (h *URLHandler) GetURLsByCreator(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Missing creator reference"})
 	}
 
-	log.Debug().Str("creator_reference", creatorReference).Msg("Getting URLs by creator")
+	limit, err := queryInt(c, "limit", defaultPageLimit)
+	if err != nil || limit == 0 || limit > maxPageLimit {
+		log.Error().Str("limit", c.QueryParam("limit")).Msg("Invalid limit in creator URLs request")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid limit"})
+	}
 
-	// Get URLs by creator
-	urls, err := h.service.GetByCreator(c.Request().Context(), creatorReference)
+	offset, err := queryInt(c, "offset", 0)
 	if err != nil {
-		log.Error().Err(err).Str("creator_reference", creatorReference).Msg("Failed to retrieve URLs by creator")
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve URLs by creator"})
+		log.Error().Str("offset", c.QueryParam("offset")).Msg("Invalid offset in creator URLs request")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid offset"})
 	}
 
-	if len(urls) == 0 {
-		log.Info().Str("creator_reference", creatorReference).Msg("No URLs found for creator")
-		return c.JSON(http.StatusOK, []interface{}{})
+	order := c.QueryParam("order")
+	switch order {
+	case "":
+		order = "desc"
+	case "asc", "desc":
+	default:
+		log.Error().Str("order", order).Msg("Invalid order in creator URLs request")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid order"})
+	}
+
+	filter := store.CreatorURLFilter{
+		Sort:       c.QueryParam("sort"),
+		Descending: order == "desc",
+		Limit:      limit,
+		Offset:     offset,
+	}
+	if filter.Sort == "" {
+		filter.Sort = store.SortByCreatedAt
+	}
+
+	log.Debug().
+		Str("creator_reference", creatorReference).
+		Str("sort", filter.Sort).
+		Str("order", order).
+		Int("limit", filter.Limit).
+		Int("offset", filter.Offset).
+		Msg("Getting URLs by creator")
+
+	urls, total, err := h.service.GetByCreator(c.Request().Context(), creatorReference, filter)
+	if err != nil {
+		log.Error().Err(err).Str("creator_reference", creatorReference).Msg("Failed to retrieve URLs by creator")
+		if errors.Is(err, store.ErrInvalidSort) {
+			return err
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve URLs by creator"})
 	}
 
 	// Convert URLs to response format
-	var response []URLResponse
+	response := make([]URLResponse, 0, len(urls))
 	for _, url := range urls {
-		shortURL := h.baseURL + "/" + url.Short
-		response = append(response, URLResponse{
-			OriginalURL:      url.Original,
-			ShortURL:         shortURL,
-			ShortCode:        url.Short,
-			Title:            url.Title,
-			ExpiresAt:        url.ExpiresAt,
-			CreatedAt:        url.CreatedAt,
-			Clicks:           url.Clicks,
-			CreatorReference: url.CreatorReference,
-		})
+		response = append(response, h.toURLResponse(url))
 	}
 
 	log.Info().
 		Str("creator_reference", creatorReference).
 		Int("count", len(urls)).
+		Int64("total", total).
 		Msg("URLs retrieved by creator successfully")
 
-	// Return URLs
-	return c.JSON(http.StatusOK, response)
+	setPaginationLinks(c, filter.Limit, filter.Offset, total)
+	return c.JSON(http.StatusOK, CreatorURLsResponse{
+		URLs:   response,
+		Total:  total,
+		Limit:  filter.Limit,
+		Offset: filter.Offset,
+		Sort:   filter.Sort,
+		Order:  order,
+	})
+}
+
+// CreatorURLCountResponse is the number of live URLs of a creator
+type CreatorURLCountResponse struct {
+	CreatorReference string `json:"creator_reference"`
+	Count            int64  `json:"count"`
+}
+
+// CountURLsByCreator returns the number of live URLs of a creator, matching the total of GetURLsByCreator
+func (h *URLHandler) CountURLsByCreator(c echo.Context) error {
+	creatorReference := c.Param("creator_reference")
+	if creatorReference == "" {
+		log.Error().Msg("Missing creator reference in count request")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Missing creator reference"})
+	}
+
+	log.Debug().Str("creator_reference", creatorReference).Msg("Counting URLs by creator")
+
+	count, err := h.service.CountByCreator(c.Request().Context(), creatorReference)
+	if err != nil {
+		log.Error().Err(err).Str("creator_reference", creatorReference).Msg("Failed to count URLs by creator")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to count URLs by creator"})
+	}
+
+	log.Info().Str("creator_reference", creatorReference).Int64("count", count).Msg("URLs counted by creator successfully")
+
+	return c.JSON(http.StatusOK, CreatorURLCountResponse{CreatorReference: creatorReference, Count: count})
+}
+
+// GetRawURL returns the stored URL record as-is, including soft-deleted records, for debugging
+func (h *URLHandler) GetRawURL(c echo.Context) error {
+	code := c.Param("code")
+	log.Debug().Str("code", code).Msg("Getting raw URL record")
+
+	url, err := h.service.GetByShortIncludingDeleted(c.Request().Context(), code)
+	if err != nil {
+		log.Error().Err(err).Str("code", code).Msg("Failed to retrieve raw URL record")
+		return err
+	}
+
+	log.Info().Str("code", code).Int64("id", url.ID).Msg("Raw URL record retrieved")
+
+	return c.JSON(http.StatusOK, url)
+}
+
+// RebuildAnalytics recomputes stored click counts from the raw clicks for the code given in the
+// query, or for every URL when no code is given
+func (h *URLHandler) RebuildAnalytics(c echo.Context) error {
+	code := c.QueryParam("code")
+	log.Debug().Str("code", code).Msg("Rebuilding analytics")
+
+	rebuilt, err := h.service.RebuildClickCounts(c.Request().Context(), code)
+	if err != nil {
+		log.Error().Err(err).Str("code", code).Int("rebuilt", rebuilt).Msg("Failed to rebuild analytics")
+		return err
+	}
+
+	log.Info().Str("code", code).Int("rebuilt", rebuilt).Msg("Analytics rebuilt")
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"rebuilt": rebuilt})
+}
+
+// GetConfig returns the effective configuration with secrets redacted
+func (h *URLHandler) GetConfig(c echo.Context) error {
+	log.Debug().Msg("Getting effective configuration")
+
+	redacted := h.cfg.Redacted()
+
+	log.Info().Msg("Effective configuration returned")
+	return c.JSON(http.StatusOK, redacted)
+}
+
+// SelfTest runs an end-to-end create→resolve→delete check and reports per-step timings
+func (h *URLHandler) SelfTest(c echo.Context) error {
+	log.Debug().Str("creator_reference", h.selfTestCreator).Msg("Running self-test")
+
+	result := h.service.SelfTest(c.Request().Context(), h.selfTestCreator)
+	if !result.Success {
+		log.Error().
+			Str("short", result.ShortCode).
+			Interface("steps", result.Steps).
+			Msg("Self-test failed")
+		return c.JSON(http.StatusServiceUnavailable, result)
+	}
+
+	log.Info().
+		Str("short", result.ShortCode).
+		Float64("total_ms", result.TotalMS).
+		Msg("Self-test passed")
+
+	return c.JSON(http.StatusOK, result)
 }
@@ -3,12 +3,19 @@ package handlers
 import (
 	"bytes"
 	"context"
+	"encoding/csv"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/fransfilastap/urlshortener/config"
 	"github.com/fransfilastap/urlshortener/models"
 	"github.com/fransfilastap/urlshortener/store"
 	"github.com/labstack/echo/v4"
@@ -209,30 +216,6 @@ func (h *TestURLHandler) DeleteURL(c echo.Context) error {
 	return c.JSON(http.StatusOK, map[string]string{"message": "URL deleted successfully"})
 }
 
-// TestHealthCheck tests the health check endpoint
-func TestHealthCheck(t *testing.T) {
-	// Setup
-	e := echo.New()
-	req := httptest.NewRequest(http.MethodGet, "/health", nil)
-	rec := httptest.NewRecorder()
-	c := e.NewContext(req, rec)
-
-	// Create a simple handler for the health check
-	handler := func(c echo.Context) error {
-		return c.JSON(http.StatusOK, map[string]string{"status": "ok"})
-	}
-
-	// Call the handler
-	if assert.NoError(t, handler(c)) {
-		// Assertions
-		assert.Equal(t, http.StatusOK, rec.Code)
-		var response map[string]string
-		err := json.Unmarshal(rec.Body.Bytes(), &response)
-		assert.NoError(t, err)
-		assert.Equal(t, "ok", response["status"])
-	}
-}
-
 // TestShortenURL tests the ShortenURL handler
 func TestShortenURL(t *testing.T) {
 	// Setup
@@ -263,7 +246,7 @@ func TestShortenURL(t *testing.T) {
 			Short:            "custom",
 			Title:            "Example",
 			CreatedAt:        time.Now(),
-			ExpiresAt:        time.Now().Add(time.Hour),
+			ExpiresAt:        timePtr(time.Now().Add(time.Hour)),
 			Clicks:           0,
 			CreatorReference: "test-user",
 		}
@@ -342,7 +325,7 @@ func TestRedirectURL(t *testing.T) {
 			Original:  "https://example.com",
 			Short:     "abc123",
 			CreatedAt: time.Now(),
-			ExpiresAt: time.Now().Add(time.Hour),
+			ExpiresAt: timePtr(time.Now().Add(time.Hour)),
 			Clicks:    0,
 		}
 		mockService.On("GetByShort", mock.Anything, "abc123").Return(url, nil)
@@ -397,8 +380,7 @@ func TestGetURLInfo(t *testing.T) {
 	// Test case: Valid short URL
 	t.Run("ValidShortURL", func(t *testing.T) {
 		// Setup request
-		req := httptest.NewRequest(http.MethodGet, "/api/urls/:code", nil)
-		req.Header.Set("X-API-Key", "test-api-key")
+		req := newAPIRequest(http.MethodGet, "/api/urls/:code", "")
 		rec := httptest.NewRecorder()
 		c := e.NewContext(req, rec)
 		c.SetParamNames("code")
@@ -410,7 +392,7 @@ func TestGetURLInfo(t *testing.T) {
 			Short:            "abc123",
 			Title:            "Example",
 			CreatedAt:        time.Now(),
-			ExpiresAt:        time.Now().Add(time.Hour),
+			ExpiresAt:        timePtr(time.Now().Add(time.Hour)),
 			Clicks:           10,
 			CreatorReference: "test-user",
 		}
@@ -446,8 +428,7 @@ func TestDeleteURL(t *testing.T) {
 	// Test case: Valid delete
 	t.Run("ValidDelete", func(t *testing.T) {
 		// Setup request
-		req := httptest.NewRequest(http.MethodDelete, "/api/urls/:code", nil)
-		req.Header.Set("X-API-Key", "test-api-key")
+		req := newAPIRequest(http.MethodDelete, "/api/urls/:code", "")
 		rec := httptest.NewRecorder()
 		c := e.NewContext(req, rec)
 		c.SetParamNames("code")
@@ -460,7 +441,7 @@ func TestDeleteURL(t *testing.T) {
 			Short:            "abc123",
 			Title:            "Example",
 			CreatedAt:        time.Now(),
-			ExpiresAt:        time.Now().Add(time.Hour),
+			ExpiresAt:        timePtr(time.Now().Add(time.Hour)),
 			Clicks:           10,
 			CreatorReference: "test-user",
 		}
@@ -508,9 +489,2299 @@ func TestURLHandler_Integration(t *testing.T) {
 
 	// Test the health check endpoint
 	req := httptest.NewRequest(http.MethodGet, "/health", nil)
+	rec := serveRequest(e, req)
+
+	// Assertions
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+// timePtr returns a pointer to t
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+// newTestConfig returns a configuration suitable for exercising the real URLHandler
+func newTestConfig() *config.Config {
+	return &config.Config{
+		BaseURL:         "http://localhost:8080",
+		APIKey:          "test-api-key",
+		AdminAPIKey:     "test-admin-key",
+		SelfTestCreator: "__selftest__",
+	}
+}
+
+// newRealTestServer wires the real URLHandler to an in-memory repository without a cache. Destination
+// checks may reach local test servers.
+func newRealTestServer(repo *fakeRepository, cfg *config.Config) *echo.Echo {
+	e := echo.New()
+	service := store.NewURLService(repo, nil, store.WithCreatorDefaults(repo), store.WithPrivateReachabilityChecks())
+	NewURLHandler(service, cfg).Register(e)
+	return e
+}
+
+// chdirRepoRoot switches to the repository root so handlers can load templates from static/
+func chdirRepoRoot(t *testing.T) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(".."); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+}
+
+// newAPIRequest builds a request to an API route authenticated with the test API key; a non-empty body is sent as JSON
+func newAPIRequest(method, path, body string) *http.Request {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	}
+	req.Header.Set("X-API-Key", "test-api-key")
+	return req
+}
+
+// serveRequest serves req with e and returns the recorded response
+func serveRequest(e *echo.Echo, req *http.Request) *httptest.ResponseRecorder {
 	rec := httptest.NewRecorder()
 	e.ServeHTTP(rec, req)
+	return rec
+}
+
+// serveAPI serves a request built by newAPIRequest
+func serveAPI(e *echo.Echo, method, path, body string) *httptest.ResponseRecorder {
+	return serveRequest(e, newAPIRequest(method, path, body))
+}
+
+// TestSelfTest tests the admin self-test endpoint
+func TestSelfTest(t *testing.T) {
+	t.Run("ReportsStepTimingsAndCleansUp", func(t *testing.T) {
+		repo := newFakeRepository()
+		cfg := newTestConfig()
+		cfg.SelfTestEnabled = true
+		e := newRealTestServer(repo, cfg)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/selftest", nil)
+		req.Header.Set("X-Admin-Key", "test-admin-key")
+		rec := serveRequest(e, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var result store.SelfTestResult
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result))
+		assert.True(t, result.Success)
+		assert.NotEmpty(t, result.ShortCode)
+
+		var names []string
+		for _, step := range result.Steps {
+			names = append(names, step.Name)
+			assert.GreaterOrEqual(t, step.DurationMS, float64(0))
+			assert.Empty(t, step.Error)
+		}
+		assert.Equal(t, []string{"generate", "create", "resolve", "delete"}, names)
+		assert.GreaterOrEqual(t, result.TotalMS, float64(0))
+
+		// No residual data, not even a soft-deleted row
+		assert.Equal(t, 0, repo.count())
+	})
+
+	t.Run("RequiresAdminKey", func(t *testing.T) {
+		cfg := newTestConfig()
+		cfg.SelfTestEnabled = true
+		e := newRealTestServer(newFakeRepository(), cfg)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/selftest", nil)
+		req.Header.Set("X-Admin-Key", "test-api-key")
+		rec := serveRequest(e, req)
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("DisabledByDefault", func(t *testing.T) {
+		e := newRealTestServer(newFakeRepository(), newTestConfig())
+
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/selftest", nil)
+		req.Header.Set("X-Admin-Key", "test-admin-key")
+		rec := serveRequest(e, req)
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
+
+// TestBeacon tests counting clicks only once the visitor proceeds past the interstitial
+func TestBeacon(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("InterstitialViewWithoutBeaconDoesNotCount", func(t *testing.T) {
+		repo := newFakeRepository()
+		_, err := repo.Create(ctx, models.NewURL("https://example.com", "abc123", "", time.Time{}, "test-user"))
+		assert.NoError(t, err)
+		cfg := newTestConfig()
+		cfg.ClickCountMode = config.ClickCountModeProceed
+		e := newRealTestServer(repo, cfg)
+
+		req := httptest.NewRequest(http.MethodGet, "/abc123", nil)
+		req.Header.Set("Accept", "text/html")
+		serveRequest(e, req)
+
+		url, err := repo.GetByShort(ctx, "abc123")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), url.Clicks)
+		clicks, _ := repo.GetClicksByShort(ctx, "abc123")
+		assert.Empty(t, clicks)
+	})
+
+	t.Run("BeaconCounts", func(t *testing.T) {
+		repo := newFakeRepository()
+		_, err := repo.Create(ctx, models.NewURL("https://example.com", "abc123", "", time.Time{}, "test-user"))
+		assert.NoError(t, err)
+		cfg := newTestConfig()
+		cfg.ClickCountMode = config.ClickCountModeProceed
+		e := newRealTestServer(repo, cfg)
+
+		req := httptest.NewRequest(http.MethodPost, "/abc123/beacon", nil)
+		rec := serveRequest(e, req)
+
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		url, err := repo.GetByShort(ctx, "abc123")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), url.Clicks)
+	})
+
+	t.Run("BeaconIgnoredInViewMode", func(t *testing.T) {
+		repo := newFakeRepository()
+		_, err := repo.Create(ctx, models.NewURL("https://example.com", "abc123", "", time.Time{}, "test-user"))
+		assert.NoError(t, err)
+		e := newRealTestServer(repo, newTestConfig())
+
+		req := httptest.NewRequest(http.MethodPost, "/abc123/beacon", nil)
+		rec := serveRequest(e, req)
+
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		url, err := repo.GetByShort(ctx, "abc123")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), url.Clicks)
+	})
+
+	t.Run("BeaconUnknownCode", func(t *testing.T) {
+		cfg := newTestConfig()
+		cfg.ClickCountMode = config.ClickCountModeProceed
+		e := newRealTestServer(newFakeRepository(), cfg)
+
+		req := httptest.NewRequest(http.MethodPost, "/missing/beacon", nil)
+		rec := serveRequest(e, req)
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
+
+// TestGetURLHistory tests filtering and paging through URL history
+func TestGetURLHistory(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeRepository()
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, repo.LogURLHistory(ctx, 1, "abc123", "update", map[string]int{"version": i}, map[string]int{"version": i + 1}, "test-user"))
+	}
+	assert.NoError(t, repo.LogURLHistory(ctx, 1, "abc123", "delete", nil, nil, "test-user"))
+	assert.NoError(t, repo.LogURLHistory(ctx, 2, "other", "update", nil, nil, "test-user"))
+	e := newRealTestServer(repo, newTestConfig())
+
+	type historyResponse struct {
+		History []models.URLHistory `json:"history"`
+		Total   int64               `json:"total"`
+		Limit   int                 `json:"limit"`
+		Offset  int                 `json:"offset"`
+	}
+
+	get := func(query string) (int, historyResponse) {
+		rec := serveAPI(e, http.MethodGet, "/api/urls/abc123/history"+query, "")
+		var response historyResponse
+		_ = json.Unmarshal(rec.Body.Bytes(), &response)
+		return rec.Code, response
+	}
+
+	t.Run("AllActions", func(t *testing.T) {
+		code, response := get("")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, int64(6), response.Total)
+		assert.Len(t, response.History, 6)
+		assert.Equal(t, "delete", response.History[0].Action)
+	})
+
+	t.Run("FilterUpdates", func(t *testing.T) {
+		code, response := get("?action=update")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, int64(5), response.Total)
+		for _, entry := range response.History {
+			assert.Equal(t, "update", entry.Action)
+			assert.Equal(t, "abc123", entry.URLShort)
+		}
+	})
+
+	t.Run("PageThroughUpdates", func(t *testing.T) {
+		var seen []int64
+		for offset := 0; offset < 6; offset += 2 {
+			code, response := get("?action=update&limit=2&offset=" + strconv.Itoa(offset))
+			assert.Equal(t, http.StatusOK, code)
+			assert.Equal(t, int64(5), response.Total)
+			assert.Equal(t, 2, response.Limit)
+			assert.Equal(t, offset, response.Offset)
+			for _, entry := range response.History {
+				seen = append(seen, entry.ID)
+			}
+		}
+		assert.Equal(t, []int64{5, 4, 3, 2, 1}, seen)
+	})
+
+	t.Run("InvalidParameters", func(t *testing.T) {
+		for _, query := range []string{"?action=rename", "?limit=0", "?limit=1000", "?offset=-1", "?limit=abc"} {
+			code, _ := get(query)
+			assert.Equal(t, http.StatusBadRequest, code, query)
+		}
+	})
+}
+
+// TestNonExpiringURLOmitsExpiresAt tests that links without expiry omit expires_at in JSON
+func TestNonExpiringURLOmitsExpiresAt(t *testing.T) {
+	e := newRealTestServer(newFakeRepository(), newTestConfig())
+
+	// Shorten without an expiry
+	reqJSON, _ := json.Marshal(ShortenRequest{URL: "https://example.com", CustomCode: "forever"})
+	rec := serveAPI(e, http.MethodPost, "/api/shorten", string(reqJSON))
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "expires_at")
+
+	// Fetch the URL info
+	rec = serveAPI(e, http.MethodGet, "/api/urls/forever", "")
 
-	// Assertions
 	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "expires_at")
+
+	// An expiring link still reports its expiry
+	reqJSON, _ = json.Marshal(ShortenRequest{URL: "https://example.com/soon", CustomCode: "soon", Expiry: 3600})
+	rec = serveAPI(e, http.MethodPost, "/api/shorten", string(reqJSON))
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	var response URLResponse
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	if assert.NotNil(t, response.ExpiresAt) {
+		assert.WithinDuration(t, time.Now().Add(time.Hour), *response.ExpiresAt, time.Minute)
+	}
+}
+
+// TestRedirectNotFound tests content negotiation for missing short codes
+func TestRedirectNotFound(t *testing.T) {
+	chdirRepoRoot(t)
+
+	t.Run("HTMLClientGetsBrandedPage", func(t *testing.T) {
+		e := newRealTestServer(newFakeRepository(), newTestConfig())
+
+		req := httptest.NewRequest(http.MethodGet, "/missing", nil)
+		req.Header.Set("Accept", "text/html,application/xhtml+xml")
+		rec := serveRequest(e, req)
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Contains(t, rec.Header().Get(echo.HeaderContentType), "text/html")
+		assert.Contains(t, rec.Body.String(), "Link not found")
+		assert.Contains(t, rec.Body.String(), "/missing")
+	})
+
+	t.Run("APIClientGetsJSON", func(t *testing.T) {
+		e := newRealTestServer(newFakeRepository(), newTestConfig())
+
+		req := httptest.NewRequest(http.MethodGet, "/missing", nil)
+		req.Header.Set("Accept", "application/json")
+		rec := serveRequest(e, req)
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Contains(t, rec.Header().Get(echo.HeaderContentType), "application/json")
+		var response map[string]string
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		assert.Equal(t, "URL not found", response["error"])
+	})
+}
+
+// blockingRepository holds click recording open until released and tracks peak concurrency
+type blockingRepository struct {
+	*fakeRepository
+	release  chan struct{}
+	mu       sync.Mutex
+	inFlight int
+	peak     int
+	calls    int
+}
+
+func (r *blockingRepository) HasRecentClick(ctx context.Context, short string, ip string, browser string, device string, window time.Duration) (bool, error) {
+	r.mu.Lock()
+	r.calls++
+	r.inFlight++
+	if r.inFlight > r.peak {
+		r.peak = r.inFlight
+	}
+	r.mu.Unlock()
+
+	<-r.release
+
+	r.mu.Lock()
+	r.inFlight--
+	r.mu.Unlock()
+	return r.fakeRepository.HasRecentClick(ctx, short, ip, browser, device, window)
+}
+
+// TestClickWorkerLimit tests that clicks are tracked by a fixed pool of workers and overflow beyond the
+// queue is dropped
+func TestClickWorkerLimit(t *testing.T) {
+	ctx := context.Background()
+	repo := &blockingRepository{fakeRepository: newFakeRepository(), release: make(chan struct{})}
+	_, err := repo.Create(ctx, models.NewURL("https://example.com", "abc123", "", time.Time{}, "test-user"))
+	assert.NoError(t, err)
+
+	service := store.NewURLService(repo, nil, store.WithClickWorkers(5, 10))
+	handler := NewURLHandler(service, newTestConfig())
+	e := echo.New()
+	handler.Register(e)
+
+	redirect := func(n int) {
+		for i := 0; i < n; i++ {
+			req := httptest.NewRequest(http.MethodGet, "/abc123", nil)
+			rec := serveRequest(e, req)
+			assert.Equal(t, http.StatusFound, rec.Code)
+		}
+	}
+
+	// The first five clicks hold every worker
+	redirect(5)
+	assert.Eventually(t, func() bool {
+		repo.mu.Lock()
+		defer repo.mu.Unlock()
+		return repo.inFlight == 5
+	}, time.Second, 10*time.Millisecond)
+
+	// The next ten fill the queue, so the rest are dropped
+	redirect(45)
+	assert.Equal(t, int64(35), handler.DroppedClicks())
+
+	// Closing the service tracks the queued clicks before returning
+	close(repo.release)
+	service.Close()
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	assert.Zero(t, repo.inFlight)
+	assert.Equal(t, 15, repo.calls)
+	assert.LessOrEqual(t, repo.peak, 5)
+}
+
+// TestClickQueueDrainsOnClose tests that closing the service records every queued click and drops later ones
+func TestClickQueueDrainsOnClose(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeRepository()
+	_, err := repo.Create(ctx, models.NewURL("https://example.com", "abc123", "", time.Time{}, "test-user"))
+	assert.NoError(t, err)
+	service := store.NewURLService(repo, nil, store.WithClickWorkers(2, 100))
+
+	for i := 0; i < 20; i++ {
+		assert.True(t, service.EnqueueClick(&store.ClickContext{Short: "abc123", IP: fmt.Sprintf("10.0.0.%d", i+1)}))
+	}
+	service.Close()
+
+	url, err := repo.GetByShort(ctx, "abc123")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(20), url.Clicks)
+	assert.False(t, service.EnqueueClick(&store.ClickContext{Short: "abc123", IP: "10.0.1.1"}))
+}
+
+// TestGetRawURL tests the admin raw URL record endpoint
+func TestGetRawURL(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("ReturnsSoftDeletedRecord", func(t *testing.T) {
+		repo := newFakeRepository()
+		_, err := repo.Create(ctx, models.NewURL("https://example.com", "abc123", "Example", time.Time{}, "test-user"))
+		assert.NoError(t, err)
+		assert.NoError(t, repo.Delete(ctx, "abc123"))
+		e := newRealTestServer(repo, newTestConfig())
+
+		// The normal endpoint no longer sees the deleted record
+		rec := serveAPI(e, http.MethodGet, "/api/urls/abc123", "")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/urls/abc123/raw", nil)
+		req.Header.Set("X-Admin-Key", "test-admin-key")
+		rec = serveRequest(e, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var url models.URL
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &url))
+		assert.Equal(t, int64(1), url.ID)
+		assert.Equal(t, "https://example.com", url.Original)
+		assert.Equal(t, "test-user", url.CreatorReference)
+		assert.NotNil(t, url.DeletedAt)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		e := newRealTestServer(newFakeRepository(), newTestConfig())
+
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/urls/missing/raw", nil)
+		req.Header.Set("X-Admin-Key", "test-admin-key")
+		rec := serveRequest(e, req)
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("RequiresAdminKey", func(t *testing.T) {
+		e := newRealTestServer(newFakeRepository(), newTestConfig())
+
+		rec := serveAPI(e, http.MethodGet, "/api/admin/urls/abc123/raw", "")
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+}
+
+// TestShortenURLReuseExisting tests how reuse_existing combines with custom codes under each conflict policy
+func TestShortenURLReuseExisting(t *testing.T) {
+	ctx := context.Background()
+
+	cases := []struct {
+		name          string
+		policy        store.ReuseConflictPolicy
+		creator       string
+		customCode    string
+		reuseExisting bool
+		wantStatus    int
+		wantShort     string
+		wantURLs      int
+	}{
+		{"ReuseWithoutCustomCode", store.ReuseConflictError, "test-user", "", true, http.StatusOK, "existing", 1},
+		{"ReuseWithMatchingCustomCode", store.ReuseConflictError, "test-user", "existing", true, http.StatusOK, "existing", 1},
+		{"ConflictRejectedByDefault", store.ReuseConflictError, "test-user", "custom", true, http.StatusConflict, "", 1},
+		{"ConflictPrefersCustom", store.ReuseConflictPreferCustom, "test-user", "custom", true, http.StatusCreated, "custom", 2},
+		{"ConflictPrefersExisting", store.ReuseConflictPreferExisting, "test-user", "custom", true, http.StatusOK, "existing", 1},
+		{"OtherCreatorNotReused", store.ReuseConflictError, "other-user", "custom", true, http.StatusCreated, "custom", 2},
+		{"CustomCodeWithoutReuse", store.ReuseConflictError, "test-user", "custom", false, http.StatusCreated, "custom", 2},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := newFakeRepository()
+			_, err := repo.Create(ctx, models.NewURL("https://example.com", "existing", "", time.Time{}, "test-user"))
+			assert.NoError(t, err)
+			e := echo.New()
+			service := store.NewURLService(repo, nil, store.WithReuseConflictPolicy(tc.policy))
+			NewURLHandler(service, newTestConfig()).Register(e)
+
+			body, _ := json.Marshal(ShortenRequest{
+				URL:              "https://example.com",
+				CustomCode:       tc.customCode,
+				CreatorReference: tc.creator,
+				ReuseExisting:    tc.reuseExisting,
+			})
+			rec := serveAPI(e, http.MethodPost, "/api/shorten", string(body))
+
+			assert.Equal(t, tc.wantStatus, rec.Code)
+			assert.Equal(t, tc.wantURLs, repo.count())
+			if tc.wantStatus == http.StatusConflict {
+				var response map[string]string
+				assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+				assert.NotEmpty(t, response["error"])
+				assert.Equal(t, "http://localhost:8080/existing", response["existing_short_url"])
+				return
+			}
+			var response ShortenResponse
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+			assert.Equal(t, "http://localhost:8080/"+tc.wantShort, response.ShortURL)
+			// Only brand-new links are reported as created, with 201 instead of 200
+			assert.Equal(t, tc.wantStatus == http.StatusCreated, response.Created)
+		})
+	}
+}
+
+// TestShortenURLReuseOnlyLiveOwnLinks tests that reuse_existing skips expired links and other creators' links
+func TestShortenURLReuseOnlyLiveOwnLinks(t *testing.T) {
+	ctx := context.Background()
+
+	shorten := func(e *echo.Echo) ShortenResponse {
+		rec := serveAPI(e, http.MethodPost, "/api/shorten", `{"url": "https://example.com/reuse", "creator_reference": "test-user", "reuse_existing": true}`)
+		var response ShortenResponse
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		return response
+	}
+
+	t.Run("ExpiredNotReused", func(t *testing.T) {
+		repo := newFakeRepository()
+		_, err := repo.Create(ctx, models.NewURL("https://example.com/reuse", "expired", "", time.Now().Add(-time.Hour), "test-user"))
+		assert.NoError(t, err)
+		e := newRealTestServer(repo, newTestConfig())
+
+		response := shorten(e)
+		assert.True(t, response.Created)
+		assert.NotEqual(t, "http://localhost:8080/expired", response.ShortURL)
+	})
+
+	t.Run("OwnLinkFoundAmongOthers", func(t *testing.T) {
+		repo := newFakeRepository()
+		_, err := repo.Create(ctx, models.NewURL("https://example.com/reuse", "mine", "", time.Time{}, "test-user"))
+		assert.NoError(t, err)
+		_, err = repo.Create(ctx, models.NewURL("https://example.com/reuse", "theirs", "", time.Time{}, "other-user"))
+		assert.NoError(t, err)
+		_, err = repo.Create(ctx, models.NewURL("https://example.com/reuse", "old", "", time.Now().Add(-time.Hour), "test-user"))
+		assert.NoError(t, err)
+		e := newRealTestServer(repo, newTestConfig())
+
+		response := shorten(e)
+		assert.False(t, response.Created)
+		assert.Equal(t, "http://localhost:8080/mine", response.ShortURL)
+		assert.Equal(t, 3, repo.count())
+	})
+}
+
+// TestShortenURLReuseConcurrent tests that concurrent reuse_existing requests for the same URL create one link
+func TestShortenURLReuseConcurrent(t *testing.T) {
+	repo := newFakeRepository()
+	e := newRealTestServer(repo, newTestConfig())
+
+	const requests = 10
+	responses := make([]ShortenResponse, requests)
+	var wg sync.WaitGroup
+	for i := range responses {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rec := serveAPI(e, http.MethodPost, "/api/shorten", `{"url": "https://example.com/concurrent", "creator_reference": "test-user", "reuse_existing": true}`)
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &responses[i]))
+		}()
+	}
+	wg.Wait()
+
+	created := 0
+	for _, response := range responses {
+		assert.Equal(t, responses[0].ShortURL, response.ShortURL)
+		if response.Created {
+			created++
+		}
+	}
+	assert.Equal(t, 1, created)
+	assert.Equal(t, 1, repo.count())
+}
+
+// TestRedirectRateLimit tests throttling redirects of a rate-limited URL
+func TestRedirectRateLimit(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeRepository()
+	e := echo.New()
+	NewURLHandler(store.NewURLService(repo, store.NewInMemoryCache(10, time.Hour)), newTestConfig()).Register(e)
+
+	shorten := func(rateLimit int) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(ShortenRequest{URL: "https://example.com", CustomCode: "limited", CreatorReference: "test-user", RateLimit: rateLimit})
+		return serveAPI(e, http.MethodPost, "/api/shorten", string(body))
+	}
+	redirect := func(code string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/"+code, nil)
+		return serveRequest(e, req)
+	}
+
+	t.Run("RejectsNegativeLimit", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, shorten(-1).Code)
+	})
+
+	t.Run("ThrottlesPastLimit", func(t *testing.T) {
+		rec := shorten(3)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		var response URLResponse
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		assert.Equal(t, 3, response.RateLimit)
+
+		for i := 0; i < 3; i++ {
+			assert.Equal(t, http.StatusFound, redirect("limited").Code)
+		}
+		rec = redirect("limited")
+		assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+		retryAfter, err := strconv.Atoi(rec.Header().Get("Retry-After"))
+		assert.NoError(t, err)
+		assert.True(t, retryAfter >= 1 && retryAfter <= 60, retryAfter)
+	})
+
+	t.Run("UnlimitedURLsAreNotThrottled", func(t *testing.T) {
+		_, err := repo.Create(ctx, models.NewURL("https://example.com/free", "free", "", time.Time{}, "test-user"))
+		assert.NoError(t, err)
+		for i := 0; i < 10; i++ {
+			assert.Equal(t, http.StatusFound, redirect("free").Code)
+		}
+	})
+
+	t.Run("UpdateRemovesLimit", func(t *testing.T) {
+		body := []byte(`{"rate_limit": 0, "creator_reference": "test-user"}`)
+		rec := serveAPI(e, http.MethodPut, "/api/urls/limited", string(body))
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		assert.Equal(t, http.StatusFound, redirect("limited").Code)
+	})
+}
+
+// TestGetURLTimeSeries tests interval selection and coarsening for the time series endpoint
+func TestGetURLTimeSeries(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeRepository()
+	created, err := repo.Create(ctx, models.NewURL("https://example.com", "abc123", "", time.Time{}, "test-user"))
+	assert.NoError(t, err)
+	assert.NoError(t, repo.StoreClick(ctx, models.NewClick(created.ID, "abc123", "10.0.0.1", "Unknown", "Chrome", "Desktop")))
+	e := echo.New()
+	service := store.NewURLService(repo, nil, store.WithMaxSeriesBuckets(500))
+	NewURLHandler(service, newTestConfig()).Register(e)
+
+	get := func(query string) *httptest.ResponseRecorder {
+		return serveAPI(e, http.MethodGet, "/api/urls/abc123/analytics/timeseries"+query, "")
+	}
+	type response struct {
+		Interval          string                    `json:"interval"`
+		RequestedInterval string                    `json:"requested_interval"`
+		Points            []*models.TimeSeriesPoint `json:"points"`
+	}
+
+	t.Run("OverGranularIsCoarsened", func(t *testing.T) {
+		rec := get("?days=365&interval=minute")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var body response
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		assert.Equal(t, "minute", body.RequestedInterval)
+		assert.Equal(t, "day", body.Interval)
+		assert.Len(t, body.Points, 365)
+	})
+
+	t.Run("ReasonablePassesThrough", func(t *testing.T) {
+		rec := get("?days=1&interval=hour")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var body response
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		assert.Equal(t, "hour", body.Interval)
+		if assert.Len(t, body.Points, 24) {
+			assert.Equal(t, int64(1), body.Points[23].Clicks)
+		}
+	})
+
+	t.Run("InvalidInterval", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, get("?interval=second").Code)
+	})
+
+	t.Run("InvalidTimezone", func(t *testing.T) {
+		rec := get("?tz=Mars/Olympus_Mons")
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), "invalid_timezone")
+	})
+
+	t.Run("TimezoneShiftsDayBucket", func(t *testing.T) {
+		// 23:30 UTC yesterday is 08:30 the next day in Tokyo
+		tokyo, err := time.LoadLocation("Asia/Tokyo")
+		assert.NoError(t, err)
+		clickTime := time.Now().UTC().Truncate(24 * time.Hour).Add(-30 * time.Minute)
+		click := models.NewClick(created.ID, "abc123", "10.0.0.2", "Unknown", "Chrome", "Desktop")
+		click.Timestamp = clickTime
+		assert.NoError(t, repo.StoreClick(ctx, click))
+
+		clicksOn := func(query string, day time.Time) int64 {
+			rec := get(query)
+			assert.Equal(t, http.StatusOK, rec.Code)
+			var body response
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+			for _, point := range body.Points {
+				if point.Time.Equal(day) {
+					return point.Clicks
+				}
+			}
+			t.Fatalf("no bucket for %s in %s", day, rec.Body.String())
+			return 0
+		}
+
+		utcDay := clickTime.Truncate(24 * time.Hour)
+		local := clickTime.In(tokyo)
+		tokyoDay := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, tokyo)
+		assert.Equal(t, int64(1), clicksOn("?days=3&interval=day", utcDay))
+		assert.Equal(t, int64(0), clicksOn("?days=3&interval=day&tz=Asia/Tokyo", tokyoDay.AddDate(0, 0, -1)))
+		assert.Equal(t, int64(1), clicksOn("?days=3&interval=day&tz=Asia/Tokyo", tokyoDay))
+		assert.Equal(t, utcDay.AddDate(0, 0, 1), time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC))
+	})
+}
+
+// TestNotFoundRedirect tests the fallback redirect for unknown and expired codes
+func TestNotFoundRedirect(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeRepository()
+	_, err := repo.Create(ctx, models.NewURL("https://example.com", "expired", "", time.Now().Add(-time.Hour), "test-user"))
+	assert.NoError(t, err)
+
+	serve := func(e *echo.Echo, path string, apiKey bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if apiKey {
+			req.Header.Set("X-API-Key", "test-api-key")
+		}
+		return serveRequest(e, req)
+	}
+
+	t.Run("ConfiguredFallback", func(t *testing.T) {
+		cfg := newTestConfig()
+		cfg.NotFoundRedirectURL = "https://example.org/search"
+		e := newRealTestServer(repo, cfg)
+
+		for _, path := range []string{"/missing", "/expired"} {
+			rec := serve(e, path, false)
+			assert.Equal(t, http.StatusFound, rec.Code, path)
+			assert.Equal(t, "https://example.org/search", rec.Header().Get("Location"), path)
+		}
+
+		// API endpoints keep returning 404
+		assert.Equal(t, http.StatusNotFound, serve(e, "/api/urls/missing", true).Code)
+	})
+
+	t.Run("DefaultNotFound", func(t *testing.T) {
+		e := newRealTestServer(repo, newTestConfig())
+
+		rec := serve(e, "/missing", false)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Empty(t, rec.Header().Get("Location"))
+	})
+
+	t.Run("ExpiredGone", func(t *testing.T) {
+		e := newRealTestServer(repo, newTestConfig())
+
+		for _, path := range []string{"/expired", "/api/urls/expired"} {
+			rec := serve(e, path, true)
+			assert.Equal(t, http.StatusGone, rec.Code, path)
+			var response map[string]string
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response), path)
+			assert.Equal(t, "url_expired", response["code"], path)
+		}
+
+		// Unknown codes are still reported as missing
+		assert.Equal(t, http.StatusNotFound, serve(e, "/api/urls/missing", true).Code)
+	})
+
+	t.Run("ExpiredPage", func(t *testing.T) {
+		chdirRepoRoot(t)
+		e := newRealTestServer(repo, newTestConfig())
+
+		req := httptest.NewRequest(http.MethodGet, "/expired", nil)
+		req.Header.Set("Accept", "text/html")
+		rec := serveRequest(e, req)
+		assert.Equal(t, http.StatusGone, rec.Code)
+		assert.Contains(t, rec.Body.String(), "Link expired")
+	})
+}
+
+// TestRebuildAnalytics tests that rebuilt click counts match the raw clicks
+func TestRebuildAnalytics(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeRepository()
+	counts := map[string]int{"aaa111": 3, "bbb222": 0, "ccc333": 1}
+	for short, clicks := range counts {
+		url, err := repo.Create(ctx, models.NewURL("https://example.com/"+short, short, "", time.Time{}, "test-user"))
+		assert.NoError(t, err)
+		for i := 0; i < clicks; i++ {
+			assert.NoError(t, repo.StoreClick(ctx, models.NewClick(url.ID, short, "127.0.0.1", "Unknown", "Chrome", "Desktop")))
+		}
+		// Simulate a drifted counter
+		repo.urls[short].Clicks = 42
+	}
+	e := newRealTestServer(repo, newTestConfig())
+
+	rebuild := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/admin/analytics/rebuild"+query, nil)
+		req.Header.Set("X-Admin-Key", "test-admin-key")
+		return serveRequest(e, req)
+	}
+
+	t.Run("SingleCode", func(t *testing.T) {
+		rec := rebuild("?code=aaa111")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"rebuilt":1}`, rec.Body.String())
+		assert.Equal(t, int64(3), repo.urls["aaa111"].Clicks)
+		assert.Equal(t, int64(42), repo.urls["bbb222"].Clicks)
+	})
+
+	t.Run("AllCodes", func(t *testing.T) {
+		rec := rebuild("")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"rebuilt":3}`, rec.Body.String())
+		for short, clicks := range counts {
+			assert.Equal(t, int64(clicks), repo.urls[short].Clicks, short)
+		}
+	})
+
+	t.Run("UnknownCode", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, rebuild("?code=missing").Code)
+	})
+}
+
+// TestRedirectHeaders tests that only allowlisted custom headers are set on redirects
+func TestRedirectHeaders(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.RedirectHeaderNames = []string{"cache-control", "X-Campaign"}
+	e := newRealTestServer(newFakeRepository(), cfg)
+
+	rec := serveAPI(e, http.MethodPost, "/api/shorten", `{
+		"url": "https://example.com",
+		"custom_code": "hdr123",
+		"creator_reference": "test-user",
+		"redirect_headers": {"Cache-Control": "no-store", "X-Campaign": "spring", "Set-Cookie": "session=evil"}
+	}`)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"redirect_headers"`)
+
+	t.Run("AllowedHeadersSet", func(t *testing.T) {
+		rec := serveAPI(e, http.MethodGet, "/hdr123", "")
+		assert.Equal(t, http.StatusFound, rec.Code)
+		assert.Equal(t, "no-store", rec.Header().Get("Cache-Control"))
+		assert.Equal(t, "spring", rec.Header().Get("X-Campaign"))
+	})
+
+	t.Run("DisallowedHeadersIgnored", func(t *testing.T) {
+		rec := serveAPI(e, http.MethodGet, "/hdr123", "")
+		assert.Equal(t, http.StatusFound, rec.Code)
+		assert.Empty(t, rec.Header().Get("Set-Cookie"))
+	})
+
+	t.Run("InterstitialHeadersSet", func(t *testing.T) {
+		chdirRepoRoot(t)
+		req := httptest.NewRequest(http.MethodGet, "/hdr123", nil)
+		req.Header.Set("Accept", "text/html")
+		rec := serveRequest(e, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Header().Get(echo.HeaderContentType), "text/html")
+		assert.Equal(t, "no-store", rec.Header().Get("Cache-Control"))
+		assert.Equal(t, "spring", rec.Header().Get("X-Campaign"))
+		assert.Empty(t, rec.Header().Get("Set-Cookie"))
+	})
+
+	t.Run("ClearedOnUpdate", func(t *testing.T) {
+		rec := serveAPI(e, http.MethodPut, "/api/urls/hdr123", `{"creator_reference": "test-user", "redirect_headers": {}}`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		rec = serveAPI(e, http.MethodGet, "/hdr123", "")
+		assert.Equal(t, http.StatusFound, rec.Code)
+		assert.Empty(t, rec.Header().Get("Cache-Control"))
+		assert.Empty(t, rec.Header().Get("X-Campaign"))
+	})
+}
+
+// TestTransferURL tests owner-initiated and admin URL ownership transfers
+func TestTransferURL(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeRepository()
+	for _, short := range []string{"own111", "own222", "own333"} {
+		_, err := repo.Create(ctx, models.NewURL("https://example.com/"+short, short, "", time.Time{}, "alice"))
+		assert.NoError(t, err)
+	}
+	e := newRealTestServer(repo, newTestConfig())
+
+	post := func(path, body string, admin bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		if admin {
+			req.Header.Set("X-Admin-Key", "test-admin-key")
+		} else {
+			req.Header.Set("X-API-Key", "test-api-key")
+		}
+		return serveRequest(e, req)
+	}
+
+	t.Run("OwnerTransfer", func(t *testing.T) {
+		rec := post("/api/urls/own111/transfer", `{"creator_reference": "bob", "current_creator_reference": "alice"}`, false)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"creator_reference":"bob"`)
+		assert.Equal(t, "bob", repo.urls["own111"].CreatorReference)
+
+		history, _, err := repo.GetURLHistory(ctx, "own111", store.HistoryFilter{Action: "transfer", Limit: 10})
+		assert.NoError(t, err)
+		assert.Len(t, history, 1)
+	})
+
+	t.Run("NonOwnerRejected", func(t *testing.T) {
+		rec := post("/api/urls/own222/transfer", `{"creator_reference": "mallory", "current_creator_reference": "mallory"}`, false)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Equal(t, "alice", repo.urls["own222"].CreatorReference)
+
+		rec = post("/api/urls/own222/transfer", `{"creator_reference": "mallory"}`, false)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("AdminOverride", func(t *testing.T) {
+		rec := post("/api/admin/urls/own222/transfer", `{"creator_reference": "carol"}`, true)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "carol", repo.urls["own222"].CreatorReference)
+
+		rec = post("/api/admin/urls/own222/transfer", `{"creator_reference": "carol"}`, false)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("BulkTransfer", func(t *testing.T) {
+		rec := post("/api/admin/creators/alice/transfer", `{"creator_reference": "dave"}`, true)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"transferred":1}`, rec.Body.String())
+		assert.Equal(t, "dave", repo.urls["own333"].CreatorReference)
+		assert.Equal(t, "bob", repo.urls["own111"].CreatorReference)
+	})
+
+	t.Run("UnknownCode", func(t *testing.T) {
+		rec := post("/api/admin/urls/missing/transfer", `{"creator_reference": "carol"}`, true)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
+
+// TestGetConfig tests the admin endpoint returning the effective configuration with secrets masked
+func TestGetConfig(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.PostgresURL = "postgres://postgres:db-secret@db:5432/urlshortener"
+	cfg.ValkeyCachePassword = "cache-secret"
+	e := newRealTestServer(newFakeRepository(), cfg)
+
+	serve := func(adminKey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/config", nil)
+		req.Header.Set("X-Admin-Key", adminKey)
+		return serveRequest(e, req)
+	}
+
+	rec := serve("test-admin-key")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	body := rec.Body.String()
+	for _, secret := range []string{"test-api-key", "test-admin-key", "db-secret", "cache-secret"} {
+		assert.NotContains(t, body, secret)
+	}
+
+	var response config.Config
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, "REDACTED", response.APIKey)
+	assert.Equal(t, "postgres://postgres:REDACTED@db:5432/urlshortener", response.PostgresURL)
+	assert.Equal(t, "http://localhost:8080", response.BaseURL)
+
+	assert.Equal(t, http.StatusUnauthorized, serve("test-api-key").Code)
+}
+
+// TestImportURLWithClicks tests seeding the initial click count of migrated links through the admin endpoint
+func TestImportURLWithClicks(t *testing.T) {
+	repo := newFakeRepository()
+	e := newRealTestServer(repo, newTestConfig())
+
+	serve := func(path string, header, key string, body ShortenRequest) *httptest.ResponseRecorder {
+		payload, _ := json.Marshal(body)
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(payload))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set(header, key)
+		return serveRequest(e, req)
+	}
+
+	t.Run("AdminSeedsClicks", func(t *testing.T) {
+		rec := serve("/api/admin/shorten", "X-Admin-Key", "test-admin-key", ShortenRequest{URL: "https://example.com", CustomCode: "migrated", Clicks: 1234})
+		assert.Equal(t, http.StatusCreated, rec.Code)
+
+		var response ShortenResponse
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		assert.Equal(t, int64(1234), response.Clicks)
+
+		stored, err := repo.GetByShort(context.Background(), "migrated")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1234), stored.Clicks)
+	})
+
+	t.Run("RejectsClicksWithoutAdminScope", func(t *testing.T) {
+		rec := serve("/api/shorten", "X-API-Key", "test-api-key", ShortenRequest{URL: "https://example.com", CustomCode: "sneaky", Clicks: 99})
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		_, err := repo.GetByShort(context.Background(), "sneaky")
+		assert.ErrorIs(t, err, store.ErrURLNotFound)
+	})
+
+	t.Run("RejectsNegativeClicks", func(t *testing.T) {
+		rec := serve("/api/admin/shorten", "X-Admin-Key", "test-admin-key", ShortenRequest{URL: "https://example.com", CustomCode: "negative", Clicks: -1})
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("RegularLinksStartAtZero", func(t *testing.T) {
+		rec := serve("/api/shorten", "X-API-Key", "test-api-key", ShortenRequest{URL: "https://example.com", CustomCode: "fresh"})
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		stored, err := repo.GetByShort(context.Background(), "fresh")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), stored.Clicks)
+	})
+}
+
+// TestCreatorAnalyticsByMetadata tests totalling a creator's clicks per metadata value
+func TestCreatorAnalyticsByMetadata(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeRepository()
+	e := newRealTestServer(repo, newTestConfig())
+
+	create := func(short, creator string, metadata map[string]string, clicks int) {
+		url := models.NewURL("https://example.com", short, "", time.Time{}, creator)
+		url.Metadata = metadata
+		created, err := repo.Create(ctx, url)
+		assert.NoError(t, err)
+		for i := 0; i < clicks; i++ {
+			assert.NoError(t, repo.StoreClick(ctx, models.NewClick(created.ID, short, "127.0.0.1", "Unknown", "Chrome", "Desktop")))
+		}
+	}
+	create("spring1", "marketer", map[string]string{"campaign": "spring"}, 2)
+	create("spring2", "marketer", map[string]string{"campaign": "spring"}, 3)
+	create("autumn1", "marketer", map[string]string{"campaign": "autumn"}, 1)
+	create("untagged", "marketer", nil, 4)
+	create("other", "someone-else", map[string]string{"campaign": "spring"}, 7)
+
+	serve := func(query string) *httptest.ResponseRecorder {
+		return serveAPI(e, http.MethodGet, "/api/creators/marketer/analytics"+query, "")
+	}
+
+	rec := serve("?group_by=campaign")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var response struct {
+		GroupBy string                       `json:"group_by"`
+		Groups  []*models.MetadataClickCount `json:"groups"`
+	}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, "campaign", response.GroupBy)
+	assert.Equal(t, []*models.MetadataClickCount{
+		{Value: "spring", URLs: 2, Clicks: 5},
+		{Value: "autumn", URLs: 1, Clicks: 1},
+	}, response.Groups)
+
+	assert.Equal(t, http.StatusBadRequest, serve("").Code)
+}
+
+// TestShortenURLMetadata tests setting, returning and clearing URL metadata
+func TestShortenURLMetadata(t *testing.T) {
+	repo := newFakeRepository()
+	e := newRealTestServer(repo, newTestConfig())
+
+	body, _ := json.Marshal(ShortenRequest{URL: "https://example.com", CustomCode: "tagged", CreatorReference: "test-user", Metadata: map[string]string{"campaign": "spring"}})
+	rec := serveAPI(e, http.MethodPost, "/api/shorten", string(body))
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	var created ShortenResponse
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	assert.Equal(t, map[string]string{"campaign": "spring"}, created.Metadata)
+
+	req := newAPIRequest(http.MethodPut, "/api/urls/tagged", `{"creator_reference": "test-user", "metadata": {}}`)
+	rec = serveRequest(e, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	stored, err := repo.GetByShort(context.Background(), "tagged")
+	assert.NoError(t, err)
+	assert.Nil(t, stored.Metadata)
+}
+
+// TestShortenBlockedDestination tests that blocklisted destinations are refused with 403
+func TestShortenBlockedDestination(t *testing.T) {
+	validator, err := store.NewURLValidator("evil.example", "paypal-*.com")
+	assert.NoError(t, err)
+	e := echo.New()
+	NewURLHandler(store.NewURLService(newFakeRepository(), nil, store.WithURLValidator(validator)), newTestConfig()).Register(e)
+
+	shorten := func(url string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(ShortenRequest{URL: url})
+		return serveAPI(e, http.MethodPost, "/api/shorten", string(body))
+	}
+
+	for _, url := range []string{"https://login.evil.example/account", "https://paypal-verify.com"} {
+		rec := shorten(url)
+		assert.Equal(t, http.StatusForbidden, rec.Code, url)
+		assert.Contains(t, rec.Body.String(), "blocked_destination", url)
+	}
+
+	rec := shorten("javascript:alert(1)")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "disallowed_scheme")
+
+	rec = shorten("https://example.com")
+	assert.Equal(t, http.StatusCreated, rec.Code)
+}
+
+// TestShortenCodeConflicts tests that a taken custom code is a 409 conflict while running out of generated
+// codes is a retryable 503
+func TestShortenCodeConflicts(t *testing.T) {
+	repo := newFakeRepository()
+	_, err := repo.Create(context.Background(), models.NewURL("https://example.com", "taken", "", time.Time{}, "test-user"))
+	assert.NoError(t, err)
+
+	// Every generated code is already in use
+	generator := func(ctx context.Context) (string, error) { return "taken", nil }
+	e := echo.New()
+	NewURLHandler(store.NewURLService(repo, nil, store.WithCodeGenerator(generator)), newTestConfig()).Register(e)
+
+	shorten := func(customCode string) (*httptest.ResponseRecorder, map[string]string) {
+		body, _ := json.Marshal(ShortenRequest{URL: "https://example.com/new", CustomCode: customCode})
+		rec := serveAPI(e, http.MethodPost, "/api/shorten", string(body))
+		var response map[string]string
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		return rec, response
+	}
+
+	t.Run("CustomCodeTaken", func(t *testing.T) {
+		rec, response := shorten("taken")
+		assert.Equal(t, http.StatusConflict, rec.Code)
+		assert.Equal(t, "url_exists", response["code"])
+		assert.Empty(t, rec.Header().Get("Retry-After"))
+	})
+
+	t.Run("GenerationExhausted", func(t *testing.T) {
+		rec, response := shorten("")
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		assert.Equal(t, "code_generation_failed", response["code"])
+		assert.Equal(t, "1", rec.Header().Get("Retry-After"))
+	})
+}
+
+// TestMaxClicks tests that usage-limited links stop redirecting after their click limit, also under
+// concurrent redirects
+func TestMaxClicks(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeRepository()
+	e := newRealTestServer(repo, newTestConfig())
+
+	shorten := func(body string) *httptest.ResponseRecorder {
+		return serveAPI(e, http.MethodPost, "/api/shorten", body)
+	}
+	redirect := func(code string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/"+code, nil)
+		return serveRequest(e, req)
+	}
+
+	t.Run("SingleUse", func(t *testing.T) {
+		rec := shorten(`{"url": "https://example.com/once", "custom_code": "once", "max_clicks": 1}`)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"max_clicks":1`)
+
+		assert.Equal(t, http.StatusFound, redirect("once").Code)
+		rec = redirect("once")
+		assert.Equal(t, http.StatusGone, rec.Code)
+		assert.Contains(t, rec.Body.String(), "click_limit_reached")
+	})
+
+	t.Run("ConcurrentRedirects", func(t *testing.T) {
+		assert.Equal(t, http.StatusCreated, shorten(`{"url": "https://example.com/few", "custom_code": "few", "max_clicks": 5}`).Code)
+
+		var mu sync.Mutex
+		statuses := map[int]int{}
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				code := redirect("few").Code
+				mu.Lock()
+				statuses[code]++
+				mu.Unlock()
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, map[int]int{http.StatusFound: 5, http.StatusGone: 15}, statuses)
+		url, err := repo.GetByShort(ctx, "few")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(5), url.Clicks)
+	})
+
+	t.Run("NegativeLimit", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, shorten(`{"url": "https://example.com", "max_clicks": -1}`).Code)
+	})
+}
+
+// TestCountURLsByCreator tests that the count leaves out deleted and expired links and other creators' links
+func TestCountURLsByCreator(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeRepository()
+	for _, url := range []*models.URL{
+		models.NewURL("https://example.com/a", "counta", "", time.Time{}, "counter"),
+		models.NewURL("https://example.com/b", "countb", "", time.Now().Add(time.Hour), "counter"),
+		models.NewURL("https://example.com/c", "countexpired", "", time.Now().Add(-time.Hour), "counter"),
+		models.NewURL("https://example.com/d", "countdeleted", "", time.Time{}, "counter"),
+		models.NewURL("https://example.com/e", "countother", "", time.Time{}, "someone-else"),
+	} {
+		_, err := repo.Create(ctx, url)
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, repo.Delete(ctx, "countdeleted"))
+	e := newRealTestServer(repo, newTestConfig())
+
+	count := func(creatorReference string) int64 {
+		rec := serveAPI(e, http.MethodGet, "/api/urls/creator/"+creatorReference+"/count", "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var response struct {
+			CreatorReference string `json:"creator_reference"`
+			Count            int64  `json:"count"`
+		}
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		assert.Equal(t, creatorReference, response.CreatorReference)
+		return response.Count
+	}
+
+	assert.Equal(t, int64(2), count("counter"))
+	assert.Equal(t, int64(1), count("someone-else"))
+	assert.Equal(t, int64(0), count("nobody"))
+}
+
+// TestShortenCacheTTL tests storing a per-link cache TTL and rejecting negative values
+func TestShortenCacheTTL(t *testing.T) {
+	repo := newFakeRepository()
+	e := newRealTestServer(repo, newTestConfig())
+
+	shorten := func(body string) *httptest.ResponseRecorder {
+		return serveAPI(e, http.MethodPost, "/api/shorten", body)
+	}
+
+	rec := shorten(`{"url": "https://example.com/hot", "custom_code": "hot", "cache_ttl": 86400}`)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	var response URLResponse
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, 86400, response.CacheTTL)
+
+	url, err := repo.GetByShort(context.Background(), "hot")
+	assert.NoError(t, err)
+	assert.Equal(t, 86400, url.CacheTTL)
+
+	rec = shorten(`{"url": "https://example.com/cold", "cache_ttl": -1}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+// TestShortenVerify tests checking that destinations respond before shortening them
+func TestShortenVerify(t *testing.T) {
+	reachable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer reachable.Close()
+	unreachable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer unreachable.Close()
+
+	shorten := func(e *echo.Echo, query, url, code string) *httptest.ResponseRecorder {
+		body := fmt.Sprintf(`{"url": %q, "custom_code": %q}`, url, code)
+		return serveAPI(e, http.MethodPost, "/api/shorten"+query, body)
+	}
+
+	t.Run("Requested", func(t *testing.T) {
+		repo := newFakeRepository()
+		e := newRealTestServer(repo, newTestConfig())
+
+		assert.Equal(t, http.StatusCreated, shorten(e, "?verify=true", reachable.URL, "up").Code)
+
+		rec := shorten(e, "?verify=true", unreachable.URL, "down")
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		assert.Contains(t, rec.Body.String(), "destination_unreachable")
+		_, err := repo.GetByShort(context.Background(), "down")
+		assert.ErrorIs(t, err, store.ErrURLNotFound)
+
+		// Without verify, destinations aren't checked
+		assert.Equal(t, http.StatusCreated, shorten(e, "", unreachable.URL, "unchecked").Code)
+		assert.Equal(t, http.StatusBadRequest, shorten(e, "?verify=maybe", reachable.URL, "invalid").Code)
+	})
+
+	t.Run("Configured", func(t *testing.T) {
+		cfg := newTestConfig()
+		cfg.VerifyDestinations = true
+		e := newRealTestServer(newFakeRepository(), cfg)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, shorten(e, "", unreachable.URL, "down").Code)
+		assert.Equal(t, http.StatusCreated, shorten(e, "?verify=false", unreachable.URL, "optout").Code)
+	})
+
+	t.Run("BatchAndRPC", func(t *testing.T) {
+		cfg := newTestConfig()
+		cfg.VerifyDestinations = true
+		e := newRealTestServer(newFakeRepository(), cfg)
+
+		body := fmt.Sprintf(`[{"url": %q}, {"url": %q}]`, reachable.URL, unreachable.URL)
+		rec := serveAPI(e, http.MethodPost, "/api/shorten/batch", body)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var results []BatchShortenResult
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &results))
+		if assert.Len(t, results, 2) {
+			assert.NotNil(t, results[0].URL)
+			assert.Equal(t, "destination_unreachable", results[1].Code)
+		}
+
+		body = fmt.Sprintf(`[{"id": 1, "method": "shorten", "params": {"url": %q}}]`, unreachable.URL)
+		rec = serveAPI(e, http.MethodPost, "/api/rpc", body)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var responses []RPCResponse
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &responses))
+		if assert.Len(t, responses, 1) && assert.NotNil(t, responses[0].Error) {
+			assert.Equal(t, "destination_unreachable", responses[0].Error.Code)
+		}
+	})
+}
+
+// TestGetURLClickDistribution tests that clicks fall into the hour and day buckets of the requested time zone
+func TestGetURLClickDistribution(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeRepository()
+	created, err := repo.Create(ctx, models.NewURL("https://example.com", "abc123", "", time.Time{}, "test-user"))
+	assert.NoError(t, err)
+	for _, timestamp := range []string{
+		"2024-03-04T09:30:00Z", // Monday
+		"2024-03-04T09:45:00Z", // Monday
+		"2024-03-09T20:00:00Z", // Saturday, already Sunday in Tokyo
+		"2024-05-01T12:00:00Z", // outside the range
+	} {
+		click := models.NewClick(created.ID, "abc123", "10.0.0.1", "Unknown", "Chrome", "Desktop")
+		click.Timestamp, err = time.Parse(time.RFC3339, timestamp)
+		assert.NoError(t, err)
+		assert.NoError(t, repo.StoreClick(ctx, click))
+	}
+	e := newRealTestServer(repo, newTestConfig())
+
+	get := func(query string) (*httptest.ResponseRecorder, models.ClickDistribution) {
+		rec := serveAPI(e, http.MethodGet, "/api/urls/abc123/analytics/distribution?from=2024-03-01&to=2024-04-01"+query, "")
+		var distribution models.ClickDistribution
+		if rec.Code == http.StatusOK {
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &distribution))
+		}
+		return rec, distribution
+	}
+
+	t.Run("UTC", func(t *testing.T) {
+		rec, distribution := get("")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "UTC", distribution.Timezone)
+		var hours [24]int64
+		hours[9], hours[20] = 2, 1
+		assert.Equal(t, hours, distribution.HourOfDay)
+		assert.Equal(t, [7]int64{0, 2, 0, 0, 0, 0, 1}, distribution.DayOfWeek)
+	})
+
+	t.Run("TimeZone", func(t *testing.T) {
+		rec, distribution := get("&tz=Asia/Tokyo")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "Asia/Tokyo", distribution.Timezone)
+		var hours [24]int64
+		hours[18], hours[5] = 2, 1
+		assert.Equal(t, hours, distribution.HourOfDay)
+		assert.Equal(t, [7]int64{1, 2, 0, 0, 0, 0, 0}, distribution.DayOfWeek)
+	})
+
+	t.Run("InvalidTimeZone", func(t *testing.T) {
+		rec, _ := get("&tz=Mars/Olympus")
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("UnknownURL", func(t *testing.T) {
+		rec := serveAPI(e, http.MethodGet, "/api/urls/missing/analytics/distribution", "")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
+
+// TestURLResponsesDescribeURLsInFull tests that single-URL responses carry the same fields as listings
+func TestURLResponsesDescribeURLsInFull(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeRepository()
+	e := newRealTestServer(repo, newTestConfig())
+
+	rec := serveAPI(e, http.MethodPost, "/api/shorten", `{"url": "https://example.com/full", "custom_code": "full"}`)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	var shortened URLResponse
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &shortened))
+	assert.Equal(t, "full", shortened.ShortCode)
+	assert.False(t, shortened.CreatedAt.IsZero())
+
+	_, err := repo.IncrementClicks(ctx, "full")
+	assert.NoError(t, err)
+
+	rec = serveAPI(e, http.MethodGet, "/api/urls/full", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var info URLResponse
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &info))
+	assert.Equal(t, "full", info.ShortCode)
+	assert.Equal(t, shortened.CreatedAt.Unix(), info.CreatedAt.Unix())
+	assert.NotNil(t, info.LastAccessedAt)
+}
+
+// TestSuggestCodes tests that suggested alternatives to a desired code are available and not reserved
+func TestSuggestCodes(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeRepository()
+	for _, short := range []string{"sale", "sale2", "sale3", "sal"} {
+		_, err := repo.Create(ctx, models.NewURL("https://example.com", short, "", time.Time{}, "test-user"))
+		assert.NoError(t, err)
+	}
+	e := echo.New()
+	service := store.NewURLService(repo, nil, store.WithReservedCodes("Promo"))
+	NewURLHandler(service, newTestConfig()).Register(e)
+
+	suggest := func(code string, count int) store.CodeSuggestion {
+		body, _ := json.Marshal(SuggestCodeRequest{Code: code, Count: count})
+		rec := serveAPI(e, http.MethodPost, "/api/urls/suggest", string(body))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var suggestion store.CodeSuggestion
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &suggestion))
+		return suggestion
+	}
+
+	// assertUsable checks every suggestion is unused, unreserved and valid
+	assertUsable := func(t *testing.T, suggestions []string) {
+		for _, code := range suggestions {
+			_, err := repo.GetByShortIncludingDeleted(ctx, code)
+			assert.ErrorIs(t, err, store.ErrURLNotFound, code)
+			assert.NoError(t, service.ValidateCustomCode(code), code)
+		}
+	}
+
+	t.Run("TakenCode", func(t *testing.T) {
+		suggestion := suggest("sale", 10)
+		assert.False(t, suggestion.Available)
+		assert.Equal(t, "taken", suggestion.Reason)
+		assert.Len(t, suggestion.Suggestions, 10)
+		assert.Equal(t, "sale4", suggestion.Suggestions[0])
+		assert.NotContains(t, suggestion.Suggestions, "sal")
+		assertUsable(t, suggestion.Suggestions)
+	})
+
+	t.Run("ReservedCode", func(t *testing.T) {
+		for _, code := range []string{"api", "promo"} {
+			suggestion := suggest(code, 5)
+			assert.False(t, suggestion.Available)
+			assert.Equal(t, "reserved", suggestion.Reason)
+			assert.NotEmpty(t, suggestion.Suggestions)
+			assertUsable(t, suggestion.Suggestions)
+		}
+	})
+
+	t.Run("InvalidCode", func(t *testing.T) {
+		suggestion := suggest("summer sale!", 5)
+		assert.False(t, suggestion.Available)
+		assert.Equal(t, "invalid", suggestion.Reason)
+		assert.Contains(t, suggestion.Suggestions, "summer-sale2")
+		assertUsable(t, suggestion.Suggestions)
+	})
+
+	t.Run("AvailableCode", func(t *testing.T) {
+		suggestion := suggest("fresh", 3)
+		assert.True(t, suggestion.Available)
+		assert.Empty(t, suggestion.Reason)
+		assert.Len(t, suggestion.Suggestions, 3)
+		assertUsable(t, suggestion.Suggestions)
+	})
+
+	t.Run("CreateRejectsReservedAndInvalidCodes", func(t *testing.T) {
+		_, err := service.CreateShortURL(ctx, "https://example.com", "health", "", 0, "test-user")
+		assert.ErrorIs(t, err, store.ErrReservedCode)
+		_, err = service.CreateShortURL(ctx, "https://example.com", "bad/code", "", 0, "test-user")
+		assert.ErrorIs(t, err, store.ErrInvalidCustomCode)
+	})
+}
+
+func TestDisableURL(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeRepository()
+	_, err := repo.Create(ctx, models.NewURL("https://example.com", "abc123", "", time.Time{}, "test-user"))
+	assert.NoError(t, err)
+	e := newRealTestServer(repo, newTestConfig())
+
+	setEnabled := func(action, creator string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(SetEnabledRequest{CreatorReference: creator})
+		return serveAPI(e, http.MethodPost, "/api/urls/abc123/"+action, string(body))
+	}
+	redirect := func() *httptest.ResponseRecorder {
+		return serveRequest(e, httptest.NewRequest(http.MethodGet, "/abc123", nil))
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, setEnabled("disable", "someone-else").Code)
+	assert.Equal(t, http.StatusBadRequest, setEnabled("disable", "").Code)
+
+	rec := setEnabled("disable", "test-user")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var response URLResponse
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.False(t, response.Enabled)
+
+	rec = redirect()
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Contains(t, rec.Body.String(), "url_disabled")
+
+	// The link and its analytics are kept while disabled
+	stored, err := repo.GetByShort(ctx, "abc123")
+	assert.NoError(t, err)
+	assert.False(t, stored.Enabled)
+	assert.Nil(t, stored.DeletedAt)
+
+	assert.Equal(t, http.StatusOK, setEnabled("enable", "test-user").Code)
+	assert.Equal(t, http.StatusFound, redirect().Code)
+}
+
+func TestDisableCreatorURLsByTag(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeRepository()
+	e := newRealTestServer(repo, newTestConfig())
+
+	serve := func(method, path string, body interface{}) *httptest.ResponseRecorder {
+		payload, _ := json.Marshal(body)
+		return serveAPI(e, method, path, string(payload))
+	}
+
+	for code, tags := range map[string][]string{
+		"spring1": {"spring", "promo"},
+		"spring2": {" spring "},
+		"plain":   nil,
+	} {
+		rec := serve(http.MethodPost, "/api/shorten", ShortenRequest{URL: "https://example.com/" + code, CustomCode: code, CreatorReference: "test-user", Tags: tags})
+		assert.Equal(t, http.StatusCreated, rec.Code)
+	}
+	_, err := repo.Create(ctx, models.NewURL("https://example.com/other", "other", "", time.Time{}, "other-user"))
+	assert.NoError(t, err)
+
+	rec := serve(http.MethodPost, "/api/creators/test-user/disable", SetCreatorURLsEnabledRequest{Tag: "spring"})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"affected": 2}`, rec.Body.String())
+
+	for code, enabled := range map[string]bool{"spring1": false, "spring2": false, "plain": true, "other": true} {
+		stored, err := repo.GetByShort(ctx, code)
+		assert.NoError(t, err)
+		assert.Equal(t, enabled, stored.Enabled, code)
+	}
+
+	// Already disabled links are not counted again
+	rec = serve(http.MethodPost, "/api/creators/test-user/disable", SetCreatorURLsEnabledRequest{Tag: "spring"})
+	assert.JSONEq(t, `{"affected": 0}`, rec.Body.String())
+
+	// Without a tag every link of the creator is affected
+	rec = serve(http.MethodPost, "/api/creators/test-user/enable", SetCreatorURLsEnabledRequest{})
+	assert.JSONEq(t, `{"affected": 2}`, rec.Body.String())
+	stored, err := repo.GetByShort(ctx, "spring2")
+	assert.NoError(t, err)
+	assert.True(t, stored.Enabled)
+	assert.Equal(t, []string{"spring"}, stored.Tags)
+}
+
+func TestDisabledResponse(t *testing.T) {
+	chdirRepoRoot(t)
+	ctx := context.Background()
+
+	newServer := func(t *testing.T, mode string, redirectURL string) (*echo.Echo, *fakeRepository) {
+		repo := newFakeRepository()
+		url := models.NewURL("https://example.com", "abc123", "", time.Time{}, "test-user")
+		url.Enabled = false
+		_, err := repo.Create(ctx, url)
+		assert.NoError(t, err)
+		cfg := newTestConfig()
+		cfg.DisabledResponse = mode
+		cfg.DisabledRedirectURL = redirectURL
+		return newRealTestServer(repo, cfg), repo
+	}
+	get := func(e *echo.Echo, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/abc123", nil)
+		req.Header.Set("Accept", accept)
+		return serveRequest(e, req)
+	}
+
+	t.Run("JSON", func(t *testing.T) {
+		e, _ := newServer(t, config.DisabledResponseJSON, "")
+		rec := get(e, "text/html")
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		assert.Contains(t, rec.Body.String(), "url_disabled")
+	})
+
+	t.Run("HTML", func(t *testing.T) {
+		e, _ := newServer(t, config.DisabledResponseHTML, "")
+		rec := get(e, "text/html,application/xhtml+xml")
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		assert.Contains(t, rec.Header().Get(echo.HeaderContentType), "text/html")
+		assert.Contains(t, rec.Body.String(), "Link disabled")
+
+		// API clients still get JSON
+		rec = get(e, "application/json")
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		assert.Contains(t, rec.Body.String(), "url_disabled")
+	})
+
+	t.Run("Redirect", func(t *testing.T) {
+		e, _ := newServer(t, config.DisabledResponseRedirect, "https://example.org/inactive")
+		rec := get(e, "text/html")
+		assert.Equal(t, http.StatusFound, rec.Code)
+		assert.Equal(t, "https://example.org/inactive", rec.Header().Get("Location"))
+	})
+
+	t.Run("PerURLOverride", func(t *testing.T) {
+		e, repo := newServer(t, config.DisabledResponseJSON, "")
+		body := `{"creator_reference": "test-user", "disabled_redirect_url": "https://example.org/campaign-ended"}`
+		rec := serveAPI(e, http.MethodPut, "/api/urls/abc123", body)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		rec = get(e, "application/json")
+		assert.Equal(t, http.StatusFound, rec.Code)
+		assert.Equal(t, "https://example.org/campaign-ended", rec.Header().Get("Location"))
+
+		stored, err := repo.GetByShort(ctx, "abc123")
+		assert.NoError(t, err)
+		assert.False(t, stored.Enabled)
+	})
+
+	t.Run("PerURLOverrideMustBeHTTP", func(t *testing.T) {
+		e, _ := newServer(t, config.DisabledResponseJSON, "")
+		body := `{"url": "https://example.com/x", "disabled_redirect_url": "javascript:alert(1)"}`
+		rec := serveAPI(e, http.MethodPost, "/api/shorten", body)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
+
+func TestGetURLResolveTiming(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("PercentilesOverFixture", func(t *testing.T) {
+		repo := newFakeRepository()
+		created, err := repo.Create(ctx, models.NewURL("https://example.com", "abc123", "", time.Time{}, "test-user"))
+		assert.NoError(t, err)
+		// Resolve times of 1..100ms, plus a click recorded without timing
+		for i := 1; i <= 100; i++ {
+			click := models.NewClick(created.ID, "abc123", "10.0.0.1", "Unknown", "Chrome", "Desktop")
+			ms := float64(i)
+			click.ResolveMs = &ms
+			assert.NoError(t, repo.StoreClick(ctx, click))
+		}
+		assert.NoError(t, repo.StoreClick(ctx, models.NewClick(created.ID, "abc123", "10.0.0.2", "Unknown", "Chrome", "Desktop")))
+		e := newRealTestServer(repo, newTestConfig())
+
+		rec := serveAPI(e, http.MethodGet, "/api/urls/abc123/analytics/timing", "")
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var timing models.ResolveTiming
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &timing))
+		assert.Equal(t, int64(100), timing.Samples)
+		assert.InDelta(t, 50.5, timing.P50, 1e-9)
+		assert.InDelta(t, 95.05, timing.P95, 1e-9)
+		assert.InDelta(t, 99.01, timing.P99, 1e-9)
+	})
+
+	t.Run("RedirectCapturesResolveTime", func(t *testing.T) {
+		repo := newFakeRepository()
+		_, err := repo.Create(ctx, models.NewURL("https://example.com", "abc123", "", time.Time{}, "test-user"))
+		assert.NoError(t, err)
+		cfg := newTestConfig()
+		cfg.ClickResolveTiming = true
+		e := newRealTestServer(repo, cfg)
+
+		rec := serveRequest(e, httptest.NewRequest(http.MethodGet, "/abc123", nil))
+		assert.Equal(t, http.StatusFound, rec.Code)
+
+		assert.Eventually(t, func() bool {
+			clicks, _ := repo.GetClicksByShort(ctx, "abc123")
+			return len(clicks) == 1 && clicks[0].ResolveMs != nil && *clicks[0].ResolveMs > 0
+		}, time.Second, 10*time.Millisecond)
+	})
+}
+
+// TestRandomTargetRedirect tests links that redirect to a random link of their creator
+func TestRandomTargetRedirect(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeRepository()
+	targets := map[string]bool{}
+	shorts := map[string]bool{}
+	for i := 0; i < 5; i++ {
+		short := fmt.Sprintf("link%d", i)
+		original := fmt.Sprintf("https://example.com/%d", i)
+		targets[original] = true
+		shorts[short] = true
+		_, err := repo.Create(ctx, models.NewURL(original, short, "", time.Time{}, "test-user"))
+		assert.NoError(t, err)
+	}
+	disabled := models.NewURL("https://example.com/disabled", "off", "", time.Time{}, "test-user")
+	disabled.Enabled = false
+	_, err := repo.Create(ctx, disabled)
+	assert.NoError(t, err)
+	_, err = repo.Create(ctx, models.NewURL("https://example.com/other", "other", "", time.Time{}, "other-user"))
+	assert.NoError(t, err)
+	e := newRealTestServer(repo, newTestConfig())
+
+	shorten := func(body string) *httptest.ResponseRecorder {
+		return serveAPI(e, http.MethodPost, "/api/shorten", body)
+	}
+
+	t.Run("RequiresCreator", func(t *testing.T) {
+		rec := shorten(`{"url": "https://example.com", "random_target": true}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), "random_target_without_creator")
+	})
+
+	rec := shorten(`{"url": "https://example.com/fallback", "custom_code": "surprise", "creator_reference": "test-user", "random_target": true}`)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"random_target":true`)
+
+	t.Run("RepeatedHitsYieldVariedTargets", func(t *testing.T) {
+		seen := map[string]int{}
+		for i := 0; i < 50; i++ {
+			req := httptest.NewRequest(http.MethodGet, "/surprise", nil)
+			req.RemoteAddr = fmt.Sprintf("10.0.0.%d:1234", i)
+			rec := serveRequest(e, req)
+			assert.Equal(t, http.StatusFound, rec.Code)
+			location := rec.Header().Get("Location")
+			assert.True(t, targets[location], "unexpected target %q", location)
+			seen[location]++
+		}
+		assert.Greater(t, len(seen), 1)
+
+		// Every click records the link it was sent to
+		assert.Eventually(t, func() bool {
+			analytics, _ := repo.GetClickAnalytics(ctx, "surprise", store.ClickRange{})
+			byTarget, _ := analytics["targets"].(map[string]int64)
+			var total int64
+			for short, count := range byTarget {
+				if !shorts[short] {
+					return false
+				}
+				total += count
+			}
+			return total == 50
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("FallsBackWithoutLiveLinks", func(t *testing.T) {
+		rec := shorten(`{"url": "https://example.com/fallback", "custom_code": "lonely", "creator_reference": "nobody", "random_target": true}`)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+
+		rec = serveRequest(e, httptest.NewRequest(http.MethodGet, "/lonely", nil))
+		assert.Equal(t, http.StatusFound, rec.Code)
+		assert.Equal(t, "https://example.com/fallback", rec.Header().Get("Location"))
+	})
+}
+
+// TestAdminBypassesCreatorCheck tests that admins can update and delete URLs they didn't create
+func TestAdminBypassesCreatorCheck(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeRepository()
+	e := newRealTestServer(repo, newTestConfig())
+
+	_, err := repo.Create(ctx, models.NewURL("https://example.com/abuse", "abusive", "", time.Time{}, "spammer"))
+	assert.NoError(t, err)
+
+	serve := func(method, path, body, adminKey string) *httptest.ResponseRecorder {
+		req := newAPIRequest(method, path, body)
+		if adminKey != "" {
+			req.Header.Set("X-Admin-Key", adminKey)
+		}
+		return serveRequest(e, req)
+	}
+
+	t.Run("NonAdminsNeedCreator", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, serve(http.MethodDelete, "/api/urls/abusive", "", "").Code)
+		assert.Equal(t, http.StatusBadRequest, serve(http.MethodDelete, "/api/urls/abusive", "", "wrong-key").Code)
+		assert.Equal(t, http.StatusUnauthorized, serve(http.MethodDelete, "/api/urls/abusive?creator_reference=someone", "", "").Code)
+		assert.Equal(t, http.StatusBadRequest, serve(http.MethodPut, "/api/urls/abusive", `{"title": "Changed"}`, "").Code)
+	})
+
+	t.Run("AdminUpdate", func(t *testing.T) {
+		rec := serve(http.MethodPut, "/api/urls/abusive", `{"url": "https://example.com/removed"}`, "test-admin-key")
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		url, err := repo.GetByShort(ctx, "abusive")
+		assert.NoError(t, err)
+		assert.Equal(t, "https://example.com/removed", url.Original)
+		assert.Equal(t, "spammer", url.CreatorReference)
+	})
+
+	t.Run("AdminDelete", func(t *testing.T) {
+		rec := serve(http.MethodDelete, "/api/urls/abusive", "", "test-admin-key")
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		_, err := repo.GetByShort(ctx, "abusive")
+		assert.ErrorIs(t, err, store.ErrURLNotFound)
+
+		history, _, err := repo.GetURLHistory(ctx, "abusive", store.HistoryFilter{Limit: 10})
+		assert.NoError(t, err)
+		if assert.Len(t, history, 2) {
+			assert.Equal(t, "delete", history[0].Action)
+			assert.Equal(t, "admin", history[0].ModifiedBy)
+			assert.Equal(t, "update", history[1].Action)
+			assert.Equal(t, "admin", history[1].ModifiedBy)
+		}
+	})
+
+	t.Run("AdminFromDisallowedIP", func(t *testing.T) {
+		cfg := newTestConfig()
+		cfg.AdminAllowedCIDRs = []string{"203.0.113.0/24"}
+		e := newRealTestServer(repo, cfg)
+		_, err := repo.Create(ctx, models.NewURL("https://example.com/other", "other-abusive", "", time.Time{}, "spammer"))
+		assert.NoError(t, err)
+
+		req := newAPIRequest(http.MethodDelete, "/api/urls/other-abusive", "")
+		req.Header.Set("X-Admin-Key", "test-admin-key")
+		rec := serveRequest(e, req)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
+
+// TestURLAnalyticsTimeSeries tests the click time series added to analytics by a granularity
+func TestURLAnalyticsTimeSeries(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeRepository()
+	created, err := repo.Create(ctx, models.NewURL("https://example.com", "abc123", "", time.Time{}, "test-user"))
+	assert.NoError(t, err)
+	day := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+	for _, offset := range []time.Duration{time.Hour, 2 * time.Hour, 26 * time.Hour, 74 * time.Hour} {
+		click := models.NewClick(created.ID, "abc123", "127.0.0.1", "Unknown", "Chrome", "Desktop")
+		click.Timestamp = day.Add(offset)
+		assert.NoError(t, repo.StoreClick(ctx, click))
+	}
+	e := newRealTestServer(repo, newTestConfig())
+
+	type timeSeries struct {
+		Granularity string                    `json:"granularity"`
+		Points      []*models.TimeSeriesPoint `json:"points"`
+	}
+	get := func(query string) (int, *timeSeries) {
+		rec := serveAPI(e, http.MethodGet, "/api/urls/abc123/analytics"+query, "")
+		var response struct {
+			TimeSeries *timeSeries `json:"time_series"`
+		}
+		_ = json.Unmarshal(rec.Body.Bytes(), &response)
+		return rec.Code, response.TimeSeries
+	}
+
+	t.Run("Daily", func(t *testing.T) {
+		code, series := get("?from=2024-03-10&to=2024-03-15&granularity=day")
+		assert.Equal(t, http.StatusOK, code)
+		if assert.NotNil(t, series) && assert.Len(t, series.Points, 5) {
+			assert.Equal(t, "day", series.Granularity)
+			var clicks []int64
+			for i, point := range series.Points {
+				assert.True(t, day.AddDate(0, 0, i).Equal(point.Time))
+				clicks = append(clicks, point.Clicks)
+			}
+			assert.Equal(t, []int64{2, 1, 0, 1, 0}, clicks)
+		}
+	})
+
+	t.Run("Hourly", func(t *testing.T) {
+		code, series := get("?from=2024-03-10&to=2024-03-10T03:00:00Z&granularity=hour")
+		assert.Equal(t, http.StatusOK, code)
+		if assert.NotNil(t, series) && assert.Len(t, series.Points, 3) {
+			assert.Equal(t, int64(0), series.Points[0].Clicks)
+			assert.Equal(t, int64(1), series.Points[1].Clicks)
+			assert.Equal(t, int64(1), series.Points[2].Clicks)
+		}
+	})
+
+	t.Run("EmptyRange", func(t *testing.T) {
+		code, series := get("?from=2025-01-01&to=2025-01-03&granularity=day")
+		assert.Equal(t, http.StatusOK, code)
+		if assert.NotNil(t, series) && assert.Len(t, series.Points, 2) {
+			assert.Equal(t, int64(0), series.Points[0].Clicks+series.Points[1].Clicks)
+		}
+	})
+
+	t.Run("WithoutGranularity", func(t *testing.T) {
+		code, series := get("?from=2024-03-10")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Nil(t, series)
+	})
+
+	t.Run("InvalidGranularity", func(t *testing.T) {
+		code, _ := get("?granularity=fortnight")
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+}
+
+// TestReferrerTracking tests that redirects record the referring host and analytics aggregate it
+func TestReferrerTracking(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeRepository()
+	e := newRealTestServer(repo, newTestConfig())
+	_, err := repo.Create(ctx, models.NewURL("https://example.com", "abc123", "", time.Time{}, "test-user"))
+	assert.NoError(t, err)
+
+	for i, referrer := range []string{"https://www.Google.com/search?q=links", "https://news.ycombinator.com/item?id=1", "https://google.com/", ""} {
+		req := httptest.NewRequest(http.MethodGet, "/abc123", nil)
+		req.RemoteAddr = fmt.Sprintf("203.0.113.%d:1234", i+1)
+		if referrer != "" {
+			req.Header.Set("Referer", referrer)
+		}
+		rec := serveRequest(e, req)
+		assert.Equal(t, http.StatusFound, rec.Code)
+	}
+	assert.Eventually(t, func() bool {
+		clicks, _ := repo.GetClicksByShort(ctx, "abc123")
+		return len(clicks) == 4
+	}, time.Second, 10*time.Millisecond)
+
+	rec := serveAPI(e, http.MethodGet, "/api/urls/abc123/analytics", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var response struct {
+		Analytics struct {
+			Referrers map[string]int64 `json:"referrers"`
+		} `json:"analytics"`
+	}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, map[string]int64{"google.com": 2, "news.ycombinator.com": 1, "direct": 1}, response.Analytics.Referrers)
+}
+
+// TestURLNotes tests that internal notes round-trip through the API but never reach the interstitial
+func TestURLNotes(t *testing.T) {
+	chdirRepoRoot(t)
+	repo := newFakeRepository()
+	e := newRealTestServer(repo, newTestConfig())
+	const note = "Internal: owned by the spring campaign team"
+
+	send := func(method, path, body string, headers map[string]string) *httptest.ResponseRecorder {
+		req := newAPIRequest(method, path, body)
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		return serveRequest(e, req)
+	}
+	notesOf := func(rec *httptest.ResponseRecorder) string {
+		var response URLResponse
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		return response.Notes
+	}
+
+	rec := send(http.MethodPost, "/api/shorten", `{"url": "https://example.com/spring", "custom_code": "spring", "title": "Spring sale", "notes": "`+note+`", "creator_reference": "marketer"}`, nil)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, note, notesOf(rec))
+
+	rec = send(http.MethodGet, "/api/urls/spring", "", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, note, notesOf(rec))
+
+	t.Run("NotInInterstitial", func(t *testing.T) {
+		for _, rec := range []*httptest.ResponseRecorder{
+			send(http.MethodGet, "/api/urls/spring/preview", "", nil),
+			send(http.MethodGet, "/spring", "", map[string]string{"Accept": "text/html"}),
+		} {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Contains(t, rec.Body.String(), "https://example.com/spring")
+			assert.NotContains(t, rec.Body.String(), note)
+		}
+	})
+
+	t.Run("UpdateKeepsTitleSeparate", func(t *testing.T) {
+		rec := send(http.MethodPut, "/api/urls/spring", `{"creator_reference": "marketer", "notes": "Renewed for summer"}`, nil)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "Renewed for summer", notesOf(rec))
+
+		url, err := repo.GetByShort(context.Background(), "spring")
+		assert.NoError(t, err)
+		assert.Equal(t, "Renewed for summer", url.Notes)
+		assert.Equal(t, "Spring sale", url.Title)
+
+		// Updates without notes keep them; an empty string removes them
+		rec = send(http.MethodPut, "/api/urls/spring", `{"creator_reference": "marketer", "title": "Summer sale"}`, nil)
+		assert.Equal(t, "Renewed for summer", notesOf(rec))
+		rec = send(http.MethodPut, "/api/urls/spring", `{"creator_reference": "marketer", "notes": ""}`, nil)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Empty(t, notesOf(rec))
+	})
+}
+
+// TestLinkPreviewPages tests that link preview crawlers get a page with OpenGraph tags while browsers
+// and search engines are redirected
+func TestLinkPreviewPages(t *testing.T) {
+	chdirRepoRoot(t)
+	ctx := context.Background()
+	repo := newFakeRepository()
+	titled := models.NewURL("https://example.com/article?id=1", "titled", "An <Article>", time.Time{}, "")
+	titled.Metadata = map[string]string{"description": "All about articles"}
+	_, err := repo.Create(ctx, titled)
+	assert.NoError(t, err)
+	_, err = repo.Create(ctx, models.NewURL("https://docs.example.org/guide", "untitled", "", time.Time{}, ""))
+	assert.NoError(t, err)
+
+	cfg := newTestConfig()
+	cfg.LinkPreviewPages = true
+	e := newRealTestServer(repo, cfg)
+
+	get := func(e *echo.Echo, path, ua string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("User-Agent", ua)
+		return serveRequest(e, req)
+	}
+	const facebook = "facebookexternalhit/1.1 (+http://www.facebook.com/externalhit_uatext.php)"
+
+	t.Run("CrawlerGetsOpenGraphPage", func(t *testing.T) {
+		rec := get(e, "/titled", facebook)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Header().Get(echo.HeaderContentType), "text/html")
+		body := rec.Body.String()
+		assert.Contains(t, body, `<meta property="og:title" content="An &lt;Article&gt;" />`)
+		assert.Contains(t, body, `<meta property="og:description" content="All about articles" />`)
+		assert.Contains(t, body, `<meta property="og:url" content="http://localhost:8080/titled" />`)
+		assert.Contains(t, body, `<meta property="og:site_name" content="example.com" />`)
+	})
+
+	t.Run("TitleFallsBackToHost", func(t *testing.T) {
+		rec := get(e, "/untitled", "Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `<meta property="og:title" content="docs.example.org" />`)
+		assert.Contains(t, rec.Body.String(), `<meta property="og:description" content="Link to docs.example.org" />`)
+	})
+
+	t.Run("BrowserIsRedirected", func(t *testing.T) {
+		rec := get(e, "/titled", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
+		assert.Equal(t, http.StatusFound, rec.Code)
+		assert.Equal(t, "https://example.com/article?id=1", rec.Header().Get(echo.HeaderLocation))
+	})
+
+	t.Run("SearchEngineIsRedirected", func(t *testing.T) {
+		rec := get(e, "/titled", "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)")
+		assert.Equal(t, http.StatusFound, rec.Code)
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		rec := get(newRealTestServer(repo, newTestConfig()), "/titled", facebook)
+		assert.Equal(t, http.StatusFound, rec.Code)
+	})
+
+	t.Run("CrawlerVisitsAreNotTracked", func(t *testing.T) {
+		repo := newFakeRepository()
+		_, err := repo.Create(ctx, models.NewURL("https://example.com/tracked", "tracked", "", time.Time{}, ""))
+		assert.NoError(t, err)
+		e := newRealTestServer(repo, cfg)
+		clicks := func() []*models.Click {
+			repo.mu.Lock()
+			defer repo.mu.Unlock()
+			return append([]*models.Click(nil), repo.clicks...)
+		}
+
+		rec := get(e, "/tracked", facebook)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		// A browser click from another visitor shows when tracking has caught up
+		req := httptest.NewRequest(http.MethodGet, "/tracked", nil)
+		req.RemoteAddr = "203.0.113.7:1234"
+		serveRequest(e, req)
+
+		assert.Eventually(t, func() bool { return len(clicks()) == 1 }, time.Second, 10*time.Millisecond)
+		assert.Never(t, func() bool { return len(clicks()) > 1 }, 100*time.Millisecond, 10*time.Millisecond)
+		if recorded := clicks(); assert.Len(t, recorded, 1) {
+			assert.Equal(t, "203.0.113.7", recorded[0].IP)
+		}
+	})
+}
+
+// TestClickExtraFields tests recording allowlisted extra click fields from redirects and beacons
+func TestClickExtraFields(t *testing.T) {
+	ctx := context.Background()
+	newServer := func(t *testing.T) (*fakeRepository, *echo.Echo) {
+		repo := newFakeRepository()
+		_, err := repo.Create(ctx, models.NewURL("https://example.com", "abc123", "", time.Time{}, "test-user"))
+		assert.NoError(t, err)
+		cfg := newTestConfig()
+		cfg.ClickCountMode = config.ClickCountModeProceed
+		e := echo.New()
+		NewURLHandler(store.NewURLService(repo, nil, store.WithClickExtraFields("screen", "theme")), cfg).Register(e)
+		return repo, e
+	}
+	clicks := func(repo *fakeRepository) []*models.Click {
+		repo.mu.Lock()
+		defer repo.mu.Unlock()
+		return append([]*models.Click(nil), repo.clicks...)
+	}
+	beacon := func(e *echo.Echo, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/abc123/beacon", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, "text/plain")
+		return serveRequest(e, req)
+	}
+
+	t.Run("RedirectRecordsAllowlistedQueryParameters", func(t *testing.T) {
+		repo, e := newServer(t)
+
+		req := httptest.NewRequest(http.MethodGet, "/abc123?screen=1920x1080&utm_source=mail", nil)
+		rec := serveRequest(e, req)
+		assert.Equal(t, http.StatusFound, rec.Code)
+
+		assert.Eventually(t, func() bool { return len(clicks(repo)) == 1 }, time.Second, 10*time.Millisecond)
+		assert.Equal(t, map[string]string{"screen": "1920x1080"}, clicks(repo)[0].Extra)
+	})
+
+	t.Run("BeaconStoresExtraFields", func(t *testing.T) {
+		repo, e := newServer(t)
+
+		rec := beacon(e, `{"extra": {"screen": "390x844", "theme": "dark"}}`)
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		if assert.Len(t, clicks(repo), 1) {
+			assert.Equal(t, map[string]string{"screen": "390x844", "theme": "dark"}, clicks(repo)[0].Extra)
+		}
+
+		// Extra fields show up in raw click exports
+		req := newAPIRequest(http.MethodGet, "/api/urls/abc123/clicks/export", "")
+		req.Header.Set("Accept", MIMEApplicationNDJSON)
+		rec = serveRequest(e, req)
+		var exported models.Click
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &exported))
+		assert.Equal(t, "dark", exported.Extra["theme"])
+
+		req = newAPIRequest(http.MethodGet, "/api/urls/abc123/clicks/export", "")
+		rec = serveRequest(e, req)
+		records, err := csv.NewReader(rec.Body).ReadAll()
+		assert.NoError(t, err)
+		if assert.Len(t, records, 2) {
+			assert.Equal(t, "extra", records[0][11])
+			assert.JSONEq(t, `{"screen": "390x844", "theme": "dark"}`, records[1][11])
+		}
+	})
+
+	t.Run("BeaconRejectsFieldsNotAllowlisted", func(t *testing.T) {
+		repo, e := newServer(t)
+
+		rec := beacon(e, `{"extra": {"screen": "390x844", "battery": "12%"}}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), "click_field_not_allowed")
+
+		rec = beacon(e, `{"extra": {"screen": "`+strings.Repeat("x", store.MaxClickExtraValueLength+1)+`"}}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+		rec = beacon(e, `not json`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Empty(t, clicks(repo))
+	})
+}
+
+// TestGetURLsByCreator tests sorting and paging a creator's URLs
+func TestGetURLsByCreator(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeRepository()
+	e := newRealTestServer(repo, newTestConfig())
+
+	base := time.Now().Add(-time.Hour)
+	for i, clicks := range []int64{3, 1, 2} {
+		url := models.NewURL("https://example.com", "creator"+strconv.Itoa(i), "", time.Time{}, "lister")
+		url.CreatedAt = base.Add(time.Duration(i) * time.Minute)
+		url.Clicks = clicks
+		_, err := repo.Create(ctx, url)
+		assert.NoError(t, err)
+	}
+	_, err := repo.Create(ctx, models.NewURL("https://example.com", "someone", "", time.Time{}, "someone-else"))
+	assert.NoError(t, err)
+
+	type listResponse struct {
+		URLs   []URLResponse `json:"urls"`
+		Total  int64         `json:"total"`
+		Limit  int           `json:"limit"`
+		Offset int           `json:"offset"`
+		Sort   string        `json:"sort"`
+		Order  string        `json:"order"`
+	}
+
+	get := func(query string) (*httptest.ResponseRecorder, listResponse) {
+		rec := serveAPI(e, http.MethodGet, "/api/urls/creator/lister"+query, "")
+		var response listResponse
+		_ = json.Unmarshal(rec.Body.Bytes(), &response)
+		return rec, response
+	}
+	codes := func(response listResponse) []string {
+		var shorts []string
+		for _, url := range response.URLs {
+			shorts = append(shorts, url.ShortCode)
+		}
+		return shorts
+	}
+
+	t.Run("Defaults", func(t *testing.T) {
+		rec, response := get("")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, int64(3), response.Total)
+		assert.Equal(t, 20, response.Limit)
+		assert.Equal(t, 0, response.Offset)
+		assert.Equal(t, "created_at", response.Sort)
+		assert.Equal(t, "desc", response.Order)
+		assert.Equal(t, []string{"creator2", "creator1", "creator0"}, codes(response))
+	})
+
+	t.Run("SortByClicks", func(t *testing.T) {
+		_, response := get("?sort=clicks&order=asc")
+		assert.Equal(t, []string{"creator1", "creator2", "creator0"}, codes(response))
+	})
+
+	t.Run("Page", func(t *testing.T) {
+		rec, response := get("?sort=clicks&limit=2&offset=2")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, int64(3), response.Total)
+		assert.Equal(t, []string{"creator1"}, codes(response))
+		assert.Contains(t, rec.Header().Get("Link"), `rel="prev"`)
+	})
+
+	t.Run("NoURLs", func(t *testing.T) {
+		rec := serveAPI(e, http.MethodGet, "/api/urls/creator/nobody", "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"urls":[]`)
+	})
+
+	t.Run("InvalidParameters", func(t *testing.T) {
+		for _, query := range []string{"?sort=title", "?order=up", "?limit=0", "?limit=1000", "?offset=-1"} {
+			rec, _ := get(query)
+			assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+		}
+	})
+}
+
+// TestPreviewInterstitial tests rendering the interstitial without counting a click
+func TestPreviewInterstitial(t *testing.T) {
+	chdirRepoRoot(t)
+	ctx := context.Background()
+	repo := newFakeRepository()
+	_, err := repo.Create(ctx, models.NewURL("https://example.com/landing", "abc123", "", time.Time{}, "test-user"))
+	assert.NoError(t, err)
+	e := newRealTestServer(repo, newTestConfig())
+
+	// No Accept header: the preview renders HTML regardless
+	rec := serveAPI(e, http.MethodGet, "/api/urls/abc123/preview", "")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Header().Get(echo.HeaderContentType), "text/html")
+	assert.Contains(t, rec.Body.String(), "https://example.com/landing")
+	assert.Contains(t, rec.Body.String(), `data-beacon=""`)
+
+	url, err := repo.GetByShort(ctx, "abc123")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), url.Clicks)
+	clicks, _ := repo.GetClicksByShort(ctx, "abc123")
+	assert.Empty(t, clicks)
+
+	req := newAPIRequest(http.MethodGet, "/api/urls/missing/preview", "")
+	rec = serveRequest(e, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+// TestURLAnalyticsWindow tests that analytics default to the configured window unless a range is given
+func TestURLAnalyticsWindow(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeRepository()
+	created, err := repo.Create(ctx, models.NewURL("https://example.com", "abc123", "", time.Time{}, "test-user"))
+	assert.NoError(t, err)
+	for _, age := range []time.Duration{time.Hour, 24 * time.Hour, 100 * 24 * time.Hour} {
+		click := models.NewClick(created.ID, "abc123", "127.0.0.1", "Unknown", "Chrome", "Desktop")
+		click.Timestamp = time.Now().Add(-age)
+		assert.NoError(t, repo.StoreClick(ctx, click))
+	}
+	e := echo.New()
+	service := store.NewURLService(repo, nil, store.WithAnalyticsWindow(90*24*time.Hour))
+	NewURLHandler(service, newTestConfig()).Register(e)
+
+	get := func(query string) (int, map[string]interface{}) {
+		rec := serveAPI(e, http.MethodGet, "/api/urls/abc123/analytics"+query, "")
+		var response struct {
+			Analytics map[string]interface{} `json:"analytics"`
+		}
+		_ = json.Unmarshal(rec.Body.Bytes(), &response)
+		return rec.Code, response.Analytics
+	}
+
+	t.Run("DefaultWindow", func(t *testing.T) {
+		code, analytics := get("")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, float64(2), analytics["total_clicks"])
+		assert.Contains(t, analytics, "from")
+		assert.NotContains(t, analytics, "to")
+	})
+
+	t.Run("ExplicitRange", func(t *testing.T) {
+		from := time.Now().AddDate(0, 0, -200).Format(time.DateOnly)
+		code, analytics := get("?from=" + from)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, float64(3), analytics["total_clicks"])
+
+		to := time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339)
+		code, analytics = get("?from=" + from + "&to=" + to)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, float64(2), analytics["total_clicks"])
+	})
+
+	t.Run("InvalidRange", func(t *testing.T) {
+		for _, query := range []string{"?from=yesterday", "?to=2024-13-01", "?from=2024-02-01&to=2024-01-01"} {
+			code, _ := get(query)
+			assert.Equal(t, http.StatusBadRequest, code, query)
+		}
+	})
 }
@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/fransfilastap/urlshortener/store"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// Errors reported for CSV rows that can't be imported
+var (
+	errInvalidImportRow = store.NewAPIError(http.StatusBadRequest, "invalid_row", "Rows need an original URL, optionally followed by a short code and a title")
+	errInvalidCSV       = store.NewAPIError(http.StatusBadRequest, "invalid_csv", "Invalid CSV")
+)
+
+// ImportRowResult is the outcome of one CSV row of an import: the created short URL, or the error that
+// prevented it. Row is the row's line number in the file.
+type ImportRowResult struct {
+	Row       int    `json:"row"`
+	ShortCode string `json:"short_code,omitempty"`
+	ShortURL  string `json:"short_url,omitempty"`
+	Error     string `json:"error,omitempty"`
+	Code      string `json:"code,omitempty"`
+}
+
+// ImportResponse reports the outcome of a CSV import
+type ImportResponse struct {
+	Imported int               `json:"imported"`
+	Failed   int               `json:"failed"`
+	Results  []ImportRowResult `json:"results"`
+}
+
+// setError records why a row failed, hiding the details of unexpected errors
+func (r *ImportRowResult) setError(err error) {
+	apiErr := batchItemError(err)
+	r.Error = apiErr.Message
+	r.Code = apiErr.Code
+}
+
+// ImportCSV handles requests to create short URLs from a CSV file uploaded as the "file" part of a
+// multipart form. Each row holds an original URL, an optional short code and an optional title; a
+// first row starting with "original" is skipped as a header. An optional creator_reference field must
+// precede the file. The file is streamed and saved in chunks of store.MaxImportChunkSize rows, so
+// invalid rows, rows over the shorten rate limit and rows whose destination fails verification are
+// reported in their result without failing the others.
+func (h *URLHandler) ImportCSV(c echo.Context) error {
+	reader, err := c.Request().MultipartReader()
+	if err != nil {
+		log.Error().Err(err).Msg("Invalid request format for URL import")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request"})
+	}
+
+	var creatorReference string
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			log.Error().Msg("No file provided for URL import")
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Missing file"})
+		}
+		if err != nil {
+			log.Error().Err(err).Msg("Invalid multipart body for URL import")
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request"})
+		}
+
+		switch part.FormName() {
+		case "creator_reference":
+			value, err := io.ReadAll(io.LimitReader(part, 256))
+			if err != nil {
+				log.Error().Err(err).Msg("Failed to read creator reference for URL import")
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request"})
+			}
+			creatorReference = strings.TrimSpace(string(value))
+		case "file":
+			return h.importCSV(c, part, creatorReference)
+		}
+	}
+}
+
+// importCSV reads CSV rows from r and imports them in chunks
+func (h *URLHandler) importCSV(c echo.Context, r io.Reader, creatorReference string) error {
+	log.Debug().Str("creator_reference", creatorReference).Msg("Importing URLs from CSV")
+
+	ctx := c.Request().Context()
+	records := csv.NewReader(r)
+	records.FieldsPerRecord = -1
+	records.TrimLeadingSpace = true
+	records.ReuseRecord = true
+
+	response := ImportResponse{Results: []ImportRowResult{}}
+	items := make([]store.ImportItem, 0, store.MaxImportChunkSize)
+	// pending holds the index in response.Results of each item
+	var pending []int
+
+	// flush imports the pending rows and fills in their results
+	flush := func() error {
+		if len(items) == 0 {
+			return nil
+		}
+		results, err := h.service.ImportURLs(ctx, items, creatorReference)
+		if err != nil {
+			return err
+		}
+		for i, result := range results {
+			row := &response.Results[pending[i]]
+			if result.Err != nil {
+				row.setError(result.Err)
+				response.Failed++
+				continue
+			}
+			row.ShortCode = result.URL.Short
+			row.ShortURL = h.shortURL(result.URL.Short)
+			response.Imported++
+		}
+		items, pending = items[:0], pending[:0]
+		return nil
+	}
+
+	first := true
+	for {
+		record, err := records.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			// The reader skips past a malformed row, so the rows after it can still be imported
+			log.Debug().Err(err).Int("row", parseErr.StartLine).Msg("Malformed CSV row")
+			row := ImportRowResult{Row: parseErr.StartLine}
+			row.setError(errInvalidCSV)
+			response.Results = append(response.Results, row)
+			response.Failed++
+			first = false
+			continue
+		}
+		if err != nil {
+			log.Error().Err(err).Int("imported", response.Imported).Msg("Failed to read CSV for URL import")
+			return errInvalidCSV
+		}
+
+		line, _ := records.FieldPos(0)
+		if first {
+			first = false
+			if strings.EqualFold(strings.TrimSpace(record[0]), "original") {
+				continue
+			}
+		}
+
+		if len(record) > 3 {
+			row := ImportRowResult{Row: line}
+			row.setError(errInvalidImportRow)
+			response.Results = append(response.Results, row)
+			response.Failed++
+			continue
+		}
+		item := store.ImportItem{OriginalURL: strings.TrimSpace(record[0])}
+		err = h.limitShorten(c)
+		if err == nil {
+			err = h.verifyDestination(c, item.OriginalURL)
+		}
+		if err != nil {
+			row := ImportRowResult{Row: line}
+			row.setError(err)
+			response.Results = append(response.Results, row)
+			response.Failed++
+			continue
+		}
+		if len(record) > 1 {
+			item.CustomShort = strings.TrimSpace(record[1])
+		}
+		if len(record) > 2 {
+			item.Title = strings.TrimSpace(record[2])
+		}
+		items = append(items, item)
+		pending = append(pending, len(response.Results))
+		response.Results = append(response.Results, ImportRowResult{Row: line})
+
+		if len(items) == store.MaxImportChunkSize {
+			if err := flush(); err != nil {
+				log.Error().Err(err).Msg("Failed to import URLs from CSV")
+				return err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		log.Error().Err(err).Msg("Failed to import URLs from CSV")
+		return err
+	}
+
+	log.Info().
+		Str("creator_reference", creatorReference).
+		Int("imported", response.Imported).
+		Int("failed", response.Failed).
+		Msg("URLs imported from CSV")
+
+	return c.JSON(http.StatusOK, response)
+}
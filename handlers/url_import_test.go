@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestImportCSV tests that URLs can be imported from an uploaded CSV file, with failures reported per row
+func TestImportCSV(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeRepository()
+	e := newRealTestServer(repo, newTestConfig())
+	_, err := repo.Create(ctx, models.NewURL("https://example.com/taken", "taken", "", time.Time{}, ""))
+	assert.NoError(t, err)
+
+	post := func(creatorReference, file string) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		if creatorReference != "" {
+			assert.NoError(t, form.WriteField("creator_reference", creatorReference))
+		}
+		if file != "" {
+			part, err := form.CreateFormFile("file", "urls.csv")
+			assert.NoError(t, err)
+			_, err = part.Write([]byte(file))
+			assert.NoError(t, err)
+		}
+		assert.NoError(t, form.Close())
+
+		req := httptest.NewRequest(http.MethodPost, "/api/import", &body)
+		req.Header.Set(echo.HeaderContentType, form.FormDataContentType())
+		req.Header.Set("X-API-Key", "test-api-key")
+		return serveRequest(e, req)
+	}
+
+	t.Run("MissingFile", func(t *testing.T) {
+		rec := post("importer", "")
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/import", strings.NewReader("original\n"))
+		req.Header.Set(echo.HeaderContentType, "text/csv")
+		req.Header.Set("X-API-Key", "test-api-key")
+		rec = serveRequest(e, req)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("PerRowResults", func(t *testing.T) {
+		rec := post("importer", strings.Join([]string{
+			"original,short,title",
+			"https://example.com/one,imported-one,First",
+			"https://example.com/two",
+			"not a url,bad-url",
+			"https://example.com/three,taken",
+			"https://example.com/four,imported-one",
+			"https://example.com/five,a,b,c",
+			`https://example.com/six,"bad"quote`,
+			"https://example.com/seven,imported-seven",
+		}, "\n"))
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var response ImportResponse
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		assert.Equal(t, 3, response.Imported)
+		assert.Equal(t, 5, response.Failed)
+		if assert.Len(t, response.Results, 8) {
+			for i, result := range response.Results {
+				assert.Equal(t, i+2, result.Row)
+			}
+			assert.Equal(t, "imported-one", response.Results[0].ShortCode)
+			assert.Equal(t, "http://localhost:8080/imported-one", response.Results[0].ShortURL)
+			assert.NotEmpty(t, response.Results[1].ShortCode)
+			assert.Equal(t, "invalid_url", response.Results[2].Code)
+			assert.Equal(t, "url_exists", response.Results[3].Code)
+			assert.Equal(t, "url_exists", response.Results[4].Code)
+			assert.Equal(t, "invalid_row", response.Results[5].Code)
+			assert.Equal(t, "invalid_csv", response.Results[6].Code)
+			assert.Equal(t, "imported-seven", response.Results[7].ShortCode)
+		}
+
+		url, err := repo.GetByShort(ctx, "imported-one")
+		assert.NoError(t, err)
+		assert.Equal(t, "https://example.com/one", url.Original)
+		assert.Equal(t, "First", url.Title)
+		assert.Equal(t, "importer", url.CreatorReference)
+
+		url, err = repo.GetByShort(ctx, "taken")
+		assert.NoError(t, err)
+		assert.Equal(t, "https://example.com/taken", url.Original)
+	})
+}
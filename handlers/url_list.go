@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/fransfilastap/urlshortener/store"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// AdminURLResponse is a URL in the admin listing, which may be soft-deleted
+type AdminURLResponse struct {
+	URLResponse
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+// URLListResponse is a page of all URLs
+type URLListResponse struct {
+	URLs   []AdminURLResponse `json:"urls"`
+	Total  int64              `json:"total"`
+	Limit  int                `json:"limit"`
+	Offset int                `json:"offset"`
+}
+
+// ListURLs returns a page of all URLs, newest first, for operators. Query parameters filter by creator,
+// creation time, minimum clicks and a case-insensitive search of the original URL and title;
+// include_deleted=true also lists soft-deleted URLs.
+func (h *URLHandler) ListURLs(c echo.Context) error {
+	limit, err := queryInt(c, "limit", defaultPageLimit)
+	if err != nil || limit == 0 || limit > maxPageLimit {
+		log.Error().Str("limit", c.QueryParam("limit")).Msg("Invalid limit in URL list request")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid limit"})
+	}
+	offset, err := queryInt(c, "offset", 0)
+	if err != nil {
+		log.Error().Str("offset", c.QueryParam("offset")).Msg("Invalid offset in URL list request")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid offset"})
+	}
+
+	filter := store.URLListFilter{
+		CreatorReference: c.QueryParam("creator_reference"),
+		Search:           c.QueryParam("q"),
+	}
+	if filter.CreatedAfter, err = queryTime(c, "created_after"); err != nil {
+		log.Error().Str("created_after", c.QueryParam("created_after")).Msg("Invalid created_after in URL list request")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid created_after"})
+	}
+	if filter.CreatedBefore, err = queryTime(c, "created_before"); err != nil {
+		log.Error().Str("created_before", c.QueryParam("created_before")).Msg("Invalid created_before in URL list request")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid created_before"})
+	}
+	if !filter.CreatedAfter.IsZero() && !filter.CreatedBefore.IsZero() && !filter.CreatedAfter.Before(filter.CreatedBefore) {
+		log.Error().Time("created_after", filter.CreatedAfter).Time("created_before", filter.CreatedBefore).Msg("Empty range in URL list request")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "created_after must be before created_before"})
+	}
+	minClicks, err := queryInt(c, "min_clicks", 0)
+	if err != nil {
+		log.Error().Str("min_clicks", c.QueryParam("min_clicks")).Msg("Invalid min_clicks in URL list request")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid min_clicks"})
+	}
+	filter.MinClicks = int64(minClicks)
+	if param := c.QueryParam("include_deleted"); param != "" {
+		if filter.IncludeDeleted, err = strconv.ParseBool(param); err != nil {
+			log.Error().Str("include_deleted", param).Msg("Invalid include_deleted in URL list request")
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid include_deleted"})
+		}
+	}
+	page := store.Page{Limit: limit, Offset: offset}
+
+	log.Debug().
+		Str("creator_reference", filter.CreatorReference).
+		Str("q", filter.Search).
+		Bool("include_deleted", filter.IncludeDeleted).
+		Int("limit", limit).
+		Int("offset", offset).
+		Msg("Listing all URLs")
+
+	urls, total, err := h.service.ListURLs(c.Request().Context(), filter, page)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to list URLs")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to list URLs"})
+	}
+
+	response := make([]AdminURLResponse, 0, len(urls))
+	for _, url := range urls {
+		response = append(response, AdminURLResponse{URLResponse: h.toURLResponse(url), DeletedAt: url.DeletedAt})
+	}
+
+	log.Info().Int("count", len(response)).Int64("total", total).Msg("All URLs listed")
+
+	setPaginationLinks(c, limit, offset, total)
+	return c.JSON(http.StatusOK, URLListResponse{
+		URLs:   response,
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	})
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestListURLs tests the admin listing of all URLs and its filters
+func TestListURLs(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeRepository()
+	now := time.Now().UTC().Truncate(time.Second)
+	seed := []struct {
+		short, original, title, creator string
+		age                             time.Duration
+		clicks                          int64
+		deleted                         bool
+	}{
+		{"alpha", "https://example.com/spring-sale", "Spring", "alice", 3 * time.Hour, 10, false},
+		{"beta", "https://shop.example/100%_off", "Clearance", "bob", 2 * time.Hour, 0, false},
+		{"gamma", "https://example.org/docs", "Spring docs", "alice", time.Hour, 5, false},
+		{"delta", "https://example.com/old", "", "alice", 30 * time.Minute, 50, true},
+	}
+	for _, u := range seed {
+		url := models.NewURL(u.original, u.short, u.title, time.Time{}, u.creator)
+		url.CreatedAt = now.Add(-u.age)
+		url.Clicks = u.clicks
+		if u.deleted {
+			deletedAt := now
+			url.DeletedAt = &deletedAt
+		}
+		_, err := repo.Create(ctx, url)
+		assert.NoError(t, err)
+	}
+	e := newRealTestServer(repo, newTestConfig())
+
+	list := func(t *testing.T, query string) (*httptest.ResponseRecorder, URLListResponse) {
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/urls?"+query, nil)
+		req.Header.Set("X-Admin-Key", "test-admin-key")
+		rec := serveRequest(e, req)
+		var response URLListResponse
+		if rec.Code == http.StatusOK {
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		}
+		return rec, response
+	}
+
+	cases := []struct {
+		name      string
+		query     string
+		wantCodes []string
+		wantTotal int64
+	}{
+		{"AllLiveNewestFirst", "", []string{"gamma", "beta", "alpha"}, 3},
+		{"IncludeDeleted", "include_deleted=true", []string{"delta", "gamma", "beta", "alpha"}, 4},
+		{"Creator", "creator_reference=alice", []string{"gamma", "alpha"}, 2},
+		{"CreatedAfter", "created_after=" + now.Add(-150*time.Minute).Format(time.RFC3339), []string{"gamma", "beta"}, 2},
+		{"CreatedBefore", "created_before=" + now.Add(-time.Hour).Format(time.RFC3339), []string{"beta", "alpha"}, 2},
+		{"MinClicks", "min_clicks=5", []string{"gamma", "alpha"}, 2},
+		{"SearchTitleIgnoringCase", "q=SPRING", []string{"gamma", "alpha"}, 2},
+		{"SearchOriginalWithWildcardCharacters", "q=100%25_", []string{"beta"}, 1},
+		{"Combined", "creator_reference=alice&min_clicks=1&q=docs", []string{"gamma"}, 1},
+		{"Paged", "limit=2&offset=1", []string{"beta", "alpha"}, 3},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec, response := list(t, tc.query)
+			assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+			codes := make([]string, 0, len(response.URLs))
+			for _, u := range response.URLs {
+				codes = append(codes, u.ShortCode)
+			}
+			assert.Equal(t, tc.wantCodes, codes)
+			assert.Equal(t, tc.wantTotal, response.Total)
+		})
+	}
+
+	t.Run("DeletedAtReported", func(t *testing.T) {
+		rec, response := list(t, "include_deleted=true&limit=1")
+		if !assert.Len(t, response.URLs, 1) {
+			return
+		}
+		assert.Equal(t, "delta", response.URLs[0].ShortCode)
+		assert.NotNil(t, response.URLs[0].DeletedAt)
+		assert.Contains(t, rec.Header().Get("Link"), `rel="next"`)
+	})
+
+	t.Run("InvalidParameters", func(t *testing.T) {
+		for _, query := range []string{"limit=0", "offset=-1", "min_clicks=x", "include_deleted=maybe", "created_after=yesterday", "created_after=2024-02-01&created_before=2024-01-01"} {
+			rec, _ := list(t, query)
+			assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+		}
+	})
+
+	t.Run("RequiresAdminKey", func(t *testing.T) {
+		rec := serveAPI(e, http.MethodGet, "/api/admin/urls", "")
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// ResolveBatchRequest lists the short codes to resolve
+type ResolveBatchRequest struct {
+	Codes []string `json:"codes"`
+}
+
+// ResolveError explains why a code didn't resolve
+type ResolveError struct {
+	Error string `json:"error"`
+	Code  string `json:"code"`
+}
+
+// ResolveBatchResponse maps each resolved code to its original URL and each other code to the reason
+// it doesn't redirect to a fixed URL
+type ResolveBatchResponse struct {
+	URLs   map[string]string       `json:"urls"`
+	Errors map[string]ResolveError `json:"errors"`
+}
+
+// ResolveBatch handles requests to look up the destinations of up to store.MaxBatchSize codes at once,
+// e.g. to preload redirects. Nothing is counted as a click.
+func (h *URLHandler) ResolveBatch(c echo.Context) error {
+	var req ResolveBatchRequest
+	if err := c.Bind(&req); err != nil {
+		log.Error().Err(err).Msg("Invalid request format for batch resolve")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request"})
+	}
+	if len(req.Codes) == 0 {
+		log.Error().Msg("No codes provided for batch resolve")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Missing codes"})
+	}
+
+	log.Debug().Int("codes", len(req.Codes)).Msg("Resolving code batch")
+
+	// Signed codes are looked up by their public code; forged ones can't match any URL
+	codes := make([]string, len(req.Codes))
+	for i, signedCode := range req.Codes {
+		if code, ok := h.publicCode(signedCode); ok {
+			codes[i] = code
+		}
+	}
+
+	results, err := h.service.ResolveCodes(c.Request().Context(), codes)
+	if err != nil {
+		log.Error().Err(err).Int("codes", len(req.Codes)).Msg("Failed to resolve code batch")
+		return err
+	}
+
+	response := ResolveBatchResponse{URLs: map[string]string{}, Errors: map[string]ResolveError{}}
+	for i, result := range results {
+		if result.Err != nil {
+			apiErr := batchItemError(result.Err)
+			response.Errors[req.Codes[i]] = ResolveError{Error: apiErr.Message, Code: apiErr.Code}
+			continue
+		}
+		response.URLs[req.Codes[i]] = result.URL.Original
+	}
+
+	log.Info().
+		Int("codes", len(req.Codes)).
+		Int("resolved", len(response.URLs)).
+		Msg("Code batch resolved")
+
+	return c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestResolveBatch tests resolving active, expired, disabled and missing codes in one request
+func TestResolveBatch(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeRepository()
+	for _, url := range []*models.URL{
+		models.NewURL("https://example.com/active", "active", "", time.Time{}, "test-user"),
+		models.NewURL("https://example.com/expired", "expired", "", time.Now().Add(-time.Hour), "test-user"),
+		models.NewURL("https://example.com/disabled", "disabled", "", time.Time{}, "test-user"),
+	} {
+		_, err := repo.Create(ctx, url)
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, repo.SetEnabled(ctx, "disabled", false))
+	e := newRealTestServer(repo, newTestConfig())
+
+	post := func(body string) *httptest.ResponseRecorder {
+		return serveAPI(e, http.MethodPost, "/api/resolve/batch", body)
+	}
+
+	rec := post(`{"codes": ["active", "expired", "disabled", "missing"]}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var response ResolveBatchResponse
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, map[string]string{"active": "https://example.com/active"}, response.URLs)
+	assert.Equal(t, "url_expired", response.Errors["expired"].Code)
+	assert.Equal(t, "url_disabled", response.Errors["disabled"].Code)
+	assert.Equal(t, "url_not_found", response.Errors["missing"].Code)
+
+	// Resolving doesn't count clicks
+	url, err := repo.GetByShort(ctx, "active")
+	assert.NoError(t, err)
+	assert.Zero(t, url.Clicks)
+	clicks, err := repo.GetClicksByShort(ctx, "active")
+	assert.NoError(t, err)
+	assert.Empty(t, clicks)
+
+	assert.Equal(t, http.StatusBadRequest, post(`{"codes": []}`).Code)
+}
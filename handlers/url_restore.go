@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// RestoreURLRequest represents a request to restore a deleted URL
+type RestoreURLRequest struct {
+	CreatorReference string `json:"creator_reference"`
+}
+
+// RestoreURL handles requests to undo the deletion of a URL. Admins may restore any URL; others must
+// name the URL's creator. Restoring fails with 409 if a live URL uses the short code.
+func (h *URLHandler) RestoreURL(c echo.Context) error {
+	code := c.Param("code")
+
+	var req RestoreURLRequest
+	if err := c.Bind(&req); err != nil {
+		log.Error().Err(err).Msg("Invalid request format for restoring URL")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request"})
+	}
+
+	log.Debug().Str("code", code).Str("creator_reference", req.CreatorReference).Msg("Restoring URL")
+
+	creatorReference, modifiedBy := req.CreatorReference, req.CreatorReference
+	if h.isAdmin(c) {
+		creatorReference, modifiedBy = "", adminIdentity
+	} else if creatorReference == "" {
+		log.Warn().Str("code", code).Msg("No creator reference provided for restoring URL")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Missing creator reference"})
+	}
+
+	url, err := h.service.Restore(c.Request().Context(), code, creatorReference, modifiedBy)
+	if err != nil {
+		log.Error().Err(err).Str("code", code).Msg("Failed to restore URL")
+		return err
+	}
+
+	log.Info().Str("code", code).Str("modified_by", modifiedBy).Msg("URL restored successfully")
+
+	return c.JSON(http.StatusOK, h.toURLResponse(url))
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/fransfilastap/urlshortener/store"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRestoreURL tests undoing the deletion of a URL
+func TestRestoreURL(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeRepository()
+	e := newRealTestServer(repo, newTestConfig())
+
+	create := func(code, creator string) {
+		t.Helper()
+		_, err := repo.Create(ctx, models.NewURL("https://example.com/"+code, code, "", time.Time{}, creator))
+		assert.NoError(t, err)
+	}
+	deleteURL := func(code, creator string) {
+		t.Helper()
+		rec := serveAPI(e, http.MethodDelete, "/api/urls/"+code+"?creator_reference="+creator, "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+	}
+	restore := func(code, body string, admin bool) *httptest.ResponseRecorder {
+		req := newAPIRequest(http.MethodPost, "/api/urls/"+code+"/restore", body)
+		if admin {
+			req.Header.Set("X-Admin-Key", "test-admin-key")
+		}
+		return serveRequest(e, req)
+	}
+	redirect := func(code string) int {
+		rec := serveRequest(e, httptest.NewRequest(http.MethodGet, "/"+code, nil))
+		return rec.Code
+	}
+	lastHistory := func(code string) *models.URLHistory {
+		history, _, err := repo.GetURLHistory(ctx, code, store.HistoryFilter{Limit: 1})
+		assert.NoError(t, err)
+		assert.Len(t, history, 1)
+		return history[0]
+	}
+
+	t.Run("ByCreator", func(t *testing.T) {
+		create("oops", "alice")
+		deleteURL("oops", "alice")
+		assert.Equal(t, http.StatusNotFound, redirect("oops"))
+
+		assert.Equal(t, http.StatusBadRequest, restore("oops", `{}`, false).Code)
+		assert.Equal(t, http.StatusUnauthorized, restore("oops", `{"creator_reference": "bob"}`, false).Code)
+
+		rec := restore("oops", `{"creator_reference": "alice"}`, false)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var response URLResponse
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		assert.Equal(t, "oops", response.ShortCode)
+		assert.Equal(t, http.StatusFound, redirect("oops"))
+
+		entry := lastHistory("oops")
+		assert.Equal(t, "restore", entry.Action)
+		assert.Equal(t, "alice", entry.ModifiedBy)
+
+		rec = serveAPI(e, http.MethodGet, "/api/urls/oops/history?action=restore", "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"total":1`)
+	})
+
+	t.Run("ByAdmin", func(t *testing.T) {
+		create("abuse", "alice")
+		deleteURL("abuse", "alice")
+
+		assert.Equal(t, http.StatusOK, restore("abuse", `{}`, true).Code)
+		assert.Equal(t, "admin", lastHistory("abuse").ModifiedBy)
+	})
+
+	t.Run("NotDeleted", func(t *testing.T) {
+		create("alive", "alice")
+
+		rec := restore("alive", `{"creator_reference": "alice"}`, false)
+		assert.Equal(t, http.StatusConflict, rec.Code)
+		assert.Contains(t, rec.Body.String(), "code_in_use")
+	})
+
+	t.Run("CodeStaysReserved", func(t *testing.T) {
+		create("reserved", "alice")
+		deleteURL("reserved", "alice")
+		_, err := repo.Create(ctx, models.NewURL("https://example.com/other", "reserved", "", time.Time{}, "bob"))
+		assert.ErrorIs(t, err, store.ErrURLExists)
+
+		assert.Equal(t, http.StatusOK, restore("reserved", `{"creator_reference": "alice"}`, false).Code)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, restore("missing", `{"creator_reference": "alice"}`, false).Code)
+	})
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/fransfilastap/urlshortener/store"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// URLSearchResponse is a page of URLs matching a search query, best matches first
+type URLSearchResponse struct {
+	URLs   []URLResponse `json:"urls"`
+	Query  string        `json:"query"`
+	Total  int64         `json:"total"`
+	Limit  int           `json:"limit"`
+	Offset int           `json:"offset"`
+}
+
+// SearchURLs returns a ranked page of live URLs whose original URL or title match the words of the q
+// query parameter. Without creator_reference every creator's URLs are searched, which requires admin
+// access. An empty query matches nothing.
+func (h *URLHandler) SearchURLs(c echo.Context) error {
+	query := c.QueryParam("q")
+	creatorReference := c.QueryParam("creator_reference")
+
+	limit, err := queryInt(c, "limit", defaultPageLimit)
+	if err != nil || limit == 0 || limit > maxPageLimit {
+		log.Error().Str("limit", c.QueryParam("limit")).Msg("Invalid limit in URL search request")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid limit"})
+	}
+	offset, err := queryInt(c, "offset", 0)
+	if err != nil {
+		log.Error().Str("offset", c.QueryParam("offset")).Msg("Invalid offset in URL search request")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid offset"})
+	}
+
+	log.Debug().
+		Str("q", query).
+		Str("creator_reference", creatorReference).
+		Int("limit", limit).
+		Int("offset", offset).
+		Msg("Searching URLs")
+
+	// Every creator's links are only visible to admins
+	if creatorReference == "" && !h.isAdmin(c) {
+		log.Warn().Msg("Search across all creators requested without admin access")
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "Searching all creators' URLs requires admin access"})
+	}
+
+	urls, total, err := h.service.SearchURLs(c.Request().Context(), query, creatorReference, store.Page{Limit: limit, Offset: offset})
+	if err != nil {
+		log.Error().Err(err).Str("q", query).Msg("Failed to search URLs")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to search URLs"})
+	}
+
+	response := make([]URLResponse, 0, len(urls))
+	for _, url := range urls {
+		response = append(response, h.toURLResponse(url))
+	}
+
+	log.Info().Str("q", query).Int("count", len(response)).Int64("total", total).Msg("URLs searched")
+
+	setPaginationLinks(c, limit, offset, total)
+	return c.JSON(http.StatusOK, URLSearchResponse{
+		URLs:   response,
+		Query:  query,
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	})
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSearchURLs tests searching links by the words of their original URL and title
+func TestSearchURLs(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeRepository()
+	for _, url := range []*models.URL{
+		models.NewURL("https://example.com/spring-sale", "alpha", "", time.Time{}, "alice"),
+		models.NewURL("https://example.org/docs", "beta", "Spring catalogue", time.Time{}, "alice"),
+		models.NewURL("https://example.net/spring", "gamma", "", time.Time{}, "bob"),
+		models.NewURL("https://example.com/autumn", "delta", "", time.Time{}, "alice"),
+	} {
+		_, err := repo.Create(ctx, url)
+		assert.NoError(t, err)
+	}
+	e := newRealTestServer(repo, newTestConfig())
+
+	search := func(query string, admin bool) (*httptest.ResponseRecorder, URLSearchResponse) {
+		req := newAPIRequest(http.MethodGet, "/api/urls/search?"+query, "")
+		if admin {
+			req.Header.Set("X-Admin-Key", "test-admin-key")
+		}
+		rec := serveRequest(e, req)
+		var response URLSearchResponse
+		if rec.Code == http.StatusOK {
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		}
+		return rec, response
+	}
+	codes := func(response URLSearchResponse) []string {
+		shorts := make([]string, 0, len(response.URLs))
+		for _, url := range response.URLs {
+			shorts = append(shorts, url.ShortCode)
+		}
+		return shorts
+	}
+
+	t.Run("ScopedToCreator", func(t *testing.T) {
+		rec, response := search("q=spring&creator_reference=alice", false)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.ElementsMatch(t, []string{"alpha", "beta"}, codes(response))
+		assert.Equal(t, int64(2), response.Total)
+		assert.Equal(t, "spring", response.Query)
+	})
+
+	t.Run("EveryWordPrefixMatches", func(t *testing.T) {
+		_, response := search("q=SPR+catal&creator_reference=alice", false)
+		assert.Equal(t, []string{"beta"}, codes(response))
+	})
+
+	t.Run("AllCreatorsForAdmins", func(t *testing.T) {
+		rec, response := search("q=spring", true)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.ElementsMatch(t, []string{"alpha", "beta", "gamma"}, codes(response))
+	})
+
+	t.Run("AllCreatorsRequiresAdmin", func(t *testing.T) {
+		rec, _ := search("q=spring", false)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	t.Run("EmptyQueryMatchesNothing", func(t *testing.T) {
+		for _, query := range []string{"q=&creator_reference=alice", "q=%20%26%21&creator_reference=alice", "creator_reference=alice"} {
+			rec, response := search(query, false)
+			assert.Equal(t, http.StatusOK, rec.Code, query)
+			assert.Empty(t, response.URLs, query)
+			assert.NotNil(t, response.URLs, query)
+			assert.Zero(t, response.Total, query)
+		}
+	})
+
+	t.Run("Paged", func(t *testing.T) {
+		rec, response := search("q=example&creator_reference=alice&limit=2&offset=2", false)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Len(t, response.URLs, 1)
+		assert.Equal(t, int64(3), response.Total)
+		assert.Contains(t, rec.Header().Get("Link"), `rel="prev"`)
+	})
+
+	t.Run("InvalidLimit", func(t *testing.T) {
+		rec, _ := search("q=spring&creator_reference=alice&limit=0", false)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// URLSummaryResponse is everything known about a short link: its stored record, aggregated
+// analytics, most recent history and whether the cache holds it
+type URLSummaryResponse struct {
+	URL          *models.URL            `json:"url"`
+	ShortURL     string                 `json:"short_url"`
+	Analytics    map[string]interface{} `json:"analytics"`
+	History      []*models.URLHistory   `json:"history"`
+	HistoryTotal int64                  `json:"history_total"`
+	CacheStatus  string                 `json:"cache_status"`
+}
+
+// GetURLSummary handles requests for a link's full lifecycle summary, for support requests. Admins may
+// summarize any link, including deleted ones; others must pass the owner's creator_reference.
+func (h *URLHandler) GetURLSummary(c echo.Context) error {
+	code := c.Param("code")
+	creatorReference := c.QueryParam("creator_reference")
+
+	log.Debug().Str("code", code).Str("creator_reference", creatorReference).Msg("Getting URL summary")
+
+	if h.isAdmin(c) {
+		creatorReference = ""
+	} else if creatorReference == "" {
+		log.Error().Str("code", code).Msg("No creator reference provided for URL summary")
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Missing creator reference"})
+	}
+
+	summary, err := h.service.GetURLSummary(c.Request().Context(), code, creatorReference)
+	if err != nil {
+		log.Error().Err(err).Str("code", code).Msg("Failed to retrieve URL summary")
+		return err
+	}
+
+	log.Info().Str("code", code).Str("cache_status", summary.CacheStatus).Msg("URL summary retrieved")
+
+	return c.JSON(http.StatusOK, URLSummaryResponse{
+		URL:          summary.URL,
+		ShortURL:     h.shortURL(summary.URL.Short),
+		Analytics:    summary.Analytics,
+		History:      summary.History,
+		HistoryTotal: summary.HistoryTotal,
+		CacheStatus:  summary.CacheStatus,
+	})
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/fransfilastap/urlshortener/store"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestURLSummary tests that a link's summary composes its record, analytics, history and cache state
+func TestURLSummary(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeRepository()
+	e := echo.New()
+	NewURLHandler(store.NewURLService(repo, store.NewInMemoryCache(10, time.Hour)), newTestConfig()).Register(e)
+
+	created, err := repo.Create(ctx, models.NewURL("https://example.com/support", "support", "Support", time.Time{}, "owner"))
+	assert.NoError(t, err)
+	assert.NoError(t, repo.StoreClick(ctx, &models.Click{URLShort: "support", Browser: "Firefox", Timestamp: time.Now()}))
+	assert.NoError(t, repo.LogURLHistory(ctx, created.ID, "support", "create", nil, created, "owner"))
+
+	get := func(query string, adminKey string) *httptest.ResponseRecorder {
+		req := newAPIRequest(http.MethodGet, "/api/urls/support/summary"+query, "")
+		if adminKey != "" {
+			req.Header.Set("X-Admin-Key", adminKey)
+		}
+		return serveRequest(e, req)
+	}
+
+	t.Run("RequiresOwnerOrAdmin", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, get("", "").Code)
+		assert.Equal(t, http.StatusUnauthorized, get("?creator_reference=someone-else", "").Code)
+		assert.Equal(t, http.StatusBadRequest, get("", "wrong").Code)
+	})
+
+	t.Run("ComposesAllSections", func(t *testing.T) {
+		rec := get("?creator_reference=owner", "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var response map[string]interface{}
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		for _, section := range []string{"url", "short_url", "analytics", "history", "history_total", "cache_status"} {
+			assert.Contains(t, response, section)
+		}
+		url := response["url"].(map[string]interface{})
+		assert.Equal(t, "https://example.com/support", url["original"])
+		assert.Equal(t, "http://localhost:8080/support", response["short_url"])
+		analytics := response["analytics"].(map[string]interface{})
+		assert.Equal(t, float64(1), analytics["total_clicks"])
+		assert.Len(t, response["history"], 1)
+		assert.Equal(t, float64(1), response["history_total"])
+		assert.Equal(t, store.CacheStatusNotCached, response["cache_status"])
+	})
+
+	t.Run("ReportsCachedURL", func(t *testing.T) {
+		req := newAPIRequest(http.MethodGet, "/api/urls/support", "")
+		serveRequest(e, req)
+
+		var response URLSummaryResponse
+		rec := get("", "test-admin-key")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		assert.Equal(t, store.CacheStatusCached, response.CacheStatus)
+	})
+
+	t.Run("IncludesDeletedURLsForAdmins", func(t *testing.T) {
+		assert.NoError(t, repo.Delete(ctx, "support"))
+
+		var response URLSummaryResponse
+		rec := get("", "test-admin-key")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		assert.NotNil(t, response.URL.DeletedAt)
+	})
+}
@@ -7,10 +7,13 @@ import (
 	"os/signal"
 	"syscall"
 	"time"
+	// Embed the time zone database so analytics time zones resolve in minimal images without tzdata
+	_ "time/tzdata"
 
 	"github.com/fransfilastap/urlshortener/config"
 	"github.com/fransfilastap/urlshortener/handlers"
 	"github.com/fransfilastap/urlshortener/logger"
+	"github.com/fransfilastap/urlshortener/outbound"
 	"github.com/fransfilastap/urlshortener/store"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
@@ -24,6 +27,17 @@ func main() {
 	// Initialize logger
 	logger.InitLogger(cfg.LogLevel, cfg.LogFormat)
 
+	if cfg.SignedCodesEnabled && cfg.SignedCodesSecret == "" {
+		log.Fatal().Msg("SIGNED_CODES_SECRET is required when SIGNED_CODES_ENABLED is true")
+	}
+
+	if _, err := handlers.ParseCIDRs(cfg.AdminAllowedCIDRs); err != nil {
+		log.Fatal().Err(err).Msg("Invalid ADMIN_ALLOWED_CIDRS")
+	}
+	if _, err := handlers.ParseCIDRs(cfg.TrustedProxies); err != nil {
+		log.Fatal().Err(err).Msg("Invalid TRUSTED_PROXIES")
+	}
+
 	// Initialize database
 	db, err := store.NewPostgresRepository(cfg.PostgresURL)
 	if err != nil {
@@ -36,17 +50,154 @@ func main() {
 		log.Fatal().Err(err).Msg("Failed to initialize database schema")
 	}
 
+	// Partition clicks by month and keep next month's partition created ahead of time
+	maintenanceCtx, stopMaintenance := context.WithCancel(context.Background())
+	defer stopMaintenance()
+	if cfg.ClickPartitioning {
+		if err := db.PartitionClicks(context.Background()); err != nil {
+			log.Fatal().Err(err).Msg("Failed to partition clicks table")
+		}
+		go db.MaintainClickPartitions(maintenanceCtx, 24*time.Hour)
+	}
+
 	// Initialize cache
-	cache := store.NewCacheRepository(
-		cfg.ValkeyCacheAddr,
-		cfg.ValkeyCachePassword,
-		cfg.ValkeyCacheDB,
-		cfg.ValkeyCacheTTL,
-	)
+	var cache store.CacheRepositoryInterface
+	switch cfg.CacheBackend {
+	case config.CacheBackendMemory:
+		memory := store.NewInMemoryCache(cfg.MemoryCacheSize, cfg.MemoryCacheTTL)
+		memory.SetNotFoundTTL(cfg.CacheNotFoundTTL)
+		cache = memory
+	case config.CacheBackendValkey:
+		valkey := store.NewCacheRepository(
+			cfg.ValkeyCacheAddr,
+			cfg.ValkeyCachePassword,
+			cfg.ValkeyCacheDB,
+			cfg.ValkeyCacheTTL,
+		)
+		valkey.SetMaxValueSize(cfg.ValkeyMaxValueSize)
+		valkey.SetNotFoundTTL(cfg.CacheNotFoundTTL)
+		cache = valkey
+	default:
+		log.Fatal().Str("cache_backend", cfg.CacheBackend).Msg("Unknown cache backend")
+	}
 	defer cache.Close()
 
+	// Initialize click enrichment pipeline
+	enrichers, err := store.ClickEnrichersByName(cfg.ClickEnrichers)
+	if err != nil {
+		log.Fatal().Err(err).Strs("click_enrichers", cfg.ClickEnrichers).Msg("Invalid click enricher configuration")
+	}
+
+	// Resolve click locations from a MaxMind database, if one is configured. Clicks fall back to the
+	// GEOIP_FALLBACK_LOCATION when it can't be opened.
+	var geoLocator store.GeoLocator = store.NoopGeoLocator{}
+	if cfg.GeoIPDatabasePath != "" {
+		maxMind, err := store.NewMaxMindGeoLocator(cfg.GeoIPDatabasePath)
+		if err != nil {
+			log.Warn().Err(err).Str("path", cfg.GeoIPDatabasePath).Msg("Failed to open GeoIP database, click locations will be unknown")
+		} else {
+			defer maxMind.Close()
+			geoLocator = maxMind
+		}
+	}
+
+	// Validate the reuse conflict policy
+	reusePolicy, err := store.ParseReuseConflictPolicy(cfg.ReuseConflictPolicy)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid reuse conflict policy")
+	}
+
+	// Validate the URL validation level
+	urlValidation, err := store.ParseURLValidation(cfg.URLValidation)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid URL validation level")
+	}
+	urlValidator, err := store.NewURLValidator(cfg.BlockedDestinations...)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid blocked destinations")
+	}
+
+	// Initialize the short code strategy and generator
+	codeStrategy, err := store.ParseCodeStrategy(cfg.CodeStrategy)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid code strategy")
+	}
+	words := store.DefaultWordlist()
+	if cfg.CodeWordlistFile != "" {
+		words, err = store.LoadWordlist(cfg.CodeWordlistFile)
+		if err != nil {
+			log.Fatal().Err(err).Str("file", cfg.CodeWordlistFile).Msg("Invalid code wordlist")
+		}
+	}
+	codeGenerator, err := store.NewCodeGenerator(store.CodeGeneratorConfig{
+		Name:      cfg.CodeGenerator,
+		Alphabet:  cfg.CodeAlphabet,
+		Length:    cfg.CodeLength,
+		Words:     words,
+		WordCount: cfg.CodeWordCount,
+	})
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid code generator configuration")
+	}
+
+	// Grow random codes as links fill the keyspace
+	var codeLengthScaler *store.CodeLengthScaler
+	if cfg.CodeLengthScaleThreshold > 0 && cfg.CodeGenerator == store.CodeGeneratorRandom {
+		alphabet := cfg.CodeAlphabet
+		if alphabet == "" {
+			alphabet = store.Base62Alphabet
+		}
+		codeLengthScaler, err = store.NewCodeLengthScaler(alphabet, cfg.CodeLength, cfg.CodeLengthScaleThreshold)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Invalid code length scaling configuration")
+		}
+	}
+
+	// Initialize the shared outbound HTTP client
+	httpClient, err := outbound.NewHTTPClient(cfg.OutboundHTTPProxy, cfg.OutboundNoProxy, cfg.OutboundHTTPTimeout)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid outbound HTTP configuration")
+	}
+
 	// Initialize URL service
-	urlService := store.NewURLService(db, cache)
+	serviceOptions := []store.Option{
+		store.WithClickEnrichers(enrichers...),
+		store.WithClickExtraFields(cfg.ClickExtraFields...),
+		store.WithReuseConflictPolicy(reusePolicy),
+		store.WithHTTPClient(httpClient),
+		store.WithReachabilityTimeout(cfg.DestinationCheckTimeout),
+		store.WithMaxSeriesBuckets(cfg.MaxSeriesBuckets),
+		store.WithAnalyticsWindow(cfg.AnalyticsWindow),
+		store.WithClickBatching(cfg.ClickBatchSize, cfg.ClickBatchInterval),
+		store.WithClickGrace(cfg.ClickGrace),
+		store.WithClickDedupWindow(cfg.ClickDedupWindow),
+		store.WithClickWorkers(cfg.MaxClickWorkers, cfg.ClickQueueSize),
+		store.WithCodeGenerator(codeGenerator),
+		store.WithCodeStrategy(codeStrategy),
+		store.WithBlockedShortenerDomains(cfg.BlockedShorteners...),
+		store.WithAllowedSchemes(cfg.AllowedURLSchemes...),
+		store.WithURLValidation(urlValidation),
+		store.WithURLValidator(urlValidator),
+		store.WithBaseURL(cfg.BaseURL),
+		store.WithCreatorDefaults(db),
+		store.WithClickWebhook(cfg.ClickWebhookURL, cfg.ClickWebhookSecret),
+		store.WithReservedCodes(cfg.ReservedCodes...),
+		store.WithGeoLocator(geoLocator),
+		store.WithUnknownLocation(cfg.GeoIPFallbackLocation),
+	}
+	if codeLengthScaler != nil {
+		serviceOptions = append(serviceOptions, store.WithCodeLengthScaling(codeLengthScaler))
+	}
+	if cfg.CodeBannedWords != nil {
+		serviceOptions = append(serviceOptions, store.WithBannedWords(cfg.CodeBannedWords...))
+	}
+	urlService := store.NewURLService(db, cache, serviceOptions...)
+	if codeLengthScaler != nil {
+		go urlService.MaintainCodeLength(maintenanceCtx, cfg.CodeLengthScaleInterval)
+	}
+	if cfg.ExpiredPurgeInterval > 0 {
+		go urlService.MaintainExpiredPurge(maintenanceCtx, cfg.ExpiredPurgeInterval, cfg.ExpiredPurgeGrace)
+	}
 
 	// Initialize Echo
 	e := echo.New()
@@ -60,14 +211,23 @@ func main() {
 	e.Static("/static", "static")
 
 	// Initialize handlers
-	urlHandler := handlers.NewURLHandler(urlService, cfg.BaseURL, cfg.APIKey)
+	urlHandler := handlers.NewURLHandler(urlService, cfg)
+	if cfg.ShortenRateLimit > 0 {
+		if valkey, ok := cache.(*store.CacheRepository); ok {
+			urlHandler.SetShortenRateLimiter(valkey.NewRateLimiter("shorten", cfg.ShortenRateLimit, cfg.ShortenRateBurst))
+		} else {
+			log.Warn().Str("cache_backend", cfg.CacheBackend).Msg("SHORTEN_RATE_LIMIT needs the valkey cache backend, not rate limiting shortening")
+		}
+	}
+	if cfg.AnalyticsCacheTTL > 0 && cfg.AnalyticsServerCache {
+		if valkey, ok := cache.(*store.CacheRepository); ok {
+			urlHandler.SetAnalyticsResponseCache(valkey.NewResponseCache("analytics"))
+		} else {
+			log.Warn().Str("cache_backend", cfg.CacheBackend).Msg("ANALYTICS_SERVER_CACHE needs the valkey cache backend, not caching analytics responses")
+		}
+	}
 	urlHandler.Register(e)
 
-	// Add health check endpoint
-	e.GET("/health", func(c echo.Context) error {
-		return c.JSON(http.StatusOK, map[string]string{"status": "ok"})
-	})
-
 	// Start server
 	go func() {
 		if err := e.Start(":" + cfg.ServerPort); err != nil && err != http.ErrServerClosed {
@@ -87,5 +247,8 @@ func main() {
 		log.Fatal().Err(err).Msg("Server shutdown failed")
 	}
 
+	// Record queued clicks and flush clicks still waiting to be written in a batch
+	urlService.Close()
+
 	log.Info().Msg("Server gracefully stopped")
 }
@@ -0,0 +1,103 @@
+// Package metrics defines the Prometheus metrics of the URL shortener
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Outcomes of a URL lookup by short code
+const (
+	// LookupCacheHit is a lookup served from the cache
+	LookupCacheHit = "cache_hit"
+	// LookupCacheMiss is a lookup the cache didn't have, served from the database
+	LookupCacheMiss = "cache_miss"
+	// LookupDBFallback is a lookup served from the database because the cache failed
+	LookupDBFallback = "db_fallback"
+	// LookupCachedNotFound is a lookup of a code the cache remembers no URL has
+	LookupCachedNotFound = "cache_not_found"
+)
+
+// Triggers of a click batch flush
+const (
+	// FlushBySize is a flush of a full batch
+	FlushBySize = "size"
+	// FlushByInterval is a flush after the flush interval passed, including the final flush on shutdown
+	FlushByInterval = "interval"
+)
+
+var (
+	// Registry holds the metrics of this package, separate from the default registry
+	Registry = prometheus.NewRegistry()
+
+	// ShortenRequests counts requests to create short URLs by response status code
+	ShortenRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "urlshortener_shorten_requests_total",
+		Help: "Requests to shorten a URL, by response status code.",
+	}, []string{"status"})
+
+	// Redirects counts requests to short URLs by response status code
+	Redirects = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "urlshortener_redirects_total",
+		Help: "Requests to short URLs, by response status code.",
+	}, []string{"status"})
+
+	// RedirectDuration observes how long requests to short URLs take to answer
+	RedirectDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "urlshortener_redirect_duration_seconds",
+		Help:    "Time taken to answer requests to short URLs.",
+		Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	})
+
+	// URLLookups counts URL lookups by short code by where they were served from
+	URLLookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "urlshortener_url_lookups_total",
+		Help: "URL lookups by short code, by result: cache_hit, cache_miss, db_fallback or cache_not_found.",
+	}, []string{"result"})
+
+	// Errors counts failed requests by error code and response status code
+	Errors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "urlshortener_errors_total",
+		Help: "Failed requests, by error code and response status code.",
+	}, []string{"code", "status"})
+
+	// ClickBatchFlushes counts flushed click batches by what triggered the flush
+	ClickBatchFlushes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "urlshortener_click_batch_flushes_total",
+		Help: "Click batches flushed, by trigger: size or interval.",
+	}, []string{"trigger"})
+
+	// ClickBatchSize observes the number of clicks in each flushed click batch
+	ClickBatchSize = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "urlshortener_click_batch_size",
+		Help:    "Clicks per flushed click batch.",
+		Buckets: prometheus.ExponentialBuckets(1, 2, 12),
+	})
+
+	// ClickBatchFailedClicks counts clicks in click batches that failed to write
+	ClickBatchFailedClicks = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "urlshortener_click_batch_failed_clicks_total",
+		Help: "Clicks in click batches that failed to write.",
+	})
+
+	// ClickWebhooksDropped counts click webhooks dropped because the webhook queue was full
+	ClickWebhooksDropped = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "urlshortener_click_webhooks_dropped_total",
+		Help: "Click webhooks dropped because the webhook queue was full.",
+	})
+
+	// CacheOversizedValues counts URLs that weren't cached because they exceeded the maximum value size
+	CacheOversizedValues = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "urlshortener_cache_oversized_values_total",
+		Help: "URLs not cached because their serialized size exceeded the maximum value size.",
+	})
+)
+
+func init() {
+	Registry.MustRegister(ShortenRequests, Redirects, RedirectDuration, URLLookups, Errors, ClickBatchFlushes, ClickBatchSize, ClickBatchFailedClicks, ClickWebhooksDropped, CacheOversizedValues)
+	for _, result := range []string{LookupCacheHit, LookupCacheMiss, LookupDBFallback, LookupCachedNotFound} {
+		URLLookups.WithLabelValues(result)
+	}
+	for _, trigger := range []string{FlushBySize, FlushByInterval} {
+		ClickBatchFlushes.WithLabelValues(trigger)
+	}
+}
@@ -14,6 +14,16 @@ type Click struct {
 	Browser   string    `json:"browser,omitempty" db:"browser"`
 	Device    string    `json:"device,omitempty" db:"device"`
 	Timestamp time.Time `json:"timestamp" db:"timestamp"`
+	ResolveMs *float64  `json:"resolve_ms,omitempty" db:"resolve_ms"` // time the redirect took to resolve the code, if captured
+	Target    string    `json:"target,omitempty" db:"target"`         // short code a random link redirected to
+
+	BrowserVersion string `json:"browser_version,omitempty" db:"browser_version"`
+	OS             string `json:"os,omitempty" db:"os"`
+	Referrer       string `json:"referrer,omitempty" db:"referrer"` // referring host, or "direct"
+
+	Extra map[string]string `json:"extra,omitempty" db:"extra"` // deployment-specific attributes allowlisted by CLICK_EXTRA_FIELDS, e.g. screen size
+
+	Counted bool `json:"-" db:"-"` // the URL's click count was already incremented for this click
 }
 
 // NewClick creates a new Click instance
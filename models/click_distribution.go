@@ -0,0 +1,9 @@
+package models
+
+// ClickDistribution counts clicks by the local hour of the day and day of the week they happened at,
+// showing when a link's audience is active
+type ClickDistribution struct {
+	Timezone  string    `json:"tz"`
+	HourOfDay [24]int64 `json:"hour_of_day"` // clicks per hour, 0 (midnight) to 23
+	DayOfWeek [7]int64  `json:"day_of_week"` // clicks per day, 0 (Sunday) to 6 (Saturday)
+}
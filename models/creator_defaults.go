@@ -0,0 +1,14 @@
+package models
+
+import (
+	"time"
+)
+
+// CreatorDefaults holds the settings applied to a creator's new links when the request omits them
+type CreatorDefaults struct {
+	CreatorReference string            `json:"creator_reference" db:"creator_reference"`
+	Expiry           int64             `json:"expiry,omitempty" db:"expiry_seconds"` // in seconds, 0 = never expires
+	RateLimit        int               `json:"rate_limit,omitempty" db:"rate_limit"` // redirects per minute, 0 = unlimited
+	RedirectHeaders  map[string]string `json:"redirect_headers,omitempty" db:"redirect_headers"`
+	UpdatedAt        time.Time         `json:"updated_at" db:"updated_at"`
+}
@@ -0,0 +1,8 @@
+package models
+
+// MetadataClickCount represents the clicks on a creator's URLs that share a metadata value
+type MetadataClickCount struct {
+	Value  string `json:"value" db:"value"`
+	URLs   int64  `json:"urls" db:"urls"`
+	Clicks int64  `json:"clicks" db:"clicks"`
+}
@@ -0,0 +1,9 @@
+package models
+
+// ResolveTiming summarizes how long a URL's redirects took to resolve the short code, in milliseconds
+type ResolveTiming struct {
+	Samples int64   `json:"samples"` // clicks with a captured resolve time
+	P50     float64 `json:"p50_ms"`
+	P95     float64 `json:"p95_ms"`
+	P99     float64 `json:"p99_ms"`
+}
@@ -0,0 +1,11 @@
+package models
+
+import (
+	"time"
+)
+
+// TimeSeriesPoint represents the number of clicks within a time bucket
+type TimeSeriesPoint struct {
+	Time   time.Time `json:"time" db:"time"`
+	Clicks int64     `json:"clicks" db:"clicks"`
+}
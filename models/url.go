@@ -1,31 +1,71 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 )
 
 // URL represents a shortened URL
 type URL struct {
-	ID               int64      `json:"id" db:"id"`
-	Original         string     `json:"original" db:"original"`
-	Short            string     `json:"short" db:"short"`
-	Title            string     `json:"title" db:"title"`
-	CreatedAt        time.Time  `json:"created_at" db:"created_at"`
-	ExpiresAt        time.Time  `json:"expires_at,omitempty" db:"expires_at"`
-	Clicks           int64      `json:"clicks" db:"clicks"`
-	CreatorReference string     `json:"creator_reference,omitempty" db:"creator_reference"`
-	DeletedAt        *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+	ID                  int64             `json:"id" db:"id"`
+	Original            string            `json:"original" db:"original"`
+	Short               string            `json:"short" db:"short"`
+	Title               string            `json:"title" db:"title"`
+	CreatedAt           time.Time         `json:"created_at" db:"created_at"`
+	ExpiresAt           *time.Time        `json:"expires_at,omitempty" db:"expires_at"`
+	Clicks              int64             `json:"clicks" db:"clicks"`
+	CreatorReference    string            `json:"creator_reference,omitempty" db:"creator_reference"`
+	DeletedAt           *time.Time        `json:"deleted_at,omitempty" db:"deleted_at"`
+	RateLimit           int               `json:"rate_limit,omitempty" db:"rate_limit"` // redirects per minute, 0 = unlimited
+	LastAccessedAt      *time.Time        `json:"last_accessed_at,omitempty" db:"last_accessed_at"`
+	RedirectHeaders     map[string]string `json:"redirect_headers,omitempty" db:"redirect_headers"`           // only allowlisted names are applied
+	Metadata            map[string]string `json:"metadata,omitempty" db:"metadata"`                           // free-form labels such as a campaign id
+	Enabled             bool              `json:"enabled" db:"enabled"`                                       // disabled links stop redirecting but keep their analytics
+	Tags                []string          `json:"tags,omitempty" db:"tags"`                                   // labels for managing links in bulk, e.g. a campaign
+	DisabledRedirectURL string            `json:"disabled_redirect_url,omitempty" db:"disabled_redirect_url"` // where to send visitors while disabled, overriding the configured response
+	RandomTarget        bool              `json:"random_target,omitempty" db:"random_target"`                 // redirects to a random live link of the same creator, falling back to Original
+	Notes               string            `json:"notes,omitempty" db:"notes"`                                 // internal free-text note, never shown to visitors
+	MaxClicks           int64             `json:"max_clicks,omitempty" db:"max_clicks"`                       // redirects before the link stops working, 0 = unlimited
+	CacheTTL            int               `json:"cache_ttl,omitempty" db:"cache_ttl"`                         // seconds the URL stays cached, 0 = the cache's default TTL
+	SeededClicks        int64             `json:"seeded_clicks,omitempty" db:"seeded_clicks"`                 // clicks carried over on import, counted on top of the recorded clicks
 }
 
-// NewURL creates a new URL instance
+// NewURL creates a new URL instance. A zero expiresAt means the URL never expires.
 func NewURL(original, short, title string, expiresAt time.Time, creatorReference string) *URL {
-	return &URL{
+	url := &URL{
 		Original:         original,
 		Short:            short,
 		Title:            title,
 		CreatedAt:        time.Now(),
-		ExpiresAt:        expiresAt,
 		Clicks:           0,
 		CreatorReference: creatorReference,
+		Enabled:          true,
 	}
+	if !expiresAt.IsZero() {
+		url.ExpiresAt = &expiresAt
+	}
+	return url
+}
+
+// IsExpired reports whether the URL has an expiry that has already passed.
+// A nil ExpiresAt means the URL never expires.
+func (u *URL) IsExpired() bool {
+	return u.IsExpiredAt(time.Now())
+}
+
+// IsExpiredAt reports whether the URL's expiry is before the given time
+func (u *URL) IsExpiredAt(t time.Time) bool {
+	return u.ExpiresAt != nil && u.ExpiresAt.Before(t)
+}
+
+// UnmarshalJSON decodes a URL, treating a missing enabled field as enabled so URLs encoded
+// before links could be disabled (e.g. in the cache) keep redirecting
+func (u *URL) UnmarshalJSON(data []byte) error {
+	type plainURL URL
+	decoded := plainURL{Enabled: true}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*u = URL(decoded)
+	return nil
 }
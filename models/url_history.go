@@ -0,0 +1,18 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// URLHistory represents a recorded modification of a URL
+type URLHistory struct {
+	ID         int64           `json:"id" db:"id"`
+	URLID      int64           `json:"url_id" db:"url_id"`
+	URLShort   string          `json:"url_short" db:"url_short"`
+	Action     string          `json:"action" db:"action"`
+	OldValue   json.RawMessage `json:"old_value,omitempty" db:"old_value"`
+	NewValue   json.RawMessage `json:"new_value,omitempty" db:"new_value"`
+	ModifiedAt time.Time       `json:"modified_at" db:"modified_at"`
+	ModifiedBy string          `json:"modified_by,omitempty" db:"modified_by"`
+}
@@ -0,0 +1,37 @@
+package outbound
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// NewHTTPClient creates the shared HTTP client used for requests to destination URLs.
+// When proxyURL is set, requests go through that proxy except for hosts matched by noProxy,
+// which uses the same syntax as the NO_PROXY environment variable. Without proxyURL the
+// standard proxy environment variables apply.
+func NewHTTPClient(proxyURL, noProxy string, timeout time.Duration) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if proxyURL != "" {
+		if _, err := url.Parse(proxyURL); err != nil {
+			return nil, fmt.Errorf("invalid outbound proxy URL: %w", err)
+		}
+		proxyFunc := (&httpproxy.Config{
+			HTTPProxy:  proxyURL,
+			HTTPSProxy: proxyURL,
+			NoProxy:    noProxy,
+		}).ProxyFunc()
+		transport.Proxy = func(req *http.Request) (*url.URL, error) {
+			return proxyFunc(req.URL)
+		}
+	}
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   timeout,
+	}, nil
+}
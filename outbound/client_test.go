@@ -0,0 +1,50 @@
+package outbound
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewHTTPClient(t *testing.T) {
+	// Test case 1: Requests go through the configured proxy unless NO_PROXY matches
+	t.Run("UsesConfiguredProxy", func(t *testing.T) {
+		client, err := NewHTTPClient("http://proxy.internal:3128", "localhost,.corp.example", 5*time.Second)
+		require.NoError(t, err)
+		assert.Equal(t, 5*time.Second, client.Timeout)
+
+		transport, ok := client.Transport.(*http.Transport)
+		require.True(t, ok)
+
+		for _, target := range []string{"https://example.com/page", "http://example.org"} {
+			req, _ := http.NewRequest(http.MethodGet, target, nil)
+			proxy, err := transport.Proxy(req)
+			require.NoError(t, err)
+			require.NotNil(t, proxy, target)
+			assert.Equal(t, "proxy.internal:3128", proxy.Host, target)
+		}
+
+		for _, target := range []string{"http://localhost:8080", "https://wiki.corp.example/page"} {
+			req, _ := http.NewRequest(http.MethodGet, target, nil)
+			proxy, err := transport.Proxy(req)
+			require.NoError(t, err)
+			assert.Nil(t, proxy, target)
+		}
+	})
+
+	// Test case 2: Invalid proxy URLs are rejected
+	t.Run("InvalidProxy", func(t *testing.T) {
+		_, err := NewHTTPClient("http://[::1", "", time.Second)
+		assert.Error(t, err)
+	})
+
+	// Test case 3: The shared default transport is never modified
+	t.Run("DoesNotModifyDefaultTransport", func(t *testing.T) {
+		client, err := NewHTTPClient("http://proxy.internal:3128", "", time.Second)
+		require.NoError(t, err)
+		assert.NotSame(t, http.DefaultTransport, client.Transport)
+	})
+}
@@ -0,0 +1,33 @@
+package store
+
+// APIError is an error that carries the HTTP status and machine-readable code it is reported with,
+// so handlers can return it as-is and leave rendering to the echo error handler
+type APIError struct {
+	// Status is the HTTP status code of the response
+	Status int
+	// Code is a stable identifier clients and metrics can match on
+	Code string
+	// Message is the human-readable error shown to clients
+	Message string
+
+	// parent is a more general error this one also matches with errors.Is
+	parent *APIError
+}
+
+// NewAPIError creates an APIError
+func NewAPIError(status int, code, message string) *APIError {
+	return &APIError{Status: status, Code: code, Message: message}
+}
+
+// Error returns the client-facing message
+func (e *APIError) Error() string {
+	return e.Message
+}
+
+// Unwrap returns the more general error this one also matches, if any
+func (e *APIError) Unwrap() error {
+	if e.parent == nil {
+		return nil
+	}
+	return e.parent
+}
@@ -0,0 +1,39 @@
+package store
+
+import "strings"
+
+// defaultBannedWords are offensive words that generated codes must not contain
+var defaultBannedWords = []string{
+	"anal", "anus", "arse", "bitch", "boob", "cock", "cum", "cunt", "dick", "dildo", "fag", "fuck",
+	"jizz", "kike", "nazi", "nigg", "penis", "piss", "porn", "pussy", "rape", "shit", "slut", "spic",
+	"tits", "twat", "vagina", "wank", "whore",
+}
+
+// lookalikeDigits maps digits to the letters they are commonly used to spell
+var lookalikeDigits = strings.NewReplacer("0", "o", "1", "i", "3", "e", "4", "a", "5", "s", "7", "t")
+
+// WithBannedWords replaces the words that generated codes must not contain. An empty list turns the
+// filter off. Custom codes aren't filtered.
+func WithBannedWords(words ...string) Option {
+	return func(s *URLService) {
+		s.bannedWords = make([]string, 0, len(words))
+		for _, word := range words {
+			if word = strings.ToLower(strings.TrimSpace(word)); word != "" {
+				s.bannedWords = append(s.bannedWords, word)
+			}
+		}
+	}
+}
+
+// containsBannedWord reports whether code contains a banned word, ignoring case and reading
+// look-alike digits as letters (e.g. "5h1t")
+func (s *URLService) containsBannedWord(code string) bool {
+	lower := strings.ToLower(code)
+	spelled := lookalikeDigits.Replace(lower)
+	for _, word := range s.bannedWords {
+		if strings.Contains(lower, word) || strings.Contains(spelled, word) {
+			return true
+		}
+	}
+	return false
+}
@@ -4,10 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"strconv"
 	"time"
 
+	"github.com/fransfilastap/urlshortener/metrics"
 	"github.com/fransfilastap/urlshortener/models"
 	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
 )
 
 // CacheRepositoryInterface defines the interface for cache operations
@@ -20,16 +23,30 @@ type CacheRepositoryInterface interface {
 	GetByOriginal(ctx context.Context, original string) (*models.URL, error)
 	// IncrementClicks increments the click count for a URL in cache
 	IncrementClicks(ctx context.Context, short string) error
+	// SetNotFound remembers for a short while that no URL has a short code, so lookups of unknown codes
+	// don't all reach the database. GetByShort returns ErrCachedNotFound for the code until it expires or
+	// a URL with the code is set.
+	SetNotFound(ctx context.Context, short string) error
 	// Delete removes a URL from cache
 	Delete(ctx context.Context, short string) error
+	// IncrementRedirects counts a redirect for a URL in the fixed window containing now and returns the window's count
+	IncrementRedirects(ctx context.Context, short string, window time.Duration) (int64, error)
 	// Close closes the cache connection
 	Close() error
 }
 
+// ErrCachedNotFound is returned by a cache for short codes it remembers no URL has
+var ErrCachedNotFound = errors.New("url cached as not found")
+
+// notFoundMarker is cached in place of a URL for short codes no URL has
+const notFoundMarker = "not_found"
+
 // CacheRepository implements caching for URLs using Valkey/Redis
 type CacheRepository struct {
-	client *redis.Client
-	ttl    time.Duration
+	client       *redis.Client
+	ttl          time.Duration
+	notFoundTTL  time.Duration
+	maxValueSize int
 }
 
 // Ensure CacheRepository implements CacheRepositoryInterface
@@ -49,68 +66,89 @@ func NewCacheRepository(addr, password string, db int, ttl time.Duration) *Cache
 	}
 }
 
-// Set stores a URL in the cache
+// SetNotFoundTTL sets how long unknown short codes are remembered; 0 turns negative caching off
+func (c *CacheRepository) SetNotFoundTTL(ttl time.Duration) {
+	c.notFoundTTL = ttl
+}
+
+// SetMaxValueSize limits the serialized size of cached URLs to size bytes; 0 removes the limit
+func (c *CacheRepository) SetMaxValueSize(size int) {
+	c.maxValueSize = size
+}
+
+// Set stores a URL in the cache for its own cache TTL, or the default TTL if it has none. URLs larger
+// than the maximum value size are skipped, so they are always read from the database.
 func (c *CacheRepository) Set(ctx context.Context, url *models.URL) error {
 	data, err := json.Marshal(url)
 	if err != nil {
 		return err
 	}
+	if c.maxValueSize > 0 && len(data) > c.maxValueSize {
+		log.Warn().Str("short", url.Short).Int("size", len(data)).Int("max_size", c.maxValueSize).Msg("URL too large to cache")
+		metrics.CacheOversizedValues.Inc()
+		// Drop a not found marker left for the code, which would otherwise hide the URL until it expires
+		if err := c.client.Del(ctx, "short:"+url.Short).Err(); err != nil {
+			log.Warn().Err(err).Str("short", url.Short).Msg("Failed to drop cached entry of oversized URL")
+		}
+		return nil
+	}
+
+	// A URL's own cache TTL wins over the default
+	ttl := c.ttl
+	if url.CacheTTL > 0 {
+		ttl = time.Duration(url.CacheTTL) * time.Second
+	}
 
 	// Cache by short URL
-	err = c.client.Set(ctx, "short:"+url.Short, data, c.ttl).Err()
+	err = c.client.Set(ctx, "short:"+url.Short, data, ttl).Err()
 	if err != nil {
 		return err
 	}
 
 	// Also cache by original URL
-	return c.client.Set(ctx, "original:"+url.Original, data, c.ttl).Err()
+	return c.client.Set(ctx, "original:"+url.Original, data, ttl).Err()
 }
 
 // GetByShort retrieves a URL by its short code from cache
 func (c *CacheRepository) GetByShort(ctx context.Context, short string) (*models.URL, error) {
-	data, err := c.client.Get(ctx, "short:"+short).Bytes()
+	return c.getLive(ctx, "short:"+short)
+}
+
+// GetByOriginal retrieves a URL by its original URL from cache
+func (c *CacheRepository) GetByOriginal(ctx context.Context, original string) (*models.URL, error) {
+	return c.getLive(ctx, "original:"+original)
+}
+
+// getLive retrieves the cached URL under key, returning ErrURLExpired if it has expired. Expired URLs
+// stay cached until their TTL so repeated lookups keep reporting the expiry.
+func (c *CacheRepository) getLive(ctx context.Context, key string) (*models.URL, error) {
+	url, err := c.get(ctx, key)
 	if err != nil {
-		if errors.Is(err, redis.Nil) {
-			return nil, ErrURLNotFound
-		}
 		return nil, err
 	}
-
-	var url models.URL
-	if err := json.Unmarshal(data, &url); err != nil {
-		return nil, err
+	if url.IsExpired() {
+		return nil, ErrURLExpired
 	}
-
-	// Check if URL has expired
-	if !url.ExpiresAt.IsZero() && url.ExpiresAt.Before(time.Now()) {
-		c.client.Del(ctx, "short:"+short, "original:"+url.Original)
-		return nil, ErrURLNotFound
-	}
-
-	return &url, nil
+	return url, nil
 }
 
-// GetByOriginal retrieves a URL by its original URL from cache
-func (c *CacheRepository) GetByOriginal(ctx context.Context, original string) (*models.URL, error) {
-	data, err := c.client.Get(ctx, "original:"+original).Bytes()
+// get retrieves the cached URL under key, whether or not it has expired
+func (c *CacheRepository) get(ctx context.Context, key string) (*models.URL, error) {
+	data, err := c.client.Get(ctx, key).Bytes()
 	if err != nil {
 		if errors.Is(err, redis.Nil) {
 			return nil, ErrURLNotFound
 		}
 		return nil, err
 	}
+	if string(data) == notFoundMarker {
+		return nil, ErrCachedNotFound
+	}
 
 	var url models.URL
 	if err := json.Unmarshal(data, &url); err != nil {
 		return nil, err
 	}
-
-	// Check if URL has expired
-	if !url.ExpiresAt.IsZero() && url.ExpiresAt.Before(time.Now()) {
-		c.client.Del(ctx, "short:"+url.Short, "original:"+original)
-		return nil, ErrURLNotFound
-	}
-
 	return &url, nil
 }
 
@@ -129,11 +167,19 @@ func (c *CacheRepository) IncrementClicks(ctx context.Context, short string) err
 	return c.Set(ctx, url)
 }
 
+// SetNotFound caches a not found marker for a short code for the not found TTL
+func (c *CacheRepository) SetNotFound(ctx context.Context, short string) error {
+	if c.notFoundTTL <= 0 {
+		return nil
+	}
+	return c.client.Set(ctx, "short:"+short, notFoundMarker, c.notFoundTTL).Err()
+}
+
 // Delete removes a URL from cache
 func (c *CacheRepository) Delete(ctx context.Context, short string) error {
-	// Get the URL to also delete the original key
-	url, err := c.GetByShort(ctx, short)
-	if err != nil && !errors.Is(err, ErrURLNotFound) {
+	// Get the URL, even an expired one, to also delete the original key
+	url, err := c.get(ctx, "short:"+short)
+	if err != nil && !errors.Is(err, ErrURLNotFound) && !errors.Is(err, ErrCachedNotFound) {
 		return err
 	}
 
@@ -150,6 +196,39 @@ func (c *CacheRepository) Delete(ctx context.Context, short string) error {
 	return nil
 }
 
+// IncrementRedirects counts a redirect for a URL in the fixed window containing now and returns the window's count
+func (c *CacheRepository) IncrementRedirects(ctx context.Context, short string, window time.Duration) (int64, error) {
+	windowStart := time.Now().Truncate(window)
+	key := "redirects:" + short + ":" + strconv.FormatInt(windowStart.Unix(), 10)
+
+	pipe := c.client.TxPipeline()
+	count := pipe.Incr(ctx, key)
+	pipe.ExpireNX(ctx, key, window)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+
+	return count.Val(), nil
+}
+
+// Ping checks that the Valkey/Redis server can be reached
+func (c *CacheRepository) Ping(ctx context.Context) error {
+	return c.client.Ping(ctx).Err()
+}
+
+// Stats returns a snapshot of the Valkey/Redis connection pool
+func (c *CacheRepository) Stats() PoolStats {
+	stat := c.client.PoolStats()
+	return PoolStats{
+		TotalConns:    int64(stat.TotalConns),
+		AcquiredConns: int64(stat.TotalConns) - int64(stat.IdleConns),
+		IdleConns:     int64(stat.IdleConns),
+		MaxConns:      int64(c.client.Options().PoolSize),
+		EmptyAcquires: int64(stat.Misses),
+		Timeouts:      int64(stat.Timeouts),
+	}
+}
+
 // Close closes the cache connection
 func (c *CacheRepository) Close() error {
 	return c.client.Close()
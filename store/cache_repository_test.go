@@ -2,6 +2,7 @@ package store
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 
@@ -78,6 +79,48 @@ func TestCacheRepository_Integration(t *testing.T) {
 		assert.Equal(t, int64(1), retrieved.Clicks)
 	})
 
+	// Test that a non-expiring URL round-trips without an expiry
+	t.Run("NonExpiringURL", func(t *testing.T) {
+		forever := models.NewURL("https://example.com/forever", "forever", "", time.Time{}, "test-user")
+		err := repo.Set(ctx, forever)
+		require.NoError(t, err)
+
+		retrieved, err := repo.GetByShort(ctx, "forever")
+		assert.NoError(t, err)
+		assert.Nil(t, retrieved.ExpiresAt)
+	})
+
+	// Test nil, future and past expiry
+	t.Run("Expiry", func(t *testing.T) {
+		cases := []struct {
+			short     string
+			expiresAt time.Time
+			found     bool
+		}{
+			{"expnil", time.Time{}, true},
+			{"expfuture", time.Now().Add(time.Hour), true},
+			{"exppast", time.Now().Add(-time.Hour), false},
+		}
+		for _, tc := range cases {
+			err := repo.Set(ctx, models.NewURL("https://example.com/"+tc.short, tc.short, "", tc.expiresAt, "test-user"))
+			require.NoError(t, err)
+
+			_, err = repo.GetByOriginal(ctx, "https://example.com/"+tc.short)
+			if tc.found {
+				assert.NoError(t, err, tc.short)
+			} else {
+				assert.Equal(t, ErrURLExpired, err, tc.short)
+			}
+
+			_, err = repo.GetByShort(ctx, tc.short)
+			if tc.found {
+				assert.NoError(t, err, tc.short)
+			} else {
+				assert.Equal(t, ErrURLExpired, err, tc.short)
+			}
+		}
+	})
+
 	// Test deleting a URL
 	t.Run("Delete", func(t *testing.T) {
 		// First set the URL
@@ -92,4 +135,153 @@ func TestCacheRepository_Integration(t *testing.T) {
 		_, err = repo.GetByShort(ctx, url.Short)
 		assert.Equal(t, ErrURLNotFound, err)
 	})
+
+	// Test counting redirects per window
+	t.Run("IncrementRedirects", func(t *testing.T) {
+		// Use a long window so the test cannot straddle a window boundary
+		for i := int64(1); i <= 3; i++ {
+			count, err := repo.IncrementRedirects(ctx, "ratelimited", time.Hour)
+			require.NoError(t, err)
+			assert.Equal(t, i, count)
+		}
+
+		// Counter keys expire with their window
+		keys, err := repo.client.Keys(ctx, "redirects:ratelimited:*").Result()
+		require.NoError(t, err)
+		require.Len(t, keys, 1)
+		ttl, err := repo.client.TTL(ctx, keys[0]).Result()
+		require.NoError(t, err)
+		assert.Greater(t, ttl, time.Duration(0))
+		assert.LessOrEqual(t, ttl, time.Hour)
+	})
+
+	t.Run("RateLimiter", func(t *testing.T) {
+		limiter := repo.NewRateLimiter("test", 1, 3)
+		for i := 0; i < 3; i++ {
+			allowed, _, err := limiter.Allow(ctx, "203.0.113.1")
+			require.NoError(t, err)
+			assert.True(t, allowed)
+		}
+
+		// The burst is used up and a token takes a minute to refill
+		allowed, retryAfter, err := limiter.Allow(ctx, "203.0.113.1")
+		require.NoError(t, err)
+		assert.False(t, allowed)
+		assert.Greater(t, retryAfter, 50*time.Second)
+
+		// Other keys have their own bucket
+		allowed, _, err = limiter.Allow(ctx, "203.0.113.2")
+		require.NoError(t, err)
+		assert.True(t, allowed)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		repo.SetNotFoundTTL(100 * time.Millisecond)
+		defer repo.SetNotFoundTTL(0)
+
+		require.NoError(t, repo.SetNotFound(ctx, "missing"))
+		_, err := repo.GetByShort(ctx, "missing")
+		assert.ErrorIs(t, err, ErrCachedNotFound)
+
+		// Deleting or setting the code drops the marker
+		require.NoError(t, repo.Delete(ctx, "missing"))
+		_, err = repo.GetByShort(ctx, "missing")
+		assert.ErrorIs(t, err, ErrURLNotFound)
+		require.NoError(t, repo.SetNotFound(ctx, "missing"))
+		require.NoError(t, repo.Set(ctx, models.NewURL("https://example.com/found", "missing", "", time.Time{}, "")))
+		found, err := repo.GetByShort(ctx, "missing")
+		require.NoError(t, err)
+		assert.Equal(t, "https://example.com/found", found.Original)
+
+		// Markers expire with the not found TTL
+		require.NoError(t, repo.SetNotFound(ctx, "gone"))
+		time.Sleep(150 * time.Millisecond)
+		_, err = repo.GetByShort(ctx, "gone")
+		assert.ErrorIs(t, err, ErrURLNotFound)
+	})
+
+	t.Run("PerURLTTL", func(t *testing.T) {
+		custom := models.NewURL("https://example.com/cachettl", "cachettl", "", time.Time{}, "")
+		custom.CacheTTL = 1
+		require.NoError(t, repo.Set(ctx, custom))
+		require.NoError(t, repo.Set(ctx, models.NewURL("https://example.com/defaultttl", "defaultttl", "", time.Time{}, "")))
+
+		ttl, err := repo.client.TTL(ctx, "short:cachettl").Result()
+		require.NoError(t, err)
+		assert.LessOrEqual(t, ttl, time.Second)
+		ttl, err = repo.client.TTL(ctx, "original:https://example.com/cachettl").Result()
+		require.NoError(t, err)
+		assert.LessOrEqual(t, ttl, time.Second)
+
+		// The URL expires on its own schedule while others keep the default TTL
+		time.Sleep(1100 * time.Millisecond)
+		_, err = repo.GetByShort(ctx, "cachettl")
+		assert.ErrorIs(t, err, ErrURLNotFound)
+		_, err = repo.GetByShort(ctx, "defaultttl")
+		assert.NoError(t, err)
+	})
+
+	t.Run("OversizedValue", func(t *testing.T) {
+		repo.SetMaxValueSize(512)
+		defer repo.SetMaxValueSize(0)
+
+		oversized := models.NewURL("https://example.com/?q="+strings.Repeat("a", 1024), "oversized", "", time.Time{}, "")
+		require.NoError(t, repo.Set(ctx, oversized))
+		_, err := repo.GetByShort(ctx, oversized.Short)
+		assert.ErrorIs(t, err, ErrURLNotFound)
+
+		// The URL is still resolved from the database
+		mockRepo := new(MockURLRepository)
+		mockRepo.On("GetByShort", ctx, oversized.Short).Return(oversized, nil)
+		resolved, err := NewURLService(mockRepo, repo).GetByShort(ctx, oversized.Short)
+		require.NoError(t, err)
+		assert.Equal(t, oversized.Original, resolved.Original)
+		_, err = repo.GetByShort(ctx, oversized.Short)
+		assert.ErrorIs(t, err, ErrURLNotFound)
+	})
+
+	t.Run("ResponseCache", func(t *testing.T) {
+		cache := repo.NewResponseCache("test")
+		_, ok, err := cache.Get(ctx, "/api/urls/abc/analytics?")
+		require.NoError(t, err)
+		assert.False(t, ok)
+
+		require.NoError(t, cache.Set(ctx, "/api/urls/abc/analytics?", []byte(`{"total_clicks":1}`), 100*time.Millisecond))
+		data, ok, err := cache.Get(ctx, "/api/urls/abc/analytics?")
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, `{"total_clicks":1}`, string(data))
+
+		// Responses expire with their TTL
+		time.Sleep(150 * time.Millisecond)
+		_, ok, err = cache.Get(ctx, "/api/urls/abc/analytics?")
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+}
+
+// TestCacheRepositoryOversizedValue tests that URLs over the maximum value size are skipped without
+// contacting Valkey, and still resolve from the database
+func TestCacheRepositoryOversizedValue(t *testing.T) {
+	ctx := context.Background()
+	// Nothing listens here, so any command sent to Valkey fails
+	cache := NewCacheRepository("127.0.0.1:1", "", 0, time.Minute)
+	defer cache.Close()
+	cache.SetMaxValueSize(512)
+
+	oversized := models.NewURL("https://example.com/?q="+strings.Repeat("a", 1024), "oversized", "", time.Time{}, "")
+	before := counterValue(t, "urlshortener_cache_oversized_values_total")
+	assert.NoError(t, cache.Set(ctx, oversized))
+	assert.Equal(t, before+1, counterValue(t, "urlshortener_cache_oversized_values_total"))
+
+	mockRepo := new(MockURLRepository)
+	mockRepo.On("GetByShort", ctx, oversized.Short).Return(oversized, nil)
+	resolved, err := NewURLService(mockRepo, cache).GetByShort(ctx, oversized.Short)
+	require.NoError(t, err)
+	assert.Equal(t, oversized.Original, resolved.Original)
+	assert.Equal(t, before+2, counterValue(t, "urlshortener_cache_oversized_values_total"))
+
+	// URLs within the limit are still written to Valkey
+	small := models.NewURL("https://example.com", "small", "", time.Time{}, "")
+	assert.Error(t, cache.Set(ctx, small))
 }
@@ -0,0 +1,200 @@
+package store
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/fransfilastap/urlshortener/metrics"
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/rs/zerolog/log"
+)
+
+// ClickBatchStats summarizes the batches flushed by a ClickBatcher
+type ClickBatchStats struct {
+	// Batches is the number of batches flushed
+	Batches int64
+	// Clicks is the number of clicks flushed across all batches
+	Clicks int64
+	// SizeFlushes is the number of flushes triggered by a full batch
+	SizeFlushes int64
+	// IntervalFlushes is the number of flushes triggered by the flush interval, including the final flush on Close
+	IntervalFlushes int64
+	// FailedClicks is the number of clicks in batches that failed to write
+	FailedClicks int64
+	// LastBatchSize is the size of the most recent batch
+	LastBatchSize int
+	// MaxBatchSize is the size of the largest batch so far
+	MaxBatchSize int
+}
+
+// ClickBatchWriter writes a batch of clicks
+type ClickBatchWriter func(ctx context.Context, clicks []*models.Click) error
+
+// ClickBatcher buffers clicks and hands them to a writer once maxSize clicks are buffered or
+// interval has passed since the oldest buffered click, whichever comes first
+type ClickBatcher struct {
+	write    ClickBatchWriter
+	maxSize  int
+	interval time.Duration
+
+	clicks chan *models.Click
+	done   chan struct{}
+
+	closeMu sync.RWMutex
+	closed  bool
+
+	statsMu sync.Mutex
+	stats   ClickBatchStats
+}
+
+// NewClickBatcher creates a ClickBatcher and starts its flush loop
+func NewClickBatcher(write ClickBatchWriter, maxSize int, interval time.Duration) *ClickBatcher {
+	b := &ClickBatcher{
+		write:    write,
+		maxSize:  maxSize,
+		interval: interval,
+		clicks:   make(chan *models.Click, maxSize),
+		done:     make(chan struct{}),
+	}
+	go b.run()
+	return b
+}
+
+// Add queues a click for the next batch. Clicks added after Close are dropped.
+func (b *ClickBatcher) Add(click *models.Click) {
+	b.closeMu.RLock()
+	defer b.closeMu.RUnlock()
+	if b.closed {
+		log.Warn().Str("short", click.URLShort).Msg("Click batcher closed, dropping click")
+		return
+	}
+	b.clicks <- click
+}
+
+// Stats returns a snapshot of the batch statistics
+func (b *ClickBatcher) Stats() ClickBatchStats {
+	b.statsMu.Lock()
+	defer b.statsMu.Unlock()
+	return b.stats
+}
+
+// Close flushes the buffered clicks and stops the flush loop
+func (b *ClickBatcher) Close() {
+	b.closeMu.Lock()
+	if !b.closed {
+		b.closed = true
+		close(b.clicks)
+	}
+	b.closeMu.Unlock()
+	<-b.done
+}
+
+// run collects clicks into batches until the click channel is closed
+func (b *ClickBatcher) run() {
+	defer close(b.done)
+
+	batch := make([]*models.Click, 0, b.maxSize)
+	timer := time.NewTimer(b.interval)
+	timer.Stop()
+
+	flush := func(bySize bool) {
+		timer.Stop()
+		if len(batch) == 0 {
+			return
+		}
+		b.flush(batch, bySize)
+		batch = make([]*models.Click, 0, b.maxSize)
+	}
+
+	for {
+		select {
+		case click, ok := <-b.clicks:
+			if !ok {
+				flush(false)
+				return
+			}
+			batch = append(batch, click)
+			// The interval bounds how long the oldest click in a batch waits
+			if len(batch) == 1 {
+				timer.Reset(b.interval)
+			}
+			if len(batch) >= b.maxSize {
+				flush(true)
+			}
+		case <-timer.C:
+			flush(false)
+		}
+	}
+}
+
+// flush writes a batch and records its statistics, both in Stats and in the click batch metrics
+func (b *ClickBatcher) flush(batch []*models.Click, bySize bool) {
+	err := b.write(context.Background(), batch)
+
+	trigger := metrics.FlushByInterval
+	if bySize {
+		trigger = metrics.FlushBySize
+	}
+	metrics.ClickBatchFlushes.WithLabelValues(trigger).Inc()
+	metrics.ClickBatchSize.Observe(float64(len(batch)))
+	if err != nil {
+		metrics.ClickBatchFailedClicks.Add(float64(len(batch)))
+	}
+
+	b.statsMu.Lock()
+	b.stats.Batches++
+	b.stats.Clicks += int64(len(batch))
+	if bySize {
+		b.stats.SizeFlushes++
+	} else {
+		b.stats.IntervalFlushes++
+	}
+	if err != nil {
+		b.stats.FailedClicks += int64(len(batch))
+	}
+	b.stats.LastBatchSize = len(batch)
+	if len(batch) > b.stats.MaxBatchSize {
+		b.stats.MaxBatchSize = len(batch)
+	}
+	b.statsMu.Unlock()
+
+	if err != nil {
+		log.Error().Err(err).Int("batch_size", len(batch)).Msg("Failed to write click batch")
+		return
+	}
+	log.Debug().Int("batch_size", len(batch)).Bool("size_triggered", bySize).Msg("Click batch written")
+}
+
+// WithClickBatching buffers tracked clicks and writes them, together with the click count increments,
+// once maxSize clicks are buffered or interval has passed, whichever comes first. A maxSize of 0
+// writes every click immediately. Buffered clicks are not yet visible to recent-click deduplication.
+func WithClickBatching(maxSize int, interval time.Duration) Option {
+	return func(s *URLService) {
+		s.clickBatchSize = maxSize
+		s.clickBatchInterval = interval
+	}
+}
+
+// ClickBatchStats returns the click batching statistics, which are zero when batching is disabled
+func (s *URLService) ClickBatchStats() ClickBatchStats {
+	if s.clickBatcher == nil {
+		return ClickBatchStats{}
+	}
+	return s.clickBatcher.Stats()
+}
+
+// writeClickBatch stores a batch of clicks and drops their URLs from the cache so cached click counts don't go stale
+func (s *URLService) writeClickBatch(ctx context.Context, clicks []*models.Click) error {
+	if err := s.db.StoreClickBatch(ctx, clicks); err != nil {
+		return err
+	}
+	invalidated := make(map[string]bool)
+	for _, click := range clicks {
+		if !invalidated[click.URLShort] {
+			invalidated[click.URLShort] = true
+			s.invalidateCache(ctx, click.URLShort)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fransfilastap/urlshortener/metrics"
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingWriter returns a ClickBatchWriter that sends every batch to the returned channel
+func recordingWriter() (ClickBatchWriter, chan []*models.Click) {
+	batches := make(chan []*models.Click, 10)
+	return func(ctx context.Context, clicks []*models.Click) error {
+		batches <- clicks
+		return nil
+	}, batches
+}
+
+func waitForBatch(t *testing.T, batches chan []*models.Click) []*models.Click {
+	t.Helper()
+	select {
+	case batch := <-batches:
+		return batch
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for click batch")
+		return nil
+	}
+}
+
+func TestClickBatcher(t *testing.T) {
+	click := func(short string) *models.Click {
+		return models.NewClick(1, short, "127.0.0.1", "Unknown", "Chrome", "Desktop")
+	}
+
+	// Test case 1: A full batch is flushed without waiting for the interval
+	t.Run("SizeTriggeredFlush", func(t *testing.T) {
+		write, batches := recordingWriter()
+		batcher := NewClickBatcher(write, 3, time.Hour)
+		defer batcher.Close()
+
+		for i := 0; i < 3; i++ {
+			batcher.Add(click("abc123"))
+		}
+
+		assert.Len(t, waitForBatch(t, batches), 3)
+		stats := batcher.Stats()
+		assert.Equal(t, int64(1), stats.SizeFlushes)
+		assert.Equal(t, int64(0), stats.IntervalFlushes)
+		assert.Equal(t, 3, stats.MaxBatchSize)
+	})
+
+	// Test case 2: A partial batch is flushed once the interval passes
+	t.Run("TimeTriggeredFlush", func(t *testing.T) {
+		write, batches := recordingWriter()
+		batcher := NewClickBatcher(write, 100, 20*time.Millisecond)
+		defer batcher.Close()
+
+		start := time.Now()
+		batcher.Add(click("abc123"))
+		batcher.Add(click("def456"))
+
+		assert.Len(t, waitForBatch(t, batches), 2)
+		assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+		stats := batcher.Stats()
+		assert.Equal(t, int64(0), stats.SizeFlushes)
+		assert.Equal(t, int64(1), stats.IntervalFlushes)
+		assert.Equal(t, int64(2), stats.Clicks)
+	})
+
+	// Test case 3: Close flushes buffered clicks and drops later ones
+	t.Run("CloseFlushes", func(t *testing.T) {
+		write, batches := recordingWriter()
+		batcher := NewClickBatcher(write, 100, time.Hour)
+
+		batcher.Add(click("abc123"))
+		batcher.Close()
+		batcher.Add(click("abc123"))
+
+		assert.Len(t, waitForBatch(t, batches), 1)
+		assert.Equal(t, int64(1), batcher.Stats().Clicks)
+	})
+
+	// Test case 4: Flushes and failed clicks are exported as metrics
+	t.Run("Metrics", func(t *testing.T) {
+		flushes := testutil.ToFloat64(metrics.ClickBatchFlushes.WithLabelValues(metrics.FlushBySize))
+		failed := testutil.ToFloat64(metrics.ClickBatchFailedClicks)
+		batcher := NewClickBatcher(func(ctx context.Context, clicks []*models.Click) error {
+			return errors.New("connection refused")
+		}, 2, time.Hour)
+
+		batcher.Add(click("abc123"))
+		batcher.Add(click("abc123"))
+		batcher.Close()
+
+		assert.Equal(t, flushes+1, testutil.ToFloat64(metrics.ClickBatchFlushes.WithLabelValues(metrics.FlushBySize)))
+		assert.Equal(t, failed+2, testutil.ToFloat64(metrics.ClickBatchFailedClicks))
+	})
+}
+
+func TestTrackClickBatched(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(MockURLRepository)
+	service := NewURLService(mockRepo, nil, WithClickBatching(2, time.Hour))
+
+	mockRepo.On("HasRecentClick", ctx, mock.Anything, "1.2.3.4", mock.Anything, mock.Anything, mock.Anything).Return(false, nil)
+	mockRepo.On("GetByShort", ctx, "abc123").Return(&models.URL{ID: 1, Short: "abc123"}, nil)
+	mockRepo.On("StoreClickBatch", mock.Anything, mock.AnythingOfType("[]*models.Click")).Return(nil)
+
+	require.NoError(t, service.TrackClick(ctx, &ClickContext{Short: "abc123", IP: "1.2.3.4"}))
+	require.NoError(t, service.TrackClick(ctx, &ClickContext{Short: "abc123", IP: "1.2.3.4"}))
+	service.Close()
+
+	mockRepo.AssertNumberOfCalls(t, "StoreClickBatch", 1)
+	mockRepo.AssertNotCalled(t, "StoreClick", mock.Anything, mock.Anything)
+	mockRepo.AssertNotCalled(t, "IncrementClicks", mock.Anything, mock.Anything)
+	assert.Equal(t, int64(1), service.ClickBatchStats().SizeFlushes)
+}
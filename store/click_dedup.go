@@ -0,0 +1,26 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// DefaultClickDedupWindow is how long a visitor's repeat clicks on a URL are deduplicated by default
+const DefaultClickDedupWindow = time.Hour
+
+// WithClickDedupWindow sets how long repeat clicks from the same visitor (IP, browser and device) on a URL
+// are recorded as a single click. Zero or a negative window turns deduplication off, so every click is
+// recorded.
+func WithClickDedupWindow(window time.Duration) Option {
+	return func(s *URLService) {
+		s.clickDedupWindow = window
+	}
+}
+
+// hasRecentClick reports whether the visitor clicked the URL within the dedup window
+func (s *URLService) hasRecentClick(ctx context.Context, short string, ip, browser, device string) (bool, error) {
+	if s.clickDedupWindow <= 0 {
+		return false, nil
+	}
+	return s.db.HasRecentClick(ctx, short, ip, browser, device, s.clickDedupWindow)
+}
@@ -0,0 +1,54 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestClickDedupWindow(t *testing.T) {
+	ctx := context.Background()
+
+	newMockRepo := func() *MockURLRepository {
+		mockRepo := new(MockURLRepository)
+		mockRepo.On("GetByShort", mock.Anything, "abc123").Return(&models.URL{ID: 1, Short: "abc123"}, nil)
+		mockRepo.On("StoreClick", mock.Anything, mock.AnythingOfType("*models.Click")).Return(nil)
+		mockRepo.On("IncrementClicks", mock.Anything, "abc123").Return(int64(1), nil)
+		return mockRepo
+	}
+
+	t.Run("Default window", func(t *testing.T) {
+		mockRepo := newMockRepo()
+		mockRepo.On("HasRecentClick", mock.Anything, "abc123", "1.2.3.4", "Chrome", "Desktop", time.Hour).Return(true, nil)
+		service := NewURLService(mockRepo, nil)
+
+		assert.ErrorIs(t, service.RecordClick(ctx, "abc123", "1.2.3.4", "", "Chrome", "Desktop"), ErrRecentClick)
+		mockRepo.AssertNotCalled(t, "StoreClick", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Configured window", func(t *testing.T) {
+		mockRepo := newMockRepo()
+		mockRepo.On("HasRecentClick", mock.Anything, "abc123", "1.2.3.4", "Chrome", "Desktop", 10*time.Minute).Return(false, nil)
+		service := NewURLService(mockRepo, nil, WithClickDedupWindow(10*time.Minute))
+
+		assert.NoError(t, service.RecordClick(ctx, "abc123", "1.2.3.4", "", "Chrome", "Desktop"))
+		mockRepo.AssertCalled(t, "HasRecentClick", mock.Anything, "abc123", "1.2.3.4", "Chrome", "Desktop", 10*time.Minute)
+		mockRepo.AssertNumberOfCalls(t, "StoreClick", 1)
+	})
+
+	for _, window := range []time.Duration{0, -time.Minute} {
+		t.Run("Disabled with "+window.String(), func(t *testing.T) {
+			mockRepo := newMockRepo()
+			service := NewURLService(mockRepo, nil, WithClickDedupWindow(window))
+
+			assert.NoError(t, service.RecordClick(ctx, "abc123", "1.2.3.4", "", "Chrome", "Desktop"))
+			assert.NoError(t, service.RecordClick(ctx, "abc123", "1.2.3.4", "", "Chrome", "Desktop"))
+			mockRepo.AssertNotCalled(t, "HasRecentClick", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			mockRepo.AssertNumberOfCalls(t, "StoreClick", 2)
+		})
+	}
+}
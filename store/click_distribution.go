@@ -0,0 +1,41 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/rs/zerolog/log"
+)
+
+// GetClickDistribution counts a URL's clicks within rng by the hour of the day and day of the week they
+// happened at on the wall clock of loc (UTC when nil). Like GetClickAnalytics, a range without bounds
+// covers the configured analytics window.
+func (s *URLService) GetClickDistribution(ctx context.Context, short string, rng ClickRange, loc *time.Location) (*models.ClickDistribution, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	if rng.From.IsZero() && rng.To.IsZero() && s.analyticsWindow > 0 {
+		rng.From = time.Now().Add(-s.analyticsWindow)
+	}
+
+	log.Debug().
+		Str("short", short).
+		Time("from", rng.From).
+		Time("to", rng.To).
+		Str("timezone", loc.String()).
+		Msg("Getting click distribution")
+
+	distribution, err := s.db.GetClickDistribution(ctx, short, rng, loc.String())
+	if err != nil {
+		log.Error().Err(err).Str("short", short).Msg("Failed to get click distribution")
+		return nil, err
+	}
+
+	log.Info().
+		Str("short", short).
+		Str("timezone", loc.String()).
+		Msg("Click distribution retrieved successfully")
+
+	return distribution, nil
+}
@@ -0,0 +1,43 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetClickDistribution(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Unbounded range covers the analytics window in UTC", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		service := NewURLService(mockRepo, nil, WithAnalyticsWindow(24*time.Hour))
+		distribution := &models.ClickDistribution{Timezone: "UTC"}
+		mockRepo.On("GetClickDistribution", ctx, "abc123", mock.MatchedBy(func(rng ClickRange) bool {
+			return rng.To.IsZero() && time.Since(rng.From) >= 24*time.Hour && time.Since(rng.From) < 25*time.Hour
+		}), "UTC").Return(distribution, nil)
+
+		got, err := service.GetClickDistribution(ctx, "abc123", ClickRange{}, nil)
+		require.NoError(t, err)
+		assert.Same(t, distribution, got)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Explicit range and time zone are passed on", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		service := NewURLService(mockRepo, nil, WithAnalyticsWindow(24*time.Hour))
+		tokyo, err := time.LoadLocation("Asia/Tokyo")
+		require.NoError(t, err)
+		rng := ClickRange{From: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)}
+		mockRepo.On("GetClickDistribution", ctx, "abc123", rng, "Asia/Tokyo").Return(&models.ClickDistribution{Timezone: "Asia/Tokyo"}, nil)
+
+		_, err = service.GetClickDistribution(ctx, "abc123", rng, tokyo)
+		require.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+}
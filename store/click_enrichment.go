@@ -0,0 +1,169 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ClickContext carries the request details of a click and the attributes derived from them
+type ClickContext struct {
+	Short     string
+	IP        string
+	UserAgent string
+	Referrer  string
+	// ResolveTime is how long the redirect took to resolve the code, zero when not captured
+	ResolveTime time.Duration
+	// Target is the short code a random link redirected to, empty for ordinary links
+	Target string
+	// Extra holds the click's allowlisted extra fields, see WithClickExtraFields
+	Extra map[string]string
+	// Counted means the URL's click count was already incremented for the click, as it is when a
+	// usage-limited link is redirected
+	Counted bool
+
+	Location       string
+	Browser        string
+	BrowserVersion string
+	OS             string
+	Device         string
+
+	// Skip tells the service not to record the click, e.g. because it came from a bot
+	Skip bool
+}
+
+// ClickEnricher derives click attributes by mutating a ClickContext
+type ClickEnricher func(ctx context.Context, click *ClickContext) error
+
+// Names of the built-in click enrichers
+const (
+	EnricherUserAgent = "user_agent"
+	EnricherLocation  = "location"
+	EnricherReferrer  = "referrer"
+	EnricherBotFilter = "bot_filter"
+)
+
+// DefaultClickEnrichers lists the built-in click enrichers in their default order. The bot filter is
+// opt-in, so bot clicks are recorded unless it is configured.
+var DefaultClickEnrichers = []string{EnricherReferrer, EnricherUserAgent, EnricherLocation}
+
+// clickEnrichers maps enricher names to their implementations
+var clickEnrichers = map[string]ClickEnricher{
+	EnricherUserAgent: UserAgentEnricher,
+	EnricherLocation:  UnknownLocationEnricher,
+	EnricherReferrer:  ReferrerEnricher,
+	EnricherBotFilter: BotFilterEnricher,
+}
+
+// ClickEnrichersByName resolves an ordered list of enricher names, failing on unknown names
+func ClickEnrichersByName(names []string) ([]ClickEnricher, error) {
+	enrichers := make([]ClickEnricher, 0, len(names))
+	for _, name := range names {
+		enricher, ok := clickEnrichers[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownClickEnricher, name)
+		}
+		enrichers = append(enrichers, enricher)
+	}
+	return enrichers, nil
+}
+
+// UserAgentEnricher derives the browser, browser version, OS and device from the user agent
+func UserAgentEnricher(ctx context.Context, click *ClickContext) error {
+	ua := ParseUserAgent(click.UserAgent)
+	click.Browser = ua.Browser
+	click.BrowserVersion = ua.BrowserVersion
+	click.OS = ua.OS
+	click.Device = ua.Device
+	return nil
+}
+
+// ReferrerEnricher normalizes the referrer to the referring host, see NormalizeReferrer
+func ReferrerEnricher(ctx context.Context, click *ClickContext) error {
+	click.Referrer = NormalizeReferrer(click.Referrer)
+	return nil
+}
+
+// BotFilterEnricher skips clicks whose user agent belongs to a bot, such as a crawler or an HTTP client
+func BotFilterEnricher(ctx context.Context, click *ClickContext) error {
+	if ParseUserAgent(click.UserAgent).Device == DeviceBot {
+		click.Skip = true
+	}
+	return nil
+}
+
+// UnknownLocationEnricher sets a placeholder location when none has been resolved
+func UnknownLocationEnricher(ctx context.Context, click *ClickContext) error {
+	if click.Location == "" {
+		click.Location = DefaultUnknownLocation
+	}
+	return nil
+}
+
+// enrichClick resolves the click's location, then runs the configured enrichers in order.
+// Enrichment is best-effort: a failing enricher is logged and the remaining enrichers still run.
+func (s *URLService) enrichClick(ctx context.Context, click *ClickContext) {
+	s.locateClick(ctx, click)
+
+	for i, enricher := range s.enrichers {
+		if err := enricher(ctx, click); err != nil {
+			log.Warn().Err(err).Str("short", click.Short).Int("enricher", i).Msg("Click enricher failed")
+		}
+		if click.Skip {
+			return
+		}
+	}
+}
+
+// TrackClick enriches a click, then records its analytics and increments the click count in one
+// transaction, unless the click is skipped or a recent click from the same visitor exists
+func (s *URLService) TrackClick(ctx context.Context, click *ClickContext) error {
+	log.Debug().Str("short", click.Short).Str("ip", click.IP).Msg("Tracking click")
+
+	if err := s.ValidateClickExtra(click.Extra); err != nil {
+		log.Error().Err(err).Str("short", click.Short).Msg("Rejected click with invalid extra fields")
+		return err
+	}
+	if s.isDoubleSubmit(click) {
+		return ErrRecentClick
+	}
+
+	s.enrichClick(ctx, click)
+	if click.Skip {
+		log.Debug().Str("short", click.Short).Msg("Click skipped by enricher")
+		return nil
+	}
+
+	record, err := s.clickRecord(ctx, click)
+	if err != nil {
+		return err
+	}
+	record.Counted = click.Counted
+
+	// With batching, the click and its count increment are written together in the next batch
+	if s.clickBatcher != nil {
+		s.clickBatcher.Add(record)
+		s.sendClickWebhook(ctx, click)
+		return nil
+	}
+
+	// The click is stored and counted together, so a failure can't leave one without the other
+	clicks, err := s.db.StoreCountedClick(ctx, record)
+	if err != nil {
+		log.Error().Err(err).Str("short", click.Short).Msg("Failed to store and count click")
+		return err
+	}
+	if !record.Counted && s.cache != nil {
+		if err := s.cache.IncrementClicks(ctx, click.Short); err != nil {
+			// The database is the source of truth, so a stale cached count is only logged
+			log.Warn().Err(err).Str("short", click.Short).Msg("Failed to increment click count in cache")
+		}
+	}
+
+	log.Info().Str("short", click.Short).Str("ip", click.IP).Int64("clicks", clicks).Msg("Click tracked")
+
+	s.sendClickWebhook(ctx, click)
+	return nil
+}
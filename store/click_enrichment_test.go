@@ -0,0 +1,125 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrackClick(t *testing.T) {
+	ctx := context.Background()
+
+	// Test case 1: Composed enrichers all apply, in order
+	t.Run("ComposedEnrichers", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		countryEnricher := func(ctx context.Context, click *ClickContext) error {
+			click.Location = "NL"
+			return nil
+		}
+		service := NewURLService(mockRepo, nil, WithClickEnrichers(countryEnricher, UserAgentEnricher, UnknownLocationEnricher))
+
+		var stored *models.Click
+		mockRepo.On("HasRecentClick", ctx, "abc123", "1.2.3.4", "Chrome", "Mobile", mock.Anything).Return(false, nil)
+		mockRepo.On("GetByShort", ctx, "abc123").Return(&models.URL{ID: 1, Short: "abc123"}, nil)
+		mockRepo.On("StoreCountedClick", ctx, mock.AnythingOfType("*models.Click")).Run(func(args mock.Arguments) {
+			stored = args.Get(1).(*models.Click)
+		}).Return(int64(1), nil)
+
+		click := &ClickContext{Short: "abc123", IP: "1.2.3.4", UserAgent: "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.6099.144 Mobile Safari/537.36"}
+		require.NoError(t, service.TrackClick(ctx, click))
+
+		require.NotNil(t, stored)
+		assert.Equal(t, "NL", stored.Location)
+		assert.Equal(t, "Chrome", stored.Browser)
+		assert.Equal(t, "120.0.6099.144", stored.BrowserVersion)
+		assert.Equal(t, "Android", stored.OS)
+		assert.Equal(t, "Mobile", stored.Device)
+		assert.False(t, stored.Counted)
+		mockRepo.AssertNotCalled(t, "StoreClick", mock.Anything, mock.Anything)
+		mockRepo.AssertNotCalled(t, "IncrementClicks", mock.Anything, mock.Anything)
+	})
+
+	// Test case 2: An enricher can skip the click before later enrichers run
+	t.Run("SkippedClick", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		ran := false
+		botFilter := func(ctx context.Context, click *ClickContext) error {
+			click.Skip = true
+			return nil
+		}
+		after := func(ctx context.Context, click *ClickContext) error {
+			ran = true
+			return nil
+		}
+		service := NewURLService(mockRepo, nil, WithClickEnrichers(botFilter, after))
+
+		require.NoError(t, service.TrackClick(ctx, &ClickContext{Short: "abc123"}))
+
+		assert.False(t, ran)
+		mockRepo.AssertNotCalled(t, "StoreCountedClick", mock.Anything, mock.Anything)
+	})
+
+	// Test case 3: Allowlisted extra fields are stored with the click, others reject it
+	t.Run("ExtraFields", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		service := NewURLService(mockRepo, nil, WithClickExtraFields("screen", " theme "))
+		assert.ElementsMatch(t, []string{"screen", "theme"}, service.ClickExtraFields())
+
+		var stored *models.Click
+		mockRepo.On("HasRecentClick", ctx, "abc123", "1.2.3.4", mock.Anything, mock.Anything, mock.Anything).Return(false, nil)
+		mockRepo.On("GetByShort", ctx, "abc123").Return(&models.URL{ID: 1, Short: "abc123"}, nil)
+		mockRepo.On("StoreCountedClick", ctx, mock.AnythingOfType("*models.Click")).Run(func(args mock.Arguments) {
+			stored = args.Get(1).(*models.Click)
+		}).Return(int64(1), nil)
+
+		extra := map[string]string{"screen": "1920x1080", "theme": "dark"}
+		require.NoError(t, service.TrackClick(ctx, &ClickContext{Short: "abc123", IP: "1.2.3.4", Extra: extra}))
+		require.NotNil(t, stored)
+		assert.Equal(t, extra, stored.Extra)
+
+		err := service.TrackClick(ctx, &ClickContext{Short: "abc123", IP: "1.2.3.4", Extra: map[string]string{"battery": "12%"}})
+		assert.ErrorIs(t, err, ErrClickFieldNotAllowed)
+		mockRepo.AssertNumberOfCalls(t, "StoreCountedClick", 1)
+	})
+
+	// Test case 4: The bot filter skips bot clicks and the referrer enricher normalizes the referrer
+	t.Run("BotFilterAndReferrer", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		service := NewURLService(mockRepo, nil, WithClickEnrichers(BotFilterEnricher, ReferrerEnricher, UserAgentEnricher))
+
+		var stored *models.Click
+		mockRepo.On("HasRecentClick", ctx, "abc123", "1.2.3.4", mock.Anything, mock.Anything, mock.Anything).Return(false, nil)
+		mockRepo.On("GetByShort", ctx, "abc123").Return(&models.URL{ID: 1, Short: "abc123"}, nil)
+		mockRepo.On("StoreCountedClick", ctx, mock.AnythingOfType("*models.Click")).Run(func(args mock.Arguments) {
+			stored = args.Get(1).(*models.Click)
+		}).Return(int64(1), nil)
+
+		bot := &ClickContext{Short: "abc123", IP: "1.2.3.4", UserAgent: "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)"}
+		require.NoError(t, service.TrackClick(ctx, bot))
+		assert.True(t, bot.Skip)
+		mockRepo.AssertNotCalled(t, "StoreCountedClick", mock.Anything, mock.Anything)
+
+		browser := &ClickContext{Short: "abc123", IP: "1.2.3.4", UserAgent: "Mozilla/5.0 (X11; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0", Referrer: "https://www.Example.com/page"}
+		require.NoError(t, service.TrackClick(ctx, browser))
+		require.NotNil(t, stored)
+		assert.Equal(t, "example.com", stored.Referrer)
+	})
+
+	// Test case 5: Enrichers resolve by name and reject unknown names
+	t.Run("EnrichersByName", func(t *testing.T) {
+		enrichers, err := ClickEnrichersByName([]string{EnricherLocation, EnricherUserAgent})
+		require.NoError(t, err)
+		assert.Len(t, enrichers, 2)
+
+		enrichers, err = ClickEnrichersByName(DefaultClickEnrichers)
+		require.NoError(t, err)
+		assert.Len(t, enrichers, len(DefaultClickEnrichers))
+
+		_, err = ClickEnrichersByName([]string{EnricherUserAgent, "geoip"})
+		assert.ErrorIs(t, err, ErrUnknownClickEnricher)
+	})
+}
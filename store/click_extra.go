@@ -0,0 +1,51 @@
+package store
+
+import (
+	"net/http"
+	"strings"
+)
+
+// MaxClickExtraValueLength is the longest value an extra click field may have
+const MaxClickExtraValueLength = 256
+
+var (
+	// ErrClickFieldNotAllowed is returned when a click carries an extra field that isn't allowlisted
+	ErrClickFieldNotAllowed = NewAPIError(http.StatusBadRequest, "click_field_not_allowed", "Extra click field is not allowed")
+	// ErrClickFieldTooLong is returned when an extra click field's value exceeds MaxClickExtraValueLength
+	ErrClickFieldTooLong = NewAPIError(http.StatusBadRequest, "click_field_too_long", "Extra click field value is too long")
+)
+
+// WithClickExtraFields allowlists the names of extra attributes, e.g. "screen", that clicks may carry.
+// Without this option clicks carry no extra fields.
+func WithClickExtraFields(fields ...string) Option {
+	return func(s *URLService) {
+		s.clickExtraFields = make(map[string]bool, len(fields))
+		for _, field := range fields {
+			if field = strings.TrimSpace(field); field != "" {
+				s.clickExtraFields[field] = true
+			}
+		}
+	}
+}
+
+// ClickExtraFields returns the allowlisted extra click field names
+func (s *URLService) ClickExtraFields() []string {
+	fields := make([]string, 0, len(s.clickExtraFields))
+	for field := range s.clickExtraFields {
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// ValidateClickExtra checks that every extra click field is allowlisted and not too long
+func (s *URLService) ValidateClickExtra(extra map[string]string) error {
+	for field, value := range extra {
+		if !s.clickExtraFields[field] {
+			return ErrClickFieldNotAllowed
+		}
+		if len(value) > MaxClickExtraValueLength {
+			return ErrClickFieldTooLong
+		}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package store
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// clickGrace remembers recent clicks in memory to absorb near-simultaneous duplicates, such as a
+// prefetch followed by the navigation, which the recent-click check in the database misses when
+// both arrive before the first click is stored
+type clickGrace struct {
+	window time.Duration
+
+	mu        sync.Mutex
+	seen      map[string]time.Time
+	lastSweep time.Time
+}
+
+func newClickGrace(window time.Duration) *clickGrace {
+	return &clickGrace{window: window, seen: make(map[string]time.Time)}
+}
+
+// WithClickGrace drops clicks identical to one from the same visitor within window, e.g. a second
+// or less; 0 turns the in-memory check off
+func WithClickGrace(window time.Duration) Option {
+	return func(s *URLService) {
+		s.clickGrace = nil
+		if window > 0 {
+			s.clickGrace = newClickGrace(window)
+		}
+	}
+}
+
+// duplicate reports whether an identical click was seen within the window, remembering this one
+// otherwise
+func (g *clickGrace) duplicate(key string, now time.Time) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if now.Sub(g.lastSweep) >= g.window {
+		for k, at := range g.seen {
+			if now.Sub(at) >= g.window {
+				delete(g.seen, k)
+			}
+		}
+		g.lastSweep = now
+	}
+
+	if at, ok := g.seen[key]; ok && now.Sub(at) < g.window {
+		return true
+	}
+	g.seen[key] = now
+	return false
+}
+
+// isDoubleSubmit reports whether the click repeats one the same visitor made within the click grace
+func (s *URLService) isDoubleSubmit(click *ClickContext) bool {
+	if s.clickGrace == nil {
+		return false
+	}
+	key := strings.Join([]string{click.Short, click.IP, click.UserAgent, click.Browser, click.Device}, "\x00")
+	if s.clickGrace.duplicate(key, time.Now()) {
+		log.Debug().Str("short", click.Short).Str("ip", click.IP).Msg("Dropped double-submitted click")
+		return true
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+package store
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestClickGrace(t *testing.T) {
+	ctx := context.Background()
+	const userAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+
+	// newService returns a service whose database never reports a recent click, as when both clicks
+	// arrive before the first is stored
+	newService := func(window time.Duration) (*URLService, *MockURLRepository) {
+		mockRepo := new(MockURLRepository)
+		mockRepo.On("HasRecentClick", mock.Anything, "abc123", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(false, nil)
+		mockRepo.On("GetByShort", mock.Anything, "abc123").Return(&models.URL{ID: 1, Short: "abc123"}, nil)
+		mockRepo.On("StoreClick", mock.Anything, mock.AnythingOfType("*models.Click")).Return(nil)
+		mockRepo.On("StoreCountedClick", mock.Anything, mock.AnythingOfType("*models.Click")).Return(int64(1), nil)
+		return NewURLService(mockRepo, nil, WithClickGrace(window)), mockRepo
+	}
+
+	t.Run("Near-simultaneous identical clicks", func(t *testing.T) {
+		service, mockRepo := newService(time.Second)
+
+		errs := make([]error, 2)
+		var wg sync.WaitGroup
+		for i := range errs {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs[i] = service.TrackClick(ctx, &ClickContext{Short: "abc123", IP: "1.2.3.4", UserAgent: userAgent})
+			}()
+		}
+		wg.Wait()
+
+		assert.ElementsMatch(t, []error{nil, ErrRecentClick}, errs)
+		mockRepo.AssertNumberOfCalls(t, "StoreCountedClick", 1)
+	})
+
+	t.Run("Different visitors", func(t *testing.T) {
+		service, mockRepo := newService(time.Second)
+
+		assert.NoError(t, service.TrackClick(ctx, &ClickContext{Short: "abc123", IP: "1.2.3.4", UserAgent: userAgent}))
+		assert.NoError(t, service.TrackClick(ctx, &ClickContext{Short: "abc123", IP: "5.6.7.8", UserAgent: userAgent}))
+		assert.NoError(t, service.TrackClick(ctx, &ClickContext{Short: "abc123", IP: "1.2.3.4", UserAgent: "curl/8.0"}))
+		mockRepo.AssertNumberOfCalls(t, "StoreCountedClick", 3)
+	})
+
+	t.Run("After the window", func(t *testing.T) {
+		service, mockRepo := newService(20 * time.Millisecond)
+
+		assert.NoError(t, service.RecordClick(ctx, "abc123", "1.2.3.4", "", "Chrome", "Desktop"))
+		assert.ErrorIs(t, service.RecordClick(ctx, "abc123", "1.2.3.4", "", "Chrome", "Desktop"), ErrRecentClick)
+		time.Sleep(30 * time.Millisecond)
+		assert.NoError(t, service.RecordClick(ctx, "abc123", "1.2.3.4", "", "Chrome", "Desktop"))
+		mockRepo.AssertNumberOfCalls(t, "StoreClick", 2)
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		service, mockRepo := newService(0)
+
+		assert.NoError(t, service.RecordClick(ctx, "abc123", "1.2.3.4", "", "Chrome", "Desktop"))
+		assert.NoError(t, service.RecordClick(ctx, "abc123", "1.2.3.4", "", "Chrome", "Desktop"))
+		mockRepo.AssertNumberOfCalls(t, "StoreClick", 2)
+	})
+}
@@ -0,0 +1,71 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/rs/zerolog/log"
+)
+
+// MaxClickImportSize is the largest number of clicks that can be imported in one request
+const MaxClickImportSize = 10000
+
+// ImportedClick is a historical click to import. When Browser is empty, the browser, OS and device are
+// parsed from UserAgent.
+type ImportedClick struct {
+	Timestamp      time.Time
+	IP             string
+	Location       string
+	UserAgent      string
+	Browser        string
+	BrowserVersion string
+	OS             string
+	Device         string
+	Target         string
+	Referrer       string
+}
+
+// ImportClicks stores historical clicks of a live URL, for example when migrating from another
+// shortener, and returns the URL's new click count
+func (s *URLService) ImportClicks(ctx context.Context, short string, imported []ImportedClick) (int64, error) {
+	log.Debug().Str("short", short).Int("count", len(imported)).Msg("Importing clicks")
+
+	if len(imported) > MaxClickImportSize {
+		return 0, ErrClickImportTooLarge
+	}
+
+	now := time.Now()
+	clicks := make([]*models.Click, 0, len(imported))
+	for _, in := range imported {
+		if in.Timestamp.IsZero() || in.Timestamp.After(now) {
+			return 0, ErrInvalidClick
+		}
+		click := &models.Click{
+			IP:             in.IP,
+			Location:       in.Location,
+			Browser:        in.Browser,
+			BrowserVersion: in.BrowserVersion,
+			OS:             in.OS,
+			Device:         in.Device,
+			Timestamp:      in.Timestamp,
+			Target:         in.Target,
+			Referrer:       NormalizeReferrer(in.Referrer),
+		}
+		if click.Browser == "" && in.UserAgent != "" {
+			ua := ParseUserAgent(in.UserAgent)
+			click.Browser, click.BrowserVersion, click.OS, click.Device = ua.Browser, ua.BrowserVersion, ua.OS, ua.Device
+		}
+		clicks = append(clicks, click)
+	}
+
+	count, err := s.db.ImportClicks(ctx, short, clicks)
+	if err != nil {
+		log.Error().Err(err).Str("short", short).Msg("Failed to import clicks")
+		return 0, err
+	}
+	s.invalidateCache(ctx, short)
+
+	log.Info().Str("short", short).Int("imported", len(clicks)).Int64("clicks", count).Msg("Clicks imported")
+	return count, nil
+}
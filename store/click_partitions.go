@@ -0,0 +1,213 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+)
+
+// clickDefaultPartition holds clicks outside every monthly partition so inserts never fail
+const clickDefaultPartition = "clicks_default"
+
+// clickPartitionName returns the name of the monthly clicks partition containing t, e.g. clicks_p2024_05
+func clickPartitionName(t time.Time) string {
+	return fmt.Sprintf("clicks_p%04d_%02d", t.Year(), int(t.Month()))
+}
+
+// monthStart returns midnight UTC on the first day of t's month
+func monthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// clickTimestamp returns the click's timestamp, or now if unset, so the click is routed to its month's partition
+func clickTimestamp(ts time.Time) time.Time {
+	if ts.IsZero() {
+		return time.Now()
+	}
+	return ts
+}
+
+// ClicksPartitioned reports whether the clicks table is range partitioned by month
+func (r *PostgresRepository) ClicksPartitioned(ctx context.Context) (bool, error) {
+	var partitioned bool
+	err := r.pool.QueryRow(ctx,
+		"SELECT EXISTS(SELECT 1 FROM pg_partitioned_table WHERE partrelid = 'clicks'::regclass)").Scan(&partitioned)
+	return partitioned, err
+}
+
+// PartitionClicks converts the clicks table to one range partitioned by month on timestamp, with a
+// partition per month of existing clicks plus the current and next month. Click IDs are preserved.
+// It does nothing if the table is already partitioned. The copy runs in one transaction, so
+// schedule it for a quiet period on large tables.
+func (r *PostgresRepository) PartitionClicks(ctx context.Context) error {
+	partitioned, err := r.ClicksPartitioned(ctx)
+	if err != nil || partitioned {
+		return err
+	}
+
+	log.Info().Msg("Migrating clicks table to monthly partitions")
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// Move the old table aside, freeing its constraint and index names for the partitioned table
+	_, err = tx.Exec(ctx, `
+		LOCK TABLE clicks IN ACCESS EXCLUSIVE MODE;
+		ALTER TABLE clicks RENAME TO clicks_legacy;
+		ALTER TABLE clicks_legacy RENAME CONSTRAINT clicks_pkey TO clicks_legacy_pkey;
+		DROP INDEX IF EXISTS idx_clicks_url_id;
+		DROP INDEX IF EXISTS idx_clicks_url_short;
+
+		CREATE TABLE clicks (
+			id BIGINT NOT NULL DEFAULT nextval('clicks_id_seq'),
+			url_id BIGINT NOT NULL REFERENCES urls(id) ON DELETE CASCADE,
+			url_short TEXT NOT NULL,
+			ip TEXT NOT NULL,
+			location TEXT,
+			browser TEXT,
+			device TEXT,
+			timestamp TIMESTAMP NOT NULL DEFAULT NOW(),
+			resolve_ms DOUBLE PRECISION,
+			target TEXT NOT NULL DEFAULT '',
+			browser_version TEXT NOT NULL DEFAULT '',
+			os TEXT NOT NULL DEFAULT '',
+			referrer TEXT NOT NULL DEFAULT '',
+			extra JSONB,
+			PRIMARY KEY (id, timestamp)
+		) PARTITION BY RANGE (timestamp);
+		CREATE INDEX idx_clicks_url_id ON clicks(url_id);
+		CREATE INDEX idx_clicks_url_short ON clicks(url_short);
+		CREATE TABLE `+clickDefaultPartition+` PARTITION OF clicks DEFAULT;
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create a partition for every month with clicks, then copy the clicks across
+	rows, err := tx.Query(ctx, "SELECT DISTINCT date_trunc('month', timestamp) FROM clicks_legacy")
+	if err != nil {
+		return err
+	}
+	months, err := pgx.CollectRows(rows, pgx.RowTo[time.Time])
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	months = append(months, now, now.AddDate(0, 1, 0))
+	for _, month := range months {
+		if _, err := createClickPartition(ctx, tx, month); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO clicks (id, url_id, url_short, ip, location, browser, device, timestamp, resolve_ms, target, browser_version, os, referrer, extra)
+		SELECT id, url_id, url_short, ip, location, browser, device, timestamp, resolve_ms, target, browser_version, os, referrer, extra FROM clicks_legacy;
+		ALTER SEQUENCE clicks_id_seq OWNED BY clicks.id;
+		DROP TABLE clicks_legacy;
+	`)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	log.Info().Int("partitions", len(months)).Msg("Clicks table migrated to monthly partitions")
+	return nil
+}
+
+// EnsureClickPartitions creates the monthly clicks partitions for the month containing now and the
+// following monthsAhead months, if missing. Run it regularly so partitions exist before clicks arrive.
+func (r *PostgresRepository) EnsureClickPartitions(ctx context.Context, now time.Time, monthsAhead int) ([]string, error) {
+	var created []string
+	for i := 0; i <= monthsAhead; i++ {
+		name, err := createClickPartition(ctx, r.pool, monthStart(now).AddDate(0, i, 0))
+		if err != nil {
+			return created, err
+		}
+		if name != "" {
+			created = append(created, name)
+		}
+	}
+	return created, nil
+}
+
+// MaintainClickPartitions ensures next month's clicks partition exists now and then every interval,
+// until ctx is cancelled
+func (r *PostgresRepository) MaintainClickPartitions(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		created, err := r.EnsureClickPartitions(ctx, time.Now(), 1)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to create clicks partitions")
+		} else if len(created) > 0 {
+			log.Info().Strs("partitions", created).Msg("Created clicks partitions")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// partitionDB is satisfied by both the pool and a transaction
+type partitionDB interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// createClickPartition creates the clicks partition for the month containing month and returns its
+// name, or an empty name if it already exists. Clicks for that month that landed in the default
+// partition while the partition was missing are moved into it.
+func createClickPartition(ctx context.Context, db partitionDB, month time.Time) (string, error) {
+	start := monthStart(month)
+	end := start.AddDate(0, 1, 0)
+	name := clickPartitionName(start)
+
+	var exists bool
+	if err := db.QueryRow(ctx, "SELECT to_regclass($1) IS NOT NULL", name).Scan(&exists); err != nil {
+		return "", err
+	}
+	if exists {
+		return "", nil
+	}
+
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback(ctx)
+
+	// Build the partition detached so stray rows can be moved out of the default partition before
+	// attaching, which would otherwise fail
+	partition := pgx.Identifier{name}.Sanitize()
+	_, err = tx.Exec(ctx, fmt.Sprintf(`
+		CREATE TABLE %[1]s (LIKE clicks INCLUDING DEFAULTS);
+		WITH moved AS (
+			DELETE FROM %[2]s WHERE timestamp >= '%[3]s' AND timestamp < '%[4]s' RETURNING *
+		)
+		INSERT INTO %[1]s SELECT * FROM moved;
+		ALTER TABLE clicks ATTACH PARTITION %[1]s FOR VALUES FROM ('%[3]s') TO ('%[4]s');
+	`, partition, clickDefaultPartition, start.Format(time.DateOnly), end.Format(time.DateOnly)))
+	if err != nil {
+		return "", err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return "", err
+	}
+	return name, nil
+}
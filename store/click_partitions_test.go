@@ -0,0 +1,101 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClickPartitionName(t *testing.T) {
+	assert.Equal(t, "clicks_p2024_05", clickPartitionName(time.Date(2024, time.May, 31, 23, 0, 0, 0, time.UTC)))
+	assert.Equal(t, time.Date(2024, time.December, 1, 0, 0, 0, 0, time.UTC), monthStart(time.Date(2024, time.December, 15, 8, 0, 0, 0, time.UTC)))
+}
+
+// TestClickPartitions_Integration tests migrating clicks to monthly partitions against a real database
+func TestClickPartitions_Integration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+	pgContainer, err := SetupPostgresContainer(ctx)
+	if err != nil {
+		t.Skipf("Failed to setup PostgreSQL container: %v", err)
+	}
+	defer pgContainer.Teardown(ctx)
+
+	repo, err := NewPostgresRepository(pgContainer.URI)
+	if err != nil {
+		t.Skipf("Failed to connect to PostgreSQL: %v", err)
+	}
+	defer repo.Close()
+	require.NoError(t, repo.InitSchema(ctx))
+
+	url, err := repo.Create(ctx, models.NewURL("https://example.com", "parted", "", time.Time{}, "test-user"))
+	require.NoError(t, err)
+
+	// partitionOf returns the partition holding the click with the given timestamp
+	partitionOf := func(ts time.Time) string {
+		var partition string
+		err := repo.pool.QueryRow(ctx,
+			"SELECT tableoid::regclass::text FROM clicks WHERE url_short = $1 AND timestamp = $2", "parted", ts).Scan(&partition)
+		require.NoError(t, err)
+		return partition
+	}
+	storeClick := func(ts time.Time) {
+		click := models.NewClick(url.ID, "parted", "127.0.0.1", "Unknown", "Chrome", "Desktop")
+		click.Timestamp = ts
+		require.NoError(t, repo.StoreClick(ctx, click))
+	}
+
+	// A click from before the migration keeps its ID and moves into its month's partition
+	old := time.Date(2023, time.March, 10, 12, 0, 0, 0, time.UTC)
+	storeClick(old)
+	before, err := repo.GetClicksByShort(ctx, "parted")
+	require.NoError(t, err)
+
+	require.NoError(t, repo.PartitionClicks(ctx))
+	partitioned, err := repo.ClicksPartitioned(ctx)
+	require.NoError(t, err)
+	assert.True(t, partitioned)
+	// Migrating again is a no-op
+	require.NoError(t, repo.PartitionClicks(ctx))
+
+	assert.Equal(t, "clicks_p2023_03", partitionOf(old))
+
+	t.Run("Inserts land in their month's partition", func(t *testing.T) {
+		now := time.Now().UTC().Truncate(time.Microsecond)
+		storeClick(now)
+		assert.Equal(t, clickPartitionName(now), partitionOf(now))
+	})
+
+	t.Run("Clicks without a partition are moved once it is created", func(t *testing.T) {
+		future := time.Date(2099, time.July, 4, 0, 0, 0, 0, time.UTC)
+		storeClick(future)
+		assert.Equal(t, clickDefaultPartition, partitionOf(future))
+
+		created, err := repo.EnsureClickPartitions(ctx, future, 1)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"clicks_p2099_07", "clicks_p2099_08"}, created)
+		assert.Equal(t, "clicks_p2099_07", partitionOf(future))
+
+		created, err = repo.EnsureClickPartitions(ctx, future, 1)
+		require.NoError(t, err)
+		assert.Empty(t, created)
+	})
+
+	t.Run("Queries still return partitioned clicks", func(t *testing.T) {
+		clicks, err := repo.GetClicksByShort(ctx, "parted")
+		require.NoError(t, err)
+		assert.Len(t, clicks, 3)
+		assert.Equal(t, before[0].ID, clicks[len(clicks)-1].ID)
+
+		count, err := repo.RebuildClickCount(ctx, "parted")
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), count)
+	})
+}
@@ -0,0 +1,96 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+)
+
+// clickQueue feeds clicks to a fixed pool of workers through a buffered channel
+type clickQueue struct {
+	clicks chan *ClickContext
+	wg     sync.WaitGroup
+
+	// mu guards closed, so no click is sent on the channel after it is closed
+	mu     sync.RWMutex
+	closed bool
+}
+
+// WithClickWorkers tracks clicks passed to EnqueueClick on a pool of workers goroutines, queueing up to
+// size clicks while all workers are busy. Without it, or with 0 workers, EnqueueClick tracks clicks
+// right away.
+func WithClickWorkers(workers, size int) Option {
+	return func(s *URLService) {
+		s.clickWorkers = workers
+		s.clickQueueSize = size
+	}
+}
+
+// startClickWorkers starts the click worker pool if one is configured
+func (s *URLService) startClickWorkers() {
+	if s.clickWorkers <= 0 {
+		return
+	}
+	size := max(s.clickQueueSize, 0)
+	s.clickQueue = &clickQueue{clicks: make(chan *ClickContext, size)}
+	for range s.clickWorkers {
+		s.clickQueue.wg.Add(1)
+		go func() {
+			defer s.clickQueue.wg.Done()
+			for click := range s.clickQueue.clicks {
+				s.trackQueuedClick(click)
+			}
+		}()
+	}
+	log.Debug().Int("workers", s.clickWorkers).Int("queue_size", size).Msg("Click workers started")
+}
+
+// EnqueueClick queues a click to be tracked in the background by the click workers. It reports false,
+// dropping the click, when the queue is full or the service is closed.
+func (s *URLService) EnqueueClick(click *ClickContext) bool {
+	if s.clickQueue == nil {
+		s.trackQueuedClick(click)
+		return true
+	}
+
+	s.clickQueue.mu.RLock()
+	defer s.clickQueue.mu.RUnlock()
+	if s.clickQueue.closed {
+		return false
+	}
+	select {
+	case s.clickQueue.clicks <- click:
+		return true
+	default:
+		return false
+	}
+}
+
+// trackQueuedClick tracks a click outside of any request, so it isn't cut short when the request ends
+func (s *URLService) trackQueuedClick(click *ClickContext) {
+	if err := s.TrackClick(context.Background(), click); err != nil {
+		if errors.Is(err, ErrRecentClick) {
+			log.Debug().Str("short", click.Short).Msg("Recent click from the same visitor, not incrementing click count")
+		} else {
+			log.Error().Err(err).Str("short", click.Short).Msg("Failed to track click")
+		}
+	}
+}
+
+// closeClickQueue stops accepting clicks and waits for the workers to track the queued ones
+func (s *URLService) closeClickQueue() {
+	if s.clickQueue == nil {
+		return
+	}
+	s.clickQueue.mu.Lock()
+	if !s.clickQueue.closed {
+		s.clickQueue.closed = true
+		close(s.clickQueue.clicks)
+	}
+	s.clickQueue.mu.Unlock()
+
+	s.clickQueue.wg.Wait()
+	log.Info().Msg("Click queue drained")
+}
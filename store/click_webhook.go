@@ -0,0 +1,185 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fransfilastap/urlshortener/metrics"
+	"github.com/rs/zerolog/log"
+)
+
+// WebhookSignatureHeader carries the HMAC-SHA256 signature of a webhook body, as "sha256=<hex>"
+const WebhookSignatureHeader = "X-Signature"
+
+// webhookSignaturePrefix precedes the hex digest in WebhookSignatureHeader, mirroring GitHub's scheme
+const webhookSignaturePrefix = "sha256="
+
+// ClickWebhookPayload is the JSON body POSTed to the click webhook for every tracked click
+type ClickWebhookPayload struct {
+	Event     string    `json:"event"`
+	Short     string    `json:"short"`
+	Location  string    `json:"location,omitempty"`
+	Browser   string    `json:"browser,omitempty"`
+	Device    string    `json:"device,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+
+	BrowserVersion string `json:"browser_version,omitempty"`
+	OS             string `json:"os,omitempty"`
+	Referrer       string `json:"referrer,omitempty"`
+}
+
+// DefaultClickWebhookQueueSize is the number of click webhooks waiting to be sent before new ones are dropped
+const DefaultClickWebhookQueueSize = 1000
+
+// clickWebhookQueue feeds click notifications to the webhook sender through a buffered channel
+type clickWebhookQueue struct {
+	payloads chan ClickWebhookPayload
+	done     chan struct{}
+
+	// mu guards closed, so no payload is sent on the channel after it is closed
+	mu     sync.RWMutex
+	closed bool
+}
+
+// WithClickWebhook POSTs every tracked click to url in the background. When secret is set, each request
+// is signed with an X-Signature header receivers can check with VerifyWebhookSignature.
+func WithClickWebhook(url string, secret string) Option {
+	return func(s *URLService) {
+		s.clickWebhookURL = url
+		s.clickWebhookSecret = secret
+	}
+}
+
+// WithClickWebhookQueueSize sets how many click webhooks may wait to be sent before new ones are dropped,
+// DefaultClickWebhookQueueSize by default
+func WithClickWebhookQueueSize(size int) Option {
+	return func(s *URLService) {
+		s.clickWebhookQueueSize = size
+	}
+}
+
+// SignWebhookPayload returns the X-Signature value for body: "sha256=" followed by the hex HMAC-SHA256
+func SignWebhookPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return webhookSignaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhookSignature reports whether signature is a valid X-Signature for body, in constant time
+func VerifyWebhookSignature(secret string, body []byte, signature string) bool {
+	if !strings.HasPrefix(signature, webhookSignaturePrefix) {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(SignWebhookPayload(secret, body)))
+}
+
+// sendClickWebhook queues a notification of the click for the click webhook, if configured. Webhooks are
+// sent in the background so a slow receiver never holds up the click; when the queue is full the
+// notification is dropped and counted. Failures are logged and never fail the click.
+func (s *URLService) sendClickWebhook(ctx context.Context, click *ClickContext) {
+	if s.clickWebhooks == nil {
+		return
+	}
+
+	payload := ClickWebhookPayload{
+		Event:     "click",
+		Short:     click.Short,
+		Location:  click.Location,
+		Browser:   click.Browser,
+		Device:    click.Device,
+		Timestamp: time.Now(),
+
+		BrowserVersion: click.BrowserVersion,
+		OS:             click.OS,
+		Referrer:       click.Referrer,
+	}
+
+	s.clickWebhooks.mu.RLock()
+	defer s.clickWebhooks.mu.RUnlock()
+	if s.clickWebhooks.closed {
+		log.Warn().Str("short", click.Short).Msg("Click webhooks stopped, dropping click webhook")
+		return
+	}
+	select {
+	case s.clickWebhooks.payloads <- payload:
+	default:
+		metrics.ClickWebhooksDropped.Inc()
+		log.Warn().Str("short", click.Short).Msg("Click webhook queue full, dropping click webhook")
+	}
+}
+
+// startClickWebhooks starts the click webhook sender if a click webhook is configured
+func (s *URLService) startClickWebhooks() {
+	if s.clickWebhookURL == "" {
+		return
+	}
+	size := max(s.clickWebhookQueueSize, 0)
+	s.clickWebhooks = &clickWebhookQueue{payloads: make(chan ClickWebhookPayload, size), done: make(chan struct{})}
+	go func() {
+		defer close(s.clickWebhooks.done)
+		for payload := range s.clickWebhooks.payloads {
+			s.postClickWebhook(payload)
+		}
+	}()
+	log.Debug().Int("queue_size", size).Msg("Click webhook sender started")
+}
+
+// closeClickWebhooks stops accepting click webhooks and waits for the queued ones to be sent
+func (s *URLService) closeClickWebhooks() {
+	if s.clickWebhooks == nil {
+		return
+	}
+	s.clickWebhooks.mu.Lock()
+	if !s.clickWebhooks.closed {
+		s.clickWebhooks.closed = true
+		close(s.clickWebhooks.payloads)
+	}
+	s.clickWebhooks.mu.Unlock()
+
+	<-s.clickWebhooks.done
+	log.Info().Msg("Click webhook queue drained")
+}
+
+// postClickWebhook POSTs a click notification to the click webhook, signing it when a secret is set
+func (s *URLService) postClickWebhook(payload ClickWebhookPayload) {
+	log.Debug().Str("short", payload.Short).Msg("Sending click webhook")
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		log.Error().Err(err).Str("short", payload.Short).Msg("Failed to encode click webhook")
+		return
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, s.clickWebhookURL, bytes.NewReader(body))
+	if err != nil {
+		log.Error().Err(err).Str("short", payload.Short).Msg("Failed to build click webhook request")
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.clickWebhookSecret != "" {
+		req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(s.clickWebhookSecret, body))
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err == nil {
+		resp.Body.Close()
+		if resp.StatusCode >= http.StatusMultipleChoices {
+			err = fmt.Errorf("unexpected status %d", resp.StatusCode)
+		}
+	}
+	if err != nil {
+		log.Error().Err(err).Str("short", payload.Short).Msg("Failed to send click webhook")
+		return
+	}
+
+	log.Info().Str("short", payload.Short).Msg("Click webhook sent successfully")
+}
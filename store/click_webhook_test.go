@@ -0,0 +1,96 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fransfilastap/urlshortener/metrics"
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookSignature(t *testing.T) {
+	secret := "webhook-secret"
+	body := []byte(`{"event":"click","short":"abc123"}`)
+	signature := SignWebhookPayload(secret, body)
+
+	t.Run("Validates against the payload", func(t *testing.T) {
+		assert.Regexp(t, `^sha256=[0-9a-f]{64}$`, signature)
+		assert.True(t, VerifyWebhookSignature(secret, body, signature))
+	})
+
+	t.Run("Fails on tampering", func(t *testing.T) {
+		assert.False(t, VerifyWebhookSignature(secret, []byte(`{"event":"click","short":"evil"}`), signature))
+		assert.False(t, VerifyWebhookSignature("other-secret", body, signature))
+		assert.False(t, VerifyWebhookSignature(secret, body, signature[len("sha256="):]))
+		assert.False(t, VerifyWebhookSignature(secret, body, ""))
+	})
+}
+
+func TestSendClickWebhook(t *testing.T) {
+	type request struct {
+		body      []byte
+		signature string
+	}
+	requests := make(chan request, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- request{body: body, signature: r.Header.Get(WebhookSignatureHeader)}
+	}))
+	defer server.Close()
+
+	service := NewURLService(new(MockURLRepository), nil, WithClickWebhook(server.URL, "webhook-secret"))
+	defer service.Close()
+	service.sendClickWebhook(context.Background(), &ClickContext{Short: "abc123", Browser: "Firefox"})
+
+	var received request
+	select {
+	case received = <-requests:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for click webhook")
+	}
+	assert.True(t, VerifyWebhookSignature("webhook-secret", received.body, received.signature))
+
+	var payload ClickWebhookPayload
+	require.NoError(t, json.Unmarshal(received.body, &payload))
+	assert.Equal(t, "click", payload.Event)
+	assert.Equal(t, "abc123", payload.Short)
+	assert.Equal(t, "Firefox", payload.Browser)
+}
+
+func TestClickWebhookDoesNotBlockTrackClick(t *testing.T) {
+	ctx := context.Background()
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+
+	mockRepo := new(MockURLRepository)
+	mockRepo.On("GetByShort", ctx, "abc123").Return(&models.URL{ID: 1, Short: "abc123"}, nil)
+	mockRepo.On("StoreCountedClick", ctx, mock.AnythingOfType("*models.Click")).Return(int64(1), nil)
+	service := NewURLService(mockRepo, nil, WithClickWebhook(server.URL, ""), WithClickWebhookQueueSize(1), WithClickDedupWindow(0))
+	defer func() {
+		close(release)
+		service.Close()
+	}()
+
+	dropped := testutil.ToFloat64(metrics.ClickWebhooksDropped)
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		require.NoError(t, service.TrackClick(ctx, &ClickContext{Short: "abc123", IP: "1.2.3.4"}))
+	}
+
+	// The receiver never answers, so the clicks were tracked without waiting for it and the
+	// webhooks that didn't fit in the queue were dropped
+	assert.Less(t, time.Since(start), time.Second)
+	assert.GreaterOrEqual(t, testutil.ToFloat64(metrics.ClickWebhooksDropped), dropped+1)
+}
@@ -0,0 +1,209 @@
+package store
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Code generator names accepted by NewCodeGenerator
+const (
+	// CodeGeneratorRandom generates random codes from an alphabet
+	CodeGeneratorRandom = "random"
+	// CodeGeneratorMemorable joins random words from a wordlist, e.g. happy-blue-otter
+	CodeGeneratorMemorable = "memorable"
+)
+
+// Base62Alphabet is the default alphabet of random codes. It avoids "-" and "_", which some
+// downstream systems mangle.
+const Base62Alphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+
+// DefaultCodeLength is the default length of random codes
+const DefaultCodeLength = 6
+
+var (
+	// ErrUnknownCodeGenerator is returned when configuring a code generator that does not exist
+	ErrUnknownCodeGenerator = errors.New("unknown code generator")
+	// ErrInvalidCodeLength is returned when configuring random codes with a non-positive length
+	ErrInvalidCodeLength = errors.New("code length must be greater than 0")
+	// ErrInvalidCodeAlphabet is returned when configuring random codes with an unusable alphabet
+	ErrInvalidCodeAlphabet = errors.New("code alphabet must have at least 2 unique characters from A-Z, a-z, 0-9, - and _")
+)
+
+// CodeGeneratorConfig selects and configures the strategy used to generate short codes
+type CodeGeneratorConfig struct {
+	// Name is CodeGeneratorRandom or CodeGeneratorMemorable
+	Name string
+	// Alphabet and Length configure random codes; they default to Base62Alphabet and DefaultCodeLength
+	Alphabet string
+	Length   int
+	// Words and WordCount configure memorable codes
+	Words     []string
+	WordCount int
+}
+
+// CodeGenerator produces a candidate short code. URLService checks the candidate is unused and
+// asks for another one if it is taken.
+type CodeGenerator func(ctx context.Context) (string, error)
+
+//go:embed wordlist.txt
+var defaultWordlist string
+
+// wordPattern restricts wordlist entries to characters that are safe in a URL path
+var wordPattern = regexp.MustCompile(`^[a-z0-9]+$`)
+
+// WithCodeGenerator sets the strategy used to generate short codes when no custom code is given
+func WithCodeGenerator(generator CodeGenerator) Option {
+	return func(s *URLService) {
+		s.codeGenerator = generator
+	}
+}
+
+// NewCodeGenerator returns the configured code generator. Random codes use cfg.Alphabet and
+// cfg.Length; memorable codes join cfg.WordCount words from cfg.Words.
+func NewCodeGenerator(cfg CodeGeneratorConfig) (CodeGenerator, error) {
+	switch cfg.Name {
+	case CodeGeneratorRandom:
+		alphabet, length := cfg.Alphabet, cfg.Length
+		if alphabet == "" {
+			alphabet = Base62Alphabet
+		}
+		if length == 0 {
+			length = DefaultCodeLength
+		}
+		return RandomCodeGenerator(alphabet, length)
+	case CodeGeneratorMemorable:
+		if len(cfg.Words) == 0 {
+			return nil, errors.New("memorable codes need a non-empty wordlist")
+		}
+		if cfg.WordCount < 1 {
+			return nil, fmt.Errorf("invalid word count for memorable codes: %d", cfg.WordCount)
+		}
+		return WordlistCodeGenerator(cfg.Words, cfg.WordCount, "-"), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownCodeGenerator, cfg.Name)
+	}
+}
+
+// RandomCodeGenerator generates random codes of the given length whose characters are drawn uniformly
+// from alphabet. Duplicate characters in alphabet are ignored.
+func RandomCodeGenerator(alphabet string, length int) (CodeGenerator, error) {
+	if length <= 0 {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidCodeLength, length)
+	}
+	chars, err := parseAlphabet(alphabet)
+	if err != nil {
+		return nil, err
+	}
+	return alphabetCodeGenerator(chars, length), nil
+}
+
+// parseAlphabet returns the unique characters of alphabet, which must be at least 2 characters
+// that are valid in custom codes
+func parseAlphabet(alphabet string) ([]rune, error) {
+	var chars []rune
+	seen := make(map[rune]bool)
+	for _, char := range alphabet {
+		if seen[char] {
+			continue
+		}
+		// Generated codes must pass the same checks as custom codes
+		if !customCodePattern.MatchString(string(char)) {
+			return nil, fmt.Errorf("%w: invalid character %q", ErrInvalidCodeAlphabet, char)
+		}
+		seen[char] = true
+		chars = append(chars, char)
+	}
+	if len(chars) < 2 {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidCodeAlphabet, alphabet)
+	}
+	return chars, nil
+}
+
+// alphabetCodeGenerator generates random codes from a validated alphabet
+func alphabetCodeGenerator(chars []rune, length int) CodeGenerator {
+	return func(ctx context.Context) (string, error) {
+		return randomCode(chars, length)
+	}
+}
+
+// randomCode draws length characters uniformly from chars
+func randomCode(chars []rune, length int) (string, error) {
+	size := big.NewInt(int64(len(chars)))
+	code := make([]rune, length)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, size)
+		if err != nil {
+			return "", err
+		}
+		code[i] = chars[n.Int64()]
+	}
+	return string(code), nil
+}
+
+// WordlistCodeGenerator generates memorable codes by joining count random words with separator
+func WordlistCodeGenerator(words []string, count int, separator string) CodeGenerator {
+	return func(ctx context.Context) (string, error) {
+		picked := make([]string, count)
+		for i := range picked {
+			n, err := rand.Int(rand.Reader, big.NewInt(int64(len(words))))
+			if err != nil {
+				return "", err
+			}
+			picked[i] = words[n.Int64()]
+		}
+		return strings.Join(picked, separator), nil
+	}
+}
+
+// DefaultWordlist returns the bundled wordlist for memorable codes
+func DefaultWordlist() []string {
+	words, err := parseWordlist(strings.NewReader(defaultWordlist))
+	if err != nil {
+		panic(err)
+	}
+	return words
+}
+
+// LoadWordlist reads a wordlist file with one word per line. Blank lines and lines starting
+// with # are ignored; words may only contain lowercase letters and digits.
+func LoadWordlist(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return parseWordlist(f)
+}
+
+func parseWordlist(r io.Reader) ([]string, error) {
+	var words []string
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		word := strings.TrimSpace(scanner.Text())
+		if word == "" || strings.HasPrefix(word, "#") || seen[word] {
+			continue
+		}
+		if !wordPattern.MatchString(word) {
+			return nil, fmt.Errorf("invalid word in wordlist: %q", word)
+		}
+		seen[word] = true
+		words = append(words, word)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(words) == 0 {
+		return nil, errors.New("wordlist is empty")
+	}
+	return words, nil
+}
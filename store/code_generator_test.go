@@ -0,0 +1,211 @@
+package store
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWordlistCodeGenerator(t *testing.T) {
+	ctx := context.Background()
+	words := DefaultWordlist()
+	require.NotEmpty(t, words)
+
+	inList := make(map[string]bool, len(words))
+	for _, w := range words {
+		inList[w] = true
+	}
+
+	generator, err := NewCodeGenerator(CodeGeneratorConfig{Name: CodeGeneratorMemorable, Words: words, WordCount: 4})
+	require.NoError(t, err)
+
+	t.Run("Codes are built from the wordlist", func(t *testing.T) {
+		for i := 0; i < 100; i++ {
+			code, err := generator(ctx)
+			require.NoError(t, err)
+			parts := strings.Split(code, "-")
+			assert.Len(t, parts, 4)
+			for _, part := range parts {
+				assert.True(t, inList[part], "unexpected word %q in %q", part, code)
+			}
+		}
+	})
+
+	t.Run("Codes are unique across many generations", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		service := NewURLService(mockRepo, nil, WithCodeGenerator(generator))
+		mockRepo.On("GetByShortIncludingDeleted", ctx, mock.AnythingOfType("string")).Return(nil, ErrURLNotFound)
+
+		seen := make(map[string]bool)
+		for i := 0; i < 500; i++ {
+			code, err := service.generateShortURL(ctx)
+			require.NoError(t, err)
+			assert.False(t, seen[code], "duplicate code %q", code)
+			seen[code] = true
+		}
+	})
+}
+
+func TestGenerateShortURLRetriesTakenCodes(t *testing.T) {
+	ctx := context.Background()
+	codes := []string{"happy-blue-otter", "calm-green-fox"}
+	calls := 0
+	generator := func(ctx context.Context) (string, error) {
+		code := codes[calls%len(codes)]
+		calls++
+		return code, nil
+	}
+
+	// Soft-deleted URLs still hold their codes
+	deletedAt := time.Now()
+	mockRepo := new(MockURLRepository)
+	service := NewURLService(mockRepo, nil, WithCodeGenerator(generator))
+	mockRepo.On("GetByShortIncludingDeleted", ctx, "happy-blue-otter").Return(&models.URL{Short: "happy-blue-otter", DeletedAt: &deletedAt}, nil)
+	mockRepo.On("GetByShortIncludingDeleted", ctx, "calm-green-fox").Return(nil, ErrURLNotFound)
+
+	code, err := service.generateShortURL(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "calm-green-fox", code)
+	assert.Equal(t, 2, calls)
+}
+
+func TestGenerateShortURLSkipsBannedWords(t *testing.T) {
+	ctx := context.Background()
+	codes := []string{"xFuCkx", "a5h1tz", "clean1"}
+	calls := 0
+	generator := func(ctx context.Context) (string, error) {
+		code := codes[calls%len(codes)]
+		calls++
+		return code, nil
+	}
+
+	t.Run("Default list", func(t *testing.T) {
+		calls = 0
+		mockRepo := new(MockURLRepository)
+		service := NewURLService(mockRepo, nil, WithCodeGenerator(generator))
+		mockRepo.On("GetByShortIncludingDeleted", ctx, "clean1").Return(nil, ErrURLNotFound)
+
+		code, err := service.generateShortURL(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "clean1", code)
+		assert.Equal(t, 3, calls)
+		mockRepo.AssertNumberOfCalls(t, "GetByShortIncludingDeleted", 1)
+	})
+
+	t.Run("Configured list", func(t *testing.T) {
+		calls = 0
+		mockRepo := new(MockURLRepository)
+		service := NewURLService(mockRepo, nil, WithCodeGenerator(generator), WithBannedWords(" Clean "))
+		mockRepo.On("GetByShortIncludingDeleted", ctx, "xFuCkx").Return(nil, ErrURLNotFound)
+
+		code, err := service.generateShortURL(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "xFuCkx", code)
+		assert.False(t, service.containsBannedWord("a5h1tz"))
+		assert.True(t, service.containsBannedWord("xxcLEANxx"))
+	})
+
+	t.Run("Only banned codes", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		service := NewURLService(mockRepo, nil, WithCodeGenerator(func(ctx context.Context) (string, error) {
+			return "sh1t00", nil
+		}))
+
+		_, err := service.generateShortURL(ctx)
+		assert.ErrorIs(t, err, ErrCodeGenerationFailed)
+		mockRepo.AssertNotCalled(t, "GetByShortIncludingDeleted", mock.Anything, mock.Anything)
+	})
+}
+
+func TestNewCodeGenerator(t *testing.T) {
+	_, err := NewCodeGenerator(CodeGeneratorConfig{Name: "nope", Words: DefaultWordlist(), WordCount: 3})
+	assert.ErrorIs(t, err, ErrUnknownCodeGenerator)
+
+	_, err = NewCodeGenerator(CodeGeneratorConfig{Name: CodeGeneratorMemorable, WordCount: 3})
+	assert.Error(t, err)
+
+	_, err = NewCodeGenerator(CodeGeneratorConfig{Name: CodeGeneratorMemorable, Words: DefaultWordlist()})
+	assert.Error(t, err)
+
+	random, err := NewCodeGenerator(CodeGeneratorConfig{Name: CodeGeneratorRandom})
+	require.NoError(t, err)
+	code, err := random(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, code, DefaultCodeLength)
+	assert.Regexp(t, "^[A-Za-z0-9]+$", code)
+
+	_, err = NewCodeGenerator(CodeGeneratorConfig{Name: CodeGeneratorRandom, Length: -1})
+	assert.ErrorIs(t, err, ErrInvalidCodeLength)
+}
+
+func TestRandomCodeGenerator(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Honors alphabet and length", func(t *testing.T) {
+		generator, err := RandomCodeGenerator("abc", 10)
+		require.NoError(t, err)
+		for i := 0; i < 100; i++ {
+			code, err := generator(ctx)
+			require.NoError(t, err)
+			assert.Regexp(t, "^[abc]{10}$", code)
+		}
+	})
+
+	t.Run("Rejects invalid configuration", func(t *testing.T) {
+		_, err := RandomCodeGenerator(Base62Alphabet, 0)
+		assert.ErrorIs(t, err, ErrInvalidCodeLength)
+
+		_, err = RandomCodeGenerator("aaaa", 6)
+		assert.ErrorIs(t, err, ErrInvalidCodeAlphabet)
+
+		_, err = RandomCodeGenerator("", 6)
+		assert.ErrorIs(t, err, ErrInvalidCodeAlphabet)
+
+		_, err = RandomCodeGenerator("ab/", 6)
+		assert.ErrorIs(t, err, ErrInvalidCodeAlphabet)
+	})
+
+	t.Run("Retries taken codes", func(t *testing.T) {
+		generator, err := RandomCodeGenerator("ab", 1)
+		require.NoError(t, err)
+
+		// The first code is taken, the second one is free
+		mockRepo := new(MockURLRepository)
+		service := NewURLService(mockRepo, nil, WithCodeGenerator(generator))
+		mockRepo.On("GetByShortIncludingDeleted", ctx, mock.AnythingOfType("string")).Return(&models.URL{Short: "a"}, nil).Once()
+		mockRepo.On("GetByShortIncludingDeleted", ctx, mock.AnythingOfType("string")).Return(nil, ErrURLNotFound)
+
+		code, err := service.generateShortURL(ctx)
+		require.NoError(t, err)
+		assert.Contains(t, []string{"a", "b"}, code)
+		mockRepo.AssertNumberOfCalls(t, "GetByShortIncludingDeleted", 2)
+	})
+}
+
+func TestLoadWordlist(t *testing.T) {
+	dir := t.TempDir()
+
+	valid := filepath.Join(dir, "valid.txt")
+	require.NoError(t, os.WriteFile(valid, []byte("# comment\nhappy\n\n  otter \nhappy\n"), 0o644))
+	words, err := LoadWordlist(valid)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"happy", "otter"}, words)
+
+	invalid := filepath.Join(dir, "invalid.txt")
+	require.NoError(t, os.WriteFile(invalid, []byte("happy\nBlue Otter\n"), 0o644))
+	_, err = LoadWordlist(invalid)
+	assert.Error(t, err)
+
+	empty := filepath.Join(dir, "empty.txt")
+	require.NoError(t, os.WriteFile(empty, []byte("# nothing\n"), 0o644))
+	_, err = LoadWordlist(empty)
+	assert.Error(t, err)
+}
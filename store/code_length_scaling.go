@@ -0,0 +1,120 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ErrInvalidScaleThreshold is returned when configuring code length scaling with a threshold outside (0, 1)
+var ErrInvalidScaleThreshold = errors.New("code length scale threshold must be between 0 and 1")
+
+// CodeLengthScaler generates random codes whose length grows as links fill the keyspace of the
+// current length, so generated codes rarely collide. The length never shrinks.
+type CodeLengthScaler struct {
+	chars     []rune
+	threshold float64
+	length    atomic.Int64
+}
+
+// NewCodeLengthScaler creates a scaler generating codes from alphabet, starting at length. Once the
+// active links reach threshold (e.g. 0.01) of the number of possible codes, the length grows by one.
+func NewCodeLengthScaler(alphabet string, length int, threshold float64) (*CodeLengthScaler, error) {
+	if length <= 0 {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidCodeLength, length)
+	}
+	if threshold <= 0 || threshold >= 1 {
+		return nil, fmt.Errorf("%w: %g", ErrInvalidScaleThreshold, threshold)
+	}
+	chars, err := parseAlphabet(alphabet)
+	if err != nil {
+		return nil, err
+	}
+
+	scaler := &CodeLengthScaler{chars: chars, threshold: threshold}
+	scaler.length.Store(int64(length))
+	return scaler, nil
+}
+
+// Length returns the current length of generated codes
+func (c *CodeLengthScaler) Length() int {
+	return int(c.length.Load())
+}
+
+// Generate is a CodeGenerator producing codes of the current length
+func (c *CodeLengthScaler) Generate(ctx context.Context) (string, error) {
+	return randomCode(c.chars, c.Length())
+}
+
+// Observe grows the code length until activeURLs are below the threshold share of its keyspace,
+// and returns the resulting length
+func (c *CodeLengthScaler) Observe(activeURLs int64) int {
+	length := c.Length()
+	for length < maxCustomCodeLength && float64(activeURLs) >= c.threshold*math.Pow(float64(len(c.chars)), float64(length)) {
+		length++
+	}
+	c.length.Store(int64(length))
+	return length
+}
+
+// WithCodeLengthScaling generates codes with scaler, whose length ScaleCodeLength keeps up to date
+func WithCodeLengthScaling(scaler *CodeLengthScaler) Option {
+	return func(s *URLService) {
+		s.codeLengthScaler = scaler
+		s.codeGenerator = scaler.Generate
+	}
+}
+
+// CodeLength returns the current length of generated random codes, or 0 if it isn't scaled
+func (s *URLService) CodeLength() int {
+	if s.codeLengthScaler == nil {
+		return 0
+	}
+	return s.codeLengthScaler.Length()
+}
+
+// ScaleCodeLength counts the active links and grows the length of generated codes if they fill too
+// much of the current keyspace
+func (s *URLService) ScaleCodeLength(ctx context.Context) error {
+	if s.codeLengthScaler == nil {
+		return nil
+	}
+	log.Debug().Int("length", s.codeLengthScaler.Length()).Msg("Scaling code length")
+
+	active, err := s.db.CountActiveURLs(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to count active URLs")
+		return err
+	}
+
+	previous := s.codeLengthScaler.Length()
+	length := s.codeLengthScaler.Observe(active)
+	if length != previous {
+		log.Info().Int64("active_urls", active).Int("previous_length", previous).Int("length", length).Msg("Code length increased")
+	} else {
+		log.Debug().Int64("active_urls", active).Int("length", length).Msg("Code length unchanged")
+	}
+	return nil
+}
+
+// MaintainCodeLength scales the code length now and then every interval, until ctx is cancelled
+func (s *URLService) MaintainCodeLength(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		// Errors are logged by ScaleCodeLength; the next tick retries
+		_ = s.ScaleCodeLength(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
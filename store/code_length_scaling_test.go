@@ -0,0 +1,66 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodeLengthScaler(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Rejects invalid configuration", func(t *testing.T) {
+		_, err := NewCodeLengthScaler(Base62Alphabet, 6, 0)
+		assert.ErrorIs(t, err, ErrInvalidScaleThreshold)
+		_, err = NewCodeLengthScaler(Base62Alphabet, 6, 1)
+		assert.ErrorIs(t, err, ErrInvalidScaleThreshold)
+		_, err = NewCodeLengthScaler(Base62Alphabet, 0, 0.5)
+		assert.ErrorIs(t, err, ErrInvalidCodeLength)
+		_, err = NewCodeLengthScaler("a", 6, 0.5)
+		assert.ErrorIs(t, err, ErrInvalidCodeAlphabet)
+	})
+
+	t.Run("Crossing the threshold increases the generated length", func(t *testing.T) {
+		// 2 characters of a 10-character alphabet give 100 codes, so the threshold is 50 links
+		scaler, err := NewCodeLengthScaler("0123456789", 2, 0.5)
+		require.NoError(t, err)
+
+		assert.Equal(t, 2, scaler.Observe(49))
+		code, err := scaler.Generate(ctx)
+		require.NoError(t, err)
+		assert.Len(t, code, 2)
+
+		assert.Equal(t, 3, scaler.Observe(50))
+		code, err = scaler.Generate(ctx)
+		require.NoError(t, err)
+		assert.Len(t, code, 3)
+
+		// Far past the threshold the length grows several steps at once, and it never shrinks
+		assert.Equal(t, 5, scaler.Observe(49999))
+		assert.Equal(t, 5, scaler.Observe(0))
+	})
+
+	t.Run("Service scales from the count of active URLs", func(t *testing.T) {
+		scaler, err := NewCodeLengthScaler("0123456789", 2, 0.5)
+		require.NoError(t, err)
+		mockRepo := new(MockURLRepository)
+		service := NewURLService(mockRepo, nil, WithCodeLengthScaling(scaler))
+		mockRepo.On("CountActiveURLs", ctx).Return(int64(60), nil)
+
+		assert.Equal(t, 2, service.CodeLength())
+		require.NoError(t, service.ScaleCodeLength(ctx))
+		assert.Equal(t, 3, service.CodeLength())
+
+		code, err := service.codeGenerator(ctx)
+		require.NoError(t, err)
+		assert.Len(t, code, 3)
+	})
+
+	t.Run("Unscaled services report no code length", func(t *testing.T) {
+		service := NewURLService(new(MockURLRepository), nil)
+		assert.Zero(t, service.CodeLength())
+		assert.NoError(t, service.ScaleCodeLength(ctx))
+	})
+}
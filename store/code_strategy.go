@@ -0,0 +1,87 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+)
+
+// CodeStrategy decides how CreateShortURL generates codes for links without a custom code
+type CodeStrategy string
+
+const (
+	// CodeStrategyRandom generates codes with the configured CodeGenerator, retrying codes that are taken
+	CodeStrategyRandom CodeStrategy = "random"
+	// CodeStrategySequential encodes the ID of the new row in base62. Codes never collide and stay short
+	// (6 characters cover the first 56 billion links), but they increase monotonically: anyone holding a
+	// code can enumerate other links by counting up or down from it and estimate how many links exist.
+	// Use it only when links aren't secret.
+	CodeStrategySequential CodeStrategy = "sequential"
+)
+
+// ParseCodeStrategy validates a code strategy name
+func ParseCodeStrategy(name string) (CodeStrategy, error) {
+	switch strategy := CodeStrategy(name); strategy {
+	case CodeStrategyRandom, CodeStrategySequential:
+		return strategy, nil
+	default:
+		return "", fmt.Errorf("unknown code strategy: %q", name)
+	}
+}
+
+// WithCodeStrategy sets how CreateShortURL generates codes
+func WithCodeStrategy(strategy CodeStrategy) Option {
+	return func(s *URLService) {
+		s.codeStrategy = strategy
+	}
+}
+
+// EncodeBase62 encodes a non-negative ID with Base62Alphabet, most significant digit first
+func EncodeBase62(id int64) string {
+	if id == 0 {
+		return Base62Alphabet[:1]
+	}
+	var digits []byte
+	for ; id > 0; id /= 62 {
+		digits = append(digits, Base62Alphabet[id%62])
+	}
+	for i, j := 0, len(digits)-1; i < j; i, j = i+1, j-1 {
+		digits[i], digits[j] = digits[j], digits[i]
+	}
+	return string(digits)
+}
+
+// generateSequentialShortURL reserves the ID of the new URL and returns it with its base62 code.
+// An ID whose code is already taken, e.g. by a custom code, is skipped.
+func (s *URLService) generateSequentialShortURL(ctx context.Context) (int64, string, error) {
+	log.Debug().Msg("Generating sequential short URL")
+
+	for i := 0; i < 5; i++ { // Try up to 5 times
+		id, err := s.db.NextURLID(ctx)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to reserve URL ID")
+			return 0, "", err
+		}
+
+		short := EncodeBase62(id)
+		if s.containsBannedWord(short) {
+			log.Debug().Int64("id", id).Str("short", short).Msg("Sequential short code contains a banned word, skipping ID")
+			continue
+		}
+
+		_, err = s.GetByShort(ctx, short)
+		if errors.Is(err, ErrURLNotFound) {
+			log.Debug().Int64("id", id).Str("short", short).Msg("Sequential short code is available")
+			return id, short, nil
+		} else if err != nil {
+			log.Error().Err(err).Str("short", short).Msg("Error checking if short code exists")
+		} else {
+			log.Debug().Int64("id", id).Str("short", short).Msg("Sequential short code already in use, skipping ID")
+		}
+	}
+
+	log.Error().Msg("Failed to generate unique sequential short URL after 5 attempts")
+	return 0, "", ErrCodeGenerationFailed
+}
@@ -0,0 +1,96 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeBase62(t *testing.T) {
+	cases := map[int64]string{
+		0:           "A",
+		1:           "B",
+		61:          "9",
+		62:          "BA",
+		3843:        "99",
+		56800235583: "999999",
+	}
+	for id, want := range cases {
+		assert.Equal(t, want, EncodeBase62(id), "id %d", id)
+	}
+}
+
+func TestParseCodeStrategy(t *testing.T) {
+	strategy, err := ParseCodeStrategy("sequential")
+	require.NoError(t, err)
+	assert.Equal(t, CodeStrategySequential, strategy)
+
+	_, err = ParseCodeStrategy("nope")
+	assert.Error(t, err)
+}
+
+func TestSequentialCodeStrategy(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Code encodes the reserved ID", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		service := NewURLService(mockRepo, nil, WithCodeStrategy(CodeStrategySequential))
+		mockRepo.On("NextURLID", ctx).Return(int64(62), nil)
+		mockRepo.On("GetByShort", ctx, "BA").Return(nil, ErrURLNotFound)
+		mockRepo.On("Create", ctx, mock.MatchedBy(func(u *models.URL) bool {
+			return u.ID == 62 && u.Short == "BA"
+		})).Return(&models.URL{ID: 62, Short: "BA", Original: "https://example.com"}, nil)
+
+		url, err := service.CreateShortURL(ctx, "https://example.com", "", "", 0, "")
+		require.NoError(t, err)
+		assert.Equal(t, "BA", url.Short)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Initial clicks are seeded on sequential codes", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		service := NewURLService(mockRepo, nil, WithCodeStrategy(CodeStrategySequential))
+		mockRepo.On("NextURLID", ctx).Return(int64(62), nil)
+		mockRepo.On("GetByShort", ctx, "BA").Return(nil, ErrURLNotFound)
+		mockRepo.On("Create", ctx, mock.MatchedBy(func(u *models.URL) bool {
+			return u.ID == 62 && u.Clicks == 42
+		})).Return(&models.URL{ID: 62, Short: "BA", Clicks: 42}, nil)
+
+		url, err := service.CreateShortURL(ctx, "https://example.com", "", "", 0, "", WithInitialClicks(42))
+		require.NoError(t, err)
+		assert.Equal(t, int64(42), url.Clicks)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("IDs whose code is taken are skipped", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		service := NewURLService(mockRepo, nil, WithCodeStrategy(CodeStrategySequential))
+		mockRepo.On("NextURLID", ctx).Return(int64(1), nil).Once()
+		mockRepo.On("NextURLID", ctx).Return(int64(2), nil).Once()
+		mockRepo.On("GetByShort", ctx, "B").Return(&models.URL{Short: "B", CreatedAt: time.Now()}, nil)
+		mockRepo.On("GetByShort", ctx, "C").Return(nil, ErrURLNotFound)
+
+		id, short, err := service.generateSequentialShortURL(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), id)
+		assert.Equal(t, "C", short)
+	})
+
+	t.Run("Custom codes bypass the strategy", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		service := NewURLService(mockRepo, nil, WithCodeStrategy(CodeStrategySequential))
+		mockRepo.On("GetByShort", ctx, "custom").Return(nil, ErrURLNotFound)
+		mockRepo.On("Create", ctx, mock.MatchedBy(func(u *models.URL) bool {
+			return u.ID == 0 && u.Short == "custom"
+		})).Return(&models.URL{ID: 7, Short: "custom"}, nil)
+
+		_, err := service.CreateShortURL(ctx, "https://example.com", "custom", "", 0, "")
+		require.NoError(t, err)
+		mockRepo.AssertNotCalled(t, "NextURLID", ctx)
+	})
+}
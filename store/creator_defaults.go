@@ -0,0 +1,81 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/rs/zerolog/log"
+)
+
+// CreatorDefaultsRepository stores per-creator defaults for new links
+type CreatorDefaultsRepository interface {
+	// GetCreatorDefaults returns a creator's defaults, or ErrCreatorDefaultsNotFound if none are set
+	GetCreatorDefaults(ctx context.Context, creatorReference string) (*models.CreatorDefaults, error)
+	// SetCreatorDefaults creates or replaces a creator's defaults and returns the stored record
+	SetCreatorDefaults(ctx context.Context, defaults *models.CreatorDefaults) (*models.CreatorDefaults, error)
+}
+
+// WithCreatorDefaults applies per-creator defaults from repo to new links
+func WithCreatorDefaults(repo CreatorDefaultsRepository) Option {
+	return func(s *URLService) {
+		s.creatorDefaults = repo
+	}
+}
+
+// GetCreatorDefaults returns the defaults applied to a creator's new links
+func (s *URLService) GetCreatorDefaults(ctx context.Context, creatorReference string) (*models.CreatorDefaults, error) {
+	log.Debug().Str("creator_reference", creatorReference).Msg("Getting creator defaults")
+
+	if s.creatorDefaults == nil {
+		return nil, ErrCreatorDefaultsNotFound
+	}
+	defaults, err := s.creatorDefaults.GetCreatorDefaults(ctx, creatorReference)
+	if err != nil {
+		if !errors.Is(err, ErrCreatorDefaultsNotFound) {
+			log.Error().Err(err).Str("creator_reference", creatorReference).Msg("Failed to get creator defaults")
+		}
+		return nil, err
+	}
+
+	log.Info().Str("creator_reference", creatorReference).Msg("Creator defaults retrieved successfully")
+	return defaults, nil
+}
+
+// SetCreatorDefaults creates or replaces the defaults applied to a creator's new links
+func (s *URLService) SetCreatorDefaults(ctx context.Context, defaults *models.CreatorDefaults) (*models.CreatorDefaults, error) {
+	log.Debug().Str("creator_reference", defaults.CreatorReference).Msg("Setting creator defaults")
+
+	if s.creatorDefaults == nil {
+		return nil, errors.New("creator defaults are not configured")
+	}
+	if defaults.Expiry < 0 || defaults.RateLimit < 0 {
+		return nil, ErrInvalidCreatorDefaults
+	}
+	if len(defaults.RedirectHeaders) == 0 {
+		defaults.RedirectHeaders = nil
+	}
+	defaults.UpdatedAt = time.Now()
+
+	stored, err := s.creatorDefaults.SetCreatorDefaults(ctx, defaults)
+	if err != nil {
+		log.Error().Err(err).Str("creator_reference", defaults.CreatorReference).Msg("Failed to set creator defaults")
+		return nil, err
+	}
+
+	log.Info().Str("creator_reference", stored.CreatorReference).Msg("Creator defaults set successfully")
+	return stored, nil
+}
+
+// defaultsFor returns the creator's defaults, or nil when the creator has none
+func (s *URLService) defaultsFor(ctx context.Context, creatorReference string) (*models.CreatorDefaults, error) {
+	if s.creatorDefaults == nil || creatorReference == "" {
+		return nil, nil
+	}
+	defaults, err := s.creatorDefaults.GetCreatorDefaults(ctx, creatorReference)
+	if errors.Is(err, ErrCreatorDefaultsNotFound) {
+		return nil, nil
+	}
+	return defaults, err
+}
@@ -0,0 +1,76 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// stubCreatorDefaults is an in-memory CreatorDefaultsRepository
+type stubCreatorDefaults map[string]*models.CreatorDefaults
+
+func (s stubCreatorDefaults) GetCreatorDefaults(ctx context.Context, creatorReference string) (*models.CreatorDefaults, error) {
+	defaults, ok := s[creatorReference]
+	if !ok {
+		return nil, ErrCreatorDefaultsNotFound
+	}
+	return defaults, nil
+}
+
+func (s stubCreatorDefaults) SetCreatorDefaults(ctx context.Context, defaults *models.CreatorDefaults) (*models.CreatorDefaults, error) {
+	s[defaults.CreatorReference] = defaults
+	return defaults, nil
+}
+
+func TestCreateShortURLCreatorDefaults(t *testing.T) {
+	ctx := context.Background()
+	defaults := stubCreatorDefaults{
+		"power-user": {CreatorReference: "power-user", Expiry: 3600, RateLimit: 10},
+	}
+
+	// create returns the URL that CreateShortURL saved to the repository
+	create := func(t *testing.T, creator string, expireAfter time.Duration, opts ...URLOption) *models.URL {
+		mockRepo := new(MockURLRepository)
+		service := NewURLService(mockRepo, nil, WithCreatorDefaults(defaults))
+		mockRepo.On("GetByShort", ctx, "custom").Return(nil, ErrURLNotFound)
+		var saved *models.URL
+		mockRepo.On("Create", ctx, mock.AnythingOfType("*models.URL")).Run(func(args mock.Arguments) {
+			saved = args.Get(1).(*models.URL)
+		}).Return(&models.URL{ID: 1, Short: "custom"}, nil)
+
+		_, err := service.CreateShortURL(ctx, "https://example.com", "custom", "", expireAfter, creator, opts...)
+		require.NoError(t, err)
+		return saved
+	}
+
+	t.Run("Applies default expiry when not specified", func(t *testing.T) {
+		created := create(t, "power-user", 0)
+		require.NotNil(t, created.ExpiresAt)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), *created.ExpiresAt, time.Minute)
+		assert.Equal(t, 10, created.RateLimit)
+	})
+
+	t.Run("Request overrides default expiry", func(t *testing.T) {
+		created := create(t, "power-user", 24*time.Hour, WithRateLimit(5))
+		require.NotNil(t, created.ExpiresAt)
+		assert.WithinDuration(t, time.Now().Add(24*time.Hour), *created.ExpiresAt, time.Minute)
+		assert.Equal(t, 5, created.RateLimit)
+	})
+
+	t.Run("Creators without defaults are unaffected", func(t *testing.T) {
+		created := create(t, "someone-else", 0)
+		assert.Nil(t, created.ExpiresAt)
+		assert.Zero(t, created.RateLimit)
+	})
+}
+
+func TestSetCreatorDefaultsRejectsNegativeValues(t *testing.T) {
+	service := NewURLService(new(MockURLRepository), nil, WithCreatorDefaults(stubCreatorDefaults{}))
+	_, err := service.SetCreatorDefaults(context.Background(), &models.CreatorDefaults{CreatorReference: "power-user", Expiry: -1})
+	assert.ErrorIs(t, err, ErrInvalidCreatorDefaults)
+}
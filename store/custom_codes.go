@@ -0,0 +1,191 @@
+package store
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// maxCustomCodeLength is the longest custom code accepted
+const maxCustomCodeLength = 64
+
+// minSuggestedCodeLength is the shortest code suggested when shortening a desired code
+const minSuggestedCodeLength = 3
+
+// customCodePattern restricts custom codes to characters that are safe in a URL path
+var customCodePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// defaultReservedCodes would shadow the server's own routes
+var defaultReservedCodes = []string{"api", "static", "health", "livez", "admin", "metrics"}
+
+// CodeSuggestion reports whether a desired custom code can be used and offers available alternatives
+type CodeSuggestion struct {
+	Code      string `json:"code"`
+	Available bool   `json:"available"`
+	// Reason explains why the code is unavailable: invalid, reserved or taken
+	Reason      string   `json:"reason,omitempty"`
+	Suggestions []string `json:"suggestions"`
+}
+
+// WithReservedCodes prevents the given codes from being used as custom codes, in addition to the
+// codes reserved for the server's own routes. Codes are matched case-insensitively.
+func WithReservedCodes(codes ...string) Option {
+	return func(s *URLService) {
+		for _, code := range codes {
+			s.reservedCodes[strings.ToLower(code)] = true
+		}
+	}
+}
+
+// ValidateCustomCode returns ErrInvalidCustomCode or ErrReservedCode if code can't be used as a custom code
+func (s *URLService) ValidateCustomCode(code string) error {
+	if len(code) > maxCustomCodeLength || !customCodePattern.MatchString(code) {
+		return ErrInvalidCustomCode
+	}
+	if s.reservedCodes[strings.ToLower(code)] {
+		return ErrReservedCode
+	}
+	return nil
+}
+
+// SuggestCodes checks whether desired is available as a custom code and suggests up to count
+// available alternatives: numbered variants, memorable variants and shortened variants
+func (s *URLService) SuggestCodes(ctx context.Context, desired string, count int) (*CodeSuggestion, error) {
+	log.Debug().Str("code", desired).Int("count", count).Msg("Suggesting custom codes")
+
+	result := &CodeSuggestion{Code: desired, Suggestions: []string{}}
+	switch err := s.ValidateCustomCode(desired); {
+	case errors.Is(err, ErrInvalidCustomCode):
+		result.Reason = "invalid"
+	case errors.Is(err, ErrReservedCode):
+		result.Reason = "reserved"
+	default:
+		available, err := s.codeAvailable(ctx, desired)
+		if err != nil {
+			return nil, err
+		}
+		if available {
+			result.Available = true
+		} else {
+			result.Reason = "taken"
+		}
+	}
+
+	base := sanitizeCode(desired)
+	seen := map[string]bool{desired: true}
+	for _, candidate := range s.codeCandidates(ctx, base) {
+		if len(result.Suggestions) >= count {
+			break
+		}
+		if seen[candidate] {
+			continue
+		}
+		seen[candidate] = true
+
+		ok, err := s.codeAvailable(ctx, candidate)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			result.Suggestions = append(result.Suggestions, candidate)
+		}
+	}
+
+	log.Info().
+		Str("code", desired).
+		Bool("available", result.Available).
+		Strs("suggestions", result.Suggestions).
+		Msg("Custom code suggestions generated")
+
+	return result, nil
+}
+
+// codeAvailable reports whether code is a valid, unreserved custom code that no URL uses, including
+// deleted and expired URLs whose codes are still held
+func (s *URLService) codeAvailable(ctx context.Context, code string) (bool, error) {
+	if s.ValidateCustomCode(code) != nil {
+		return false, nil
+	}
+	_, err := s.db.GetByShortIncludingDeleted(ctx, code)
+	if errors.Is(err, ErrURLNotFound) {
+		return true, nil
+	}
+	if err != nil {
+		log.Error().Err(err).Str("code", code).Msg("Failed to check custom code availability")
+		return false, err
+	}
+	return false, nil
+}
+
+// codeCandidates lists alternatives to base in order of preference
+func (s *URLService) codeCandidates(ctx context.Context, base string) []string {
+	var candidates []string
+	if base == "" {
+		// Nothing usable in the desired code, fall back to generated codes
+		for i := 0; i < 10; i++ {
+			if code, err := s.codeGenerator(ctx); err == nil {
+				candidates = append(candidates, code)
+			}
+		}
+		return candidates
+	}
+
+	// Numbered variants: sale2, sale3, ...
+	for n := 2; n <= 9; n++ {
+		candidates = append(candidates, truncateCode(base, len(strconv.Itoa(n)))+strconv.Itoa(n))
+	}
+
+	// Memorable variants: sale-otter, sale-happy, ...
+	words := DefaultWordlist()
+	for i := 0; i < 5; i++ {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(words))))
+		if err != nil {
+			break
+		}
+		word := words[n.Int64()]
+		candidates = append(candidates, truncateCode(base, len(word)+1)+"-"+word)
+	}
+
+	// Shortened variants: sal, ...
+	for length := len(base) - 1; length >= minSuggestedCodeLength; length-- {
+		candidates = append(candidates, base[:length])
+	}
+
+	// Random numbered variants in case every short number is taken
+	for i := 0; i < 5; i++ {
+		n, err := rand.Int(rand.Reader, big.NewInt(900))
+		if err != nil {
+			break
+		}
+		suffix := strconv.FormatInt(n.Int64()+100, 10)
+		candidates = append(candidates, truncateCode(base, len(suffix))+suffix)
+	}
+	return candidates
+}
+
+// sanitizeCode replaces characters not allowed in custom codes with '-' and trims the result
+func sanitizeCode(code string) string {
+	var b strings.Builder
+	for _, r := range code {
+		if r < 128 && customCodePattern.MatchString(string(r)) {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('-')
+		}
+	}
+	return strings.Trim(truncateCode(b.String(), 0), "-")
+}
+
+// truncateCode shortens code so that appending suffixLength characters stays within maxCustomCodeLength
+func truncateCode(code string, suffixLength int) string {
+	if limit := maxCustomCodeLength - suffixLength; len(code) > limit {
+		return code[:limit]
+	}
+	return code
+}
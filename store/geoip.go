@@ -0,0 +1,147 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	"github.com/oschwald/geoip2-golang"
+	"github.com/rs/zerolog/log"
+)
+
+// ErrInvalidIP is returned when looking up the location of a malformed IP address
+var ErrInvalidIP = errors.New("invalid IP address")
+
+// Click locations that aren't resolved by GeoIP
+const (
+	// LocationLocal is the location of clicks from private, loopback and link-local IPs
+	LocationLocal = "Local"
+	// DefaultUnknownLocation is the location of clicks whose IP couldn't be resolved
+	DefaultUnknownLocation = "Unknown"
+)
+
+// GeoLocator resolves the location of an IP address. Lookups return an empty name when the
+// location is unknown.
+type GeoLocator interface {
+	// LookupCountry returns the English name of the IP's country
+	LookupCountry(ip string) (string, error)
+	// LookupCity returns the English name of the IP's city
+	LookupCity(ip string) (string, error)
+}
+
+// NoopGeoLocator is a GeoLocator that never knows the location
+type NoopGeoLocator struct{}
+
+// LookupCountry always returns an empty name
+func (NoopGeoLocator) LookupCountry(ip string) (string, error) { return "", nil }
+
+// LookupCity always returns an empty name
+func (NoopGeoLocator) LookupCity(ip string) (string, error) { return "", nil }
+
+// MaxMindGeoLocator is a GeoLocator backed by a MaxMind GeoIP2 or GeoLite2 database. A country
+// database only resolves countries.
+type MaxMindGeoLocator struct {
+	reader *geoip2.Reader
+}
+
+// NewMaxMindGeoLocator opens the mmdb database at path
+func NewMaxMindGeoLocator(path string) (*MaxMindGeoLocator, error) {
+	reader, err := geoip2.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &MaxMindGeoLocator{reader: reader}, nil
+}
+
+// LookupCountry returns the English name of the IP's country
+func (l *MaxMindGeoLocator) LookupCountry(ip string) (string, error) {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return "", ErrInvalidIP
+	}
+	record, err := l.reader.Country(parsed)
+	if err != nil {
+		return "", err
+	}
+	return record.Country.Names["en"], nil
+}
+
+// LookupCity returns the English name of the IP's city
+func (l *MaxMindGeoLocator) LookupCity(ip string) (string, error) {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return "", ErrInvalidIP
+	}
+	record, err := l.reader.City(parsed)
+	if err != nil {
+		return "", err
+	}
+	return record.City.Names["en"], nil
+}
+
+// Close closes the database
+func (l *MaxMindGeoLocator) Close() error {
+	return l.reader.Close()
+}
+
+// WithGeoLocator resolves click locations from the visitor's IP with locator
+func WithGeoLocator(locator GeoLocator) Option {
+	return func(s *URLService) {
+		s.geoLocator = locator
+	}
+}
+
+// WithUnknownLocation sets the location recorded for clicks whose IP can't be resolved, instead of
+// DefaultUnknownLocation
+func WithUnknownLocation(location string) Option {
+	return func(s *URLService) {
+		if location != "" {
+			s.unknownLocation = location
+		}
+	}
+}
+
+// GeoLocationEnricher sets the click location to "City, Country" (or just the country) resolved
+// by the service's GeoLocator, or to LocationLocal for private, loopback and link-local IPs.
+// Malformed IPs and failed lookups leave the location unset.
+func (s *URLService) GeoLocationEnricher(ctx context.Context, click *ClickContext) error {
+	if click.Location != "" {
+		return nil
+	}
+
+	ip := net.ParseIP(click.IP)
+	if ip == nil {
+		return nil
+	}
+	if ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsUnspecified() {
+		click.Location = LocationLocal
+		return nil
+	}
+
+	country, err := s.geoLocator.LookupCountry(click.IP)
+	if err != nil || country == "" {
+		return err
+	}
+	city, err := s.geoLocator.LookupCity(click.IP)
+	if err != nil {
+		// Country databases can't resolve cities
+		log.Debug().Err(err).Str("ip", click.IP).Msg("City lookup failed, using country only")
+	}
+
+	click.Location = country
+	if city != "" {
+		click.Location = city + ", " + country
+	}
+	return nil
+}
+
+// locateClick resolves the click's location, falling back to the unknown location when the IP
+// can't be resolved. A failed lookup is only logged, so it never fails the click.
+func (s *URLService) locateClick(ctx context.Context, click *ClickContext) {
+	if err := s.GeoLocationEnricher(ctx, click); err != nil {
+		log.Warn().Err(err).Str("short", click.Short).Str("ip", click.IP).Msg("Click location lookup failed")
+	}
+	if click.Location == "" {
+		click.Location = s.unknownLocation
+	}
+}
@@ -0,0 +1,127 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeGeoLocator resolves the IPs in its maps and fails country and city lookups with countryErr
+// and cityErr
+type fakeGeoLocator struct {
+	countries  map[string]string
+	cities     map[string]string
+	countryErr error
+	cityErr    error
+}
+
+func (l fakeGeoLocator) LookupCountry(ip string) (string, error) {
+	return l.countries[ip], l.countryErr
+}
+
+func (l fakeGeoLocator) LookupCity(ip string) (string, error) { return l.cities[ip], l.cityErr }
+
+func TestGeoLocationEnricher(t *testing.T) {
+	ctx := context.Background()
+	locator := fakeGeoLocator{
+		countries: map[string]string{"81.2.69.142": "United Kingdom", "2.125.160.216": "United Kingdom", "10.0.0.1": "Nowhere"},
+		cities:    map[string]string{"81.2.69.142": "London"},
+	}
+	service := NewURLService(new(MockURLRepository), nil, WithGeoLocator(locator))
+
+	tests := []struct {
+		name     string
+		ip       string
+		location string
+	}{
+		{"City and country", "81.2.69.142", "London, United Kingdom"},
+		{"Country only", "2.125.160.216", "United Kingdom"},
+		{"Unknown IP", "8.8.8.8", "Unknown"},
+		{"Private IP", "10.0.0.1", "Local"},
+		{"Loopback", "127.0.0.1", "Local"},
+		{"Link-local IPv6", "fe80::1", "Local"},
+		{"Malformed", "not-an-ip", "Unknown"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			click := &ClickContext{IP: tt.ip}
+			service.enrichClick(ctx, click)
+			assert.Equal(t, tt.location, click.Location)
+		})
+	}
+
+	t.Run("Country database", func(t *testing.T) {
+		service := NewURLService(new(MockURLRepository), nil, WithGeoLocator(fakeGeoLocator{
+			countries: locator.countries,
+			cityErr:   errors.New("database has no cities"),
+		}))
+		click := &ClickContext{IP: "81.2.69.142"}
+		service.enrichClick(ctx, click)
+		assert.Equal(t, "United Kingdom", click.Location)
+	})
+
+	t.Run("Lookup error", func(t *testing.T) {
+		service := NewURLService(new(MockURLRepository), nil, WithGeoLocator(fakeGeoLocator{
+			countries:  locator.countries,
+			countryErr: errors.New("corrupt database"),
+		}))
+		click := &ClickContext{IP: "81.2.69.142"}
+		service.enrichClick(ctx, click)
+		assert.Equal(t, "Unknown", click.Location)
+	})
+
+	t.Run("Configured fallback", func(t *testing.T) {
+		service := NewURLService(new(MockURLRepository), nil, WithGeoLocator(locator), WithUnknownLocation("Elsewhere"))
+		for ip, location := range map[string]string{"8.8.8.8": "Elsewhere", "192.168.1.1": "Local", "81.2.69.142": "London, United Kingdom"} {
+			click := &ClickContext{IP: ip}
+			service.enrichClick(ctx, click)
+			assert.Equal(t, location, click.Location, ip)
+		}
+	})
+
+	t.Run("Default locator", func(t *testing.T) {
+		click := &ClickContext{IP: "81.2.69.142"}
+		NewURLService(new(MockURLRepository), nil).enrichClick(ctx, click)
+		assert.Equal(t, "Unknown", click.Location)
+	})
+}
+
+func TestRecordClickLocation(t *testing.T) {
+	ctx := context.Background()
+	url := &models.URL{ID: 1, Original: "https://example.com", Short: "abc"}
+
+	tests := []struct {
+		name     string
+		ip       string
+		locator  fakeGeoLocator
+		location string
+	}{
+		{"Resolvable IP", "81.2.69.142", fakeGeoLocator{countries: map[string]string{"81.2.69.142": "United Kingdom"}}, "United Kingdom"},
+		{"Private IP", "192.168.1.10", fakeGeoLocator{}, "Local"},
+		{"Lookup error", "81.2.69.142", fakeGeoLocator{countryErr: errors.New("corrupt database")}, "Somewhere"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockURLRepository)
+			mockRepo.On("HasRecentClick", ctx, "abc", tt.ip, "Chrome", "Desktop", mock.Anything).Return(false, nil)
+			mockRepo.On("GetByShort", ctx, "abc").Return(url, nil)
+			mockRepo.On("StoreClick", ctx, mock.MatchedBy(func(click *models.Click) bool {
+				return click.Location == tt.location
+			})).Return(nil)
+			service := NewURLService(mockRepo, nil, WithGeoLocator(tt.locator), WithUnknownLocation("Somewhere"))
+
+			require.NoError(t, service.RecordClick(ctx, "abc", tt.ip, "", "Chrome", "Desktop"))
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestNewMaxMindGeoLocatorMissingDatabase(t *testing.T) {
+	_, err := NewMaxMindGeoLocator("testdata/missing.mmdb")
+	require.Error(t, err)
+}
@@ -0,0 +1,76 @@
+package store
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// States of a dependency reported by CheckHealth
+const (
+	HealthOK          = "ok"
+	HealthUnavailable = "unavailable"
+)
+
+// HealthCheckTimeout bounds how long CheckHealth waits for each dependency to answer
+const HealthCheckTimeout = 2 * time.Second
+
+// Pinger is implemented by repositories that can check their connection to a server
+type Pinger interface {
+	// Ping returns an error if the server can't be reached
+	Ping(ctx context.Context) error
+}
+
+// HealthReport holds the state of each of the service's dependencies
+type HealthReport struct {
+	// Healthy is true if every dependency is reachable
+	Healthy bool
+	// Dependencies maps "database" and "cache" to HealthOK or HealthUnavailable. Backends that can't
+	// be pinged, such as the in-memory cache, are left out.
+	Dependencies map[string]string
+}
+
+// CheckHealth pings the database and cache concurrently, giving each HealthCheckTimeout to answer
+func (s *URLService) CheckHealth(ctx context.Context) HealthReport {
+	log.Debug().Msg("Checking dependency health")
+
+	pingers := map[string]Pinger{}
+	if pinger, ok := s.db.(Pinger); ok {
+		pingers["database"] = pinger
+	}
+	if pinger, ok := s.cache.(Pinger); ok {
+		pingers["cache"] = pinger
+	}
+
+	report := HealthReport{Healthy: true, Dependencies: make(map[string]string, len(pingers))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, pinger := range pingers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			pingCtx, cancel := context.WithTimeout(ctx, HealthCheckTimeout)
+			defer cancel()
+
+			status := HealthOK
+			if err := pinger.Ping(pingCtx); err != nil {
+				log.Error().Err(err).Str("dependency", name).Msg("Dependency health check failed")
+				status = HealthUnavailable
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Dependencies[name] = status
+			if status != HealthOK {
+				report.Healthy = false
+			}
+		}()
+	}
+	wg.Wait()
+
+	log.Info().Bool("healthy", report.Healthy).Interface("dependencies", report.Dependencies).Msg("Dependency health checked")
+
+	return report
+}
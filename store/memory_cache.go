@@ -0,0 +1,230 @@
+package store
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/fransfilastap/urlshortener/models"
+)
+
+// InMemoryCache implements an in-process LRU cache for URLs, bounded by size and TTL
+type InMemoryCache struct {
+	mu          sync.Mutex
+	size        int
+	ttl         time.Duration
+	notFoundTTL time.Duration
+	order       *list.List
+	byShort     map[string]*list.Element
+	byOriginal  map[string]string
+	now         func() time.Time
+
+	// redirects counts redirects per URL in the window starting at redirectsStart
+	redirects      map[string]int64
+	redirectsStart time.Time
+}
+
+// memoryCacheEntry is a cached URL together with its cache expiry. Not found entries only carry the
+// URL's short code.
+type memoryCacheEntry struct {
+	url       models.URL
+	expiresAt time.Time
+	notFound  bool
+}
+
+// Ensure InMemoryCache implements CacheRepositoryInterface
+var _ CacheRepositoryInterface = (*InMemoryCache)(nil)
+
+// NewInMemoryCache creates a new in-memory cache holding at most size URLs for up to ttl each.
+// A non-positive size or ttl disables the respective bound.
+func NewInMemoryCache(size int, ttl time.Duration) *InMemoryCache {
+	return &InMemoryCache{
+		size:       size,
+		ttl:        ttl,
+		order:      list.New(),
+		byShort:    make(map[string]*list.Element),
+		byOriginal: make(map[string]string),
+		now:        time.Now,
+		redirects:  make(map[string]int64),
+	}
+}
+
+// SetNotFoundTTL sets how long unknown short codes are remembered; 0 turns negative caching off
+func (c *InMemoryCache) SetNotFoundTTL(ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.notFoundTTL = ttl
+}
+
+// Set stores a URL in the cache for its own cache TTL, or the default TTL if it has none
+func (c *InMemoryCache) Set(ctx context.Context, url *models.URL) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &memoryCacheEntry{url: *url}
+	if url.CacheTTL > 0 {
+		entry.expiresAt = c.now().Add(time.Duration(url.CacheTTL) * time.Second)
+	} else if c.ttl > 0 {
+		entry.expiresAt = c.now().Add(c.ttl)
+	}
+	c.store(entry)
+	c.byOriginal[url.Original] = url.Short
+
+	return nil
+}
+
+// SetNotFound remembers that no URL has a short code for the not found TTL
+func (c *InMemoryCache) SetNotFound(ctx context.Context, short string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.notFoundTTL <= 0 {
+		return nil
+	}
+	c.store(&memoryCacheEntry{url: models.URL{Short: short}, expiresAt: c.now().Add(c.notFoundTTL), notFound: true})
+
+	return nil
+}
+
+// store adds or replaces the entry for a short code and evicts least recently used entries beyond the
+// size bound. The caller must hold the lock.
+func (c *InMemoryCache) store(entry *memoryCacheEntry) {
+	url := &entry.url
+	if element, ok := c.byShort[url.Short]; ok {
+		// Replace the existing entry and drop a stale original URL mapping
+		previous := element.Value.(*memoryCacheEntry)
+		if previous.url.Original != url.Original && c.byOriginal[previous.url.Original] == url.Short {
+			delete(c.byOriginal, previous.url.Original)
+		}
+		element.Value = entry
+		c.order.MoveToFront(element)
+	} else {
+		c.byShort[url.Short] = c.order.PushFront(entry)
+	}
+
+	// Evict least recently used entries beyond the size bound
+	for c.size > 0 && c.order.Len() > c.size {
+		c.removeElement(c.order.Back())
+	}
+}
+
+// GetByShort retrieves a URL by its short code from cache
+func (c *InMemoryCache) GetByShort(ctx context.Context, short string) (*models.URL, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.get(short)
+}
+
+// GetByOriginal retrieves a URL by its original URL from cache
+func (c *InMemoryCache) GetByOriginal(ctx context.Context, original string) (*models.URL, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	short, ok := c.byOriginal[original]
+	if !ok {
+		return nil, ErrURLNotFound
+	}
+
+	return c.get(short)
+}
+
+// IncrementClicks increments the click count for a URL in cache
+func (c *InMemoryCache) IncrementClicks(ctx context.Context, short string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, err := c.get(short); err != nil {
+		return err
+	}
+	c.byShort[short].Value.(*memoryCacheEntry).url.Clicks++
+
+	return nil
+}
+
+// Delete removes a URL from cache
+func (c *InMemoryCache) Delete(ctx context.Context, short string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.byShort[short]; ok {
+		c.removeElement(element)
+	}
+
+	return nil
+}
+
+// IncrementRedirects counts a redirect for a URL in the fixed window containing now and returns the window's count
+func (c *InMemoryCache) IncrementRedirects(ctx context.Context, short string, window time.Duration) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Start counting afresh once a new window begins
+	if start := c.now().Truncate(window); start.After(c.redirectsStart) {
+		c.redirects = make(map[string]int64)
+		c.redirectsStart = start
+	}
+	c.redirects[short]++
+
+	return c.redirects[short], nil
+}
+
+// Close releases the cached entries
+func (c *InMemoryCache) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.order.Init()
+	c.byShort = make(map[string]*list.Element)
+	c.byOriginal = make(map[string]string)
+	c.redirects = make(map[string]int64)
+
+	return nil
+}
+
+// Len returns the number of cached URLs, including entries that have expired but not yet been evicted
+func (c *InMemoryCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
+
+// get returns a copy of a live cached URL and marks it as recently used. The caller must hold the lock.
+func (c *InMemoryCache) get(short string) (*models.URL, error) {
+	element, ok := c.byShort[short]
+	if !ok {
+		return nil, ErrURLNotFound
+	}
+
+	entry := element.Value.(*memoryCacheEntry)
+	now := c.now()
+
+	// Drop entries past their cache TTL. Expired URLs stay until then so repeated lookups keep
+	// reporting the expiry.
+	if !entry.expiresAt.IsZero() && !now.Before(entry.expiresAt) {
+		c.removeElement(element)
+		return nil, ErrURLNotFound
+	}
+
+	c.order.MoveToFront(element)
+	if entry.notFound {
+		return nil, ErrCachedNotFound
+	}
+	if entry.url.IsExpiredAt(now) {
+		return nil, ErrURLExpired
+	}
+	url := entry.url
+	return &url, nil
+}
+
+// removeElement removes an entry from all indexes. The caller must hold the lock.
+func (c *InMemoryCache) removeElement(element *list.Element) {
+	entry := c.order.Remove(element).(*memoryCacheEntry)
+	delete(c.byShort, entry.url.Short)
+	if !entry.notFound && c.byOriginal[entry.url.Original] == entry.url.Short {
+		delete(c.byOriginal, entry.url.Original)
+	}
+}
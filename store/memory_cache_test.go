@@ -0,0 +1,240 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryCache(t *testing.T) {
+	ctx := context.Background()
+
+	// Test case 1: Least recently used URLs are evicted and then loaded from the database
+	t.Run("EvictionThroughService", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		cache := NewInMemoryCache(2, time.Hour)
+		service := NewURLService(mockRepo, cache)
+
+		first := &models.URL{ID: 1, Original: "https://example.com/1", Short: "first"}
+		second := &models.URL{ID: 2, Original: "https://example.com/2", Short: "second"}
+		third := &models.URL{ID: 3, Original: "https://example.com/3", Short: "third"}
+		mockRepo.On("GetByShort", ctx, "first").Return(first, nil).Twice()
+		mockRepo.On("GetByShort", ctx, "second").Return(second, nil).Once()
+		mockRepo.On("GetByShort", ctx, "third").Return(third, nil).Once()
+
+		// Load first and second, then touch first so second becomes least recently used
+		_, err := service.GetByShort(ctx, "first")
+		require.NoError(t, err)
+		_, err = service.GetByShort(ctx, "second")
+		require.NoError(t, err)
+		_, err = service.GetByShort(ctx, "first")
+		require.NoError(t, err)
+		mockRepo.AssertNumberOfCalls(t, "GetByShort", 2)
+
+		// Loading third evicts second
+		_, err = service.GetByShort(ctx, "third")
+		require.NoError(t, err)
+		assert.Equal(t, 2, cache.Len())
+		_, err = cache.GetByShort(ctx, "second")
+		assert.Equal(t, ErrURLNotFound, err)
+		_, err = cache.GetByOriginal(ctx, "https://example.com/2")
+		assert.Equal(t, ErrURLNotFound, err)
+
+		// first and third are still served from cache
+		_, err = service.GetByShort(ctx, "first")
+		require.NoError(t, err)
+		_, err = service.GetByShort(ctx, "third")
+		require.NoError(t, err)
+		mockRepo.AssertNumberOfCalls(t, "GetByShort", 3)
+	})
+
+	// Test case 2: Entries past their TTL are reloaded from the database
+	t.Run("TTLExpiryThroughService", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		cache := NewInMemoryCache(10, time.Minute)
+		now := time.Now()
+		cache.now = func() time.Time { return now }
+		service := NewURLService(mockRepo, cache)
+
+		url := &models.URL{ID: 1, Original: "https://example.com", Short: "abc123"}
+		mockRepo.On("GetByShort", ctx, "abc123").Return(url, nil)
+
+		_, err := service.GetByShort(ctx, "abc123")
+		require.NoError(t, err)
+		_, err = service.GetByShort(ctx, "abc123")
+		require.NoError(t, err)
+		mockRepo.AssertNumberOfCalls(t, "GetByShort", 1)
+
+		// Advance past the TTL
+		now = now.Add(time.Minute)
+		_, err = cache.GetByShort(ctx, "abc123")
+		assert.Equal(t, ErrURLNotFound, err)
+		_, err = service.GetByShort(ctx, "abc123")
+		require.NoError(t, err)
+		mockRepo.AssertNumberOfCalls(t, "GetByShort", 2)
+	})
+
+	// URLs with their own cache TTL expire on their own schedule
+	t.Run("PerURLTTL", func(t *testing.T) {
+		cache := NewInMemoryCache(10, time.Minute)
+		now := time.Now()
+		cache.now = func() time.Time { return now }
+
+		require.NoError(t, cache.Set(ctx, &models.URL{Original: "https://example.com/default", Short: "default"}))
+		require.NoError(t, cache.Set(ctx, &models.URL{Original: "https://example.com/short", Short: "shortttl", CacheTTL: 10}))
+		require.NoError(t, cache.Set(ctx, &models.URL{Original: "https://example.com/hot", Short: "hot", CacheTTL: 3600}))
+
+		now = now.Add(10 * time.Second)
+		_, err := cache.GetByShort(ctx, "shortttl")
+		assert.Equal(t, ErrURLNotFound, err)
+		_, err = cache.GetByShort(ctx, "default")
+		assert.NoError(t, err)
+
+		now = now.Add(time.Minute)
+		_, err = cache.GetByShort(ctx, "default")
+		assert.Equal(t, ErrURLNotFound, err)
+		_, err = cache.GetByShort(ctx, "hot")
+		assert.NoError(t, err)
+	})
+
+	// Test case 3: Expired URLs are never served from cache
+	t.Run("URLExpiry", func(t *testing.T) {
+		cases := []struct {
+			short     string
+			expiresAt *time.Time
+			found     bool
+		}{
+			{"expnil", nil, true},
+			{"expfuture", timePtr(time.Now().Add(time.Hour)), true},
+			{"exppast", timePtr(time.Now().Add(-time.Second)), false},
+		}
+		cache := NewInMemoryCache(10, time.Hour)
+		for _, tc := range cases {
+			url := &models.URL{Original: "https://example.com/" + tc.short, Short: tc.short, ExpiresAt: tc.expiresAt}
+			require.NoError(t, cache.Set(ctx, url))
+
+			_, err := cache.GetByShort(ctx, tc.short)
+			if tc.found {
+				assert.NoError(t, err, tc.short)
+			} else {
+				assert.Equal(t, ErrURLExpired, err, tc.short)
+				assert.ErrorIs(t, err, ErrURLNotFound, tc.short)
+			}
+		}
+		// Expired URLs stay cached so repeated lookups keep reporting the expiry
+		assert.Equal(t, 3, cache.Len())
+
+		_, err := cache.GetByShort(ctx, "missing")
+		assert.Equal(t, ErrURLNotFound, err)
+	})
+
+	// Test case 4: Click increments and deletes
+	t.Run("IncrementAndDelete", func(t *testing.T) {
+		cache := NewInMemoryCache(10, time.Hour)
+		url := &models.URL{Original: "https://example.com", Short: "abc123"}
+		require.NoError(t, cache.Set(ctx, url))
+
+		require.NoError(t, cache.IncrementClicks(ctx, "abc123"))
+		cached, err := cache.GetByOriginal(ctx, "https://example.com")
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), cached.Clicks)
+		assert.Equal(t, int64(0), url.Clicks)
+
+		require.NoError(t, cache.Delete(ctx, "abc123"))
+		_, err = cache.GetByShort(ctx, "abc123")
+		assert.Equal(t, ErrURLNotFound, err)
+		_, err = cache.GetByOriginal(ctx, "https://example.com")
+		assert.Equal(t, ErrURLNotFound, err)
+	})
+
+	// Test case 5: Redirects are counted per URL in fixed windows
+	t.Run("IncrementRedirects", func(t *testing.T) {
+		cache := NewInMemoryCache(10, time.Hour)
+		now := time.Date(2025, 1, 1, 12, 0, 10, 0, time.UTC)
+		cache.now = func() time.Time { return now }
+
+		for i := int64(1); i <= 3; i++ {
+			count, err := cache.IncrementRedirects(ctx, "abc123", time.Minute)
+			require.NoError(t, err)
+			assert.Equal(t, i, count)
+		}
+		count, err := cache.IncrementRedirects(ctx, "other", time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+
+		// The next window starts counting afresh
+		now = now.Add(50 * time.Second)
+		count, err = cache.IncrementRedirects(ctx, "abc123", time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+	})
+
+	// Test case 6: Unknown codes are remembered for the not found TTL
+	t.Run("NotFoundThroughService", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		cache := NewInMemoryCache(10, time.Hour)
+		cache.SetNotFoundTTL(10 * time.Second)
+		now := time.Now()
+		cache.now = func() time.Time { return now }
+		service := NewURLService(mockRepo, cache)
+
+		mockRepo.On("GetByShort", ctx, "missing").Return(nil, ErrURLNotFound)
+
+		// Only the first lookup reaches the database
+		for i := 0; i < 3; i++ {
+			_, err := service.GetByShort(ctx, "missing")
+			assert.Equal(t, ErrURLNotFound, err)
+		}
+		mockRepo.AssertNumberOfCalls(t, "GetByShort", 1)
+		_, err := cache.GetByShort(ctx, "missing")
+		assert.Equal(t, ErrCachedNotFound, err)
+
+		// The marker expires after the not found TTL
+		now = now.Add(10 * time.Second)
+		_, err = service.GetByShort(ctx, "missing")
+		assert.Equal(t, ErrURLNotFound, err)
+		mockRepo.AssertNumberOfCalls(t, "GetByShort", 2)
+
+		// Caching a URL with the code replaces the marker
+		require.NoError(t, cache.Set(ctx, &models.URL{Original: "https://example.com", Short: "missing"}))
+		found, err := service.GetByShort(ctx, "missing")
+		require.NoError(t, err)
+		assert.Equal(t, "https://example.com", found.Original)
+		mockRepo.AssertNumberOfCalls(t, "GetByShort", 2)
+
+		// Without a not found TTL nothing is remembered
+		off := NewInMemoryCache(10, time.Hour)
+		require.NoError(t, off.SetNotFound(ctx, "missing"))
+		_, err = off.GetByShort(ctx, "missing")
+		assert.Equal(t, ErrURLNotFound, err)
+	})
+
+	// Test case 7: Concurrent use stays within the size bound
+	t.Run("Concurrent", func(t *testing.T) {
+		cache := NewInMemoryCache(50, time.Hour)
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func(worker int) {
+				defer wg.Done()
+				for j := 0; j < 200; j++ {
+					short := fmt.Sprintf("w%d-%d", worker, j%75)
+					_ = cache.Set(ctx, &models.URL{Original: "https://example.com/" + short, Short: short})
+					_, _ = cache.GetByShort(ctx, short)
+					_ = cache.IncrementClicks(ctx, short)
+					if j%10 == 0 {
+						_ = cache.Delete(ctx, short)
+					}
+				}
+			}(i)
+		}
+		wg.Wait()
+		assert.LessOrEqual(t, cache.Len(), 50)
+	})
+}
@@ -0,0 +1,40 @@
+package store
+
+// PoolStats describes a connection pool, to help diagnose connection exhaustion
+type PoolStats struct {
+	TotalConns    int64 `json:"total_conns"`
+	AcquiredConns int64 `json:"acquired_conns"`
+	IdleConns     int64 `json:"idle_conns"`
+	MaxConns      int64 `json:"max_conns"`
+	// EmptyAcquires counts acquisitions that found no idle connection and had to wait or dial
+	EmptyAcquires int64 `json:"empty_acquires"`
+	// Timeouts counts acquisitions that gave up before getting a connection
+	Timeouts int64 `json:"timeouts"`
+}
+
+// PoolStatsProvider is implemented by repositories backed by a connection pool
+type PoolStatsProvider interface {
+	// Stats returns a snapshot of the connection pool
+	Stats() PoolStats
+}
+
+// ServiceStats holds the connection pool stats of the service's backends. Backends without a
+// connection pool, such as the in-memory cache, are left out.
+type ServiceStats struct {
+	Database *PoolStats `json:"database,omitempty"`
+	Cache    *PoolStats `json:"cache,omitempty"`
+}
+
+// Stats returns the connection pool stats of the database and cache
+func (s *URLService) Stats() ServiceStats {
+	var stats ServiceStats
+	if provider, ok := s.db.(PoolStatsProvider); ok {
+		dbStats := provider.Stats()
+		stats.Database = &dbStats
+	}
+	if provider, ok := s.cache.(PoolStatsProvider); ok {
+		cacheStats := provider.Stats()
+		stats.Cache = &cacheStats
+	}
+	return stats
+}
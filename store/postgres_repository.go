@@ -5,10 +5,12 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/fransfilastap/urlshortener/models"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -61,6 +63,19 @@ func NewPostgresRepository(connString string) (*PostgresRepository, error) {
 	return nil, fmt.Errorf("failed to connect to database after %d attempts: %w", maxRetries, err)
 }
 
+// urlColumns lists the urls columns in the order scanned by scanURL
+const urlColumns = "id, original, short, title, created_at, expires_at, clicks, creator_reference, deleted_at, rate_limit, last_accessed_at, redirect_headers, metadata, enabled, tags, disabled_redirect_url, random_target, notes, max_clicks, cache_ttl, seeded_clicks"
+
+// scanURL scans a row selected with urlColumns
+func scanURL(row pgx.Row) (*models.URL, error) {
+	url := &models.URL{}
+	err := row.Scan(&url.ID, &url.Original, &url.Short, &url.Title, &url.CreatedAt, &url.ExpiresAt, &url.Clicks, &url.CreatorReference, &url.DeletedAt, &url.RateLimit, &url.LastAccessedAt, &url.RedirectHeaders, &url.Metadata, &url.Enabled, &url.Tags, &url.DisabledRedirectURL, &url.RandomTarget, &url.Notes, &url.MaxClicks, &url.CacheTTL, &url.SeededClicks)
+	if err != nil {
+		return nil, err
+	}
+	return url, nil
+}
+
 // InitSchema initializes the database schema
 func (r *PostgresRepository) InitSchema(ctx context.Context) error {
 	_, err := r.pool.Exec(ctx, `
@@ -78,6 +93,25 @@ func (r *PostgresRepository) InitSchema(ctx context.Context) error {
 		CREATE INDEX IF NOT EXISTS idx_urls_short ON urls(short);
 		CREATE INDEX IF NOT EXISTS idx_urls_original ON urls(original);
 
+		-- Non-expiring URLs used to be stored with a zero timestamp instead of NULL
+		UPDATE urls SET expires_at = NULL WHERE expires_at = '0001-01-01 00:00:00';
+
+		ALTER TABLE urls ADD COLUMN IF NOT EXISTS rate_limit INTEGER NOT NULL DEFAULT 0;
+		ALTER TABLE urls ADD COLUMN IF NOT EXISTS last_accessed_at TIMESTAMP;
+		ALTER TABLE urls ADD COLUMN IF NOT EXISTS redirect_headers JSONB;
+		ALTER TABLE urls ADD COLUMN IF NOT EXISTS metadata JSONB;
+		ALTER TABLE urls ADD COLUMN IF NOT EXISTS enabled BOOLEAN NOT NULL DEFAULT TRUE;
+		ALTER TABLE urls ADD COLUMN IF NOT EXISTS tags TEXT[];
+		ALTER TABLE urls ADD COLUMN IF NOT EXISTS disabled_redirect_url TEXT NOT NULL DEFAULT '';
+		ALTER TABLE urls ADD COLUMN IF NOT EXISTS random_target BOOLEAN NOT NULL DEFAULT FALSE;
+		ALTER TABLE urls ADD COLUMN IF NOT EXISTS notes TEXT NOT NULL DEFAULT '';
+		ALTER TABLE urls ADD COLUMN IF NOT EXISTS max_clicks BIGINT NOT NULL DEFAULT 0;
+		ALTER TABLE urls ADD COLUMN IF NOT EXISTS cache_ttl INTEGER NOT NULL DEFAULT 0;
+		ALTER TABLE urls ADD COLUMN IF NOT EXISTS seeded_clicks BIGINT NOT NULL DEFAULT 0;
+		CREATE INDEX IF NOT EXISTS idx_urls_tags ON urls USING GIN (tags);
+		CREATE INDEX IF NOT EXISTS idx_urls_creator_reference ON urls(creator_reference);
+		CREATE INDEX IF NOT EXISTS idx_urls_search ON urls USING GIN ((`+urlSearchDocument+`));
+
 		CREATE TABLE IF NOT EXISTS clicks (
 			id SERIAL PRIMARY KEY,
 			url_id BIGINT NOT NULL REFERENCES urls(id) ON DELETE CASCADE,
@@ -90,6 +124,12 @@ func (r *PostgresRepository) InitSchema(ctx context.Context) error {
 		);
 		CREATE INDEX IF NOT EXISTS idx_clicks_url_id ON clicks(url_id);
 		CREATE INDEX IF NOT EXISTS idx_clicks_url_short ON clicks(url_short);
+		ALTER TABLE clicks ADD COLUMN IF NOT EXISTS resolve_ms DOUBLE PRECISION;
+		ALTER TABLE clicks ADD COLUMN IF NOT EXISTS target TEXT NOT NULL DEFAULT '';
+		ALTER TABLE clicks ADD COLUMN IF NOT EXISTS browser_version TEXT NOT NULL DEFAULT '';
+		ALTER TABLE clicks ADD COLUMN IF NOT EXISTS os TEXT NOT NULL DEFAULT '';
+		ALTER TABLE clicks ADD COLUMN IF NOT EXISTS referrer TEXT NOT NULL DEFAULT '';
+		ALTER TABLE clicks ADD COLUMN IF NOT EXISTS extra JSONB;
 
 		CREATE TABLE IF NOT EXISTS url_history (
 			id SERIAL PRIMARY KEY,
@@ -103,15 +143,134 @@ func (r *PostgresRepository) InitSchema(ctx context.Context) error {
 		);
 		CREATE INDEX IF NOT EXISTS idx_url_history_url_id ON url_history(url_id);
 		CREATE INDEX IF NOT EXISTS idx_url_history_url_short ON url_history(url_short);
+
+		CREATE TABLE IF NOT EXISTS creator_defaults (
+			creator_reference TEXT PRIMARY KEY,
+			expiry_seconds BIGINT NOT NULL DEFAULT 0,
+			rate_limit INTEGER NOT NULL DEFAULT 0,
+			redirect_headers JSONB,
+			updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+		);
 	`)
 	return err
 }
 
 // Create stores a new URL and returns the created URL with all fields
 func (r *PostgresRepository) Create(ctx context.Context, url *models.URL) (*models.URL, error) {
+	return createURL(ctx, r.pool, url)
+}
+
+// CreateBatch stores new URLs in a single transaction. Each URL is inserted in its own savepoint, so a
+// URL that can't be stored only fails its own entry in the returned errors.
+func (r *PostgresRepository) CreateBatch(ctx context.Context, urls []*models.URL) ([]*models.URL, []error, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	created := make([]*models.URL, len(urls))
+	errs := make([]error, len(urls))
+	for i, url := range urls {
+		savepoint, err := tx.Begin(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		created[i], errs[i] = createURL(ctx, savepoint, url)
+		if errs[i] != nil {
+			err = savepoint.Rollback(ctx)
+		} else {
+			err = savepoint.Commit(ctx)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, nil, err
+	}
+	return created, errs, nil
+}
+
+// urlImportColumns are the columns BulkCreate copies into its staging table, after the row's position
+var urlImportColumns = []string{"ord", "id", "original", "short", "title", "created_at", "expires_at", "clicks", "creator_reference", "rate_limit", "redirect_headers", "metadata", "enabled", "tags", "disabled_redirect_url", "random_target", "notes", "max_clicks", "cache_ttl", "seeded_clicks"}
+
+// BulkCreate copies new URLs into a temporary staging table with COPY and inserts them from there in a
+// single statement. A URL whose short code is taken, or already used by an earlier URL of the same
+// call, fails with ErrURLExists in the returned errors.
+func (r *PostgresRepository) BulkCreate(ctx context.Context, urls []*models.URL) ([]error, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, "CREATE TEMP TABLE url_import ON COMMIT DROP AS SELECT 0 AS ord, "+strings.Join(urlImportColumns[1:], ", ")+" FROM urls WITH NO DATA")
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([][]any, len(urls))
+	for i, url := range urls {
+		rows[i] = []any{i, url.ID, url.Original, url.Short, url.Title, url.CreatedAt, url.ExpiresAt, url.Clicks, url.CreatorReference, url.RateLimit, url.RedirectHeaders, url.Metadata, url.Enabled, url.Tags, url.DisabledRedirectURL, url.RandomTarget, url.Notes, url.MaxClicks, url.CacheTTL, url.SeededClicks}
+	}
+	if _, err := tx.CopyFrom(ctx, pgx.Identifier{"url_import"}, urlImportColumns, pgx.CopyFromRows(rows)); err != nil {
+		return nil, err
+	}
+
+	// Only the first staged URL of each short code is inserted; the rest conflict with it
+	columns := strings.Join(urlImportColumns[2:], ", ")
+	inserted, err := tx.Query(ctx,
+		"INSERT INTO urls (id, "+columns+") "+
+			"SELECT COALESCE(NULLIF(id, 0), nextval(pg_get_serial_sequence('urls', 'id'))), "+columns+" "+
+			"FROM (SELECT DISTINCT ON (short) * FROM url_import ORDER BY short, ord) AS first ORDER BY ord "+
+			"ON CONFLICT (short) DO NOTHING RETURNING short")
+	if err != nil {
+		return nil, err
+	}
+	created := make(map[string]bool, len(urls))
+	for inserted.Next() {
+		var short string
+		if err := inserted.Scan(&short); err != nil {
+			inserted.Close()
+			return nil, err
+		}
+		created[short] = true
+	}
+	if err := inserted.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	errs := make([]error, len(urls))
+	for i, url := range urls {
+		if !created[url.Short] {
+			errs[i] = ErrURLExists
+			continue
+		}
+		// Later URLs with the same short code lost to this one
+		delete(created, url.Short)
+	}
+	return errs, nil
+}
+
+// rowQuerier is satisfied by both the pool and a transaction
+type rowQuerier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// uniqueViolation is the SQLSTATE PostgreSQL reports when an insert conflicts with a unique constraint
+const uniqueViolation = "23505"
+
+// createURL inserts a URL unless another URL, even a deleted or expired one, already uses its short code
+func createURL(ctx context.Context, db rowQuerier, url *models.URL) (*models.URL, error) {
 	// Check if short URL already exists
 	var exists bool
-	err := r.pool.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM urls WHERE short = $1 AND deleted_at IS NULL)", url.Short).Scan(&exists)
+	err := db.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM urls WHERE short = $1)", url.Short).Scan(&exists)
 	if err != nil {
 		return nil, err
 	}
@@ -119,169 +278,831 @@ func (r *PostgresRepository) Create(ctx context.Context, url *models.URL) (*mode
 		return nil, ErrURLExists
 	}
 
-	// Insert new URL and return all fields including the generated ID
-	var createdURL models.URL
-	err = r.pool.QueryRow(ctx,
-		"INSERT INTO urls (original, short, title, created_at, expires_at, clicks, creator_reference, deleted_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id, original, short, title, created_at, expires_at, clicks, creator_reference, deleted_at",
-		url.Original, url.Short, url.Title, url.CreatedAt, url.ExpiresAt, url.Clicks, url.CreatorReference, url.DeletedAt).
-		Scan(&createdURL.ID, &createdURL.Original, &createdURL.Short, &createdURL.Title, &createdURL.CreatedAt, &createdURL.ExpiresAt, &createdURL.Clicks, &createdURL.CreatorReference, &createdURL.DeletedAt)
+	// Insert new URL and return all fields including the generated ID, unless an ID was reserved
+	createdURL, err := scanURL(db.QueryRow(ctx,
+		"INSERT INTO urls (id, original, short, title, created_at, expires_at, clicks, creator_reference, deleted_at, rate_limit, redirect_headers, metadata, enabled, tags, disabled_redirect_url, random_target, notes, max_clicks, cache_ttl, seeded_clicks) VALUES (COALESCE(NULLIF($1, 0), nextval(pg_get_serial_sequence('urls', 'id'))), $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20) RETURNING "+urlColumns,
+		url.ID, url.Original, url.Short, url.Title, url.CreatedAt, url.ExpiresAt, url.Clicks, url.CreatorReference, url.DeletedAt, url.RateLimit, url.RedirectHeaders, url.Metadata, url.Enabled, url.Tags, url.DisabledRedirectURL, url.RandomTarget, url.Notes, url.MaxClicks, url.CacheTTL, url.SeededClicks))
+	// The code may have been taken since it was checked
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return nil, ErrURLExists
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return createdURL, nil
+}
+
+// CountActiveURLs counts the URLs that are neither deleted nor expired
+func (r *PostgresRepository) CountActiveURLs(ctx context.Context) (int64, error) {
+	var count int64
+	err := r.pool.QueryRow(ctx, "SELECT COUNT(*) FROM urls WHERE deleted_at IS NULL AND (expires_at IS NULL OR expires_at > NOW())").Scan(&count)
+	return count, err
+}
+
+// NextURLID reserves an ID from the urls sequence. Sequence values are never handed out twice,
+// even if the URL is never created.
+func (r *PostgresRepository) NextURLID(ctx context.Context) (int64, error) {
+	var id int64
+	err := r.pool.QueryRow(ctx, "SELECT nextval(pg_get_serial_sequence('urls', 'id'))").Scan(&id)
+	return id, err
+}
+
+// GetRandomURLByCreator retrieves a random live, enabled URL of a creator, excluding random links
+func (r *PostgresRepository) GetRandomURLByCreator(ctx context.Context, creatorReference string) (*models.URL, error) {
+	url, err := scanURL(r.pool.QueryRow(ctx,
+		"SELECT "+urlColumns+" FROM urls WHERE creator_reference = $1 AND deleted_at IS NULL AND (expires_at IS NULL OR expires_at > NOW()) AND enabled AND NOT random_target ORDER BY random() LIMIT 1",
+		creatorReference))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrURLNotFound
+		}
+		return nil, err
+	}
+	return url, nil
+}
+
+// GetByShort retrieves a URL by its short code
+func (r *PostgresRepository) GetByShort(ctx context.Context, short string) (*models.URL, error) {
+	url, err := scanURL(r.pool.QueryRow(ctx,
+		"SELECT "+urlColumns+" FROM urls WHERE short = $1 AND deleted_at IS NULL",
+		short))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrURLNotFound
+		}
+		return nil, err
+	}
+
+	// Check if URL has expired
+	if url.IsExpired() {
+		return nil, ErrURLExpired
+	}
+
+	return url, nil
+}
+
+// GetByShorts retrieves the URLs with any of the short codes, including expired but not soft-deleted URLs
+func (r *PostgresRepository) GetByShorts(ctx context.Context, shorts []string) ([]*models.URL, error) {
+	rows, err := r.pool.Query(ctx,
+		"SELECT "+urlColumns+" FROM urls WHERE short = ANY($1) AND deleted_at IS NULL",
+		shorts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var urls []*models.URL
+	for rows.Next() {
+		url, err := scanURL(rows)
+		if err != nil {
+			return nil, err
+		}
+		urls = append(urls, url)
+	}
+	return urls, rows.Err()
+}
+
+// GetByShortIncludingDeleted retrieves a URL by its short code, including soft-deleted and expired URLs
+func (r *PostgresRepository) GetByShortIncludingDeleted(ctx context.Context, short string) (*models.URL, error) {
+	url, err := scanURL(r.pool.QueryRow(ctx,
+		"SELECT "+urlColumns+" FROM urls WHERE short = $1",
+		short))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrURLNotFound
+		}
+		return nil, err
+	}
+
+	return url, nil
+}
+
+// GetByOriginal retrieves a URL by its original URL
+func (r *PostgresRepository) GetByOriginal(ctx context.Context, original string) (*models.URL, error) {
+	url, err := scanURL(r.pool.QueryRow(ctx,
+		"SELECT "+urlColumns+" FROM urls WHERE original = $1 AND deleted_at IS NULL",
+		original))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrURLNotFound
+		}
+		return nil, err
+	}
+
+	// Check if URL has expired
+	if url.IsExpired() {
+		return nil, ErrURLExpired
+	}
+
+	return url, nil
+}
+
+// GetByOriginalForCreator retrieves the creator's most recently created live URL for an original URL
+func (r *PostgresRepository) GetByOriginalForCreator(ctx context.Context, original string, creatorReference string) (*models.URL, error) {
+	url, err := scanURL(r.pool.QueryRow(ctx,
+		"SELECT "+urlColumns+" FROM urls WHERE original = $1 AND creator_reference = $2 AND deleted_at IS NULL AND (expires_at IS NULL OR expires_at > NOW()) ORDER BY created_at DESC, id DESC LIMIT 1",
+		original, creatorReference))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrURLNotFound
+		}
+		return nil, err
+	}
+
+	return url, nil
+}
+
+// CreateOrGet returns the creator's most recently created live URL for url.Original, or stores url if
+// there is none. A transaction-scoped advisory lock on the original URL and creator serializes
+// concurrent calls, so the second call finds the URL the first one created.
+func (r *PostgresRepository) CreateOrGet(ctx context.Context, url *models.URL) (*models.URL, bool, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock(hashtextextended($1::text || E'\\n' || $2::text, 0))", url.Original, url.CreatorReference); err != nil {
+		return nil, false, err
+	}
+
+	existing, err := scanURL(tx.QueryRow(ctx,
+		"SELECT "+urlColumns+" FROM urls WHERE original = $1 AND creator_reference = $2 AND deleted_at IS NULL AND (expires_at IS NULL OR expires_at > NOW()) ORDER BY created_at DESC, id DESC LIMIT 1",
+		url.Original, url.CreatorReference))
+	if err == nil {
+		return existing, false, tx.Commit(ctx)
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, false, err
+	}
+
+	created, err := createURL(ctx, tx, url)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, false, err
+	}
+	return created, true, nil
+}
+
+// creatorSortColumns maps accepted sort names to their SQL columns
+var creatorSortColumns = map[string]string{
+	SortByCreatedAt: "created_at",
+	SortByClicks:    "clicks",
+}
+
+// liveCreatorURLs matches the live URLs of the creator given as $1
+const liveCreatorURLs = "creator_reference = $1 AND deleted_at IS NULL AND (expires_at IS NULL OR expires_at > NOW())"
+
+// GetByCreator retrieves a sorted page of a creator's live URLs and the total number of live URLs
+func (r *PostgresRepository) GetByCreator(ctx context.Context, creatorReference string, filter CreatorURLFilter) ([]*models.URL, int64, error) {
+	column, ok := creatorSortColumns[filter.Sort]
+	if !ok {
+		column = creatorSortColumns[SortByCreatedAt]
+	}
+	direction := "ASC"
+	if filter.Descending {
+		direction = "DESC"
+	}
+
+	// Count all live URLs
+	var total int64
+	err := r.pool.QueryRow(ctx,
+		"SELECT COUNT(*) FROM urls WHERE "+liveCreatorURLs,
+		creatorReference).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Get the requested page, breaking ties by id so pages are stable
+	rows, err := r.pool.Query(ctx,
+		"SELECT "+urlColumns+" FROM urls WHERE "+liveCreatorURLs+" ORDER BY "+column+" "+direction+", id "+direction+" LIMIT $2 OFFSET $3",
+		creatorReference, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var urls []*models.URL
+	for rows.Next() {
+		url, err := scanURL(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		urls = append(urls, url)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return urls, total, nil
+}
+
+// likeEscaper escapes the wildcards of a LIKE pattern, with backslash as the escape character
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// ListURLs retrieves a page of all URLs matching filter, newest first, and the total number of matching
+// URLs. Filter values are only ever passed as query arguments.
+func (r *PostgresRepository) ListURLs(ctx context.Context, filter URLListFilter, page Page) ([]*models.URL, int64, error) {
+	var conditions []string
+	var args []any
+	where := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, strings.ReplaceAll(condition, "?", fmt.Sprintf("$%d", len(args))))
+	}
+	if !filter.IncludeDeleted {
+		conditions = append(conditions, "deleted_at IS NULL")
+	}
+	if filter.CreatorReference != "" {
+		where("creator_reference = ?", filter.CreatorReference)
+	}
+	if !filter.CreatedAfter.IsZero() {
+		where("created_at >= ?", filter.CreatedAfter.UTC())
+	}
+	if !filter.CreatedBefore.IsZero() {
+		where("created_at < ?", filter.CreatedBefore.UTC())
+	}
+	if filter.MinClicks > 0 {
+		where("clicks >= ?", filter.MinClicks)
+	}
+	if filter.Search != "" {
+		where(`(original ILIKE '%' || ?::text || '%' ESCAPE '\' OR title ILIKE '%' || ?::text || '%' ESCAPE '\')`, likeEscaper.Replace(filter.Search))
+	}
+	condition := "TRUE"
+	if len(conditions) > 0 {
+		condition = strings.Join(conditions, " AND ")
+	}
+
+	var total int64
+	if err := r.pool.QueryRow(ctx, "SELECT COUNT(*) FROM urls WHERE "+condition, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	// Break ties by id so pages are stable
+	args = append(args, page.Limit, page.Offset)
+	rows, err := r.pool.Query(ctx,
+		fmt.Sprintf("SELECT %s FROM urls WHERE %s ORDER BY created_at DESC, id DESC LIMIT $%d OFFSET $%d", urlColumns, condition, len(args)-1, len(args)),
+		args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var urls []*models.URL
+	for rows.Next() {
+		url, err := scanURL(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		urls = append(urls, url)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return urls, total, nil
+}
+
+// urlSearchDocument is the full-text search document of a URL, indexed by idx_urls_search. Punctuation
+// is replaced by spaces so the words of the original URL are indexed rather than the URL as one token,
+// and the simple configuration doesn't stem, since URLs and titles are in any language.
+const urlSearchDocument = `to_tsvector('simple', regexp_replace(original || ' ' || COALESCE(title, ''), '[^[:alnum:]]+', ' ', 'g'))`
+
+// SearchURLs retrieves a page of live URLs matching every word of query as a prefix, ranked by ts_rank
+// and then newest first, and the total number of matches
+func (r *PostgresRepository) SearchURLs(ctx context.Context, query string, creatorReference string, page Page) ([]*models.URL, int64, error) {
+	// The terms only hold letters and digits, so they can't inject tsquery operators
+	terms := searchTerms(query)
+	for i, term := range terms {
+		terms[i] = term + ":*"
+	}
+	condition := urlSearchDocument + " @@ to_tsquery('simple', $1) AND deleted_at IS NULL AND (expires_at IS NULL OR expires_at > NOW()) AND ($2 = '' OR creator_reference = $2)"
+	args := []any{strings.Join(terms, " & "), creatorReference}
+
+	var total int64
+	if err := r.pool.QueryRow(ctx, "SELECT COUNT(*) FROM urls WHERE "+condition, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.pool.Query(ctx,
+		"SELECT "+urlColumns+" FROM urls WHERE "+condition+" ORDER BY ts_rank("+urlSearchDocument+", to_tsquery('simple', $1)) DESC, created_at DESC, id DESC LIMIT $3 OFFSET $4",
+		append(args, page.Limit, page.Offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var urls []*models.URL
+	for rows.Next() {
+		url, err := scanURL(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		urls = append(urls, url)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return urls, total, nil
+}
+
+// CountByCreator counts a creator's live URLs
+func (r *PostgresRepository) CountByCreator(ctx context.Context, creatorReference string) (int64, error) {
+	var count int64
+	err := r.pool.QueryRow(ctx,
+		"SELECT COUNT(*) FROM urls WHERE "+liveCreatorURLs,
+		creatorReference).Scan(&count)
+	return count, err
+}
+
+// StreamByCreator calls fn for each live URL of a creator, oldest first, reading rows as they arrive
+func (r *PostgresRepository) StreamByCreator(ctx context.Context, creatorReference string, fn func(*models.URL) error) error {
+	rows, err := r.pool.Query(ctx,
+		"SELECT "+urlColumns+" FROM urls WHERE "+liveCreatorURLs+" ORDER BY created_at ASC, id ASC",
+		creatorReference)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		url, err := scanURL(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(url); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// GetTopURLs retrieves up to limit live URLs with the most clicks, most clicked first, across all
+// creators or only creatorReference's when it is not empty
+func (r *PostgresRepository) GetTopURLs(ctx context.Context, creatorReference string, limit int) ([]*models.URL, error) {
+	rows, err := r.pool.Query(ctx,
+		"SELECT "+urlColumns+" FROM urls WHERE ($1 = '' OR creator_reference = $1) AND deleted_at IS NULL AND (expires_at IS NULL OR expires_at > NOW()) ORDER BY clicks DESC, id ASC LIMIT $2",
+		creatorReference, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var urls []*models.URL
+	for rows.Next() {
+		url, err := scanURL(rows)
+		if err != nil {
+			return nil, err
+		}
+		urls = append(urls, url)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return urls, nil
+}
+
+// IncrementClicks increments the click count and stamps last_accessed_at in a single statement,
+// returning the new click count. The statement only matches URLs below their click limit, so
+// concurrent clicks can't push a usage-limited URL past it.
+func (r *PostgresRepository) IncrementClicks(ctx context.Context, short string) (int64, error) {
+	var clicks int64
+	err := r.pool.QueryRow(ctx,
+		"UPDATE urls SET clicks = clicks + 1, last_accessed_at = NOW() WHERE short = $1 AND deleted_at IS NULL AND (max_clicks = 0 OR clicks < max_clicks) RETURNING clicks",
+		short).Scan(&clicks)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return 0, err
+		}
+		// Tell a URL that reached its limit from a missing one
+		var exists bool
+		if err := r.pool.QueryRow(ctx,
+			"SELECT EXISTS (SELECT 1 FROM urls WHERE short = $1 AND deleted_at IS NULL)",
+			short).Scan(&exists); err != nil {
+			return 0, err
+		}
+		if exists {
+			return 0, ErrClickLimitReached
+		}
+		return 0, ErrURLNotFound
+	}
+	return clicks, nil
+}
+
+// RebuildClickCount recomputes a URL's click count from its seeded clicks and the clicks table and returns it
+func (r *PostgresRepository) RebuildClickCount(ctx context.Context, short string) (int64, error) {
+	var clicks int64
+	err := r.pool.QueryRow(ctx,
+		"UPDATE urls SET clicks = seeded_clicks + (SELECT COUNT(*) FROM clicks WHERE clicks.url_id = urls.id) WHERE short = $1 RETURNING clicks",
+		short).Scan(&clicks)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, ErrURLNotFound
+		}
+		return 0, err
+	}
+	return clicks, nil
+}
+
+// RebuildClickCounts recomputes the click counts of up to limit URLs with an ID above afterID from their
+// seeded clicks and the clicks table. Each call is a single short statement so large rebuilds don't hold row locks for long.
+func (r *PostgresRepository) RebuildClickCounts(ctx context.Context, afterID int64, limit int) ([]string, int64, error) {
+	rows, err := r.pool.Query(ctx, `
+		WITH batch AS (
+			SELECT id FROM urls WHERE id > $1 ORDER BY id LIMIT $2
+		)
+		UPDATE urls SET clicks = urls.seeded_clicks + (SELECT COUNT(*) FROM clicks WHERE clicks.url_id = urls.id)
+		FROM batch
+		WHERE urls.id = batch.id
+		RETURNING urls.id, urls.short`, afterID, limit)
+	if err != nil {
+		return nil, afterID, err
+	}
+	defer rows.Close()
+
+	var shorts []string
+	lastID := afterID
+	for rows.Next() {
+		var id int64
+		var short string
+		if err := rows.Scan(&id, &short); err != nil {
+			return nil, afterID, err
+		}
+		shorts = append(shorts, short)
+		if id > lastID {
+			lastID = id
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, afterID, err
+	}
+
+	return shorts, lastID, nil
+}
+
+// Delete soft deletes a URL by setting its DeletedAt field
+func (r *PostgresRepository) Delete(ctx context.Context, short string) error {
+	_, err := r.pool.Exec(ctx, "UPDATE urls SET deleted_at = NOW() WHERE short = $1 AND deleted_at IS NULL", short)
+	return err
+}
+
+// DeleteWithCreator soft deletes a URL if the creator_reference matches
+func (r *PostgresRepository) DeleteWithCreator(ctx context.Context, short string, creatorReference string) error {
+	// Check if URL exists and belongs to the creator
+	existingURL, err := r.GetByShort(ctx, short)
+	if err != nil {
+		return err
+	}
+
+	// Check if the creator_reference matches
+	if existingURL.CreatorReference != creatorReference {
+		return ErrCreatorMismatch
+	}
+
+	// Soft delete URL
+	_, err = r.pool.Exec(ctx, "UPDATE urls SET deleted_at = NOW() WHERE short = $1 AND creator_reference = $2 AND deleted_at IS NULL", short, creatorReference)
+	return err
+}
+
+// Restore clears the deletion of the URL with the short code. Codes are unique across live and deleted
+// URLs, so a URL that isn't deleted makes the restore fail with ErrRestoreConflict.
+func (r *PostgresRepository) Restore(ctx context.Context, short string) (*models.URL, error) {
+	url, err := scanURL(r.pool.QueryRow(ctx,
+		"UPDATE urls SET deleted_at = NULL WHERE short = $1 AND deleted_at IS NOT NULL RETURNING "+urlColumns,
+		short))
+	if err == nil {
+		return url, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+
+	// Nothing restored: either the URL isn't deleted or it doesn't exist
+	var exists bool
+	if err := r.pool.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM urls WHERE short = $1)", short).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrRestoreConflict
+	}
+	return nil, ErrURLNotFound
+}
+
+// HardDelete permanently removes a URL owned by creatorReference from the database
+func (r *PostgresRepository) HardDelete(ctx context.Context, short, creatorReference string) error {
+	_, err := r.pool.Exec(ctx, "DELETE FROM urls WHERE short = $1 AND creator_reference = $2", short, creatorReference)
+	return err
+}
+
+// PurgeExpired permanently removes up to limit URLs that expired more than grace ago; their clicks and
+// history cascade
+func (r *PostgresRepository) PurgeExpired(ctx context.Context, grace time.Duration, limit int) ([]string, error) {
+	rows, err := r.pool.Query(ctx, `
+		WITH batch AS (
+			SELECT id FROM urls WHERE expires_at < NOW() - make_interval(secs => $1) ORDER BY id LIMIT $2
+		)
+		DELETE FROM urls USING batch
+		WHERE urls.id = batch.id
+		RETURNING urls.short`, grace.Seconds(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var shorts []string
+	for rows.Next() {
+		var short string
+		if err := rows.Scan(&short); err != nil {
+			return nil, err
+		}
+		shorts = append(shorts, short)
+	}
+	return shorts, rows.Err()
+}
+
+// StoreClick stores click analytics data
+func (r *PostgresRepository) StoreClick(ctx context.Context, click *models.Click) error {
+	fmt.Printf("Storing click: %+v\n", click)
+	_, err := r.pool.Exec(ctx,
+		"INSERT INTO clicks (url_id, url_short, ip, location, browser, device, timestamp, resolve_ms, target, browser_version, os, referrer, extra) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)",
+		click.URLID, click.URLShort, click.IP, click.Location, click.Browser, click.Device, clickTimestamp(click.Timestamp), click.ResolveMs, click.Target, click.BrowserVersion, click.OS, click.Referrer, click.Extra)
+	return err
+}
+
+// StoreCountedClick stores a click and increments its URL's click count in one transaction
+func (r *PostgresRepository) StoreCountedClick(ctx context.Context, click *models.Click) (int64, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	var clicks int64
+	if !click.Counted {
+		err = tx.QueryRow(ctx,
+			"UPDATE urls SET clicks = clicks + 1, last_accessed_at = NOW() WHERE short = $1 AND deleted_at IS NULL AND (max_clicks = 0 OR clicks < max_clicks) RETURNING clicks",
+			click.URLShort).Scan(&clicks)
+		if err != nil {
+			if !errors.Is(err, pgx.ErrNoRows) {
+				return 0, err
+			}
+			// Tell a URL that reached its limit from a missing one
+			var exists bool
+			if err := tx.QueryRow(ctx,
+				"SELECT EXISTS (SELECT 1 FROM urls WHERE short = $1 AND deleted_at IS NULL)",
+				click.URLShort).Scan(&exists); err != nil {
+				return 0, err
+			}
+			if exists {
+				return 0, ErrClickLimitReached
+			}
+			return 0, ErrURLNotFound
+		}
+	}
+
+	_, err = tx.Exec(ctx,
+		"INSERT INTO clicks (url_id, url_short, ip, location, browser, device, timestamp, resolve_ms, target, browser_version, os, referrer, extra) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)",
+		click.URLID, click.URLShort, click.IP, click.Location, click.Browser, click.Device, clickTimestamp(click.Timestamp), click.ResolveMs, click.Target, click.BrowserVersion, click.OS, click.Referrer, click.Extra)
+	if err != nil {
+		return 0, err
+	}
+
+	return clicks, tx.Commit(ctx)
+}
+
+// StoreClickBatch copies clicks into the clicks table and increments the click count and
+// last_accessed_at of every URL in the batch, in a single transaction
+func (r *PostgresRepository) StoreClickBatch(ctx context.Context, clicks []*models.Click) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.CopyFrom(ctx,
+		pgx.Identifier{"clicks"},
+		[]string{"url_id", "url_short", "ip", "location", "browser", "device", "timestamp", "resolve_ms", "target", "browser_version", "os", "referrer", "extra"},
+		pgx.CopyFromSlice(len(clicks), func(i int) ([]any, error) {
+			click := clicks[i]
+			return []any{click.URLID, click.URLShort, click.IP, click.Location, click.Browser, click.Device, clickTimestamp(click.Timestamp), click.ResolveMs, click.Target, click.BrowserVersion, click.OS, click.Referrer, click.Extra}, nil
+		}))
+	if err != nil {
+		return err
+	}
+
+	// One increment per URL rather than per click
+	counts := make(map[string]int64)
+	for _, click := range clicks {
+		if !click.Counted {
+			counts[click.URLShort]++
+		}
+	}
+	shorts := make([]string, 0, len(counts))
+	increments := make([]int64, 0, len(counts))
+	for short, count := range counts {
+		shorts = append(shorts, short)
+		increments = append(increments, count)
+	}
+	_, err = tx.Exec(ctx, `
+		UPDATE urls SET clicks = clicks + batch.clicks, last_accessed_at = NOW()
+		FROM unnest($1::text[], $2::bigint[]) AS batch(short, clicks)
+		WHERE urls.short = batch.short AND urls.deleted_at IS NULL`,
+		shorts, increments)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	return &createdURL, nil
+	return tx.Commit(ctx)
 }
 
-// GetByShort retrieves a URL by its short code
-func (r *PostgresRepository) GetByShort(ctx context.Context, short string) (*models.URL, error) {
-	url := &models.URL{}
-	err := r.pool.QueryRow(ctx,
-		"SELECT id, original, short, title, created_at, expires_at, clicks, creator_reference, deleted_at FROM urls WHERE short = $1 AND deleted_at IS NULL",
-		short).Scan(&url.ID, &url.Original, &url.Short, &url.Title, &url.CreatedAt, &url.ExpiresAt, &url.Clicks, &url.CreatorReference, &url.DeletedAt)
+// ImportClicks stores historical clicks of a live URL. The URL row is locked for the import and every
+// click is attached to it, so imported clicks can't point at another or a deleted URL.
+func (r *PostgresRepository) ImportClicks(ctx context.Context, short string, clicks []*models.Click) (int64, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	var urlID int64
+	err = tx.QueryRow(ctx, "SELECT id FROM urls WHERE short = $1 AND deleted_at IS NULL FOR UPDATE", short).Scan(&urlID)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, ErrURLNotFound
+			return 0, ErrURLNotFound
 		}
-		return nil, err
+		return 0, err
 	}
 
-	// Check if URL has expired
-	if !url.ExpiresAt.IsZero() && url.ExpiresAt.Before(time.Now()) {
-		return nil, ErrURLNotFound
+	var latest time.Time
+	for _, click := range clicks {
+		click.URLID = urlID
+		click.URLShort = short
+		if click.Timestamp.After(latest) {
+			latest = click.Timestamp
+		}
 	}
 
-	return url, nil
-}
-
-// GetByOriginal retrieves a URL by its original URL
-func (r *PostgresRepository) GetByOriginal(ctx context.Context, original string) (*models.URL, error) {
-	url := &models.URL{}
-	err := r.pool.QueryRow(ctx,
-		"SELECT id, original, short, title, created_at, expires_at, clicks, creator_reference, deleted_at FROM urls WHERE original = $1 AND deleted_at IS NULL",
-		original).Scan(&url.ID, &url.Original, &url.Short, &url.Title, &url.CreatedAt, &url.ExpiresAt, &url.Clicks, &url.CreatorReference, &url.DeletedAt)
+	_, err = tx.CopyFrom(ctx,
+		pgx.Identifier{"clicks"},
+		[]string{"url_id", "url_short", "ip", "location", "browser", "device", "timestamp", "resolve_ms", "target", "browser_version", "os", "referrer"},
+		pgx.CopyFromSlice(len(clicks), func(i int) ([]any, error) {
+			click := clicks[i]
+			return []any{click.URLID, click.URLShort, click.IP, click.Location, click.Browser, click.Device, click.Timestamp, click.ResolveMs, click.Target, click.BrowserVersion, click.OS, click.Referrer}, nil
+		}))
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, ErrURLNotFound
-		}
-		return nil, err
+		return 0, err
 	}
 
-	// Check if URL has expired
-	if !url.ExpiresAt.IsZero() && url.ExpiresAt.Before(time.Now()) {
-		return nil, ErrURLNotFound
+	// Imported clicks only move the last access time forward
+	var count int64
+	err = tx.QueryRow(ctx, `
+		UPDATE urls SET clicks = clicks + $1, last_accessed_at = GREATEST(last_accessed_at, $2)
+		WHERE id = $3
+		RETURNING clicks`,
+		len(clicks), latest, urlID).Scan(&count)
+	if err != nil {
+		return 0, err
 	}
 
-	return url, nil
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	return count, nil
 }
 
-// GetByCreator retrieves URLs by their creator reference
-func (r *PostgresRepository) GetByCreator(ctx context.Context, creatorReference string) ([]*models.URL, error) {
+// GetClicksByShort retrieves click analytics data for a URL
+func (r *PostgresRepository) GetClicksByShort(ctx context.Context, short string) ([]*models.Click, error) {
 	rows, err := r.pool.Query(ctx,
-		"SELECT id, original, short, title, created_at, expires_at, clicks, creator_reference, deleted_at FROM urls WHERE creator_reference = $1 AND deleted_at IS NULL",
-		creatorReference)
+		"SELECT id, url_id, url_short, ip, location, browser, device, timestamp, browser_version, os, referrer, extra FROM clicks WHERE url_short = $1 ORDER BY timestamp DESC",
+		short)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var urls []*models.URL
+	var clicks []*models.Click
 	for rows.Next() {
-		url := &models.URL{}
-		err := rows.Scan(&url.ID, &url.Original, &url.Short, &url.Title, &url.CreatedAt, &url.ExpiresAt, &url.Clicks, &url.CreatorReference, &url.DeletedAt)
+		click := &models.Click{}
+		err := rows.Scan(&click.ID, &click.URLID, &click.URLShort, &click.IP, &click.Location, &click.Browser, &click.Device, &click.Timestamp, &click.BrowserVersion, &click.OS, &click.Referrer, &click.Extra)
 		if err != nil {
 			return nil, err
 		}
-
-		// Skip expired URLs
-		if !url.ExpiresAt.IsZero() && url.ExpiresAt.Before(time.Now()) {
-			continue
-		}
-
-		urls = append(urls, url)
+		clicks = append(clicks, click)
 	}
 
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
 
-	return urls, nil
-}
-
-// IncrementClicks increments the click count for a URL
-func (r *PostgresRepository) IncrementClicks(ctx context.Context, short string) error {
-	_, err := r.pool.Exec(ctx, "UPDATE urls SET clicks = clicks + 1 WHERE short = $1 AND deleted_at IS NULL", short)
-	return err
-}
-
-// Delete soft deletes a URL by setting its DeletedAt field
-func (r *PostgresRepository) Delete(ctx context.Context, short string) error {
-	_, err := r.pool.Exec(ctx, "UPDATE urls SET deleted_at = NOW() WHERE short = $1 AND deleted_at IS NULL", short)
-	return err
+	return clicks, nil
 }
 
-// DeleteWithCreator soft deletes a URL if the creator_reference matches
-func (r *PostgresRepository) DeleteWithCreator(ctx context.Context, short string, creatorReference string) error {
-	// Check if URL exists and belongs to the creator
-	existingURL, err := r.GetByShort(ctx, short)
+// StreamClicksByShort calls fn for each click of a URL, oldest first, reading rows from the cursor as they arrive
+func (r *PostgresRepository) StreamClicksByShort(ctx context.Context, short string, fn func(*models.Click) error) error {
+	rows, err := r.pool.Query(ctx,
+		"SELECT id, url_id, url_short, ip, location, browser, device, timestamp, browser_version, os, referrer, extra FROM clicks WHERE url_short = $1 ORDER BY timestamp ASC, id ASC",
+		short)
 	if err != nil {
 		return err
 	}
+	defer rows.Close()
 
-	// Check if the creator_reference matches
-	if existingURL.CreatorReference != creatorReference {
-		return errors.New("unauthorized: creator reference does not match")
+	for rows.Next() {
+		click := &models.Click{}
+		err := rows.Scan(&click.ID, &click.URLID, &click.URLShort, &click.IP, &click.Location, &click.Browser, &click.Device, &click.Timestamp, &click.BrowserVersion, &click.OS, &click.Referrer, &click.Extra)
+		if err != nil {
+			return err
+		}
+		if err := fn(click); err != nil {
+			return err
+		}
 	}
 
-	// Soft delete URL
-	_, err = r.pool.Exec(ctx, "UPDATE urls SET deleted_at = NOW() WHERE short = $1 AND creator_reference = $2 AND deleted_at IS NULL", short, creatorReference)
-	return err
+	return rows.Err()
 }
 
-// HardDelete permanently removes a URL from the database
-func (r *PostgresRepository) HardDelete(ctx context.Context, short string) error {
-	_, err := r.pool.Exec(ctx, "DELETE FROM urls WHERE short = $1", short)
-	return err
-}
+// GetClickTimeSeries retrieves click counts for a URL since the given time in buckets of
+// interval (minute, hour, day or week) following the wall clock of the IANA timezone, oldest first
+func (r *PostgresRepository) GetClickTimeSeries(ctx context.Context, short string, rng ClickRange, interval string, timezone string) ([]*models.TimeSeriesPoint, error) {
+	inRange, rangeArgs := clickRangeCondition(rng, 4)
+	// Click timestamps are stored in UTC; truncate their wall clock time in the requested zone
+	// and convert the bucket start back to an absolute time
+	rows, err := r.pool.Query(ctx, `
+		SELECT date_trunc($2, (timestamp AT TIME ZONE 'UTC') AT TIME ZONE $3) AT TIME ZONE $3 AS bucket, COUNT(*)
+		FROM clicks
+		WHERE url_short = $1 AND `+inRange+`
+		GROUP BY bucket
+		ORDER BY bucket
+	`, append([]any{short, interval, timezone}, rangeArgs...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-// StoreClick stores click analytics data
-func (r *PostgresRepository) StoreClick(ctx context.Context, click *models.Click) error {
-	fmt.Printf("Storing click: %+v\n", click)
-	_, err := r.pool.Exec(ctx,
-		"INSERT INTO clicks (url_id, url_short, ip, location, browser, device, timestamp) VALUES ($1, $2, $3, $4, $5, $6, $7)",
-		click.URLID, click.URLShort, click.IP, click.Location, click.Browser, click.Device, click.Timestamp)
-	return err
+	var points []*models.TimeSeriesPoint
+	for rows.Next() {
+		point := &models.TimeSeriesPoint{}
+		if err := rows.Scan(&point.Time, &point.Clicks); err != nil {
+			return nil, err
+		}
+		points = append(points, point)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return points, nil
 }
 
-// GetClicksByShort retrieves click analytics data for a URL
-func (r *PostgresRepository) GetClicksByShort(ctx context.Context, short string) ([]*models.Click, error) {
-	rows, err := r.pool.Query(ctx,
-		"SELECT id, url_id, url_short, ip, location, browser, device, timestamp FROM clicks WHERE url_short = $1 ORDER BY timestamp DESC",
-		short)
+// GetClickDistribution counts a URL's clicks within rng by the hour of the day and day of the week of
+// their wall clock time in timezone
+func (r *PostgresRepository) GetClickDistribution(ctx context.Context, short string, rng ClickRange, timezone string) (*models.ClickDistribution, error) {
+	inRange, rangeArgs := clickRangeCondition(rng, 3)
+	// Click timestamps are stored in UTC; EXTRACT reads the wall clock time in the requested zone.
+	// DOW counts from 0 for Sunday.
+	rows, err := r.pool.Query(ctx, `
+		SELECT EXTRACT(HOUR FROM local)::int, EXTRACT(DOW FROM local)::int, COUNT(*)
+		FROM (
+			SELECT (timestamp AT TIME ZONE 'UTC') AT TIME ZONE $2 AS local
+			FROM clicks
+			WHERE url_short = $1 AND `+inRange+`
+		) AS local_clicks
+		GROUP BY 1, 2
+	`, append([]any{short, timezone}, rangeArgs...)...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var clicks []*models.Click
+	distribution := &models.ClickDistribution{Timezone: timezone}
 	for rows.Next() {
-		click := &models.Click{}
-		err := rows.Scan(&click.ID, &click.URLID, &click.URLShort, &click.IP, &click.Location, &click.Browser, &click.Device, &click.Timestamp)
-		if err != nil {
+		var hour, day int
+		var clicks int64
+		if err := rows.Scan(&hour, &day, &clicks); err != nil {
 			return nil, err
 		}
-		clicks = append(clicks, click)
+		distribution.HourOfDay[hour] += clicks
+		distribution.DayOfWeek[day] += clicks
 	}
 
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
 
-	return clicks, nil
+	return distribution, nil
 }
 
-// HasRecentClick checks if there's a recent click from the same visitor
-func (r *PostgresRepository) HasRecentClick(ctx context.Context, short string, ip string, browser string, device string) (bool, error) {
-	// Check if there's a click from the same visitor (IP + browser + device) within the last hour
+// HasRecentClick checks if there's a click from the same visitor within the window
+func (r *PostgresRepository) HasRecentClick(ctx context.Context, short string, ip string, browser string, device string, window time.Duration) (bool, error) {
+	// Check if there's a click from the same visitor (IP + browser + device) within the window
 	var exists bool
 	err := r.pool.QueryRow(ctx, `
 		SELECT EXISTS(
@@ -290,9 +1111,9 @@ func (r *PostgresRepository) HasRecentClick(ctx context.Context, short string, i
 			AND ip = $2 
 			AND browser = $3 
 			AND device = $4 
-			AND timestamp > NOW() - INTERVAL '1 hour'
+			AND timestamp > NOW() - make_interval(secs => $5)
 		)
-	`, short, ip, browser, device).Scan(&exists)
+	`, short, ip, browser, device, window.Seconds()).Scan(&exists)
 
 	if err != nil {
 		return false, err
@@ -311,8 +1132,8 @@ func (r *PostgresRepository) UpdateURL(ctx context.Context, short string, url *m
 
 	// Update URL
 	_, err = r.pool.Exec(ctx,
-		"UPDATE urls SET original = $1, title = $2, expires_at = $3 WHERE short = $4 AND deleted_at IS NULL",
-		url.Original, url.Title, url.ExpiresAt, short)
+		"UPDATE urls SET original = $1, title = $2, expires_at = $3, rate_limit = $4, redirect_headers = $5, metadata = $6, tags = $7, disabled_redirect_url = $8, notes = $9 WHERE short = $10 AND deleted_at IS NULL",
+		url.Original, url.Title, url.ExpiresAt, url.RateLimit, url.RedirectHeaders, url.Metadata, url.Tags, url.DisabledRedirectURL, url.Notes, short)
 	return err
 }
 
@@ -326,16 +1147,134 @@ func (r *PostgresRepository) UpdateURLWithCreator(ctx context.Context, short str
 
 	// Check if the creator_reference matches
 	if existingURL.CreatorReference != creatorReference {
-		return errors.New("unauthorized: creator reference does not match")
+		return ErrCreatorMismatch
 	}
 
 	// Update URL
 	_, err = r.pool.Exec(ctx,
-		"UPDATE urls SET original = $1, title = $2, expires_at = $3 WHERE short = $4 AND creator_reference = $5 AND deleted_at IS NULL",
-		url.Original, url.Title, url.ExpiresAt, short, creatorReference)
+		"UPDATE urls SET original = $1, title = $2, expires_at = $3, rate_limit = $4, redirect_headers = $5, metadata = $6, tags = $7, disabled_redirect_url = $8, notes = $9 WHERE short = $10 AND creator_reference = $11 AND deleted_at IS NULL",
+		url.Original, url.Title, url.ExpiresAt, url.RateLimit, url.RedirectHeaders, url.Metadata, url.Tags, url.DisabledRedirectURL, url.Notes, short, creatorReference)
 	return err
 }
 
+// SetCreator assigns a URL to a creator
+func (r *PostgresRepository) SetCreator(ctx context.Context, short string, creatorReference string) error {
+	tag, err := r.pool.Exec(ctx,
+		"UPDATE urls SET creator_reference = $1 WHERE short = $2 AND deleted_at IS NULL",
+		creatorReference, short)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrURLNotFound
+	}
+	return nil
+}
+
+// SetEnabled enables or disables a live URL
+func (r *PostgresRepository) SetEnabled(ctx context.Context, short string, enabled bool) error {
+	tag, err := r.pool.Exec(ctx,
+		"UPDATE urls SET enabled = $1 WHERE short = $2 AND deleted_at IS NULL",
+		enabled, short)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrURLNotFound
+	}
+	return nil
+}
+
+// SetEnabledByCreator enables or disables all live URLs of a creator, optionally only those carrying tag,
+// in a single statement and returns the URLs whose state changed
+func (r *PostgresRepository) SetEnabledByCreator(ctx context.Context, creatorReference string, tag string, enabled bool) ([]*models.URL, error) {
+	rows, err := r.pool.Query(ctx,
+		"UPDATE urls SET enabled = $1 WHERE creator_reference = $2 AND deleted_at IS NULL AND enabled <> $1 AND ($3 = '' OR $3 = ANY(tags)) RETURNING "+urlColumns,
+		enabled, creatorReference, tag)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var urls []*models.URL
+	for rows.Next() {
+		url, err := scanURL(rows)
+		if err != nil {
+			return nil, err
+		}
+		urls = append(urls, url)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return urls, nil
+}
+
+// UpdateTags applies change to the tags of the live URLs with the given codes in a single transaction,
+// locking each row while its owner is checked
+func (r *PostgresRepository) UpdateTags(ctx context.Context, creatorReference string, codes []string, change TagChange) ([]*models.URL, []error, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	previous := make([]*models.URL, len(codes))
+	errs := make([]error, len(codes))
+	for i, short := range codes {
+		url, err := scanURL(tx.QueryRow(ctx,
+			"SELECT "+urlColumns+" FROM urls WHERE short = $1 AND deleted_at IS NULL FOR UPDATE",
+			short))
+		if errors.Is(err, pgx.ErrNoRows) || (err == nil && url.IsExpired()) {
+			errs[i] = ErrURLNotFound
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if url.CreatorReference != creatorReference {
+			errs[i] = ErrCreatorMismatch
+			continue
+		}
+
+		if _, err := tx.Exec(ctx, "UPDATE urls SET tags = $1 WHERE id = $2", change.Apply(url.Tags), url.ID); err != nil {
+			return nil, nil, err
+		}
+		previous[i] = url
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, nil, err
+	}
+	return previous, errs, nil
+}
+
+// TransferCreator assigns all live URLs of a creator to another creator and returns the transferred URLs
+func (r *PostgresRepository) TransferCreator(ctx context.Context, from string, to string) ([]*models.URL, error) {
+	rows, err := r.pool.Query(ctx,
+		"UPDATE urls SET creator_reference = $1 WHERE creator_reference = $2 AND deleted_at IS NULL RETURNING "+urlColumns,
+		to, from)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var urls []*models.URL
+	for rows.Next() {
+		url, err := scanURL(rows)
+		if err != nil {
+			return nil, err
+		}
+		urls = append(urls, url)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return urls, nil
+}
+
 // LogURLHistory logs a URL modification
 func (r *PostgresRepository) LogURLHistory(ctx context.Context, urlID int64, short string, action string, oldValue, newValue interface{}, modifiedBy string) error {
 	// Convert values to JSON
@@ -356,76 +1295,239 @@ func (r *PostgresRepository) LogURLHistory(ctx context.Context, urlID int64, sho
 	return err
 }
 
-// GetClickAnalytics retrieves aggregated click analytics data for a URL
-func (r *PostgresRepository) GetClickAnalytics(ctx context.Context, short string) (map[string]interface{}, error) {
-	// Get total clicks
-	var totalClicks int64
-	err := r.pool.QueryRow(ctx, "SELECT COUNT(*) FROM clicks WHERE url_short = $1", short).Scan(&totalClicks)
+// GetURLHistory retrieves a page of history entries for a URL, newest first, and the total number of matching entries
+func (r *PostgresRepository) GetURLHistory(ctx context.Context, short string, filter HistoryFilter) ([]*models.URLHistory, int64, error) {
+	// Count all matching entries
+	var total int64
+	err := r.pool.QueryRow(ctx,
+		"SELECT COUNT(*) FROM url_history WHERE url_short = $1 AND ($2::text = '' OR action = $2)",
+		short, filter.Action).Scan(&total)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
-	// Get clicks by browser
-	rows, err := r.pool.Query(ctx, "SELECT browser, COUNT(*) FROM clicks WHERE url_short = $1 GROUP BY browser", short)
+	// Get the requested page
+	rows, err := r.pool.Query(ctx,
+		"SELECT id, url_id, url_short, action, old_value, new_value, modified_at, COALESCE(modified_by, '') FROM url_history WHERE url_short = $1 AND ($2::text = '' OR action = $2) ORDER BY modified_at DESC, id DESC LIMIT $3 OFFSET $4",
+		short, filter.Action, filter.Limit, filter.Offset)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	defer rows.Close()
 
-	browserStats := make(map[string]int64)
+	var history []*models.URLHistory
 	for rows.Next() {
-		var browser string
-		var count int64
-		if err := rows.Scan(&browser, &count); err != nil {
-			return nil, err
+		entry := &models.URLHistory{}
+		err := rows.Scan(&entry.ID, &entry.URLID, &entry.URLShort, &entry.Action, &entry.OldValue, &entry.NewValue, &entry.ModifiedAt, &entry.ModifiedBy)
+		if err != nil {
+			return nil, 0, err
 		}
-		browserStats[browser] = count
+		history = append(history, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
 	}
 
-	// Get clicks by device
-	rows, err = r.pool.Query(ctx, "SELECT device, COUNT(*) FROM clicks WHERE url_short = $1 GROUP BY device", short)
+	return history, total, nil
+}
+
+// ClickCountsByMetadata counts clicks across a creator's live URLs grouped by the value of a metadata key
+func (r *PostgresRepository) ClickCountsByMetadata(ctx context.Context, creatorReference string, key string) ([]*models.MetadataClickCount, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT u.metadata->>$2 AS value, COUNT(DISTINCT u.id) AS urls, COUNT(c.id) AS clicks
+		FROM urls u
+		LEFT JOIN clicks c ON c.url_id = u.id
+		WHERE u.creator_reference = $1 AND u.deleted_at IS NULL AND u.metadata ? $2
+		GROUP BY value
+		ORDER BY clicks DESC, value`,
+		creatorReference, key)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	deviceStats := make(map[string]int64)
+	var counts []*models.MetadataClickCount
 	for rows.Next() {
-		var device string
-		var count int64
-		if err := rows.Scan(&device, &count); err != nil {
+		count := &models.MetadataClickCount{}
+		if err := rows.Scan(&count.Value, &count.URLs, &count.Clicks); err != nil {
 			return nil, err
 		}
-		deviceStats[device] = count
+		counts = append(counts, count)
+	}
+	return counts, rows.Err()
+}
+
+// CountClicks counts the recorded clicks of a URL using the url_short index. The clicks table is the
+// authoritative click count; urls.clicks is a denormalized counter that may lag behind it.
+func (r *PostgresRepository) CountClicks(ctx context.Context, short string) (int64, error) {
+	var count int64
+	err := r.pool.QueryRow(ctx, "SELECT COUNT(*) FROM clicks WHERE url_short = $1", short).Scan(&count)
+	if err != nil {
+		return 0, err
 	}
+	return count, nil
+}
 
-	// Get clicks by location
-	rows, err = r.pool.Query(ctx, "SELECT location, COUNT(*) FROM clicks WHERE url_short = $1 GROUP BY location", short)
+// GetResolveTiming computes percentiles of the captured resolve times of a URL's clicks, interpolating
+// between samples. Clicks without a resolve time are ignored.
+func (r *PostgresRepository) GetResolveTiming(ctx context.Context, short string) (*models.ResolveTiming, error) {
+	timing := &models.ResolveTiming{}
+	err := r.pool.QueryRow(ctx, `
+		SELECT COUNT(resolve_ms),
+			COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY resolve_ms), 0),
+			COALESCE(percentile_cont(0.95) WITHIN GROUP (ORDER BY resolve_ms), 0),
+			COALESCE(percentile_cont(0.99) WITHIN GROUP (ORDER BY resolve_ms), 0)
+		FROM clicks
+		WHERE url_short = $1
+	`, short).Scan(&timing.Samples, &timing.P50, &timing.P95, &timing.P99)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
+	return timing, nil
+}
 
-	locationStats := make(map[string]int64)
-	for rows.Next() {
-		var location string
-		var count int64
-		if err := rows.Scan(&location, &count); err != nil {
-			return nil, err
+// clickRangeCondition restricts clicks to rng, comparing timestamps with the parameters at
+// positions from and from+1. It returns the condition and its arguments; open bounds are NULL.
+func clickRangeCondition(rng ClickRange, from int) (string, []any) {
+	bound := func(t time.Time) any {
+		if t.IsZero() {
+			return nil
 		}
-		locationStats[location] = count
+		return t.UTC()
+	}
+	condition := fmt.Sprintf("($%[1]d::timestamp IS NULL OR timestamp >= $%[1]d) AND ($%[2]d::timestamp IS NULL OR timestamp < $%[2]d)", from, from+1)
+	return condition, []any{bound(rng.From), bound(rng.To)}
+}
+
+// GetClickAnalytics retrieves aggregated click analytics data for a URL's clicks within rng
+func (r *PostgresRepository) GetClickAnalytics(ctx context.Context, short string, rng ClickRange) (map[string]interface{}, error) {
+	inRange, rangeArgs := clickRangeCondition(rng, 2)
+	args := append([]any{short}, rangeArgs...)
+
+	// Get total clicks
+	var totalClicks int64
+	err := r.pool.QueryRow(ctx, "SELECT COUNT(*) FROM clicks WHERE url_short = $1 AND "+inRange, args...).Scan(&totalClicks)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get clicks by browser, device, operating system and referrer (leaving out clicks recorded before
+	// they were tracked), location and the link a random link redirected to
+	browserStats, err := r.countClicksBy(ctx, "browser", "url_short = $1 AND "+inRange, args)
+	if err != nil {
+		return nil, err
+	}
+	deviceStats, err := r.countClicksBy(ctx, "device", "url_short = $1 AND "+inRange, args)
+	if err != nil {
+		return nil, err
+	}
+	osStats, err := r.countClicksBy(ctx, "os", "url_short = $1 AND os <> '' AND "+inRange, args)
+	if err != nil {
+		return nil, err
+	}
+	locationStats, err := r.countClicksBy(ctx, "location", "url_short = $1 AND "+inRange, args)
+	if err != nil {
+		return nil, err
+	}
+	targetStats, err := r.countClicksBy(ctx, "target", "url_short = $1 AND target <> '' AND "+inRange, args)
+	if err != nil {
+		return nil, err
+	}
+	referrerStats, err := r.countClicksBy(ctx, "referrer", "url_short = $1 AND referrer <> '' AND "+inRange, args)
+	if err != nil {
+		return nil, err
 	}
 
 	// Return aggregated data
-	return map[string]interface{}{
+	analytics := map[string]interface{}{
 		"total_clicks": totalClicks,
 		"browsers":     browserStats,
 		"devices":      deviceStats,
+		"os":           osStats,
 		"locations":    locationStats,
-	}, nil
+		"referrers":    referrerStats,
+	}
+	if len(targetStats) > 0 {
+		analytics["targets"] = targetStats
+	}
+	return analytics, nil
+}
+
+// countClicksBy counts the clicks matching condition grouped by column, which must be a trusted column name
+func (r *PostgresRepository) countClicksBy(ctx context.Context, column string, condition string, args []any) (map[string]int64, error) {
+	rows, err := r.pool.Query(ctx, "SELECT COALESCE("+column+", ''), COUNT(*) FROM clicks WHERE "+condition+" GROUP BY 1", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int64)
+	for rows.Next() {
+		var value string
+		var count int64
+		if err := rows.Scan(&value, &count); err != nil {
+			return nil, err
+		}
+		counts[value] = count
+	}
+	return counts, rows.Err()
+}
+
+// Ping checks that the database can be reached
+func (r *PostgresRepository) Ping(ctx context.Context) error {
+	return r.pool.Ping(ctx)
+}
+
+// Stats returns a snapshot of the database connection pool
+func (r *PostgresRepository) Stats() PoolStats {
+	stat := r.pool.Stat()
+	return PoolStats{
+		TotalConns:    int64(stat.TotalConns()),
+		AcquiredConns: int64(stat.AcquiredConns()),
+		IdleConns:     int64(stat.IdleConns()),
+		MaxConns:      int64(stat.MaxConns()),
+		EmptyAcquires: stat.EmptyAcquireCount(),
+		Timeouts:      stat.CanceledAcquireCount(),
+	}
 }
 
 // Close closes the database connection
 func (r *PostgresRepository) Close() {
 	r.pool.Close()
 }
+
+// GetCreatorDefaults returns a creator's defaults for new links
+func (r *PostgresRepository) GetCreatorDefaults(ctx context.Context, creatorReference string) (*models.CreatorDefaults, error) {
+	defaults := &models.CreatorDefaults{}
+	err := r.pool.QueryRow(ctx,
+		"SELECT creator_reference, expiry_seconds, rate_limit, redirect_headers, updated_at FROM creator_defaults WHERE creator_reference = $1",
+		creatorReference).Scan(&defaults.CreatorReference, &defaults.Expiry, &defaults.RateLimit, &defaults.RedirectHeaders, &defaults.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrCreatorDefaultsNotFound
+		}
+		return nil, err
+	}
+	return defaults, nil
+}
+
+// SetCreatorDefaults creates or replaces a creator's defaults for new links
+func (r *PostgresRepository) SetCreatorDefaults(ctx context.Context, defaults *models.CreatorDefaults) (*models.CreatorDefaults, error) {
+	stored := &models.CreatorDefaults{}
+	err := r.pool.QueryRow(ctx, `
+		INSERT INTO creator_defaults (creator_reference, expiry_seconds, rate_limit, redirect_headers, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (creator_reference) DO UPDATE SET
+			expiry_seconds = EXCLUDED.expiry_seconds,
+			rate_limit = EXCLUDED.rate_limit,
+			redirect_headers = EXCLUDED.redirect_headers,
+			updated_at = EXCLUDED.updated_at
+		RETURNING creator_reference, expiry_seconds, rate_limit, redirect_headers, updated_at`,
+		defaults.CreatorReference, defaults.Expiry, defaults.RateLimit, defaults.RedirectHeaders, defaults.UpdatedAt,
+	).Scan(&stored.CreatorReference, &stored.Expiry, &stored.RateLimit, &stored.RedirectHeaders, &stored.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return stored, nil
+}
@@ -2,6 +2,9 @@ package store
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sync"
 	"testing"
 	"time"
 
@@ -73,12 +76,53 @@ func TestPostgresRepository_Integration(t *testing.T) {
 
 	// Test incrementing clicks
 	t.Run("IncrementClicks", func(t *testing.T) {
-		err := repo.IncrementClicks(ctx, "test123")
+		before := time.Now().Add(-time.Minute)
+		clicks, err := repo.IncrementClicks(ctx, "test123")
 		assert.NoError(t, err)
+		assert.Equal(t, int64(1), clicks)
 
 		url, err := repo.GetByShort(ctx, "test123")
 		assert.NoError(t, err)
 		assert.Equal(t, int64(1), url.Clicks)
+		if assert.NotNil(t, url.LastAccessedAt) {
+			assert.True(t, url.LastAccessedAt.After(before))
+		}
+
+		_, err = repo.IncrementClicks(ctx, "missing")
+		assert.ErrorIs(t, err, ErrURLNotFound)
+	})
+
+	t.Run("IncrementClicksMaxClicks", func(t *testing.T) {
+		limited := models.NewURL("https://example.com/limited", "maxclicks", "", time.Time{}, "")
+		limited.MaxClicks = 3
+		_, err := repo.Create(ctx, limited)
+		assert.NoError(t, err)
+
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		var succeeded, limitReached int
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := repo.IncrementClicks(ctx, "maxclicks")
+				mu.Lock()
+				defer mu.Unlock()
+				if err == nil {
+					succeeded++
+				} else if errors.Is(err, ErrClickLimitReached) {
+					limitReached++
+				}
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, 3, succeeded)
+		assert.Equal(t, 7, limitReached)
+
+		url, err := repo.GetByShort(ctx, "maxclicks")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), url.Clicks)
+		assert.Equal(t, int64(3), url.MaxClicks)
 	})
 
 	// Test storing click analytics
@@ -95,6 +139,7 @@ func TestPostgresRepository_Integration(t *testing.T) {
 
 		// Create a click
 		click := models.NewClick(retrievedURL.ID, "clicktest", "127.0.0.1", "Unknown", "Chrome", "Desktop")
+		click.Extra = map[string]string{"screen": "1920x1080"}
 		err = repo.StoreClick(ctx, click)
 		assert.NoError(t, err)
 	})
@@ -107,13 +152,85 @@ func TestPostgresRepository_Integration(t *testing.T) {
 		assert.Equal(t, "clicktest", clicks[0].URLShort)
 	})
 
+	// Test rebuilding click counts from the clicks table
+	t.Run("RebuildClickCounts", func(t *testing.T) {
+		clicks, err := repo.RebuildClickCount(ctx, "clicktest")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), clicks)
+
+		shorts, lastID, err := repo.RebuildClickCounts(ctx, 0, 100)
+		assert.NoError(t, err)
+		assert.Contains(t, shorts, "clicktest")
+		assert.Greater(t, lastID, int64(0))
+
+		url, err := repo.GetByShort(ctx, "clicktest")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), url.Clicks)
+	})
+
+	// Test that rebuilding keeps clicks seeded on import
+	t.Run("RebuildKeepsSeededClicks", func(t *testing.T) {
+		url := models.NewURL("https://example.com/seeded", "seeded", "", time.Time{}, "")
+		url.Clicks = 100
+		url.SeededClicks = 100
+		created, err := repo.Create(ctx, url)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(100), created.SeededClicks)
+
+		err = repo.StoreClick(ctx, models.NewClick(created.ID, "seeded", "127.0.0.1", "Unknown", "Chrome", "Desktop"))
+		assert.NoError(t, err)
+
+		clicks, err := repo.RebuildClickCount(ctx, "seeded")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(101), clicks)
+	})
+
+	// Test streaming clicks by short code
+	t.Run("StreamClicksByShort", func(t *testing.T) {
+		var clicks []*models.Click
+		err := repo.StreamClicksByShort(ctx, "clicktest", func(click *models.Click) error {
+			clicks = append(clicks, click)
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Len(t, clicks, 1)
+		assert.Equal(t, "Chrome", clicks[0].Browser)
+		assert.Equal(t, map[string]string{"screen": "1920x1080"}, clicks[0].Extra)
+
+		// Callback errors stop the stream
+		err = repo.StreamClicksByShort(ctx, "clicktest", func(click *models.Click) error {
+			return assert.AnError
+		})
+		assert.Equal(t, assert.AnError, err)
+	})
+
 	// Test checking for recent clicks
 	t.Run("HasRecentClick", func(t *testing.T) {
-		hasRecent, err := repo.HasRecentClick(ctx, "clicktest", "127.0.0.1", "Chrome", "Desktop")
+		hasRecent, err := repo.HasRecentClick(ctx, "clicktest", "127.0.0.1", "Chrome", "Desktop", time.Hour)
 		assert.NoError(t, err)
 		assert.True(t, hasRecent)
 	})
 
+	t.Run("HasRecentClickWindow", func(t *testing.T) {
+		url := models.NewURL("https://example.com/dedup", "dedupwindow", "Dedup Window", time.Now().Add(24*time.Hour), "ABC")
+		_, err := repo.Create(ctx, url)
+		assert.NoError(t, err)
+		stored, err := repo.GetByShort(ctx, "dedupwindow")
+		assert.NoError(t, err)
+
+		click := models.NewClick(stored.ID, "dedupwindow", "10.0.0.1", "Unknown", "Chrome", "Desktop")
+		click.Timestamp = time.Now().Add(-30 * time.Minute)
+		assert.NoError(t, repo.StoreClick(ctx, click))
+
+		// A click inside the window is recent; the same click outside a shorter window isn't
+		hasRecent, err := repo.HasRecentClick(ctx, "dedupwindow", "10.0.0.1", "Chrome", "Desktop", 31*time.Minute)
+		assert.NoError(t, err)
+		assert.True(t, hasRecent)
+		hasRecent, err = repo.HasRecentClick(ctx, "dedupwindow", "10.0.0.1", "Chrome", "Desktop", 29*time.Minute)
+		assert.NoError(t, err)
+		assert.False(t, hasRecent)
+	})
+
 	// Test updating a URL
 	t.Run("UpdateURL", func(t *testing.T) {
 		// Get the URL to update
@@ -146,12 +263,592 @@ func TestPostgresRepository_Integration(t *testing.T) {
 		assert.NoError(t, err)
 	})
 
+	// Test filtering and paging URL history
+	t.Run("GetURLHistory", func(t *testing.T) {
+		url, err := repo.GetByShort(ctx, "clicktest")
+		assert.NoError(t, err)
+
+		// Log a few more updates and a delete
+		for i := 0; i < 3; i++ {
+			err = repo.LogURLHistory(ctx, url.ID, "clicktest", "update", nil, map[string]int{"version": i}, "test-user")
+			assert.NoError(t, err)
+		}
+		err = repo.LogURLHistory(ctx, url.ID, "clicktest", "delete", url, nil, "test-user")
+		assert.NoError(t, err)
+
+		history, total, err := repo.GetURLHistory(ctx, "clicktest", HistoryFilter{Action: "update", Limit: 2})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(4), total)
+		assert.Len(t, history, 2)
+		for _, entry := range history {
+			assert.Equal(t, "update", entry.Action)
+		}
+
+		history, total, err = repo.GetURLHistory(ctx, "clicktest", HistoryFilter{Action: "update", Limit: 2, Offset: 2})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(4), total)
+		assert.Len(t, history, 2)
+	})
+
+	// Test counting raw clicks against the clicks counter
+	t.Run("CountClicks", func(t *testing.T) {
+		url, err := repo.GetByShort(ctx, "clicktest")
+		assert.NoError(t, err)
+		count, err := repo.CountClicks(ctx, "clicktest")
+		assert.NoError(t, err)
+		assert.Equal(t, url.Clicks, count)
+
+		// Drift the counter without recording a click
+		counter, err := repo.IncrementClicks(ctx, "clicktest")
+		assert.NoError(t, err)
+		assert.Equal(t, count+1, counter)
+		recount, err := repo.CountClicks(ctx, "clicktest")
+		assert.NoError(t, err)
+		assert.Equal(t, count, recount)
+
+		_, err = repo.RebuildClickCount(ctx, "clicktest")
+		assert.NoError(t, err)
+	})
+
+	t.Run("CreateBatch", func(t *testing.T) {
+		urls := []*models.URL{
+			models.NewURL("https://example.com/batch1", "batch1", "", time.Time{}, ""),
+			models.NewURL("https://example.com/batch2", "batch1", "", time.Time{}, ""),
+			models.NewURL("https://example.com/batch3", "batch3", "", time.Time{}, ""),
+		}
+		created, errs, err := repo.CreateBatch(ctx, urls)
+		assert.NoError(t, err)
+		assert.NoError(t, errs[0])
+		assert.ErrorIs(t, errs[1], ErrURLExists)
+		assert.NoError(t, errs[2])
+
+		// The failed insert didn't roll back the others
+		url, err := repo.GetByShort(ctx, "batch3")
+		assert.NoError(t, err)
+		assert.Equal(t, created[2].ID, url.ID)
+	})
+
+	t.Run("BulkCreate", func(t *testing.T) {
+		withMetadata := models.NewURL("https://example.com/bulk2", "bulk2", "Bulk", time.Time{}, "bulk-owner")
+		withMetadata.Metadata = map[string]string{"campaign": "import"}
+		urls := []*models.URL{
+			models.NewURL("https://example.com/bulk1", "bulk1", "", time.Time{}, ""),
+			withMetadata,
+			models.NewURL("https://example.com/bulk3", "bulk1", "", time.Time{}, ""),
+			models.NewURL("https://example.com/bulk4", "batch1", "", time.Time{}, ""),
+		}
+		errs, err := repo.BulkCreate(ctx, urls)
+		assert.NoError(t, err)
+		assert.NoError(t, errs[0])
+		assert.NoError(t, errs[1])
+		assert.ErrorIs(t, errs[2], ErrURLExists)
+		assert.ErrorIs(t, errs[3], ErrURLExists)
+
+		url, err := repo.GetByShort(ctx, "bulk1")
+		assert.NoError(t, err)
+		assert.Equal(t, "https://example.com/bulk1", url.Original)
+		assert.NotZero(t, url.ID)
+
+		url, err = repo.GetByShort(ctx, "bulk2")
+		assert.NoError(t, err)
+		assert.Equal(t, "Bulk", url.Title)
+		assert.Equal(t, "bulk-owner", url.CreatorReference)
+		assert.Equal(t, "import", url.Metadata["campaign"])
+	})
+
+	t.Run("UpdateTags", func(t *testing.T) {
+		tagged := models.NewURL("https://example.com/tagged", "tagtest", "", time.Time{}, "tag-owner")
+		tagged.Tags = []string{"old"}
+		_, err := repo.Create(ctx, tagged)
+		assert.NoError(t, err)
+
+		previous, errs, err := repo.UpdateTags(ctx, "tag-owner", []string{"tagtest", "batch1", "missing"},
+			TagChange{Add: []string{"new"}, Remove: []string{"old"}})
+		assert.NoError(t, err)
+		assert.NoError(t, errs[0])
+		assert.Equal(t, []string{"old"}, previous[0].Tags)
+		assert.ErrorIs(t, errs[1], ErrCreatorMismatch)
+		assert.ErrorIs(t, errs[2], ErrURLNotFound)
+
+		url, err := repo.GetByShort(ctx, "tagtest")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"new"}, url.Tags)
+	})
+
+	t.Run("GetTopURLs", func(t *testing.T) {
+		for i, clicks := range []int64{3, 9, 6} {
+			url := models.NewURL("https://example.com/top", fmt.Sprintf("toptest%d", i), "", time.Time{}, "top-creator")
+			url.Clicks = clicks
+			_, err := repo.Create(ctx, url)
+			assert.NoError(t, err)
+		}
+
+		urls, err := repo.GetTopURLs(ctx, "top-creator", 2)
+		assert.NoError(t, err)
+		if assert.Len(t, urls, 2) {
+			assert.Equal(t, "toptest1", urls[0].Short)
+			assert.Equal(t, "toptest2", urls[1].Short)
+		}
+
+		urls, err = repo.GetTopURLs(ctx, "", 100)
+		assert.NoError(t, err)
+		assert.Greater(t, len(urls), 3)
+	})
+
+	t.Run("GetByOriginalForCreator", func(t *testing.T) {
+		_, err := repo.Create(ctx, models.NewURL("https://example.com/reuse", "reusemine", "", time.Time{}, "reuser"))
+		assert.NoError(t, err)
+		_, err = repo.Create(ctx, models.NewURL("https://example.com/reuse", "reusetheirs", "", time.Time{}, "someone-else"))
+		assert.NoError(t, err)
+		_, err = repo.Create(ctx, models.NewURL("https://example.com/reuse", "reuseexpired", "", time.Now().Add(-time.Hour), "reuser"))
+		assert.NoError(t, err)
+
+		url, err := repo.GetByOriginalForCreator(ctx, "https://example.com/reuse", "reuser")
+		assert.NoError(t, err)
+		assert.Equal(t, "reusemine", url.Short)
+
+		_, err = repo.GetByOriginalForCreator(ctx, "https://example.com/reuse", "nobody")
+		assert.ErrorIs(t, err, ErrURLNotFound)
+	})
+
+	t.Run("CreateOrGet", func(t *testing.T) {
+		const calls = 8
+		urls := make([]*models.URL, calls)
+		created := make([]bool, calls)
+		errs := make([]error, calls)
+		var wg sync.WaitGroup
+		for i := 0; i < calls; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				url := models.NewURL("https://example.com/create-or-get", fmt.Sprintf("createorget%d", i), "", time.Time{}, "concurrent")
+				urls[i], created[i], errs[i] = repo.CreateOrGet(ctx, url)
+			}(i)
+		}
+		wg.Wait()
+
+		createdCount := 0
+		for i := 0; i < calls; i++ {
+			assert.NoError(t, errs[i])
+			if errs[i] != nil {
+				continue
+			}
+			assert.Equal(t, urls[0].Short, urls[i].Short)
+			if created[i] {
+				createdCount++
+			}
+		}
+		assert.Equal(t, 1, createdCount)
+
+		count, err := repo.CountByCreator(ctx, "concurrent")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("ListURLs", func(t *testing.T) {
+		_, err := repo.Create(ctx, models.NewURL("https://example.com/list-a", "lista", "Plain", time.Time{}, "lister"))
+		assert.NoError(t, err)
+		_, err = repo.Create(ctx, models.NewURL("https://example.com/list_b", "listb", "50% off", time.Time{}, "lister"))
+		assert.NoError(t, err)
+		_, err = repo.Create(ctx, models.NewURL("https://example.com/list-c", "listc", "", time.Time{}, "lister"))
+		assert.NoError(t, err)
+		_, err = repo.IncrementClicks(ctx, "lista")
+		assert.NoError(t, err)
+		assert.NoError(t, repo.Delete(ctx, "listc"))
+
+		codes := func(filter URLListFilter) []string {
+			filter.CreatorReference = "lister"
+			urls, total, err := repo.ListURLs(ctx, filter, Page{Limit: 10})
+			assert.NoError(t, err)
+			shorts := make([]string, 0, len(urls))
+			for _, url := range urls {
+				shorts = append(shorts, url.Short)
+			}
+			assert.Equal(t, int64(len(shorts)), total)
+			return shorts
+		}
+
+		assert.Equal(t, []string{"listb", "lista"}, codes(URLListFilter{}))
+		assert.Equal(t, []string{"listc", "listb", "lista"}, codes(URLListFilter{IncludeDeleted: true}))
+		assert.Equal(t, []string{"lista"}, codes(URLListFilter{MinClicks: 1}))
+		assert.Equal(t, []string{"lista"}, codes(URLListFilter{Search: "PLAIN"}))
+		// LIKE wildcards in the search match literally
+		assert.Equal(t, []string{"listb"}, codes(URLListFilter{Search: "%"}))
+		assert.Equal(t, []string{"listb"}, codes(URLListFilter{Search: "_"}))
+		assert.Empty(t, codes(URLListFilter{Search: "' OR '1'='1"}))
+		assert.Empty(t, codes(URLListFilter{CreatedAfter: time.Now().Add(time.Hour)}))
+		assert.Empty(t, codes(URLListFilter{CreatedBefore: time.Now().Add(-time.Hour)}))
+	})
+
+	t.Run("SearchURLs", func(t *testing.T) {
+		_, err := repo.Create(ctx, models.NewURL("https://example.com/spring-sale", "searchurl", "", time.Time{}, "searcher"))
+		assert.NoError(t, err)
+		_, err = repo.Create(ctx, models.NewURL("https://example.org/docs", "searchtitle", "Spring spring catalogue", time.Time{}, "searcher"))
+		assert.NoError(t, err)
+		_, err = repo.Create(ctx, models.NewURL("https://example.net/spring", "searchother", "", time.Time{}, "other-searcher"))
+		assert.NoError(t, err)
+		_, err = repo.Create(ctx, models.NewURL("https://example.com/spring-old", "searchdeleted", "", time.Time{}, "searcher"))
+		assert.NoError(t, err)
+		assert.NoError(t, repo.Delete(ctx, "searchdeleted"))
+
+		codes := func(query string, creator string) []string {
+			urls, total, err := repo.SearchURLs(ctx, query, creator, Page{Limit: 10})
+			assert.NoError(t, err)
+			shorts := make([]string, 0, len(urls))
+			for _, url := range urls {
+				shorts = append(shorts, url.Short)
+			}
+			assert.Equal(t, int64(len(shorts)), total)
+			return shorts
+		}
+
+		// The title mentions spring twice, so it ranks first
+		assert.Equal(t, []string{"searchtitle", "searchurl"}, codes("spring", "searcher"))
+		assert.Equal(t, []string{"searchurl"}, codes("SPR sal", "searcher"))
+		assert.ElementsMatch(t, []string{"searchtitle", "searchurl", "searchother"}, codes("spring", ""))
+		assert.Empty(t, codes("spring & !", "nobody"))
+		assert.Empty(t, codes("' OR 1=1 --", "searcher"))
+	})
+
+	t.Run("ImportClicks", func(t *testing.T) {
+		created, err := repo.Create(ctx, models.NewURL("https://example.com/imported", "importtest", "", time.Time{}, "importer"))
+		assert.NoError(t, err)
+
+		past := time.Now().AddDate(-1, 0, 0)
+		clicks := []*models.Click{
+			{URLShort: "othercode", IP: "203.0.113.1", Browser: "Chrome", Referrer: "google.com", Timestamp: past},
+			{IP: "203.0.113.2", Browser: "Firefox", Timestamp: past.Add(time.Hour)},
+		}
+		count, err := repo.ImportClicks(ctx, "importtest", clicks)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), count)
+		for _, click := range clicks {
+			assert.Equal(t, created.ID, click.URLID)
+			assert.Equal(t, "importtest", click.URLShort)
+		}
+
+		analytics, err := repo.GetClickAnalytics(ctx, "importtest", ClickRange{From: past.Add(-time.Hour)})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), analytics["total_clicks"])
+		assert.Equal(t, map[string]int64{"google.com": 1}, analytics["referrers"])
+
+		points, err := repo.GetClickTimeSeries(ctx, "importtest", ClickRange{From: past.Add(-time.Hour), To: past.Add(time.Hour)}, "hour", "UTC")
+		assert.NoError(t, err)
+		if assert.Len(t, points, 1) {
+			assert.Equal(t, int64(1), points[0].Clicks)
+		}
+
+		_, err = repo.ImportClicks(ctx, "importmissing", []*models.Click{{Timestamp: past}})
+		assert.ErrorIs(t, err, ErrURLNotFound)
+	})
+
+	t.Run("Notes", func(t *testing.T) {
+		url := models.NewURL("https://example.com/notes", "notestest", "Title", time.Time{}, "noter")
+		url.Notes = "internal note"
+		_, err := repo.Create(ctx, url)
+		assert.NoError(t, err)
+
+		stored, err := repo.GetByShort(ctx, "notestest")
+		assert.NoError(t, err)
+		assert.Equal(t, "internal note", stored.Notes)
+		assert.Equal(t, "Title", stored.Title)
+
+		stored.Notes = ""
+		assert.NoError(t, repo.UpdateURLWithCreator(ctx, "notestest", stored, "noter"))
+		stored, err = repo.GetByShort(ctx, "notestest")
+		assert.NoError(t, err)
+		assert.Empty(t, stored.Notes)
+	})
+
+	// Test purging expired URLs along with their clicks
+	t.Run("PurgeExpired", func(t *testing.T) {
+		expired, err := repo.Create(ctx, models.NewURL("https://example.com/expired", "purgeold", "", time.Now().Add(-2*time.Hour), ""))
+		assert.NoError(t, err)
+		assert.NoError(t, repo.StoreClick(ctx, models.NewClick(expired.ID, expired.Short, "127.0.0.1", "Unknown", "Chrome", "Desktop")))
+
+		_, err = repo.Create(ctx, models.NewURL("https://example.com/recent", "purgenew", "", time.Now().Add(-time.Minute), ""))
+		assert.NoError(t, err)
+
+		shorts, err := repo.PurgeExpired(ctx, time.Hour, 100)
+		assert.NoError(t, err)
+		assert.Contains(t, shorts, "purgeold")
+		assert.NotContains(t, shorts, "purgenew")
+
+		_, err = repo.GetByShortIncludingDeleted(ctx, "purgeold")
+		assert.ErrorIs(t, err, ErrURLNotFound)
+		clicks, err := repo.GetClicksByShort(ctx, "purgeold")
+		assert.NoError(t, err)
+		assert.Empty(t, clicks)
+		_, err = repo.GetByShortIncludingDeleted(ctx, "purgenew")
+		assert.NoError(t, err)
+	})
+
+	t.Run("StreamByCreator", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			url := models.NewURL("https://example.com/stream", fmt.Sprintf("streamtest%d", i), "", time.Time{}, "stream-creator")
+			url.CreatedAt = time.Now().Add(time.Duration(i-3) * time.Hour)
+			_, err := repo.Create(ctx, url)
+			assert.NoError(t, err)
+		}
+		expired := models.NewURL("https://example.com/stream", "streamexpired", "", time.Now().Add(-time.Hour), "stream-creator")
+		_, err := repo.Create(ctx, expired)
+		assert.NoError(t, err)
+
+		var codes []string
+		err = repo.StreamByCreator(ctx, "stream-creator", func(url *models.URL) error {
+			codes = append(codes, url.Short)
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"streamtest0", "streamtest1", "streamtest2"}, codes)
+	})
+
+	t.Run("GetByShorts", func(t *testing.T) {
+		_, err := repo.Create(ctx, models.NewURL("https://example.com/a", "resolvea", "", time.Time{}, "resolver"))
+		assert.NoError(t, err)
+		_, err = repo.Create(ctx, models.NewURL("https://example.com/b", "resolveexpired", "", time.Now().Add(-time.Hour), "resolver"))
+		assert.NoError(t, err)
+		_, err = repo.Create(ctx, models.NewURL("https://example.com/c", "resolvedeleted", "", time.Time{}, "resolver"))
+		assert.NoError(t, err)
+		assert.NoError(t, repo.Delete(ctx, "resolvedeleted"))
+
+		urls, err := repo.GetByShorts(ctx, []string{"resolvea", "resolveexpired", "resolvedeleted", "resolvemissing"})
+		assert.NoError(t, err)
+		var codes []string
+		for _, url := range urls {
+			codes = append(codes, url.Short)
+		}
+		assert.ElementsMatch(t, []string{"resolvea", "resolveexpired"}, codes)
+	})
+
+	t.Run("CountByCreator", func(t *testing.T) {
+		for _, url := range []*models.URL{
+			models.NewURL("https://example.com/count", "countlive", "", time.Time{}, "count-creator"),
+			models.NewURL("https://example.com/count", "countfuture", "", time.Now().Add(time.Hour), "count-creator"),
+			models.NewURL("https://example.com/count", "countexpired", "", time.Now().Add(-time.Hour), "count-creator"),
+			models.NewURL("https://example.com/count", "countdeleted", "", time.Time{}, "count-creator"),
+		} {
+			_, err := repo.Create(ctx, url)
+			assert.NoError(t, err)
+		}
+		assert.NoError(t, repo.Delete(ctx, "countdeleted"))
+
+		count, err := repo.CountByCreator(ctx, "count-creator")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), count)
+
+		count, err = repo.CountByCreator(ctx, "count-nobody")
+		assert.NoError(t, err)
+		assert.Zero(t, count)
+	})
+
+	t.Run("StoreCountedClick", func(t *testing.T) {
+		url, err := repo.Create(ctx, models.NewURL("https://example.com/counted", "countedclick", "", time.Time{}, ""))
+		assert.NoError(t, err)
+
+		clicks, err := repo.StoreCountedClick(ctx, models.NewClick(url.ID, url.Short, "127.0.0.1", "Unknown", "Chrome", "Desktop"))
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), clicks)
+
+		// A click counted elsewhere is stored without another increment
+		counted := models.NewClick(url.ID, url.Short, "127.0.0.2", "Unknown", "Chrome", "Desktop")
+		counted.Counted = true
+		_, err = repo.StoreCountedClick(ctx, counted)
+		assert.NoError(t, err)
+
+		// Past the limit, neither the click nor the increment is written
+		_, err = repo.pool.Exec(ctx, "UPDATE urls SET max_clicks = 1 WHERE short = $1", url.Short)
+		assert.NoError(t, err)
+		_, err = repo.StoreCountedClick(ctx, models.NewClick(url.ID, url.Short, "127.0.0.3", "Unknown", "Chrome", "Desktop"))
+		assert.ErrorIs(t, err, ErrClickLimitReached)
+
+		stored, err := repo.CountClicks(ctx, url.Short)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), stored)
+		got, err := repo.GetByShort(ctx, url.Short)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), got.Clicks)
+	})
+
+	t.Run("Restore", func(t *testing.T) {
+		_, err := repo.Create(ctx, models.NewURL("https://example.com/restore", "restoreme", "", time.Time{}, "alice"))
+		assert.NoError(t, err)
+
+		_, err = repo.Restore(ctx, "restoreme")
+		assert.ErrorIs(t, err, ErrRestoreConflict)
+
+		assert.NoError(t, repo.Delete(ctx, "restoreme"))
+		url, err := repo.Restore(ctx, "restoreme")
+		assert.NoError(t, err)
+		assert.Nil(t, url.DeletedAt)
+		_, err = repo.GetByShort(ctx, "restoreme")
+		assert.NoError(t, err)
+
+		_, err = repo.Restore(ctx, "neverexisted")
+		assert.ErrorIs(t, err, ErrURLNotFound)
+	})
+
+	t.Run("NextURLID", func(t *testing.T) {
+		id, err := repo.NextURLID(ctx)
+		assert.NoError(t, err)
+
+		url := models.NewURL("https://example.com/sequential", EncodeBase62(id), "", time.Time{}, "")
+		url.ID = id
+		created, err := repo.Create(ctx, url)
+		assert.NoError(t, err)
+		assert.Equal(t, id, created.ID)
+
+		// The reserved ID isn't handed out again
+		next, err := repo.NextURLID(ctx)
+		assert.NoError(t, err)
+		assert.Greater(t, next, id)
+	})
+
+	t.Run("GetRandomURLByCreator", func(t *testing.T) {
+		random := models.NewURL("https://example.com/fallback", "randomtest", "", time.Time{}, "random-creator")
+		random.RandomTarget = true
+		_, err := repo.Create(ctx, random)
+		assert.NoError(t, err)
+
+		// Random links are never picked as a target
+		_, err = repo.GetRandomURLByCreator(ctx, "random-creator")
+		assert.ErrorIs(t, err, ErrURLNotFound)
+
+		_, err = repo.Create(ctx, models.NewURL("https://example.com/target", "randomtarget", "", time.Time{}, "random-creator"))
+		assert.NoError(t, err)
+		target, err := repo.GetRandomURLByCreator(ctx, "random-creator")
+		assert.NoError(t, err)
+		assert.Equal(t, "randomtarget", target.Short)
+	})
+
 	// Test getting click analytics
 	t.Run("GetClickAnalytics", func(t *testing.T) {
-		analytics, err := repo.GetClickAnalytics(ctx, "clicktest")
+		analytics, err := repo.GetClickAnalytics(ctx, "clicktest", ClickRange{})
 		assert.NoError(t, err)
 		assert.NotNil(t, analytics)
 		assert.Contains(t, analytics, "total_clicks")
+
+		// Clicks outside the range aren't aggregated
+		analytics, err = repo.GetClickAnalytics(ctx, "clicktest", ClickRange{From: time.Now().Add(time.Hour)})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), analytics["total_clicks"])
+	})
+
+	// Test resolve time percentiles over a fixture of 1..100ms plus an untimed click
+	t.Run("GetResolveTiming", func(t *testing.T) {
+		created, err := repo.Create(ctx, models.NewURL("https://example.com", "timing", "", time.Time{}, "ABC"))
+		assert.NoError(t, err)
+		for i := 1; i <= 100; i++ {
+			click := models.NewClick(created.ID, "timing", "127.0.0.1", "Unknown", "Chrome", "Desktop")
+			ms := float64(i)
+			click.ResolveMs = &ms
+			assert.NoError(t, repo.StoreClick(ctx, click))
+		}
+		assert.NoError(t, repo.StoreClick(ctx, models.NewClick(created.ID, "timing", "127.0.0.1", "Unknown", "Chrome", "Desktop")))
+
+		timing, err := repo.GetResolveTiming(ctx, "timing")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(100), timing.Samples)
+		assert.InDelta(t, 50.5, timing.P50, 1e-9)
+		assert.InDelta(t, 95.05, timing.P95, 1e-9)
+		assert.InDelta(t, 99.01, timing.P99, 1e-9)
+	})
+
+	// Test bucketing a fixture of clicks by hour of day and day of week in UTC and in Tokyo
+	t.Run("GetClickDistribution", func(t *testing.T) {
+		created, err := repo.Create(ctx, models.NewURL("https://example.com", "distribution", "", time.Time{}, "ABC"))
+		assert.NoError(t, err)
+		for _, timestamp := range []string{
+			"2024-03-04T09:30:00Z", // Monday
+			"2024-03-04T09:45:00Z", // Monday
+			"2024-03-09T20:00:00Z", // Saturday, already Sunday in Tokyo
+			"2024-05-01T12:00:00Z", // outside the range
+		} {
+			click := models.NewClick(created.ID, "distribution", "127.0.0.1", "Unknown", "Chrome", "Desktop")
+			click.Timestamp, err = time.Parse(time.RFC3339, timestamp)
+			assert.NoError(t, err)
+			assert.NoError(t, repo.StoreClick(ctx, click))
+		}
+		rng := ClickRange{From: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)}
+
+		distribution, err := repo.GetClickDistribution(ctx, "distribution", rng, "UTC")
+		assert.NoError(t, err)
+		var hours [24]int64
+		hours[9], hours[20] = 2, 1
+		assert.Equal(t, hours, distribution.HourOfDay)
+		assert.Equal(t, [7]int64{0, 2, 0, 0, 0, 0, 1}, distribution.DayOfWeek)
+
+		distribution, err = repo.GetClickDistribution(ctx, "distribution", rng, "Asia/Tokyo")
+		assert.NoError(t, err)
+		hours = [24]int64{}
+		hours[18], hours[5] = 2, 1
+		assert.Equal(t, hours, distribution.HourOfDay)
+		assert.Equal(t, [7]int64{1, 2, 0, 0, 0, 0, 0}, distribution.DayOfWeek)
+	})
+
+	// Test grouping a creator's clicks by a metadata key
+	t.Run("ClickCountsByMetadata", func(t *testing.T) {
+		for i, campaign := range []string{"spring", "spring", "autumn"} {
+			url := models.NewURL("https://example.com", fmt.Sprintf("campaign%d", i), "", time.Time{}, "marketer")
+			url.Metadata = map[string]string{"campaign": campaign}
+			created, err := repo.Create(ctx, url)
+			assert.NoError(t, err)
+			for j := 0; j <= i; j++ {
+				assert.NoError(t, repo.StoreClick(ctx, models.NewClick(created.ID, created.Short, "127.0.0.1", "Unknown", "Chrome", "Desktop")))
+			}
+		}
+
+		counts, err := repo.ClickCountsByMetadata(ctx, "marketer", "campaign")
+		assert.NoError(t, err)
+		assert.Equal(t, []*models.MetadataClickCount{
+			{Value: "autumn", URLs: 1, Clicks: 3},
+			{Value: "spring", URLs: 2, Clicks: 3},
+		}, counts)
+	})
+
+	// Test nil, future and past expiry
+	t.Run("Expiry", func(t *testing.T) {
+		cases := []struct {
+			short     string
+			expiresAt time.Time
+			found     bool
+		}{
+			{"expnil", time.Time{}, true},
+			{"expfuture", time.Now().Add(time.Hour), true},
+			{"exppast", time.Now().Add(-time.Hour), false},
+		}
+		for _, tc := range cases {
+			_, err := repo.Create(ctx, models.NewURL("https://example.com/"+tc.short, tc.short, "", tc.expiresAt, "ABC"))
+			assert.NoError(t, err)
+
+			url, err := repo.GetByShort(ctx, tc.short)
+			if tc.found {
+				assert.NoError(t, err, tc.short)
+				assert.False(t, url.IsExpired(), tc.short)
+			} else {
+				assert.Equal(t, ErrURLExpired, err, tc.short)
+			}
+
+			_, err = repo.GetByOriginal(ctx, "https://example.com/"+tc.short)
+			if tc.found {
+				assert.NoError(t, err, tc.short)
+			} else {
+				assert.Equal(t, ErrURLExpired, err, tc.short)
+			}
+		}
+
+		// Non-expiring URLs are stored as NULL
+		url, err := repo.GetByShort(ctx, "expnil")
+		assert.NoError(t, err)
+		assert.Nil(t, url.ExpiresAt)
+
+		// Only live URLs are listed for the creator
+		urls, total, err := repo.GetByCreator(ctx, "ABC", CreatorURLFilter{Sort: SortByCreatedAt, Descending: true, Limit: 100})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(len(urls)), total)
+		for _, url := range urls {
+			assert.NotEqual(t, "exppast", url.Short)
+		}
 	})
 
 	// Test deleting a URL
@@ -166,6 +863,48 @@ func TestPostgresRepository_Integration(t *testing.T) {
 		err = repo.Delete(ctx, "clicktest")
 		assert.NoError(t, err)
 	})
+
+	// Test getting a soft-deleted URL
+	t.Run("GetByShortIncludingDeleted", func(t *testing.T) {
+		url, err := repo.GetByShortIncludingDeleted(ctx, "test123")
+		assert.NoError(t, err)
+		assert.Equal(t, "https://example.com", url.Original)
+		assert.NotNil(t, url.DeletedAt)
+
+		// The default lookup still hides the deleted URL
+		_, err = repo.GetByShort(ctx, "test123")
+		assert.Equal(t, ErrURLNotFound, err)
+
+		// Expired URLs are returned as well
+		url, err = repo.GetByShortIncludingDeleted(ctx, "exppast")
+		assert.NoError(t, err)
+		assert.True(t, url.IsExpired())
+
+		_, err = repo.GetByShortIncludingDeleted(ctx, "missing")
+		assert.Equal(t, ErrURLNotFound, err)
+	})
+
+	// Test that the codes of deleted and expired URLs stay taken
+	t.Run("CreateTakenCode", func(t *testing.T) {
+		for _, short := range []string{"test123", "exppast"} {
+			_, err := repo.Create(ctx, models.NewURL("https://example.com/again", short, "", time.Time{}, ""))
+			assert.ErrorIs(t, err, ErrURLExists, short)
+		}
+	})
+
+	// Test that hard deletes only remove the given creator's URL
+	t.Run("HardDelete", func(t *testing.T) {
+		_, err := repo.Create(ctx, models.NewURL("https://example.com/owned", "owned", "", time.Time{}, "owner"))
+		assert.NoError(t, err)
+
+		assert.NoError(t, repo.HardDelete(ctx, "owned", "__selftest__"))
+		_, err = repo.GetByShortIncludingDeleted(ctx, "owned")
+		assert.NoError(t, err)
+
+		assert.NoError(t, repo.HardDelete(ctx, "owned", "owner"))
+		_, err = repo.GetByShortIncludingDeleted(ctx, "owned")
+		assert.Equal(t, ErrURLNotFound, err)
+	})
 }
 
 // TestPostgresRepository_Unit tests the PostgresRepository with a mock database.
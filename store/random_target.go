@@ -0,0 +1,28 @@
+package store
+
+import (
+	"context"
+	"errors"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/rs/zerolog/log"
+)
+
+// ResolveRandomTarget picks the link a random link redirects to on this hit: a random live, enabled
+// link of the same creator, or the random link itself when the creator has none
+func (s *URLService) ResolveRandomTarget(ctx context.Context, url *models.URL) (*models.URL, error) {
+	log.Debug().Str("short", url.Short).Str("creator_reference", url.CreatorReference).Msg("Resolving random link target")
+
+	target, err := s.db.GetRandomURLByCreator(ctx, url.CreatorReference)
+	if errors.Is(err, ErrURLNotFound) {
+		log.Info().Str("short", url.Short).Msg("Creator has no live links, falling back to the random link's URL")
+		return url, nil
+	}
+	if err != nil {
+		log.Error().Err(err).Str("short", url.Short).Msg("Failed to pick random link target")
+		return nil, err
+	}
+
+	log.Info().Str("short", url.Short).Str("target", target.Short).Msg("Random link target resolved")
+	return target, nil
+}
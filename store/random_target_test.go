@@ -0,0 +1,73 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRandomTarget(t *testing.T) {
+	ctx := context.Background()
+	random := &models.URL{Short: "surprise", Original: "https://example.com/fallback", CreatorReference: "alice", RandomTarget: true}
+
+	t.Run("Picks a link of the creator", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		service := NewURLService(mockRepo, nil)
+		mockRepo.On("GetRandomURLByCreator", ctx, "alice").Return(&models.URL{Short: "abc123", Original: "https://example.com/a"}, nil)
+
+		target, err := service.ResolveRandomTarget(ctx, random)
+		require.NoError(t, err)
+		assert.Equal(t, "abc123", target.Short)
+	})
+
+	t.Run("Falls back to its own URL without live links", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		service := NewURLService(mockRepo, nil)
+		mockRepo.On("GetRandomURLByCreator", ctx, "alice").Return(nil, ErrURLNotFound)
+
+		target, err := service.ResolveRandomTarget(ctx, random)
+		require.NoError(t, err)
+		assert.Same(t, random, target)
+	})
+
+	t.Run("Chosen target is recorded with the click", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		service := NewURLService(mockRepo, nil)
+
+		var stored *models.Click
+		mockRepo.On("HasRecentClick", ctx, "surprise", "1.2.3.4", mock.Anything, mock.Anything, mock.Anything).Return(false, nil)
+		mockRepo.On("GetByShort", ctx, "surprise").Return(&models.URL{ID: 1, Short: "surprise"}, nil)
+		mockRepo.On("StoreCountedClick", ctx, mock.AnythingOfType("*models.Click")).Run(func(args mock.Arguments) {
+			stored = args.Get(1).(*models.Click)
+		}).Return(int64(1), nil)
+
+		require.NoError(t, service.TrackClick(ctx, &ClickContext{Short: "surprise", IP: "1.2.3.4", Target: "abc123"}))
+		require.NotNil(t, stored)
+		assert.Equal(t, "abc123", stored.Target)
+	})
+
+	t.Run("Random links need a creator", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		service := NewURLService(mockRepo, nil)
+		mockRepo.On("GetByShortIncludingDeleted", ctx, mock.AnythingOfType("string")).Return(nil, ErrURLNotFound)
+
+		_, err := service.CreateShortURL(ctx, "https://example.com", "", "", 0, "", WithRandomTarget())
+		assert.ErrorIs(t, err, ErrRandomTargetWithoutCreator)
+	})
+
+	t.Run("Updates keep the link random", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		service := NewURLService(mockRepo, nil)
+		mockRepo.On("GetByShort", ctx, "surprise").Return(random, nil)
+		mockRepo.On("LogURLHistory", ctx, mock.Anything, "surprise", "update", mock.Anything, mock.Anything, "admin").Return(nil)
+		mockRepo.On("UpdateURL", ctx, "surprise", mock.AnythingOfType("*models.URL")).Return(nil)
+
+		updated, err := service.UpdateURL(ctx, "surprise", "Renamed", random.Original, 0, "admin")
+		require.NoError(t, err)
+		assert.True(t, updated.RandomTarget)
+	})
+}
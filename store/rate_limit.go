@@ -0,0 +1,42 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/rs/zerolog/log"
+)
+
+// RedirectRateWindow is the fixed window over which a URL's RateLimit is enforced
+const RedirectRateWindow = time.Minute
+
+// AllowRedirect counts a redirect against the URL's rate limit and returns ErrRateLimited once the
+// limit for the current window is exceeded. URLs without a limit are always allowed. Counting
+// requires a cache; if it is missing or fails, the redirect is allowed.
+func (s *URLService) AllowRedirect(ctx context.Context, url *models.URL) error {
+	if url.RateLimit <= 0 {
+		return nil
+	}
+	if s.cache == nil {
+		log.Debug().Str("short", url.Short).Msg("No cache configured, not enforcing redirect rate limit")
+		return nil
+	}
+
+	count, err := s.cache.IncrementRedirects(ctx, url.Short, RedirectRateWindow)
+	if err != nil {
+		log.Warn().Err(err).Str("short", url.Short).Msg("Failed to count redirect, allowing it")
+		return nil
+	}
+
+	if count > int64(url.RateLimit) {
+		log.Warn().
+			Str("short", url.Short).
+			Int64("count", count).
+			Int("rate_limit", url.RateLimit).
+			Msg("Redirect rate limit exceeded")
+		return ErrRateLimited
+	}
+
+	return nil
+}
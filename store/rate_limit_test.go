@@ -0,0 +1,45 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllowRedirect(t *testing.T) {
+	ctx := context.Background()
+	limited := &models.URL{Short: "abc123", RateLimit: 2}
+
+	// Test case 1: Redirects beyond the limit are rejected
+	t.Run("ExceedsLimit", func(t *testing.T) {
+		mockCache := new(MockCacheRepository)
+		service := NewURLService(new(MockURLRepository), mockCache)
+
+		mockCache.On("IncrementRedirects", ctx, "abc123", RedirectRateWindow).Return(int64(2), nil).Once()
+		mockCache.On("IncrementRedirects", ctx, "abc123", RedirectRateWindow).Return(int64(3), nil).Once()
+
+		assert.NoError(t, service.AllowRedirect(ctx, limited))
+		assert.Equal(t, ErrRateLimited, service.AllowRedirect(ctx, limited))
+	})
+
+	// Test case 2: URLs without a limit are not counted
+	t.Run("Unlimited", func(t *testing.T) {
+		mockCache := new(MockCacheRepository)
+		service := NewURLService(new(MockURLRepository), mockCache)
+
+		assert.NoError(t, service.AllowRedirect(ctx, &models.URL{Short: "abc123"}))
+		mockCache.AssertNotCalled(t, "IncrementRedirects")
+	})
+
+	// Test case 3: Counting failures allow the redirect
+	t.Run("FailsOpen", func(t *testing.T) {
+		mockCache := new(MockCacheRepository)
+		service := NewURLService(new(MockURLRepository), mockCache)
+		mockCache.On("IncrementRedirects", ctx, "abc123", RedirectRateWindow).Return(int64(0), assert.AnError)
+
+		assert.NoError(t, service.AllowRedirect(ctx, limited))
+		assert.NoError(t, NewURLService(new(MockURLRepository), nil).AllowRedirect(ctx, limited))
+	})
+}
@@ -0,0 +1,161 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultReachabilityTimeout bounds a destination reachability check when none is configured
+const DefaultReachabilityTimeout = 5 * time.Second
+
+// ErrDestinationUnreachable is returned when a destination URL didn't respond with a 2xx or 3xx status
+var ErrDestinationUnreachable = NewAPIError(http.StatusUnprocessableEntity, "destination_unreachable", "Destination URL is not reachable")
+
+// WithReachabilityTimeout bounds how long VerifyReachable waits for a destination, including the GET
+// fallback
+func WithReachabilityTimeout(timeout time.Duration) Option {
+	return func(s *URLService) {
+		s.reachabilityTimeout = timeout
+	}
+}
+
+// WithPrivateReachabilityChecks lets VerifyReachable check destinations on loopback, private and
+// link-local addresses, which it otherwise refuses so checks can't be used to scan internal services.
+// Meant for development and tests against local servers.
+func WithPrivateReachabilityChecks() Option {
+	return func(s *URLService) {
+		s.privateReachability = true
+	}
+}
+
+// VerifyReachable checks that a destination URL responds to a HEAD request with a 2xx or 3xx status,
+// without following redirects. Servers that reject HEAD are retried with a GET, whose body isn't read.
+// Invalid URLs fail validation like they would on creation; other failures, including destinations on
+// non-public addresses, return ErrDestinationUnreachable. Destinations with schemes other than http and
+// https can't be checked and pass.
+func (s *URLService) VerifyReachable(ctx context.Context, originalURL string) error {
+	log.Debug().Str("url", originalURL).Msg("Verifying destination is reachable")
+
+	if err := s.validateURL(originalURL); err != nil {
+		return err
+	}
+	parsed, _ := url.Parse(originalURL)
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		log.Debug().Str("url", originalURL).Msg("Skipping reachability check of non-HTTP destination")
+		return nil
+	}
+
+	timeout := s.reachabilityTimeout
+	if timeout <= 0 {
+		timeout = DefaultReachabilityTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	client, err := s.reachabilityClient(ctx, parsed)
+	if err == nil {
+		err = probe(ctx, client, http.MethodHead, originalURL)
+		if err != nil && ctx.Err() == nil {
+			log.Debug().Err(err).Str("url", originalURL).Msg("HEAD request failed, retrying with GET")
+			err = probe(ctx, client, http.MethodGet, originalURL)
+		}
+	}
+	if err != nil {
+		log.Error().Err(err).Str("url", originalURL).Msg("Destination is not reachable")
+		return ErrDestinationUnreachable
+	}
+
+	log.Info().Str("url", originalURL).Msg("Destination is reachable")
+	return nil
+}
+
+// reachabilityClient returns the client VerifyReachable probes target with. It doesn't follow redirects
+// and refuses destinations on non-public addresses. Direct connections are checked as they are dialed;
+// when target is reached through a proxy, which makes the connection itself, the addresses its host
+// resolves to are checked up front instead.
+func (s *URLService) reachabilityClient(ctx context.Context, target *url.URL) (*http.Client, error) {
+	// A redirect already shows the destination is served, wherever it leads
+	client := *s.httpClient
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	if s.privateReachability {
+		return &client, nil
+	}
+
+	// Only the standard transport can be guarded, so other round trippers are replaced by it
+	transport, ok := client.Transport.(*http.Transport)
+	if !ok {
+		transport = http.DefaultTransport.(*http.Transport)
+	}
+	transport = transport.Clone()
+	client.Transport = transport
+
+	if transport.Proxy != nil {
+		proxyURL, err := transport.Proxy(&http.Request{URL: target})
+		if err != nil {
+			return nil, err
+		}
+		if proxyURL != nil {
+			ips, err := net.DefaultResolver.LookupIP(ctx, "ip", target.Hostname())
+			if err != nil {
+				return nil, err
+			}
+			for _, ip := range ips {
+				if !isPublicIP(ip) {
+					return nil, fmt.Errorf("destination resolves to non-public address %s", ip)
+				}
+			}
+			return &client, nil
+		}
+	}
+
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Control: dialPublicOnly}
+	transport.DialContext = dialer.DialContext
+	return &client, nil
+}
+
+// dialPublicOnly is a net.Dialer control function that refuses connections to non-public addresses
+func dialPublicOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+		return fmt.Errorf("refusing to connect to non-public address %s", host)
+	}
+	return nil
+}
+
+// sharedAddressSpace is the carrier-grade NAT range, which isn't reachable from the internet either
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// isPublicIP reports whether ip is a globally routable unicast address, rather than a loopback,
+// private, link-local, shared, multicast or unspecified one
+func isPublicIP(ip net.IP) bool {
+	return ip.IsGlobalUnicast() && !ip.IsPrivate() && !sharedAddressSpace.Contains(ip)
+}
+
+// probe sends a request and fails unless it is answered with a 2xx or 3xx status
+func probe(ctx context.Context, client *http.Client, method, target string) error {
+	req, err := http.NewRequestWithContext(ctx, method, target, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
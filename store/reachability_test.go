@@ -0,0 +1,116 @@
+package store
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	neturl "net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyReachable(t *testing.T) {
+	ctx := context.Background()
+	service := NewURLService(new(MockURLRepository), nil, WithReachabilityTimeout(time.Second), WithPrivateReachabilityChecks())
+
+	serve := func(handler http.HandlerFunc) string {
+		server := httptest.NewServer(handler)
+		t.Cleanup(server.Close)
+		return server.URL
+	}
+
+	t.Run("Reachable", func(t *testing.T) {
+		url := serve(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, http.MethodHead, r.Method)
+		})
+		assert.NoError(t, service.VerifyReachable(ctx, url))
+	})
+
+	t.Run("Redirects are not followed", func(t *testing.T) {
+		url := serve(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/gone" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			http.Redirect(w, r, "/gone", http.StatusFound)
+		})
+		assert.NoError(t, service.VerifyReachable(ctx, url))
+	})
+
+	t.Run("HEAD blocked falls back to GET", func(t *testing.T) {
+		var gets atomic.Int32
+		url := serve(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			gets.Add(1)
+			_, _ = w.Write([]byte("hello"))
+		})
+		assert.NoError(t, service.VerifyReachable(ctx, url))
+		assert.Equal(t, int32(1), gets.Load())
+	})
+
+	t.Run("Error status", func(t *testing.T) {
+		url := serve(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		})
+		assert.ErrorIs(t, service.VerifyReachable(ctx, url), ErrDestinationUnreachable)
+	})
+
+	t.Run("Server down", func(t *testing.T) {
+		server := httptest.NewServer(http.NotFoundHandler())
+		url := server.URL
+		server.Close()
+		assert.ErrorIs(t, service.VerifyReachable(ctx, url), ErrDestinationUnreachable)
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		release := make(chan struct{})
+		url := serve(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+		})
+		defer close(release)
+
+		slow := NewURLService(new(MockURLRepository), nil, WithReachabilityTimeout(50*time.Millisecond), WithPrivateReachabilityChecks())
+		start := time.Now()
+		assert.ErrorIs(t, slow.VerifyReachable(ctx, url), ErrDestinationUnreachable)
+		assert.Less(t, time.Since(start), time.Second)
+	})
+
+	t.Run("Non-public destinations are refused", func(t *testing.T) {
+		var requests atomic.Int32
+		url := serve(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+		})
+
+		guarded := NewURLService(new(MockURLRepository), nil, WithReachabilityTimeout(time.Second))
+		for _, destination := range []string{url, "http://169.254.169.254/latest/meta-data/", "http://10.0.0.1/", "http://[::1]:8080/"} {
+			assert.ErrorIs(t, guarded.VerifyReachable(ctx, destination), ErrDestinationUnreachable, destination)
+		}
+		assert.Zero(t, requests.Load())
+	})
+
+	t.Run("Non-public destinations behind a proxy are refused", func(t *testing.T) {
+		var requests atomic.Int32
+		proxy := serve(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+		})
+		proxyURL, _ := neturl.Parse(proxy)
+
+		guarded := NewURLService(new(MockURLRepository), nil, WithReachabilityTimeout(time.Second),
+			WithHTTPClient(&http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(proxyURL)}}))
+		assert.ErrorIs(t, guarded.VerifyReachable(ctx, "http://127.0.0.1:8080/admin"), ErrDestinationUnreachable)
+		assert.Zero(t, requests.Load())
+	})
+
+	t.Run("Invalid URL", func(t *testing.T) {
+		assert.ErrorIs(t, service.VerifyReachable(ctx, "not a url"), ErrInvalidURL)
+	})
+}
@@ -0,0 +1,23 @@
+package store
+
+import (
+	"net/url"
+	"strings"
+)
+
+// ReferrerDirect is the referrer recorded for clicks without a usable Referer header
+const ReferrerDirect = "direct"
+
+// NormalizeReferrer reduces a Referer header to its lowercased host without port or a leading "www.",
+// so analytics group by site rather than by page. Empty or unparseable referrers are ReferrerDirect.
+func NormalizeReferrer(referrer string) string {
+	parsed, err := url.Parse(strings.TrimSpace(referrer))
+	if err != nil {
+		return ReferrerDirect
+	}
+	host := strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.")
+	if host == "" {
+		return ReferrerDirect
+	}
+	return host
+}
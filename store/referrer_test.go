@@ -0,0 +1,26 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeReferrer(t *testing.T) {
+	tests := []struct {
+		referrer string
+		want     string
+	}{
+		{"https://www.google.com/search?q=shortener", "google.com"},
+		{"https://News.YCombinator.com/item?id=1", "news.ycombinator.com"},
+		{"http://localhost:3000/dashboard", "localhost"},
+		{"android-app://com.slack/", "com.slack"},
+		{"", ReferrerDirect},
+		{"   ", ReferrerDirect},
+		{"/relative/path", ReferrerDirect},
+		{"://broken", ReferrerDirect},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, NormalizeReferrer(tt.referrer), tt.referrer)
+	}
+}
@@ -0,0 +1,65 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// tokenBucketScript takes a token from the bucket at KEYS[1], refilled at ARGV[1] tokens per second
+// up to ARGV[2] tokens, at ARGV[3] milliseconds. It returns whether a token was taken and, if not,
+// how many milliseconds until one is available.
+var tokenBucketScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'at')
+local tokens = tonumber(bucket[1]) or burst
+local at = tonumber(bucket[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - at) * rate / 1000)
+local allowed, wait = 0, 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	wait = math.ceil((1 - tokens) * 1000 / rate)
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'at', now)
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst * 1000 / rate))
+return {allowed, wait}
+`)
+
+// RedisRateLimiter is a token bucket rate limiter kept in Valkey/Redis, so the limit holds across
+// every instance sharing the cache
+type RedisRateLimiter struct {
+	client *redis.Client
+	prefix string
+	rate   float64
+	burst  int
+}
+
+// NewRateLimiter creates a rate limiter on the cache's connection that allows requestsPerMinute
+// requests per key on average, in bursts of up to burst requests. Keys are namespaced by prefix.
+func (c *CacheRepository) NewRateLimiter(prefix string, requestsPerMinute, burst int) *RedisRateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RedisRateLimiter{
+		client: c.client,
+		prefix: "ratelimit:" + prefix + ":",
+		rate:   float64(requestsPerMinute) / 60,
+		burst:  burst,
+	}
+}
+
+// Allow counts a request for key and reports whether it is within the limit, and if not, how long
+// until the next request would be
+func (l *RedisRateLimiter) Allow(ctx context.Context, key string) (bool, time.Duration, error) {
+	result, err := tokenBucketScript.Run(ctx, l.client, []string{l.prefix + key},
+		l.rate, l.burst, time.Now().UnixMilli()).Int64Slice()
+	if err != nil {
+		return false, 0, err
+	}
+	return result[0] == 1, time.Duration(result[1]) * time.Millisecond, nil
+}
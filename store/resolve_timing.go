@@ -0,0 +1,39 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/rs/zerolog/log"
+)
+
+// resolveMs returns the click's resolve time in fractional milliseconds, or nil when it wasn't captured
+func (c *ClickContext) resolveMs() *float64 {
+	if c.ResolveTime <= 0 {
+		return nil
+	}
+	ms := float64(c.ResolveTime) / float64(time.Millisecond)
+	return &ms
+}
+
+// GetResolveTiming returns the p50, p95 and p99 time a URL's redirects took to resolve its code.
+// Consistently slow links usually miss the cache.
+func (s *URLService) GetResolveTiming(ctx context.Context, short string) (*models.ResolveTiming, error) {
+	log.Debug().Str("short", short).Msg("Getting resolve timing")
+
+	timing, err := s.db.GetResolveTiming(ctx, short)
+	if err != nil {
+		log.Error().Err(err).Str("short", short).Msg("Failed to get resolve timing")
+		return nil, err
+	}
+
+	log.Info().
+		Str("short", short).
+		Int64("samples", timing.Samples).
+		Float64("p50_ms", timing.P50).
+		Float64("p99_ms", timing.P99).
+		Msg("Resolve timing retrieved successfully")
+
+	return timing, nil
+}
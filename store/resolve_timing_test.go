@@ -0,0 +1,51 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveTiming(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Captured resolve time is stored in milliseconds", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		service := NewURLService(mockRepo, nil)
+
+		var stored *models.Click
+		mockRepo.On("HasRecentClick", ctx, "abc123", "1.2.3.4", mock.Anything, mock.Anything, mock.Anything).Return(false, nil)
+		mockRepo.On("GetByShort", ctx, "abc123").Return(&models.URL{ID: 1, Short: "abc123"}, nil)
+		mockRepo.On("StoreCountedClick", ctx, mock.AnythingOfType("*models.Click")).Run(func(args mock.Arguments) {
+			stored = args.Get(1).(*models.Click)
+		}).Return(int64(1), nil)
+
+		click := &ClickContext{Short: "abc123", IP: "1.2.3.4", ResolveTime: 1500 * time.Microsecond}
+		require.NoError(t, service.TrackClick(ctx, click))
+
+		require.NotNil(t, stored)
+		require.NotNil(t, stored.ResolveMs)
+		assert.InDelta(t, 1.5, *stored.ResolveMs, 1e-9)
+	})
+
+	t.Run("Uncaptured resolve time is left empty", func(t *testing.T) {
+		assert.Nil(t, (&ClickContext{}).resolveMs())
+	})
+
+	t.Run("Percentiles come from the repository", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		service := NewURLService(mockRepo, nil)
+
+		mockRepo.On("GetResolveTiming", ctx, "abc123").Return(&models.ResolveTiming{Samples: 3, P50: 2, P95: 9, P99: 9.8}, nil)
+
+		timing, err := service.GetResolveTiming(ctx, "abc123")
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), timing.Samples)
+		assert.Equal(t, 9.8, timing.P99)
+	})
+}
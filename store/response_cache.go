@@ -0,0 +1,41 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisResponseCache keeps rendered HTTP responses in Valkey/Redis until they expire, so every
+// instance sharing the cache serves them
+type RedisResponseCache struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewResponseCache creates a response cache on the cache's connection. Keys are namespaced by prefix.
+func (c *CacheRepository) NewResponseCache(prefix string) *RedisResponseCache {
+	return &RedisResponseCache{
+		client: c.client,
+		prefix: "response:" + prefix + ":",
+	}
+}
+
+// Get retrieves the response cached under key, reporting whether there was one
+func (r *RedisResponseCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	data, err := r.client.Get(ctx, r.prefix+key).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+// Set caches a response under key for ttl
+func (r *RedisResponseCache) Set(ctx context.Context, key string, data []byte, ttl time.Duration) error {
+	return r.client.Set(ctx, r.prefix+key, data, ttl).Err()
+}
@@ -0,0 +1,46 @@
+package store
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// WithBaseURL tells the service the base URL its short links are served from, so original URLs
+// pointing back at it are rejected instead of creating redirect loops. Only the host is compared;
+// the port, path and any trailing slash are ignored.
+func WithBaseURL(baseURL string) Option {
+	return func(s *URLService) {
+		s.baseHost = baseURLHost(baseURL)
+	}
+}
+
+// baseURLHost returns the normalized host of a base URL, which may omit its scheme
+func baseURLHost(baseURL string) string {
+	baseURL = strings.TrimSpace(baseURL)
+	if baseURL == "" {
+		return ""
+	}
+	if !strings.Contains(baseURL, "://") {
+		baseURL = "//" + baseURL
+	}
+	parsed, err := url.Parse(baseURL)
+	if err != nil {
+		log.Warn().Err(err).Str("base_url", baseURL).Msg("Invalid base URL, self-referential URLs won't be rejected")
+		return ""
+	}
+	return normalizeHost(parsed.Hostname())
+}
+
+// checkSelfReference returns ErrSelfReferentialURL if the original URL is hosted on the base URL's host
+func (s *URLService) checkSelfReference(parsed *url.URL) error {
+	if s.baseHost == "" {
+		return nil
+	}
+	if normalizeHost(parsed.Hostname()) == s.baseHost {
+		log.Warn().Str("url", parsed.String()).Str("base_host", s.baseHost).Msg("Rejected URL pointing back at this shortener")
+		return ErrSelfReferentialURL
+	}
+	return nil
+}
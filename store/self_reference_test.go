@@ -0,0 +1,50 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelfReferentialURLs(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Rejects URLs on the base URL's host", func(t *testing.T) {
+		for _, baseURL := range []string{"https://sho.rt", "https://sho.rt/", "https://SHO.RT:8443/s/", "sho.rt"} {
+			mockRepo := new(MockURLRepository)
+			service := NewURLService(mockRepo, nil, WithBaseURL(baseURL))
+
+			for _, original := range []string{"https://sho.rt/abc", "http://sho.rt:8080/abc/", "https://Sho.Rt./s/abc", "https://sho.rt"} {
+				_, err := service.CreateShortURL(ctx, original, "custom", "", 0, "creator")
+				assert.ErrorIs(t, err, ErrSelfReferentialURL, "%s with base URL %s", original, baseURL)
+			}
+			mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("Rejects updates pointing back at the shortener", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		service := NewURLService(mockRepo, nil, WithBaseURL("http://localhost:8080"))
+		mockRepo.On("GetByShort", ctx, "abc").Return(&models.URL{ID: 1, Original: "https://example.com", Short: "abc"}, nil)
+
+		_, err := service.UpdateURL(ctx, "abc", "", "http://localhost:3000/def", 0, "creator")
+		assert.ErrorIs(t, err, ErrSelfReferentialURL)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Allows other hosts and subdomains", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		service := NewURLService(mockRepo, nil, WithBaseURL("https://sho.rt"))
+		mockRepo.On("GetByShort", ctx, "custom").Return(nil, ErrURLNotFound)
+		mockRepo.On("Create", ctx, mock.AnythingOfType("*models.URL")).Return(&models.URL{ID: 1, Short: "custom"}, nil)
+
+		for _, original := range []string{"https://docs.sho.rt/abc", "https://notsho.rt/abc"} {
+			_, err := service.CreateShortURL(ctx, original, "custom", "", 0, "creator")
+			require.NoError(t, err, original)
+		}
+	})
+}
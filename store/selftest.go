@@ -0,0 +1,130 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// selfTestCleanupTimeout bounds how long the self-test may spend removing its throwaway link
+const selfTestCleanupTimeout = 5 * time.Second
+
+// SelfTestStep reports the outcome and timing of a single self-test step
+type SelfTestStep struct {
+	Name       string  `json:"name"`
+	DurationMS float64 `json:"duration_ms"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// SelfTestResult reports the outcome of a create→resolve→delete self-test
+type SelfTestResult struct {
+	Success   bool           `json:"success"`
+	ShortCode string         `json:"short_code,omitempty"`
+	Steps     []SelfTestStep `json:"steps"`
+	TotalMS   float64        `json:"total_ms"`
+}
+
+// SelfTest exercises the full create→resolve→delete path with a throwaway link
+// owned by creatorReference. Once created, the link is purged even if a later step fails.
+func (s *URLService) SelfTest(ctx context.Context, creatorReference string) *SelfTestResult {
+	log.Debug().Str("creator_reference", creatorReference).Msg("Running self-test")
+
+	result := &SelfTestResult{Success: true}
+	started := time.Now()
+
+	// runStep times a single step and records its outcome
+	runStep := func(name string, fn func() error) bool {
+		stepStarted := time.Now()
+		err := fn()
+		step := SelfTestStep{
+			Name:       name,
+			DurationMS: float64(time.Since(stepStarted).Microseconds()) / 1000,
+		}
+		if err != nil {
+			step.Error = err.Error()
+			result.Success = false
+		}
+		result.Steps = append(result.Steps, step)
+		return err == nil
+	}
+
+	var short string
+	if !runStep("generate", func() error {
+		var err error
+		short, err = s.generateShortURL(ctx)
+		return err
+	}) {
+		result.TotalMS = float64(time.Since(started).Microseconds()) / 1000
+		return result
+	}
+	result.ShortCode = short
+
+	// Only arm the cleanup once creation succeeded: a failed create may mean the code collided
+	// with someone else's link, which must never be purged. A link left behind by a create that
+	// failed half-way expires within a minute anyway.
+	originalURL := "https://example.com/selftest/" + short
+	if !runStep("create", func() error {
+		_, err := s.CreateShortURL(ctx, originalURL, short, "Self-test", time.Minute, creatorReference)
+		return err
+	}) {
+		result.TotalMS = float64(time.Since(started).Microseconds()) / 1000
+		return result
+	}
+
+	deleted := false
+	defer func() {
+		if deleted {
+			return
+		}
+		// Use a detached context so cleanup still runs if the request was cancelled
+		cleanupCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), selfTestCleanupTimeout)
+		defer cancel()
+		if err := s.purge(cleanupCtx, short, creatorReference); err != nil {
+			log.Error().Err(err).Str("short", short).Msg("Failed to clean up self-test URL")
+		}
+	}()
+
+	runStep("resolve", func() error {
+		resolved, err := s.GetByShort(ctx, short)
+		if err != nil {
+			return err
+		}
+		if resolved.Original != originalURL {
+			return fmt.Errorf("resolved to %q, expected %q", resolved.Original, originalURL)
+		}
+		return nil
+	})
+
+	deleted = runStep("delete", func() error {
+		return s.purge(ctx, short, creatorReference)
+	})
+
+	result.TotalMS = float64(time.Since(started).Microseconds()) / 1000
+
+	log.Info().
+		Str("short", short).
+		Bool("success", result.Success).
+		Float64("total_ms", result.TotalMS).
+		Msg("Self-test completed")
+
+	return result
+}
+
+// purge permanently removes a URL owned by creatorReference from the database and the cache
+func (s *URLService) purge(ctx context.Context, short, creatorReference string) error {
+	if err := s.db.HardDelete(ctx, short, creatorReference); err != nil {
+		log.Error().Err(err).Str("short", short).Msg("Failed to hard delete URL from database")
+		return err
+	}
+
+	if s.cache != nil {
+		if err := s.cache.Delete(ctx, short); err != nil {
+			log.Error().Err(err).Str("short", short).Msg("Failed to delete URL from cache")
+			return err
+		}
+	}
+
+	return nil
+}
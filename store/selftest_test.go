@@ -0,0 +1,82 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSelfTest(t *testing.T) {
+	ctx := context.Background()
+
+	// Test case 1: All steps succeed
+	t.Run("Success", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		service := NewURLService(mockRepo, nil)
+
+		stored := &models.URL{}
+		mockRepo.On("GetByShortIncludingDeleted", ctx, mock.AnythingOfType("string")).Return(nil, ErrURLNotFound).Once()
+		mockRepo.On("GetByShort", ctx, mock.AnythingOfType("string")).Return(nil, ErrURLNotFound).Once()
+		mockRepo.On("Create", ctx, mock.AnythingOfType("*models.URL")).Run(func(args mock.Arguments) {
+			*stored = *args.Get(1).(*models.URL)
+		}).Return(stored, nil)
+		mockRepo.On("GetByShort", ctx, mock.AnythingOfType("string")).Return(stored, nil).Once()
+		mockRepo.On("HardDelete", ctx, mock.AnythingOfType("string"), "__selftest__").Return(nil).Once()
+
+		result := service.SelfTest(ctx, "__selftest__")
+
+		assert.True(t, result.Success)
+		assert.Len(t, result.Steps, 4)
+		for _, step := range result.Steps {
+			assert.Empty(t, step.Error, step.Name)
+		}
+		assert.Equal(t, "__selftest__", stored.CreatorReference)
+		mockRepo.AssertCalled(t, "HardDelete", ctx, result.ShortCode, "__selftest__")
+		mockRepo.AssertNumberOfCalls(t, "HardDelete", 1)
+	})
+
+	// Test case 2: Resolve fails after the link was created
+	t.Run("CleansUpOnPartialFailure", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		mockCache := new(MockCacheRepository)
+		service := NewURLService(mockRepo, mockCache)
+
+		mockCache.On("GetByShort", ctx, mock.AnythingOfType("string")).Return(nil, ErrURLNotFound)
+		mockCache.On("SetNotFound", ctx, mock.AnythingOfType("string")).Return(nil)
+		mockRepo.On("GetByShortIncludingDeleted", ctx, mock.AnythingOfType("string")).Return(nil, ErrURLNotFound).Once()
+		mockRepo.On("GetByShort", ctx, mock.AnythingOfType("string")).Return(nil, ErrURLNotFound).Once()
+		mockRepo.On("Create", ctx, mock.AnythingOfType("*models.URL")).Return(&models.URL{Short: "x"}, nil)
+		mockCache.On("Set", ctx, mock.AnythingOfType("*models.URL")).Return(nil)
+		mockRepo.On("GetByShort", ctx, mock.AnythingOfType("string")).Return(nil, assert.AnError)
+		mockRepo.On("HardDelete", mock.Anything, mock.AnythingOfType("string"), "__selftest__").Return(nil)
+		mockCache.On("Delete", mock.Anything, mock.AnythingOfType("string")).Return(nil)
+
+		result := service.SelfTest(ctx, "__selftest__")
+
+		assert.False(t, result.Success)
+		assert.Equal(t, "resolve", result.Steps[2].Name)
+		assert.NotEmpty(t, result.Steps[2].Error)
+		mockRepo.AssertCalled(t, "HardDelete", mock.Anything, result.ShortCode, "__selftest__")
+		mockCache.AssertCalled(t, "Delete", mock.Anything, result.ShortCode)
+	})
+
+	// Test case 3: Creation fails, e.g. because the code collided with another creator's link
+	t.Run("DoesNotPurgeWhenCreateFails", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		service := NewURLService(mockRepo, nil)
+
+		mockRepo.On("GetByShortIncludingDeleted", ctx, mock.AnythingOfType("string")).Return(nil, ErrURLNotFound)
+		mockRepo.On("GetByShort", ctx, mock.AnythingOfType("string")).Return(nil, ErrURLNotFound)
+		mockRepo.On("Create", ctx, mock.AnythingOfType("*models.URL")).Return(nil, ErrURLExists)
+
+		result := service.SelfTest(ctx, "__selftest__")
+
+		assert.False(t, result.Success)
+		assert.Len(t, result.Steps, 2)
+		assert.Equal(t, "create", result.Steps[1].Name)
+		mockRepo.AssertNotCalled(t, "HardDelete", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
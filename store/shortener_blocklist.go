@@ -0,0 +1,38 @@
+package store
+
+import (
+	"net/url"
+
+	"github.com/rs/zerolog/log"
+)
+
+// WithBlockedShortenerDomains rejects original URLs hosted on the given URL shortener domains
+// (and their subdomains) so links can't be chained through other shorteners
+func WithBlockedShortenerDomains(domains ...string) Option {
+	return func(s *URLService) {
+		s.blockedShortenerDomains = make(map[string]bool, len(domains))
+		for _, domain := range domains {
+			if domain = normalizeHost(domain); domain != "" {
+				s.blockedShortenerDomains[domain] = true
+			}
+		}
+	}
+}
+
+// checkBlockedShortener returns ErrBlockedURL if originalURL points at a blocked shortener domain
+func (s *URLService) checkBlockedShortener(originalURL string) error {
+	if len(s.blockedShortenerDomains) == 0 {
+		return nil
+	}
+
+	parsed, err := url.Parse(originalURL)
+	if err != nil {
+		return ErrInvalidURL
+	}
+
+	if domain, ok := matchDomain(s.blockedShortenerDomains, normalizeHost(parsed.Hostname())); ok {
+		log.Warn().Str("url", originalURL).Str("domain", domain).Msg("Rejected URL pointing to another URL shortener")
+		return ErrBlockedURL
+	}
+	return nil
+}
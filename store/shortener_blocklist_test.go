@@ -0,0 +1,44 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockedShortenerDomains(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Rejects blocked shortener domains and their subdomains", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		service := NewURLService(mockRepo, nil, WithBlockedShortenerDomains("bit.ly", " TinyURL.com "))
+
+		for _, original := range []string{"https://bit.ly/abc", "http://BIT.LY./abc", "https://www.tinyurl.com/xyz"} {
+			_, err := service.CreateShortURL(ctx, original, "custom", "", 0, "creator")
+			assert.ErrorIs(t, err, ErrBlockedURL, original)
+		}
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Allows normal domains", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		service := NewURLService(mockRepo, nil, WithBlockedShortenerDomains("bit.ly"))
+
+		original := "https://notbit.ly/page"
+		mockRepo.On("GetByShort", ctx, "custom").Return(nil, ErrURLNotFound)
+		mockRepo.On("Create", ctx, mock.AnythingOfType("*models.URL")).Return(&models.URL{ID: 1, Original: original, Short: "custom"}, nil)
+
+		created, err := service.CreateShortURL(ctx, original, "custom", "", 0, "creator")
+		require.NoError(t, err)
+		assert.Equal(t, original, created.Original)
+	})
+
+	t.Run("Allows everything when no domains are configured", func(t *testing.T) {
+		service := NewURLService(new(MockURLRepository), nil)
+		assert.NoError(t, service.checkBlockedShortener("https://bit.ly/abc"))
+	})
+}
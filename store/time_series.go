@@ -0,0 +1,280 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/rs/zerolog/log"
+)
+
+// TimeSeriesInterval is the bucket size of a click time series
+type TimeSeriesInterval string
+
+// Supported time series intervals, from finest to coarsest
+const (
+	IntervalMinute TimeSeriesInterval = "minute"
+	IntervalHour   TimeSeriesInterval = "hour"
+	IntervalDay    TimeSeriesInterval = "day"
+	IntervalWeek   TimeSeriesInterval = "week"
+)
+
+// timeSeriesIntervals lists the supported intervals from finest to coarsest
+var timeSeriesIntervals = []TimeSeriesInterval{IntervalMinute, IntervalHour, IntervalDay, IntervalWeek}
+
+// ParseTimeSeriesInterval validates a time series interval name
+func ParseTimeSeriesInterval(name string) (TimeSeriesInterval, error) {
+	for _, interval := range timeSeriesIntervals {
+		if string(interval) == name {
+			return interval, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %q", ErrInvalidInterval, name)
+}
+
+// Duration returns the length of one bucket. Weeks start on Monday, matching
+// PostgreSQL's date_trunc, because time.Truncate counts from Monday, January 1, year 1.
+func (i TimeSeriesInterval) Duration() time.Duration {
+	switch i {
+	case IntervalMinute:
+		return time.Minute
+	case IntervalHour:
+		return time.Hour
+	case IntervalWeek:
+		return 7 * 24 * time.Hour
+	default:
+		return 24 * time.Hour
+	}
+}
+
+// BucketStart returns the start of the bucket containing t, with bucket boundaries following the wall
+// clock of loc, e.g. local midnight for days. Weeks start on Monday, like PostgreSQL's date_trunc.
+func (i TimeSeriesInterval) BucketStart(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	year, month, day := t.Date()
+	switch i {
+	case IntervalMinute:
+		return time.Date(year, month, day, t.Hour(), t.Minute(), 0, 0, loc)
+	case IntervalHour:
+		return time.Date(year, month, day, t.Hour(), 0, 0, 0, loc)
+	case IntervalWeek:
+		midnight := time.Date(year, month, day, 0, 0, 0, 0, loc)
+		return midnight.AddDate(0, 0, -(int(midnight.Weekday())+6)%7)
+	default:
+		return time.Date(year, month, day, 0, 0, 0, 0, loc)
+	}
+}
+
+// addBuckets moves a bucket start n buckets forward, or backward for negative n. Days and weeks follow
+// the wall clock, so they stay aligned to local midnight across daylight saving changes.
+func (i TimeSeriesInterval) addBuckets(bucket time.Time, n int) time.Time {
+	switch i {
+	case IntervalMinute, IntervalHour:
+		return bucket.Add(time.Duration(n) * i.Duration())
+	case IntervalWeek:
+		return bucket.AddDate(0, 0, 7*n)
+	default:
+		return bucket.AddDate(0, 0, n)
+	}
+}
+
+// ParseTimezone validates an IANA time zone name such as "Europe/Berlin". An empty name means UTC.
+func ParseTimezone(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	// "Local" would silently depend on the server's configuration
+	if name == "Local" {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidTimezone, name)
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidTimezone, name)
+	}
+	return loc, nil
+}
+
+// bucketCount returns the number of buckets of this interval needed to cover span
+func (i TimeSeriesInterval) bucketCount(span time.Duration) int {
+	d := i.Duration()
+	return int((span + d - 1) / d)
+}
+
+// CoarsenInterval returns the finest interval at least as coarse as requested that covers span
+// in no more than maxBuckets buckets. The coarsest interval is returned if none fits.
+// A non-positive maxBuckets disables coarsening.
+func CoarsenInterval(requested TimeSeriesInterval, span time.Duration, maxBuckets int) TimeSeriesInterval {
+	if maxBuckets <= 0 {
+		return requested
+	}
+	effective := requested
+	for _, interval := range timeSeriesIntervals {
+		if interval.Duration() < requested.Duration() {
+			continue
+		}
+		effective = interval
+		if interval.bucketCount(span) <= maxBuckets {
+			break
+		}
+	}
+	return effective
+}
+
+// WithMaxSeriesBuckets caps the number of buckets a click time series may return
+func WithMaxSeriesBuckets(maxBuckets int) Option {
+	return func(s *URLService) {
+		s.maxSeriesBuckets = maxBuckets
+	}
+}
+
+// WithAnalyticsWindow limits click analytics requested without a range to the clicks of the last
+// window, keeping aggregate queries fast on large click tables. Zero aggregates all clicks.
+func WithAnalyticsWindow(window time.Duration) Option {
+	return func(s *URLService) {
+		s.analyticsWindow = window
+	}
+}
+
+// GetClickTimeSeriesByInterval retrieves click counts for the last number of days in buckets of
+// the requested interval, oldest first, with empty buckets included as zero counts. Buckets follow
+// the wall clock of loc (UTC when nil), so daily buckets start at local midnight. Intervals that
+// would exceed the configured bucket cap are coarsened; the interval actually used is returned.
+func (s *URLService) GetClickTimeSeriesByInterval(ctx context.Context, short string, days int, interval TimeSeriesInterval, loc *time.Location) ([]*models.TimeSeriesPoint, TimeSeriesInterval, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	log.Debug().
+		Str("short", short).
+		Int("days", days).
+		Str("interval", string(interval)).
+		Str("timezone", loc.String()).
+		Msg("Getting click time series")
+
+	span := time.Duration(days) * 24 * time.Hour
+	effective := CoarsenInterval(interval, span, s.maxSeriesBuckets)
+	if effective != interval {
+		log.Debug().
+			Str("short", short).
+			Str("requested_interval", string(interval)).
+			Str("interval", string(effective)).
+			Int("max_buckets", s.maxSeriesBuckets).
+			Msg("Coarsening time series interval")
+	}
+
+	// Start on a bucket boundary in loc so the first bucket is complete
+	buckets := effective.bucketCount(span)
+	last := effective.BucketStart(time.Now(), loc)
+	since := effective.addBuckets(last, -(buckets - 1))
+
+	points, err := s.db.GetClickTimeSeries(ctx, short, ClickRange{From: since.UTC()}, string(effective), loc.String())
+	if err != nil {
+		log.Error().Err(err).Str("short", short).Msg("Failed to get click time series")
+		return nil, "", err
+	}
+
+	// Index clicks by bucket and fill in buckets without clicks
+	clicksByBucket := make(map[time.Time]int64, len(points))
+	for _, point := range points {
+		clicksByBucket[effective.BucketStart(point.Time, loc).UTC()] += point.Clicks
+	}
+
+	series := make([]*models.TimeSeriesPoint, 0, buckets)
+	for bucket := since; !bucket.After(last); bucket = effective.addBuckets(bucket, 1) {
+		series = append(series, &models.TimeSeriesPoint{Time: bucket, Clicks: clicksByBucket[bucket.UTC()]})
+	}
+
+	log.Info().
+		Str("short", short).
+		Int("days", days).
+		Str("interval", string(effective)).
+		Str("timezone", loc.String()).
+		Int("buckets", len(series)).
+		Msg("Click time series retrieved successfully")
+
+	return series, effective, nil
+}
+
+// GetClickTimeSeriesInRange retrieves click counts for a URL's clicks within rng in buckets of the
+// requested interval, oldest first, with empty buckets included as zero counts. Like GetClickAnalytics,
+// a range without bounds covers the configured analytics window; without From the series starts at the
+// first click, and without To it ends now. Intervals that would exceed the configured bucket cap are
+// coarsened; the interval actually used is returned.
+func (s *URLService) GetClickTimeSeriesInRange(ctx context.Context, short string, rng ClickRange, interval TimeSeriesInterval, loc *time.Location) ([]*models.TimeSeriesPoint, TimeSeriesInterval, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	if rng.From.IsZero() && rng.To.IsZero() && s.analyticsWindow > 0 {
+		rng.From = time.Now().Add(-s.analyticsWindow)
+	}
+	end := rng.To
+	if end.IsZero() {
+		end = time.Now()
+	}
+
+	log.Debug().
+		Str("short", short).
+		Time("from", rng.From).
+		Time("to", rng.To).
+		Str("interval", string(interval)).
+		Str("timezone", loc.String()).
+		Msg("Getting click time series in range")
+
+	if !rng.From.IsZero() && !rng.From.Before(end) {
+		log.Info().Str("short", short).Time("from", rng.From).Time("to", end).Msg("Empty click time series range")
+		return []*models.TimeSeriesPoint{}, interval, nil
+	}
+
+	// With a known start the database buckets by the coarsened interval directly; otherwise the
+	// span is only known from the first click, and finer buckets are merged below
+	effective := interval
+	if !rng.From.IsZero() {
+		effective = CoarsenInterval(interval, end.Sub(rng.From), s.maxSeriesBuckets)
+	}
+	points, err := s.db.GetClickTimeSeries(ctx, short, rng, string(effective), loc.String())
+	if err != nil {
+		log.Error().Err(err).Str("short", short).Msg("Failed to get click time series in range")
+		return nil, "", err
+	}
+
+	start := rng.From
+	if start.IsZero() {
+		if len(points) == 0 {
+			log.Info().Str("short", short).Msg("No clicks for click time series")
+			return []*models.TimeSeriesPoint{}, interval, nil
+		}
+		start = points[0].Time
+		effective = CoarsenInterval(interval, end.Sub(start), s.maxSeriesBuckets)
+	}
+	if effective != interval {
+		log.Debug().
+			Str("short", short).
+			Str("requested_interval", string(interval)).
+			Str("interval", string(effective)).
+			Int("max_buckets", s.maxSeriesBuckets).
+			Msg("Coarsening time series interval")
+	}
+
+	clicksByBucket := make(map[time.Time]int64, len(points))
+	for _, point := range points {
+		clicksByBucket[effective.BucketStart(point.Time, loc).UTC()] += point.Clicks
+	}
+
+	// To is exclusive, so the last bucket is the one holding the instant before it
+	first := effective.BucketStart(start, loc)
+	last := effective.BucketStart(end.Add(-time.Nanosecond), loc)
+	var series []*models.TimeSeriesPoint
+	for bucket := first; !bucket.After(last); bucket = effective.addBuckets(bucket, 1) {
+		series = append(series, &models.TimeSeriesPoint{Time: bucket, Clicks: clicksByBucket[bucket.UTC()]})
+	}
+
+	log.Info().
+		Str("short", short).
+		Str("interval", string(effective)).
+		Str("timezone", loc.String()).
+		Int("buckets", len(series)).
+		Msg("Click time series in range retrieved successfully")
+
+	return series, effective, nil
+}
@@ -0,0 +1,203 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCoarsenInterval(t *testing.T) {
+	year := 365 * 24 * time.Hour
+	cases := []struct {
+		name       string
+		requested  TimeSeriesInterval
+		span       time.Duration
+		maxBuckets int
+		want       TimeSeriesInterval
+	}{
+		{"MinuteOverYear", IntervalMinute, year, 1000, IntervalDay},
+		{"HourOverYear", IntervalHour, year, 1000, IntervalDay},
+		{"MinuteOverHour", IntervalMinute, time.Hour, 1000, IntervalMinute},
+		{"HourOverWeek", IntervalHour, 7 * 24 * time.Hour, 1000, IntervalHour},
+		{"NeverFiner", IntervalWeek, time.Hour, 1000, IntervalWeek},
+		{"CoarsestWhenNothingFits", IntervalMinute, year, 10, IntervalWeek},
+		{"Disabled", IntervalMinute, year, 0, IntervalMinute},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, CoarsenInterval(tc.requested, tc.span, tc.maxBuckets))
+		})
+	}
+}
+
+func TestGetClickTimeSeriesByInterval(t *testing.T) {
+	ctx := context.Background()
+
+	// Test case 1: An over-granular request is coarsened to fit the bucket cap
+	t.Run("Coarsened", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		service := NewURLService(mockRepo, nil, WithMaxSeriesBuckets(500))
+		mockRepo.On("GetClickTimeSeries", ctx, "abc123", mock.AnythingOfType("store.ClickRange"), "day", "UTC").Return([]*models.TimeSeriesPoint{}, nil)
+
+		series, interval, err := service.GetClickTimeSeriesByInterval(ctx, "abc123", 365, IntervalMinute, nil)
+
+		require.NoError(t, err)
+		assert.Equal(t, IntervalDay, interval)
+		assert.Len(t, series, 365)
+		mockRepo.AssertExpectations(t)
+	})
+
+	// Test case 2: A reasonable request passes through and fills empty buckets
+	t.Run("PassesThrough", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		service := NewURLService(mockRepo, nil, WithMaxSeriesBuckets(500))
+
+		last := time.Now().UTC().Truncate(time.Hour)
+		since := last.Add(-23 * time.Hour)
+		mockRepo.On("GetClickTimeSeries", ctx, "abc123", ClickRange{From: since}, "hour", "UTC").Return([]*models.TimeSeriesPoint{
+			{Time: since, Clicks: 3},
+			{Time: last, Clicks: 1},
+		}, nil)
+
+		series, interval, err := service.GetClickTimeSeriesByInterval(ctx, "abc123", 1, IntervalHour, nil)
+
+		require.NoError(t, err)
+		assert.Equal(t, IntervalHour, interval)
+		require.Len(t, series, 24)
+		assert.Equal(t, int64(3), series[0].Clicks)
+		assert.Equal(t, int64(0), series[12].Clicks)
+		assert.Equal(t, last, series[23].Time)
+		assert.Equal(t, int64(1), series[23].Clicks)
+	})
+
+	// Test case 3: Interval names are validated
+	t.Run("ParseInterval", func(t *testing.T) {
+		interval, err := ParseTimeSeriesInterval("week")
+		require.NoError(t, err)
+		assert.Equal(t, IntervalWeek, interval)
+
+		_, err = ParseTimeSeriesInterval("second")
+		assert.ErrorIs(t, err, ErrInvalidInterval)
+	})
+}
+
+func TestTimeSeriesTimezone(t *testing.T) {
+	ctx := context.Background()
+	tokyo, err := ParseTimezone("Asia/Tokyo")
+	require.NoError(t, err)
+
+	// Test case 1: Time zone names are validated
+	t.Run("ParseTimezone", func(t *testing.T) {
+		loc, err := ParseTimezone("")
+		require.NoError(t, err)
+		assert.Equal(t, time.UTC, loc)
+
+		_, err = ParseTimezone("Mars/Olympus_Mons")
+		assert.ErrorIs(t, err, ErrInvalidTimezone)
+		_, err = ParseTimezone("Local")
+		assert.ErrorIs(t, err, ErrInvalidTimezone)
+	})
+
+	// Test case 2: Bucket boundaries follow the local wall clock
+	t.Run("BucketStart", func(t *testing.T) {
+		click := time.Date(2026, 3, 9, 20, 30, 0, 0, time.UTC) // a Monday
+		assert.Equal(t, time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC), IntervalDay.BucketStart(click, time.UTC))
+		assert.Equal(t, time.Date(2026, 3, 10, 0, 0, 0, 0, tokyo), IntervalDay.BucketStart(click, tokyo))
+		assert.Equal(t, time.Date(2026, 3, 9, 0, 0, 0, 0, tokyo), IntervalWeek.BucketStart(click, tokyo))
+
+		kolkata, err := ParseTimezone("Asia/Kolkata")
+		require.NoError(t, err)
+		// Hours in UTC+05:30 start half past the UTC hour
+		assert.Equal(t, time.Date(2026, 3, 9, 20, 30, 0, 0, time.UTC), IntervalHour.BucketStart(click.Add(15*time.Minute), kolkata).UTC())
+	})
+
+	// Test case 3: Days stay aligned to local midnight across daylight saving changes
+	t.Run("DaylightSaving", func(t *testing.T) {
+		berlin, err := ParseTimezone("Europe/Berlin")
+		require.NoError(t, err)
+		midnight := time.Date(2026, 3, 29, 0, 0, 0, 0, berlin)
+		assert.Equal(t, time.Date(2026, 3, 30, 0, 0, 0, 0, berlin), IntervalDay.addBuckets(midnight, 1))
+		assert.Equal(t, 23*time.Hour, IntervalDay.addBuckets(midnight, 1).Sub(midnight))
+	})
+
+	// Test case 4: The repository is asked for local buckets and the series starts at local midnight
+	t.Run("Series", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		service := NewURLService(mockRepo, nil)
+
+		last := IntervalDay.BucketStart(time.Now(), tokyo)
+		since := last.AddDate(0, 0, -6)
+		mockRepo.On("GetClickTimeSeries", ctx, "abc123", ClickRange{From: since.UTC()}, "day", "Asia/Tokyo").Return([]*models.TimeSeriesPoint{
+			{Time: last.UTC(), Clicks: 4},
+		}, nil)
+
+		series, _, err := service.GetClickTimeSeriesByInterval(ctx, "abc123", 7, IntervalDay, tokyo)
+
+		require.NoError(t, err)
+		require.Len(t, series, 7)
+		assert.True(t, since.Equal(series[0].Time))
+		assert.Equal(t, int64(4), series[6].Clicks)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestGetClickTimeSeriesInRange(t *testing.T) {
+	ctx := context.Background()
+	from := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	// Test case 1: Buckets cover the range, with To exclusive, and empty buckets are filled
+	t.Run("Bounded", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		service := NewURLService(mockRepo, nil)
+		rng := ClickRange{From: from, To: from.AddDate(0, 0, 3)}
+		mockRepo.On("GetClickTimeSeries", ctx, "abc123", rng, "day", "UTC").Return([]*models.TimeSeriesPoint{
+			{Time: from.AddDate(0, 0, 2), Clicks: 5},
+		}, nil)
+
+		series, interval, err := service.GetClickTimeSeriesInRange(ctx, "abc123", rng, IntervalDay, nil)
+
+		require.NoError(t, err)
+		assert.Equal(t, IntervalDay, interval)
+		require.Len(t, series, 3)
+		assert.Equal(t, int64(0), series[0].Clicks)
+		assert.Equal(t, int64(5), series[2].Clicks)
+		mockRepo.AssertExpectations(t)
+	})
+
+	// Test case 2: Without From, the series starts at the first click and is coarsened to the cap
+	t.Run("OpenStart", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		service := NewURLService(mockRepo, nil, WithMaxSeriesBuckets(10))
+		monday := from.AddDate(0, 0, 1)
+		rng := ClickRange{To: monday.AddDate(0, 0, 14)}
+		mockRepo.On("GetClickTimeSeries", ctx, "abc123", rng, "day", "UTC").Return([]*models.TimeSeriesPoint{
+			{Time: monday, Clicks: 1},
+			{Time: monday.AddDate(0, 0, 1), Clicks: 2},
+		}, nil)
+
+		series, interval, err := service.GetClickTimeSeriesInRange(ctx, "abc123", rng, IntervalDay, nil)
+
+		require.NoError(t, err)
+		assert.Equal(t, IntervalWeek, interval)
+		require.Len(t, series, 2)
+		assert.Equal(t, int64(3), series[0].Clicks)
+		mockRepo.AssertExpectations(t)
+	})
+
+	// Test case 3: A range starting in the future is empty without querying
+	t.Run("Empty", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		service := NewURLService(mockRepo, nil)
+
+		series, _, err := service.GetClickTimeSeriesInRange(ctx, "abc123", ClickRange{From: time.Now().Add(time.Hour)}, IntervalHour, nil)
+
+		require.NoError(t, err)
+		assert.Empty(t, series)
+		mockRepo.AssertNotCalled(t, "GetClickTimeSeries")
+	})
+}
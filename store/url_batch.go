@@ -0,0 +1,86 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/rs/zerolog/log"
+)
+
+// MaxBatchSize is the most URLs CreateShortURLBatch accepts in one call
+const MaxBatchSize = 500
+
+// BatchItem describes one URL to create with CreateShortURLBatch; the fields match the arguments of CreateShortURL
+type BatchItem struct {
+	OriginalURL      string
+	CustomShort      string
+	Title            string
+	ExpireAfter      time.Duration
+	CreatorReference string
+	Options          []URLOption
+}
+
+// BatchResult is the outcome of one BatchItem: the created URL, or the error that prevented it
+type BatchResult struct {
+	URL *models.URL
+	Err error
+}
+
+// CreateShortURLBatch creates short URLs for several items, validating each on its own and saving the
+// valid ones in a single transaction. Results are in item order; an item that fails doesn't stop the
+// others. The returned error is only set if the batch as a whole failed.
+func (s *URLService) CreateShortURLBatch(ctx context.Context, items []BatchItem) ([]BatchResult, error) {
+	log.Debug().Int("items", len(items)).Msg("Creating short URL batch")
+
+	if len(items) > MaxBatchSize {
+		log.Error().Int("items", len(items)).Int("max", MaxBatchSize).Msg("Batch too large")
+		return nil, ErrBatchTooLarge
+	}
+
+	results := make([]BatchResult, len(items))
+	var pending []*models.URL
+	var pendingIndexes []int
+	for i, item := range items {
+		newURL, err := s.newShortURL(ctx, item.OriginalURL, item.CustomShort, item.Title, item.ExpireAfter, item.CreatorReference, item.Options...)
+		if err != nil {
+			log.Debug().Err(err).Int("index", i).Msg("Invalid batch item")
+			results[i].Err = err
+			continue
+		}
+		pending = append(pending, newURL)
+		pendingIndexes = append(pendingIndexes, i)
+	}
+
+	var created int
+	if len(pending) > 0 {
+		createdURLs, errs, err := s.db.CreateBatch(ctx, pending)
+		if err != nil {
+			log.Error().Err(err).Int("items", len(items)).Msg("Failed to save URL batch to database")
+			return nil, err
+		}
+		for j, i := range pendingIndexes {
+			if errs[j] != nil {
+				results[i].Err = errs[j]
+				continue
+			}
+			results[i].URL = createdURLs[j]
+			created++
+
+			// The URL is already saved, so a cache failure only costs a cache miss later
+			if s.cache != nil {
+				if err := s.cache.Set(ctx, createdURLs[j]); err != nil {
+					log.Warn().Err(err).Str("short", createdURLs[j].Short).Msg("Failed to cache URL from batch")
+				}
+			}
+		}
+	}
+
+	log.Info().
+		Int("items", len(items)).
+		Int("created", created).
+		Int("failed", len(items)-created).
+		Msg("Short URL batch created")
+
+	return results, nil
+}
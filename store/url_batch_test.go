@@ -0,0 +1,44 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateShortURLBatch(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Valid items are saved together and failures are reported per item", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		service := NewURLService(mockRepo, nil)
+		mockRepo.On("GetByShort", ctx, mock.AnythingOfType("string")).Return(nil, ErrURLNotFound)
+		dbErr := errors.New("insert failed")
+		mockRepo.On("CreateBatch", ctx, mock.MatchedBy(func(urls []*models.URL) bool {
+			return len(urls) == 2 && urls[0].Short == "one" && urls[1].Short == "two"
+		})).Return([]*models.URL{{ID: 1, Short: "one"}, nil}, []error{nil, dbErr}, nil)
+
+		results, err := service.CreateShortURLBatch(ctx, []BatchItem{
+			{OriginalURL: "https://example.com/1", CustomShort: "one"},
+			{OriginalURL: "ftp://example.com/file"},
+			{OriginalURL: "https://example.com/2", CustomShort: "two"},
+		})
+		require.NoError(t, err)
+		require.Len(t, results, 3)
+		assert.Equal(t, "one", results[0].URL.Short)
+		assert.ErrorIs(t, results[1].Err, ErrDisallowedScheme)
+		assert.ErrorIs(t, results[2].Err, dbErr)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Batches are capped", func(t *testing.T) {
+		service := NewURLService(new(MockURLRepository), nil)
+		_, err := service.CreateShortURLBatch(ctx, make([]BatchItem, MaxBatchSize+1))
+		assert.ErrorIs(t, err, ErrBatchTooLarge)
+	})
+}
@@ -0,0 +1,77 @@
+package store
+
+import (
+	"context"
+	"errors"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/rs/zerolog/log"
+)
+
+// MaxImportChunkSize is the most URLs ImportURLs accepts in one call; larger imports are split into chunks
+const MaxImportChunkSize = 1000
+
+// ImportItem is one URL to import with ImportURLs. A code is generated when CustomShort is empty.
+type ImportItem struct {
+	OriginalURL string
+	CustomShort string
+	Title       string
+}
+
+// ImportURLs validates imported URLs one by one and bulk-inserts the valid ones. Results are in item
+// order; an item that fails, for example because its short code is taken, doesn't stop the others.
+// The returned error is only set if the import as a whole failed.
+func (s *URLService) ImportURLs(ctx context.Context, items []ImportItem, creatorReference string) ([]BatchResult, error) {
+	log.Debug().Int("items", len(items)).Str("creator_reference", creatorReference).Msg("Importing URLs")
+
+	if len(items) > MaxImportChunkSize {
+		log.Error().Int("items", len(items)).Int("max", MaxImportChunkSize).Msg("Too many URLs to import at once")
+		return nil, ErrBatchTooLarge
+	}
+
+	results := make([]BatchResult, len(items))
+	var pending []*models.URL
+	var pendingIndexes []int
+	for i, item := range items {
+		// Taken short codes are reported by BulkCreate, which saves a lookup per row
+		newURL, err := s.buildShortURL(ctx, item.OriginalURL, item.CustomShort, item.Title, 0, creatorReference)
+		if err != nil {
+			log.Debug().Err(err).Int("index", i).Msg("Invalid import item")
+			results[i].Err = err
+			continue
+		}
+		pending = append(pending, newURL)
+		pendingIndexes = append(pendingIndexes, i)
+	}
+
+	var created int
+	if len(pending) > 0 {
+		errs, err := s.db.BulkCreate(ctx, pending)
+		if err != nil {
+			log.Error().Err(err).Int("items", len(items)).Msg("Failed to save imported URLs to database")
+			return nil, err
+		}
+		for j, i := range pendingIndexes {
+			if errs[j] != nil {
+				results[i].Err = errs[j]
+				if items[i].CustomShort == "" && errors.Is(errs[j], ErrURLExists) {
+					results[i].Err = ErrCodeGenerationFailed
+				}
+				continue
+			}
+			results[i].URL = pending[j]
+			created++
+
+			// Drop any cached miss for the code so the new URL resolves right away
+			s.invalidateCache(ctx, pending[j].Short)
+		}
+	}
+
+	log.Info().
+		Int("items", len(items)).
+		Int("created", created).
+		Int("failed", len(items)-created).
+		Msg("URLs imported")
+
+	return results, nil
+}
@@ -0,0 +1,47 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportURLs(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Valid items are bulk created and failures are reported per item", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		mockCache := new(MockCacheRepository)
+		service := NewURLService(mockRepo, mockCache)
+		mockRepo.On("BulkCreate", ctx, mock.MatchedBy(func(urls []*models.URL) bool {
+			return len(urls) == 2 && urls[0].Short == "one" && urls[0].CreatorReference == "importer" && urls[1].Short == "two"
+		})).Return([]error{nil, ErrURLExists}, nil)
+		mockCache.On("Delete", ctx, "one").Return(nil)
+
+		results, err := service.ImportURLs(ctx, []ImportItem{
+			{OriginalURL: "https://example.com/1", CustomShort: "one", Title: "One"},
+			{OriginalURL: "not a url"},
+			{OriginalURL: "https://example.com/2", CustomShort: "two"},
+		}, "importer")
+		require.NoError(t, err)
+		require.Len(t, results, 3)
+		assert.Equal(t, "one", results[0].URL.Short)
+		assert.Equal(t, "One", results[0].URL.Title)
+		assert.ErrorIs(t, results[1].Err, ErrInvalidURL)
+		assert.ErrorIs(t, results[2].Err, ErrURLExists)
+		// Taken codes are left to BulkCreate instead of being looked up row by row
+		mockRepo.AssertNotCalled(t, "GetByShort", mock.Anything, mock.Anything)
+		mockRepo.AssertExpectations(t)
+		mockCache.AssertExpectations(t)
+	})
+
+	t.Run("Chunks are capped", func(t *testing.T) {
+		service := NewURLService(new(MockURLRepository), nil)
+		_, err := service.ImportURLs(ctx, make([]ImportItem, MaxImportChunkSize+1), "")
+		assert.ErrorIs(t, err, ErrBatchTooLarge)
+	})
+}
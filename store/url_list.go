@@ -0,0 +1,58 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/rs/zerolog/log"
+)
+
+// URLListFilter narrows a listing of all URLs. Zero fields don't filter.
+type URLListFilter struct {
+	// CreatorReference limits results to one creator's URLs
+	CreatorReference string
+	// CreatedAfter limits results to URLs created at or after it
+	CreatedAfter time.Time
+	// CreatedBefore limits results to URLs created before it
+	CreatedBefore time.Time
+	// MinClicks limits results to URLs with at least this many clicks
+	MinClicks int64
+	// Search limits results to URLs whose original URL or title contains it, ignoring case
+	Search string
+	// IncludeDeleted also lists soft-deleted URLs
+	IncludeDeleted bool
+}
+
+// Page selects a limit/offset page of a listing
+type Page struct {
+	// Limit is the maximum number of results to return
+	Limit int
+	// Offset is the number of results to skip
+	Offset int
+}
+
+// ListURLs retrieves a page of all URLs matching filter, newest first, and the total number of matching
+// URLs. Expired URLs are included.
+func (s *URLService) ListURLs(ctx context.Context, filter URLListFilter, page Page) ([]*models.URL, int64, error) {
+	log.Debug().
+		Str("creator_reference", filter.CreatorReference).
+		Time("created_after", filter.CreatedAfter).
+		Time("created_before", filter.CreatedBefore).
+		Int64("min_clicks", filter.MinClicks).
+		Str("search", filter.Search).
+		Bool("include_deleted", filter.IncludeDeleted).
+		Int("limit", page.Limit).
+		Int("offset", page.Offset).
+		Msg("Listing URLs")
+
+	urls, total, err := s.db.ListURLs(ctx, filter, page)
+	if err != nil {
+		log.Error().Err(err).Msg("Database error when listing URLs")
+		return nil, 0, err
+	}
+
+	log.Info().Int("count", len(urls)).Int64("total", total).Msg("URLs listed")
+
+	return urls, total, nil
+}
@@ -0,0 +1,54 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// purgeChunkSize is the number of expired URLs removed per statement
+const purgeChunkSize = 1000
+
+// PurgeExpired permanently removes URLs that expired more than grace ago, along with their clicks and
+// history, and returns how many were removed. URLs are removed in chunks so no single statement locks
+// many rows.
+func (s *URLService) PurgeExpired(ctx context.Context, grace time.Duration) (int64, error) {
+	log.Debug().Dur("grace", grace).Msg("Purging expired URLs")
+
+	var purged int64
+	for {
+		shorts, err := s.db.PurgeExpired(ctx, grace, purgeChunkSize)
+		if err != nil {
+			log.Error().Err(err).Int64("purged", purged).Msg("Failed to purge expired URLs")
+			return purged, err
+		}
+		for _, short := range shorts {
+			s.invalidateCache(ctx, short)
+		}
+		purged += int64(len(shorts))
+		if len(shorts) < purgeChunkSize {
+			break
+		}
+	}
+
+	log.Info().Int64("purged", purged).Dur("grace", grace).Msg("Expired URLs purged")
+	return purged, nil
+}
+
+// MaintainExpiredPurge purges expired URLs now and then every interval, until ctx is cancelled
+func (s *URLService) MaintainExpiredPurge(ctx context.Context, interval time.Duration, grace time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		// Errors are logged by PurgeExpired; the next tick retries
+		_, _ = s.PurgeExpired(ctx, grace)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -3,49 +3,229 @@ package store
 import (
 	"context"
 	"errors"
+	"net/http"
+	"time"
 
 	"github.com/fransfilastap/urlshortener/models"
 )
 
 var (
 	// ErrURLNotFound is returned when a URL is not found
-	ErrURLNotFound = errors.New("url not found")
+	ErrURLNotFound = NewAPIError(http.StatusNotFound, "url_not_found", "URL not found")
 	// ErrURLExists is returned when a URL with the same short code already exists
-	ErrURLExists = errors.New("url with this short code already exists")
+	ErrURLExists = NewAPIError(http.StatusConflict, "url_exists", "Custom code already in use")
+	// ErrCodeGenerationFailed is returned when no unused short code could be generated within the allowed
+	// attempts. Unlike ErrURLExists it isn't the client's fault, and retrying usually succeeds.
+	ErrCodeGenerationFailed = NewAPIError(http.StatusServiceUnavailable, "code_generation_failed", "Could not generate a unique short code, please try again")
 	// ErrInvalidURL is returned when the URL is invalid
-	ErrInvalidURL = errors.New("invalid url")
+	ErrInvalidURL = NewAPIError(http.StatusBadRequest, "invalid_url", "Invalid URL")
 	// ErrRecentClick is returned when there's a recent click from the same visitor
 	ErrRecentClick = errors.New("recent click from the same visitor")
+	// ErrInvalidHistoryAction is returned when filtering history by an unknown action
+	ErrInvalidHistoryAction = NewAPIError(http.StatusBadRequest, "invalid_history_action", "Invalid action")
+	// ErrUnknownClickEnricher is returned when configuring a click enricher that does not exist
+	ErrUnknownClickEnricher = errors.New("unknown click enricher")
+	// ErrReuseConflict is returned when a custom code conflicts with the existing link being reused
+	ErrReuseConflict = NewAPIError(http.StatusConflict, "reuse_conflict", "URL is already shortened with a different code; omit custom_code or reuse_existing")
+	// ErrRateLimited is returned when a URL has been redirected more often than its rate limit allows
+	ErrRateLimited = NewAPIError(http.StatusTooManyRequests, "rate_limited", "Too many requests for this URL")
+	// ErrCreatorMismatch is returned when a creator reference does not match the URL's owner
+	ErrCreatorMismatch = NewAPIError(http.StatusUnauthorized, "creator_mismatch", "Unauthorized: creator reference does not match")
+	// ErrBlockedURL is returned when the original URL points at a blocked URL shortener
+	ErrBlockedURL = NewAPIError(http.StatusBadRequest, "blocked_url", "URLs from other URL shorteners are not allowed")
+	// ErrSelfReferentialURL is returned when the original URL points back at this shortener
+	ErrSelfReferentialURL = NewAPIError(http.StatusBadRequest, "self_referential_url", "URLs pointing back to this URL shortener are not allowed")
+	// ErrBlockedDestination is returned when the original URL's host is on the destination blocklist
+	ErrBlockedDestination = NewAPIError(http.StatusForbidden, "blocked_destination", "This destination is not allowed")
+	// ErrDisallowedScheme is returned when the original URL uses a scheme that isn't allowlisted
+	ErrDisallowedScheme = NewAPIError(http.StatusBadRequest, "disallowed_scheme", "URL scheme is not allowed")
+	// ErrCreatorDefaultsNotFound is returned when a creator has no defaults for new links
+	ErrCreatorDefaultsNotFound = NewAPIError(http.StatusNotFound, "creator_defaults_not_found", "No defaults set for this creator")
+	// ErrInvalidCreatorDefaults is returned when creator defaults contain negative values
+	ErrInvalidCreatorDefaults = NewAPIError(http.StatusBadRequest, "invalid_creator_defaults", "Invalid creator defaults")
+	// ErrInvalidCustomCode is returned when a custom code contains characters that aren't allowed or is too long
+	ErrInvalidCustomCode = NewAPIError(http.StatusBadRequest, "invalid_custom_code", "Custom code may only contain letters, digits, '-' and '_' (up to 64 characters)")
+	// ErrReservedCode is returned when a custom code is reserved
+	ErrReservedCode = NewAPIError(http.StatusBadRequest, "reserved_code", "Custom code is reserved")
+	// ErrURLExpired is returned when a URL has expired. It also matches ErrURLNotFound, so callers that
+	// only need a live URL treat both alike.
+	ErrURLExpired = &APIError{Status: http.StatusGone, Code: "url_expired", Message: "This link has expired", parent: ErrURLNotFound}
+	// ErrClickLimitReached is returned when a usage-limited URL has been clicked as often as it allows
+	ErrClickLimitReached = NewAPIError(http.StatusGone, "click_limit_reached", "This link has reached its click limit")
+	// ErrURLDisabled is returned when redirecting a URL that its owner has disabled
+	ErrURLDisabled = NewAPIError(http.StatusForbidden, "url_disabled", "This link has been disabled")
+	// ErrInvalidInterval is returned when requesting a time series with an unknown interval
+	ErrInvalidInterval = NewAPIError(http.StatusBadRequest, "invalid_interval", "Invalid interval")
+	// ErrInvalidTimezone is returned when requesting a time series in an unknown time zone
+	ErrInvalidTimezone = NewAPIError(http.StatusBadRequest, "invalid_timezone", "Invalid time zone")
+	// ErrBatchTooLarge is returned when a batch holds more than MaxBatchSize items
+	ErrBatchTooLarge = NewAPIError(http.StatusBadRequest, "batch_too_large", "Too many URLs in one batch")
+	// ErrRandomTargetWithoutCreator is returned when creating a random link without a creator to pick links from
+	ErrRandomTargetWithoutCreator = NewAPIError(http.StatusBadRequest, "random_target_without_creator", "Random links need a creator_reference")
+	// ErrInvalidSort is returned when listing URLs sorted by an unknown column
+	ErrInvalidSort = NewAPIError(http.StatusBadRequest, "invalid_sort", "Invalid sort")
+	// ErrNoTagChange is returned when a bulk tag request neither adds nor removes a tag
+	ErrNoTagChange = NewAPIError(http.StatusBadRequest, "no_tag_change", "No tags to add or remove")
+	// ErrClickImportTooLarge is returned when importing more clicks than MaxClickImportSize at once
+	ErrClickImportTooLarge = NewAPIError(http.StatusBadRequest, "click_import_too_large", "Too many clicks in one import")
+	// ErrInvalidClick is returned when an imported click has no timestamp or one in the future
+	ErrInvalidClick = NewAPIError(http.StatusBadRequest, "invalid_click", "Imported clicks need a timestamp that is not in the future")
 )
 
+// HistoryFilter narrows and pages URL history queries
+type HistoryFilter struct {
+	// Action limits results to a single action (create, update, delete, restore, transfer, enable or disable) when set
+	Action string
+	// Limit is the maximum number of entries to return
+	Limit int
+	// Offset is the number of matching entries to skip
+	Offset int
+}
+
+// ClickRange limits click analytics to clicks at or after From and before To. A zero bound is open.
+type ClickRange struct {
+	From time.Time
+	To   time.Time
+}
+
+// Sort columns for creator URL listings
+const (
+	SortByCreatedAt = "created_at"
+	SortByClicks    = "clicks"
+)
+
+// CreatorURLFilter sorts and pages a creator's URL listing
+type CreatorURLFilter struct {
+	// Sort is the column to order by, SortByCreatedAt or SortByClicks
+	Sort string
+	// Descending reverses the sort order
+	Descending bool
+	// Limit is the maximum number of URLs to return
+	Limit int
+	// Offset is the number of URLs to skip
+	Offset int
+}
+
 // URLRepository defines the interface for URL storage operations
 type URLRepository interface {
-	// Create stores a new URL and returns the created URL with all fields
+	// Create stores a new URL and returns the created URL with all fields. A non-zero url.ID, reserved
+	// with NextURLID, becomes the ID of the new row.
 	Create(ctx context.Context, url *models.URL) (*models.URL, error)
+	// CreateBatch stores new URLs together, returning for each URL either the created URL or the error
+	// that prevented it. The final error is only set if the batch as a whole failed.
+	CreateBatch(ctx context.Context, urls []*models.URL) ([]*models.URL, []error, error)
+	// BulkCreate stores many new URLs quickly, returning for each URL the error that prevented it, if any.
+	// The final error is only set if the whole call failed.
+	BulkCreate(ctx context.Context, urls []*models.URL) ([]error, error)
+	// CountActiveURLs counts the URLs that are neither deleted nor expired
+	CountActiveURLs(ctx context.Context) (int64, error)
+	// NextURLID reserves an ID for a URL that hasn't been created yet
+	NextURLID(ctx context.Context) (int64, error)
 	// GetByShort retrieves a URL by its short code
 	GetByShort(ctx context.Context, short string) (*models.URL, error)
+	// GetByShortIncludingDeleted retrieves a URL by its short code, including soft-deleted and expired URLs
+	GetByShortIncludingDeleted(ctx context.Context, short string) (*models.URL, error)
+	// GetByShorts retrieves the URLs with any of the short codes in one query, skipping soft-deleted URLs
+	// but including expired ones. Codes without a URL are left out.
+	GetByShorts(ctx context.Context, shorts []string) ([]*models.URL, error)
 	// GetByOriginal retrieves a URL by its original URL
 	GetByOriginal(ctx context.Context, original string) (*models.URL, error)
-	// GetByCreator retrieves URLs by their creator reference
-	GetByCreator(ctx context.Context, creatorReference string) ([]*models.URL, error)
-	// IncrementClicks increments the click count for a URL
-	IncrementClicks(ctx context.Context, short string) error
+	// GetByOriginalForCreator retrieves the creator's most recently created live URL for an original URL
+	GetByOriginalForCreator(ctx context.Context, original string, creatorReference string) (*models.URL, error)
+	// CreateOrGet returns the creator's most recently created live URL for url.Original, or stores url if
+	// there is none, in one transaction. Concurrent calls for the same original URL and creator create it
+	// once. The returned boolean reports whether url was created.
+	CreateOrGet(ctx context.Context, url *models.URL) (*models.URL, bool, error)
+	// GetByCreator retrieves a sorted page of a creator's live URLs and the total number of live URLs
+	GetByCreator(ctx context.Context, creatorReference string, filter CreatorURLFilter) ([]*models.URL, int64, error)
+	// ListURLs retrieves a page of all URLs matching filter, newest first, and the total number of
+	// matching URLs. Expired URLs are included; soft-deleted URLs only with filter.IncludeDeleted.
+	ListURLs(ctx context.Context, filter URLListFilter, page Page) ([]*models.URL, int64, error)
+	// SearchURLs retrieves a page of live URLs whose original URL or title contains words starting with
+	// every word of query, best matches first, and the total number of matches. Unless creatorReference
+	// is empty, only that creator's URLs are searched.
+	SearchURLs(ctx context.Context, query string, creatorReference string, page Page) ([]*models.URL, int64, error)
+	// CountByCreator counts a creator's live URLs
+	CountByCreator(ctx context.Context, creatorReference string) (int64, error)
+	// StreamByCreator calls fn for each live URL of a creator, oldest first, without loading all URLs into memory
+	StreamByCreator(ctx context.Context, creatorReference string, fn func(*models.URL) error) error
+	// GetTopURLs retrieves up to limit live URLs with the most clicks, most clicked first, across all
+	// creators or only creatorReference's when it is not empty
+	GetTopURLs(ctx context.Context, creatorReference string, limit int) ([]*models.URL, error)
+	// GetRandomURLByCreator retrieves a random live, enabled URL of a creator, excluding random links
+	GetRandomURLByCreator(ctx context.Context, creatorReference string) (*models.URL, error)
+	// IncrementClicks increments the click count and last access time for a URL and returns the new count,
+	// or ErrClickLimitReached if the URL already has MaxClicks clicks
+	IncrementClicks(ctx context.Context, short string) (int64, error)
+	// RebuildClickCount recomputes a URL's click count from its seeded and recorded clicks and returns it
+	RebuildClickCount(ctx context.Context, short string) (int64, error)
+	// RebuildClickCounts recomputes the click counts of up to limit URLs with an ID above afterID, in ID order,
+	// from their seeded and recorded clicks, and returns their short codes and the highest ID processed
+	RebuildClickCounts(ctx context.Context, afterID int64, limit int) ([]string, int64, error)
 	// Delete removes a URL
 	Delete(ctx context.Context, short string) error
+	// HardDelete permanently removes a URL owned by creatorReference and its dependent records
+	HardDelete(ctx context.Context, short, creatorReference string) error
+	// PurgeExpired permanently removes up to limit URLs, with their dependent records, that expired more
+	// than grace ago and returns their short codes
+	PurgeExpired(ctx context.Context, grace time.Duration, limit int) ([]string, error)
 	// DeleteWithCreator soft deletes a URL if the creator_reference matches
 	DeleteWithCreator(ctx context.Context, short string, creatorReference string) error
+	// Restore clears the deletion of the URL with the short code. It returns ErrRestoreConflict if the
+	// URL isn't deleted and ErrURLNotFound if no URL has the code.
+	Restore(ctx context.Context, short string) (*models.URL, error)
 	// StoreClick stores click analytics data
 	StoreClick(ctx context.Context, click *models.Click) error
+	// StoreCountedClick stores a click and, unless it is already counted, increments its URL's click count
+	// in one transaction, returning the new count. Neither happens if the URL reached its click limit
+	// (ErrClickLimitReached) or doesn't exist (ErrURLNotFound).
+	StoreCountedClick(ctx context.Context, click *models.Click) (int64, error)
+	// StoreClickBatch stores clicks and increments the click count and last access time of their URLs in one transaction
+	StoreClickBatch(ctx context.Context, clicks []*models.Click) error
+	// ImportClicks stores historical clicks of a live URL, adds them to its click count in one transaction
+	// and returns the new count
+	ImportClicks(ctx context.Context, short string, clicks []*models.Click) (int64, error)
 	// GetClicksByShort retrieves click analytics data for a URL
 	GetClicksByShort(ctx context.Context, short string) ([]*models.Click, error)
-	// GetClickAnalytics retrieves aggregated click analytics data for a URL
-	GetClickAnalytics(ctx context.Context, short string) (map[string]interface{}, error)
-	// HasRecentClick checks if there's a recent click from the same visitor
-	HasRecentClick(ctx context.Context, short string, ip string, browser string, device string) (bool, error)
+	// StreamClicksByShort calls fn for each click of a URL, oldest first, without loading all clicks into memory
+	StreamClicksByShort(ctx context.Context, short string, fn func(*models.Click) error) error
+	// GetClickTimeSeries retrieves click counts for a URL's clicks within rng in buckets of interval
+	// (minute, hour, day or week) following the wall clock of the IANA timezone, oldest first
+	GetClickTimeSeries(ctx context.Context, short string, rng ClickRange, interval string, timezone string) ([]*models.TimeSeriesPoint, error)
+	// GetClickDistribution counts a URL's clicks within rng by the hour of the day and day of the week
+	// they happened at, following the wall clock of the IANA timezone
+	GetClickDistribution(ctx context.Context, short string, rng ClickRange, timezone string) (*models.ClickDistribution, error)
+	// CountClicks counts the recorded clicks of a URL. The clicks table is the authoritative click count;
+	// the URL's clicks counter is a fast denormalized copy that may lag behind or drift from it.
+	CountClicks(ctx context.Context, short string) (int64, error)
+	// GetResolveTiming computes percentiles of the captured resolve times of a URL's clicks
+	GetResolveTiming(ctx context.Context, short string) (*models.ResolveTiming, error)
+	// GetClickAnalytics retrieves aggregated click analytics data for a URL's clicks within rng
+	GetClickAnalytics(ctx context.Context, short string, rng ClickRange) (map[string]interface{}, error)
+	// ClickCountsByMetadata counts clicks across a creator's live URLs grouped by the value of a metadata key,
+	// most clicked first. URLs without the key are left out.
+	ClickCountsByMetadata(ctx context.Context, creatorReference string, key string) ([]*models.MetadataClickCount, error)
+	// HasRecentClick checks if there's a click from the same visitor within the window
+	HasRecentClick(ctx context.Context, short string, ip string, browser string, device string, window time.Duration) (bool, error)
 	// UpdateURL updates an existing URL
 	UpdateURL(ctx context.Context, short string, url *models.URL) error
 	// UpdateURLWithCreator updates an existing URL if the creator_reference matches
 	UpdateURLWithCreator(ctx context.Context, short string, url *models.URL, creatorReference string) error
+	// SetCreator assigns a URL to a creator
+	SetCreator(ctx context.Context, short string, creatorReference string) error
+	// SetEnabled enables or disables a live URL
+	SetEnabled(ctx context.Context, short string, enabled bool) error
+	// SetEnabledByCreator enables or disables all live URLs of a creator, optionally only those carrying tag,
+	// and returns the URLs whose state changed
+	SetEnabledByCreator(ctx context.Context, creatorReference string, tag string, enabled bool) ([]*models.URL, error)
+	// UpdateTags applies change to the tags of the live URLs with the given codes in a single transaction,
+	// skipping codes that don't exist or aren't owned by creatorReference. It returns each URL as it was
+	// before the change, and the error for each skipped code, in code order.
+	UpdateTags(ctx context.Context, creatorReference string, codes []string, change TagChange) ([]*models.URL, []error, error)
+	// TransferCreator assigns all live URLs of a creator to another creator and returns the transferred URLs
+	TransferCreator(ctx context.Context, from string, to string) ([]*models.URL, error)
 	// LogURLHistory logs a URL modification
 	LogURLHistory(ctx context.Context, urlID int64, short string, action string, oldValue, newValue interface{}, modifiedBy string) error
+	// GetURLHistory retrieves a page of history entries for a URL, newest first, and the total number of matching entries
+	GetURLHistory(ctx context.Context, short string, filter HistoryFilter) ([]*models.URLHistory, int64, error)
 }
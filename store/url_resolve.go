@@ -0,0 +1,58 @@
+package store
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/rs/zerolog/log"
+)
+
+// ErrRandomTargetUnresolved is returned when resolving a random link, whose destination is only
+// chosen when it is redirected
+var ErrRandomTargetUnresolved = NewAPIError(http.StatusUnprocessableEntity, "random_target", "Random links choose their destination on each redirect")
+
+// ResolveCodes looks up the destinations of up to MaxBatchSize short codes in a single query, without
+// recording clicks. Results are in code order: the URL a code redirects to, or ErrURLNotFound,
+// ErrURLExpired, ErrURLDisabled or ErrRandomTargetUnresolved when it doesn't redirect to a fixed URL.
+func (s *URLService) ResolveCodes(ctx context.Context, codes []string) ([]BatchResult, error) {
+	log.Debug().Int("codes", len(codes)).Msg("Resolving short codes")
+
+	if len(codes) > MaxBatchSize {
+		log.Error().Int("codes", len(codes)).Int("max", MaxBatchSize).Msg("Too many codes to resolve at once")
+		return nil, ErrBatchTooLarge
+	}
+
+	urls, err := s.db.GetByShorts(ctx, codes)
+	if err != nil {
+		log.Error().Err(err).Int("codes", len(codes)).Msg("Failed to resolve short codes")
+		return nil, err
+	}
+	byShort := make(map[string]*models.URL, len(urls))
+	for _, url := range urls {
+		byShort[url.Short] = url
+	}
+
+	results := make([]BatchResult, len(codes))
+	var resolved int
+	for i, code := range codes {
+		url, ok := byShort[code]
+		switch {
+		case !ok:
+			results[i].Err = ErrURLNotFound
+		case url.IsExpired():
+			results[i].Err = ErrURLExpired
+		case !url.Enabled:
+			results[i].Err = ErrURLDisabled
+		case url.RandomTarget:
+			results[i].Err = ErrRandomTargetUnresolved
+		default:
+			results[i].URL = url
+			resolved++
+		}
+	}
+
+	log.Info().Int("codes", len(codes)).Int("resolved", resolved).Msg("Short codes resolved")
+
+	return results, nil
+}
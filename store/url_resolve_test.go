@@ -0,0 +1,46 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveCodes(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Classifies every code", func(t *testing.T) {
+		active := models.NewURL("https://example.com/active", "active", "", time.Time{}, "creator")
+		expired := models.NewURL("https://example.com/expired", "expired", "", time.Now().Add(-time.Minute), "creator")
+		disabled := models.NewURL("https://example.com/disabled", "disabled", "", time.Time{}, "creator")
+		disabled.Enabled = false
+		random := models.NewURL("https://example.com/random", "random", "", time.Time{}, "creator")
+		random.RandomTarget = true
+
+		codes := []string{"expired", "active", "missing", "disabled", "random", "active"}
+		mockRepo := new(MockURLRepository)
+		mockRepo.On("GetByShorts", ctx, codes).Return([]*models.URL{active, expired, disabled, random}, nil)
+		service := NewURLService(mockRepo, nil)
+
+		results, err := service.ResolveCodes(ctx, codes)
+		require.NoError(t, err)
+		require.Len(t, results, len(codes))
+		assert.ErrorIs(t, results[0].Err, ErrURLExpired)
+		assert.Equal(t, active, results[1].URL)
+		assert.ErrorIs(t, results[2].Err, ErrURLNotFound)
+		assert.ErrorIs(t, results[3].Err, ErrURLDisabled)
+		assert.ErrorIs(t, results[4].Err, ErrRandomTargetUnresolved)
+		assert.Equal(t, active, results[5].URL)
+	})
+
+	t.Run("Rejects too many codes", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		_, err := NewURLService(mockRepo, nil).ResolveCodes(ctx, make([]string, MaxBatchSize+1))
+		assert.ErrorIs(t, err, ErrBatchTooLarge)
+		mockRepo.AssertNotCalled(t, "GetByShorts")
+	})
+}
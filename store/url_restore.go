@@ -0,0 +1,52 @@
+package store
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/rs/zerolog/log"
+)
+
+// ErrRestoreConflict is returned when restoring a URL that isn't deleted
+var ErrRestoreConflict = NewAPIError(http.StatusConflict, "code_in_use", "Short code is in use by a live URL")
+
+// Restore undoes the soft deletion of a URL, caching it again and recording modifiedBy in its history.
+// Unless creatorReference is empty, the URL must belong to that creator.
+func (s *URLService) Restore(ctx context.Context, short string, creatorReference string, modifiedBy string) (*models.URL, error) {
+	log.Debug().Str("short", short).Str("creator_reference", creatorReference).Str("modified_by", modifiedBy).Msg("Restoring URL")
+
+	url, err := s.GetByShortIncludingDeleted(ctx, short)
+	if err != nil {
+		return nil, err
+	}
+	if creatorReference != "" && url.CreatorReference != creatorReference {
+		log.Error().Str("short", short).Str("creator_reference", creatorReference).Msg("Restore requested by someone other than the owner")
+		return nil, ErrCreatorMismatch
+	}
+	if url.DeletedAt == nil {
+		log.Error().Str("short", short).Msg("URL to restore is not deleted")
+		return nil, ErrRestoreConflict
+	}
+
+	restored, err := s.db.Restore(ctx, short)
+	if err != nil {
+		log.Error().Err(err).Str("short", short).Msg("Failed to restore URL in database")
+		return nil, err
+	}
+
+	if err := s.db.LogURLHistory(ctx, restored.ID, short, "restore", url, restored, modifiedBy); err != nil {
+		log.Error().Err(err).Str("short", short).Msg("Failed to log URL restore history")
+		// The URL is restored even if logging fails
+	}
+
+	// Replaces a cached not found marker too
+	if s.cache != nil {
+		if err := s.cache.Set(ctx, restored); err != nil {
+			log.Warn().Err(err).Str("short", short).Msg("Failed to cache restored URL")
+		}
+	}
+
+	log.Info().Str("short", short).Str("modified_by", modifiedBy).Msg("URL restored successfully")
+	return restored, nil
+}
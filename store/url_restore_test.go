@@ -0,0 +1,58 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRestore(t *testing.T) {
+	ctx := context.Background()
+	deletedAt := time.Now().Add(-time.Hour)
+
+	t.Run("Restored URL is cached and logged", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		mockCache := new(MockCacheRepository)
+		service := NewURLService(mockRepo, mockCache)
+
+		deleted := &models.URL{ID: 1, Short: "abc123", CreatorReference: "alice", DeletedAt: &deletedAt}
+		restored := &models.URL{ID: 1, Short: "abc123", CreatorReference: "alice"}
+		mockRepo.On("GetByShortIncludingDeleted", ctx, "abc123").Return(deleted, nil)
+		mockRepo.On("Restore", ctx, "abc123").Return(restored, nil)
+		mockRepo.On("LogURLHistory", ctx, int64(1), "abc123", "restore", deleted, restored, "alice").Return(nil)
+		mockCache.On("Set", ctx, restored).Return(nil)
+
+		url, err := service.Restore(ctx, "abc123", "alice", "alice")
+		require.NoError(t, err)
+		assert.Same(t, restored, url)
+		mockRepo.AssertExpectations(t)
+		mockCache.AssertExpectations(t)
+	})
+
+	t.Run("Other creators can't restore", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		service := NewURLService(mockRepo, nil)
+		mockRepo.On("GetByShortIncludingDeleted", ctx, "abc123").
+			Return(&models.URL{ID: 1, Short: "abc123", CreatorReference: "alice", DeletedAt: &deletedAt}, nil)
+
+		_, err := service.Restore(ctx, "abc123", "bob", "bob")
+		assert.ErrorIs(t, err, ErrCreatorMismatch)
+		mockRepo.AssertNotCalled(t, "Restore", mock.Anything, mock.Anything)
+	})
+
+	t.Run("URLs that are not deleted conflict", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		service := NewURLService(mockRepo, nil)
+		mockRepo.On("GetByShortIncludingDeleted", ctx, "abc123").
+			Return(&models.URL{ID: 2, Short: "abc123", CreatorReference: "alice"}, nil)
+
+		_, err := service.Restore(ctx, "abc123", "", "admin")
+		assert.ErrorIs(t, err, ErrRestoreConflict)
+		mockRepo.AssertNotCalled(t, "Restore", mock.Anything, mock.Anything)
+	})
+}
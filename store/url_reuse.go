@@ -0,0 +1,162 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/rs/zerolog/log"
+)
+
+// ReuseConflictPolicy decides what happens when a request asks to reuse an existing link
+// but also provides a custom code that differs from the existing link's code
+type ReuseConflictPolicy string
+
+const (
+	// ReuseConflictError rejects the request with ErrReuseConflict
+	ReuseConflictError ReuseConflictPolicy = "error"
+	// ReuseConflictPreferCustom ignores reuse and creates a new link with the custom code
+	ReuseConflictPreferCustom ReuseConflictPolicy = "custom"
+	// ReuseConflictPreferExisting ignores the custom code and returns the existing link
+	ReuseConflictPreferExisting ReuseConflictPolicy = "existing"
+)
+
+// ParseReuseConflictPolicy validates a reuse conflict policy name
+func ParseReuseConflictPolicy(name string) (ReuseConflictPolicy, error) {
+	switch policy := ReuseConflictPolicy(name); policy {
+	case ReuseConflictError, ReuseConflictPreferCustom, ReuseConflictPreferExisting:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown reuse conflict policy: %q", name)
+	}
+}
+
+// WithReuseConflictPolicy sets how CreateOrReuseShortURL resolves a custom code that conflicts with an existing link
+func WithReuseConflictPolicy(policy ReuseConflictPolicy) Option {
+	return func(s *URLService) {
+		s.reuseConflictPolicy = policy
+	}
+}
+
+// CreateOrReuseShortURL returns the creator's existing live link for originalURL if there is one,
+// and otherwise creates a new short URL. The returned boolean reports whether an existing link was reused.
+func (s *URLService) CreateOrReuseShortURL(ctx context.Context, originalURL string, customShort string, title string, expireAfter time.Duration, creatorReference string, opts ...URLOption) (*models.URL, bool, error) {
+	log.Debug().
+		Str("original_url", originalURL).
+		Str("custom_short", customShort).
+		Str("creator_reference", creatorReference).
+		Str("policy", string(s.reuseConflictPolicy)).
+		Msg("Creating or reusing short URL")
+
+	// Without a custom code there is nothing to conflict with, so the link is found or created atomically
+	if customShort == "" {
+		url, created, err := s.createOrGet(ctx, originalURL, title, expireAfter, creatorReference, opts...)
+		if err != nil {
+			return nil, false, err
+		}
+		return url, !created, nil
+	}
+
+	// Only the creator's own live links are reused. The cache maps an original URL to a single link of
+	// any creator, so the database is asked directly.
+	existing, err := s.db.GetByOriginalForCreator(ctx, originalURL, creatorReference)
+	if err != nil && !errors.Is(err, ErrURLNotFound) {
+		log.Error().Err(err).Str("original_url", originalURL).Msg("Failed to look up existing URL for reuse")
+		return nil, false, err
+	}
+
+	if existing != nil {
+		switch {
+		case customShort == existing.Short:
+			log.Info().Str("original_url", originalURL).Str("short", existing.Short).Msg("Reusing existing short URL")
+			return existing, true, nil
+		case s.reuseConflictPolicy == ReuseConflictPreferExisting:
+			log.Info().
+				Str("original_url", originalURL).
+				Str("short", existing.Short).
+				Str("custom_short", customShort).
+				Msg("Ignoring custom code and reusing existing short URL")
+			return existing, true, nil
+		case s.reuseConflictPolicy == ReuseConflictPreferCustom:
+			log.Debug().
+				Str("short", existing.Short).
+				Str("custom_short", customShort).
+				Msg("Ignoring existing short URL in favor of custom code")
+		default:
+			log.Error().
+				Str("original_url", originalURL).
+				Str("short", existing.Short).
+				Str("custom_short", customShort).
+				Msg("Custom code conflicts with existing short URL")
+			return existing, false, ErrReuseConflict
+		}
+	}
+
+	created, err := s.CreateShortURL(ctx, originalURL, customShort, title, expireAfter, creatorReference, opts...)
+	if err != nil {
+		return nil, false, err
+	}
+	return created, false, nil
+}
+
+// CreateOrGet returns the creator's existing live link for originalURL, or creates one if there is none.
+// Unlike CreateOrReuseShortURL, finding and creating happen in one transaction, so concurrent calls for
+// the same URL and creator create a single link. The returned boolean reports whether it was created.
+func (s *URLService) CreateOrGet(ctx context.Context, originalURL string, creatorReference string, opts ...URLOption) (*models.URL, bool, error) {
+	return s.createOrGet(ctx, originalURL, "", 0, creatorReference, opts...)
+}
+
+// createOrGet is CreateOrGet with the title and expiry of a created link
+func (s *URLService) createOrGet(ctx context.Context, originalURL string, title string, expireAfter time.Duration, creatorReference string, opts ...URLOption) (*models.URL, bool, error) {
+	log.Debug().
+		Str("original_url", originalURL).
+		Str("creator_reference", creatorReference).
+		Msg("Getting or creating short URL")
+
+	// Most calls find a link, which needs no code to be generated. The cache maps an original URL to a
+	// single link of any creator, so the database is asked directly.
+	existing, err := s.db.GetByOriginalForCreator(ctx, originalURL, creatorReference)
+	if err == nil {
+		log.Info().Str("original_url", originalURL).Str("short", existing.Short).Msg("Reusing existing short URL")
+		return existing, false, nil
+	}
+	if !errors.Is(err, ErrURLNotFound) {
+		log.Error().Err(err).Str("original_url", originalURL).Msg("Failed to look up existing URL for reuse")
+		return nil, false, err
+	}
+
+	newURL, err := s.buildShortURL(ctx, originalURL, "", title, expireAfter, creatorReference, opts...)
+	if err != nil {
+		return nil, false, err
+	}
+
+	url, created, err := s.db.CreateOrGet(ctx, newURL)
+	if err != nil {
+		log.Error().Err(err).Str("short", newURL.Short).Msg("Failed to get or create URL in database")
+		// A generated code taken since it was checked is a generation failure, not a client conflict
+		if errors.Is(err, ErrURLExists) {
+			return nil, false, ErrCodeGenerationFailed
+		}
+		return nil, false, err
+	}
+	if !created {
+		log.Info().Str("original_url", originalURL).Str("short", url.Short).Msg("Reusing short URL created concurrently")
+		return url, false, nil
+	}
+
+	if s.cache != nil {
+		if err := s.cache.Set(ctx, url); err != nil {
+			return nil, false, err
+		}
+	}
+
+	log.Info().
+		Str("original_url", originalURL).
+		Str("short", url.Short).
+		Int64("id", url.ID).
+		Msg("Short URL created successfully")
+
+	return url, true, nil
+}
@@ -0,0 +1,75 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateOrGet(t *testing.T) {
+	ctx := context.Background()
+	fixedCode := WithCodeGenerator(func(ctx context.Context) (string, error) { return "abc123", nil })
+
+	t.Run("Existing URL is returned without generating a code", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		service := NewURLService(mockRepo, nil, fixedCode)
+		existing := &models.URL{ID: 1, Original: "https://example.com", Short: "old", CreatorReference: "alice"}
+		mockRepo.On("GetByOriginalForCreator", ctx, "https://example.com", "alice").Return(existing, nil)
+
+		url, created, err := service.CreateOrGet(ctx, "https://example.com", "alice")
+		require.NoError(t, err)
+		assert.False(t, created)
+		assert.Same(t, existing, url)
+		mockRepo.AssertNotCalled(t, "GetByShortIncludingDeleted", mock.Anything, mock.Anything)
+		mockRepo.AssertNotCalled(t, "CreateOrGet", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Missing URL is created and cached", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		mockCache := new(MockCacheRepository)
+		service := NewURLService(mockRepo, mockCache, fixedCode)
+		created := &models.URL{ID: 2, Original: "https://example.com", Short: "abc123", CreatorReference: "alice"}
+		mockRepo.On("GetByOriginalForCreator", ctx, "https://example.com", "alice").Return(nil, ErrURLNotFound)
+		mockRepo.On("GetByShortIncludingDeleted", ctx, "abc123").Return(nil, ErrURLNotFound)
+		mockRepo.On("CreateOrGet", ctx, mock.MatchedBy(func(url *models.URL) bool {
+			return url.Short == "abc123" && url.Original == "https://example.com" && url.CreatorReference == "alice"
+		})).Return(created, true, nil)
+		mockCache.On("Set", ctx, created).Return(nil)
+
+		url, wasCreated, err := service.CreateOrGet(ctx, "https://example.com", "alice")
+		require.NoError(t, err)
+		assert.True(t, wasCreated)
+		assert.Same(t, created, url)
+		mockRepo.AssertExpectations(t)
+		mockCache.AssertExpectations(t)
+	})
+
+	t.Run("URL created concurrently is returned", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		service := NewURLService(mockRepo, nil, fixedCode)
+		concurrent := &models.URL{ID: 3, Original: "https://example.com", Short: "xyz789", CreatorReference: "alice"}
+		mockRepo.On("GetByOriginalForCreator", ctx, "https://example.com", "alice").Return(nil, ErrURLNotFound)
+		mockRepo.On("GetByShortIncludingDeleted", ctx, "abc123").Return(nil, ErrURLNotFound)
+		mockRepo.On("CreateOrGet", ctx, mock.Anything).Return(concurrent, false, nil)
+
+		url, created, err := service.CreateOrGet(ctx, "https://example.com", "alice")
+		require.NoError(t, err)
+		assert.False(t, created)
+		assert.Same(t, concurrent, url)
+	})
+
+	t.Run("Generated code taken meanwhile is a generation failure", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		service := NewURLService(mockRepo, nil, fixedCode)
+		mockRepo.On("GetByOriginalForCreator", ctx, "https://example.com", "alice").Return(nil, ErrURLNotFound)
+		mockRepo.On("GetByShortIncludingDeleted", ctx, "abc123").Return(nil, ErrURLNotFound)
+		mockRepo.On("CreateOrGet", ctx, mock.Anything).Return(nil, false, ErrURLExists)
+
+		_, _, err := service.CreateOrGet(ctx, "https://example.com", "alice")
+		assert.ErrorIs(t, err, ErrCodeGenerationFailed)
+	})
+}
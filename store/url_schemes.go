@@ -0,0 +1,37 @@
+package store
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// WithAllowedSchemes restricts original URLs to the given schemes, e.g. "http", "https", "mailto".
+// Without this option only http and https are allowed.
+func WithAllowedSchemes(schemes ...string) Option {
+	return func(s *URLService) {
+		s.allowedSchemes = make(map[string]bool, len(schemes))
+		for _, scheme := range schemes {
+			scheme = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(scheme)), ":")
+			if scheme != "" {
+				s.allowedSchemes[scheme] = true
+			}
+		}
+	}
+}
+
+// checkScheme returns ErrDisallowedScheme if originalURL uses a scheme that isn't allowlisted
+func (s *URLService) checkScheme(originalURL string) error {
+	parsed, err := url.Parse(originalURL)
+	if err != nil {
+		return ErrInvalidURL
+	}
+
+	// url.Parse lowercases the scheme
+	if !s.allowedSchemes[parsed.Scheme] {
+		log.Warn().Str("url", originalURL).Str("scheme", parsed.Scheme).Msg("Rejected URL with disallowed scheme")
+		return ErrDisallowedScheme
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAllowedSchemes(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Allows https by default", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		service := NewURLService(mockRepo, nil)
+
+		original := "https://example.com/page"
+		mockRepo.On("GetByShort", ctx, "custom").Return(nil, ErrURLNotFound)
+		mockRepo.On("Create", ctx, mock.AnythingOfType("*models.URL")).Return(&models.URL{ID: 1, Original: original, Short: "custom"}, nil)
+
+		created, err := service.CreateShortURL(ctx, original, "custom", "", 0, "creator")
+		require.NoError(t, err)
+		assert.Equal(t, original, created.Original)
+	})
+
+	t.Run("Rejects other schemes by default", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		service := NewURLService(mockRepo, nil)
+
+		for _, original := range []string{"ftp://example.com/file", "mailto:someone@example.com", "javascript:alert(1)"} {
+			_, err := service.CreateShortURL(ctx, original, "custom", "", 0, "creator")
+			assert.ErrorIs(t, err, ErrDisallowedScheme, original)
+		}
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Allows mailto when configured", func(t *testing.T) {
+		service := NewURLService(new(MockURLRepository), nil, WithAllowedSchemes("https", " MAILTO "))
+		assert.NoError(t, service.checkScheme("mailto:someone@example.com"))
+		assert.NoError(t, service.checkScheme("HTTPS://example.com"))
+		assert.ErrorIs(t, service.checkScheme("http://example.com"), ErrDisallowedScheme)
+	})
+
+	t.Run("Rejects disallowed schemes on update", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		service := NewURLService(mockRepo, nil)
+
+		existing := &models.URL{ID: 1, Original: "https://example.com", Short: "abc123", CreatorReference: "creator", Enabled: true}
+		mockRepo.On("GetByShort", ctx, "abc123").Return(existing, nil)
+
+		_, err := service.UpdateURLWithCreator(ctx, "abc123", "", "ftp://example.com/file", 0, "creator")
+		assert.ErrorIs(t, err, ErrDisallowedScheme)
+		mockRepo.AssertNotCalled(t, "UpdateURLWithCreator", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,52 @@
+package store
+
+import (
+	"context"
+	"strings"
+	"unicode"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/rs/zerolog/log"
+)
+
+// maxSearchTerms caps the words of a search query, so a long query can't build an expensive search
+const maxSearchTerms = 8
+
+// searchTerms splits a search query into lowercase words of letters and digits. Punctuation separates
+// words, as it does in the indexed URLs, so "example.com/spring" searches example, com and spring.
+func searchTerms(query string) []string {
+	terms := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(terms) > maxSearchTerms {
+		terms = terms[:maxSearchTerms]
+	}
+	return terms
+}
+
+// SearchURLs retrieves a page of live URLs whose original URL or title contains words starting with
+// every word of query, best matches first, and the total number of matches. Unless creatorReference is
+// empty, only that creator's URLs are searched. A query without words matches nothing.
+func (s *URLService) SearchURLs(ctx context.Context, query string, creatorReference string, page Page) ([]*models.URL, int64, error) {
+	log.Debug().
+		Str("query", query).
+		Str("creator_reference", creatorReference).
+		Int("limit", page.Limit).
+		Int("offset", page.Offset).
+		Msg("Searching URLs")
+
+	if len(searchTerms(query)) == 0 {
+		log.Info().Str("query", query).Msg("Empty search query, no URLs matched")
+		return nil, 0, nil
+	}
+
+	urls, total, err := s.db.SearchURLs(ctx, query, creatorReference, page)
+	if err != nil {
+		log.Error().Err(err).Str("query", query).Msg("Database error when searching URLs")
+		return nil, 0, err
+	}
+
+	log.Info().Str("query", query).Int("count", len(urls)).Int64("total", total).Msg("URLs searched")
+
+	return urls, total, nil
+}
@@ -0,0 +1,47 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchTerms(t *testing.T) {
+	assert.Equal(t, []string{"example", "com", "spring"}, searchTerms("Example.com/SPRING"))
+	assert.Equal(t, []string{"café", "2024"}, searchTerms("  café & 2024:* | !"))
+	assert.Empty(t, searchTerms(" &|!:* "))
+	assert.Len(t, searchTerms("a b c d e f g h i j"), maxSearchTerms)
+}
+
+func TestSearchURLs(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Query is searched", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		service := NewURLService(mockRepo, nil)
+		found := []*models.URL{{ID: 1, Short: "abc123"}}
+		mockRepo.On("SearchURLs", ctx, "spring sale", "alice", Page{Limit: 20}).Return(found, int64(1), nil)
+
+		urls, total, err := service.SearchURLs(ctx, "spring sale", "alice", Page{Limit: 20})
+		require.NoError(t, err)
+		assert.Equal(t, found, urls)
+		assert.Equal(t, int64(1), total)
+	})
+
+	t.Run("Query without words matches nothing", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		service := NewURLService(mockRepo, nil)
+
+		for _, query := range []string{"", "   ", "&|!"} {
+			urls, total, err := service.SearchURLs(ctx, query, "alice", Page{Limit: 20})
+			require.NoError(t, err)
+			assert.Empty(t, urls)
+			assert.Zero(t, total)
+		}
+		mockRepo.AssertNotCalled(t, "SearchURLs", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
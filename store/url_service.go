@@ -2,33 +2,225 @@ package store
 
 import (
 	"context"
-	"crypto/rand"
-	"encoding/base64"
 	"errors"
-	"net/url"
+	"net/http"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/fransfilastap/urlshortener/metrics"
 	"github.com/fransfilastap/urlshortener/models"
 	"github.com/rs/zerolog/log"
 )
 
 // URLService provides URL shortening and retrieval services
 type URLService struct {
-	db    URLRepository
-	cache CacheRepositoryInterface
+	db        URLRepository
+	cache     CacheRepositoryInterface
+	enrichers []ClickEnricher
+
+	reuseConflictPolicy ReuseConflictPolicy
+	httpClient          *http.Client
+	reachabilityTimeout time.Duration
+	privateReachability bool
+	maxSeriesBuckets    int
+	analyticsWindow     time.Duration
+	codeGenerator       CodeGenerator
+	codeStrategy        CodeStrategy
+	urlValidation       URLValidation
+	urlValidator        *URLValidator
+	codeLengthScaler    *CodeLengthScaler
+
+	blockedShortenerDomains map[string]bool
+	creatorDefaults         CreatorDefaultsRepository
+	clickWebhookURL         string
+	clickWebhookSecret      string
+	clickWebhookQueueSize   int
+	clickWebhooks           *clickWebhookQueue
+	reservedCodes           map[string]bool
+	bannedWords             []string
+	allowedSchemes          map[string]bool
+	clickExtraFields        map[string]bool
+	geoLocator              GeoLocator
+	unknownLocation         string
+	baseHost                string
+	clickGrace              *clickGrace
+	clickDedupWindow        time.Duration
+
+	clickBatchSize     int
+	clickBatchInterval time.Duration
+	clickBatcher       *ClickBatcher
+	clickWorkers       int
+	clickQueueSize     int
+	clickQueue         *clickQueue
+}
+
+// Option configures optional URLService behavior
+type Option func(*URLService)
+
+// WithClickEnrichers sets the ordered pipeline of enrichers applied to every click
+func WithClickEnrichers(enrichers ...ClickEnricher) Option {
+	return func(s *URLService) {
+		s.enrichers = enrichers
+	}
+}
+
+// WithHTTPClient sets the shared client used for outbound requests to destination URLs
+func WithHTTPClient(client *http.Client) Option {
+	return func(s *URLService) {
+		s.httpClient = client
+	}
+}
+
+// URLOption sets optional attributes of a URL when it is created or updated
+type URLOption func(*models.URL)
+
+// WithRateLimit limits a URL to the given number of redirects per minute; 0 removes the limit
+func WithRateLimit(perMinute int) URLOption {
+	return func(url *models.URL) {
+		url.RateLimit = perMinute
+	}
+}
+
+// WithRedirectHeaders sets extra headers for the URL's redirect responses; an empty map removes them
+func WithRedirectHeaders(headers map[string]string) URLOption {
+	return func(url *models.URL) {
+		if len(headers) == 0 {
+			url.RedirectHeaders = nil
+			return
+		}
+		url.RedirectHeaders = headers
+	}
+}
+
+// WithMetadata sets free-form labels on the URL, e.g. {"campaign": "spring"}; an empty map removes them
+func WithMetadata(metadata map[string]string) URLOption {
+	return func(url *models.URL) {
+		if len(metadata) == 0 {
+			url.Metadata = nil
+			return
+		}
+		url.Metadata = metadata
+	}
+}
+
+// WithTags sets the URL's tags, trimming and de-duplicating them; an empty list removes them
+func WithTags(tags []string) URLOption {
+	return func(url *models.URL) {
+		url.Tags = normalizeTags(tags)
+	}
+}
+
+// normalizeTags trims tags and drops empty and duplicate ones, keeping their order
+func normalizeTags(tags []string) []string {
+	var normalized []string
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || slices.Contains(normalized, tag) {
+			continue
+		}
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
+
+// WithDisabledRedirectURL sets where visitors are redirected while the URL is disabled; empty restores
+// the configured disabled response
+func WithDisabledRedirectURL(target string) URLOption {
+	return func(url *models.URL) {
+		url.DisabledRedirectURL = target
+	}
+}
+
+// WithMaxClicks stops the URL redirecting after the given number of clicks; 0 removes the limit
+func WithMaxClicks(maxClicks int64) URLOption {
+	return func(url *models.URL) {
+		url.MaxClicks = maxClicks
+	}
+}
+
+// WithCacheTTL keeps the URL cached for the given number of seconds instead of the cache's default TTL;
+// 0 restores the default
+func WithCacheTTL(seconds int) URLOption {
+	return func(url *models.URL) {
+		url.CacheTTL = seconds
+	}
+}
+
+// WithNotes sets the URL's internal note; empty removes it
+func WithNotes(notes string) URLOption {
+	return func(url *models.URL) {
+		url.Notes = notes
+	}
+}
+
+// WithRandomTarget makes the URL redirect to a random live link of its creator, chosen per hit.
+// The original URL is used when the creator has no other live links.
+func WithRandomTarget() URLOption {
+	return func(u *models.URL) {
+		u.RandomTarget = true
+	}
+}
+
+// WithInitialClicks seeds the click count of a newly created URL, e.g. when migrating from another shortener.
+// The seed is kept apart from the recorded clicks so rebuilding the counts preserves it. It has no effect on updates.
+func WithInitialClicks(clicks int64) URLOption {
+	return func(url *models.URL) {
+		if url.ID == 0 {
+			url.Clicks = clicks
+			url.SeededClicks = clicks
+		}
+	}
 }
 
 // NewURLService creates a new URL service
-func NewURLService(db URLRepository, cache CacheRepositoryInterface) *URLService {
-	return &URLService{
-		db:    db,
-		cache: cache,
+func NewURLService(db URLRepository, cache CacheRepositoryInterface, opts ...Option) *URLService {
+	s := &URLService{
+		db:        db,
+		cache:     cache,
+		enrichers: []ClickEnricher{ReferrerEnricher, UserAgentEnricher, UnknownLocationEnricher},
+
+		reuseConflictPolicy: ReuseConflictError,
+		httpClient:          http.DefaultClient,
+		maxSeriesBuckets:    1000,
+		codeGenerator:       alphabetCodeGenerator([]rune(Base62Alphabet), DefaultCodeLength),
+		codeStrategy:        CodeStrategyRandom,
+		urlValidation:       URLValidationStrict,
+		reservedCodes:       make(map[string]bool),
+		bannedWords:         defaultBannedWords,
+		allowedSchemes:      map[string]bool{"http": true, "https": true},
+		geoLocator:          NoopGeoLocator{},
+		unknownLocation:     DefaultUnknownLocation,
+		clickDedupWindow:    DefaultClickDedupWindow,
+
+		clickWebhookQueueSize: DefaultClickWebhookQueueSize,
+	}
+	for _, code := range defaultReservedCodes {
+		s.reservedCodes[code] = true
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	if s.clickBatchSize > 0 {
+		s.clickBatcher = NewClickBatcher(s.writeClickBatch, s.clickBatchSize, s.clickBatchInterval)
+	}
+	s.startClickWebhooks()
+	s.startClickWorkers()
+	return s
+}
+
+// Close tracks the clicks still queued for the click workers, then flushes clicks still waiting to be
+// written in a batch and sends the queued click webhooks
+func (s *URLService) Close() {
+	s.closeClickQueue()
+	if s.clickBatcher != nil {
+		s.clickBatcher.Close()
+	}
+	s.closeClickWebhooks()
 }
 
 // CreateShortURL creates a new short URL
-func (s *URLService) CreateShortURL(ctx context.Context, originalURL string, customShort string, title string, expireAfter time.Duration, creatorReference string) (*models.URL, error) {
+func (s *URLService) CreateShortURL(ctx context.Context, originalURL string, customShort string, title string, expireAfter time.Duration, creatorReference string, opts ...URLOption) (*models.URL, error) {
 	log.Debug().
 		Str("original_url", originalURL).
 		Str("custom_short", customShort).
@@ -37,30 +229,105 @@ func (s *URLService) CreateShortURL(ctx context.Context, originalURL string, cus
 		Str("creator_reference", creatorReference).
 		Msg("Creating short URL")
 
+	newURL, err := s.newShortURL(ctx, originalURL, customShort, title, expireAfter, creatorReference, opts...)
+	if err != nil {
+		return nil, err
+	}
+	short := newURL.Short
+
+	// Save to database
+	log.Debug().Str("short", short).Msg("Saving URL to database")
+	createdURL, err := s.db.Create(ctx, newURL)
+	if err != nil {
+		log.Error().Err(err).Str("short", short).Msg("Failed to save URL to database")
+		// A generated code taken since it was checked is a generation failure, not a client conflict
+		if customShort == "" && errors.Is(err, ErrURLExists) {
+			return nil, ErrCodeGenerationFailed
+		}
+		return nil, err
+	}
+
+	// Cache the URL
+	if s.cache != nil {
+		log.Debug().Str("short", short).Msg("Caching URL")
+		err := s.cache.Set(ctx, createdURL)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	log.Info().
+		Str("original_url", originalURL).
+		Str("short", short).
+		Interface("expires_at", createdURL.ExpiresAt).
+		Int64("id", createdURL.ID).
+		Msg("Short URL created successfully")
+
+	return createdURL, nil
+}
+
+// newShortURL validates a new short URL and builds it, generating its code unless customShort is given.
+// The URL isn't saved.
+func (s *URLService) newShortURL(ctx context.Context, originalURL string, customShort string, title string, expireAfter time.Duration, creatorReference string, opts ...URLOption) (*models.URL, error) {
+	newURL, err := s.buildShortURL(ctx, originalURL, customShort, title, expireAfter, creatorReference, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if customShort == "" {
+		return newURL, nil
+	}
+
+	// Check if custom short URL already exists
+	_, err = s.GetByShort(ctx, customShort)
+	if err == nil {
+		log.Error().Str("custom_short", customShort).Msg("Custom short code already in use")
+		return nil, ErrURLExists
+	} else if !errors.Is(err, ErrURLNotFound) {
+		log.Error().Err(err).Str("custom_short", customShort).Msg("Error checking if custom short code exists")
+		return nil, err
+	}
+
+	return newURL, nil
+}
+
+// buildShortURL validates a new short URL and builds it like newShortURL, without checking whether
+// customShort is already in use
+func (s *URLService) buildShortURL(ctx context.Context, originalURL string, customShort string, title string, expireAfter time.Duration, creatorReference string, opts ...URLOption) (*models.URL, error) {
 	// Validate URL
-	if _, err := url.ParseRequestURI(originalURL); err != nil {
-		log.Error().Err(err).Str("url", originalURL).Msg("Invalid URL format")
-		return nil, ErrInvalidURL
+	if err := s.validateURL(originalURL); err != nil {
+		return nil, err
+	}
+
+	// Look up the creator's defaults for settings the request omits
+	defaults, err := s.defaultsFor(ctx, creatorReference)
+	if err != nil {
+		log.Error().Err(err).Str("creator_reference", creatorReference).Msg("Failed to get creator defaults")
+		return nil, err
+	}
+	if defaults != nil && expireAfter <= 0 && defaults.Expiry > 0 {
+		expireAfter = time.Duration(defaults.Expiry) * time.Second
+		log.Debug().Dur("expire_after", expireAfter).Msg("Applying creator default expiry")
 	}
 
 	// Generate short URL if not provided
 	short := customShort
+	var id int64
 	if short == "" {
 		var err error
-		log.Debug().Msg("No custom short code provided, generating random code")
-		short, err = s.generateShortURL(6)
+		if s.codeStrategy == CodeStrategySequential {
+			log.Debug().Msg("No custom short code provided, encoding the next URL ID")
+			id, short, err = s.generateSequentialShortURL(ctx)
+		} else {
+			log.Debug().Msg("No custom short code provided, generating random code")
+			short, err = s.generateShortURL(ctx)
+		}
 		if err != nil {
 			log.Error().Err(err).Msg("Failed to generate short URL")
 			return nil, err
 		}
 	} else {
-		// Check if custom short URL already exists
-		_, err := s.GetByShort(ctx, short)
-		if err == nil {
-			log.Error().Str("custom_short", short).Msg("Custom short code already in use")
-			return nil, ErrURLExists
-		} else if !errors.Is(err, ErrURLNotFound) {
-			log.Error().Err(err).Str("custom_short", short).Msg("Error checking if custom short code exists")
+		if err := s.ValidateCustomCode(short); err != nil {
+			log.Error().Err(err).Str("custom_short", short).Msg("Invalid custom short code")
 			return nil, err
 		}
 	}
@@ -74,32 +341,25 @@ func (s *URLService) CreateShortURL(ctx context.Context, originalURL string, cus
 
 	// Create URL
 	newURL := models.NewURL(originalURL, short, title, expiresAt, creatorReference)
-
-	// Save to database
-	log.Debug().Str("short", short).Msg("Saving URL to database")
-	createdURL, err := s.db.Create(ctx, newURL)
-	if err != nil {
-		log.Error().Err(err).Str("short", short).Msg("Failed to save URL to database")
+	if defaults != nil {
+		newURL.RateLimit = defaults.RateLimit
+		newURL.RedirectHeaders = defaults.RedirectHeaders
+	}
+	for _, opt := range opts {
+		opt(newURL)
+	}
+	// Options see a URL without an ID so creation-only options (e.g. WithInitialClicks) apply
+	// to sequential codes too; the reserved ID is assigned afterwards.
+	newURL.ID = id
+	if err := checkDisabledRedirectURL(newURL); err != nil {
 		return nil, err
 	}
-
-	// Cache the URL
-	if s.cache != nil {
-		log.Debug().Str("short", short).Msg("Caching URL")
-		err := s.cache.Set(ctx, createdURL)
-		if err != nil {
-			return nil, err
-		}
+	if newURL.RandomTarget && creatorReference == "" {
+		log.Error().Str("short", short).Msg("Random link created without a creator reference")
+		return nil, ErrRandomTargetWithoutCreator
 	}
 
-	log.Info().
-		Str("original_url", originalURL).
-		Str("short", short).
-		Time("expires_at", expiresAt).
-		Int64("id", createdURL.ID).
-		Msg("Short URL created successfully")
-
-	return createdURL, nil
+	return newURL, nil
 }
 
 // GetByShort retrieves a URL by its short code
@@ -112,11 +372,22 @@ func (s *URLService) GetByShort(ctx context.Context, short string) (*models.URL,
 		foundURL, err := s.cache.GetByShort(ctx, short)
 		if err == nil {
 			log.Debug().Str("short", short).Msg("URL found in cache")
+			metrics.URLLookups.WithLabelValues(metrics.LookupCacheHit).Inc()
 			return foundURL, nil
+		} else if errors.Is(err, ErrCachedNotFound) {
+			log.Debug().Str("short", short).Msg("URL cached as not found")
+			metrics.URLLookups.WithLabelValues(metrics.LookupCachedNotFound).Inc()
+			return nil, ErrURLNotFound
+		} else if errors.Is(err, ErrURLExpired) {
+			log.Debug().Str("short", short).Msg("URL in cache has expired")
+			metrics.URLLookups.WithLabelValues(metrics.LookupCacheHit).Inc()
+			return nil, ErrURLExpired
 		} else if !errors.Is(err, ErrURLNotFound) {
 			log.Error().Err(err).Str("short", short).Msg("Cache error when getting URL by short code")
+			metrics.URLLookups.WithLabelValues(metrics.LookupDBFallback).Inc()
 		} else {
 			log.Debug().Str("short", short).Msg("URL not found in cache, checking database")
+			metrics.URLLookups.WithLabelValues(metrics.LookupCacheMiss).Inc()
 		}
 	}
 
@@ -124,8 +395,16 @@ func (s *URLService) GetByShort(ctx context.Context, short string) (*models.URL,
 	log.Debug().Str("short", short).Msg("Getting URL from database")
 	urlRecord, err := s.db.GetByShort(ctx, short)
 	if err != nil {
-		if errors.Is(err, ErrURLNotFound) {
+		if errors.Is(err, ErrURLExpired) {
+			log.Debug().Str("short", short).Msg("URL in database has expired")
+		} else if errors.Is(err, ErrURLNotFound) {
 			log.Debug().Str("short", short).Msg("URL not found in database")
+			// Remember the miss so repeated lookups of the code don't reach the database
+			if s.cache != nil {
+				if err := s.cache.SetNotFound(ctx, short); err != nil {
+					log.Warn().Err(err).Str("short", short).Msg("Failed to cache URL as not found")
+				}
+			}
 		} else {
 			log.Error().Err(err).Str("short", short).Msg("Database error when getting URL by short code")
 		}
@@ -144,13 +423,36 @@ func (s *URLService) GetByShort(ctx context.Context, short string) (*models.URL,
 	log.Info().
 		Str("short", short).
 		Str("original_url", urlRecord.Original).
-		Time("expires_at", urlRecord.ExpiresAt).
+		Interface("expires_at", urlRecord.ExpiresAt).
 		Int64("clicks", urlRecord.Clicks).
 		Msg("URL retrieved by short code")
 
 	return urlRecord, nil
 }
 
+// GetByShortIncludingDeleted retrieves a URL by its short code from the database, including
+// soft-deleted and expired URLs. It bypasses the cache, which only holds live URLs.
+func (s *URLService) GetByShortIncludingDeleted(ctx context.Context, short string) (*models.URL, error) {
+	log.Debug().Str("short", short).Msg("Getting URL by short code including deleted")
+
+	urlRecord, err := s.db.GetByShortIncludingDeleted(ctx, short)
+	if err != nil {
+		if errors.Is(err, ErrURLNotFound) {
+			log.Debug().Str("short", short).Msg("URL not found in database")
+		} else {
+			log.Error().Err(err).Str("short", short).Msg("Database error when getting URL by short code including deleted")
+		}
+		return nil, err
+	}
+
+	log.Info().
+		Str("short", short).
+		Interface("deleted_at", urlRecord.DeletedAt).
+		Msg("URL retrieved by short code including deleted")
+
+	return urlRecord, nil
+}
+
 // GetByOriginal retrieves a URL by its original URL
 func (s *URLService) GetByOriginal(ctx context.Context, original string) (*models.URL, error) {
 	log.Debug().Str("original_url", original).Msg("Getting URL by original URL")
@@ -193,45 +495,92 @@ func (s *URLService) GetByOriginal(ctx context.Context, original string) (*model
 	log.Info().
 		Str("original_url", original).
 		Str("short", urlRecord.Short).
-		Time("expires_at", urlRecord.ExpiresAt).
+		Interface("expires_at", urlRecord.ExpiresAt).
 		Int64("clicks", urlRecord.Clicks).
 		Msg("URL retrieved by original URL")
 
 	return urlRecord, nil
 }
 
-// GetByCreator retrieves URLs by their creator reference
-func (s *URLService) GetByCreator(ctx context.Context, creatorReference string) ([]*models.URL, error) {
-	log.Debug().Str("creator_reference", creatorReference).Msg("Getting URLs by creator reference")
+// CountByCreator counts a creator's live URLs, leaving out deleted and expired ones like GetByCreator
+func (s *URLService) CountByCreator(ctx context.Context, creatorReference string) (int64, error) {
+	log.Debug().Str("creator_reference", creatorReference).Msg("Counting URLs by creator reference")
+
+	count, err := s.db.CountByCreator(ctx, creatorReference)
+	if err != nil {
+		log.Error().Err(err).Str("creator_reference", creatorReference).Msg("Database error when counting URLs by creator reference")
+		return 0, err
+	}
+
+	log.Info().Str("creator_reference", creatorReference).Int64("count", count).Msg("URLs counted by creator reference")
+
+	return count, nil
+}
+
+// GetByCreator retrieves a sorted page of a creator's live URLs and the total number of live URLs
+func (s *URLService) GetByCreator(ctx context.Context, creatorReference string, filter CreatorURLFilter) ([]*models.URL, int64, error) {
+	log.Debug().
+		Str("creator_reference", creatorReference).
+		Str("sort", filter.Sort).
+		Bool("descending", filter.Descending).
+		Int("limit", filter.Limit).
+		Int("offset", filter.Offset).
+		Msg("Getting URLs by creator reference")
+
+	switch filter.Sort {
+	case SortByCreatedAt, SortByClicks:
+	default:
+		log.Error().Str("sort", filter.Sort).Msg("Invalid creator URL sort")
+		return nil, 0, ErrInvalidSort
+	}
 
 	// Get from database
-	log.Debug().Str("creator_reference", creatorReference).Msg("Getting URLs from database")
-	urlRecords, err := s.db.GetByCreator(ctx, creatorReference)
+	urlRecords, total, err := s.db.GetByCreator(ctx, creatorReference, filter)
 	if err != nil {
 		log.Error().Err(err).Str("creator_reference", creatorReference).Msg("Database error when getting URLs by creator reference")
-		return nil, err
+		return nil, 0, err
 	}
 
-	if len(urlRecords) == 0 {
-		log.Debug().Str("creator_reference", creatorReference).Msg("No URLs found for creator reference")
-	} else {
-		log.Info().
-			Str("creator_reference", creatorReference).
-			Int("count", len(urlRecords)).
-			Msg("URLs retrieved by creator reference")
+	log.Info().
+		Str("creator_reference", creatorReference).
+		Int("count", len(urlRecords)).
+		Int64("total", total).
+		Msg("URLs retrieved by creator reference")
+
+	return urlRecords, total, nil
+}
+
+// StreamByCreator calls fn for each of a creator's live URLs, oldest first
+func (s *URLService) StreamByCreator(ctx context.Context, creatorReference string, fn func(*models.URL) error) error {
+	log.Debug().Str("creator_reference", creatorReference).Msg("Streaming URLs by creator reference")
+
+	var count int
+	err := s.db.StreamByCreator(ctx, creatorReference, func(url *models.URL) error {
+		count++
+		return fn(url)
+	})
+	if err != nil {
+		log.Error().Err(err).Str("creator_reference", creatorReference).Int("count", count).Msg("Failed to stream URLs by creator reference")
+		return err
 	}
 
-	return urlRecords, nil
+	log.Info().
+		Str("creator_reference", creatorReference).
+		Int("count", count).
+		Msg("URLs streamed by creator reference")
+
+	return nil
 }
 
-// IncrementClicks increments the click count for a URL
-func (s *URLService) IncrementClicks(ctx context.Context, short string) error {
+// IncrementClicks increments the click count for a URL and returns the new count
+func (s *URLService) IncrementClicks(ctx context.Context, short string) (int64, error) {
 	log.Debug().Str("short", short).Msg("Incrementing click count")
 
 	// Update database
-	if err := s.db.IncrementClicks(ctx, short); err != nil {
+	clicks, err := s.db.IncrementClicks(ctx, short)
+	if err != nil {
 		log.Error().Err(err).Str("short", short).Msg("Failed to increment click count in database")
-		return err
+		return 0, err
 	}
 
 	// Update cache if it exists
@@ -243,13 +592,28 @@ func (s *URLService) IncrementClicks(ctx context.Context, short string) error {
 		}
 	}
 
-	log.Debug().Str("short", short).Msg("Click count incremented successfully")
-	return nil
+	log.Debug().Str("short", short).Int64("clicks", clicks).Msg("Click count incremented successfully")
+	return clicks, nil
+}
+
+// GetTopURLs retrieves up to limit live URLs with the most clicks, most clicked first, across all
+// creators or only creatorReference's when it is not empty
+func (s *URLService) GetTopURLs(ctx context.Context, creatorReference string, limit int) ([]*models.URL, error) {
+	log.Debug().Str("creator_reference", creatorReference).Int("limit", limit).Msg("Getting top URLs")
+
+	urls, err := s.db.GetTopURLs(ctx, creatorReference, limit)
+	if err != nil {
+		log.Error().Err(err).Str("creator_reference", creatorReference).Msg("Database error when getting top URLs")
+		return nil, err
+	}
+
+	log.Info().Str("creator_reference", creatorReference).Int("count", len(urls)).Msg("Top URLs retrieved")
+	return urls, nil
 }
 
-// Delete removes a URL
-func (s *URLService) Delete(ctx context.Context, short string) error {
-	log.Debug().Str("short", short).Msg("Deleting URL")
+// Delete removes a URL regardless of its owner, recording modifiedBy in the URL history
+func (s *URLService) Delete(ctx context.Context, short string, modifiedBy string) error {
+	log.Debug().Str("short", short).Str("modified_by", modifiedBy).Msg("Deleting URL")
 
 	// Get URL before deleting to log history
 	url, err := s.GetByShort(ctx, short)
@@ -259,7 +623,7 @@ func (s *URLService) Delete(ctx context.Context, short string) error {
 	}
 
 	// Log URL deletion history
-	if err := s.db.LogURLHistory(ctx, url.ID, short, "delete", url, nil, ""); err != nil {
+	if err := s.db.LogURLHistory(ctx, url.ID, short, "delete", url, nil, modifiedBy); err != nil {
 		log.Error().Err(err).Str("short", short).Msg("Failed to log URL deletion history")
 		// Continue with deletion even if logging fails
 	}
@@ -294,6 +658,12 @@ func (s *URLService) DeleteWithCreator(ctx context.Context, short string, creato
 		return err
 	}
 
+	// Don't record a deletion that won't happen
+	if url.CreatorReference != creatorReference {
+		log.Error().Str("short", short).Str("creator_reference", creatorReference).Msg("Delete requested by someone other than the owner")
+		return ErrCreatorMismatch
+	}
+
 	// Log URL deletion history
 	if err := s.db.LogURLHistory(ctx, url.ID, short, "delete", url, nil, creatorReference); err != nil {
 		log.Error().Err(err).Str("short", short).Msg("Failed to log URL deletion history")
@@ -319,13 +689,14 @@ func (s *URLService) DeleteWithCreator(ctx context.Context, short string, creato
 	return nil
 }
 
-// UpdateURL updates an existing URL
-func (s *URLService) UpdateURL(ctx context.Context, short string, title, originalURL string, expireAfter time.Duration) (*models.URL, error) {
+// UpdateURL updates an existing URL regardless of its owner, recording modifiedBy in the URL history
+func (s *URLService) UpdateURL(ctx context.Context, short string, title, originalURL string, expireAfter time.Duration, modifiedBy string, opts ...URLOption) (*models.URL, error) {
 	log.Debug().
 		Str("short", short).
 		Str("title", title).
 		Str("original_url", originalURL).
 		Dur("expire_after", expireAfter).
+		Str("modified_by", modifiedBy).
 		Msg("Updating URL")
 
 	// Get existing URL
@@ -337,33 +708,50 @@ func (s *URLService) UpdateURL(ctx context.Context, short string, title, origina
 
 	// Validate URL if changed
 	if originalURL != existingURL.Original {
-		if _, err := url.ParseRequestURI(originalURL); err != nil {
-			log.Error().Err(err).Str("url", originalURL).Msg("Invalid URL format")
-			return nil, ErrInvalidURL
+		if err := s.validateURL(originalURL); err != nil {
+			return nil, err
 		}
 	}
 
 	// Create updated URL
 	updatedURL := &models.URL{
-		ID:               existingURL.ID,
-		Original:         originalURL,
-		Short:            short,
-		Title:            title,
-		CreatedAt:        existingURL.CreatedAt,
-		Clicks:           existingURL.Clicks,
-		CreatorReference: existingURL.CreatorReference,
+		ID:                  existingURL.ID,
+		Original:            originalURL,
+		Short:               short,
+		Title:               title,
+		CreatedAt:           existingURL.CreatedAt,
+		Clicks:              existingURL.Clicks,
+		CreatorReference:    existingURL.CreatorReference,
+		RateLimit:           existingURL.RateLimit,
+		RedirectHeaders:     existingURL.RedirectHeaders,
+		Metadata:            existingURL.Metadata,
+		Enabled:             existingURL.Enabled,
+		Tags:                existingURL.Tags,
+		DisabledRedirectURL: existingURL.DisabledRedirectURL,
+		RandomTarget:        existingURL.RandomTarget,
+		Notes:               existingURL.Notes,
+		MaxClicks:           existingURL.MaxClicks,
+		CacheTTL:            existingURL.CacheTTL,
+		SeededClicks:        existingURL.SeededClicks,
 	}
 
 	// Set expiration time if provided
 	if expireAfter > 0 {
-		updatedURL.ExpiresAt = time.Now().Add(expireAfter)
-		log.Debug().Time("expires_at", updatedURL.ExpiresAt).Msg("Setting URL expiration time")
+		expiresAt := time.Now().Add(expireAfter)
+		updatedURL.ExpiresAt = &expiresAt
+		log.Debug().Time("expires_at", expiresAt).Msg("Setting URL expiration time")
 	} else {
 		updatedURL.ExpiresAt = existingURL.ExpiresAt
 	}
+	for _, opt := range opts {
+		opt(updatedURL)
+	}
+	if err := checkDisabledRedirectURL(updatedURL); err != nil {
+		return nil, err
+	}
 
 	// Log URL update history
-	if err := s.db.LogURLHistory(ctx, existingURL.ID, short, "update", existingURL, updatedURL, ""); err != nil {
+	if err := s.db.LogURLHistory(ctx, existingURL.ID, short, "update", existingURL, updatedURL, modifiedBy); err != nil {
 		log.Error().Err(err).Str("short", short).Msg("Failed to log URL update history")
 		// Continue with update even if logging fails
 	}
@@ -387,14 +775,14 @@ func (s *URLService) UpdateURL(ctx context.Context, short string, title, origina
 		Str("short", short).
 		Str("original_url", updatedURL.Original).
 		Str("title", updatedURL.Title).
-		Time("expires_at", updatedURL.ExpiresAt).
+		Interface("expires_at", updatedURL.ExpiresAt).
 		Msg("URL updated successfully")
 
 	return updatedURL, nil
 }
 
 // UpdateURLWithCreator updates an existing URL if the creator_reference matches
-func (s *URLService) UpdateURLWithCreator(ctx context.Context, short string, title, originalURL string, expireAfter time.Duration, creatorReference string) (*models.URL, error) {
+func (s *URLService) UpdateURLWithCreator(ctx context.Context, short string, title, originalURL string, expireAfter time.Duration, creatorReference string, opts ...URLOption) (*models.URL, error) {
 	log.Debug().
 		Str("short", short).
 		Str("title", title).
@@ -412,30 +800,47 @@ func (s *URLService) UpdateURLWithCreator(ctx context.Context, short string, tit
 
 	// Validate URL if changed
 	if originalURL != existingURL.Original {
-		if _, err := url.ParseRequestURI(originalURL); err != nil {
-			log.Error().Err(err).Str("url", originalURL).Msg("Invalid URL format")
-			return nil, ErrInvalidURL
+		if err := s.validateURL(originalURL); err != nil {
+			return nil, err
 		}
 	}
 
 	// Create updated URL
 	updatedURL := &models.URL{
-		ID:               existingURL.ID,
-		Original:         originalURL,
-		Short:            short,
-		Title:            title,
-		CreatedAt:        existingURL.CreatedAt,
-		Clicks:           existingURL.Clicks,
-		CreatorReference: existingURL.CreatorReference,
+		ID:                  existingURL.ID,
+		Original:            originalURL,
+		Short:               short,
+		Title:               title,
+		CreatedAt:           existingURL.CreatedAt,
+		Clicks:              existingURL.Clicks,
+		CreatorReference:    existingURL.CreatorReference,
+		RateLimit:           existingURL.RateLimit,
+		RedirectHeaders:     existingURL.RedirectHeaders,
+		Metadata:            existingURL.Metadata,
+		Enabled:             existingURL.Enabled,
+		Tags:                existingURL.Tags,
+		DisabledRedirectURL: existingURL.DisabledRedirectURL,
+		RandomTarget:        existingURL.RandomTarget,
+		Notes:               existingURL.Notes,
+		MaxClicks:           existingURL.MaxClicks,
+		CacheTTL:            existingURL.CacheTTL,
+		SeededClicks:        existingURL.SeededClicks,
 	}
 
 	// Set expiration time if provided
 	if expireAfter > 0 {
-		updatedURL.ExpiresAt = time.Now().Add(expireAfter)
-		log.Debug().Time("expires_at", updatedURL.ExpiresAt).Msg("Setting URL expiration time")
+		expiresAt := time.Now().Add(expireAfter)
+		updatedURL.ExpiresAt = &expiresAt
+		log.Debug().Time("expires_at", expiresAt).Msg("Setting URL expiration time")
 	} else {
 		updatedURL.ExpiresAt = existingURL.ExpiresAt
 	}
+	for _, opt := range opts {
+		opt(updatedURL)
+	}
+	if err := checkDisabledRedirectURL(updatedURL); err != nil {
+		return nil, err
+	}
 
 	// Log URL update history
 	if err := s.db.LogURLHistory(ctx, existingURL.ID, short, "update", existingURL, updatedURL, creatorReference); err != nil {
@@ -462,37 +867,35 @@ func (s *URLService) UpdateURLWithCreator(ctx context.Context, short string, tit
 		Str("short", short).
 		Str("original_url", updatedURL.Original).
 		Str("title", updatedURL.Title).
-		Time("expires_at", updatedURL.ExpiresAt).
+		Interface("expires_at", updatedURL.ExpiresAt).
 		Str("creator_reference", creatorReference).
 		Msg("URL updated successfully")
 
 	return updatedURL, nil
 }
 
-// generateShortURL generates a random short URL
-func (s *URLService) generateShortURL(length int) (string, error) {
-	log.Debug().Int("length", length).Msg("Generating random short URL")
+// generateShortURL generates an unused short code with the configured code generator
+func (s *URLService) generateShortURL(ctx context.Context) (string, error) {
+	log.Debug().Msg("Generating short URL")
 
 	for i := 0; i < 5; i++ { // Try up to 5 times
 		log.Debug().Int("attempt", i+1).Msg("Attempting to generate short URL")
 
-		// Generate random bytes
-		b := make([]byte, length)
-		_, err := rand.Read(b)
+		short, err := s.codeGenerator(ctx)
 		if err != nil {
-			log.Error().Err(err).Msg("Failed to generate random bytes")
+			log.Error().Err(err).Msg("Failed to generate short code")
 			return "", err
 		}
 
-		// Encode to base64 and clean up
-		encoded := base64.URLEncoding.EncodeToString(b)
-		// Remove padding characters and take only the first 'length' characters
-		short := strings.ReplaceAll(encoded, "=", "")[:length]
+		if s.containsBannedWord(short) {
+			log.Debug().Str("short", short).Msg("Short code contains a banned word, trying again")
+			continue
+		}
 
 		log.Debug().Str("short", short).Msg("Generated short code, checking if it exists")
 
-		// Check if it already exists
-		_, err = s.GetByShort(context.Background(), short)
+		// Check if any URL holds it, including deleted and expired URLs whose codes are still taken
+		_, err = s.db.GetByShortIncludingDeleted(ctx, short)
 		if errors.Is(err, ErrURLNotFound) {
 			// This short URL is available
 			log.Debug().Str("short", short).Msg("Short code is available")
@@ -505,24 +908,72 @@ func (s *URLService) generateShortURL(length int) (string, error) {
 	}
 
 	log.Error().Msg("Failed to generate unique short URL after 5 attempts")
-	return "", ErrURLExists
+	return "", ErrCodeGenerationFailed
 }
 
-// RecordClick records click analytics data
+// RecordClick records click analytics data. An empty location is resolved from the IP.
 func (s *URLService) RecordClick(ctx context.Context, short string, ip, location, browser, device string) error {
+	click := &ClickContext{Short: short, IP: ip, Location: location, Browser: browser, Device: device}
+	if s.isDoubleSubmit(click) {
+		return ErrRecentClick
+	}
+	s.locateClick(ctx, click)
+	return s.recordClick(ctx, click)
+}
+
+// recordClick records the analytics of an enriched click, including its resolve time if captured
+func (s *URLService) recordClick(ctx context.Context, clickContext *ClickContext) error {
+	short, ip := clickContext.Short, clickContext.IP
 	log.Debug().
 		Str("short", short).
 		Str("ip", ip).
-		Str("location", location).
-		Str("browser", browser).
-		Str("device", device).
+		Str("location", clickContext.Location).
+		Str("browser", clickContext.Browser).
+		Str("device", clickContext.Device).
 		Msg("Recording click analytics")
 
+	click, err := s.clickRecord(ctx, clickContext)
+	if err != nil {
+		return err
+	}
+
+	// Store click data
+	if err := s.db.StoreClick(ctx, click); err != nil {
+		log.Error().Err(err).Str("short", short).Msg("Failed to store click analytics")
+		return err
+	}
+
+	log.Info().
+		Str("short", short).
+		Str("ip", ip).
+		Msg("Click analytics recorded successfully")
+
+	return nil
+}
+
+// clickRecord builds the click record of an enriched click, returning ErrRecentClick if the same visitor
+// clicked recently
+func (s *URLService) clickRecord(ctx context.Context, clickContext *ClickContext) (*models.Click, error) {
+	click, err := s.newClick(ctx, clickContext.Short, clickContext.IP, clickContext.Location, clickContext.Browser, clickContext.Device)
+	if err != nil {
+		return nil, err
+	}
+	click.ResolveMs = clickContext.resolveMs()
+	click.Target = clickContext.Target
+	click.BrowserVersion = clickContext.BrowserVersion
+	click.OS = clickContext.OS
+	click.Referrer = clickContext.Referrer
+	click.Extra = clickContext.Extra
+	return click, nil
+}
+
+// newClick builds the click record for a URL, returning ErrRecentClick if the same visitor clicked recently
+func (s *URLService) newClick(ctx context.Context, short string, ip, location, browser, device string) (*models.Click, error) {
 	// Check if there's a recent click from the same visitor
-	hasRecentClick, err := s.db.HasRecentClick(ctx, short, ip, browser, device)
+	hasRecentClick, err := s.hasRecentClick(ctx, short, ip, browser, device)
 	if err != nil {
 		log.Error().Err(err).Str("short", short).Msg("Failed to check for recent clicks")
-		return err
+		return nil, err
 	}
 
 	if hasRecentClick {
@@ -532,31 +983,17 @@ func (s *URLService) RecordClick(ctx context.Context, short string, ip, location
 			Str("browser", browser).
 			Str("device", device).
 			Msg("Recent click from the same visitor found, skipping recording")
-		return ErrRecentClick
+		return nil, ErrRecentClick
 	}
 
 	// Get URL to get the ID
 	shortURL, err := s.GetByShort(ctx, short)
 	if err != nil {
 		log.Error().Err(err).Str("short", short).Msg("Failed to get URL for recording click")
-		return err
-	}
-
-	// Create click record
-	click := models.NewClick(shortURL.ID, short, ip, location, browser, device)
-
-	// Store click data
-	if err := s.db.StoreClick(ctx, click); err != nil {
-		log.Error().Err(err).Str("short", short).Msg("Failed to store click analytics")
-		return err
+		return nil, err
 	}
 
-	log.Info().
-		Str("short", short).
-		Str("ip", ip).
-		Msg("Click analytics recorded successfully")
-
-	return nil
+	return models.NewClick(shortURL.ID, short, ip, location, browser, device), nil
 }
 
 // GetClicksByShort retrieves click analytics data for a URL
@@ -577,16 +1014,67 @@ func (s *URLService) GetClicksByShort(ctx context.Context, short string) ([]*mod
 	return clicks, nil
 }
 
-// GetClickAnalytics retrieves aggregated click analytics data for a URL
-func (s *URLService) GetClickAnalytics(ctx context.Context, short string) (map[string]interface{}, error) {
-	log.Debug().Str("short", short).Msg("Getting aggregated click analytics data")
+// StreamClicks calls fn for each click of a URL, oldest first
+func (s *URLService) StreamClicks(ctx context.Context, short string, fn func(*models.Click) error) error {
+	log.Debug().Str("short", short).Msg("Streaming click analytics data")
+
+	var count int
+	err := s.db.StreamClicksByShort(ctx, short, func(click *models.Click) error {
+		count++
+		return fn(click)
+	})
+	if err != nil {
+		log.Error().Err(err).Str("short", short).Int("count", count).Msg("Failed to stream click analytics data")
+		return err
+	}
+
+	log.Info().
+		Str("short", short).
+		Int("count", count).
+		Msg("Click analytics data streamed successfully")
+
+	return nil
+}
+
+// GetClickTimeSeries retrieves daily click counts for the last number of days, oldest first.
+// Days without clicks are included with a zero count.
+func (s *URLService) GetClickTimeSeries(ctx context.Context, short string, days int) ([]*models.TimeSeriesPoint, error) {
+	series, _, err := s.GetClickTimeSeriesByInterval(ctx, short, days, IntervalDay, time.UTC)
+	return series, err
+}
+
+// GetClickAnalytics retrieves aggregated click analytics data for a URL's clicks within rng. Without
+// bounds, analytics cover the configured analytics window (all clicks when it is zero). The analytics
+// report the range they cover as "from" and "to".
+func (s *URLService) GetClickAnalytics(ctx context.Context, short string, rng ClickRange) (map[string]interface{}, error) {
+	if rng.From.IsZero() && rng.To.IsZero() && s.analyticsWindow > 0 {
+		rng.From = time.Now().Add(-s.analyticsWindow)
+	}
 
-	analytics, err := s.db.GetClickAnalytics(ctx, short)
+	log.Debug().
+		Str("short", short).
+		Time("from", rng.From).
+		Time("to", rng.To).
+		Msg("Getting aggregated click analytics data")
+
+	analytics, err := s.db.GetClickAnalytics(ctx, short, rng)
 	if err != nil {
 		log.Error().Err(err).Str("short", short).Msg("Failed to get aggregated click analytics data")
 		return nil, err
 	}
 
+	if !rng.From.IsZero() {
+		analytics["from"] = rng.From.UTC()
+	}
+	if !rng.To.IsZero() {
+		analytics["to"] = rng.To.UTC()
+	}
+
+	// Consistency check only; the analytics themselves come from the authoritative raw clicks
+	if _, err := s.ClickCountDrift(ctx, short); err != nil {
+		log.Warn().Err(err).Str("short", short).Msg("Failed to check click counter drift")
+	}
+
 	log.Info().
 		Str("short", short).
 		Interface("analytics", analytics).
@@ -594,3 +1082,57 @@ func (s *URLService) GetClickAnalytics(ctx context.Context, short string) (map[s
 
 	return analytics, nil
 }
+
+// GetCreatorAnalyticsByMetadata counts clicks across a creator's URLs grouped by a metadata key,
+// e.g. total clicks per campaign
+func (s *URLService) GetCreatorAnalyticsByMetadata(ctx context.Context, creatorReference string, key string) ([]*models.MetadataClickCount, error) {
+	log.Debug().
+		Str("creator_reference", creatorReference).
+		Str("key", key).
+		Msg("Getting creator click analytics grouped by metadata")
+
+	counts, err := s.db.ClickCountsByMetadata(ctx, creatorReference, key)
+	if err != nil {
+		log.Error().Err(err).Str("creator_reference", creatorReference).Str("key", key).Msg("Failed to get creator click analytics grouped by metadata")
+		return nil, err
+	}
+
+	log.Info().
+		Str("creator_reference", creatorReference).
+		Str("key", key).
+		Int("groups", len(counts)).
+		Msg("Creator click analytics grouped by metadata retrieved successfully")
+
+	return counts, nil
+}
+
+// GetURLHistory retrieves a filtered page of modification history for a URL
+func (s *URLService) GetURLHistory(ctx context.Context, short string, filter HistoryFilter) ([]*models.URLHistory, int64, error) {
+	log.Debug().
+		Str("short", short).
+		Str("action", filter.Action).
+		Int("limit", filter.Limit).
+		Int("offset", filter.Offset).
+		Msg("Getting URL history")
+
+	switch filter.Action {
+	case "", "create", "update", "delete", "restore", "transfer", "enable", "disable":
+	default:
+		log.Error().Str("action", filter.Action).Msg("Invalid history action filter")
+		return nil, 0, ErrInvalidHistoryAction
+	}
+
+	history, total, err := s.db.GetURLHistory(ctx, short, filter)
+	if err != nil {
+		log.Error().Err(err).Str("short", short).Msg("Failed to get URL history")
+		return nil, 0, err
+	}
+
+	log.Info().
+		Str("short", short).
+		Int("count", len(history)).
+		Int64("total", total).
+		Msg("URL history retrieved successfully")
+
+	return history, total, nil
+}
@@ -2,9 +2,12 @@ package store
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"testing"
 	"time"
 
+	"github.com/fransfilastap/urlshortener/metrics"
 	"github.com/fransfilastap/urlshortener/models"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -23,6 +26,37 @@ func (m *MockURLRepository) Create(ctx context.Context, url *models.URL) (*model
 	return args.Get(0).(*models.URL), args.Error(1)
 }
 
+func (m *MockURLRepository) CreateBatch(ctx context.Context, urls []*models.URL) ([]*models.URL, []error, error) {
+	args := m.Called(ctx, urls)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).([]*models.URL), args.Get(1).([]error), args.Error(2)
+}
+
+func (m *MockURLRepository) BulkCreate(ctx context.Context, urls []*models.URL) ([]error, error) {
+	args := m.Called(ctx, urls)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]error), args.Error(1)
+}
+
+func (m *MockURLRepository) StreamByCreator(ctx context.Context, creatorReference string, fn func(*models.URL) error) error {
+	args := m.Called(ctx, creatorReference, fn)
+	return args.Error(0)
+}
+
+func (m *MockURLRepository) CountActiveURLs(ctx context.Context) (int64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockURLRepository) NextURLID(ctx context.Context) (int64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Error(1)
+}
+
 func (m *MockURLRepository) GetByShort(ctx context.Context, short string) (*models.URL, error) {
 	args := m.Called(ctx, short)
 	if args.Get(0) == nil {
@@ -31,6 +65,22 @@ func (m *MockURLRepository) GetByShort(ctx context.Context, short string) (*mode
 	return args.Get(0).(*models.URL), args.Error(1)
 }
 
+func (m *MockURLRepository) GetByShorts(ctx context.Context, shorts []string) ([]*models.URL, error) {
+	args := m.Called(ctx, shorts)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.URL), args.Error(1)
+}
+
+func (m *MockURLRepository) GetByShortIncludingDeleted(ctx context.Context, short string) (*models.URL, error) {
+	args := m.Called(ctx, short)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.URL), args.Error(1)
+}
+
 func (m *MockURLRepository) GetByOriginal(ctx context.Context, original string) (*models.URL, error) {
 	args := m.Called(ctx, original)
 	if args.Get(0) == nil {
@@ -39,21 +89,133 @@ func (m *MockURLRepository) GetByOriginal(ctx context.Context, original string)
 	return args.Get(0).(*models.URL), args.Error(1)
 }
 
-func (m *MockURLRepository) IncrementClicks(ctx context.Context, short string) error {
+func (m *MockURLRepository) IncrementClicks(ctx context.Context, short string) (int64, error) {
 	args := m.Called(ctx, short)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockURLRepository) StoreCountedClick(ctx context.Context, click *models.Click) (int64, error) {
+	args := m.Called(ctx, click)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockURLRepository) StoreClickBatch(ctx context.Context, clicks []*models.Click) error {
+	args := m.Called(ctx, clicks)
 	return args.Error(0)
 }
 
+func (m *MockURLRepository) SetCreator(ctx context.Context, short string, creatorReference string) error {
+	args := m.Called(ctx, short, creatorReference)
+	return args.Error(0)
+}
+
+func (m *MockURLRepository) SetEnabled(ctx context.Context, short string, enabled bool) error {
+	args := m.Called(ctx, short, enabled)
+	return args.Error(0)
+}
+
+func (m *MockURLRepository) SetEnabledByCreator(ctx context.Context, creatorReference string, tag string, enabled bool) ([]*models.URL, error) {
+	args := m.Called(ctx, creatorReference, tag, enabled)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.URL), args.Error(1)
+}
+
+func (m *MockURLRepository) UpdateTags(ctx context.Context, creatorReference string, codes []string, change TagChange) ([]*models.URL, []error, error) {
+	args := m.Called(ctx, creatorReference, codes, change)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).([]*models.URL), args.Get(1).([]error), args.Error(2)
+}
+
+func (m *MockURLRepository) GetTopURLs(ctx context.Context, creatorReference string, limit int) ([]*models.URL, error) {
+	args := m.Called(ctx, creatorReference, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.URL), args.Error(1)
+}
+
+func (m *MockURLRepository) GetByOriginalForCreator(ctx context.Context, original string, creatorReference string) (*models.URL, error) {
+	args := m.Called(ctx, original, creatorReference)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.URL), args.Error(1)
+}
+
+func (m *MockURLRepository) CreateOrGet(ctx context.Context, url *models.URL) (*models.URL, bool, error) {
+	args := m.Called(ctx, url)
+	if args.Get(0) == nil {
+		return nil, args.Bool(1), args.Error(2)
+	}
+	return args.Get(0).(*models.URL), args.Bool(1), args.Error(2)
+}
+
+func (m *MockURLRepository) ImportClicks(ctx context.Context, short string, clicks []*models.Click) (int64, error) {
+	args := m.Called(ctx, short, clicks)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockURLRepository) TransferCreator(ctx context.Context, from string, to string) ([]*models.URL, error) {
+	args := m.Called(ctx, from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.URL), args.Error(1)
+}
+
+func (m *MockURLRepository) RebuildClickCount(ctx context.Context, short string) (int64, error) {
+	args := m.Called(ctx, short)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockURLRepository) RebuildClickCounts(ctx context.Context, afterID int64, limit int) ([]string, int64, error) {
+	args := m.Called(ctx, afterID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Get(1).(int64), args.Error(2)
+	}
+	return args.Get(0).([]string), args.Get(1).(int64), args.Error(2)
+}
+
 func (m *MockURLRepository) Delete(ctx context.Context, short string) error {
 	args := m.Called(ctx, short)
 	return args.Error(0)
 }
 
+func (m *MockURLRepository) Restore(ctx context.Context, short string) (*models.URL, error) {
+	args := m.Called(ctx, short)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.URL), args.Error(1)
+}
+
+func (m *MockURLRepository) HardDelete(ctx context.Context, short, creatorReference string) error {
+	args := m.Called(ctx, short, creatorReference)
+	return args.Error(0)
+}
+
+func (m *MockURLRepository) PurgeExpired(ctx context.Context, grace time.Duration, limit int) ([]string, error) {
+	args := m.Called(ctx, grace, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
 func (m *MockURLRepository) StoreClick(ctx context.Context, click *models.Click) error {
 	args := m.Called(ctx, click)
 	return args.Error(0)
 }
 
+func (m *MockURLRepository) StreamClicksByShort(ctx context.Context, short string, fn func(*models.Click) error) error {
+	args := m.Called(ctx, short, fn)
+	return args.Error(0)
+}
+
 func (m *MockURLRepository) GetClicksByShort(ctx context.Context, short string) ([]*models.Click, error) {
 	args := m.Called(ctx, short)
 	if args.Get(0) == nil {
@@ -62,16 +224,53 @@ func (m *MockURLRepository) GetClicksByShort(ctx context.Context, short string)
 	return args.Get(0).([]*models.Click), args.Error(1)
 }
 
-func (m *MockURLRepository) GetClickAnalytics(ctx context.Context, short string) (map[string]interface{}, error) {
+func (m *MockURLRepository) ClickCountsByMetadata(ctx context.Context, creatorReference string, key string) ([]*models.MetadataClickCount, error) {
+	args := m.Called(ctx, creatorReference, key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.MetadataClickCount), args.Error(1)
+}
+
+func (m *MockURLRepository) CountClicks(ctx context.Context, short string) (int64, error) {
+	args := m.Called(ctx, short)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockURLRepository) GetResolveTiming(ctx context.Context, short string) (*models.ResolveTiming, error) {
 	args := m.Called(ctx, short)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
+	return args.Get(0).(*models.ResolveTiming), args.Error(1)
+}
+
+func (m *MockURLRepository) GetClickAnalytics(ctx context.Context, short string, rng ClickRange) (map[string]interface{}, error) {
+	args := m.Called(ctx, short, rng)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
 	return args.Get(0).(map[string]interface{}), args.Error(1)
 }
 
-func (m *MockURLRepository) HasRecentClick(ctx context.Context, short string, ip string, browser string, device string) (bool, error) {
-	args := m.Called(ctx, short, ip, browser, device)
+func (m *MockURLRepository) GetClickTimeSeries(ctx context.Context, short string, rng ClickRange, interval string, timezone string) ([]*models.TimeSeriesPoint, error) {
+	args := m.Called(ctx, short, rng, interval, timezone)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.TimeSeriesPoint), args.Error(1)
+}
+
+func (m *MockURLRepository) GetClickDistribution(ctx context.Context, short string, rng ClickRange, timezone string) (*models.ClickDistribution, error) {
+	args := m.Called(ctx, short, rng, timezone)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ClickDistribution), args.Error(1)
+}
+
+func (m *MockURLRepository) HasRecentClick(ctx context.Context, short string, ip string, browser string, device string, window time.Duration) (bool, error) {
+	args := m.Called(ctx, short, ip, browser, device, window)
 	return args.Bool(0), args.Error(1)
 }
 
@@ -85,17 +284,54 @@ func (m *MockURLRepository) LogURLHistory(ctx context.Context, urlID int64, shor
 	return args.Error(0)
 }
 
+func (m *MockURLRepository) GetURLHistory(ctx context.Context, short string, filter HistoryFilter) ([]*models.URLHistory, int64, error) {
+	args := m.Called(ctx, short, filter)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]*models.URLHistory), args.Get(1).(int64), args.Error(2)
+}
+
 func (m *MockURLRepository) DeleteWithCreator(ctx context.Context, short string, creatorReference string) error {
 	args := m.Called(ctx, short, creatorReference)
 	return args.Error(0)
 }
 
-func (m *MockURLRepository) GetByCreator(ctx context.Context, creatorReference string) ([]*models.URL, error) {
+func (m *MockURLRepository) GetByCreator(ctx context.Context, creatorReference string, filter CreatorURLFilter) ([]*models.URL, int64, error) {
+	args := m.Called(ctx, creatorReference, filter)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]*models.URL), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockURLRepository) ListURLs(ctx context.Context, filter URLListFilter, page Page) ([]*models.URL, int64, error) {
+	args := m.Called(ctx, filter, page)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]*models.URL), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockURLRepository) SearchURLs(ctx context.Context, query string, creatorReference string, page Page) ([]*models.URL, int64, error) {
+	args := m.Called(ctx, query, creatorReference, page)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]*models.URL), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockURLRepository) CountByCreator(ctx context.Context, creatorReference string) (int64, error) {
+	args := m.Called(ctx, creatorReference)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockURLRepository) GetRandomURLByCreator(ctx context.Context, creatorReference string) (*models.URL, error) {
 	args := m.Called(ctx, creatorReference)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).([]*models.URL), args.Error(1)
+	return args.Get(0).(*models.URL), args.Error(1)
 }
 
 func (m *MockURLRepository) UpdateURLWithCreator(ctx context.Context, short string, url *models.URL, creatorReference string) error {
@@ -137,16 +373,31 @@ func (m *MockCacheRepository) IncrementClicks(ctx context.Context, short string)
 	return args.Error(0)
 }
 
+func (m *MockCacheRepository) SetNotFound(ctx context.Context, short string) error {
+	args := m.Called(ctx, short)
+	return args.Error(0)
+}
+
 func (m *MockCacheRepository) Delete(ctx context.Context, short string) error {
 	args := m.Called(ctx, short)
 	return args.Error(0)
 }
 
+func (m *MockCacheRepository) IncrementRedirects(ctx context.Context, short string, window time.Duration) (int64, error) {
+	args := m.Called(ctx, short, window)
+	return args.Get(0).(int64), args.Error(1)
+}
+
 func (m *MockCacheRepository) Close() error {
 	args := m.Called()
 	return args.Error(0)
 }
 
+// timePtr returns a pointer to t
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
 func TestCreateShortURL(t *testing.T) {
 	// Setup
 	mockRepo := new(MockURLRepository)
@@ -171,7 +422,7 @@ func TestCreateShortURL(t *testing.T) {
 			Short:            customShort,
 			Title:            "Test Title",
 			CreatedAt:        time.Now(),
-			ExpiresAt:        time.Now().Add(expireAfter),
+			ExpiresAt:        timePtr(time.Now().Add(expireAfter)),
 			Clicks:           0,
 			CreatorReference: "test-user",
 		}
@@ -186,6 +437,7 @@ func TestCreateShortURL(t *testing.T) {
 		mockCache.On("GetByOriginal", ctx, originalURL).Maybe().Return(nil, ErrURLNotFound)
 		mockCache.On("GetByShort", ctx, customShort).Maybe().Return(nil, ErrURLNotFound)
 		mockCache.On("GetByShort", ctx, mock.MatchedBy(func(s string) bool { return s != customShort })).Maybe().Return(nil, ErrURLNotFound)
+		mockCache.On("SetNotFound", ctx, mock.AnythingOfType("string")).Maybe().Return(nil)
 
 		// Call the service
 		url, err := service.CreateShortURL(ctx, originalURL, customShort, "Test Title", expireAfter, "test-user")
@@ -195,7 +447,7 @@ func TestCreateShortURL(t *testing.T) {
 		assert.NotNil(t, url)
 		assert.Equal(t, originalURL, url.Original)
 		assert.Equal(t, customShort, url.Short)
-		assert.WithinDuration(t, time.Now().Add(expireAfter), url.ExpiresAt, time.Second)
+		assert.WithinDuration(t, time.Now().Add(expireAfter), *url.ExpiresAt, time.Second)
 		assert.Equal(t, int64(0), url.Clicks)
 
 		// Verify mocks
@@ -216,14 +468,13 @@ func TestCreateShortURL(t *testing.T) {
 			Original:  originalURL,
 			Short:     "existing",
 			CreatedAt: time.Now(),
-			ExpiresAt: time.Time{},
+			ExpiresAt: nil,
 			Clicks:    5,
 		}
 
 		// Since we can't predict the random short code, we need to handle any string
-		// Mock for GetByShort with any string parameter
-		mockRepo.On("GetByShort", ctx, mock.AnythingOfType("string")).Return(nil, ErrURLNotFound)
-		mockCache.On("GetByShort", ctx, mock.AnythingOfType("string")).Return(nil, ErrURLNotFound)
+		// Mock for GetByShortIncludingDeleted with any string parameter
+		mockRepo.On("GetByShortIncludingDeleted", ctx, mock.AnythingOfType("string")).Return(nil, ErrURLNotFound)
 
 		// Mock for Create method
 		mockRepo.On("Create", ctx, mock.AnythingOfType("*models.URL")).Return(existingURL, nil)
@@ -254,6 +505,7 @@ func TestCreateShortURL(t *testing.T) {
 		// All calls are optional since the validation should fail before any repository calls
 		mockRepo.On("GetByShort", ctx, mock.AnythingOfType("string")).Maybe().Return(nil, ErrURLNotFound)
 		mockCache.On("GetByShort", ctx, mock.AnythingOfType("string")).Maybe().Return(nil, ErrURLNotFound)
+		mockCache.On("SetNotFound", ctx, mock.AnythingOfType("string")).Maybe().Return(nil)
 		mockRepo.On("GetByOriginal", ctx, mock.AnythingOfType("string")).Maybe().Return(nil, ErrURLNotFound)
 		mockCache.On("GetByOriginal", ctx, mock.AnythingOfType("string")).Maybe().Return(nil, ErrURLNotFound)
 
@@ -263,7 +515,7 @@ func TestCreateShortURL(t *testing.T) {
 			Original:  "invalid-url",
 			Short:     "dummy",
 			CreatedAt: time.Now(),
-			ExpiresAt: time.Time{},
+			ExpiresAt: nil,
 			Clicks:    0,
 		}
 		mockRepo.On("Create", ctx, mock.AnythingOfType("*models.URL")).Maybe().Return(dummyURL, nil)
@@ -292,7 +544,7 @@ func TestCreateShortURL(t *testing.T) {
 			Original:  "https://another-example.com",
 			Short:     customShort,
 			CreatedAt: time.Now(),
-			ExpiresAt: time.Time{},
+			ExpiresAt: nil,
 			Clicks:    5,
 		}
 
@@ -305,6 +557,7 @@ func TestCreateShortURL(t *testing.T) {
 		mockCache.On("GetByShort", ctx, customShort).Maybe().Return(nil, ErrURLNotFound)
 		mockRepo.On("GetByShort", ctx, mock.MatchedBy(func(s string) bool { return s != customShort })).Maybe().Return(nil, ErrURLNotFound)
 		mockCache.On("GetByShort", ctx, mock.MatchedBy(func(s string) bool { return s != customShort })).Maybe().Return(nil, ErrURLNotFound)
+		mockCache.On("SetNotFound", ctx, mock.AnythingOfType("string")).Maybe().Return(nil)
 		mockCache.On("Set", ctx, mock.AnythingOfType("*models.URL")).Maybe().Return(nil)
 		mockRepo.On("Create", ctx, mock.AnythingOfType("*models.URL")).Maybe().Return(existingURL, nil)
 
@@ -336,7 +589,7 @@ func TestGetByShort(t *testing.T) {
 			Original:  "https://example.com",
 			Short:     short,
 			CreatedAt: time.Now(),
-			ExpiresAt: time.Time{},
+			ExpiresAt: nil,
 			Clicks:    5,
 		}
 
@@ -368,12 +621,13 @@ func TestGetByShort(t *testing.T) {
 			Original:  "https://example.com",
 			Short:     short,
 			CreatedAt: time.Now(),
-			ExpiresAt: time.Time{},
+			ExpiresAt: nil,
 			Clicks:    5,
 		}
 
 		// Mock behavior
 		mockCache.On("GetByShort", ctx, short).Return(nil, ErrURLNotFound)
+		mockCache.On("SetNotFound", ctx, mock.AnythingOfType("string")).Maybe().Return(nil)
 		mockRepo.On("GetByShort", ctx, short).Return(url, nil)
 		mockCache.On("Set", ctx, mock.AnythingOfType("*models.URL")).Return(nil)
 
@@ -404,6 +658,7 @@ func TestGetByShort(t *testing.T) {
 
 		// Mock behavior
 		mockCache.On("GetByShort", ctx, short).Return(nil, ErrURLNotFound)
+		mockCache.On("SetNotFound", ctx, short).Return(nil)
 		mockRepo.On("GetByShort", ctx, short).Return(nil, ErrURLNotFound)
 
 		// Call the service
@@ -418,6 +673,121 @@ func TestGetByShort(t *testing.T) {
 		mockCache.AssertExpectations(t)
 		mockRepo.AssertExpectations(t)
 	})
+
+	// Test case 4: Expired URL in cache or database
+	t.Run("URLExpired", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		mockCache := new(MockCacheRepository)
+		service := NewURLService(mockRepo, mockCache)
+
+		mockCache.On("GetByShort", ctx, "cachedexp").Return(nil, ErrURLExpired)
+		mockCache.On("GetByShort", ctx, "dbexp").Return(nil, ErrURLNotFound)
+		mockRepo.On("GetByShort", ctx, "dbexp").Return(nil, ErrURLExpired)
+
+		for _, short := range []string{"cachedexp", "dbexp"} {
+			result, err := service.GetByShort(ctx, short)
+			assert.Equal(t, ErrURLExpired, err, short)
+			assert.ErrorIs(t, err, ErrURLNotFound, short)
+			assert.Nil(t, result, short)
+		}
+
+		// Expired codes aren't remembered as missing
+		mockCache.AssertNotCalled(t, "SetNotFound", ctx, "dbexp")
+		mockRepo.AssertNotCalled(t, "GetByShort", ctx, "cachedexp")
+	})
+}
+
+// TestGetByShortLookupMetrics tests counting lookups served from the cache, after a cache miss and
+// after a cache failure
+func TestGetByShortLookupMetrics(t *testing.T) {
+	ctx := context.Background()
+	url := &models.URL{Original: "https://example.com", Short: "metrics1", CreatedAt: time.Now()}
+
+	tests := []struct {
+		name     string
+		cacheErr error
+		result   string
+	}{
+		{"CacheHit", nil, metrics.LookupCacheHit},
+		{"CacheMiss", ErrURLNotFound, metrics.LookupCacheMiss},
+		{"DBFallback", errors.New("connection refused"), metrics.LookupDBFallback},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockURLRepository)
+			mockCache := new(MockCacheRepository)
+			service := NewURLService(mockRepo, mockCache)
+			if tt.cacheErr == nil {
+				mockCache.On("GetByShort", ctx, url.Short).Return(url, nil)
+			} else {
+				mockCache.On("GetByShort", ctx, url.Short).Return(nil, tt.cacheErr)
+				mockRepo.On("GetByShort", ctx, url.Short).Return(url, nil)
+				mockCache.On("Set", ctx, url).Return(nil)
+			}
+
+			before := counterValue(t, "urlshortener_url_lookups_total", tt.result)
+			_, err := service.GetByShort(ctx, url.Short)
+			assert.NoError(t, err)
+			assert.Equal(t, before+1, counterValue(t, "urlshortener_url_lookups_total", tt.result))
+		})
+	}
+}
+
+// counterValue returns the value of the counter of the metrics package named name, matching the first
+// label value when one is given
+func counterValue(t *testing.T, name string, labelValue ...string) float64 {
+	families, err := metrics.Registry.Gather()
+	assert.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			if len(labelValue) == 0 || metric.GetLabel()[0].GetValue() == labelValue[0] {
+				return metric.GetCounter().GetValue()
+			}
+		}
+	}
+	return 0
+}
+
+func TestGetByShortIncludingDeleted(t *testing.T) {
+	ctx := context.Background()
+
+	// Test case 1: Soft-deleted URL is returned from the database without touching the cache
+	t.Run("SoftDeleted", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		mockCache := new(MockCacheRepository)
+		service := NewURLService(mockRepo, mockCache)
+
+		deletedAt := time.Now()
+		url := &models.URL{ID: 1, Original: "https://example.com", Short: "abc123", DeletedAt: &deletedAt}
+		mockRepo.On("GetByShortIncludingDeleted", ctx, "abc123").Return(url, nil)
+		mockCache.On("GetByShort", ctx, "abc123").Return(nil, ErrURLNotFound)
+		mockCache.On("SetNotFound", ctx, mock.AnythingOfType("string")).Maybe().Return(nil)
+		mockRepo.On("GetByShort", ctx, "abc123").Return(nil, ErrURLNotFound)
+
+		result, err := service.GetByShortIncludingDeleted(ctx, "abc123")
+		assert.NoError(t, err)
+		assert.Equal(t, url, result)
+		mockCache.AssertNotCalled(t, "GetByShort", mock.Anything, mock.Anything)
+		mockCache.AssertNotCalled(t, "Set", mock.Anything, mock.Anything)
+
+		// The default lookup is unchanged
+		_, err = service.GetByShort(ctx, "abc123")
+		assert.Equal(t, ErrURLNotFound, err)
+	})
+
+	// Test case 2: Unknown short code
+	t.Run("NotFound", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		service := NewURLService(mockRepo, nil)
+
+		mockRepo.On("GetByShortIncludingDeleted", ctx, "missing").Return(nil, ErrURLNotFound)
+
+		_, err := service.GetByShortIncludingDeleted(ctx, "missing")
+		assert.Equal(t, ErrURLNotFound, err)
+	})
 }
 
 func TestIncrementClicks(t *testing.T) {
@@ -433,14 +803,15 @@ func TestIncrementClicks(t *testing.T) {
 		short := "abc123"
 
 		// Mock behavior
-		mockRepo.On("IncrementClicks", ctx, short).Return(nil)
+		mockRepo.On("IncrementClicks", ctx, short).Return(int64(5), nil)
 		mockCache.On("IncrementClicks", ctx, short).Return(nil)
 
 		// Call the service
-		err := service.IncrementClicks(ctx, short)
+		clicks, err := service.IncrementClicks(ctx, short)
 
 		// Assertions
 		assert.NoError(t, err)
+		assert.Equal(t, int64(5), clicks)
 
 		// Verify mocks
 		mockRepo.AssertExpectations(t)
@@ -454,10 +825,10 @@ func TestIncrementClicks(t *testing.T) {
 		dbErr := assert.AnError
 
 		// Mock behavior
-		mockRepo.On("IncrementClicks", ctx, short).Return(dbErr)
+		mockRepo.On("IncrementClicks", ctx, short).Return(int64(0), dbErr)
 
 		// Call the service
-		err := service.IncrementClicks(ctx, short)
+		_, err := service.IncrementClicks(ctx, short)
 
 		// Assertions
 		assert.Error(t, err)
@@ -468,3 +839,181 @@ func TestIncrementClicks(t *testing.T) {
 		mockCache.AssertNotCalled(t, "IncrementClicks")
 	})
 }
+
+func TestGetClickTimeSeries(t *testing.T) {
+	mockRepo := new(MockURLRepository)
+	service := NewURLService(mockRepo, nil)
+	ctx := context.Background()
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	since := today.AddDate(0, 0, -6)
+	mockRepo.On("GetClickTimeSeries", ctx, "abc123", ClickRange{From: since}, "day", "UTC").Return([]*models.TimeSeriesPoint{
+		{Time: since, Clicks: 2},
+		{Time: today, Clicks: 5},
+	}, nil)
+
+	series, err := service.GetClickTimeSeries(ctx, "abc123", 7)
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.Len(t, series, 7)
+	assert.Equal(t, since, series[0].Time)
+	assert.Equal(t, int64(2), series[0].Clicks)
+	assert.Equal(t, int64(0), series[3].Clicks)
+	assert.Equal(t, today, series[6].Time)
+	assert.Equal(t, int64(5), series[6].Clicks)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRebuildClickCounts(t *testing.T) {
+	ctx := context.Background()
+
+	// Test case: Every URL is rebuilt in chunks and dropped from the cache
+	t.Run("AllCodesInChunks", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		mockCache := new(MockCacheRepository)
+		service := NewURLService(mockRepo, mockCache)
+
+		full := make([]string, rebuildChunkSize)
+		for i := range full {
+			full[i] = fmt.Sprintf("code%d", i)
+		}
+		mockRepo.On("RebuildClickCounts", ctx, int64(0), rebuildChunkSize).Return(full, int64(rebuildChunkSize), nil)
+		mockRepo.On("RebuildClickCounts", ctx, int64(rebuildChunkSize), rebuildChunkSize).Return([]string{"last"}, int64(rebuildChunkSize+1), nil)
+		mockCache.On("Delete", ctx, mock.AnythingOfType("string")).Return(nil)
+
+		rebuilt, err := service.RebuildClickCounts(ctx, "")
+
+		assert.NoError(t, err)
+		assert.Equal(t, rebuildChunkSize+1, rebuilt)
+		mockRepo.AssertExpectations(t)
+		mockCache.AssertNumberOfCalls(t, "Delete", rebuildChunkSize+1)
+	})
+
+	// Test case: A single URL is rebuilt
+	t.Run("SingleCode", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		mockCache := new(MockCacheRepository)
+		service := NewURLService(mockRepo, mockCache)
+
+		mockRepo.On("RebuildClickCount", ctx, "abc123").Return(int64(7), nil)
+		mockCache.On("Delete", ctx, "abc123").Return(nil)
+
+		rebuilt, err := service.RebuildClickCounts(ctx, "abc123")
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, rebuilt)
+		mockRepo.AssertNotCalled(t, "RebuildClickCounts", mock.Anything, mock.Anything, mock.Anything)
+		mockCache.AssertExpectations(t)
+	})
+}
+
+func TestPurgeExpired(t *testing.T) {
+	ctx := context.Background()
+
+	// Test case: Expired URLs are purged in chunks and dropped from the cache
+	t.Run("InChunks", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		mockCache := new(MockCacheRepository)
+		service := NewURLService(mockRepo, mockCache)
+
+		full := make([]string, purgeChunkSize)
+		for i := range full {
+			full[i] = fmt.Sprintf("expired%d", i)
+		}
+		mockRepo.On("PurgeExpired", ctx, time.Hour, purgeChunkSize).Return(full, nil).Once()
+		mockRepo.On("PurgeExpired", ctx, time.Hour, purgeChunkSize).Return([]string{"last"}, nil).Once()
+		mockCache.On("Delete", ctx, mock.AnythingOfType("string")).Return(nil)
+
+		purged, err := service.PurgeExpired(ctx, time.Hour)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(purgeChunkSize+1), purged)
+		mockRepo.AssertExpectations(t)
+		mockCache.AssertNumberOfCalls(t, "Delete", purgeChunkSize+1)
+	})
+
+	// Test case: A failing chunk reports what was purged before it
+	t.Run("Error", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		service := NewURLService(mockRepo, nil)
+
+		mockRepo.On("PurgeExpired", ctx, time.Duration(0), purgeChunkSize).Return(nil, assert.AnError)
+
+		purged, err := service.PurgeExpired(ctx, 0)
+
+		assert.Equal(t, assert.AnError, err)
+		assert.Equal(t, int64(0), purged)
+	})
+}
+
+func TestClickCountDrift(t *testing.T) {
+	ctx := context.Background()
+
+	// Test case: An artificially inflated counter is detected
+	t.Run("Drift", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		service := NewURLService(mockRepo, nil)
+
+		url := models.NewURL("https://example.com", "abc123", "", time.Time{}, "")
+		url.Clicks = 12
+		mockRepo.On("GetByShort", ctx, "abc123").Return(url, nil)
+		mockRepo.On("CountClicks", ctx, "abc123").Return(int64(10), nil)
+
+		drift, err := service.ClickCountDrift(ctx, "abc123")
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), drift)
+	})
+
+	// Test case: A counter matching the raw clicks has no drift
+	t.Run("Consistent", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		service := NewURLService(mockRepo, nil)
+
+		url := models.NewURL("https://example.com", "abc123", "", time.Time{}, "")
+		url.Clicks = 10
+		mockRepo.On("GetByShort", ctx, "abc123").Return(url, nil)
+		mockRepo.On("CountClicks", ctx, "abc123").Return(int64(10), nil)
+
+		drift, err := service.ClickCountDrift(ctx, "abc123")
+
+		assert.NoError(t, err)
+		assert.Zero(t, drift)
+	})
+
+	// Test case: Clicks seeded on import are not drift
+	t.Run("Seeded", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		service := NewURLService(mockRepo, nil)
+
+		url := models.NewURL("https://example.com", "abc123", "", time.Time{}, "")
+		WithInitialClicks(100)(url)
+		url.Clicks += 10
+		mockRepo.On("GetByShort", ctx, "abc123").Return(url, nil)
+		mockRepo.On("CountClicks", ctx, "abc123").Return(int64(10), nil)
+
+		drift, err := service.ClickCountDrift(ctx, "abc123")
+
+		assert.NoError(t, err)
+		assert.Zero(t, drift)
+	})
+
+	// Test case: Analytics still succeed and report the raw count when the counter drifted
+	t.Run("AnalyticsUseRawCount", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		service := NewURLService(mockRepo, nil)
+
+		url := models.NewURL("https://example.com", "abc123", "", time.Time{}, "")
+		url.Clicks = 3
+		mockRepo.On("GetClickAnalytics", ctx, "abc123", ClickRange{}).Return(map[string]interface{}{"total_clicks": int64(5)}, nil)
+		mockRepo.On("GetByShort", ctx, "abc123").Return(url, nil)
+		mockRepo.On("CountClicks", ctx, "abc123").Return(int64(5), nil)
+
+		analytics, err := service.GetClickAnalytics(ctx, "abc123", ClickRange{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(5), analytics["total_clicks"])
+		mockRepo.AssertExpectations(t)
+	})
+}
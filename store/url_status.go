@@ -0,0 +1,99 @@
+package store
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/rs/zerolog/log"
+)
+
+// SetURLEnabled enables or disables a URL owned by creatorReference. Disabled URLs stop redirecting
+// but keep their analytics. The change is recorded in the URL history.
+func (s *URLService) SetURLEnabled(ctx context.Context, short string, creatorReference string, enabled bool) (*models.URL, error) {
+	log.Debug().
+		Str("short", short).
+		Str("creator_reference", creatorReference).
+		Bool("enabled", enabled).
+		Msg("Setting URL enabled state")
+
+	existingURL, err := s.GetByShort(ctx, short)
+	if err != nil {
+		log.Error().Err(err).Str("short", short).Msg("Failed to get URL to enable or disable")
+		return nil, err
+	}
+
+	if existingURL.CreatorReference != creatorReference {
+		log.Error().Str("short", short).Str("creator_reference", creatorReference).Msg("Enable or disable requested by someone other than the owner")
+		return nil, ErrCreatorMismatch
+	}
+
+	if err := s.db.SetEnabled(ctx, short, enabled); err != nil {
+		log.Error().Err(err).Str("short", short).Msg("Failed to set URL enabled state in database")
+		return nil, err
+	}
+
+	updated := *existingURL
+	updated.Enabled = enabled
+	action := "disable"
+	if enabled {
+		action = "enable"
+	}
+	if err := s.db.LogURLHistory(ctx, existingURL.ID, short, action, existingURL, &updated, creatorReference); err != nil {
+		log.Error().Err(err).Str("short", short).Msg("Failed to log URL enabled state history")
+	}
+	s.invalidateCache(ctx, short)
+
+	log.Info().Str("short", short).Bool("enabled", enabled).Msg("URL enabled state set successfully")
+	return &updated, nil
+}
+
+// SetCreatorURLsEnabled enables or disables all live URLs of a creator at once, or only those carrying
+// tag when it is not empty, and returns the number of URLs whose state changed
+func (s *URLService) SetCreatorURLsEnabled(ctx context.Context, creatorReference string, tag string, enabled bool) (int, error) {
+	log.Debug().
+		Str("creator_reference", creatorReference).
+		Str("tag", tag).
+		Bool("enabled", enabled).
+		Msg("Setting enabled state of creator URLs")
+
+	urls, err := s.db.SetEnabledByCreator(ctx, creatorReference, tag, enabled)
+	if err != nil {
+		log.Error().Err(err).Str("creator_reference", creatorReference).Str("tag", tag).Msg("Failed to set enabled state of creator URLs in database")
+		return 0, err
+	}
+
+	action := "disable"
+	if enabled {
+		action = "enable"
+	}
+	for _, url := range urls {
+		previous := *url
+		previous.Enabled = !enabled
+		if err := s.db.LogURLHistory(ctx, url.ID, url.Short, action, &previous, url, creatorReference); err != nil {
+			log.Error().Err(err).Str("short", url.Short).Msg("Failed to log URL enabled state history")
+		}
+		s.invalidateCache(ctx, url.Short)
+	}
+
+	log.Info().
+		Str("creator_reference", creatorReference).
+		Str("tag", tag).
+		Bool("enabled", enabled).
+		Int("count", len(urls)).
+		Msg("Enabled state of creator URLs set successfully")
+	return len(urls), nil
+}
+
+// checkDisabledRedirectURL makes sure a URL's disabled redirect target, if any, is an absolute http(s) URL
+func checkDisabledRedirectURL(u *models.URL) error {
+	if u.DisabledRedirectURL == "" {
+		return nil
+	}
+	target, err := url.ParseRequestURI(u.DisabledRedirectURL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		log.Error().Err(err).Str("disabled_redirect_url", u.DisabledRedirectURL).Msg("Invalid disabled redirect URL")
+		return ErrInvalidURL
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package store
+
+import (
+	"context"
+	"errors"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/rs/zerolog/log"
+)
+
+// Cache states of a URL reported in its summary
+const (
+	// CacheStatusCached means the cache holds the URL
+	CacheStatusCached = "cached"
+	// CacheStatusNotCached means the cache doesn't hold the URL; the next lookup reads the database
+	CacheStatusNotCached = "not_cached"
+	// CacheStatusCachedNotFound means the cache remembers that no URL has the code
+	CacheStatusCachedNotFound = "cached_not_found"
+	// CacheStatusDisabled means no cache is configured
+	CacheStatusDisabled = "disabled"
+	// CacheStatusUnavailable means the cache couldn't be reached
+	CacheStatusUnavailable = "unavailable"
+)
+
+// SummaryHistoryLimit is the number of most recent history entries in a URL summary
+const SummaryHistoryLimit = 10
+
+// URLSummary is everything known about a short link, for support requests
+type URLSummary struct {
+	// URL is the stored record, which may be deleted or expired
+	URL *models.URL
+	// Analytics are the aggregated click analytics of the URL
+	Analytics map[string]interface{}
+	// History holds up to SummaryHistoryLimit of the most recent history entries, newest first
+	History []*models.URLHistory
+	// HistoryTotal is the number of history entries of the URL
+	HistoryTotal int64
+	// CacheStatus is one of the CacheStatus constants
+	CacheStatus string
+}
+
+// GetURLSummary gathers a URL's record, analytics, recent history and cache state. Deleted and expired
+// URLs are included. Unless creatorReference is empty, the URL must belong to that creator.
+func (s *URLService) GetURLSummary(ctx context.Context, short string, creatorReference string) (*URLSummary, error) {
+	log.Debug().Str("short", short).Str("creator_reference", creatorReference).Msg("Getting URL summary")
+
+	url, err := s.GetByShortIncludingDeleted(ctx, short)
+	if err != nil {
+		return nil, err
+	}
+	if creatorReference != "" && url.CreatorReference != creatorReference {
+		log.Error().Str("short", short).Str("creator_reference", creatorReference).Msg("Creator reference does not match for URL summary")
+		return nil, ErrCreatorMismatch
+	}
+
+	analytics, err := s.GetClickAnalytics(ctx, short, ClickRange{})
+	if err != nil {
+		return nil, err
+	}
+	history, total, err := s.GetURLHistory(ctx, short, HistoryFilter{Limit: SummaryHistoryLimit})
+	if err != nil {
+		return nil, err
+	}
+	if history == nil {
+		history = []*models.URLHistory{}
+	}
+
+	summary := &URLSummary{
+		URL:          url,
+		Analytics:    analytics,
+		History:      history,
+		HistoryTotal: total,
+		CacheStatus:  s.cacheStatus(ctx, short),
+	}
+
+	log.Info().
+		Str("short", short).
+		Int64("history_total", total).
+		Str("cache_status", summary.CacheStatus).
+		Msg("URL summary retrieved")
+
+	return summary, nil
+}
+
+// cacheStatus probes the cache for a short code without falling back to the database
+func (s *URLService) cacheStatus(ctx context.Context, short string) string {
+	if s.cache == nil {
+		return CacheStatusDisabled
+	}
+	_, err := s.cache.GetByShort(ctx, short)
+	switch {
+	case err == nil, errors.Is(err, ErrURLExpired):
+		return CacheStatusCached
+	case errors.Is(err, ErrCachedNotFound):
+		return CacheStatusCachedNotFound
+	case errors.Is(err, ErrURLNotFound):
+		return CacheStatusNotCached
+	default:
+		log.Warn().Err(err).Str("short", short).Msg("Failed to probe cache for URL")
+		return CacheStatusUnavailable
+	}
+}
@@ -0,0 +1,81 @@
+package store
+
+import (
+	"context"
+	"slices"
+
+	"github.com/rs/zerolog/log"
+)
+
+// TagChange lists tags to add to and remove from URLs
+type TagChange struct {
+	Add    []string
+	Remove []string
+}
+
+// Apply returns tags with the change applied. Tags in both lists end up removed.
+func (c TagChange) Apply(tags []string) []string {
+	var applied []string
+	for _, tag := range normalizeTags(append(slices.Clone(tags), c.Add...)) {
+		if !slices.Contains(c.Remove, tag) {
+			applied = append(applied, tag)
+		}
+	}
+	return applied
+}
+
+// UpdateURLTags adds and removes tags on several URLs of a creator in one transaction. Results are in
+// code order; codes that don't exist or belong to someone else fail on their own. The returned error
+// is only set if the request as a whole failed.
+func (s *URLService) UpdateURLTags(ctx context.Context, creatorReference string, codes []string, change TagChange) ([]BatchResult, error) {
+	log.Debug().
+		Str("creator_reference", creatorReference).
+		Int("codes", len(codes)).
+		Strs("add", change.Add).
+		Strs("remove", change.Remove).
+		Msg("Updating URL tags")
+
+	if len(codes) > MaxBatchSize {
+		log.Error().Int("codes", len(codes)).Int("max", MaxBatchSize).Msg("Too many URLs to update tags of")
+		return nil, ErrBatchTooLarge
+	}
+
+	change = TagChange{Add: normalizeTags(change.Add), Remove: normalizeTags(change.Remove)}
+	if len(change.Add) == 0 && len(change.Remove) == 0 {
+		log.Error().Str("creator_reference", creatorReference).Msg("No tags to add or remove")
+		return nil, ErrNoTagChange
+	}
+
+	previous, errs, err := s.db.UpdateTags(ctx, creatorReference, codes, change)
+	if err != nil {
+		log.Error().Err(err).Str("creator_reference", creatorReference).Msg("Failed to update URL tags in database")
+		return nil, err
+	}
+
+	results := make([]BatchResult, len(codes))
+	var changed int
+	for i := range codes {
+		if errs[i] != nil {
+			results[i].Err = errs[i]
+			continue
+		}
+		updated := *previous[i]
+		updated.Tags = change.Apply(previous[i].Tags)
+		results[i].URL = &updated
+		if slices.Equal(updated.Tags, previous[i].Tags) {
+			continue
+		}
+		if err := s.db.LogURLHistory(ctx, updated.ID, updated.Short, "update", previous[i], &updated, creatorReference); err != nil {
+			log.Error().Err(err).Str("short", updated.Short).Msg("Failed to log URL tags history")
+		}
+		s.invalidateCache(ctx, updated.Short)
+		changed++
+	}
+
+	log.Info().
+		Str("creator_reference", creatorReference).
+		Int("codes", len(codes)).
+		Int("changed", changed).
+		Msg("URL tags updated successfully")
+	return results, nil
+}
@@ -0,0 +1,78 @@
+package store
+
+import (
+	"context"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/rs/zerolog/log"
+)
+
+// TransferURL assigns a URL to a new creator. Unless asAdmin is set, currentCreator must be the
+// URL's current owner. The transfer is recorded in the URL history.
+func (s *URLService) TransferURL(ctx context.Context, short string, currentCreator string, newCreator string, asAdmin bool) (*models.URL, error) {
+	log.Debug().
+		Str("short", short).
+		Str("current_creator_reference", currentCreator).
+		Str("new_creator_reference", newCreator).
+		Bool("admin", asAdmin).
+		Msg("Transferring URL")
+
+	existingURL, err := s.GetByShort(ctx, short)
+	if err != nil {
+		log.Error().Err(err).Str("short", short).Msg("Failed to get URL for transfer")
+		return nil, err
+	}
+
+	if !asAdmin && existingURL.CreatorReference != currentCreator {
+		log.Error().Str("short", short).Str("current_creator_reference", currentCreator).Msg("Transfer requested by someone other than the owner")
+		return nil, ErrCreatorMismatch
+	}
+
+	if err := s.db.SetCreator(ctx, short, newCreator); err != nil {
+		log.Error().Err(err).Str("short", short).Msg("Failed to transfer URL in database")
+		return nil, err
+	}
+
+	transferred := *existingURL
+	transferred.CreatorReference = newCreator
+	s.logTransfer(ctx, existingURL, &transferred, currentCreator)
+	s.invalidateCache(ctx, short)
+
+	log.Info().
+		Str("short", short).
+		Str("from", existingURL.CreatorReference).
+		Str("to", newCreator).
+		Msg("URL transferred successfully")
+
+	return &transferred, nil
+}
+
+// TransferCreatorURLs assigns every live URL of a creator to a new creator, recording each
+// transfer in the URL history, and returns the number of URLs transferred
+func (s *URLService) TransferCreatorURLs(ctx context.Context, from string, to string) (int, error) {
+	log.Debug().Str("from", from).Str("to", to).Msg("Transferring all URLs of creator")
+
+	urls, err := s.db.TransferCreator(ctx, from, to)
+	if err != nil {
+		log.Error().Err(err).Str("from", from).Str("to", to).Msg("Failed to transfer URLs in database")
+		return 0, err
+	}
+
+	for _, url := range urls {
+		previous := *url
+		previous.CreatorReference = from
+		s.logTransfer(ctx, &previous, url, "")
+		s.invalidateCache(ctx, url.Short)
+	}
+
+	log.Info().Str("from", from).Str("to", to).Int("count", len(urls)).Msg("URLs transferred successfully")
+	return len(urls), nil
+}
+
+// logTransfer records an ownership transfer in the URL history. Failures are logged, not returned,
+// as the transfer itself already succeeded.
+func (s *URLService) logTransfer(ctx context.Context, before, after *models.URL, modifiedBy string) {
+	if err := s.db.LogURLHistory(ctx, before.ID, before.Short, "transfer", before, after, modifiedBy); err != nil {
+		log.Error().Err(err).Str("short", before.Short).Msg("Failed to log URL transfer history")
+	}
+}
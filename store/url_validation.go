@@ -0,0 +1,65 @@
+package store
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/rs/zerolog/log"
+)
+
+// URLValidation decides how strictly original URLs are validated
+type URLValidation string
+
+const (
+	// URLValidationStrict requires an absolute URL with an allowed scheme and a host. Opaque URLs such
+	// as mailto:user@example.com need no host, unless they use http or https.
+	URLValidationStrict URLValidation = "strict"
+	// URLValidationLenient only requires a parseable absolute URL with an allowed scheme, so URLs such
+	// as http:///path without a host are accepted
+	URLValidationLenient URLValidation = "lenient"
+)
+
+// ParseURLValidation validates a URL validation level name
+func ParseURLValidation(name string) (URLValidation, error) {
+	switch level := URLValidation(name); level {
+	case URLValidationStrict, URLValidationLenient:
+		return level, nil
+	default:
+		return "", fmt.Errorf("unknown URL validation level: %q", name)
+	}
+}
+
+// WithURLValidation sets how strictly original URLs are validated
+func WithURLValidation(level URLValidation) Option {
+	return func(s *URLService) {
+		s.urlValidation = level
+	}
+}
+
+// validateURL checks an original URL before it is shortened or a link is pointed at it: it must parse,
+// use an allowed scheme, have a host under strict validation and not point at this shortener or a
+// blocked destination or shortener
+func (s *URLService) validateURL(originalURL string) error {
+	parsed, err := url.ParseRequestURI(originalURL)
+	if err != nil {
+		log.Error().Err(err).Str("url", originalURL).Msg("Invalid URL format")
+		return ErrInvalidURL
+	}
+	if err := s.checkScheme(originalURL); err != nil {
+		return err
+	}
+	if s.urlValidation == URLValidationStrict && parsed.Hostname() == "" &&
+		(parsed.Opaque == "" || parsed.Scheme == "http" || parsed.Scheme == "https") {
+		log.Error().Str("url", originalURL).Msg("Rejected URL without a host")
+		return ErrInvalidURL
+	}
+	if s.urlValidator != nil {
+		if err := s.urlValidator.Validate(originalURL); err != nil {
+			return err
+		}
+	}
+	if err := s.checkSelfReference(parsed); err != nil {
+		return err
+	}
+	return s.checkBlockedShortener(originalURL)
+}
@@ -0,0 +1,72 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestValidateURL(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Accepts valid URLs", func(t *testing.T) {
+		service := NewURLService(new(MockURLRepository), nil, WithAllowedSchemes("http", "https", "mailto"))
+		for _, original := range []string{"https://example.com", "http://example.com:8080/path?q=1", "mailto:someone@example.com"} {
+			assert.NoError(t, service.validateURL(original), original)
+		}
+	})
+
+	t.Run("Rejects hostless URLs", func(t *testing.T) {
+		service := NewURLService(new(MockURLRepository), nil)
+		for _, original := range []string{"http:///path", "https://", "https:example.com", "http://:8080/path"} {
+			assert.ErrorIs(t, service.validateURL(original), ErrInvalidURL, original)
+		}
+	})
+
+	t.Run("Rejects schemeless URLs", func(t *testing.T) {
+		for _, level := range []URLValidation{URLValidationStrict, URLValidationLenient} {
+			service := NewURLService(new(MockURLRepository), nil, WithURLValidation(level))
+			for _, original := range []string{"example.com", "example.com/path", "//example.com/path", "/path"} {
+				assert.Error(t, service.validateURL(original), original)
+			}
+		}
+	})
+
+	t.Run("Lenient validation accepts hostless URLs", func(t *testing.T) {
+		service := NewURLService(new(MockURLRepository), nil, WithURLValidation(URLValidationLenient))
+		assert.NoError(t, service.validateURL("http:///path"))
+		assert.ErrorIs(t, service.validateURL("ftp://example.com"), ErrDisallowedScheme)
+	})
+
+	t.Run("Applies to create and update", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		service := NewURLService(mockRepo, nil)
+
+		_, err := service.CreateShortURL(ctx, "http:///path", "custom", "", 0, "creator")
+		assert.ErrorIs(t, err, ErrInvalidURL)
+
+		existing := &models.URL{ID: 1, Original: "https://example.com", Short: "abc123", CreatorReference: "creator", Enabled: true}
+		mockRepo.On("GetByShort", ctx, "abc123").Return(existing, nil)
+		_, err = service.UpdateURLWithCreator(ctx, "abc123", "", "http:///path", 0, "creator")
+		assert.ErrorIs(t, err, ErrInvalidURL)
+
+		results, err := service.ImportURLs(ctx, []ImportItem{{OriginalURL: "http:///path"}}, "")
+		assert.NoError(t, err)
+		assert.ErrorIs(t, results[0].Err, ErrInvalidURL)
+
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		mockRepo.AssertNotCalled(t, "UpdateURLWithCreator", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		mockRepo.AssertNotCalled(t, "BulkCreate", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Parses levels", func(t *testing.T) {
+		level, err := ParseURLValidation("lenient")
+		assert.NoError(t, err)
+		assert.Equal(t, URLValidationLenient, level)
+		_, err = ParseURLValidation("loose")
+		assert.Error(t, err)
+	})
+}
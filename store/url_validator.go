@@ -0,0 +1,89 @@
+package store
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// URLValidator rejects original URLs whose host is blocklisted, e.g. to keep known phishing
+// destinations out. Entries are domains, which also block their subdomains, or host patterns with
+// * wildcards such as "paypal-*.com".
+type URLValidator struct {
+	domains  map[string]bool
+	patterns []string
+}
+
+// NewURLValidator creates a URLValidator for the blocked domains and host patterns
+func NewURLValidator(blocked ...string) (*URLValidator, error) {
+	v := &URLValidator{domains: make(map[string]bool)}
+	for _, entry := range blocked {
+		entry = normalizeHost(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "*") {
+			v.domains[entry] = true
+			continue
+		}
+		if _, err := path.Match(entry, ""); err != nil {
+			return nil, fmt.Errorf("invalid blocked host pattern %q: %w", entry, err)
+		}
+		v.patterns = append(v.patterns, entry)
+	}
+	return v, nil
+}
+
+// WithURLValidator rejects original URLs that the validator blocks
+func WithURLValidator(v *URLValidator) Option {
+	return func(s *URLService) {
+		s.urlValidator = v
+	}
+}
+
+// Validate returns ErrBlockedDestination if originalURL's host is blocklisted
+func (v *URLValidator) Validate(originalURL string) error {
+	parsed, err := url.Parse(originalURL)
+	if err != nil {
+		return ErrInvalidURL
+	}
+
+	host := normalizeHost(parsed.Hostname())
+	if host == "" {
+		return nil
+	}
+	if domain, ok := matchDomain(v.domains, host); ok {
+		log.Warn().Str("url", originalURL).Str("domain", domain).Msg("Rejected URL with a blocked destination")
+		return ErrBlockedDestination
+	}
+	for _, pattern := range v.patterns {
+		if matched, _ := path.Match(pattern, host); matched {
+			log.Warn().Str("url", originalURL).Str("pattern", pattern).Msg("Rejected URL with a blocked destination")
+			return ErrBlockedDestination
+		}
+	}
+	return nil
+}
+
+// normalizeHost lowercases a host and strips surrounding whitespace and a trailing dot
+func normalizeHost(host string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+}
+
+// matchDomain reports which of domains host is, or is a subdomain of
+func matchDomain(domains map[string]bool, host string) (string, bool) {
+	for host != "" {
+		if domains[host] {
+			return host, true
+		}
+		_, parent, found := strings.Cut(host, ".")
+		if !found {
+			break
+		}
+		host = parent
+	}
+	return "", false
+}
@@ -0,0 +1,97 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fransfilastap/urlshortener/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestURLValidator(t *testing.T) {
+	validator, err := NewURLValidator("evil.example", " PayPal-*.com ", "")
+	require.NoError(t, err)
+
+	tests := []struct {
+		url     string
+		blocked bool
+	}{
+		{"https://evil.example/login", true},
+		{"https://login.EVIL.example./x", true},
+		{"http://evil.example:8080", true},
+		{"https://paypal-secure.com/verify", true},
+		{"https://notevil.example", false},
+		{"https://evil.example.org", false},
+		{"https://paypal.com", false},
+		{"https://www.paypal-secure.com", false},
+		{"mailto:someone@evil.example", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			err := validator.Validate(tt.url)
+			if tt.blocked {
+				assert.ErrorIs(t, err, ErrBlockedDestination)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	t.Run("Rejects malformed patterns", func(t *testing.T) {
+		_, err := NewURLValidator("evil-[*.com")
+		assert.Error(t, err)
+	})
+}
+
+func TestURLServiceBlockedDestinations(t *testing.T) {
+	ctx := context.Background()
+	validator, err := NewURLValidator("evil.example")
+	require.NoError(t, err)
+
+	t.Run("Create rejects blocked destinations", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		service := NewURLService(mockRepo, nil, WithURLValidator(validator))
+
+		_, err := service.CreateShortURL(ctx, "https://www.evil.example/login", "custom", "", 0, "creator")
+		assert.ErrorIs(t, err, ErrBlockedDestination)
+		assert.Equal(t, 403, err.(*APIError).Status)
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Create rejects disallowed schemes", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		service := NewURLService(mockRepo, nil, WithURLValidator(validator))
+
+		for _, original := range []string{"ftp://files.example/a", "javascript:alert(1)"} {
+			_, err := service.CreateShortURL(ctx, original, "custom", "", 0, "creator")
+			assert.ErrorIs(t, err, ErrDisallowedScheme, original)
+		}
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Update rejects blocked destinations", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		service := NewURLService(mockRepo, nil, WithURLValidator(validator))
+
+		mockRepo.On("GetByShort", ctx, "abc").Return(&models.URL{ID: 1, Original: "https://good.example", Short: "abc"}, nil)
+
+		_, err := service.UpdateURL(ctx, "abc", "", "https://evil.example", 0, "creator")
+		assert.ErrorIs(t, err, ErrBlockedDestination)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Allows other destinations", func(t *testing.T) {
+		mockRepo := new(MockURLRepository)
+		service := NewURLService(mockRepo, nil, WithURLValidator(validator))
+
+		original := "https://good.example"
+		mockRepo.On("GetByShort", ctx, "custom").Return(nil, ErrURLNotFound)
+		mockRepo.On("Create", ctx, mock.AnythingOfType("*models.URL")).Return(&models.URL{ID: 1, Original: original, Short: "custom"}, nil)
+
+		created, err := service.CreateShortURL(ctx, original, "custom", "", 0, "creator")
+		require.NoError(t, err)
+		assert.Equal(t, original, created.Original)
+	})
+}
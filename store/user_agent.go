@@ -0,0 +1,141 @@
+package store
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Device types reported by ParseUserAgent
+const (
+	DeviceDesktop = "Desktop"
+	DeviceMobile  = "Mobile"
+	DeviceTablet  = "Tablet"
+	DeviceBot     = "Bot"
+)
+
+// UserAgent is the client described by a User-Agent header
+type UserAgent struct {
+	Browser        string
+	BrowserVersion string
+	OS             string
+	Device         string
+}
+
+// uaBrowser matches a browser by the product token carrying its version
+type uaBrowser struct {
+	name  string
+	token *regexp.Regexp
+}
+
+// uaBots are matched before browsers, as crawlers often mimic browser user agents
+var uaBots = []uaBrowser{
+	{"Googlebot", regexp.MustCompile(`Googlebot(?:-\w+)?/([\d.]+)`)},
+	{"Bingbot", regexp.MustCompile(`bingbot/([\d.]+)`)},
+	{"DuckDuckBot", regexp.MustCompile(`DuckDuckBot(?:-\w+)?/([\d.]+)`)},
+	{"YandexBot", regexp.MustCompile(`YandexBot/([\d.]+)`)},
+	{"Baiduspider", regexp.MustCompile(`Baiduspider(?:-\w+)?/([\d.]+)`)},
+	{"Facebook", regexp.MustCompile(`facebookexternalhit/([\d.]+)`)},
+	{"Twitterbot", regexp.MustCompile(`Twitterbot/([\d.]+)`)},
+	{"Slackbot", regexp.MustCompile(`Slackbot(?:-\w+)*(?: ([\d.]+))?`)},
+	{"WhatsApp", regexp.MustCompile(`^WhatsApp/([\d.]+)`)},
+	{"curl", regexp.MustCompile(`^curl/([\d.]+)`)},
+	{"Wget", regexp.MustCompile(`^Wget/([\d.]+)`)},
+	{"Python", regexp.MustCompile(`^python-\w+/([\d.]+)`)},
+	{"Go", regexp.MustCompile(`^Go-http-client/([\d.]+)`)},
+}
+
+// uaGenericBot catches crawlers without an entry in uaBots
+var uaGenericBot = regexp.MustCompile(`(?i)bot\b|crawler|spider|preview`)
+
+// uaPreviewBots are the bots, by name, that fetch links to render previews of them, such as social
+// networks and chat apps. Unnamed crawlers are included; search engines and HTTP clients are not.
+var uaPreviewBots = map[string]bool{
+	"Facebook":   true,
+	"Twitterbot": true,
+	"Slackbot":   true,
+	"WhatsApp":   true,
+	"Bot":        true,
+}
+
+// uaBrowsers are ordered so that browsers built on others are matched first: Edge and Opera also
+// claim to be Chrome, and Chrome also claims to be Safari
+var uaBrowsers = []uaBrowser{
+	{"Edge", regexp.MustCompile(`(?:Edg|Edge|EdgA|EdgiOS)/([\d.]+)`)},
+	{"Opera", regexp.MustCompile(`(?:OPR|OPiOS)/([\d.]+)`)},
+	{"Samsung Internet", regexp.MustCompile(`SamsungBrowser/([\d.]+)`)},
+	{"Firefox", regexp.MustCompile(`(?:Firefox|FxiOS)/([\d.]+)`)},
+	{"Chrome", regexp.MustCompile(`(?:Chrome|CriOS)/([\d.]+)`)},
+	{"Safari", regexp.MustCompile(`Version/([\d.]+).*Safari/`)},
+	{"Internet Explorer", regexp.MustCompile(`(?:MSIE |Trident/.*rv:)([\d.]+)`)},
+}
+
+// uaOS matches operating systems, most specific first: iOS and Android user agents also mention
+// Mac OS X and Linux
+var uaOS = []struct {
+	name   string
+	tokens []string
+}{
+	{"iOS", []string{"iPhone", "iPad", "iPod"}},
+	{"Android", []string{"Android"}},
+	{"Windows", []string{"Windows"}},
+	{"ChromeOS", []string{"CrOS"}},
+	{"macOS", []string{"Macintosh", "Mac OS X"}},
+	{"Linux", []string{"Linux"}},
+}
+
+// IsLinkPreviewBot reports whether a User-Agent header belongs to a crawler fetching a link to render
+// a preview of it
+func IsLinkPreviewBot(ua string) bool {
+	parsed := ParseUserAgent(ua)
+	return parsed.Device == DeviceBot && uaPreviewBots[parsed.Browser]
+}
+
+// ParseUserAgent classifies a User-Agent header into browser, browser version, OS and device type.
+// Unrecognized browsers and operating systems are reported as "Other".
+func ParseUserAgent(ua string) UserAgent {
+	parsed := UserAgent{Browser: "Other", OS: "Other", Device: DeviceDesktop}
+
+	for _, osEntry := range uaOS {
+		if containsAny(ua, osEntry.tokens...) {
+			parsed.OS = osEntry.name
+			break
+		}
+	}
+
+	for _, bot := range uaBots {
+		if match := bot.token.FindStringSubmatch(ua); match != nil {
+			parsed.Browser, parsed.BrowserVersion, parsed.Device = bot.name, match[1], DeviceBot
+			return parsed
+		}
+	}
+	if uaGenericBot.MatchString(ua) {
+		parsed.Browser, parsed.Device = "Bot", DeviceBot
+		return parsed
+	}
+
+	for _, browser := range uaBrowsers {
+		if match := browser.token.FindStringSubmatch(ua); match != nil {
+			parsed.Browser, parsed.BrowserVersion = browser.name, match[1]
+			break
+		}
+	}
+
+	switch {
+	case containsAny(ua, "iPad", "Tablet") || (parsed.OS == "Android" && !strings.Contains(ua, "Mobile")):
+		parsed.Device = DeviceTablet
+	case containsAny(ua, "Mobile", "iPhone", "iPod"):
+		parsed.Device = DeviceMobile
+	}
+
+	return parsed
+}
+
+// containsAny reports whether s contains any of the substrings
+func containsAny(s string, substrings ...string) bool {
+	for _, substring := range substrings {
+		if strings.Contains(s, substring) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,117 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseUserAgent(t *testing.T) {
+	tests := []struct {
+		name string
+		ua   string
+		want UserAgent
+	}{
+		{
+			name: "Chrome on Windows",
+			ua:   "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+			want: UserAgent{Browser: "Chrome", BrowserVersion: "120.0.0.0", OS: "Windows", Device: DeviceDesktop},
+		},
+		{
+			name: "Chrome on Android phone",
+			ua:   "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.6099.144 Mobile Safari/537.36",
+			want: UserAgent{Browser: "Chrome", BrowserVersion: "120.0.6099.144", OS: "Android", Device: DeviceMobile},
+		},
+		{
+			name: "Chrome on Android tablet",
+			ua:   "Mozilla/5.0 (Linux; Android 13; SM-X700) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/119.0.0.0 Safari/537.36",
+			want: UserAgent{Browser: "Chrome", BrowserVersion: "119.0.0.0", OS: "Android", Device: DeviceTablet},
+		},
+		{
+			name: "Chrome on iPhone",
+			ua:   "Mozilla/5.0 (iPhone; CPU iPhone OS 17_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) CriOS/120.0.6099.101 Mobile/15E148 Safari/604.1",
+			want: UserAgent{Browser: "Chrome", BrowserVersion: "120.0.6099.101", OS: "iOS", Device: DeviceMobile},
+		},
+		{
+			name: "Safari on macOS",
+			ua:   "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1 Safari/605.1.15",
+			want: UserAgent{Browser: "Safari", BrowserVersion: "17.1", OS: "macOS", Device: DeviceDesktop},
+		},
+		{
+			name: "Safari on iPad",
+			ua:   "Mozilla/5.0 (iPad; CPU OS 17_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1 Mobile/15E148 Safari/604.1",
+			want: UserAgent{Browser: "Safari", BrowserVersion: "17.1", OS: "iOS", Device: DeviceTablet},
+		},
+		{
+			name: "Firefox on Linux",
+			ua:   "Mozilla/5.0 (X11; Ubuntu; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0",
+			want: UserAgent{Browser: "Firefox", BrowserVersion: "121.0", OS: "Linux", Device: DeviceDesktop},
+		},
+		{
+			name: "Edge on Windows",
+			ua:   "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0.2210.77",
+			want: UserAgent{Browser: "Edge", BrowserVersion: "120.0.2210.77", OS: "Windows", Device: DeviceDesktop},
+		},
+		{
+			name: "Googlebot",
+			ua:   "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+			want: UserAgent{Browser: "Googlebot", BrowserVersion: "2.1", OS: "Other", Device: DeviceBot},
+		},
+		{
+			name: "Googlebot smartphone",
+			ua:   "Mozilla/5.0 (Linux; Android 6.0.1; Nexus 5X Build/MMB29P) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.6099.216 Mobile Safari/537.36 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+			want: UserAgent{Browser: "Googlebot", BrowserVersion: "2.1", OS: "Android", Device: DeviceBot},
+		},
+		{
+			name: "Unknown crawler",
+			ua:   "Mozilla/5.0 (compatible; ExampleCrawler/1.0)",
+			want: UserAgent{Browser: "Bot", OS: "Other", Device: DeviceBot},
+		},
+		{
+			name: "curl",
+			ua:   "curl/8.4.0",
+			want: UserAgent{Browser: "curl", BrowserVersion: "8.4.0", OS: "Other", Device: DeviceBot},
+		},
+		{
+			name: "WhatsApp",
+			ua:   "WhatsApp/2.23.20.0 A",
+			want: UserAgent{Browser: "WhatsApp", BrowserVersion: "2.23.20.0", OS: "Other", Device: DeviceBot},
+		},
+		{
+			name: "Empty",
+			ua:   "",
+			want: UserAgent{Browser: "Other", OS: "Other", Device: DeviceDesktop},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ParseUserAgent(tt.ua))
+		})
+	}
+}
+
+func TestIsLinkPreviewBot(t *testing.T) {
+	tests := []struct {
+		name string
+		ua   string
+		want bool
+	}{
+		{"Facebook", "facebookexternalhit/1.1 (+http://www.facebook.com/externalhit_uatext.php)", true},
+		{"Twitterbot", "Twitterbot/1.0", true},
+		{"Slackbot", "Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)", true},
+		{"LinkedIn", "LinkedInBot/1.0 (compatible; Mozilla/5.0; Apache-HttpClient +http://www.linkedin.com)", true},
+		{"Discord", "Mozilla/5.0 (compatible; Discordbot/2.0; +https://discordapp.com)", true},
+		{"WhatsApp", "WhatsApp/2.23.20.0 A", true},
+		{"Googlebot", "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)", false},
+		{"curl", "curl/8.4.0", false},
+		{"Chrome", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36", false},
+		{"Empty", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsLinkPreviewBot(tt.ua))
+		})
+	}
+}